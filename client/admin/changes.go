@@ -0,0 +1,115 @@
+package admin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StreamChanges calls fn for every change streamed by the admin server until
+// ctx is done, the server closes the stream or fn returns an error. Changes
+// after the given sequence number that the server still retains are replayed
+// first, so passing the Seq of the last handled change resumes a stream.
+func (c *Client) StreamChanges(ctx context.Context, kinds []string, after int64, fn func(*Change) error) error {
+	q := url.Values{}
+	addArray(q, "kinds", kinds)
+	if after > 0 {
+		q.Set("after", strconv.FormatInt(after, 10))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseUrl+"/api/changes/stream?"+q.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "text/event-stream")
+	c.setHeaders(ctx, req)
+
+	// the stream outlives any client timeout, it is bounded by ctx instead
+	hc := *c.httpClient
+	hc.Timeout = 0
+
+	res, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		data, _ := io.ReadAll(res.Body)
+		e := &Error{StatusCode: res.StatusCode}
+		if err := json.Unmarshal(data, e); err != nil || len(e.Title) == 0 {
+			e.Title = http.StatusText(res.StatusCode)
+			e.Detail = string(data)
+		}
+
+		return e
+	}
+
+	data := ""
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "data:") {
+			data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			continue
+		}
+
+		if len(line) != 0 || len(data) == 0 {
+			continue
+		}
+
+		ch := &Change{}
+		if err := json.Unmarshal([]byte(data), ch); err != nil {
+			return err
+		}
+
+		data = ""
+		if err := fn(ch); err != nil {
+			return err
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return scanner.Err()
+}
+
+// GetChangesVersion returns the current version of the change feed. Sync
+// agents load the whole config after calling it and then poll from the version.
+func (c *Client) GetChangesVersion(ctx context.Context) (int64, error) {
+	b := &ChangeBatch{}
+	if err := c.do(ctx, http.MethodGet, "/api/config/changes", nil, nil, b); err != nil {
+		return 0, err
+	}
+
+	return b.Version, nil
+}
+
+// PollChanges waits up to timeout for changes after the since version. An
+// empty batch means the timeout passed, a batch with Reset set means the
+// config has to be reloaded.
+func (c *Client) PollChanges(ctx context.Context, since int64, timeout time.Duration, kinds []string) (*ChangeBatch, error) {
+	q := url.Values{}
+	q.Set("since", strconv.FormatInt(since, 10))
+	q.Set("timeout", timeout.String())
+	addArray(q, "kinds", kinds)
+
+	// leave the server time to answer before the client gives up
+	hc := *c.httpClient
+	if hc.Timeout != 0 && hc.Timeout < timeout+10*time.Second {
+		hc.Timeout = timeout + 10*time.Second
+	}
+
+	b := &ChangeBatch{}
+	return b, c.doWith(&hc, ctx, http.MethodGet, "/api/config/changes", q, nil, b)
+}
@@ -0,0 +1,168 @@
+// Package admin is a typed Go client for the BricksLLM admin API.
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type Error struct {
+	StatusCode int    `json:"-"`
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Status     int    `json:"status"`
+	Detail     string `json:"detail"`
+	Instance   string `json:"instance"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("admin api responded with %d: %s: %s", e.StatusCode, e.Title, e.Detail)
+}
+
+type Client struct {
+	baseUrl    string
+	apiKey     string
+	namespace  string
+	httpClient *http.Client
+}
+
+type Option func(*Client)
+
+// WithApiKey sets the admin password sent in the X-API-KEY header.
+func WithApiKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithNamespace scopes every request of the client to a namespace through the
+// X-Namespace header.
+func WithNamespace(namespace string) Option {
+	return func(c *Client) {
+		c.namespace = namespace
+	}
+}
+
+func WithHttpClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+func NewClient(baseUrl string, opts ...Option) *Client {
+	c := &Client{
+		baseUrl:    strings.TrimSuffix(baseUrl, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+type idempotencyKeyCtx struct{}
+
+// WithIdempotencyKey attaches an Idempotency-Key header to the request made with ctx.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtx{}, key)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	return c.doWith(c.httpClient, ctx, method, path, query, in, out)
+}
+
+func (c *Client) doWith(hc *http.Client, ctx context.Context, method, path string, query url.Values, in, out any) error {
+	u := c.baseUrl + path
+	if len(query) != 0 {
+		u += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	c.setHeaders(ctx, req)
+
+	res, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		e := &Error{StatusCode: res.StatusCode}
+		if err := json.Unmarshal(data, e); err != nil || len(e.Title) == 0 {
+			e.Title = http.StatusText(res.StatusCode)
+			e.Detail = string(data)
+		}
+
+		return e
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	// the admin server answers unauthenticated requests with an empty 200
+	if len(data) == 0 {
+		return errors.New("admin api returned an empty response, check the api key")
+	}
+
+	return json.Unmarshal(data, out)
+}
+
+func addArray(q url.Values, name string, values []string) {
+	for _, v := range values {
+		q.Add(name, v)
+	}
+}
+
+func addString(q url.Values, name, value string) {
+	if len(value) != 0 {
+		q.Set(name, value)
+	}
+}
+
+func (c *Client) setHeaders(ctx context.Context, req *http.Request) {
+	if len(c.apiKey) != 0 {
+		req.Header.Set("X-API-KEY", c.apiKey)
+	}
+
+	if len(c.namespace) != 0 {
+		req.Header.Set("X-Namespace", c.namespace)
+	}
+
+	if key, ok := ctx.Value(idempotencyKeyCtx{}).(string); ok && len(key) != 0 {
+		req.Header.Set("Idempotency-Key", key)
+	}
+}
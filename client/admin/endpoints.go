@@ -0,0 +1,693 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+func (c *Client) Health(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/api/health", nil, nil, nil)
+}
+
+// GetHealthReport returns the readiness report. A report whose status is down
+// is returned as an *Error with status 503.
+func (c *Client) GetHealthReport(ctx context.Context) (*HealthReport, error) {
+	r := &HealthReport{}
+	return r, c.do(ctx, http.MethodGet, "/api/health", nil, nil, r)
+}
+
+func (c *Client) Live(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/api/health/live", nil, nil, nil)
+}
+
+func (c *Client) GetOpenApiDocument(ctx context.Context) (json.RawMessage, error) {
+	doc := json.RawMessage{}
+	return doc, c.do(ctx, http.MethodGet, "/api/openapi.json", nil, nil, &doc)
+}
+
+// StartDrain puts the proxy into drain mode. A zero retryAfter keeps the
+// Retry-After configured on the server.
+func (c *Client) StartDrain(ctx context.Context, retryAfter time.Duration) (*DrainStatus, error) {
+	q := url.Values{}
+	if retryAfter > 0 {
+		q.Set("retryAfter", retryAfter.String())
+	}
+
+	status := &DrainStatus{}
+	return status, c.do(ctx, http.MethodPost, "/api/lifecycle/drain", q, nil, status)
+}
+
+func (c *Client) GetDrainStatus(ctx context.Context) (*DrainStatus, error) {
+	status := &DrainStatus{}
+	return status, c.do(ctx, http.MethodGet, "/api/lifecycle/drain", nil, nil, status)
+}
+
+func (c *Client) StopDrain(ctx context.Context) (*DrainStatus, error) {
+	status := &DrainStatus{}
+	return status, c.do(ctx, http.MethodDelete, "/api/lifecycle/drain", nil, nil, status)
+}
+
+func (c *Client) GetSummary(ctx context.Context) (*Summary, error) {
+	s := &Summary{}
+	return s, c.do(ctx, http.MethodGet, "/api/summary", nil, nil, s)
+}
+
+type GetKeysParams struct {
+	Tag      string
+	Tags     []string
+	KeyIds   []string
+	Provider string
+}
+
+func (c *Client) GetKeys(ctx context.Context, p *GetKeysParams) ([]*Key, error) {
+	q := url.Values{}
+	if p != nil {
+		addString(q, "tag", p.Tag)
+		addArray(q, "tags", p.Tags)
+		addArray(q, "keyIds", p.KeyIds)
+		addString(q, "provider", p.Provider)
+	}
+
+	keys := []*Key{}
+	return keys, c.do(ctx, http.MethodGet, "/api/key-management/keys", q, nil, &keys)
+}
+
+func (c *Client) GetKeysV2(ctx context.Context, r *KeyRequest) (*GetKeysResponse, error) {
+	res := &GetKeysResponse{}
+	return res, c.do(ctx, http.MethodPost, "/api/v2/key-management/keys", nil, r, res)
+}
+
+func (c *Client) CreateKey(ctx context.Context, r *CreateKeyRequest) (*Key, error) {
+	k := &Key{}
+	return k, c.do(ctx, http.MethodPut, "/api/key-management/keys", nil, r, k)
+}
+
+func (c *Client) UpdateKey(ctx context.Context, id string, r *UpdateKeyRequest) (*Key, error) {
+	k := &Key{}
+	return k, c.do(ctx, http.MethodPatch, "/api/key-management/keys/"+url.PathEscape(id), nil, r, k)
+}
+
+func (c *Client) DeleteKey(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/api/key-management/keys/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// PreviewDeleteKey sends the delete with dryRun=true so nothing is removed.
+func (c *Client) PreviewDeleteKey(ctx context.Context, id string) (*DryRunResult, error) {
+	return c.previewDelete(ctx, "/api/key-management/keys/"+url.PathEscape(id), false)
+}
+
+func (c *Client) CreateClaimLink(ctx context.Context, id string, r *ClaimLinkRequest) (*ClaimLink, error) {
+	if r == nil {
+		r = &ClaimLinkRequest{}
+	}
+
+	cl := &ClaimLink{}
+	return cl, c.do(ctx, http.MethodPost, "/api/key-management/keys/"+url.PathEscape(id)+"/claim-link", nil, r, cl)
+}
+
+// GetEffectivePolicy returns the policy applied to requests of a key, sent
+// through the route with routeId if it is not empty.
+func (c *Client) GetEffectivePolicy(ctx context.Context, id, routeId string) (*EffectivePolicy, error) {
+	q := url.Values{}
+	addString(q, "routeId", routeId)
+
+	ep := &EffectivePolicy{}
+	return ep, c.do(ctx, http.MethodGet, "/api/key-management/keys/"+url.PathEscape(id)+"/effective-policy", q, nil, ep)
+}
+
+func (c *Client) ClaimKey(ctx context.Context, token string) (*ClaimedKey, error) {
+	ck := &ClaimedKey{}
+	return ck, c.do(ctx, http.MethodGet, "/api/key-management/claims/"+url.PathEscape(token), nil, nil, ck)
+}
+
+func (c *Client) VerifyKeyFormat(ctx context.Context, secret string) (*FormatVerification, error) {
+	v := &FormatVerification{}
+	return v, c.do(ctx, http.MethodPost, "/api/key-management/keys/verify-format", nil, &VerifyFormatRequest{Key: secret}, v)
+}
+
+// BulkRevokeKeys previews the revocation of the keys matched by r, or revokes
+// them when r carries the confirmation token of a preview.
+func (c *Client) BulkRevokeKeys(ctx context.Context, r *BulkRevokeRequest) (*BulkRevokeResult, error) {
+	result := &BulkRevokeResult{}
+	return result, c.do(ctx, http.MethodPost, "/api/key-management/keys/revoke", nil, r, result)
+}
+
+func (c *Client) GetKeyReporting(ctx context.Context, id string) (*KeyReporting, error) {
+	kr := &KeyReporting{}
+	return kr, c.do(ctx, http.MethodGet, "/api/reporting/keys/"+url.PathEscape(id), nil, nil, kr)
+}
+
+func (c *Client) GetEventMetrics(ctx context.Context, r *ReportingRequest) (*ReportingResponse, error) {
+	res := &ReportingResponse{}
+	return res, c.do(ctx, http.MethodPost, "/api/reporting/events", nil, r, res)
+}
+
+func (c *Client) GetEventMetricsByDay(ctx context.Context, r *ReportingRequest) (*ReportingResponseV2, error) {
+	res := &ReportingResponseV2{}
+	return res, c.do(ctx, http.MethodPost, "/api/reporting/events-by-day", nil, r, res)
+}
+
+type GetEventsParams struct {
+	CustomId string
+	UserId   string
+	KeyIds   []string
+	Start    int64
+	End      int64
+}
+
+func (c *Client) GetEvents(ctx context.Context, p *GetEventsParams) ([]*Event, error) {
+	q := url.Values{}
+	if p != nil {
+		addString(q, "customId", p.CustomId)
+		addString(q, "userId", p.UserId)
+		addArray(q, "keyIds", p.KeyIds)
+		if p.Start != 0 {
+			q.Set("start", strconv.FormatInt(p.Start, 10))
+		}
+		if p.End != 0 {
+			q.Set("end", strconv.FormatInt(p.End, 10))
+		}
+	}
+
+	events := []*Event{}
+	return events, c.do(ctx, http.MethodGet, "/api/events", q, nil, &events)
+}
+
+func (c *Client) GetEventsV2(ctx context.Context, r *EventRequest) (*EventResponse, error) {
+	res := &EventResponse{}
+	return res, c.do(ctx, http.MethodPost, "/api/v2/events", nil, r, res)
+}
+
+func (c *Client) GetBatches(ctx context.Context, keyIds []string, status string) ([]*Batch, error) {
+	q := url.Values{}
+	addArray(q, "keyIds", keyIds)
+	addString(q, "status", status)
+
+	batches := []*Batch{}
+	return batches, c.do(ctx, http.MethodGet, "/api/batches", q, nil, &batches)
+}
+
+func (c *Client) GetFineTuningJobs(ctx context.Context, keyIds []string, status string) ([]*FineTuningJob, error) {
+	q := url.Values{}
+	addArray(q, "keyIds", keyIds)
+	addString(q, "status", status)
+
+	jobs := []*FineTuningJob{}
+	return jobs, c.do(ctx, http.MethodGet, "/api/fine-tuning-jobs", q, nil, &jobs)
+}
+
+func (c *Client) GetUserIds(ctx context.Context, keyId string) ([]string, error) {
+	q := url.Values{}
+	addString(q, "keyId", keyId)
+
+	ids := []string{}
+	return ids, c.do(ctx, http.MethodGet, "/api/reporting/user-ids", q, nil, &ids)
+}
+
+func (c *Client) GetCustomIds(ctx context.Context, keyId string) ([]string, error) {
+	q := url.Values{}
+	addString(q, "keyId", keyId)
+
+	ids := []string{}
+	return ids, c.do(ctx, http.MethodGet, "/api/reporting/custom-ids", q, nil, &ids)
+}
+
+func (c *Client) GetTopKeys(ctx context.Context, r *KeyReportingRequest) (*KeyReportingResponse, error) {
+	res := &KeyReportingResponse{}
+	return res, c.do(ctx, http.MethodPost, "/api/reporting/top-keys", nil, r, res)
+}
+
+func (c *Client) GetSigningReporting(ctx context.Context, r *SigningReportingRequest) (*SigningReportingResponse, error) {
+	res := &SigningReportingResponse{}
+	return res, c.do(ctx, http.MethodPost, "/api/reporting/signing", nil, r, res)
+}
+
+func (c *Client) GetPolicyViolationReporting(ctx context.Context, r *PolicyViolationReportingRequest) (*PolicyViolationReportingResponse, error) {
+	res := &PolicyViolationReportingResponse{}
+	return res, c.do(ctx, http.MethodPost, "/api/reporting/policy-violations", nil, r, res)
+}
+
+func (c *Client) GetPolicyVersionReporting(ctx context.Context, r *PolicyVersionReportingRequest) (*PolicyVersionReportingResponse, error) {
+	res := &PolicyVersionReportingResponse{}
+	return res, c.do(ctx, http.MethodPost, "/api/reporting/policy-versions", nil, r, res)
+}
+
+func (c *Client) CreateProviderSetting(ctx context.Context, s *ProviderSetting) (*ProviderSetting, error) {
+	created := &ProviderSetting{}
+	return created, c.do(ctx, http.MethodPut, "/api/provider-settings", nil, s, created)
+}
+
+func (c *Client) GetProviderSettings(ctx context.Context, ids []string) ([]*ProviderSetting, error) {
+	q := url.Values{}
+	addArray(q, "ids", ids)
+
+	settings := []*ProviderSetting{}
+	return settings, c.do(ctx, http.MethodGet, "/api/provider-settings", q, nil, &settings)
+}
+
+type ListProviderSettingsParams struct {
+	Ids         []string
+	Name        string
+	Environment string
+	Labels      map[string]string
+}
+
+// ListProviderSettings returns the provider settings matching p. Without ids,
+// settings are only listed when p filters them by name, environment or labels.
+func (c *Client) ListProviderSettings(ctx context.Context, p *ListProviderSettingsParams) ([]*ProviderSetting, error) {
+	q := url.Values{}
+	if p != nil {
+		addArray(q, "ids", p.Ids)
+		addString(q, "name", p.Name)
+		addString(q, "environment", p.Environment)
+		for k, v := range p.Labels {
+			q.Add("labels", k+"="+v)
+		}
+	}
+
+	settings := []*ProviderSetting{}
+	return settings, c.do(ctx, http.MethodGet, "/api/provider-settings", q, nil, &settings)
+}
+
+func (c *Client) UpdateProviderSetting(ctx context.Context, id string, r *UpdateProviderSettingRequest) (*ProviderSetting, error) {
+	updated := &ProviderSetting{}
+	return updated, c.do(ctx, http.MethodPatch, "/api/provider-settings/"+url.PathEscape(id), nil, r, updated)
+}
+
+func (c *Client) DeleteProviderSetting(ctx context.Context, id string, cascade bool) error {
+	return c.do(ctx, http.MethodDelete, "/api/provider-settings/"+url.PathEscape(id), cascadeQuery(cascade), nil, nil)
+}
+
+func (c *Client) PreviewDeleteProviderSetting(ctx context.Context, id string, cascade bool) (*DryRunResult, error) {
+	return c.previewDelete(ctx, "/api/provider-settings/"+url.PathEscape(id), cascade)
+}
+
+func (c *Client) GetDeployments(ctx context.Context, settingId string) ([]*Deployment, error) {
+	deployments := []*Deployment{}
+	return deployments, c.do(ctx, http.MethodGet, "/api/provider-settings/"+url.PathEscape(settingId)+"/deployments", nil, nil, &deployments)
+}
+
+// PutDeployment maps d.Model to an azure deployment of the setting and returns
+// the resulting mapping.
+func (c *Client) PutDeployment(ctx context.Context, settingId string, d *Deployment) ([]*Deployment, error) {
+	deployments := []*Deployment{}
+	return deployments, c.do(ctx, http.MethodPut, "/api/provider-settings/"+url.PathEscape(settingId)+"/deployments/"+url.PathEscape(d.Model), nil, d, &deployments)
+}
+
+func (c *Client) DeleteDeployment(ctx context.Context, settingId, model string) ([]*Deployment, error) {
+	deployments := []*Deployment{}
+	return deployments, c.do(ctx, http.MethodDelete, "/api/provider-settings/"+url.PathEscape(settingId)+"/deployments/"+url.PathEscape(model), nil, nil, &deployments)
+}
+
+// GetProviderSettingHealth returns the error rate, latency and status of the
+// upstream of a provider setting over the health monitoring window.
+func (c *Client) GetProviderSettingHealth(ctx context.Context, id string) (*ProviderSettingHealth, error) {
+	h := &ProviderSettingHealth{}
+	return h, c.do(ctx, http.MethodGet, "/api/provider-settings/"+url.PathEscape(id)+"/health", nil, nil, h)
+}
+
+// TestProviderSetting makes a cheap call to the upstream of a provider setting
+// to check that it is reachable and accepts the credentials of the setting.
+func (c *Client) TestProviderSetting(ctx context.Context, id string) (*ConnectionTest, error) {
+	t := &ConnectionTest{}
+	return t, c.do(ctx, http.MethodPost, "/api/provider-settings/"+url.PathEscape(id)+"/test", nil, nil, t)
+}
+
+// GetProviderSettingVersions returns the version history of a provider
+// setting, newest first. Secrets of the versions are left out.
+func (c *Client) GetProviderSettingVersions(ctx context.Context, id string) ([]*ProviderSettingVersion, error) {
+	versions := []*ProviderSettingVersion{}
+	return versions, c.do(ctx, http.MethodGet, "/api/provider-settings/"+url.PathEscape(id)+"/versions", nil, nil, &versions)
+}
+
+// RollbackProviderSetting restores a provider setting, secrets included, to
+// one of its versions.
+func (c *Client) RollbackProviderSetting(ctx context.Context, id string, version int) (*ProviderSetting, error) {
+	restored := &ProviderSetting{}
+	return restored, c.do(ctx, http.MethodPost, "/api/provider-settings/"+url.PathEscape(id)+"/rollback/"+strconv.Itoa(version), nil, nil, restored)
+}
+
+func (c *Client) CreateCustomProvider(ctx context.Context, p *CustomProvider) (*CustomProvider, error) {
+	created := &CustomProvider{}
+	return created, c.do(ctx, http.MethodPost, "/api/custom/providers", nil, p, created)
+}
+
+func (c *Client) GetCustomProviders(ctx context.Context) ([]*CustomProvider, error) {
+	providers := []*CustomProvider{}
+	return providers, c.do(ctx, http.MethodGet, "/api/custom/providers", nil, nil, &providers)
+}
+
+func (c *Client) UpdateCustomProvider(ctx context.Context, id string, r *UpdateCustomProviderRequest) (*CustomProvider, error) {
+	updated := &CustomProvider{}
+	return updated, c.do(ctx, http.MethodPatch, "/api/custom/providers/"+url.PathEscape(id), nil, r, updated)
+}
+
+func (c *Client) DeleteCustomProvider(ctx context.Context, id string, cascade bool) error {
+	return c.do(ctx, http.MethodDelete, "/api/custom/providers/"+url.PathEscape(id), cascadeQuery(cascade), nil, nil)
+}
+
+func (c *Client) PreviewDeleteCustomProvider(ctx context.Context, id string, cascade bool) (*DryRunResult, error) {
+	return c.previewDelete(ctx, "/api/custom/providers/"+url.PathEscape(id), cascade)
+}
+
+func (c *Client) CreateRoute(ctx context.Context, r *Route) (*Route, error) {
+	created := &Route{}
+	return created, c.do(ctx, http.MethodPost, "/api/routes", nil, r, created)
+}
+
+func (c *Client) GetRoute(ctx context.Context, id string) (*Route, error) {
+	r := &Route{}
+	return r, c.do(ctx, http.MethodGet, "/api/routes/"+url.PathEscape(id), nil, nil, r)
+}
+
+// GetRouteStatus returns the circuit breaker state of the steps of a route as
+// seen by the gateway that serves the request.
+func (c *Client) GetRouteStatus(ctx context.Context, id string) (*RouteStatus, error) {
+	rs := &RouteStatus{}
+	return rs, c.do(ctx, http.MethodGet, "/api/routes/"+url.PathEscape(id)+"/status", nil, nil, rs)
+}
+
+func (c *Client) GetRoutes(ctx context.Context) ([]*Route, error) {
+	routes := []*Route{}
+	return routes, c.do(ctx, http.MethodGet, "/api/routes", nil, nil, &routes)
+}
+
+func (c *Client) DeleteRoute(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/api/routes/"+url.PathEscape(id), nil, nil, nil)
+}
+
+func (c *Client) PreviewDeleteRoute(ctx context.Context, id string) (*DryRunResult, error) {
+	return c.previewDelete(ctx, "/api/routes/"+url.PathEscape(id), false)
+}
+
+// UpdateRoute changes a route in place. If r names a version, the update is
+// rejected with a conflict when the route is no longer at it.
+func (c *Client) UpdateRoute(ctx context.Context, id string, r *UpdateRouteRequest) (*Route, error) {
+	updated := &Route{}
+	return updated, c.do(ctx, http.MethodPatch, "/api/routes/"+url.PathEscape(id), nil, r, updated)
+}
+
+// GetRouteVersions returns the version history of a route, newest first.
+func (c *Client) GetRouteVersions(ctx context.Context, id string) ([]*RouteVersion, error) {
+	versions := []*RouteVersion{}
+	return versions, c.do(ctx, http.MethodGet, "/api/routes/"+url.PathEscape(id)+"/versions", nil, nil, &versions)
+}
+
+// RollbackRoute restores the config a route had at one of its versions.
+func (c *Client) RollbackRoute(ctx context.Context, id string, version int) (*Route, error) {
+	restored := &Route{}
+	return restored, c.do(ctx, http.MethodPost, "/api/routes/"+url.PathEscape(id)+"/rollback/"+strconv.Itoa(version), nil, nil, restored)
+}
+
+// TestRoute shows the target a request would be sent to by a route, without
+// calling its upstreams.
+func (c *Client) TestRoute(ctx context.Context, id string, r *TestRouteRequest) (*TestRouteResult, error) {
+	result := &TestRouteResult{}
+	return result, c.do(ctx, http.MethodPost, "/api/routes/"+url.PathEscape(id)+"/test", nil, r, result)
+}
+
+func (c *Client) CreatePolicy(ctx context.Context, p *Policy) (*Policy, error) {
+	created := &Policy{}
+	return created, c.do(ctx, http.MethodPost, "/api/policies", nil, p, created)
+}
+
+func (c *Client) UpdatePolicy(ctx context.Context, id string, r *UpdatePolicyRequest) (*Policy, error) {
+	updated := &Policy{}
+	return updated, c.do(ctx, http.MethodPatch, "/api/policies/"+url.PathEscape(id), nil, r, updated)
+}
+
+func (c *Client) GetPolicies(ctx context.Context, tags []string) ([]*Policy, error) {
+	q := url.Values{}
+	addArray(q, "tags", tags)
+
+	policies := []*Policy{}
+	return policies, c.do(ctx, http.MethodGet, "/api/policies", q, nil, &policies)
+}
+
+func (c *Client) DeletePolicy(ctx context.Context, id string, cascade bool) error {
+	return c.do(ctx, http.MethodDelete, "/api/policies/"+url.PathEscape(id), cascadeQuery(cascade), nil, nil)
+}
+
+func (c *Client) PreviewDeletePolicy(ctx context.Context, id string, cascade bool) (*DryRunResult, error) {
+	return c.previewDelete(ctx, "/api/policies/"+url.PathEscape(id), cascade)
+}
+
+// EvaluatePolicy reports what a policy would do to a sample request without
+// sending it anywhere.
+func (c *Client) EvaluatePolicy(ctx context.Context, id string, r *EvaluatePolicyRequest) (*PolicyEvaluation, error) {
+	ev := &PolicyEvaluation{}
+	return ev, c.do(ctx, http.MethodPost, "/api/policies/"+url.PathEscape(id)+"/evaluate", nil, r, ev)
+}
+
+// GetPolicyVersions returns the version history of a policy, newest first.
+func (c *Client) GetPolicyVersions(ctx context.Context, id string) ([]*PolicyVersion, error) {
+	versions := []*PolicyVersion{}
+	return versions, c.do(ctx, http.MethodGet, "/api/policies/"+url.PathEscape(id)+"/versions", nil, nil, &versions)
+}
+
+// RollbackPolicy restores the config a policy had at one of its versions.
+func (c *Client) RollbackPolicy(ctx context.Context, id string, version int) (*Policy, error) {
+	restored := &Policy{}
+	return restored, c.do(ctx, http.MethodPost, "/api/policies/"+url.PathEscape(id)+"/rollback/"+strconv.Itoa(version), nil, nil, restored)
+}
+
+// SetPolicyRollout rolls the current version of a policy out to a percentage
+// of requests. A percentage of 100 promotes it to every request.
+func (c *Client) SetPolicyRollout(ctx context.Context, id string, r *UpdatePolicyRolloutRequest) (*Policy, error) {
+	updated := &Policy{}
+	return updated, c.do(ctx, http.MethodPut, "/api/policies/"+url.PathEscape(id)+"/rollout", nil, r, updated)
+}
+
+func (c *Client) CreateUser(ctx context.Context, u *User) (*User, error) {
+	created := &User{}
+	return created, c.do(ctx, http.MethodPost, "/api/users", nil, u, created)
+}
+
+func (c *Client) UpdateUser(ctx context.Context, id string, r *UpdateUserRequest) (*User, error) {
+	updated := &User{}
+	return updated, c.do(ctx, http.MethodPatch, "/api/users/"+url.PathEscape(id), nil, r, updated)
+}
+
+func (c *Client) UpdateUserViaTagsAndUserId(ctx context.Context, tags []string, userId string, r *UpdateUserRequest) (*User, error) {
+	q := url.Values{}
+	addArray(q, "tags", tags)
+	addString(q, "userId", userId)
+
+	updated := &User{}
+	return updated, c.do(ctx, http.MethodPatch, "/api/users", q, r, updated)
+}
+
+func (c *Client) PreviewUpdateUserViaTagsAndUserId(ctx context.Context, tags []string, userId string, r *UpdateUserRequest) (*DryRunResult, error) {
+	q := url.Values{}
+	addArray(q, "tags", tags)
+	addString(q, "userId", userId)
+	q.Set("dryRun", "true")
+
+	res := &DryRunResult{}
+	return res, c.do(ctx, http.MethodPatch, "/api/users", q, r, res)
+}
+
+type GetUsersParams struct {
+	Tags    []string
+	KeyIds  []string
+	UserIds []string
+	Offset  int
+	Limit   int
+}
+
+func (c *Client) GetUsers(ctx context.Context, p *GetUsersParams) ([]*User, error) {
+	q := url.Values{}
+	if p != nil {
+		addArray(q, "tags", p.Tags)
+		addArray(q, "keyIds", p.KeyIds)
+		addArray(q, "userIds", p.UserIds)
+		if p.Offset != 0 {
+			q.Set("offset", strconv.Itoa(p.Offset))
+		}
+		if p.Limit != 0 {
+			q.Set("limit", strconv.Itoa(p.Limit))
+		}
+	}
+
+	users := []*User{}
+	return users, c.do(ctx, http.MethodGet, "/api/users", q, nil, &users)
+}
+
+func (c *Client) Onboard(ctx context.Context, r *OnboardRequest) (*OnboardResponse, error) {
+	res := &OnboardResponse{}
+	return res, c.do(ctx, http.MethodPost, "/api/onboard", nil, r, res)
+}
+
+func (c *Client) Compare(ctx context.Context, r *CompareRequest) (*CompareResponse, error) {
+	res := &CompareResponse{}
+	return res, c.do(ctx, http.MethodPost, "/api/compare", nil, r, res)
+}
+
+func (c *Client) ApplyConfig(ctx context.Context, doc *ConfigDocument, dryRun, prune bool) (*ApplyConfigResult, error) {
+	q := url.Values{}
+	if dryRun {
+		q.Set("dryRun", "true")
+	}
+	if prune {
+		q.Set("prune", "true")
+	}
+
+	res := &ApplyConfigResult{}
+	return res, c.do(ctx, http.MethodPost, "/api/config/apply", q, doc, res)
+}
+
+func (c *Client) ExportConfig(ctx context.Context) (*ConfigArchive, error) {
+	archive := &ConfigArchive{}
+	return archive, c.do(ctx, http.MethodGet, "/api/config/export", nil, nil, archive)
+}
+
+// ImportConfig applies an archive from ExportConfig. Strategy is one of skip,
+// overwrite or fail, and defaults to fail when empty.
+func (c *Client) ImportConfig(ctx context.Context, archive *ConfigArchive, strategy string, dryRun bool) (*ApplyConfigResult, error) {
+	q := url.Values{}
+	if len(strategy) != 0 {
+		q.Set("strategy", strategy)
+	}
+	if dryRun {
+		q.Set("dryRun", "true")
+	}
+
+	res := &ApplyConfigResult{}
+	return res, c.do(ctx, http.MethodPost, "/api/config/import", q, archive, res)
+}
+
+func (c *Client) CreateWebhook(ctx context.Context, w *Webhook) (*Webhook, error) {
+	created := &Webhook{}
+	return created, c.do(ctx, http.MethodPost, "/api/webhooks", nil, w, created)
+}
+
+func (c *Client) GetWebhooks(ctx context.Context, org string) ([]*Webhook, error) {
+	q := url.Values{}
+	addString(q, "org", org)
+
+	hooks := []*Webhook{}
+	return hooks, c.do(ctx, http.MethodGet, "/api/webhooks", q, nil, &hooks)
+}
+
+func (c *Client) GetModels(ctx context.Context, provider string, missing bool) ([]*CatalogModel, error) {
+	q := url.Values{}
+	addString(q, "provider", provider)
+	if missing {
+		q.Set("missing", "true")
+	}
+
+	models := []*CatalogModel{}
+	return models, c.do(ctx, http.MethodGet, "/api/models", q, nil, &models)
+}
+
+func (c *Client) UpdateModel(ctx context.Context, id string, u *UpdateModelPricing) (*ModelPricing, error) {
+	updated := &ModelPricing{}
+	return updated, c.do(ctx, http.MethodPatch, "/api/models/"+url.PathEscape(id), nil, u, updated)
+}
+
+func (c *Client) GetPricing(ctx context.Context, provider string) ([]*ModelPricing, error) {
+	q := url.Values{}
+	addString(q, "provider", provider)
+
+	pricing := []*ModelPricing{}
+	return pricing, c.do(ctx, http.MethodGet, "/api/pricing", q, nil, &pricing)
+}
+
+func (c *Client) PutPricing(ctx context.Context, ps []*ModelPricing) ([]*ModelPricing, error) {
+	stored := []*ModelPricing{}
+	return stored, c.do(ctx, http.MethodPut, "/api/pricing", nil, ps, &stored)
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/api/webhooks/"+url.PathEscape(id), nil, nil, nil)
+}
+
+func (c *Client) PreviewDeleteWebhook(ctx context.Context, id string) (*DryRunResult, error) {
+	return c.previewDelete(ctx, "/api/webhooks/"+url.PathEscape(id), false)
+}
+
+func (c *Client) CreateTool(ctx context.Context, t *Tool) (*Tool, error) {
+	created := &Tool{}
+	return created, c.do(ctx, http.MethodPost, "/api/tools", nil, t, created)
+}
+
+func (c *Client) GetTools(ctx context.Context) ([]*Tool, error) {
+	tools := []*Tool{}
+	return tools, c.do(ctx, http.MethodGet, "/api/tools", nil, nil, &tools)
+}
+
+func (c *Client) DeleteTool(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/api/tools/"+url.PathEscape(id), nil, nil, nil)
+}
+
+func (c *Client) PreviewDeleteTool(ctx context.Context, id string) (*DryRunResult, error) {
+	return c.previewDelete(ctx, "/api/tools/"+url.PathEscape(id), false)
+}
+
+// GetToolExecutions returns the audit trail of a tool, most recent first. A
+// zero limit uses the server default.
+func (c *Client) GetToolExecutions(ctx context.Context, id string, limit int) ([]*ToolExecution, error) {
+	q := url.Values{}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+
+	executions := []*ToolExecution{}
+	return executions, c.do(ctx, http.MethodGet, "/api/tools/"+url.PathEscape(id)+"/executions", q, nil, &executions)
+}
+
+func (c *Client) CreateModelAlias(ctx context.Context, a *ModelAlias) (*ModelAlias, error) {
+	created := &ModelAlias{}
+	return created, c.do(ctx, http.MethodPost, "/api/model-aliases", nil, a, created)
+}
+
+func (c *Client) GetModelAliases(ctx context.Context) ([]*ModelAlias, error) {
+	aliases := []*ModelAlias{}
+	return aliases, c.do(ctx, http.MethodGet, "/api/model-aliases", nil, nil, &aliases)
+}
+
+// UpdateModelAlias points an alias at another model, which moves every client
+// using it at once.
+func (c *Client) UpdateModelAlias(ctx context.Context, id string, u *UpdateModelAlias) (*ModelAlias, error) {
+	updated := &ModelAlias{}
+	return updated, c.do(ctx, http.MethodPatch, "/api/model-aliases/"+url.PathEscape(id), nil, u, updated)
+}
+
+func (c *Client) DeleteModelAlias(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/api/model-aliases/"+url.PathEscape(id), nil, nil, nil)
+}
+
+func (c *Client) PreviewDeleteModelAlias(ctx context.Context, id string) (*DryRunResult, error) {
+	return c.previewDelete(ctx, "/api/model-aliases/"+url.PathEscape(id), false)
+}
+
+// DeleteUser deletes a user. Unless cascade is set, users whose keys still
+// exist are not deleted and a conflict is returned.
+func (c *Client) DeleteUser(ctx context.Context, id string, cascade bool) error {
+	return c.do(ctx, http.MethodDelete, "/api/users/"+url.PathEscape(id), cascadeQuery(cascade), nil, nil)
+}
+
+func (c *Client) PreviewDeleteUser(ctx context.Context, id string, cascade bool) (*DryRunResult, error) {
+	return c.previewDelete(ctx, "/api/users/"+url.PathEscape(id), cascade)
+}
+
+func (c *Client) previewDelete(ctx context.Context, path string, cascade bool) (*DryRunResult, error) {
+	q := cascadeQuery(cascade)
+	q.Set("dryRun", "true")
+
+	res := &DryRunResult{}
+	return res, c.do(ctx, http.MethodDelete, path, q, nil, res)
+}
+
+func cascadeQuery(cascade bool) url.Values {
+	q := url.Values{}
+	if cascade {
+		q.Set("cascade", "true")
+	}
+
+	return q
+}
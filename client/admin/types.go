@@ -0,0 +1,123 @@
+package admin
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/alias"
+	"github.com/bricks-cloud/bricksllm/internal/batch"
+	"github.com/bricks-cloud/bricksllm/internal/catalog"
+	"github.com/bricks-cloud/bricksllm/internal/change"
+	"github.com/bricks-cloud/bricksllm/internal/drain"
+	"github.com/bricks-cloud/bricksllm/internal/dryrun"
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/finetune"
+	"github.com/bricks-cloud/bricksllm/internal/gitops"
+	"github.com/bricks-cloud/bricksllm/internal/health"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/tool"
+	"github.com/bricks-cloud/bricksllm/internal/user"
+	"github.com/bricks-cloud/bricksllm/internal/webhook"
+)
+
+// Aliases let integrators name the admin API payloads without importing internal packages.
+type (
+	HealthReport        = health.Report
+	HealthDependency    = health.Dependency
+	ConnectionTest      = catalog.ConnectionTest
+	Key                 = key.ResponseKey
+	CreateKeyRequest    = key.RequestKey
+	UpdateKeyRequest    = key.UpdateKey
+	KeyRequest          = key.KeyRequest
+	GetKeysResponse     = key.GetKeysResponse
+	KeyReporting        = key.KeyReporting
+	ClaimLinkRequest    = key.ClaimLinkRequest
+	ClaimLink           = key.ClaimLink
+	ClaimedKey          = key.ClaimedKey
+	VerifyFormatRequest = key.VerifyFormatRequest
+	FormatVerification  = key.FormatVerification
+	BulkRevokeRequest   = key.BulkRevokeRequest
+	BulkRevokeResult    = key.BulkRevokeResult
+
+	Event                = event.Event
+	Timings              = event.Timings
+	EventRequest         = event.EventRequest
+	EventResponse        = event.EventResponse
+	ReportingRequest     = event.ReportingRequest
+	ReportingResponse    = event.ReportingResponse
+	ReportingResponseV2  = event.ReportingResponseV2
+	KeyReportingRequest  = event.KeyReportingRequest
+	KeyReportingResponse = event.KeyReportingResponse
+	Summary              = event.Summary
+
+	SigningReportingRequest          = event.SigningReportingRequest
+	SigningReportingResponse         = event.SigningReportingResponse
+	PolicyViolationReportingRequest  = event.PolicyViolationReportingRequest
+	PolicyViolationReportingResponse = event.PolicyViolationReportingResponse
+	PolicyVersionReportingRequest    = event.PolicyVersionReportingRequest
+	PolicyVersionReportingResponse   = event.PolicyVersionReportingResponse
+
+	ProviderSetting              = provider.Setting
+	UpdateProviderSettingRequest = provider.UpdateSetting
+	ProviderSettingVersion       = provider.SettingVersion
+	Deployment                   = provider.Deployment
+	KeyPool                      = provider.KeyPool
+	PooledKey                    = provider.PooledKey
+
+	CustomProvider              = custom.Provider
+	UpdateCustomProviderRequest = custom.UpdateProvider
+
+	Route              = route.Route
+	UpdateRouteRequest = route.UpdateRoute
+	RouteVersion       = route.RouteVersion
+	CompareRequest     = route.CompareRequest
+	CompareTarget      = route.CompareTarget
+	CompareResponse    = route.CompareResponse
+	RouteStatus        = route.RouteStatus
+	TestRouteRequest   = route.TestRequest
+	TestRouteResult    = route.TestResult
+
+	Policy                     = policy.Policy
+	UpdatePolicyRequest        = policy.UpdatePolicy
+	EvaluatePolicyRequest      = policy.EvaluateRequest
+	PolicyEvaluation           = policy.Evaluation
+	PolicyVersion              = policy.PolicyVersion
+	PolicyRollout              = policy.Rollout
+	UpdatePolicyRolloutRequest = policy.UpdateRollout
+	EffectivePolicy            = policy.EffectivePolicy
+
+	User              = user.User
+	UpdateUserRequest = user.UpdateUser
+	OnboardRequest    = user.OnboardRequest
+	OnboardResponse   = user.OnboardResponse
+
+	ConfigDocument    = gitops.Document
+	ApplyConfigResult = gitops.ApplyResult
+	ConfigArchive     = gitops.Archive
+
+	DryRunResult = dryrun.Result
+	Dependent    = dryrun.Dependent
+
+	CatalogModel          = catalog.Model
+	ModelPricing          = catalog.Pricing
+	UpdateModelPricing    = catalog.UpdatePricing
+	ProviderSettingHealth = catalog.SettingHealth
+
+	Change      = change.Change
+	ChangeBatch = change.Batch
+
+	DrainStatus = drain.Status
+
+	Batch         = batch.Batch
+	FineTuningJob = finetune.Job
+
+	Webhook           = webhook.Webhook
+	WebhookUsageEvent = webhook.UsageEvent
+
+	Tool          = tool.Tool
+	ToolExecution = tool.Execution
+
+	ModelAlias       = alias.Alias
+	UpdateModelAlias = alias.UpdateAlias
+)
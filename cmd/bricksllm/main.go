@@ -9,30 +9,49 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/alias"
 	auth "github.com/bricks-cloud/bricksllm/internal/authenticator"
+	"github.com/bricks-cloud/bricksllm/internal/batch"
 	"github.com/bricks-cloud/bricksllm/internal/cache"
+	"github.com/bricks-cloud/bricksllm/internal/catalog"
+	"github.com/bricks-cloud/bricksllm/internal/change"
 	"github.com/bricks-cloud/bricksllm/internal/config"
+	"github.com/bricks-cloud/bricksllm/internal/currency"
+	"github.com/bricks-cloud/bricksllm/internal/drain"
 	"github.com/bricks-cloud/bricksllm/internal/encryptor"
+	"github.com/bricks-cloud/bricksllm/internal/finetune"
+	"github.com/bricks-cloud/bricksllm/internal/health"
+	"github.com/bricks-cloud/bricksllm/internal/key"
 	"github.com/bricks-cloud/bricksllm/internal/logger/zap"
 	"github.com/bricks-cloud/bricksllm/internal/manager"
 	"github.com/bricks-cloud/bricksllm/internal/message"
 	"github.com/bricks-cloud/bricksllm/internal/pii"
 	"github.com/bricks-cloud/bricksllm/internal/pii/amazon"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
 	custompolicy "github.com/bricks-cloud/bricksllm/internal/policy/custom"
+	"github.com/bricks-cloud/bricksllm/internal/policy/moderation"
 	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
 	"github.com/bricks-cloud/bricksllm/internal/provider/azure"
+	"github.com/bricks-cloud/bricksllm/internal/provider/bedrock"
+	"github.com/bricks-cloud/bricksllm/internal/provider/cohere"
 	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
 	"github.com/bricks-cloud/bricksllm/internal/provider/deepinfra"
+	"github.com/bricks-cloud/bricksllm/internal/provider/groq"
+	"github.com/bricks-cloud/bricksllm/internal/provider/mistral"
 	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
+	"github.com/bricks-cloud/bricksllm/internal/provider/selfhosted"
 	"github.com/bricks-cloud/bricksllm/internal/provider/vllm"
 	"github.com/bricks-cloud/bricksllm/internal/recorder"
+	"github.com/bricks-cloud/bricksllm/internal/route"
 	"github.com/bricks-cloud/bricksllm/internal/server/web/admin"
 	"github.com/bricks-cloud/bricksllm/internal/server/web/proxy"
 	"github.com/bricks-cloud/bricksllm/internal/storage/memdb"
 	"github.com/bricks-cloud/bricksllm/internal/storage/postgresql"
 	redisStorage "github.com/bricks-cloud/bricksllm/internal/storage/redis"
 	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/tool"
 	"github.com/bricks-cloud/bricksllm/internal/validator"
+	"github.com/bricks-cloud/bricksllm/internal/webhook"
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 )
@@ -40,6 +59,7 @@ import (
 func main() {
 	modePtr := flag.String("m", "dev", "select the mode that bricksllm runs in")
 	privacyPtr := flag.String("p", "strict", "select the privacy mode that bricksllm runs in")
+	secretsPtr := flag.String("secrets", "", "migrate provider setting secrets to kms envelope encryption and exit: encrypt plaintext secrets (migrate), or also rewrap encrypted ones with the current kms key (rewrap)")
 
 	flag.Parse()
 
@@ -127,6 +147,11 @@ func main() {
 		log.Sugar().Fatalf("error creating policies table: %v", err)
 	}
 
+	err = store.AlterPolicyTable()
+	if err != nil {
+		log.Sugar().Fatalf("error altering policies table: %v", err)
+	}
+
 	err = store.CreateEventsByDayTable()
 	if err != nil {
 		log.Sugar().Fatalf("error creating event aggregated by day table: %v", err)
@@ -162,6 +187,86 @@ func main() {
 		log.Sugar().Fatalf("error creating user id for users table: %v", err)
 	}
 
+	err = store.CreateWebhooksTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating webhooks table: %v", err)
+	}
+
+	err = store.CreateModelAliasesTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating model aliases table: %v", err)
+	}
+
+	err = store.CreateToolsTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating tools table: %v", err)
+	}
+
+	err = store.CreateToolExecutionsTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating tool executions table: %v", err)
+	}
+
+	err = store.CreateToolIdIndexForToolExecutions()
+	if err != nil {
+		log.Sugar().Fatalf("error creating tool id index for tool executions table: %v", err)
+	}
+
+	err = store.CreateModelsTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating models table: %v", err)
+	}
+
+	err = store.CreateModelPricingTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating model pricing table: %v", err)
+	}
+
+	err = store.CreateBatchesTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating batches table: %v", err)
+	}
+
+	err = store.CreateKeyIdIndexForBatches()
+	if err != nil {
+		log.Sugar().Fatalf("error creating key id index for batches table: %v", err)
+	}
+
+	err = store.CreateFineTuningJobsTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating fine-tuning jobs table: %v", err)
+	}
+
+	err = store.CreateKeyIdIndexForFineTuningJobs()
+	if err != nil {
+		log.Sugar().Fatalf("error creating key id index for fine-tuning jobs table: %v", err)
+	}
+
+	err = store.CreateProviderSettingVersionsTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating provider setting versions table: %v", err)
+	}
+
+	err = store.CreateRouteVersionsTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating route versions table: %v", err)
+	}
+
+	err = store.CreatePolicyVersionsTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating policy versions table: %v", err)
+	}
+
+	err = store.CreatePiiTokensTable()
+	if err != nil {
+		log.Sugar().Fatalf("error creating pii tokens table: %v", err)
+	}
+
+	err = store.SeedModelPricing(catalog.DefaultPricing(), time.Now().Unix())
+	if err != nil {
+		log.Sugar().Fatalf("error seeding model pricing table: %v", err)
+	}
+
 	cpMemStore, err := memdb.NewCustomProvidersMemDb(store, log, cfg.InMemoryDbUpdateInterval)
 	if err != nil {
 		log.Sugar().Fatalf("cannot initialize custom providers memdb: %v", err)
@@ -174,6 +279,42 @@ func main() {
 	}
 	rMemStore.Listen()
 
+	pMemStore, err := memdb.NewPricingMemDb(store, log, cfg.InMemoryDbUpdateInterval)
+	if err != nil {
+		log.Sugar().Fatalf("cannot initialize pricing memdb: %v", err)
+	}
+
+	pMemStore.Listen()
+
+	dispatcher, err := webhook.NewDispatcher(store, log, cfg.InMemoryDbUpdateInterval)
+	if err != nil {
+		log.Sugar().Fatalf("cannot initialize webhook dispatcher: %v", err)
+	}
+	dispatcher.Listen()
+
+	broker, err := tool.NewBroker(store, log, cfg.InMemoryDbUpdateInterval, cfg.ToolBrokerTimeout, cfg.ToolBrokerMaxRounds)
+	if err != nil {
+		log.Sugar().Fatalf("cannot initialize tool broker: %v", err)
+	}
+	broker.Listen()
+
+	resolver, err := alias.NewResolver(store, log, cfg.InMemoryDbUpdateInterval)
+	if err != nil {
+		log.Sugar().Fatalf("cannot initialize model alias resolver: %v", err)
+	}
+	resolver.Listen()
+
+	fxRates, err := currency.ParseRates(cfg.FxRates)
+	if err != nil {
+		log.Sugar().Fatalf("error parsing fx rates: %v", err)
+	}
+
+	fx := currency.NewConverter(fxRates, cfg.FxRatesUrl, cfg.FxRatesRefreshInterval, log)
+	if !fx.Supported(cfg.ReportingCurrency) {
+		log.Sugar().Infof("no fx rate is available for reporting currency %s, reports fall back to USD until one is", cfg.ReportingCurrency)
+	}
+	fx.Listen()
+
 	defaultRedisOption := func(cfg *config.Config, dbIndex int) *redis.Options {
 
 		options := &redis.Options{
@@ -272,6 +413,47 @@ func main() {
 		log.Sugar().Fatalf("error connecting to keys redis storage: %v", err)
 	}
 
+	claimLinksRedisCache := redis.NewClient(defaultRedisOption(cfg, 11))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := claimLinksRedisCache.Ping(ctx).Err(); err != nil {
+		log.Sugar().Fatalf("error connecting to claim links redis cache: %v", err)
+	}
+
+	idempotencyRedisCache := redis.NewClient(defaultRedisOption(cfg, 12))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := idempotencyRedisCache.Ping(ctx).Err(); err != nil {
+		log.Sugar().Fatalf("error connecting to idempotency redis cache: %v", err)
+	}
+
+	settingSpendRedisCache := redis.NewClient(defaultRedisOption(cfg, 13))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := settingSpendRedisCache.Ping(ctx).Err(); err != nil {
+		log.Sugar().Fatalf("error connecting to provider setting spend redis cache: %v", err)
+	}
+
+	routeQueueRedisCache := redis.NewClient(defaultRedisOption(cfg, 14))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := routeQueueRedisCache.Ping(ctx).Err(); err != nil {
+		log.Sugar().Fatalf("error connecting to route queue redis cache: %v", err)
+	}
+
+	prober := health.NewProber(cfg.HealthCheckTimeout, cfg.HealthCheckSlowThreshold)
+	prober.Add("postgresql", true, store.Ping)
+	prober.Add("redis", true, func(ctx context.Context) error {
+		return rateLimitRedisCache.Ping(ctx).Err()
+	})
+	prober.Add("routes_memdb", false, health.Freshness(rMemStore.LastSynced, 5*cfg.InMemoryDbUpdateInterval))
+	prober.Add("custom_providers_memdb", false, health.Freshness(cpMemStore.LastSynced, 5*cfg.InMemoryDbUpdateInterval))
+	prober.Add("pricing_memdb", false, health.Freshness(pMemStore.LastSynced, 5*cfg.InMemoryDbUpdateInterval))
+
 	rateLimitCache := redisStorage.NewCache(rateLimitRedisCache, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
 	costLimitCache := redisStorage.NewCache(costLimitRedisCache, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
 	costStorage := redisStorage.NewStore(costRedisStorage, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
@@ -285,31 +467,166 @@ func main() {
 
 	psCache := redisStorage.NewProviderSettingsCache(providerSettingsRedisCache, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
 	keysCache := redisStorage.NewKeysCache(keysRedisCache, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
-
-	encryptor, err := encryptor.NewEncryptor(cfg.DecryptionEndpoint, cfg.EncryptionEndpoint, cfg.EnableEncrytion, cfg.EncryptionTimeout, cfg.Audience)
+	claimLinksCache := redisStorage.NewClaimLinksCache(claimLinksRedisCache, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
+	idempotencyCache := redisStorage.NewIdempotencyCache(idempotencyRedisCache, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
+	settingSpendCache := redisStorage.NewSettingSpendCache(settingSpendRedisCache, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
+	routeQueue := redisStorage.NewRouteQueue(routeQueueRedisCache, cfg.RedisWriteTimeout, cfg.RedisReadTimeout)
+	routeRateLimiter := redisStorage.NewRouteRateLimiter(routeQueueRedisCache, cfg.RedisWriteTimeout)
+	tokenBucket := redisStorage.NewTokenBucket(rateLimitRedisCache, cfg.RedisWriteTimeout)
+
+	legacyEncryptor, err := encryptor.NewEncryptor(cfg.DecryptionEndpoint, cfg.EncryptionEndpoint, cfg.EnableEncrytion, cfg.EncryptionTimeout, cfg.Audience)
 	if cfg.EnableEncrytion && err != nil {
 		log.Sugar().Fatalf("error creating encryption client: %v", err)
 	}
 
-	m := manager.NewManager(store, costLimitCache, rateLimitCache, accessCache, keysCache)
-	krm := manager.NewReportingManager(costStorage, store, store)
-	psm := manager.NewProviderSettingsManager(store, psCache, encryptor)
-	cpm := manager.NewCustomProvidersManager(store, cpMemStore)
-	rm := manager.NewRouteManager(store, store, rMemStore, psm)
-	pm := manager.NewPolicyManager(store, rMemStore)
-	um := manager.NewUserManager(store, store)
+	var secrets encryptor.SecretEncryptor = legacyEncryptor
+	if len(cfg.KmsProvider) != 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		kw, err := encryptor.NewKeyWrapper(ctx, cfg.KmsProvider, cfg.KmsKeyId, cfg.KmsAwsRegion, cfg.VaultAddress, cfg.VaultToken, cfg.VaultTransitMount)
+		if err != nil {
+			log.Sugar().Fatalf("error creating %s kms client: %v", cfg.KmsProvider, err)
+		}
+
+		secrets = encryptor.NewEnvelope(kw, cfg.EncryptionTimeout)
+	}
 
-	as, err := admin.NewAdminServer(log, *modePtr, m, krm, psm, cpm, rm, pm, um, cfg.AdminPass)
+	syncer, err := catalog.NewSyncer(store, secrets, log, cfg.ModelCatalogSyncInterval)
 	if err != nil {
-		log.Sugar().Fatalf("error creating admin http server: %v", err)
+		log.Sugar().Fatalf("cannot initialize model catalog syncer: %v", err)
+	}
+	syncer.Listen()
+
+	hm := catalog.NewHealthMonitor(store, secrets, log, cfg.ProviderHealthCheckInterval, cfg.ProviderHealthCheckWindow, cfg.ProviderHealthSlowThreshold)
+	hm.Listen()
+
+	secretFormat, err := key.NewSecretFormat(cfg.KeySecretPrefix, cfg.KeySecretChecksum)
+	if err != nil {
+		log.Sugar().Fatalf("error configuring key secret format: %v", err)
+	}
+
+	m := manager.NewManager(store, costLimitCache, rateLimitCache, accessCache, keysCache, claimLinksCache, secretFormat)
+	psm := manager.NewProviderSettingsManager(store, psCache, keysCache, secrets, syncer, fx, hm)
+	krm := manager.NewReportingManager(costStorage, store, store, psm, fx, cfg.ReportingCurrency)
+
+	if len(*secretsPtr) != 0 {
+		if *secretsPtr != "migrate" && *secretsPtr != "rewrap" {
+			log.Sugar().Fatalf("secrets must be either migrate or rewrap")
+		}
+
+		if len(cfg.KmsProvider) == 0 {
+			log.Sugar().Fatalf("a kms provider must be configured to migrate provider setting secrets")
+		}
+
+		migration, err := psm.MigrateSecrets(legacyEncryptor, *secretsPtr == "rewrap")
+		if err != nil {
+			log.Sugar().Fatalf("error migrating provider setting secrets: %v", err)
+		}
+
+		log.Sugar().Infof("migrated secrets of %d provider settings: %d encrypted, %d rewrapped, %d already encrypted", migration.Settings, migration.Encrypted, migration.Rewrapped, migration.Skipped)
+		return
+	}
+
+	kv := catalog.NewKeyValidator(store, secrets, psm, dispatcher, cfg.KeyValidationAlertUrl, log, cfg.KeyValidationInterval)
+	kv.Listen()
+
+	cpm := manager.NewCustomProvidersManager(store, cpMemStore, psm)
+	breakers := route.NewBreakers()
+	rm := manager.NewRouteManager(store, store, rMemStore, psm, syncer, breakers)
+
+	detector, err := amazon.NewClient(cfg.AmazonRequestTimeout, cfg.AmazonConnectionTimeout, log, cfg.AmazonRegion)
+	if err != nil {
+		log.Sugar().Infof("error when connecting to amazon: %v", err)
+	}
+
+	scanner := pii.NewScanner(detector)
+	cd := custompolicy.NewOpenAiDetector(cfg.CustomPolicyDetectionTimeout, cfg.OpenAiApiKey)
+	guardrailTls, err := moderation.NewGuardrailTls(cfg.GuardrailTlsCertFile, cfg.GuardrailTlsKeyFile, cfg.GuardrailTlsCaFile)
+	if err != nil {
+		log.Sugar().Fatalf("error loading guardrail tls config: %v", err)
+	}
+
+	moderator := moderation.NewClient(cfg.ModerationTimeout, cfg.OpenAiApiKey, cfg.AzureContentSafetyKey, cfg.ModerationWebhookSecret, guardrailTls)
+
+	if len(cfg.PiiTokenSecret) == 0 {
+		policies, err := store.GetAllPolicies()
+		if err != nil {
+			log.Sugar().Fatalf("error getting policies: %v", err)
+		}
+
+		for _, p := range policies {
+			if p.Tokenizes() {
+				log.Sugar().Fatalf("PII_TOKEN_SECRET must be set since policy %s tokenizes", p.Id)
+			}
+		}
+
+		log.Sugar().Warn("PII_TOKEN_SECRET is not set. policies can not tokenize")
 	}
+	policy.SetTokenSecret([]byte(cfg.PiiTokenSecret))
+
+	pm := manager.NewPolicyManager(store, rMemStore, keysCache, scanner, cd, moderator, secrets)
+	um := manager.NewUserManager(store, store)
+	om := manager.NewOnboardManager(store, secretFormat)
 
 	tc := openai.NewTokenCounter()
 	custom.NewTokenCounter()
 
-	as.Run()
-
 	ce := openai.NewCostEstimator(openai.OpenAiPerThousandTokenCost, tc)
+	aoe := azure.NewCostEstimator()
+
+	cm := manager.NewCompareManager(store, secrets, map[string]route.CostEstimator{
+		"openai": ce,
+		"azure":  aoe,
+	}, log)
+
+	cfm := manager.NewConfigManager(store, m, psm, pm, rm, cpm)
+	wm := manager.NewWebhookManager(store)
+	tm := manager.NewToolManager(store)
+	mam := manager.NewModelAliasManager(store, store)
+	ctm := manager.NewCatalogManager(store, syncer)
+
+	drainer := drain.NewDrainer(cfg.ProxyDrainRetryAfter)
+
+	// admin writes made through this instance reach the proxy right away
+	// instead of on the next memdb update interval
+	changes := change.NewHub(change.DefaultBacklog)
+	changes.OnPublish(func(c *change.Change) {
+		if c.Kind == change.KindRoute || c.Kind == change.KindPolicy {
+			rMemStore.Refresh()
+		}
+
+		if c.Kind == change.KindPricing {
+			pMemStore.Refresh()
+		}
+
+		if c.Kind == change.KindModelAlias {
+			resolver.Refresh()
+		}
+	})
+
+	bodyLimits, err := admin.NewBodyLimitConfig(cfg.AdminMaxBodySize, cfg.AdminRouteMaxBodySizes)
+	if err != nil {
+		log.Sugar().Fatalf("error parsing admin body limits: %v", err)
+	}
+
+	as, err := admin.NewAdminServer(log, *modePtr, m, krm, psm, cpm, rm, pm, um, om, cm, cfm, wm, tm, ctm, mam, cfg.AdminPass, cfg.AdminHost, cfg.AdminPort, &admin.TlsConfig{
+		CertFile:     cfg.AdminTlsCertFile,
+		KeyFile:      cfg.AdminTlsKeyFile,
+		ClientCaFile: cfg.AdminTlsClientCaFile,
+	}, idempotencyCache, secrets, cfg.AdminIdempotencyKeyTtl, &admin.GuardConfig{
+		RateLimit:        cfg.AdminRateLimit,
+		LockoutThreshold: cfg.AdminLockoutThreshold,
+		LockoutDuration:  cfg.AdminLockoutDuration,
+	}, &admin.CorsConfig{
+		AllowedOrigins: cfg.AdminCorsAllowedOrigins,
+		AllowedHeaders: cfg.AdminCorsAllowedHeaders,
+		AllowedMethods: cfg.AdminCorsAllowedMethods,
+	}, prober, drainer, changes, bodyLimits)
+	if err != nil {
+		log.Sugar().Fatalf("error creating admin http server: %v", err)
+	}
+
+	as.Run()
 
 	atc, err := anthropic.NewTokenCounter()
 	if err != nil {
@@ -322,16 +639,15 @@ func main() {
 	}
 
 	ace := anthropic.NewCostEstimator(atc)
-	aoe := azure.NewCostEstimator()
 	vllme := vllm.NewCostEstimator(vllmtc)
 	die := deepinfra.NewCostEstimator()
 
 	v := validator.NewValidator(costLimitCache, rateLimitCache, costStorage)
 	uv := validator.NewUserValidator(userCostLimitCache, userRateLimitCache, userCostStorage)
 
-	rec := recorder.NewRecorder(costStorage, userCostStorage, costLimitCache, userCostLimitCache, ce, store)
+	rec := recorder.NewRecorder(costStorage, userCostStorage, costLimitCache, userCostLimitCache, ce, store, settingSpendCache)
 	rlm := manager.NewRateLimitManager(rateLimitCache, userRateLimitCache)
-	a := auth.NewAuthenticator(psm, m, rm, store, encryptor)
+	a := auth.NewAuthenticator(psm, m, rm, store, secrets)
 
 	c := cache.NewCache(apiCache)
 
@@ -339,33 +655,56 @@ func main() {
 	eventMessageChan := make(chan message.Message)
 	messageBus.Subscribe("event", eventMessageChan)
 
-	handler := message.NewHandler(rec, log, ace, ce, vllme, aoe, v, uv, m, um, rlm, accessCache, userAccessCache)
+	handler := message.NewHandler(rec, log, ace, ce, vllme, aoe, v, uv, m, um, rlm, accessCache, userAccessCache, dispatcher, fx)
 
 	eventConsumer := message.NewConsumer(eventMessageChan, log, 4, handler.HandleEventWithRequestAndResponse)
 	eventConsumer.StartEventMessageConsumers()
 
-	detector, err := amazon.NewClient(cfg.AmazonRequestTimeout, cfg.AmazonConnectionTimeout, log, cfg.AmazonRegion)
-	if err != nil {
-		log.Sugar().Infof("error when connecting to amazon: %v", err)
-	}
+	reconciler := batch.NewReconciler(store, secrets, ce, messageBus, log, cfg.BatchReconcileInterval)
+	reconciler.Listen()
 
-	scanner := pii.NewScanner(detector)
-	cd := custompolicy.NewOpenAiDetector(cfg.CustomPolicyDetectionTimeout, cfg.OpenAiApiKey)
+	ftReconciler := finetune.NewReconciler(store, secrets, ce, messageBus, log, cfg.FineTuningReconcileInterval)
+	ftReconciler.Listen()
+
+	gatewayId := cfg.GatewayId
+	if len(gatewayId) == 0 {
+		hostname, err := os.Hostname()
+		if err != nil {
+			log.Sugar().Fatalf("cannot determine gateway id from hostname: %v", err)
+		}
+
+		gatewayId = hostname
+	}
 
-	ps, err := proxy.NewProxyServer(log, *modePtr, *privacyPtr, c, m, rm, a, psm, cpm, store, ce, ace, aoe, v, rec, messageBus, rlm, cfg.ProxyTimeout, accessCache, userAccessCache, pm, scanner, cd, die, um, cfg.RemoveUserAgent)
+	ps, err := proxy.NewProxyServer(log, *modePtr, *privacyPtr, c, m, rm, a, psm, cpm, store, ce, ace, aoe, v, rec, messageBus, rlm, cfg.ProxyTimeout, accessCache, userAccessCache, pm, scanner, cd, moderator, die, um, cfg.RemoveUserAgent, &proxy.DisconnectConfig{
+		Threshold: cfg.ProxyDisconnectStormThreshold,
+		Ratio:     cfg.ProxyDisconnectStormRatio,
+		Window:    cfg.ProxyDisconnectStormWindow,
+		Cooldown:  cfg.ProxyDisconnectStormCooldown,
+	}, prober, drainer, broker, bedrock.NewCostEstimator(ace), mistral.NewCostEstimator(), groq.NewCostEstimator(), cohere.NewCostEstimator(), selfhosted.NewCostEstimator(), cfg.ProxyQuotaWarningThresholds, cfg.ProxySseMaxLineSize, hm, gatewayId, pMemStore, idempotencyCache, store, settingSpendCache, store, breakers, resolver, routeQueue, routeRateLimiter, tokenBucket)
 	if err != nil {
 		log.Sugar().Fatalf("error creating proxy http server: %v", err)
 	}
 
 	ps.Run()
 
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
+	reconciler.Stop()
+	ftReconciler.Stop()
 	eventConsumer.Stop()
 	cpMemStore.Stop()
 	rMemStore.Stop()
+	pMemStore.Stop()
+	dispatcher.Stop()
+	broker.Stop()
+	resolver.Stop()
+	fx.Stop()
+	syncer.Stop()
+	hm.Stop()
+	kv.Stop()
 
 	log.Sugar().Infof("shutting down server...")
 
@@ -0,0 +1,89 @@
+package alias
+
+import (
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+// Alias maps a client facing model name of a provider, such as "fast", to the
+// model requests naming it are sent with. An alias with key ids only applies
+// to requests made with those keys, and takes precedence over an alias of the
+// same name that applies to every key.
+type Alias struct {
+	Id        string   `json:"id"`
+	CreatedAt int64    `json:"createdAt"`
+	UpdatedAt int64    `json:"updatedAt"`
+	Name      string   `json:"name"`
+	Provider  string   `json:"provider"`
+	Model     string   `json:"model"`
+	KeyIds    []string `json:"keyIds"`
+}
+
+type UpdateAlias struct {
+	UpdatedAt int64     `json:"updatedAt"`
+	Model     string    `json:"model"`
+	KeyIds    *[]string `json:"keyIds"`
+}
+
+func (a *Alias) Validate() error {
+	invalid := []string{}
+
+	if len(a.Name) == 0 {
+		invalid = append(invalid, "name")
+	}
+
+	if len(a.Provider) == 0 {
+		invalid = append(invalid, "provider")
+	}
+
+	if len(a.Model) == 0 || a.Model == a.Name {
+		invalid = append(invalid, "model")
+	}
+
+	for _, id := range a.KeyIds {
+		if len(id) == 0 {
+			invalid = append(invalid, "keyIds")
+			break
+		}
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewInvalidFieldsError(invalid)
+	}
+
+	return nil
+}
+
+// AppliesTo reports whether the alias applies to requests made with a key.
+func (a *Alias) AppliesTo(keyId string) bool {
+	if len(a.KeyIds) == 0 {
+		return true
+	}
+
+	for _, id := range a.KeyIds {
+		if id == keyId {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Overlaps reports whether both aliases could resolve the same request, which
+// would make the model it is sent with ambiguous.
+func (a *Alias) Overlaps(other *Alias) bool {
+	if a.Id == other.Id || a.Name != other.Name || a.Provider != other.Provider {
+		return false
+	}
+
+	if len(a.KeyIds) == 0 || len(other.KeyIds) == 0 {
+		return len(a.KeyIds) == len(other.KeyIds)
+	}
+
+	for _, id := range other.KeyIds {
+		if a.AppliesTo(id) {
+			return true
+		}
+	}
+
+	return false
+}
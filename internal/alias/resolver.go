@@ -0,0 +1,114 @@
+package alias
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+type Storage interface {
+	GetModelAliases() ([]*Alias, error)
+}
+
+// Resolver keeps an in memory copy of model aliases for the proxy. Aliases are
+// reloaded in full on every update so that deleted ones stop resolving.
+type Resolver struct {
+	s        Storage
+	lock     sync.RWMutex
+	aliases  map[string][]*Alias
+	interval time.Duration
+	done     chan bool
+	log      *zap.Logger
+}
+
+func NewResolver(s Storage, log *zap.Logger, interval time.Duration) (*Resolver, error) {
+	r := &Resolver{
+		s:        s,
+		interval: interval,
+		done:     make(chan bool),
+		log:      log,
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func aliasKey(provider, name string) string {
+	return provider + "/" + name
+}
+
+func (r *Resolver) load() error {
+	aliases, err := r.s.GetModelAliases()
+	if err != nil {
+		return err
+	}
+
+	byName := map[string][]*Alias{}
+	for _, a := range aliases {
+		k := aliasKey(a.Provider, a.Name)
+		byName[k] = append(byName[k], a)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.aliases = byName
+
+	return nil
+}
+
+func (r *Resolver) Listen() {
+	ticker := time.NewTicker(r.interval)
+	r.log.Info("model alias resolver started listening for alias updates")
+
+	go func() {
+		for {
+			select {
+			case <-r.done:
+				ticker.Stop()
+				r.log.Info("model alias resolver stopped")
+				return
+			case <-ticker.C:
+				r.Refresh()
+			}
+		}
+	}()
+}
+
+// Refresh reloads aliases right away instead of on the next update.
+func (r *Resolver) Refresh() {
+	if err := r.load(); err != nil {
+		telemetry.Incr("bricksllm.alias.resolver.refresh.get_model_aliases_error", nil, 1)
+		r.log.Sugar().Debugf("model alias resolver failed to update aliases: %v", err)
+	}
+}
+
+func (r *Resolver) Stop() {
+	r.done <- true
+}
+
+// Resolve returns the alias a model name of a provider stands for when used
+// with a key, or nil. Aliases of the key win over the ones of every key.
+func (r *Resolver) Resolve(provider, name, keyId string) *Alias {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var global *Alias
+	for _, a := range r.aliases[aliasKey(provider, name)] {
+		if len(a.KeyIds) == 0 {
+			global = a
+			continue
+		}
+
+		if a.AppliesTo(keyId) {
+			return a
+		}
+	}
+
+	return global
+}
@@ -46,6 +46,7 @@ type Authenticator struct {
 	rm        routesManager
 	ks        keyStorage
 	decryptor Decryptor
+	kps       *keyPoolSelector
 }
 
 func NewAuthenticator(psm providerSettingsManager, kc keysCache, rm routesManager, ks keyStorage, decryptor Decryptor) *Authenticator {
@@ -55,6 +56,7 @@ func NewAuthenticator(psm providerSettingsManager, kc keysCache, rm routesManage
 		rm:        rm,
 		ks:        ks,
 		decryptor: decryptor,
+		kps:       newKeyPoolSelector(),
 	}
 }
 
@@ -87,7 +89,7 @@ func rewriteHttpAuthHeader(req *http.Request, setting *provider.Setting) error {
 
 	apiKey := setting.GetParam("apikey")
 
-	if strings.HasPrefix(uri, "/api/providers/vllm") {
+	if strings.HasPrefix(uri, "/api/providers/vllm") || strings.HasPrefix(uri, "/api/providers/self-hosted") {
 		if len(apiKey) != 0 {
 			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
 		}
@@ -102,6 +104,15 @@ func rewriteHttpAuthHeader(req *http.Request, setting *provider.Setting) error {
 		return errors.New("api key is empty in provider setting")
 	}
 
+	// the key of this gateway must not reach the upstream gateway, which
+	// would pick it up before the authorization header
+	if strings.HasPrefix(uri, "/api/providers/bricksllm") {
+		req.Header.Del("x-api-key")
+		req.Header.Del("api-key")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
+		return nil
+	}
+
 	if strings.HasPrefix(uri, "/api/providers/anthropic") {
 		req.Header.Set("x-api-key", apiKey)
 		return nil
@@ -143,7 +154,7 @@ func (a *Authenticator) getProviderSettingsThatCanAccessCustomRoute(path string,
 	}
 
 	target := map[string]bool{}
-	for _, s := range rc.Steps {
+	for _, s := range rc.AllSteps() {
 		target[s.Provider] = true
 	}
 
@@ -184,6 +195,26 @@ func canAccessPath(provider string, path string) bool {
 		return false
 	}
 
+	if provider == "mistral" && !strings.HasPrefix(path, "/api/providers/mistral") {
+		return false
+	}
+
+	if provider == "cohere" && !strings.HasPrefix(path, "/api/providers/cohere") {
+		return false
+	}
+
+	if provider == "groq" && !strings.HasPrefix(path, "/api/providers/groq") {
+		return false
+	}
+
+	if provider == "self-hosted" && !strings.HasPrefix(path, "/api/providers/self-hosted") {
+		return false
+	}
+
+	if provider == "bricksllm" && !strings.HasPrefix(path, "/api/providers/bricksllm") {
+		return false
+	}
+
 	return true
 }
 
@@ -204,10 +235,12 @@ func anonymize(input string) string {
 	return string(input[0:5]) + "**********************************************"
 }
 
-func (a *Authenticator) AuthenticateHttpRequest(req *http.Request) (*key.ResponseKey, []*provider.Setting, error) {
+// getKey looks up the key a request is authenticated with, and returns it
+// along with the raw key for error messages.
+func (a *Authenticator) getKey(req *http.Request) (*key.ResponseKey, string, error) {
 	raw, err := getApiKey(req)
 	if err != nil {
-		return nil, nil, err
+		return nil, "", err
 	}
 
 	hash := hasher.Hash(raw)
@@ -224,18 +257,55 @@ func (a *Authenticator) AuthenticateHttpRequest(req *http.Request) (*key.Respons
 	if err != nil {
 		_, ok := err.(notFoundError)
 		if ok {
-			return nil, nil, internal_errors.NewAuthError(fmt.Sprintf("key %s is not found", anonymize(raw)))
+			return nil, "", internal_errors.NewAuthError(fmt.Sprintf("key %s is not found", anonymize(raw)))
 		}
 
-		return nil, nil, err
+		return nil, "", err
 	}
 
 	if key == nil {
-		return nil, nil, internal_errors.NewAuthError(fmt.Sprintf("key %s is not found", anonymize(raw)))
+		return nil, "", internal_errors.NewAuthError(fmt.Sprintf("key %s is not found", anonymize(raw)))
 	}
 
 	if key.Revoked {
-		return nil, nil, internal_errors.NewAuthError(fmt.Sprintf("key %s has been revoked", anonymize(raw)))
+		return nil, "", internal_errors.NewAuthError(fmt.Sprintf("key %s has been revoked", anonymize(raw)))
+	}
+
+	return key, raw, nil
+}
+
+// AuthenticateKey returns the key a request is authenticated with and every
+// provider setting associated with it. Unlike AuthenticateHttpRequest, it
+// neither selects a setting for the path nor rewrites the auth header, since
+// the request is served by the gateway itself.
+func (a *Authenticator) AuthenticateKey(req *http.Request) (*key.ResponseKey, []*provider.Setting, error) {
+	key, _, err := a.getKey(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	settings := []*provider.Setting{}
+	for _, settingId := range key.GetSettingIds() {
+		setting, _ := a.psm.GetSettingViaCache(settingId)
+		if setting == nil {
+			telemetry.Incr("bricksllm.authenticator.authenticate_key.get_setting_error", nil, 1)
+			continue
+		}
+
+		if setting.Disabled {
+			continue
+		}
+
+		settings = append(settings, setting)
+	}
+
+	return key, settings, nil
+}
+
+func (a *Authenticator) AuthenticateHttpRequest(req *http.Request) (*key.ResponseKey, []*provider.Setting, error) {
+	key, raw, err := a.getKey(req)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	if strings.HasPrefix(req.URL.Path, "/api/routes") {
@@ -255,6 +325,13 @@ func (a *Authenticator) AuthenticateHttpRequest(req *http.Request) (*key.Respons
 			continue
 		}
 
+		// disabled settings are left out so that requests fall back on the
+		// other settings of the key rather than failing upstream
+		if setting.Disabled {
+			telemetry.Incr("bricksllm.authenticator.authenticate_http_request.setting_disabled", nil, 1)
+			continue
+		}
+
 		if canAccessPath(setting.Provider, req.URL.Path) {
 			selected = append(selected, setting)
 		}
@@ -271,11 +348,15 @@ func (a *Authenticator) AuthenticateHttpRequest(req *http.Request) (*key.Respons
 	}
 
 	if len(selected) != 0 {
-		used := selected[0]
+		// the setting in use is moved to the front, since that is the one the
+		// proxy reads provider params from and attributes events to
 		if key.RotationEnabled {
-			used = selected[rand.Intn(len(selected))]
+			picked := rand.Intn(len(selected))
+			selected[0], selected[picked] = selected[picked], selected[0]
 		}
 
+		used := selected[0]
+
 		if a.decryptor.Enabled() {
 			encryptedParam := ""
 			if used.Provider == "amazon" {
@@ -296,6 +377,15 @@ func (a *Authenticator) AuthenticateHttpRequest(req *http.Request) (*key.Respons
 			}
 		}
 
+		if used.KeyPool != nil && len(used.KeyPool.Keys) != 0 {
+			pooled, err := a.usePooledKey(req, used)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			used = pooled
+		}
+
 		err := rewriteHttpAuthHeader(req, used)
 		if err != nil {
 			return nil, nil, err
@@ -306,3 +396,41 @@ func (a *Authenticator) AuthenticateHttpRequest(req *http.Request) (*key.Respons
 
 	return nil, nil, internal_errors.NewAuthError(fmt.Sprintf("provider setting not found for key %s", raw))
 }
+
+// usePooledKey returns a copy of setting that authenticates with a key picked
+// from its pool, and remembers the key on req so that upstream rejections can
+// be reported back. The apikey of the setting is used once every pooled key
+// has been removed.
+func (a *Authenticator) usePooledKey(req *http.Request, setting *provider.Setting) (*provider.Setting, error) {
+	picked := a.kps.pick(setting)
+	if picked == nil {
+		telemetry.Incr("bricksllm.authenticator.key_pool.exhausted", nil, 1)
+		if len(setting.Setting["apikey"]) != 0 {
+			return setting, nil
+		}
+
+		return nil, errors.New("no pooled key of provider setting is available")
+	}
+
+	secret := picked.Key
+	if a.decryptor.Enabled() {
+		decrypted, err := a.decryptor.Decrypt(secret, map[string]string{"X-UPDATED-AT": strconv.FormatInt(setting.UpdatedAt, 10)})
+		if err == nil {
+			secret = decrypted
+		}
+	}
+
+	copied := *setting
+	copied.Setting = map[string]string{}
+	for k, v := range setting.Setting {
+		copied.Setting[k] = v
+	}
+	copied.Setting["apikey"] = secret
+
+	*req = *req.WithContext(withPickedKey(req.Context(), &pickedKey{
+		settingId: setting.Id,
+		keyId:     picked.Id,
+	}))
+
+	return &copied, nil
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+// rateLimitedCooldown is how long a pooled key that was rate limited is left
+// out when the upstream does not say when to retry.
+const rateLimitedCooldown = time.Minute
+
+type pickedKeyCtxKey struct{}
+
+// pickedKey identifies the pooled key that a request was sent upstream with.
+type pickedKey struct {
+	settingId string
+	keyId     string
+}
+
+type keyPoolState struct {
+	updatedAt    int64
+	next         int
+	lastUsed     map[string]time.Time
+	unauthorized map[string]bool
+	limitedUntil map[string]time.Time
+}
+
+// keyPoolSelector picks among the pooled keys of provider settings. Keys that
+// the upstream rejects are left out: a 401 removes a key until the setting is
+// updated and a 429 removes it until it can be retried. The state is kept in
+// memory, so every instance of the proxy rotates on its own.
+type keyPoolSelector struct {
+	mu     sync.Mutex
+	states map[string]*keyPoolState
+}
+
+func newKeyPoolSelector() *keyPoolSelector {
+	return &keyPoolSelector{
+		states: map[string]*keyPoolState{},
+	}
+}
+
+func (s *keyPoolSelector) stateOf(setting *provider.Setting) *keyPoolState {
+	st, ok := s.states[setting.Id]
+	if !ok || st.updatedAt != setting.UpdatedAt {
+		st = &keyPoolState{
+			updatedAt:    setting.UpdatedAt,
+			lastUsed:     map[string]time.Time{},
+			unauthorized: map[string]bool{},
+			limitedUntil: map[string]time.Time{},
+		}
+
+		s.states[setting.Id] = st
+	}
+
+	return st
+}
+
+// pick returns the pooled key of setting to send the next request with, or nil
+// when every key has been removed.
+func (s *keyPoolSelector) pick(setting *provider.Setting) *provider.PooledKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	st := s.stateOf(setting)
+
+	available := []*provider.PooledKey{}
+	for _, k := range setting.KeyPool.Keys {
+		if st.unauthorized[k.Id] || now.Before(st.limitedUntil[k.Id]) {
+			continue
+		}
+
+		available = append(available, k)
+	}
+
+	if len(available) == 0 {
+		return nil
+	}
+
+	var picked *provider.PooledKey
+	switch setting.KeyPool.Strategy {
+	case provider.KeyRotationLeastRecentlyUsed:
+		for _, k := range available {
+			if picked == nil || st.lastUsed[k.Id].Before(st.lastUsed[picked.Id]) {
+				picked = k
+			}
+		}
+	case provider.KeyRotationWeighted:
+		total := 0
+		for _, k := range available {
+			total += weightOf(k)
+		}
+
+		n := rand.Intn(total)
+		for _, k := range available {
+			n -= weightOf(k)
+			if n < 0 {
+				picked = k
+				break
+			}
+		}
+	default:
+		picked = available[st.next%len(available)]
+		st.next++
+	}
+
+	st.lastUsed[picked.Id] = now
+
+	return picked
+}
+
+// weightOf treats a key without a weight as having a weight of one.
+func weightOf(k *provider.PooledKey) int {
+	if k.Weight <= 0 {
+		return 1
+	}
+
+	return k.Weight
+}
+
+func (s *keyPoolSelector) report(p *pickedKey, status int, retryAfter string) {
+	if status != http.StatusUnauthorized && status != http.StatusTooManyRequests {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.states[p.settingId]
+	if !ok {
+		return
+	}
+
+	if status == http.StatusUnauthorized {
+		telemetry.Incr("bricksllm.authenticator.key_pool.unauthorized", nil, 1)
+		st.unauthorized[p.keyId] = true
+		return
+	}
+
+	cooldown := rateLimitedCooldown
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds > 0 {
+		cooldown = time.Duration(seconds) * time.Second
+	}
+
+	telemetry.Incr("bricksllm.authenticator.key_pool.rate_limited", nil, 1)
+	st.limitedUntil[p.keyId] = time.Now().Add(cooldown)
+}
+
+// ReportUpstreamStatus lets the key pool of the provider setting that served
+// req learn from the status code the upstream responded with.
+func (a *Authenticator) ReportUpstreamStatus(req *http.Request, status int, retryAfter string) {
+	p, ok := req.Context().Value(pickedKeyCtxKey{}).(*pickedKey)
+	if !ok {
+		return
+	}
+
+	a.kps.report(p, status, retryAfter)
+}
+
+func withPickedKey(ctx context.Context, p *pickedKey) context.Context {
+	return context.WithValue(ctx, pickedKeyCtxKey{}, p)
+}
@@ -0,0 +1,42 @@
+package batch
+
+const (
+	StatusValidating = "validating"
+	StatusInProgress = "in_progress"
+	StatusFinalizing = "finalizing"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+	StatusExpired    = "expired"
+	StatusCancelling = "cancelling"
+	StatusCancelled  = "cancelled"
+)
+
+// Batch is an OpenAI batch job created through the proxy. Batches are billed
+// once they finish, so the key that created a batch is remembered until its
+// usage has been reconciled into events.
+type Batch struct {
+	Id                   string  `json:"id"`
+	KeyId                string  `json:"keyId"`
+	ProviderSettingId    string  `json:"providerSettingId"`
+	Endpoint             string  `json:"endpoint"`
+	InputFileId          string  `json:"inputFileId"`
+	OutputFileId         string  `json:"outputFileId"`
+	Status               string  `json:"status"`
+	RequestCount         int     `json:"requestCount"`
+	PromptTokenCount     int     `json:"promptTokenCount"`
+	CompletionTokenCount int     `json:"completionTokenCount"`
+	CostInUsd            float64 `json:"costInUsd"`
+	CreatedAt            int64   `json:"createdAt"`
+	UpdatedAt            int64   `json:"updatedAt"`
+	ReconciledAt         int64   `json:"reconciledAt"`
+}
+
+// IsTerminal reports whether a batch can no longer change.
+func IsTerminal(status string) bool {
+	switch status {
+	case StatusCompleted, StatusFailed, StatusExpired, StatusCancelled:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,396 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/message"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"go.uber.org/zap"
+)
+
+const (
+	openAiBaseUrl = "https://api.openai.com/v1"
+
+	requestTimeout = 5 * time.Minute
+
+	// requests of batches are billed at half of the price of their synchronous
+	// counterparts
+	batchDiscount = 0.5
+)
+
+type Storage interface {
+	GetUnreconciledBatches() ([]*Batch, error)
+	UpdateBatchStatus(id, status, outputFileId string, updatedAt int64) error
+	ReconcileBatch(b *Batch) (bool, error)
+	GetProviderSettings(withSecret bool, ids []string) ([]*provider.Setting, error)
+	GetKey(keyId string) (*key.ResponseKey, error)
+}
+
+type Decryptor interface {
+	Decrypt(input string, headers map[string]string) (string, error)
+	Enabled() bool
+}
+
+type CostEstimator interface {
+	EstimateTotalCost(model string, promptTks, completionTks int) (float64, error)
+	EstimateEmbeddingsInputCost(model string, tks int) (float64, error)
+}
+
+type Publisher interface {
+	Publish(message.Message)
+}
+
+// Reconciler polls the batches created through the proxy until they finish,
+// and turns the usage in their output files into events of the keys that
+// created them. Spend of the keys is recorded the same way as for synchronous
+// requests once the events are published.
+type Reconciler struct {
+	s        Storage
+	d        Decryptor
+	e        CostEstimator
+	pub      Publisher
+	client   http.Client
+	interval time.Duration
+	done     chan bool
+	log      *zap.Logger
+}
+
+func NewReconciler(s Storage, d Decryptor, e CostEstimator, pub Publisher, log *zap.Logger, interval time.Duration) *Reconciler {
+	return &Reconciler{
+		s:        s,
+		d:        d,
+		e:        e,
+		pub:      pub,
+		client:   http.Client{Timeout: requestTimeout},
+		interval: interval,
+		done:     make(chan bool),
+		log:      log,
+	}
+}
+
+func (r *Reconciler) Listen() {
+	if r.interval <= 0 {
+		r.log.Info("batch reconciliation is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	r.log.Info("batch reconciler started")
+
+	go func() {
+		for {
+			select {
+			case <-r.done:
+				ticker.Stop()
+				r.log.Info("batch reconciler stopped")
+				return
+			case <-ticker.C:
+				r.reconcileAndLog()
+			}
+		}
+	}()
+}
+
+func (r *Reconciler) Stop() {
+	if r.interval <= 0 {
+		return
+	}
+
+	r.done <- true
+}
+
+func (r *Reconciler) reconcileAndLog() {
+	if err := r.ReconcileAll(); err != nil {
+		telemetry.Incr("bricksllm.batch.reconciler.reconcile_error", nil, 1)
+		r.log.Sugar().Debugf("batch reconciliation failed: %v", err)
+	}
+}
+
+// ReconcileAll checks on every batch that has not been reconciled yet. A batch
+// that fails to be checked is retried on the next tick.
+func (r *Reconciler) ReconcileAll() error {
+	batches, err := r.s.GetUnreconciledBatches()
+	if err != nil {
+		return err
+	}
+
+	settings := map[string]*provider.Setting{}
+	for _, b := range batches {
+		setting, ok := settings[b.ProviderSettingId]
+		if !ok {
+			setting, err = r.setting(b.ProviderSettingId)
+			if err != nil {
+				telemetry.Incr("bricksllm.batch.reconciler.get_setting_error", nil, 1)
+				r.log.Sugar().Debugf("getting provider setting %s of batch %s failed: %v", b.ProviderSettingId, b.Id, err)
+				continue
+			}
+
+			settings[b.ProviderSettingId] = setting
+		}
+
+		if err := r.Reconcile(b, setting); err != nil {
+			telemetry.Incr("bricksllm.batch.reconciler.reconcile_batch_error", nil, 1)
+			r.log.Sugar().Debugf("reconciling batch %s failed: %v", b.Id, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Reconciler) setting(id string) (*provider.Setting, error) {
+	settings, err := r.s.GetProviderSettings(true, []string{id})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(settings) == 0 {
+		return nil, errors.New("provider setting is not found")
+	}
+
+	return r.decrypt(settings[0]), nil
+}
+
+// decrypt returns a copy of setting with its api key in plain text. Settings
+// that only have pooled keys use the first one, which belongs to the same
+// organization as the key the batch was created with.
+func (r *Reconciler) decrypt(setting *provider.Setting) *provider.Setting {
+	copied := *setting
+	copied.Setting = map[string]string{}
+	for k, v := range setting.Setting {
+		copied.Setting[k] = v
+	}
+
+	apikey := copied.Setting["apikey"]
+	if len(apikey) == 0 && setting.KeyPool != nil && len(setting.KeyPool.Keys) != 0 {
+		apikey = setting.KeyPool.Keys[0].Key
+	}
+
+	if r.d != nil && r.d.Enabled() && len(apikey) != 0 {
+		decrypted, err := r.d.Decrypt(apikey, map[string]string{"X-UPDATED-AT": strconv.FormatInt(setting.UpdatedAt, 10)})
+		if err == nil {
+			apikey = decrypted
+		}
+	}
+
+	copied.Setting["apikey"] = apikey
+
+	return &copied
+}
+
+type upstreamBatch struct {
+	Id            string `json:"id"`
+	Status        string `json:"status"`
+	OutputFileId  string `json:"output_file_id"`
+	RequestCounts struct {
+		Completed int `json:"completed"`
+	} `json:"request_counts"`
+}
+
+type batchOutput struct {
+	Response *struct {
+		StatusCode int `json:"status_code"`
+		Body       struct {
+			Model string `json:"model"`
+			Usage struct {
+				PromptTokens     int `json:"prompt_tokens"`
+				CompletionTokens int `json:"completion_tokens"`
+			} `json:"usage"`
+		} `json:"body"`
+	} `json:"response"`
+}
+
+type modelUsage struct {
+	requests      int
+	promptTks     int
+	completionTks int
+}
+
+// Reconcile updates the status of a batch, and publishes the usage of a
+// finished batch as events once it has been claimed, so that gateways sharing
+// a database never bill a batch twice.
+func (r *Reconciler) Reconcile(b *Batch, setting *provider.Setting) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	ub := &upstreamBatch{}
+	if err := r.get(ctx, setting, "/batches/"+b.Id, func(body io.Reader) error {
+		return json.NewDecoder(body).Decode(ub)
+	}); err != nil {
+		return err
+	}
+
+	if !IsTerminal(ub.Status) {
+		if ub.Status != b.Status {
+			return r.s.UpdateBatchStatus(b.Id, ub.Status, ub.OutputFileId, time.Now().Unix())
+		}
+
+		return nil
+	}
+
+	usage := map[string]*modelUsage{}
+	if len(ub.OutputFileId) != 0 {
+		if err := r.get(ctx, setting, "/files/"+ub.OutputFileId+"/content", func(body io.Reader) error {
+			return readUsage(body, usage)
+		}); err != nil {
+			return err
+		}
+	}
+
+	costs := map[string]float64{}
+	reconciled := *b
+	reconciled.Status = ub.Status
+	reconciled.OutputFileId = ub.OutputFileId
+	reconciled.RequestCount = 0
+	for model, u := range usage {
+		cost, err := r.cost(b.Endpoint, model, u)
+		if err != nil {
+			telemetry.Incr("bricksllm.batch.reconciler.estimate_cost_error", nil, 1)
+			r.log.Sugar().Debugf("estimating cost of %s in batch %s failed: %v", model, b.Id, err)
+		}
+
+		costs[model] = cost
+		reconciled.RequestCount += u.requests
+		reconciled.PromptTokenCount += u.promptTks
+		reconciled.CompletionTokenCount += u.completionTks
+		reconciled.CostInUsd += cost
+	}
+
+	now := time.Now().Unix()
+	reconciled.UpdatedAt = now
+	reconciled.ReconciledAt = now
+
+	claimed, err := r.s.ReconcileBatch(&reconciled)
+	if err != nil {
+		return err
+	}
+
+	if !claimed {
+		return nil
+	}
+
+	k, err := r.s.GetKey(b.KeyId)
+	if err != nil {
+		// the usage is still recorded as events when the key is gone
+		telemetry.Incr("bricksllm.batch.reconciler.get_key_error", nil, 1)
+		r.log.Sugar().Debugf("getting key %s of batch %s failed: %v", b.KeyId, b.Id, err)
+	}
+
+	for model, u := range usage {
+		r.publish(&reconciled, k, model, u, costs[model])
+	}
+
+	telemetry.Incr("bricksllm.batch.reconciler.reconciled", []string{"status:" + ub.Status}, 1)
+
+	return nil
+}
+
+func (r *Reconciler) cost(endpoint, model string, u *modelUsage) (float64, error) {
+	if endpoint == "/v1/embeddings" {
+		cost, err := r.e.EstimateEmbeddingsInputCost(model, u.promptTks)
+		return cost * batchDiscount, err
+	}
+
+	cost, err := r.e.EstimateTotalCost(model, u.promptTks, u.completionTks)
+	return cost * batchDiscount, err
+}
+
+func (r *Reconciler) publish(b *Batch, k *key.ResponseKey, model string, u *modelUsage, cost float64) {
+	metadata, _ := json.Marshal(map[string]any{
+		"batchId":      b.Id,
+		"requestCount": u.requests,
+	})
+
+	evt := &event.Event{
+		SchemaVersion:        event.SchemaVersion,
+		Id:                   util.NewUuid(),
+		CreatedAt:            b.ReconciledAt,
+		KeyId:                b.KeyId,
+		CostInUsd:            cost,
+		Provider:             "openai",
+		Model:                model,
+		Status:               http.StatusOK,
+		PromptTokenCount:     u.promptTks,
+		CompletionTokenCount: u.completionTks,
+		Path:                 "/api/providers/openai/v1/batches",
+		Method:               http.MethodPost,
+		Metadata:             metadata,
+		ProviderSettingId:    b.ProviderSettingId,
+	}
+
+	if k != nil {
+		evt.Tags = k.Tags
+	}
+
+	r.pub.Publish(message.Message{
+		Type: "event",
+		Data: &event.EventWithRequestAndContent{
+			Event: evt,
+			Key:   k,
+		},
+	})
+}
+
+func (r *Reconciler) get(ctx context.Context, setting *provider.Setting, path string, read func(body io.Reader) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, openAiBaseUrl+path, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+setting.Setting["apikey"])
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("openai responded to %s with %d: %s", path, res.StatusCode, data)
+	}
+
+	return read(res.Body)
+}
+
+// readUsage sums up the usage of the successful requests in a batch output
+// file by model.
+func readUsage(body io.Reader, usage map[string]*modelUsage) error {
+	reader := bufio.NewReader(body)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if trimmed := bytes.TrimSpace(line); len(trimmed) != 0 {
+			o := &batchOutput{}
+			if err := json.Unmarshal(trimmed, o); err == nil && o.Response != nil && o.Response.StatusCode == http.StatusOK {
+				model := o.Response.Body.Model
+				if _, ok := usage[model]; !ok {
+					usage[model] = &modelUsage{}
+				}
+
+				usage[model].requests++
+				usage[model].promptTks += o.Response.Body.Usage.PromptTokens
+				usage[model].completionTks += o.Response.Body.Usage.CompletionTokens
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
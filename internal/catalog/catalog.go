@@ -0,0 +1,31 @@
+package catalog
+
+const (
+	ReferenceProviderSetting = "provider_setting"
+	ReferenceRoute           = "route"
+)
+
+// Model is a model listed by a provider's models endpoint or priced by the
+// pricing table. Models that stop being listed upstream are kept with
+// Available set to false, and models that are only priced have never been
+// seen upstream.
+type Model struct {
+	Id          string       `json:"id"`
+	Provider    string       `json:"provider"`
+	Model       string       `json:"model"`
+	OwnedBy     string       `json:"ownedBy"`
+	Available   bool         `json:"available"`
+	FirstSeenAt int64        `json:"firstSeenAt"`
+	LastSeenAt  int64        `json:"lastSeenAt"`
+	Missing     bool         `json:"missing"`
+	References  []*Reference `json:"references,omitempty"`
+	Pricing     *Pricing     `json:"pricing,omitempty"`
+}
+
+// Reference points at a resource that uses a model, such as a provider setting
+// allowing it or a route step calling it.
+type Reference struct {
+	Kind string `json:"kind"`
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
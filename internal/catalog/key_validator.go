@@ -0,0 +1,194 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+const validationTimeout = 10 * time.Second
+
+type SettingDisabler interface {
+	DisableSetting(id, reason string) (bool, error)
+}
+
+type RevokedKeyNotifier interface {
+	AlertRevokedKey(url string, a *provider.RevokedKeyAlert)
+}
+
+// KeyValidator periodically checks that the api key of every enabled provider
+// setting that can be tested still authenticates upstream. Settings whose key
+// is rejected are disabled, so that keys fall back on their other settings
+// instead of failing with 401s, and alertUrl is notified.
+type KeyValidator struct {
+	s        SettingStorage
+	d        Decryptor
+	sd       SettingDisabler
+	n        RevokedKeyNotifier
+	alertUrl string
+	client   http.Client
+	interval time.Duration
+	done     chan bool
+	log      *zap.Logger
+}
+
+func NewKeyValidator(s SettingStorage, d Decryptor, sd SettingDisabler, n RevokedKeyNotifier, alertUrl string, log *zap.Logger, interval time.Duration) *KeyValidator {
+	return &KeyValidator{
+		s:        s,
+		d:        d,
+		sd:       sd,
+		n:        n,
+		alertUrl: alertUrl,
+		client:   http.Client{Timeout: validationTimeout},
+		interval: interval,
+		done:     make(chan bool),
+		log:      log,
+	}
+}
+
+func (kv *KeyValidator) Listen() {
+	if kv.interval <= 0 {
+		kv.log.Info("provider key validation is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(kv.interval)
+	kv.log.Info("provider key validator started")
+
+	go func() {
+		for {
+			select {
+			case <-kv.done:
+				ticker.Stop()
+				kv.log.Info("provider key validator stopped")
+				return
+			case <-ticker.C:
+				kv.validateAndLog()
+			}
+		}
+	}()
+}
+
+func (kv *KeyValidator) Stop() {
+	if kv.interval <= 0 {
+		return
+	}
+
+	kv.done <- true
+}
+
+func (kv *KeyValidator) validateAndLog() {
+	if err := kv.ValidateAll(); err != nil {
+		telemetry.Incr("bricksllm.catalog.key_validator.validate_error", nil, 1)
+		kv.log.Sugar().Debugf("provider key validation failed: %v", err)
+	}
+}
+
+// ValidateAll checks the keys of provider settings concurrently. Settings that
+// only have pooled keys are skipped, since rejected pooled keys are already
+// taken out of their pool by the proxy.
+func (kv *KeyValidator) ValidateAll() error {
+	settings, err := kv.s.GetProviderSettings(true, nil)
+	if err != nil {
+		return err
+	}
+
+	wg := sync.WaitGroup{}
+	for _, setting := range settings {
+		if setting.Disabled || !CanTest(setting.Provider) || len(setting.Setting["apikey"]) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func(setting *provider.Setting) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), validationTimeout)
+			defer cancel()
+
+			kv.Validate(ctx, setting)
+		}(setting)
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+// Validate tests the key of a setting with encrypted secrets, and disables the
+// setting when upstream rejects the key. Upstreams that cannot be reached say
+// nothing about the key, so the setting is left alone.
+func (kv *KeyValidator) Validate(ctx context.Context, setting *provider.Setting) {
+	decrypted, err := kv.decrypt(setting)
+	if err != nil {
+		// a key that cannot be decrypted would be rejected for the wrong reason
+		telemetry.Incr("bricksllm.catalog.key_validator.decrypt_error", nil, 1)
+		kv.log.Sugar().Debugf("decrypting api key of provider setting %s failed: %v", setting.Id, err)
+		return
+	}
+
+	t := TestConnection(ctx, &kv.client, decrypted)
+	telemetry.Incr("bricksllm.catalog.key_validator.results", []string{
+		"provider:" + setting.Provider,
+		"reachable:" + strconv.FormatBool(t.Reachable),
+		"authenticated:" + strconv.FormatBool(t.Authenticated),
+	}, 1)
+
+	if !t.Reachable || t.Authenticated {
+		return
+	}
+
+	disabled, err := kv.sd.DisableSetting(setting.Id, fmt.Sprintf("api key was rejected upstream with status %d", t.StatusCode))
+	if err != nil {
+		telemetry.Incr("bricksllm.catalog.key_validator.disable_setting_error", nil, 1)
+		kv.log.Sugar().Debugf("disabling provider setting %s failed: %v", setting.Id, err)
+		return
+	}
+
+	// another gateway got to disable the setting first
+	if !disabled {
+		return
+	}
+
+	telemetry.Incr("bricksllm.catalog.key_validator.disabled", []string{"provider:" + setting.Provider}, 1)
+	kv.log.Sugar().Infof("provider setting %s is disabled since its api key was rejected by %s", setting.Id, setting.Provider)
+
+	if kv.n == nil || len(kv.alertUrl) == 0 {
+		return
+	}
+
+	kv.n.AlertRevokedKey(kv.alertUrl, &provider.RevokedKeyAlert{
+		ProviderSettingId: setting.Id,
+		Name:              setting.Name,
+		Provider:          setting.Provider,
+		StatusCode:        t.StatusCode,
+		Error:             t.Error,
+		DisabledAt:        time.Now().Unix(),
+	})
+}
+
+func (kv *KeyValidator) decrypt(setting *provider.Setting) (*provider.Setting, error) {
+	copied := *setting
+	copied.Setting = map[string]string{}
+	for k, v := range setting.Setting {
+		copied.Setting[k] = v
+	}
+
+	if kv.d != nil && kv.d.Enabled() {
+		decrypted, err := kv.d.Decrypt(copied.Setting["apikey"], map[string]string{"X-UPDATED-AT": strconv.FormatInt(setting.UpdatedAt, 10)})
+		if err != nil {
+			return nil, err
+		}
+
+		copied.Setting["apikey"] = decrypted
+	}
+
+	return &copied, nil
+}
@@ -0,0 +1,310 @@
+package catalog
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/health"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+const (
+	probeTimeout = 10 * time.Second
+
+	// upstreams failing at least this share of probes are down, and ones
+	// failing any are degraded
+	downErrorRate = 0.5
+)
+
+type SettingStorage interface {
+	GetProviderSettings(withSecret bool, ids []string) ([]*provider.Setting, error)
+}
+
+// SettingHealth is the health of the upstream of a provider setting over the
+// monitoring window. LatencyInMs and Error are those of the latest probe.
+type SettingHealth struct {
+	SettingId      string  `json:"settingId"`
+	Name           string  `json:"name"`
+	Provider       string  `json:"provider"`
+	Status         string  `json:"status"`
+	ErrorRate      float64 `json:"errorRate"`
+	AvgLatencyInMs float64 `json:"avgLatencyInMs"`
+	Samples        int     `json:"samples"`
+	LatencyInMs    int64   `json:"latencyInMs"`
+	Error          string  `json:"error,omitempty"`
+	LastCheckedAt  int64   `json:"lastCheckedAt,omitempty"`
+}
+
+type probeSample struct {
+	at          time.Time
+	latencyInMs int64
+	failed      bool
+	err         string
+}
+
+type settingSamples struct {
+	name     string
+	provider string
+	samples  []probeSample
+}
+
+// HealthMonitor periodically probes the upstream of every provider setting
+// that can be tested, and keeps the outcomes of the probes within a rolling
+// window in memory. Routes use it to try healthy upstreams first.
+type HealthMonitor struct {
+	s        SettingStorage
+	d        Decryptor
+	client   http.Client
+	interval time.Duration
+	window   time.Duration
+	slow     time.Duration
+	done     chan bool
+	log      *zap.Logger
+	lock     sync.RWMutex
+	settings map[string]*settingSamples
+}
+
+func NewHealthMonitor(s SettingStorage, d Decryptor, log *zap.Logger, interval, window, slow time.Duration) *HealthMonitor {
+	return &HealthMonitor{
+		s:        s,
+		d:        d,
+		client:   http.Client{Timeout: probeTimeout},
+		interval: interval,
+		window:   window,
+		slow:     slow,
+		done:     make(chan bool),
+		log:      log,
+		settings: map[string]*settingSamples{},
+	}
+}
+
+func (hm *HealthMonitor) Listen() {
+	if hm.interval <= 0 {
+		hm.log.Info("provider health monitoring is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(hm.interval)
+	hm.log.Info("provider health monitor started")
+
+	go func() {
+		hm.probeAndLog()
+
+		for {
+			select {
+			case <-hm.done:
+				ticker.Stop()
+				hm.log.Info("provider health monitor stopped")
+				return
+			case <-ticker.C:
+				hm.probeAndLog()
+			}
+		}
+	}()
+}
+
+func (hm *HealthMonitor) Stop() {
+	if hm.interval <= 0 {
+		return
+	}
+
+	hm.done <- true
+}
+
+func (hm *HealthMonitor) probeAndLog() {
+	if err := hm.ProbeAll(); err != nil {
+		telemetry.Incr("bricksllm.catalog.health_monitor.probe_error", nil, 1)
+		hm.log.Sugar().Debugf("provider health probes failed: %v", err)
+	}
+}
+
+// ProbeAll probes every provider setting that can be tested concurrently and
+// forgets settings that no longer exist.
+func (hm *HealthMonitor) ProbeAll() error {
+	settings, err := hm.s.GetProviderSettings(true, nil)
+	if err != nil {
+		return err
+	}
+
+	existing := map[string]bool{}
+	wg := sync.WaitGroup{}
+	for _, setting := range settings {
+		existing[setting.Id] = true
+		if !CanTest(setting.Provider) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(setting *provider.Setting) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+			defer cancel()
+
+			hm.Probe(ctx, setting)
+		}(setting)
+	}
+
+	wg.Wait()
+
+	hm.lock.Lock()
+	defer hm.lock.Unlock()
+
+	for id := range hm.settings {
+		if !existing[id] {
+			delete(hm.settings, id)
+		}
+	}
+
+	return nil
+}
+
+// Probe tests the connection of a setting with encrypted secrets and records
+// the outcome.
+func (hm *HealthMonitor) Probe(ctx context.Context, setting *provider.Setting) *SettingHealth {
+	t := TestConnection(ctx, &hm.client, hm.decrypt(setting))
+	hm.record(setting, probeSample{
+		at:          time.Now(),
+		latencyInMs: t.LatencyInMs,
+		failed:      len(t.Error) != 0,
+		err:         t.Error,
+	})
+
+	h := hm.Health(setting.Id)
+	tags := []string{"provider:" + setting.Provider, "setting_id:" + setting.Id}
+	telemetry.Gauge("bricksllm.catalog.health_monitor.error_rate", h.ErrorRate, tags, 1)
+	telemetry.Gauge("bricksllm.catalog.health_monitor.avg_latency_in_ms", h.AvgLatencyInMs, tags, 1)
+
+	up := 1.0
+	if h.Status == health.StatusDown {
+		up = 0
+	}
+
+	telemetry.Gauge("bricksllm.catalog.health_monitor.up", up, tags, 1)
+
+	return h
+}
+
+// decrypt returns a copy of setting with the api key to probe with in plain
+// text. Settings that only have pooled keys are probed with the first one.
+func (hm *HealthMonitor) decrypt(setting *provider.Setting) *provider.Setting {
+	copied := *setting
+	copied.Setting = map[string]string{}
+	for k, v := range setting.Setting {
+		copied.Setting[k] = v
+	}
+
+	apikey := copied.Setting["apikey"]
+	if len(apikey) == 0 && setting.KeyPool != nil && len(setting.KeyPool.Keys) != 0 {
+		apikey = setting.KeyPool.Keys[0].Key
+	}
+
+	if hm.d != nil && hm.d.Enabled() && len(apikey) != 0 {
+		decrypted, err := hm.d.Decrypt(apikey, map[string]string{"X-UPDATED-AT": strconv.FormatInt(setting.UpdatedAt, 10)})
+		if err == nil {
+			apikey = decrypted
+		}
+	}
+
+	if len(apikey) != 0 {
+		copied.Setting["apikey"] = apikey
+	}
+
+	return &copied
+}
+
+func (hm *HealthMonitor) record(setting *provider.Setting, s probeSample) {
+	hm.lock.Lock()
+	defer hm.lock.Unlock()
+
+	ss, ok := hm.settings[setting.Id]
+	if !ok {
+		ss = &settingSamples{}
+		hm.settings[setting.Id] = ss
+	}
+
+	ss.name = setting.Name
+	ss.provider = setting.Provider
+
+	cutoff := s.at.Add(-hm.window)
+	kept := ss.samples[:0]
+	for _, old := range ss.samples {
+		if !old.at.Before(cutoff) {
+			kept = append(kept, old)
+		}
+	}
+
+	ss.samples = append(kept, s)
+}
+
+// Health returns the health of the upstream of a setting. Settings that have
+// not been probed within the window are reported as unknown.
+func (hm *HealthMonitor) Health(id string) *SettingHealth {
+	hm.lock.RLock()
+	defer hm.lock.RUnlock()
+
+	h := &SettingHealth{
+		SettingId: id,
+		Status:    health.StatusUnknown,
+	}
+
+	ss, ok := hm.settings[id]
+	if !ok {
+		return h
+	}
+
+	h.Name = ss.name
+	h.Provider = ss.provider
+
+	cutoff := time.Now().Add(-hm.window)
+	failures, succeeded := 0, 0
+	var total int64 = 0
+	for _, s := range ss.samples {
+		if s.at.Before(cutoff) {
+			continue
+		}
+
+		h.Samples++
+		h.LatencyInMs = s.latencyInMs
+		h.Error = s.err
+		h.LastCheckedAt = s.at.Unix()
+
+		if s.failed {
+			failures++
+			continue
+		}
+
+		succeeded++
+		total += s.latencyInMs
+	}
+
+	if h.Samples == 0 {
+		return h
+	}
+
+	h.ErrorRate = float64(failures) / float64(h.Samples)
+	if succeeded != 0 {
+		h.AvgLatencyInMs = float64(total) / float64(succeeded)
+	}
+
+	switch {
+	case h.ErrorRate >= downErrorRate:
+		h.Status = health.StatusDown
+	case failures != 0 || (hm.slow > 0 && h.AvgLatencyInMs > float64(hm.slow.Milliseconds())):
+		h.Status = health.StatusDegraded
+	default:
+		h.Status = health.StatusOk
+	}
+
+	return h
+}
+
+// Status returns the status of the upstream of a setting.
+func (hm *HealthMonitor) Status(id string) string {
+	return hm.Health(id).Status
+}
@@ -0,0 +1,193 @@
+package catalog
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/bricks-cloud/bricksllm/internal/provider/azure"
+	"github.com/bricks-cloud/bricksllm/internal/provider/bedrock"
+	"github.com/bricks-cloud/bricksllm/internal/provider/cohere"
+	"github.com/bricks-cloud/bricksllm/internal/provider/deepinfra"
+	"github.com/bricks-cloud/bricksllm/internal/provider/groq"
+	"github.com/bricks-cloud/bricksllm/internal/provider/mistral"
+	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
+)
+
+// Pricing is an entry of the managed pricing table. Entries start out with
+// the built in pricing of the gateway and are overridden by admins, after
+// which shipped pricing updates leave them alone. Costs are in USD.
+type Pricing struct {
+	Id                         string  `json:"id"`
+	Provider                   string  `json:"provider"`
+	Model                      string  `json:"model"`
+	ContextWindow              int     `json:"contextWindow"`
+	InputCostPerMillionTokens  float64 `json:"inputCostPerMillionTokens"`
+	OutputCostPerMillionTokens float64 `json:"outputCostPerMillionTokens"`
+	SupportsTools              bool    `json:"supportsTools"`
+	SupportsVision             bool    `json:"supportsVision"`
+	SupportsStreaming          bool    `json:"supportsStreaming"`
+	Overridden                 bool    `json:"overridden"`
+	UpdatedAt                  int64   `json:"updatedAt"`
+}
+
+// UpdatePricing overrides fields of a pricing table entry. Fields that are
+// not set keep their current value.
+type UpdatePricing struct {
+	ContextWindow              *int     `json:"contextWindow"`
+	InputCostPerMillionTokens  *float64 `json:"inputCostPerMillionTokens"`
+	OutputCostPerMillionTokens *float64 `json:"outputCostPerMillionTokens"`
+	SupportsTools              *bool    `json:"supportsTools"`
+	SupportsVision             *bool    `json:"supportsVision"`
+	SupportsStreaming          *bool    `json:"supportsStreaming"`
+}
+
+// Apply sets the fields of u on p.
+func (u *UpdatePricing) Apply(p *Pricing) {
+	if u.ContextWindow != nil {
+		p.ContextWindow = *u.ContextWindow
+	}
+
+	if u.InputCostPerMillionTokens != nil {
+		p.InputCostPerMillionTokens = *u.InputCostPerMillionTokens
+	}
+
+	if u.OutputCostPerMillionTokens != nil {
+		p.OutputCostPerMillionTokens = *u.OutputCostPerMillionTokens
+	}
+
+	if u.SupportsTools != nil {
+		p.SupportsTools = *u.SupportsTools
+	}
+
+	if u.SupportsVision != nil {
+		p.SupportsVision = *u.SupportsVision
+	}
+
+	if u.SupportsStreaming != nil {
+		p.SupportsStreaming = *u.SupportsStreaming
+	}
+}
+
+// Cost prices a request from its token counts.
+func (p *Pricing) Cost(promptTks, completionTks int) float64 {
+	return (float64(promptTks)*p.InputCostPerMillionTokens + float64(completionTks)*p.OutputCostPerMillionTokens) / 1000000
+}
+
+type capabilities struct {
+	contextWindow int
+	tools         bool
+	vision        bool
+	streaming     bool
+}
+
+// knownCapabilities are the capabilities of model families, matched by the
+// longest prefix of the model name. Models without a match are assumed to
+// stream and nothing else.
+var knownCapabilities = map[string]capabilities{
+	"o1":                     {contextWindow: 200000, tools: true, vision: true, streaming: false},
+	"o1-preview":             {contextWindow: 128000, streaming: false},
+	"gpt-4o":                 {contextWindow: 128000, tools: true, vision: true, streaming: true},
+	"gpt-4-turbo":            {contextWindow: 128000, tools: true, vision: true, streaming: true},
+	"gpt-4-1106":             {contextWindow: 128000, tools: true, streaming: true},
+	"gpt-4-0125":             {contextWindow: 128000, tools: true, streaming: true},
+	"gpt-4-vision":           {contextWindow: 128000, vision: true, streaming: true},
+	"gpt-4-1106-vision":      {contextWindow: 128000, vision: true, streaming: true},
+	"gpt-4":                  {contextWindow: 8192, tools: true, streaming: true},
+	"gpt-4-32k":              {contextWindow: 32768, tools: true, streaming: true},
+	"gpt-35-turbo":           {contextWindow: 16385, tools: true, streaming: true},
+	"gpt-3.5-turbo":          {contextWindow: 16385, tools: true, streaming: true},
+	"gpt-3.5-turbo-instruct": {contextWindow: 4096, streaming: true},
+	"text-embedding":         {contextWindow: 8191},
+	"claude-instant":         {contextWindow: 100000, streaming: true},
+	"claude":                 {contextWindow: 100000, streaming: true},
+	"claude-3":               {contextWindow: 200000, tools: true, vision: true, streaming: true},
+	"claude-3.5":             {contextWindow: 200000, tools: true, vision: true, streaming: true},
+	"amazon.titan-text":      {contextWindow: 8192, streaming: true},
+	"amazon.titan-text-lite": {contextWindow: 4096, streaming: true},
+	"mistral-large":          {contextWindow: 128000, tools: true, streaming: true},
+	"mistral-small":          {contextWindow: 32000, tools: true, streaming: true},
+	"mistral-embed":          {contextWindow: 8192},
+	"command-r":              {contextWindow: 128000, tools: true, streaming: true},
+	"embed-":                 {contextWindow: 512},
+	"llama":                  {contextWindow: 8192, streaming: true},
+	"llama-3.1":              {contextWindow: 131072, tools: true, streaming: true},
+	"llama3":                 {contextWindow: 8192, tools: true, streaming: true},
+	"mixtral":                {contextWindow: 32768, tools: true, streaming: true},
+	"gemma":                  {contextWindow: 8192, streaming: true},
+}
+
+func capabilitiesOf(model string) capabilities {
+	matched := ""
+	for prefix := range knownCapabilities {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(matched) {
+			matched = prefix
+		}
+	}
+
+	if len(matched) == 0 {
+		return capabilities{streaming: true}
+	}
+
+	return knownCapabilities[matched]
+}
+
+type costTable struct {
+	provider string
+	costs    map[string]map[string]float64
+	// multiplier turns the costs of the table into costs per million tokens
+	multiplier float64
+}
+
+var costTables = []costTable{
+	{provider: "openai", costs: openai.OpenAiPerThousandTokenCost, multiplier: 1000},
+	{provider: "azure", costs: azure.AzureOpenAiPerThousandTokenCost, multiplier: 1000},
+	{provider: "anthropic", costs: anthropic.AnthropicPerMillionTokenCost, multiplier: 1},
+	{provider: "bedrock", costs: bedrock.TitanPerMillionTokenCost, multiplier: 1},
+	{provider: "cohere", costs: cohere.CoherePerMillionTokenCost, multiplier: 1},
+	{provider: "deepinfra", costs: deepinfra.DeepinfraPerMillionTokenCost, multiplier: 1},
+	{provider: "groq", costs: groq.GroqPerMillionTokenCost, multiplier: 1},
+	{provider: "mistral", costs: mistral.MistralPerMillionTokenCost, multiplier: 1},
+}
+
+// DefaultPricing returns the built in pricing of every model the gateway can
+// price, which seeds the managed pricing table.
+func DefaultPricing() []*Pricing {
+	defaults := []*Pricing{}
+	for _, t := range costTables {
+		models := map[string]*Pricing{}
+		for _, kind := range []string{"prompt", "embeddings"} {
+			for model, cost := range t.costs[kind] {
+				if _, ok := models[model]; ok {
+					continue
+				}
+
+				c := capabilitiesOf(model)
+				models[model] = &Pricing{
+					Provider:                   t.provider,
+					Model:                      model,
+					ContextWindow:              c.contextWindow,
+					InputCostPerMillionTokens:  cost * t.multiplier,
+					OutputCostPerMillionTokens: t.costs["completion"][model] * t.multiplier,
+					SupportsTools:              c.tools,
+					SupportsVision:             c.vision,
+					SupportsStreaming:          c.streaming && kind == "prompt",
+				}
+			}
+		}
+
+		for _, p := range models {
+			defaults = append(defaults, p)
+		}
+	}
+
+	sort.SliceStable(defaults, func(i, j int) bool {
+		if defaults[i].Provider != defaults[j].Provider {
+			return defaults[i].Provider < defaults[j].Provider
+		}
+
+		return defaults[i].Model < defaults[j].Model
+	})
+
+	return defaults
+}
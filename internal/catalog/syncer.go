@@ -0,0 +1,212 @@
+package catalog
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+const listTimeout = 30 * time.Second
+
+type Storage interface {
+	GetProviderSettings(withSecret bool, ids []string) ([]*provider.Setting, error)
+	UpsertCatalogModels(provider string, models []*Model, syncedAt int64) error
+	GetCatalogModels(provider string) ([]*Model, error)
+}
+
+type Decryptor interface {
+	Decrypt(input string, headers map[string]string) (string, error)
+	Enabled() bool
+}
+
+// Syncer periodically copies the models endpoint of every provider that has a
+// provider setting into the catalog table. It keeps the available models in
+// memory so that model validation does not hit the database.
+type Syncer struct {
+	s         Storage
+	d         Decryptor
+	client    http.Client
+	interval  time.Duration
+	done      chan bool
+	log       *zap.Logger
+	lock      sync.RWMutex
+	available map[string]map[string]bool
+}
+
+func NewSyncer(s Storage, d Decryptor, log *zap.Logger, interval time.Duration) (*Syncer, error) {
+	sy := &Syncer{
+		s:        s,
+		d:        d,
+		client:   http.Client{Timeout: listTimeout},
+		interval: interval,
+		done:     make(chan bool),
+		log:      log,
+	}
+
+	if err := sy.load(); err != nil {
+		return nil, err
+	}
+
+	return sy, nil
+}
+
+func (sy *Syncer) load() error {
+	models, err := sy.s.GetCatalogModels("")
+	if err != nil {
+		return err
+	}
+
+	available := map[string]map[string]bool{}
+	for _, m := range models {
+		if _, ok := available[m.Provider]; !ok {
+			available[m.Provider] = map[string]bool{}
+		}
+
+		if m.Available {
+			available[m.Provider][m.Model] = true
+		}
+	}
+
+	sy.lock.Lock()
+	defer sy.lock.Unlock()
+
+	sy.available = available
+
+	return nil
+}
+
+func (sy *Syncer) Listen() {
+	if sy.interval <= 0 {
+		sy.log.Info("model catalog sync is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(sy.interval)
+	sy.log.Info("model catalog syncer started")
+
+	go func() {
+		sy.syncAndLog()
+
+		for {
+			select {
+			case <-sy.done:
+				ticker.Stop()
+				sy.log.Info("model catalog syncer stopped")
+				return
+			case <-ticker.C:
+				sy.syncAndLog()
+			}
+		}
+	}()
+}
+
+func (sy *Syncer) syncAndLog() {
+	if err := sy.Sync(); err != nil {
+		telemetry.Incr("bricksllm.catalog.syncer.sync_error", nil, 1)
+		sy.log.Sugar().Debugf("model catalog sync failed: %v", err)
+	}
+}
+
+// Sync lists the models of every provider with a supported models endpoint.
+// A provider is only updated when at least one of its settings could list
+// models, so an upstream outage never marks its models as unavailable.
+func (sy *Syncer) Sync() error {
+	settings, err := sy.s.GetProviderSettings(true, nil)
+	if err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	listed := map[string]map[string]*Model{}
+	for _, setting := range settings {
+		list, ok := listers[setting.Provider]
+		if !ok {
+			continue
+		}
+
+		decrypted := sy.decrypt(setting)
+		if seen[listerKey(decrypted)] {
+			continue
+		}
+
+		seen[listerKey(decrypted)] = true
+
+		ctx, cancel := context.WithTimeout(context.Background(), listTimeout)
+		models, err := list(ctx, &sy.client, decrypted)
+		cancel()
+
+		if err != nil {
+			telemetry.Incr("bricksllm.catalog.syncer.list_models_error", []string{"provider:" + setting.Provider}, 1)
+			sy.log.Sugar().Debugf("listing models of provider setting %s failed: %v", setting.Id, err)
+			continue
+		}
+
+		if _, ok := listed[setting.Provider]; !ok {
+			listed[setting.Provider] = map[string]*Model{}
+		}
+
+		for _, m := range models {
+			listed[setting.Provider][m.Model] = m
+		}
+	}
+
+	now := time.Now().Unix()
+	for p, byName := range listed {
+		models := make([]*Model, 0, len(byName))
+		for _, m := range byName {
+			models = append(models, m)
+		}
+
+		if err := sy.s.UpsertCatalogModels(p, models, now); err != nil {
+			return err
+		}
+	}
+
+	return sy.load()
+}
+
+func (sy *Syncer) decrypt(setting *provider.Setting) *provider.Setting {
+	copied := *setting
+	copied.Setting = map[string]string{}
+	for k, v := range setting.Setting {
+		copied.Setting[k] = v
+	}
+
+	if sy.d != nil && sy.d.Enabled() && len(copied.Setting["apikey"]) != 0 {
+		decrypted, err := sy.d.Decrypt(copied.Setting["apikey"], map[string]string{"X-UPDATED-AT": strconv.FormatInt(setting.UpdatedAt, 10)})
+		if err == nil {
+			copied.Setting["apikey"] = decrypted
+		}
+	}
+
+	return &copied
+}
+
+// IsUnavailable reports whether the provider has been synced and the model is
+// not listed upstream. Models of providers that were never synced are unknown
+// rather than unavailable.
+func (sy *Syncer) IsUnavailable(provider, model string) bool {
+	sy.lock.RLock()
+	defer sy.lock.RUnlock()
+
+	models, ok := sy.available[provider]
+	if !ok {
+		return false
+	}
+
+	return !models[model]
+}
+
+func (sy *Syncer) Stop() {
+	if sy.interval <= 0 {
+		return
+	}
+
+	sy.done <- true
+}
@@ -0,0 +1,131 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+)
+
+const defaultAzureApiVersion = "2024-06-01"
+
+// ConnectionTest is the outcome of listing the models of a provider setting.
+// A server that answered is Reachable, and it is Authenticated unless it
+// rejected the credentials of the setting.
+type ConnectionTest struct {
+	Provider      string `json:"provider"`
+	Reachable     bool   `json:"reachable"`
+	Authenticated bool   `json:"authenticated"`
+	StatusCode    int    `json:"statusCode,omitempty"`
+	LatencyInMs   int64  `json:"latencyInMs"`
+	ModelCount    int    `json:"modelCount"`
+	Error         string `json:"error,omitempty"`
+}
+
+// testers can check more providers than the catalog syncs, since azure lists
+// base models rather than the deployments that clients call.
+var testers = map[string]lister{
+	"azure": listAzure,
+}
+
+func testerOf(providerName string) (lister, bool) {
+	if list, ok := testers[providerName]; ok {
+		return list, true
+	}
+
+	list, ok := listers[providerName]
+	return list, ok
+}
+
+// CanTest reports whether the connection of settings of a provider can be
+// tested.
+func CanTest(providerName string) bool {
+	_, ok := testerOf(providerName)
+	return ok
+}
+
+// TestConnection lists the models of a setting, which is cheap and needs the
+// same credentials as the rest of the api. The secrets of the setting have to
+// be decrypted.
+func TestConnection(ctx context.Context, client *http.Client, setting *provider.Setting) *ConnectionTest {
+	t := &ConnectionTest{
+		Provider: setting.Provider,
+	}
+
+	list, ok := testerOf(setting.Provider)
+	if !ok {
+		t.Error = fmt.Sprintf("connection test is not supported for provider %s", setting.Provider)
+		return t
+	}
+
+	start := time.Now()
+	models, err := list(ctx, client, setting)
+	t.LatencyInMs = time.Since(start).Milliseconds()
+
+	if err == nil {
+		t.Reachable = true
+		t.Authenticated = true
+		t.StatusCode = http.StatusOK
+		t.ModelCount = len(models)
+		return t
+	}
+
+	t.Error = err.Error()
+
+	se := &StatusError{}
+	if errors.As(err, &se) {
+		t.Reachable = true
+		t.StatusCode = se.StatusCode
+		t.Authenticated = se.StatusCode != http.StatusUnauthorized && se.StatusCode != http.StatusForbidden
+	}
+
+	return t
+}
+
+type azureModelList struct {
+	Data []struct {
+		Id string `json:"id"`
+	} `json:"data"`
+}
+
+func listAzure(ctx context.Context, client *http.Client, setting *provider.Setting) ([]*Model, error) {
+	resourceName := setting.Setting["resourceName"]
+	if len(resourceName) == 0 {
+		return nil, fmt.Errorf("azure provider setting %s has no resource name", setting.Id)
+	}
+
+	apiVersion := setting.Setting["apiVersion"]
+	if len(apiVersion) == 0 {
+		apiVersion = defaultAzureApiVersion
+	}
+
+	q := url.Values{}
+	q.Set("api-version", apiVersion)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://%s.openai.azure.com/openai/models?%s", resourceName, q.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("api-key", setting.Setting["apikey"])
+
+	list := &azureModelList{}
+	if err := getJSON(client, req, list); err != nil {
+		return nil, err
+	}
+
+	models := []*Model{}
+	for _, d := range list.Data {
+		models = append(models, &Model{
+			Provider: setting.Provider,
+			Model:    d.Id,
+			OwnedBy:  "azure",
+		})
+	}
+
+	return models, nil
+}
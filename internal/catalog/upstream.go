@@ -0,0 +1,206 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+)
+
+const anthropicVersion = "2023-06-01"
+
+// lister fetches the models a provider setting has access to.
+type lister func(ctx context.Context, client *http.Client, setting *provider.Setting) ([]*Model, error)
+
+var listers = map[string]lister{
+	"openai":      listOpenAiCompatible("https://api.openai.com/v1/models"),
+	"deepinfra":   listOpenAiCompatible("https://api.deepinfra.com/v1/openai/models"),
+	"vllm":        listSelfHosted,
+	"anthropic":   listAnthropic,
+	"mistral":     listOpenAiCompatible("https://api.mistral.ai/v1/models"),
+	"groq":        listOpenAiCompatible("https://api.groq.com/openai/v1/models"),
+	"cohere":      listCohere,
+	"self-hosted": listSelfHosted,
+}
+
+// listerKey identifies settings that would list the same models so that each
+// upstream is only asked once per sync.
+func listerKey(setting *provider.Setting) string {
+	return setting.Provider + "|" + setting.Setting["url"] + "|" + setting.Setting["apikey"]
+}
+
+type openAiModelList struct {
+	Data []struct {
+		Id      string `json:"id"`
+		OwnedBy string `json:"owned_by"`
+	} `json:"data"`
+}
+
+func listOpenAiCompatible(endpoint string) lister {
+	return func(ctx context.Context, client *http.Client, setting *provider.Setting) ([]*Model, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if apikey := setting.Setting["apikey"]; len(apikey) != 0 {
+			req.Header.Set("Authorization", "Bearer "+apikey)
+		}
+
+		list := &openAiModelList{}
+		if err := getJSON(client, req, list); err != nil {
+			return nil, err
+		}
+
+		models := []*Model{}
+		for _, d := range list.Data {
+			models = append(models, &Model{
+				Provider: setting.Provider,
+				Model:    d.Id,
+				OwnedBy:  d.OwnedBy,
+			})
+		}
+
+		return models, nil
+	}
+}
+
+// listSelfHosted lists the models of a server that the setting points at,
+// such as vllm or ollama.
+func listSelfHosted(ctx context.Context, client *http.Client, setting *provider.Setting) ([]*Model, error) {
+	base := strings.TrimSuffix(setting.Setting["url"], "/")
+	if len(base) == 0 {
+		return nil, fmt.Errorf("%s provider setting %s has no url", setting.Provider, setting.Id)
+	}
+
+	return listOpenAiCompatible(base+"/v1/models")(ctx, client, setting)
+}
+
+type anthropicModelList struct {
+	Data []struct {
+		Id string `json:"id"`
+	} `json:"data"`
+	HasMore bool   `json:"has_more"`
+	LastId  string `json:"last_id"`
+}
+
+func listAnthropic(ctx context.Context, client *http.Client, setting *provider.Setting) ([]*Model, error) {
+	models := []*Model{}
+	after := ""
+
+	for {
+		q := url.Values{}
+		q.Set("limit", "1000")
+		if len(after) != 0 {
+			q.Set("after_id", after)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.anthropic.com/v1/models?"+q.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("x-api-key", setting.Setting["apikey"])
+		req.Header.Set("anthropic-version", anthropicVersion)
+
+		list := &anthropicModelList{}
+		if err := getJSON(client, req, list); err != nil {
+			return nil, err
+		}
+
+		for _, d := range list.Data {
+			models = append(models, &Model{
+				Provider: setting.Provider,
+				Model:    d.Id,
+				OwnedBy:  "anthropic",
+			})
+		}
+
+		if !list.HasMore || len(list.LastId) == 0 {
+			return models, nil
+		}
+
+		after = list.LastId
+	}
+}
+
+type cohereModelList struct {
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+	NextPageToken string `json:"next_page_token"`
+}
+
+func listCohere(ctx context.Context, client *http.Client, setting *provider.Setting) ([]*Model, error) {
+	models := []*Model{}
+	token := ""
+
+	for {
+		q := url.Values{}
+		q.Set("page_size", "1000")
+		if len(token) != 0 {
+			q.Set("page_token", token)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.cohere.com/v1/models?"+q.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Authorization", "Bearer "+setting.Setting["apikey"])
+
+		list := &cohereModelList{}
+		if err := getJSON(client, req, list); err != nil {
+			return nil, err
+		}
+
+		for _, m := range list.Models {
+			models = append(models, &Model{
+				Provider: setting.Provider,
+				Model:    m.Name,
+				OwnedBy:  "cohere",
+			})
+		}
+
+		if len(list.NextPageToken) == 0 {
+			return models, nil
+		}
+
+		token = list.NextPageToken
+	}
+}
+
+// StatusError is returned when a models endpoint responds with a status
+// other than 200.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("listing models returned status %d: %s", e.StatusCode, e.Body)
+}
+
+func getJSON(client *http.Client, req *http.Request, v any) error {
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return &StatusError{StatusCode: res.StatusCode, Body: string(data)}
+	}
+
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,170 @@
+package change
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+const (
+	KindKey             = "key"
+	KindPolicy          = "policy"
+	KindRoute           = "route"
+	KindProviderSetting = "providerSetting"
+	KindPricing         = "pricing"
+	KindModelAlias      = "modelAlias"
+
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionReplace = "replace"
+	ActionDelete  = "delete"
+)
+
+// DefaultBacklog is the number of recent changes a hub retains for resuming
+// subscribers and long polls.
+const DefaultBacklog = 1000
+
+// subscriberBuffer bounds how far a subscriber may fall behind before it is
+// dropped. A dropped subscriber reconnects and catches up from the backlog.
+const subscriberBuffer = 64
+
+// Change describes a write made through the admin API. Seq increases with every
+// change and is used as the event id of the stream.
+type Change struct {
+	Seq       int64  `json:"seq"`
+	CreatedAt int64  `json:"createdAt"`
+	Kind      string `json:"kind"`
+	Action    string `json:"action"`
+	Id        string `json:"id"`
+	Namespace string `json:"namespace"`
+}
+
+// Batch is the answer to a long poll for changes. Reset is set when the
+// changes since the requested version are no longer known, in which case the
+// client has to reload the whole config and continue from Version.
+type Batch struct {
+	Version int64     `json:"version"`
+	Reset   bool      `json:"reset"`
+	Changes []*Change `json:"changes"`
+}
+
+// Hub fans out changes to stream subscribers and keeps the most recent ones so
+// that a reconnecting subscriber can resume from the last change it received.
+type Hub struct {
+	lock        sync.Mutex
+	seq         int64
+	size        int
+	recent      []*Change
+	subscribers map[chan *Change]bool
+	listeners   []func(*Change)
+}
+
+func NewHub(size int) *Hub {
+	return &Hub{
+		size:        size,
+		recent:      []*Change{},
+		subscribers: map[chan *Change]bool{},
+	}
+}
+
+func (h *Hub) Publish(kind, action, id, namespace string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.seq++
+	c := &Change{
+		Seq:       h.seq,
+		CreatedAt: time.Now().Unix(),
+		Kind:      kind,
+		Action:    action,
+		Id:        id,
+		Namespace: namespace,
+	}
+
+	h.recent = append(h.recent, c)
+	if len(h.recent) > h.size {
+		h.recent = h.recent[len(h.recent)-h.size:]
+	}
+
+	for _, fn := range h.listeners {
+		fn(c)
+	}
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- c:
+		default:
+			telemetry.Incr("bricksllm.change.hub.subscriber_dropped", nil, 1)
+			delete(h.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// OnPublish registers fn to be called with every published change. It runs
+// while the hub is locked and must not block.
+func (h *Hub) OnPublish(fn func(*Change)) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.listeners = append(h.listeners, fn)
+}
+
+// Since returns the retained changes after seq together with the latest seq.
+// It reports false when the caller cannot catch up from the retained changes,
+// either because some were evicted or because seq is ahead of the hub, which
+// happens when the server restarted, and has to resync from scratch instead.
+func (h *Hub) Since(after int64) ([]*Change, int64, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if after > h.seq {
+		return []*Change{}, h.seq, false
+	}
+
+	if len(h.recent) != 0 && after < h.recent[0].Seq-1 {
+		return []*Change{}, h.seq, false
+	}
+
+	changes := []*Change{}
+	for _, c := range h.recent {
+		if c.Seq > after {
+			changes = append(changes, c)
+		}
+	}
+
+	return changes, h.seq, true
+}
+
+// Subscribe returns the retained changes after seq together with a channel of
+// the changes published from now on. The channel is closed when the subscriber
+// falls too far behind or unsubscribes.
+func (h *Hub) Subscribe(after int64) ([]*Change, chan *Change) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	backlog := []*Change{}
+	if after > 0 {
+		for _, c := range h.recent {
+			if c.Seq > after {
+				backlog = append(backlog, c)
+			}
+		}
+	}
+
+	ch := make(chan *Change, subscriberBuffer)
+	h.subscribers[ch] = true
+
+	return backlog, ch
+}
+
+func (h *Hub) Unsubscribe(ch chan *Change) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.subscribers[ch] {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+}
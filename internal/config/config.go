@@ -38,10 +38,57 @@ type Config struct {
 	PrometheusEnabled             bool          `koanf:"prometheus_enabled" env:"PROMETHEUS_ENABLED" envDefault:"true"`
 	PrometheusPort                string        `koanf:"prometheus_port" env:"PROMETHEUS_PORT" envDefault:"2112"`
 	AdminPass                     string        `koanf:"admin_pass" env:"ADMIN_PASS"`
+	AdminHost                     string        `koanf:"admin_host" env:"ADMIN_HOST"`
+	AdminPort                     string        `koanf:"admin_port" env:"ADMIN_PORT" envDefault:"8001"`
+	AdminTlsCertFile              string        `koanf:"admin_tls_cert_file" env:"ADMIN_TLS_CERT_FILE"`
+	AdminTlsKeyFile               string        `koanf:"admin_tls_key_file" env:"ADMIN_TLS_KEY_FILE"`
+	AdminTlsClientCaFile          string        `koanf:"admin_tls_client_ca_file" env:"ADMIN_TLS_CLIENT_CA_FILE"`
+	AdminIdempotencyKeyTtl        time.Duration `koanf:"admin_idempotency_key_ttl" env:"ADMIN_IDEMPOTENCY_KEY_TTL" envDefault:"24h"`
+	AdminRateLimit                int           `koanf:"admin_rate_limit" env:"ADMIN_RATE_LIMIT" envDefault:"600"`
+	AdminLockoutThreshold         int           `koanf:"admin_lockout_threshold" env:"ADMIN_LOCKOUT_THRESHOLD" envDefault:"5"`
+	AdminLockoutDuration          time.Duration `koanf:"admin_lockout_duration" env:"ADMIN_LOCKOUT_DURATION" envDefault:"1m"`
+	AdminCorsAllowedOrigins       []string      `koanf:"admin_cors_allowed_origins" env:"ADMIN_CORS_ALLOWED_ORIGINS" envSeparator:","`
+	AdminCorsAllowedHeaders       []string      `koanf:"admin_cors_allowed_headers" env:"ADMIN_CORS_ALLOWED_HEADERS" envSeparator:"," envDefault:"Content-Type,X-API-KEY,Idempotency-Key,If-None-Match,If-Match,X-Namespace"`
+	AdminCorsAllowedMethods       []string      `koanf:"admin_cors_allowed_methods" env:"ADMIN_CORS_ALLOWED_METHODS" envSeparator:"," envDefault:"GET,POST,PUT,PATCH,DELETE,OPTIONS"`
+	AdminMaxBodySize              int64         `koanf:"admin_max_body_size" env:"ADMIN_MAX_BODY_SIZE" envDefault:"10485760"`
+	AdminRouteMaxBodySizes        []string      `koanf:"admin_route_max_body_sizes" env:"ADMIN_ROUTE_MAX_BODY_SIZES" envSeparator:","`
+	HealthCheckTimeout            time.Duration `koanf:"health_check_timeout" env:"HEALTH_CHECK_TIMEOUT" envDefault:"2s"`
+	HealthCheckSlowThreshold      time.Duration `koanf:"health_check_slow_threshold" env:"HEALTH_CHECK_SLOW_THRESHOLD" envDefault:"500ms"`
+	ModelCatalogSyncInterval      time.Duration `koanf:"model_catalog_sync_interval" env:"MODEL_CATALOG_SYNC_INTERVAL" envDefault:"1h"`
+	ProviderHealthCheckInterval   time.Duration `koanf:"provider_health_check_interval" env:"PROVIDER_HEALTH_CHECK_INTERVAL" envDefault:"1m"`
+	ProviderHealthCheckWindow     time.Duration `koanf:"provider_health_check_window" env:"PROVIDER_HEALTH_CHECK_WINDOW" envDefault:"10m"`
+	ProviderHealthSlowThreshold   time.Duration `koanf:"provider_health_check_slow_threshold" env:"PROVIDER_HEALTH_CHECK_SLOW_THRESHOLD" envDefault:"3s"`
+	BatchReconcileInterval        time.Duration `koanf:"batch_reconcile_interval" env:"BATCH_RECONCILE_INTERVAL" envDefault:"5m"`
+	FineTuningReconcileInterval   time.Duration `koanf:"fine_tuning_reconcile_interval" env:"FINE_TUNING_RECONCILE_INTERVAL" envDefault:"5m"`
+	KeyValidationInterval         time.Duration `koanf:"key_validation_interval" env:"KEY_VALIDATION_INTERVAL" envDefault:"1h"`
+	KeyValidationAlertUrl         string        `koanf:"key_validation_alert_url" env:"KEY_VALIDATION_ALERT_URL"`
+	GatewayId                     string        `koanf:"gateway_id" env:"GATEWAY_ID"`
 	ProxyTimeout                  time.Duration `koanf:"proxy_timeout" env:"PROXY_TIMEOUT" envDefault:"600s"`
+	ProxyDisconnectStormThreshold int           `koanf:"proxy_disconnect_storm_threshold" env:"PROXY_DISCONNECT_STORM_THRESHOLD" envDefault:"20"`
+	ProxyDisconnectStormRatio     float64       `koanf:"proxy_disconnect_storm_ratio" env:"PROXY_DISCONNECT_STORM_RATIO" envDefault:"0.5"`
+	ProxyDisconnectStormWindow    time.Duration `koanf:"proxy_disconnect_storm_window" env:"PROXY_DISCONNECT_STORM_WINDOW" envDefault:"1m"`
+	ProxyDisconnectStormCooldown  time.Duration `koanf:"proxy_disconnect_storm_cooldown" env:"PROXY_DISCONNECT_STORM_COOLDOWN" envDefault:"30s"`
+	ProxyDrainRetryAfter          time.Duration `koanf:"proxy_drain_retry_after" env:"PROXY_DRAIN_RETRY_AFTER" envDefault:"30s"`
+	ProxyQuotaWarningThresholds   []float64     `koanf:"proxy_quota_warning_thresholds" env:"PROXY_QUOTA_WARNING_THRESHOLDS" envSeparator:","`
+	ProxySseMaxLineSize           int           `koanf:"proxy_sse_max_line_size" env:"PROXY_SSE_MAX_LINE_SIZE" envDefault:"8388608"`
+	ToolBrokerMaxRounds           int           `koanf:"tool_broker_max_rounds" env:"TOOL_BROKER_MAX_ROUNDS" envDefault:"5"`
+	ToolBrokerTimeout             time.Duration `koanf:"tool_broker_timeout" env:"TOOL_BROKER_TIMEOUT" envDefault:"10s"`
+	KeySecretPrefix               string        `koanf:"key_secret_prefix" env:"KEY_SECRET_PREFIX"`
+	KeySecretChecksum             bool          `koanf:"key_secret_checksum" env:"KEY_SECRET_CHECKSUM" envDefault:"false"`
+	FxRates                       []string      `koanf:"fx_rates" env:"FX_RATES" envSeparator:","`
+	FxRatesUrl                    string        `koanf:"fx_rates_url" env:"FX_RATES_URL"`
+	FxRatesRefreshInterval        time.Duration `koanf:"fx_rates_refresh_interval" env:"FX_RATES_REFRESH_INTERVAL" envDefault:"1h"`
+	ReportingCurrency             string        `koanf:"reporting_currency" env:"REPORTING_CURRENCY" envDefault:"USD"`
 	NumberOfEventMessageConsumers int           `koanf:"number_of_event_message_consumers" env:"NUMBER_OF_EVENT_MESSAGE_CONSUMERS" envDefault:"3"`
 	OpenAiApiKey                  string        `koanf:"openai_api_key" env:"OPENAI_API_KEY"`
 	CustomPolicyDetectionTimeout  time.Duration `koanf:"custom_policy_detection_timeout" env:"CUSTOM_POLICY_DETECTION_TIMEOUT" envDefault:"10m"`
+	ModerationTimeout             time.Duration `koanf:"moderation_timeout" env:"MODERATION_TIMEOUT" envDefault:"5s"`
+	AzureContentSafetyKey         string        `koanf:"azure_content_safety_key" env:"AZURE_CONTENT_SAFETY_KEY"`
+	ModerationWebhookSecret       string        `koanf:"moderation_webhook_secret" env:"MODERATION_WEBHOOK_SECRET"`
+	PiiTokenSecret                string        `koanf:"pii_token_secret" env:"PII_TOKEN_SECRET"`
+	GuardrailTlsCertFile          string        `koanf:"guardrail_tls_cert_file" env:"GUARDRAIL_TLS_CERT_FILE"`
+	GuardrailTlsKeyFile           string        `koanf:"guardrail_tls_key_file" env:"GUARDRAIL_TLS_KEY_FILE"`
+	GuardrailTlsCaFile            string        `koanf:"guardrail_tls_ca_file" env:"GUARDRAIL_TLS_CA_FILE"`
 	AmazonRegion                  string        `koanf:"amazon_region" env:"AMAZON_REGION" envDefault:"us-west-2"`
 	AmazonRequestTimeout          time.Duration `koanf:"amazon_request_timeout" env:"AMAZON_REQUEST_TIMEOUT" envDefault:"5s"`
 	AmazonConnectionTimeout       time.Duration `koanf:"amazon_connection_timeout" env:"AMAZON_CONNECTION_TIMEOUT" envDefault:"10s"`
@@ -51,6 +98,12 @@ type Config struct {
 	DecryptionEndpoint            string        `koanf:"decryption_endpoint" env:"DECRYPTION_ENDPOINT"`
 	EncryptionTimeout             time.Duration `koanf:"encryption_timeout" env:"ENCRYPTION_TIMEOUT" envDefault:"5s"`
 	Audience                      string        `koanf:"audience" env:"AUDIENCE"`
+	KmsProvider                   string        `koanf:"kms_provider" env:"KMS_PROVIDER"`
+	KmsKeyId                      string        `koanf:"kms_key_id" env:"KMS_KEY_ID"`
+	KmsAwsRegion                  string        `koanf:"kms_aws_region" env:"KMS_AWS_REGION" envDefault:"us-west-2"`
+	VaultAddress                  string        `koanf:"vault_address" env:"VAULT_ADDRESS"`
+	VaultToken                    string        `koanf:"vault_token" env:"VAULT_TOKEN"`
+	VaultTransitMount             string        `koanf:"vault_transit_mount" env:"VAULT_TRANSIT_MOUNT" envDefault:"transit"`
 }
 
 func prepareDotEnv(envFilePath string) error {
@@ -107,5 +160,29 @@ func LoadConfig(log *zap.Logger) (*Config, error) {
 		k.Unmarshal("", cfg)
 	}
 
+	if len(cfg.KmsProvider) != 0 && cfg.KmsProvider != "aws" && cfg.KmsProvider != "gcp" && cfg.KmsProvider != "vault" {
+		return nil, errors.New("kms provider must be one of aws, gcp or vault")
+	}
+
+	if len(cfg.KmsProvider) != 0 && len(cfg.KmsKeyId) == 0 {
+		return nil, errors.New("kms key id cannot be empty")
+	}
+
+	if cfg.KmsProvider == "vault" && (len(cfg.VaultAddress) == 0 || len(cfg.VaultToken) == 0) {
+		return nil, errors.New("vault address and token must be specified for the vault kms provider")
+	}
+
+	if (len(cfg.AdminTlsCertFile) == 0) != (len(cfg.AdminTlsKeyFile) == 0) {
+		return nil, errors.New("admin tls cert file and key file must be specified together")
+	}
+
+	if len(cfg.AdminTlsClientCaFile) != 0 && len(cfg.AdminTlsCertFile) == 0 {
+		return nil, errors.New("admin tls client ca file requires admin tls cert file and key file")
+	}
+
+	if (len(cfg.GuardrailTlsCertFile) == 0) != (len(cfg.GuardrailTlsKeyFile) == 0) {
+		return nil, errors.New("guardrail tls cert file and key file must be specified together")
+	}
+
 	return cfg, nil
 }
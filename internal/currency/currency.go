@@ -0,0 +1,223 @@
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+// USD is the currency that costs are estimated and limited in.
+const USD = "USD"
+
+var codeRegex = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Normalize upper cases an ISO 4217 code. An empty code is USD.
+func Normalize(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) == 0 {
+		return USD
+	}
+
+	return code
+}
+
+// Valid reports whether code looks like an ISO 4217 code.
+func Valid(code string) bool {
+	return codeRegex.MatchString(Normalize(code))
+}
+
+// ParseRates parses rates in the form of CODE=rate, where rate is the amount of
+// the currency that one USD buys.
+func ParseRates(entries []string) (map[string]float64, error) {
+	rates := map[string]float64{}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+
+		code, rate, found := strings.Cut(entry, "=")
+		if !found || !Valid(code) {
+			return nil, fmt.Errorf("fx rate %s is not in the form of CODE=rate", entry)
+		}
+
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("fx rate %s does not have a positive rate", entry)
+		}
+
+		rates[Normalize(code)] = parsed
+	}
+
+	return rates, nil
+}
+
+// ratesResponse is the body expected from the rates url. Rates are quoted
+// against USD, which is what most public FX APIs return for a USD base.
+type ratesResponse struct {
+	Rates map[string]float64 `json:"rates"`
+}
+
+// Converter converts amounts between USD and other currencies. Fixed rates
+// come from configuration. When a rates url is configured, rates fetched from
+// it are refreshed on an interval and used for currencies without a fixed
+// rate, so that a pinned contract rate always wins.
+type Converter struct {
+	lock     sync.RWMutex
+	fixed    map[string]float64
+	fetched  map[string]float64
+	url      string
+	client   http.Client
+	interval time.Duration
+	done     chan bool
+	started  bool
+	log      *zap.Logger
+}
+
+func NewConverter(fixed map[string]float64, url string, interval time.Duration, log *zap.Logger) *Converter {
+	c := &Converter{
+		fixed:    fixed,
+		fetched:  map[string]float64{},
+		url:      url,
+		client:   http.Client{Timeout: 10 * time.Second},
+		interval: interval,
+		done:     make(chan bool),
+		log:      log,
+	}
+
+	if len(url) != 0 {
+		if err := c.fetch(); err != nil {
+			telemetry.Incr("bricksllm.currency.converter.fetch_error", nil, 1)
+			log.Sugar().Infof("cannot fetch fx rates, falling back to fixed rates: %v", err)
+		}
+	}
+
+	return c
+}
+
+func (c *Converter) fetch() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.client.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("fx rates url responded with status %d", res.StatusCode)
+	}
+
+	parsed := &ratesResponse{}
+	if err := json.NewDecoder(res.Body).Decode(parsed); err != nil {
+		return err
+	}
+
+	fetched := map[string]float64{}
+	for code, rate := range parsed.Rates {
+		if rate > 0 && Valid(code) {
+			fetched[Normalize(code)] = rate
+		}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.fetched = fetched
+
+	return nil
+}
+
+// Listen refreshes fetched rates until Stop is called. It does nothing when no
+// rates url is configured.
+func (c *Converter) Listen() {
+	if len(c.url) == 0 {
+		return
+	}
+
+	c.started = true
+	ticker := time.NewTicker(c.interval)
+	c.log.Info("fx converter started refreshing rates")
+
+	go func() {
+		for {
+			select {
+			case <-c.done:
+				ticker.Stop()
+				c.log.Info("fx converter stopped")
+				return
+			case <-ticker.C:
+				if err := c.fetch(); err != nil {
+					telemetry.Incr("bricksllm.currency.converter.fetch_error", nil, 1)
+					c.log.Sugar().Debugf("fx converter failed to refresh rates: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (c *Converter) Stop() {
+	if c.started {
+		c.done <- true
+	}
+}
+
+// Rate returns the amount of the currency that one USD buys.
+func (c *Converter) Rate(code string) (float64, error) {
+	code = Normalize(code)
+	if code == USD {
+		return 1, nil
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if rate, ok := c.fixed[code]; ok {
+		return rate, nil
+	}
+
+	if rate, ok := c.fetched[code]; ok {
+		return rate, nil
+	}
+
+	return 0, fmt.Errorf("no fx rate is available for %s", code)
+}
+
+// Supported reports whether amounts can be converted into code.
+func (c *Converter) Supported(code string) bool {
+	_, err := c.Rate(code)
+	return err == nil
+}
+
+func (c *Converter) FromUsd(amount float64, code string) (float64, error) {
+	rate, err := c.Rate(code)
+	if err != nil {
+		return 0, err
+	}
+
+	return amount * rate, nil
+}
+
+func (c *Converter) ToUsd(amount float64, code string) (float64, error) {
+	rate, err := c.Rate(code)
+	if err != nil {
+		return 0, err
+	}
+
+	return amount / rate, nil
+}
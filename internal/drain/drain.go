@@ -0,0 +1,127 @@
+package drain
+
+import (
+	"sync"
+	"time"
+)
+
+// Status reports the progress of a drain. A drain is complete once no request
+// that was admitted before it started is still in flight.
+type Status struct {
+	Draining            bool  `json:"draining"`
+	Drained             bool  `json:"drained"`
+	StartedAt           int64 `json:"startedAt,omitempty"`
+	InFlight            int64 `json:"inFlight"`
+	InFlightAtStart     int64 `json:"inFlightAtStart"`
+	Rejected            int64 `json:"rejected"`
+	RetryAfterInSeconds int64 `json:"retryAfterInSeconds"`
+}
+
+// Drainer tracks the requests in flight on the proxy and, once draining,
+// stops admitting new ones so that the instance can be taken out of rotation
+// without cutting off long running streams.
+type Drainer struct {
+	lock            sync.Mutex
+	draining        bool
+	startedAt       time.Time
+	inFlight        int64
+	inFlightAtStart int64
+	rejected        int64
+	retryAfter      time.Duration
+}
+
+func NewDrainer(retryAfter time.Duration) *Drainer {
+	return &Drainer{
+		retryAfter: retryAfter,
+	}
+}
+
+// Admit reserves a slot for a new request. It returns false while draining,
+// in which case the request must be rejected and Done must not be called.
+func (d *Drainer) Admit() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.draining {
+		d.rejected++
+		return false
+	}
+
+	d.inFlight++
+	return true
+}
+
+// Done releases the slot reserved by a successful Admit.
+func (d *Drainer) Done() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.inFlight > 0 {
+		d.inFlight--
+	}
+}
+
+// RetryAfter is how long rejected clients are asked to wait before retrying.
+func (d *Drainer) RetryAfter() time.Duration {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return d.retryAfter
+}
+
+// Start puts the proxy into drain mode. Starting a drain that is already in
+// progress keeps its original start time. A positive retryAfter replaces the
+// configured one.
+func (d *Drainer) Start(retryAfter time.Duration) *Status {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if retryAfter > 0 {
+		d.retryAfter = retryAfter
+	}
+
+	if !d.draining {
+		d.draining = true
+		d.startedAt = time.Now()
+		d.inFlightAtStart = d.inFlight
+		d.rejected = 0
+	}
+
+	return d.status()
+}
+
+// Stop resumes admitting requests.
+func (d *Drainer) Stop() *Status {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.draining = false
+	d.startedAt = time.Time{}
+	d.inFlightAtStart = 0
+
+	return d.status()
+}
+
+func (d *Drainer) Status() *Status {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return d.status()
+}
+
+func (d *Drainer) status() *Status {
+	s := &Status{
+		Draining:            d.draining,
+		Drained:             d.draining && d.inFlight == 0,
+		InFlight:            d.inFlight,
+		InFlightAtStart:     d.inFlightAtStart,
+		Rejected:            d.rejected,
+		RetryAfterInSeconds: int64(d.retryAfter.Seconds()),
+	}
+
+	if d.draining {
+		s.StartedAt = d.startedAt.Unix()
+	}
+
+	return s
+}
@@ -0,0 +1,100 @@
+package dryrun
+
+const (
+	ActionDelete = "delete"
+	ActionUpdate = "update"
+)
+
+// Result describes what a destructive admin operation would affect had it not
+// been a dry run.
+type Result struct {
+	DryRun   bool     `json:"dryRun"`
+	Action   string   `json:"action"`
+	Resource string   `json:"resource"`
+	Count    int      `json:"count"`
+	Ids      []string `json:"ids"`
+
+	// Dependents lists the resources that still reference the ones being
+	// deleted. They are deleted along with them when Cascade is set, otherwise
+	// the deletion is Blocked and would be rejected with a conflict.
+	Dependents []*Dependent `json:"dependents,omitempty"`
+	Cascade    bool         `json:"cascade,omitempty"`
+	Blocked    bool         `json:"blocked,omitempty"`
+}
+
+// Dependent groups the ids of one kind of resource that depend on a deleted
+// resource.
+type Dependent struct {
+	Resource string   `json:"resource"`
+	Ids      []string `json:"ids"`
+}
+
+func NewResult(action, resource string, ids []string) *Result {
+	if ids == nil {
+		ids = []string{}
+	}
+
+	return &Result{
+		DryRun:   true,
+		Action:   action,
+		Resource: resource,
+		Count:    len(ids),
+		Ids:      ids,
+	}
+}
+
+// NewDeleteResult reports a deletion together with its dependents.
+func NewDeleteResult(resource string, ids []string, dependents []*Dependent, cascade bool) *Result {
+	r := NewResult(ActionDelete, resource, ids)
+	r.Dependents = dependents
+	r.Cascade = cascade
+	r.Blocked = !cascade && len(dependents) != 0
+
+	return r
+}
+
+// AddDependents merges ids into the dependents of the given resource, leaving
+// out ids that are already listed. Empty id lists are ignored.
+func AddDependents(dependents []*Dependent, resource string, ids []string) []*Dependent {
+	if len(ids) == 0 {
+		return dependents
+	}
+
+	var existing *Dependent
+	for _, d := range dependents {
+		if d.Resource == resource {
+			existing = d
+			break
+		}
+	}
+
+	if existing == nil {
+		existing = &Dependent{Resource: resource, Ids: []string{}}
+		dependents = append(dependents, existing)
+	}
+
+	seen := map[string]bool{}
+	for _, id := range existing.Ids {
+		seen[id] = true
+	}
+
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			existing.Ids = append(existing.Ids, id)
+		}
+	}
+
+	return dependents
+}
+
+// IdsOf returns the ids of the dependents of the given resource.
+func IdsOf(dependents []*Dependent, resource string) []string {
+	for _, d := range dependents {
+		if d.Resource == resource {
+			return d.Ids
+		}
+	}
+
+	return nil
+}
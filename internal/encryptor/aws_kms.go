@@ -0,0 +1,117 @@
+package encryptor
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// AwsKms wraps data keys with an AWS KMS key. Credentials are taken from the
+// default credential chain of the gateway.
+type AwsKms struct {
+	keyId    string
+	region   string
+	endpoint string
+	creds    aws.CredentialsProvider
+	signer   *v4.Signer
+	client   *http.Client
+}
+
+func NewAwsKms(ctx context.Context, keyId, region string) (*AwsKms, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, err
+	}
+
+	return &AwsKms{
+		keyId:    keyId,
+		region:   region,
+		endpoint: fmt.Sprintf("https://kms.%s.amazonaws.com/", region),
+		creds:    cfg.Credentials,
+		signer:   v4.NewSigner(),
+		client:   &http.Client{},
+	}, nil
+}
+
+func (k *AwsKms) Name() string {
+	return "aws"
+}
+
+// call sends a request to the json api of kms, which is what the sdk of a
+// service does, so that the gateway does not need the kms sdk.
+func (k *AwsKms) call(ctx context.Context, target string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+target)
+
+	creds, err := k.creds.Retrieve(ctx)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256(body)
+	if err := k.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "kms", k.region, time.Now()); err != nil {
+		return err
+	}
+
+	res, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("aws kms %s responded with status code %d: %s", target, res.StatusCode, string(data))
+	}
+
+	return json.Unmarshal(data, out)
+}
+
+func (k *AwsKms) Wrap(ctx context.Context, dataKey []byte) ([]byte, string, error) {
+	out := struct {
+		CiphertextBlob []byte
+		KeyId          string
+	}{}
+
+	if err := k.call(ctx, "Encrypt", map[string]any{"KeyId": k.keyId, "Plaintext": dataKey}, &out); err != nil {
+		return nil, "", err
+	}
+
+	return out.CiphertextBlob, out.KeyId, nil
+}
+
+func (k *AwsKms) Unwrap(ctx context.Context, wrapped []byte, keyId string) ([]byte, error) {
+	out := struct {
+		Plaintext []byte
+	}{}
+
+	if err := k.call(ctx, "Decrypt", map[string]any{"KeyId": keyId, "CiphertextBlob": wrapped}, &out); err != nil {
+		return nil, err
+	}
+
+	return out.Plaintext, nil
+}
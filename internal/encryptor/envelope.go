@@ -0,0 +1,235 @@
+package encryptor
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+const (
+	envelopePrefix = "envelope:v1:"
+
+	// dataKeyCacheSize bounds the unwrapped data keys kept in memory, so that
+	// the proxy does not call the kms every time it uses a secret.
+	dataKeyCacheSize = 1024
+)
+
+// SecretEncryptor encrypts provider setting secrets before they are stored.
+// Headers are passed to encryption services that need the context of a
+// secret, and are ignored by envelope encryption.
+type SecretEncryptor interface {
+	Encrypt(input string, headers map[string]string) (string, error)
+	Decrypt(input string, headers map[string]string) (string, error)
+	Enabled() bool
+}
+
+// KeyWrapper wraps data keys with a key encryption key that never leaves a
+// kms, such as an AWS KMS key, a GCP KMS crypto key or a Vault transit key.
+type KeyWrapper interface {
+	Name() string
+	// Wrap encrypts dataKey with the current key encryption key and returns
+	// the id of the key that wrapped it.
+	Wrap(ctx context.Context, dataKey []byte) ([]byte, string, error)
+	Unwrap(ctx context.Context, wrapped []byte, keyId string) ([]byte, error)
+}
+
+type envelope struct {
+	Provider   string `json:"p"`
+	KeyId      string `json:"k"`
+	DataKey    []byte `json:"d"`
+	Nonce      []byte `json:"n"`
+	Ciphertext []byte `json:"c"`
+}
+
+// Envelope encrypts every secret with its own AES-256-GCM data key, and
+// stores the data key wrapped by a KeyWrapper next to the ciphertext.
+type Envelope struct {
+	kw      KeyWrapper
+	timeout time.Duration
+
+	mu       sync.RWMutex
+	dataKeys map[string][]byte
+}
+
+func NewEnvelope(kw KeyWrapper, timeout time.Duration) *Envelope {
+	return &Envelope{
+		kw:       kw,
+		timeout:  timeout,
+		dataKeys: map[string][]byte{},
+	}
+}
+
+func (e *Envelope) Enabled() bool {
+	return true
+}
+
+// IsEncrypted reports whether input was encrypted by an Envelope. Anything
+// else is treated as a secret stored before envelope encryption was enabled.
+func IsEncrypted(input string) bool {
+	return strings.HasPrefix(input, envelopePrefix)
+}
+
+func (e *Envelope) Encrypt(input string, headers map[string]string) (string, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+
+	wrapped, keyId, err := e.kw.Wrap(ctx, dataKey)
+	if err != nil {
+		telemetry.Incr("bricksllm.encryptor.envelope.wrap_error", []string{"provider:" + e.kw.Name()}, 1)
+		return "", err
+	}
+
+	gcm, err := newGcm(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return encode(&envelope{
+		Provider:   e.kw.Name(),
+		KeyId:      keyId,
+		DataKey:    wrapped,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, []byte(input), nil),
+	})
+}
+
+func (e *Envelope) Decrypt(input string, headers map[string]string) (string, error) {
+	env, err := decode(input)
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := e.unwrap(env)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := newGcm(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	plain, err := gcm.Open(nil, env.Nonce, env.Ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plain), nil
+}
+
+// Rewrap wraps the data key of input again with the current key encryption
+// key, so that secrets can be moved off a key that is being retired. The
+// secret itself is not decrypted.
+func (e *Envelope) Rewrap(input string) (string, error) {
+	env, err := decode(input)
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := e.unwrap(env)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+
+	wrapped, keyId, err := e.kw.Wrap(ctx, dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	env.Provider = e.kw.Name()
+	env.KeyId = keyId
+	env.DataKey = wrapped
+
+	return encode(env)
+}
+
+func (e *Envelope) unwrap(env *envelope) ([]byte, error) {
+	if env.Provider != e.kw.Name() {
+		return nil, errors.New("secret was encrypted with " + env.Provider + " and cannot be decrypted with " + e.kw.Name())
+	}
+
+	cacheKey := env.KeyId + ":" + base64.StdEncoding.EncodeToString(env.DataKey)
+
+	e.mu.RLock()
+	dataKey, ok := e.dataKeys[cacheKey]
+	e.mu.RUnlock()
+	if ok {
+		return dataKey, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+
+	dataKey, err := e.kw.Unwrap(ctx, env.DataKey, env.KeyId)
+	if err != nil {
+		telemetry.Incr("bricksllm.encryptor.envelope.unwrap_error", []string{"provider:" + e.kw.Name()}, 1)
+		return nil, err
+	}
+
+	e.mu.Lock()
+	if len(e.dataKeys) >= dataKeyCacheSize {
+		e.dataKeys = map[string][]byte{}
+	}
+	e.dataKeys[cacheKey] = dataKey
+	e.mu.Unlock()
+
+	return dataKey, nil
+}
+
+func newGcm(dataKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func encode(env *envelope) (string, error) {
+	data, err := json.Marshal(env)
+	if err != nil {
+		return "", err
+	}
+
+	return envelopePrefix + base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decode(input string) (*envelope, error) {
+	if !IsEncrypted(input) {
+		return nil, errors.New("secret is not envelope encrypted")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(input, envelopePrefix))
+	if err != nil {
+		return nil, err
+	}
+
+	env := &envelope{}
+	if err := json.Unmarshal(data, env); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
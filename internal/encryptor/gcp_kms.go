@@ -0,0 +1,61 @@
+package encryptor
+
+import (
+	"context"
+	"encoding/base64"
+
+	"google.golang.org/api/cloudkms/v1"
+)
+
+// GcpKms wraps data keys with a GCP KMS crypto key, named as
+// projects/{project}/locations/{location}/keyRings/{ring}/cryptoKeys/{key}.
+// Credentials are taken from the application default credentials.
+type GcpKms struct {
+	keyName string
+	keys    *cloudkms.ProjectsLocationsKeyRingsCryptoKeysService
+}
+
+func NewGcpKms(ctx context.Context, keyName string) (*GcpKms, error) {
+	svc, err := cloudkms.NewService(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GcpKms{
+		keyName: keyName,
+		keys:    svc.Projects.Locations.KeyRings.CryptoKeys,
+	}, nil
+}
+
+func (k *GcpKms) Name() string {
+	return "gcp"
+}
+
+// Wrap encrypts with the primary version of the crypto key. GCP picks the
+// version on decryption, so the crypto key is recorded rather than the version.
+func (k *GcpKms) Wrap(ctx context.Context, dataKey []byte) ([]byte, string, error) {
+	res, err := k.keys.Encrypt(k.keyName, &cloudkms.EncryptRequest{
+		Plaintext: base64.StdEncoding.EncodeToString(dataKey),
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, "", err
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(res.Ciphertext)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return wrapped, k.keyName, nil
+}
+
+func (k *GcpKms) Unwrap(ctx context.Context, wrapped []byte, keyId string) ([]byte, error) {
+	res, err := k.keys.Decrypt(keyId, &cloudkms.DecryptRequest{
+		Ciphertext: base64.StdEncoding.EncodeToString(wrapped),
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(res.Plaintext)
+}
@@ -0,0 +1,22 @@
+package encryptor
+
+import (
+	"context"
+	"fmt"
+)
+
+// NewKeyWrapper creates the KeyWrapper of a kms provider, which is one of aws,
+// gcp or vault. keyId is an AWS KMS key id, arn or alias, a GCP KMS crypto key
+// name or a Vault transit key name.
+func NewKeyWrapper(ctx context.Context, provider, keyId, awsRegion, vaultAddress, vaultToken, vaultMount string) (KeyWrapper, error) {
+	switch provider {
+	case "aws":
+		return NewAwsKms(ctx, keyId, awsRegion)
+	case "gcp":
+		return NewGcpKms(ctx, keyId)
+	case "vault":
+		return NewVaultTransit(vaultAddress, vaultToken, vaultMount, keyId), nil
+	}
+
+	return nil, fmt.Errorf("kms provider %s is not supported", provider)
+}
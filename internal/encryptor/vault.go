@@ -0,0 +1,102 @@
+package encryptor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// VaultTransit wraps data keys with a key of the transit secrets engine of
+// HashiCorp Vault. Vault records the key version in its ciphertext, so
+// rotating the transit key leaves older secrets readable.
+type VaultTransit struct {
+	address string
+	token   string
+	mount   string
+	keyName string
+	client  *http.Client
+}
+
+func NewVaultTransit(address, token, mount, keyName string) *VaultTransit {
+	if len(mount) == 0 {
+		mount = "transit"
+	}
+
+	return &VaultTransit{
+		address: strings.TrimSuffix(address, "/"),
+		token:   token,
+		mount:   strings.Trim(mount, "/"),
+		keyName: keyName,
+		client:  &http.Client{},
+	}
+}
+
+func (v *VaultTransit) Name() string {
+	return "vault"
+}
+
+func (v *VaultTransit) call(ctx context.Context, op, keyName string, in map[string]string, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/%s/%s", v.address, v.mount, op, keyName)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", v.token)
+
+	res, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("vault transit %s responded with status code %d: %s", op, res.StatusCode, string(data))
+	}
+
+	return json.Unmarshal(data, out)
+}
+
+func (v *VaultTransit) Wrap(ctx context.Context, dataKey []byte) ([]byte, string, error) {
+	out := struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}{}
+
+	if err := v.call(ctx, "encrypt", v.keyName, map[string]string{"plaintext": base64.StdEncoding.EncodeToString(dataKey)}, &out); err != nil {
+		return nil, "", err
+	}
+
+	return []byte(out.Data.Ciphertext), v.keyName, nil
+}
+
+func (v *VaultTransit) Unwrap(ctx context.Context, wrapped []byte, keyId string) ([]byte, error) {
+	out := struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}{}
+
+	if err := v.call(ctx, "decrypt", keyId, map[string]string{"ciphertext": string(wrapped)}, &out); err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(out.Data.Plaintext)
+}
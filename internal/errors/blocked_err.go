@@ -2,6 +2,7 @@ package errors
 
 type BlockedError struct {
 	message string
+	rules   []string
 }
 
 func NewBlockedError(msg string) *BlockedError {
@@ -10,8 +11,18 @@ func NewBlockedError(msg string) *BlockedError {
 	}
 }
 
+// WithRules records the policy rules that blocked the request.
+func (be *BlockedError) WithRules(rules ...string) *BlockedError {
+	be.rules = append(be.rules, rules...)
+	return be
+}
+
 func (be *BlockedError) Error() string {
 	return be.message
 }
 
+func (be *BlockedError) Rules() []string {
+	return be.rules
+}
+
 func (be *BlockedError) Blocked() {}
@@ -2,6 +2,7 @@ package errors
 
 type RedactError struct {
 	message string
+	rules   []string
 }
 
 func NewRedactError(msg string) *RedactError {
@@ -10,8 +11,18 @@ func NewRedactError(msg string) *RedactError {
 	}
 }
 
+// WithRules records the policy rules that redacted the request.
+func (we *RedactError) WithRules(rules ...string) *RedactError {
+	we.rules = append(we.rules, rules...)
+	return we
+}
+
 func (we *RedactError) Error() string {
 	return we.message
 }
 
+func (we *RedactError) Rules() []string {
+	return we.rules
+}
+
 func (we *RedactError) Redacted() {}
@@ -1,7 +1,21 @@
 package errors
 
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError describes a single invalid field of a request. Value holds what
+// was provided when it is known.
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+	Value  any    `json:"value,omitempty"`
+}
+
 type ValidationError struct {
 	message string
+	fields  []*FieldError
 }
 
 func NewValidationError(msg string) *ValidationError {
@@ -10,8 +24,42 @@ func NewValidationError(msg string) *ValidationError {
 	}
 }
 
+func NewFieldsValidationError(fields []*FieldError) *ValidationError {
+	names := []string{}
+	for _, f := range fields {
+		names = append(names, f.Field)
+	}
+
+	return &ValidationError{
+		message: fmt.Sprintf("fields [%s] are invalid", strings.Join(names, ", ")),
+		fields:  fields,
+	}
+}
+
+func NewInvalidFieldsError(names []string) *ValidationError {
+	fields := []*FieldError{}
+	for _, name := range names {
+		fields = append(fields, &FieldError{
+			Field:  name,
+			Reason: "invalid",
+		})
+	}
+
+	return NewFieldsValidationError(fields)
+}
+
+// WithFields attaches field level details while keeping the message.
+func (ve *ValidationError) WithFields(fields ...*FieldError) *ValidationError {
+	ve.fields = append(ve.fields, fields...)
+	return ve
+}
+
 func (ve *ValidationError) Error() string {
 	return ve.message
 }
 
+func (ve *ValidationError) Fields() []*FieldError {
+	return ve.fields
+}
+
 func (ve *ValidationError) Validation() {}
@@ -2,6 +2,7 @@ package errors
 
 type WarningError struct {
 	message string
+	rules   []string
 }
 
 func NewWarningError(msg string) *WarningError {
@@ -10,8 +11,18 @@ func NewWarningError(msg string) *WarningError {
 	}
 }
 
+// WithRules records the policy rules that warned about the request.
+func (we *WarningError) WithRules(rules ...string) *WarningError {
+	we.rules = append(we.rules, rules...)
+	return we
+}
+
 func (we *WarningError) Error() string {
 	return we.message
 }
 
+func (we *WarningError) Rules() []string {
+	return we.rules
+}
+
 func (we *WarningError) Warnings() {}
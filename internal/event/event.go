@@ -2,12 +2,16 @@ package event
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
 )
 
+var requestHashRegex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
 type Event struct {
+	SchemaVersion        int      `json:"schema_version"`
 	Id                   string   `json:"id"`
 	CreatedAt            int64    `json:"created_at"`
 	Tags                 []string `json:"tags"`
@@ -27,14 +31,52 @@ type Event struct {
 	UserId               string   `json:"userId"`
 	Action               string   `json:"action"`
 	PolicyId             string   `json:"policyId"`
+	PolicyRules          []string `json:"policyRules"`
+	RiskScore            float64  `json:"riskScore,omitempty"`
+	PolicyVersion        int      `json:"policyVersion,omitempty"`
+	RequestHash          string   `json:"requestHash,omitempty"`
+	Language             string   `json:"language,omitempty"`
 	RouteId              string   `json:"routeId"`
 	CorrelationId        string   `json:"correlationId"`
 	Metadata             []byte   `json:"metadata"`
+	RoutingRationale     []byte   `json:"routingRationale"`
+	Signing              []byte   `json:"signing"`
+	ReasoningTokenCount  int      `json:"reasoning_token_count"`
+	CacheStatus          string   `json:"cache_status"`
+	Currency             string   `json:"currency"`
+	CostInCurrency       float64  `json:"cost_in_currency"`
+	FxRate               float64  `json:"fx_rate"`
+	Timings              *Timings `json:"timings"`
+	ProviderSettingId    string   `json:"providerSettingId"`
+	ProviderSettingName  string   `json:"providerSettingName,omitempty"`
+	OriginGateway        string   `json:"originGateway,omitempty"`
+	OriginKeyId          string   `json:"originKeyId,omitempty"`
+	// ToolCalls counts the tool calls of assistant run steps by tool type
+	ToolCalls map[string]int `json:"toolCalls,omitempty"`
+	// Experiment and Variant are set on events of routes splitting or mirroring
+	// traffic
+	Experiment string `json:"experiment,omitempty"`
+	Variant    string `json:"variant,omitempty"`
+}
+
+// Timings breaks the latency of a request down into the segments spent in the
+// gateway and the ones spent waiting on the provider. Segments a request never
+// went through, such as the cache lookup of a route without caching, are zero.
+type Timings struct {
+	AuthInMs         int64 `json:"auth_in_ms"`
+	PolicyInMs       int64 `json:"policy_in_ms"`
+	CacheLookupInMs  int64 `json:"cache_lookup_in_ms"`
+	QueueWaitInMs    int64 `json:"queue_wait_in_ms"`
+	UpstreamTtfbInMs int64 `json:"upstream_ttfb_in_ms"`
+	UpstreamInMs     int64 `json:"upstream_in_ms"`
 }
 
+// EventResponse carries the schema version of its events so that consumers can
+// tell which fields to expect.
 type EventResponse struct {
-	Events []*Event `json:"events"`
-	Count  int      `json:"count"`
+	SchemaVersion int      `json:"schemaVersion"`
+	Events        []*Event `json:"events"`
+	Count         int      `json:"count"`
 }
 
 type EventRequest struct {
@@ -54,6 +96,17 @@ type EventRequest struct {
 	DateOrder       string   `json:"dateOrder"`
 	ReturnCount     bool     `json:"returnCount"`
 	Status          int      `json:"status"`
+
+	SigningIdentities []string `json:"signingIdentities"`
+	SigningFailed     bool     `json:"signingFailed"`
+
+	// MinRiskScore only returns events with a prompt injection risk score of
+	// at least this much
+	MinRiskScore float64 `json:"minRiskScore"`
+
+	// RequestHashes only returns events of requests with these hashes, so that
+	// a request can be looked up without logging its content
+	RequestHashes []string `json:"requestHashes"`
 }
 
 func (r *EventRequest) Validate() error {
@@ -66,6 +119,10 @@ func (r *EventRequest) Validate() error {
 		invalid = append(invalid, "end")
 	}
 
+	if r.MinRiskScore < 0 || r.MinRiskScore > 1 {
+		invalid = append(invalid, "minRiskScore")
+	}
+
 	for _, kid := range r.KeyIds {
 		if len(kid) == 0 {
 			invalid = append(invalid, "keyIds")
@@ -101,8 +158,15 @@ func (r *EventRequest) Validate() error {
 		}
 	}
 
+	for _, hash := range r.RequestHashes {
+		if !requestHashRegex.MatchString(hash) {
+			invalid = append(invalid, "requestHashes")
+			break
+		}
+	}
+
 	if len(invalid) > 0 {
-		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+		return internal_errors.NewInvalidFieldsError(invalid)
 	}
 
 	if r.Start >= r.End {
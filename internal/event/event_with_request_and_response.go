@@ -15,4 +15,5 @@ type EventWithRequestAndContent struct {
 	Response            interface{}
 	Key                 *key.ResponseKey
 	CostMap             *provider.CostMap
+	SpendLimit          *provider.SpendLimit
 }
@@ -3,20 +3,23 @@ package event
 type KeyDataPoint struct {
 	KeyId     string  `json:"keyId"`
 	CostInUsd float64 `json:"costInUsd"`
+	Cost      float64 `json:"cost,omitempty"`
 }
 
 type KeyReportingResponse struct {
 	DataPoints []*KeyDataPoint `json:"dataPoints"`
+	Currency   string          `json:"currency,omitempty"`
 }
 
 type KeyReportingRequest struct {
-	Tags    []string `json:"tags"`
-	Order   string   `json:"order"`
-	KeyIds  []string `json:"keyIds"`
-	Start   int64    `json:"start"`
-	End     int64    `json:"end"`
-	Limit   int      `json:"limit"`
-	Offset  int      `json:"offset"`
-	Name    string   `json:"name"`
-	Revoked *bool    `json:"revoked"`
+	Tags     []string `json:"tags"`
+	Order    string   `json:"order"`
+	KeyIds   []string `json:"keyIds"`
+	Start    int64    `json:"start"`
+	End      int64    `json:"end"`
+	Limit    int      `json:"limit"`
+	Offset   int      `json:"offset"`
+	Name     string   `json:"name"`
+	Revoked  *bool    `json:"revoked"`
+	Currency string   `json:"currency"`
 }
@@ -0,0 +1,50 @@
+package event
+
+import (
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+// PolicyVersionReportingRequest asks for the requests a policy applied to,
+// grouped by the version of the policy that applied, so that a version being
+// rolled out can be compared with the one it replaces.
+type PolicyVersionReportingRequest struct {
+	PolicyId string `json:"policyId"`
+	Start    int64  `json:"start"`
+	End      int64  `json:"end"`
+}
+
+func (r *PolicyVersionReportingRequest) Validate() error {
+	invalid := []string{}
+	if len(r.PolicyId) == 0 {
+		invalid = append(invalid, "policyId")
+	}
+
+	if r.Start == 0 {
+		invalid = append(invalid, "start")
+	}
+
+	if r.End == 0 || r.End <= r.Start {
+		invalid = append(invalid, "end")
+	}
+
+	if len(invalid) != 0 {
+		return internal_errors.NewInvalidFieldsError(invalid)
+	}
+
+	return nil
+}
+
+// PolicyVersionDataPoint summarizes the requests a version of a policy
+// applied to. Events recorded before policies were versioned have version 0.
+type PolicyVersionDataPoint struct {
+	Version                  int     `json:"version"`
+	NumberOfRequests         int64   `json:"numberOfRequests"`
+	BlockedCount             int64   `json:"blockedCount"`
+	WarnedCount              int64   `json:"warnedCount"`
+	BlockRate                float64 `json:"blockRate"`
+	AveragePolicyLatencyInMs float64 `json:"averagePolicyLatencyInMs"`
+}
+
+type PolicyVersionReportingResponse struct {
+	DataPoints []*PolicyVersionDataPoint `json:"dataPoints"`
+}
@@ -0,0 +1,108 @@
+package event
+
+import (
+	"fmt"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+const (
+	defaultViolationSampleSize = 3
+	maxViolationSampleSize     = 20
+	maxViolationBuckets        = 1000
+)
+
+// PolicyViolationReportingRequest asks for the requests that policies warned
+// about, blocked or redacted, grouped by policy, rule, key and time bucket.
+// Without an increment the whole range is a single bucket.
+type PolicyViolationReportingRequest struct {
+	Start      int64    `json:"start"`
+	End        int64    `json:"end"`
+	Increment  int64    `json:"increment"`
+	PolicyIds  []string `json:"policyIds"`
+	KeyIds     []string `json:"keyIds"`
+	Rules      []string `json:"rules"`
+	Actions    []string `json:"actions"`
+	SampleSize int      `json:"sampleSize"`
+}
+
+func (r *PolicyViolationReportingRequest) Validate() error {
+	invalid := []string{}
+	if r.Start == 0 {
+		invalid = append(invalid, "start")
+	}
+
+	if r.End == 0 || r.End <= r.Start {
+		invalid = append(invalid, "end")
+	}
+
+	if r.Increment < 0 {
+		invalid = append(invalid, "increment")
+	}
+
+	if r.SampleSize < 0 || r.SampleSize > maxViolationSampleSize {
+		invalid = append(invalid, "sampleSize")
+	}
+
+	if len(invalid) != 0 {
+		return internal_errors.NewInvalidFieldsError(invalid)
+	}
+
+	if r.Increment != 0 && (r.End-r.Start)/r.Increment > maxViolationBuckets {
+		return internal_errors.NewValidationError(fmt.Sprintf("increment %d splits the range into more than %d buckets", r.Increment, maxViolationBuckets))
+	}
+
+	for _, a := range r.Actions {
+		if a != "warned" && a != "blocked" && a != "redacted" {
+			return internal_errors.NewValidationError(fmt.Sprintf("action cannot be %s", a))
+		}
+	}
+
+	if len(r.Actions) == 0 {
+		r.Actions = []string{"warned", "blocked", "redacted"}
+	}
+
+	if r.SampleSize == 0 {
+		r.SampleSize = defaultViolationSampleSize
+	}
+
+	return nil
+}
+
+// ViolationSample is an offending event. Its full record, with the request if
+// the key logs requests, can be looked up by id through the events api.
+type ViolationSample struct {
+	EventId   string  `json:"eventId"`
+	CreatedAt int64   `json:"createdAt"`
+	Action    string  `json:"action"`
+	Path      string  `json:"path"`
+	Model     string  `json:"model"`
+	UserId    string  `json:"userId"`
+	CustomId  string  `json:"customId"`
+	RiskScore float64 `json:"riskScore,omitempty"`
+
+	// Rules are every rule that fired on the event, and RequestHash is the
+	// SHA-256 of the request as it was received, before any redaction.
+	Rules       []string `json:"rules"`
+	RequestHash string   `json:"requestHash,omitempty"`
+}
+
+// PolicyViolationDataPoint counts violations of a rule. Events recorded
+// before rules were tracked have an empty rule. MaxRiskScore is the highest
+// prompt injection risk score among the events.
+type PolicyViolationDataPoint struct {
+	TimeStamp     int64              `json:"timeStamp"`
+	PolicyId      string             `json:"policyId"`
+	Rule          string             `json:"rule"`
+	KeyId         string             `json:"keyId"`
+	Violations    int64              `json:"violations"`
+	BlockedCount  int64              `json:"blockedCount"`
+	WarnedCount   int64              `json:"warnedCount"`
+	RedactedCount int64              `json:"redactedCount"`
+	MaxRiskScore  float64            `json:"maxRiskScore,omitempty"`
+	Samples       []*ViolationSample `json:"samples"`
+}
+
+type PolicyViolationReportingResponse struct {
+	DataPoints []*PolicyViolationDataPoint `json:"dataPoints"`
+}
@@ -4,6 +4,7 @@ type DataPoint struct {
 	TimeStamp            int64   `json:"timeStamp"`
 	NumberOfRequests     int64   `json:"numberOfRequests"`
 	CostInUsd            float64 `json:"costInUsd"`
+	Cost                 float64 `json:"cost,omitempty"`
 	LatencyInMs          int     `json:"latencyInMs"`
 	PromptTokenCount     int     `json:"promptTokenCount"`
 	CompletionTokenCount int     `json:"completionTokenCount"`
@@ -12,12 +13,18 @@ type DataPoint struct {
 	KeyId                string  `json:"keyId"`
 	CustomId             string  `json:"customId"`
 	UserId               string  `json:"userId"`
+	ProviderSettingId    string  `json:"providerSettingId,omitempty"`
+	ProviderSettingName  string  `json:"providerSettingName,omitempty"`
+	OriginGateway        string  `json:"originGateway,omitempty"`
+	Experiment           string  `json:"experiment,omitempty"`
+	Variant              string  `json:"variant,omitempty"`
 }
 
 type DataPointV2 struct {
 	TimeStamp            int64   `json:"timeStamp"`
 	NumberOfRequests     int64   `json:"numberOfRequests"`
 	CostInUsd            float64 `json:"costInUsd"`
+	Cost                 float64 `json:"cost,omitempty"`
 	LatencyInMs          int64   `json:"latencyInMs"`
 	PromptTokenCount     int64   `json:"promptTokenCount"`
 	CompletionTokenCount int64   `json:"completionTokenCount"`
@@ -28,16 +35,20 @@ type DataPointV2 struct {
 	UserId               string  `json:"userId"`
 }
 
+// Responses carry the currency that the cost of their data points is reported
+// in, next to the cost in USD. Costs are converted at the current rate.
 type ReportingResponse struct {
 	DataPoints        []*DataPoint `json:"dataPoints"`
 	LatencyInMsMedian float64      `json:"latencyInMsMedian"`
 	LatencyInMs99th   float64      `json:"latencyInMs99th"`
+	Currency          string       `json:"currency,omitempty"`
 }
 
 type ReportingResponseV2 struct {
 	DataPoints        []*DataPointV2 `json:"dataPoints"`
 	LatencyInMsMedian float64        `json:"latencyInMsMedian"`
 	LatencyInMs99th   float64        `json:"latencyInMs99th"`
+	Currency          string         `json:"currency,omitempty"`
 }
 
 type ReportingRequest struct {
@@ -49,4 +60,5 @@ type ReportingRequest struct {
 	End       int64    `json:"end"`
 	Increment int64    `json:"increment"`
 	Filters   []string `json:"filters"`
+	Currency  string   `json:"currency"`
 }
@@ -0,0 +1,77 @@
+package event
+
+// SchemaVersion is the version of the event records written by this build.
+// Bump it whenever fields are added to Event and register an upgrader that
+// fills them in for records of the previous version.
+//
+//	1: records written before versioning
+//	2: adds reasoning_token_count and cache_status
+//	3: adds currency, cost_in_currency and fx_rate
+//	4: adds timings, which are left empty on older records
+//	5: adds policy_rules, which are left empty on older records
+//	6: adds provider_setting_id, which is left empty on older records
+//	7: adds origin_gateway and origin_key_id, which are left empty on older
+//	   records
+//	8: adds tool_calls, which is left empty on older records and on events of
+//	   requests other than assistant runs
+//	9: adds experiment and variant, which are left empty on older records and
+//	   on events of routes that do not split traffic
+const SchemaVersion = 9
+
+const (
+	CacheStatusHit     = "hit"
+	CacheStatusMiss    = "miss"
+	CacheStatusBypass  = "bypass"
+	CacheStatusUnknown = "unknown"
+)
+
+// PricedInUsd records that the cost of an event was priced in USD. It leaves
+// events priced from a pricing table in another currency untouched.
+func (e *Event) PricedInUsd() {
+	if len(e.Currency) != 0 {
+		return
+	}
+
+	e.Currency = "USD"
+	e.CostInCurrency = e.CostInUsd
+	e.FxRate = 1
+}
+
+// upgraders maps a schema version to the function that turns a record of that
+// version into one of the next version.
+var upgraders = map[int]func(e *Event){
+	1: func(e *Event) {
+		// responses served from the route cache were recorded with the cached provider
+		e.CacheStatus = CacheStatusUnknown
+		if e.Provider == "cached" {
+			e.CacheStatus = CacheStatusHit
+		}
+	},
+	2: func(e *Event) {
+		// every cost was priced in usd before pricing tables had a currency
+		e.PricedInUsd()
+	},
+}
+
+// Upgrade brings a record read from storage up to SchemaVersion so that
+// consumers only ever see the current shape. Records without a version are
+// treated as version 1.
+func Upgrade(e *Event) {
+	if e.SchemaVersion < 1 {
+		e.SchemaVersion = 1
+	}
+
+	for e.SchemaVersion < SchemaVersion {
+		if upgrade, ok := upgraders[e.SchemaVersion]; ok {
+			upgrade(e)
+		}
+
+		e.SchemaVersion++
+	}
+}
+
+func UpgradeAll(events []*Event) {
+	for _, e := range events {
+		Upgrade(e)
+	}
+}
@@ -0,0 +1,56 @@
+package event
+
+import (
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+// Signing describes how an upstream request was signed. It never holds secrets,
+// only the identity of the credential and the scope of the signature.
+type Signing struct {
+	Scheme        string   `json:"scheme"`
+	Identity      string   `json:"identity"`
+	Region        string   `json:"region,omitempty"`
+	Service       string   `json:"service,omitempty"`
+	SignedHeaders []string `json:"signedHeaders,omitempty"`
+	SignedAt      int64    `json:"signedAt,omitempty"`
+	Verified      bool     `json:"verified"`
+	Failure       string   `json:"failure,omitempty"`
+}
+
+type SigningReportingRequest struct {
+	Start      int64    `json:"start"`
+	End        int64    `json:"end"`
+	KeyIds     []string `json:"keyIds"`
+	Identities []string `json:"identities"`
+}
+
+func (r *SigningReportingRequest) Validate() error {
+	invalid := []string{}
+	if r.Start == 0 {
+		invalid = append(invalid, "start")
+	}
+
+	if r.End == 0 || r.End < r.Start {
+		invalid = append(invalid, "end")
+	}
+
+	if len(invalid) != 0 {
+		return internal_errors.NewInvalidFieldsError(invalid)
+	}
+
+	return nil
+}
+
+type SigningDataPoint struct {
+	Identity             string `json:"identity"`
+	Scheme               string `json:"scheme"`
+	Provider             string `json:"provider"`
+	NumberOfRequests     int64  `json:"numberOfRequests"`
+	VerificationFailures int64  `json:"verificationFailures"`
+	LastSignedAt         int64  `json:"lastSignedAt"`
+}
+
+type SigningReportingResponse struct {
+	DataPoints     []*SigningDataPoint `json:"dataPoints"`
+	RecentFailures []*Event            `json:"recentFailures"`
+}
@@ -0,0 +1,27 @@
+package event
+
+type KeyCounts struct {
+	Active  int `json:"active"`
+	Revoked int `json:"revoked"`
+}
+
+type ProviderHealth struct {
+	Provider         string  `json:"provider"`
+	NumberOfRequests int64   `json:"numberOfRequests"`
+	ErrorCount       int64   `json:"errorCount"`
+	ErrorRate        float64 `json:"errorRate"`
+	LatencyInMsAvg   float64 `json:"latencyInMsAvg"`
+	Status           string  `json:"status"`
+}
+
+type Summary struct {
+	Keys                *KeyCounts        `json:"keys"`
+	SpendTodayInUsd     float64           `json:"spendTodayInUsd"`
+	SpendThisMonthInUsd float64           `json:"spendThisMonthInUsd"`
+	Currency            string            `json:"currency,omitempty"`
+	SpendToday          float64           `json:"spendToday,omitempty"`
+	SpendThisMonth      float64           `json:"spendThisMonth,omitempty"`
+	TopKeys             []*KeyDataPoint   `json:"topKeys"`
+	Providers           []*ProviderHealth `json:"providers"`
+	RecentErrors        []*Event          `json:"recentErrors"`
+}
@@ -0,0 +1,37 @@
+package finetune
+
+const (
+	StatusValidatingFiles = "validating_files"
+	StatusQueued          = "queued"
+	StatusRunning         = "running"
+	StatusSucceeded       = "succeeded"
+	StatusFailed          = "failed"
+	StatusCancelled       = "cancelled"
+)
+
+// Job is an OpenAI fine-tuning job created through the proxy. Training is
+// billed once a job finishes, so the key that created a job is remembered
+// until its trained tokens have been reconciled into an event.
+type Job struct {
+	Id                string  `json:"id"`
+	KeyId             string  `json:"keyId"`
+	ProviderSettingId string  `json:"providerSettingId"`
+	Model             string  `json:"model"`
+	FineTunedModel    string  `json:"fineTunedModel"`
+	Status            string  `json:"status"`
+	TrainedTokens     int     `json:"trainedTokens"`
+	CostInUsd         float64 `json:"costInUsd"`
+	CreatedAt         int64   `json:"createdAt"`
+	UpdatedAt         int64   `json:"updatedAt"`
+	ReconciledAt      int64   `json:"reconciledAt"`
+}
+
+// IsTerminal reports whether a job stopped training.
+func IsTerminal(status string) bool {
+	switch status {
+	case StatusSucceeded, StatusFailed, StatusCancelled:
+		return true
+	}
+
+	return false
+}
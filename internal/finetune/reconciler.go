@@ -0,0 +1,325 @@
+package finetune
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/message"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"go.uber.org/zap"
+)
+
+const (
+	openAiBaseUrl = "https://api.openai.com/v1"
+
+	requestTimeout = time.Minute
+)
+
+type Storage interface {
+	GetUnreconciledFineTuningJobs() ([]*Job, error)
+	UpdateFineTuningJobStatus(id, status, fineTunedModel string, updatedAt int64) error
+	ReconcileFineTuningJob(j *Job) (bool, error)
+	GetProviderSettings(withSecret bool, ids []string) ([]*provider.Setting, error)
+	GetKey(keyId string) (*key.ResponseKey, error)
+}
+
+type Decryptor interface {
+	Decrypt(input string, headers map[string]string) (string, error)
+	Enabled() bool
+}
+
+type CostEstimator interface {
+	EstimateFinetuningCost(num int, model string) (float64, error)
+}
+
+type Publisher interface {
+	Publish(message.Message)
+}
+
+// Reconciler polls the fine-tuning jobs created through the proxy until they
+// stop, and bills their trained tokens to the keys that created them through
+// an event, the same way spend of synchronous requests is recorded.
+type Reconciler struct {
+	s        Storage
+	d        Decryptor
+	e        CostEstimator
+	pub      Publisher
+	client   http.Client
+	interval time.Duration
+	done     chan bool
+	log      *zap.Logger
+}
+
+func NewReconciler(s Storage, d Decryptor, e CostEstimator, pub Publisher, log *zap.Logger, interval time.Duration) *Reconciler {
+	return &Reconciler{
+		s:        s,
+		d:        d,
+		e:        e,
+		pub:      pub,
+		client:   http.Client{Timeout: requestTimeout},
+		interval: interval,
+		done:     make(chan bool),
+		log:      log,
+	}
+}
+
+func (r *Reconciler) Listen() {
+	if r.interval <= 0 {
+		r.log.Info("fine-tuning job reconciliation is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	r.log.Info("fine-tuning job reconciler started")
+
+	go func() {
+		for {
+			select {
+			case <-r.done:
+				ticker.Stop()
+				r.log.Info("fine-tuning job reconciler stopped")
+				return
+			case <-ticker.C:
+				if err := r.ReconcileAll(); err != nil {
+					telemetry.Incr("bricksllm.finetune.reconciler.reconcile_error", nil, 1)
+					r.log.Sugar().Debugf("fine-tuning job reconciliation failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (r *Reconciler) Stop() {
+	if r.interval <= 0 {
+		return
+	}
+
+	r.done <- true
+}
+
+// ReconcileAll checks on every job that has not been reconciled yet. A job that
+// fails to be checked is retried on the next tick.
+func (r *Reconciler) ReconcileAll() error {
+	jobs, err := r.s.GetUnreconciledFineTuningJobs()
+	if err != nil {
+		return err
+	}
+
+	settings := map[string]*provider.Setting{}
+	for _, j := range jobs {
+		setting, ok := settings[j.ProviderSettingId]
+		if !ok {
+			setting, err = r.setting(j.ProviderSettingId)
+			if err != nil {
+				telemetry.Incr("bricksllm.finetune.reconciler.get_setting_error", nil, 1)
+				r.log.Sugar().Debugf("getting provider setting %s of fine-tuning job %s failed: %v", j.ProviderSettingId, j.Id, err)
+				continue
+			}
+
+			settings[j.ProviderSettingId] = setting
+		}
+
+		if err := r.Reconcile(j, setting); err != nil {
+			telemetry.Incr("bricksllm.finetune.reconciler.reconcile_job_error", nil, 1)
+			r.log.Sugar().Debugf("reconciling fine-tuning job %s failed: %v", j.Id, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Reconciler) setting(id string) (*provider.Setting, error) {
+	settings, err := r.s.GetProviderSettings(true, []string{id})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(settings) == 0 {
+		return nil, errors.New("provider setting is not found")
+	}
+
+	return r.decrypt(settings[0]), nil
+}
+
+// decrypt returns a copy of setting with its api key in plain text, falling
+// back to the first pooled key of settings without one.
+func (r *Reconciler) decrypt(setting *provider.Setting) *provider.Setting {
+	copied := *setting
+	copied.Setting = map[string]string{}
+	for k, v := range setting.Setting {
+		copied.Setting[k] = v
+	}
+
+	apikey := copied.Setting["apikey"]
+	if len(apikey) == 0 && setting.KeyPool != nil && len(setting.KeyPool.Keys) != 0 {
+		apikey = setting.KeyPool.Keys[0].Key
+	}
+
+	if r.d != nil && r.d.Enabled() && len(apikey) != 0 {
+		decrypted, err := r.d.Decrypt(apikey, map[string]string{"X-UPDATED-AT": strconv.FormatInt(setting.UpdatedAt, 10)})
+		if err == nil {
+			apikey = decrypted
+		}
+	}
+
+	copied.Setting["apikey"] = apikey
+
+	return &copied
+}
+
+type upstreamJob struct {
+	Id             string `json:"id"`
+	Model          string `json:"model"`
+	Status         string `json:"status"`
+	FineTunedModel string `json:"fine_tuned_model"`
+	TrainedTokens  int    `json:"trained_tokens"`
+}
+
+// Reconcile updates the status of a job, and publishes the training cost of a
+// stopped job once it has been claimed, so that gateways sharing a database
+// never bill a job twice. Cancelled and failed jobs are billed for the tokens
+// trained before they stopped.
+func (r *Reconciler) Reconcile(j *Job, setting *provider.Setting) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	uj, err := r.get(ctx, setting, j.Id)
+	if err != nil {
+		return err
+	}
+
+	if !IsTerminal(uj.Status) {
+		if uj.Status != j.Status {
+			return r.s.UpdateFineTuningJobStatus(j.Id, uj.Status, uj.FineTunedModel, time.Now().Unix())
+		}
+
+		return nil
+	}
+
+	reconciled := *j
+	reconciled.Status = uj.Status
+	reconciled.FineTunedModel = uj.FineTunedModel
+	reconciled.TrainedTokens = uj.TrainedTokens
+
+	if uj.TrainedTokens != 0 {
+		cost, err := r.e.EstimateFinetuningCost(uj.TrainedTokens, baseModel(j.Model))
+		if err != nil {
+			telemetry.Incr("bricksllm.finetune.reconciler.estimate_cost_error", nil, 1)
+			r.log.Sugar().Debugf("estimating training cost of fine-tuning job %s failed: %v", j.Id, err)
+		}
+
+		reconciled.CostInUsd = cost
+	}
+
+	now := time.Now().Unix()
+	reconciled.UpdatedAt = now
+	reconciled.ReconciledAt = now
+
+	claimed, err := r.s.ReconcileFineTuningJob(&reconciled)
+	if err != nil {
+		return err
+	}
+
+	if !claimed {
+		return nil
+	}
+
+	k, err := r.s.GetKey(j.KeyId)
+	if err != nil {
+		// the training cost is still recorded as an event when the key is gone
+		telemetry.Incr("bricksllm.finetune.reconciler.get_key_error", nil, 1)
+		r.log.Sugar().Debugf("getting key %s of fine-tuning job %s failed: %v", j.KeyId, j.Id, err)
+	}
+
+	r.publish(&reconciled, k)
+
+	telemetry.Incr("bricksllm.finetune.reconciler.reconciled", []string{"status:" + uj.Status}, 1)
+
+	return nil
+}
+
+// baseModel returns the model that a job continues training from, since jobs
+// can start from a fine-tuned model.
+func baseModel(model string) string {
+	parts := strings.Split(model, ":")
+	if len(parts) > 2 && parts[0] == "ft" {
+		return parts[1]
+	}
+
+	return model
+}
+
+func (r *Reconciler) publish(j *Job, k *key.ResponseKey) {
+	metadata, _ := json.Marshal(map[string]any{
+		"fineTuningJobId":     j.Id,
+		"fineTuningJobStatus": j.Status,
+		"fineTunedModel":      j.FineTunedModel,
+		"trainedTokens":       j.TrainedTokens,
+	})
+
+	evt := &event.Event{
+		SchemaVersion:     event.SchemaVersion,
+		Id:                util.NewUuid(),
+		CreatedAt:         j.ReconciledAt,
+		KeyId:             j.KeyId,
+		CostInUsd:         j.CostInUsd,
+		Provider:          "openai",
+		Model:             j.Model,
+		Status:            http.StatusOK,
+		PromptTokenCount:  j.TrainedTokens,
+		Path:              "/api/providers/openai/v1/fine_tuning/jobs",
+		Method:            http.MethodPost,
+		Metadata:          metadata,
+		ProviderSettingId: j.ProviderSettingId,
+	}
+
+	if k != nil {
+		evt.Tags = k.Tags
+	}
+
+	r.pub.Publish(message.Message{
+		Type: "event",
+		Data: &event.EventWithRequestAndContent{
+			Event: evt,
+			Key:   k,
+		},
+	})
+}
+
+func (r *Reconciler) get(ctx context.Context, setting *provider.Setting, id string) (*upstreamJob, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, openAiBaseUrl+"/fine_tuning/jobs/"+id, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+setting.Setting["apikey"])
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("openai responded to fine-tuning job %s with %d: %s", id, res.StatusCode, data)
+	}
+
+	uj := &upstreamJob{}
+	if err := json.NewDecoder(res.Body).Decode(uj); err != nil {
+		return nil, err
+	}
+
+	return uj, nil
+}
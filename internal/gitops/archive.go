@@ -0,0 +1,45 @@
+package gitops
+
+import (
+	"fmt"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+const ArchiveVersion = 1
+
+// Conflict strategies decide what an import does with resources that already
+// exist and differ from the archive.
+const (
+	StrategySkip      = "skip"
+	StrategyOverwrite = "overwrite"
+	StrategyFail      = "fail"
+)
+
+func IsValidStrategy(strategy string) bool {
+	return strategy == StrategySkip || strategy == StrategyOverwrite || strategy == StrategyFail
+}
+
+// Archive is a snapshot of the gateway configuration. Key secrets and provider
+// credentials are never exported, so they have to be added back to the archive
+// before the resources depending on them can be created elsewhere. Resources
+// that cannot be referenced by name are left out and listed in Omitted.
+type Archive struct {
+	Version    int   `json:"version"`
+	ExportedAt int64 `json:"exportedAt"`
+	Document
+	Omitted []*Change `json:"omitted,omitempty"`
+}
+
+func ParseArchive(data []byte) (*Archive, error) {
+	a := &Archive{}
+	if err := decode(data, a); err != nil {
+		return nil, err
+	}
+
+	if a.Version < 1 || a.Version > ArchiveVersion {
+		return nil, internal_errors.NewValidationError(fmt.Sprintf("archive version %d is not supported", a.Version))
+	}
+
+	return a, nil
+}
@@ -0,0 +1,172 @@
+package gitops
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	KindProviderSetting = "providerSetting"
+	KindPolicy          = "policy"
+	KindKey             = "key"
+	KindRoute           = "route"
+	KindCustomProvider  = "customProvider"
+
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionReplace   = "replace"
+	ActionDelete    = "delete"
+	ActionUnchanged = "unchanged"
+	ActionSkip      = "skip"
+)
+
+// KeySpec is a key whose provider settings and policy are referenced by name
+// since ids are not known before the document is applied.
+type KeySpec struct {
+	key.RequestKey
+	SettingNames []string `json:"settingNames"`
+	PolicyName   string   `json:"policyName"`
+}
+
+// RouteSpec is a route whose keys and policy are referenced by name.
+type RouteSpec struct {
+	route.Route
+	KeyNames   []string `json:"keyNames"`
+	PolicyName string   `json:"policyName,omitempty"`
+}
+
+// Document describes the desired state of the gateway. Provider settings and
+// policies are identified by name, keys by name, routes by path and custom
+// providers by provider. A section that is omitted is left untouched, even when
+// pruning. Custom providers are never pruned.
+type Document struct {
+	CustomProviders  []*custom.Provider  `json:"customProviders"`
+	ProviderSettings []*provider.Setting `json:"providerSettings"`
+	Policies         []*policy.Policy    `json:"policies"`
+	Keys             []*KeySpec          `json:"keys"`
+	Routes           []*RouteSpec        `json:"routes"`
+}
+
+// Parse accepts both YAML and JSON since JSON is a subset of YAML.
+func Parse(data []byte) (*Document, error) {
+	d := &Document{}
+	if err := decode(data, d); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func decode(data []byte, v any) error {
+	raw := map[string]any{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return internal_errors.NewValidationError(fmt.Sprintf("document cannot be parsed: %v", err))
+	}
+
+	bs, err := json.Marshal(raw)
+	if err != nil {
+		return internal_errors.NewValidationError(fmt.Sprintf("document cannot be converted to json: %v", err))
+	}
+
+	if err := json.Unmarshal(bs, v); err != nil {
+		return internal_errors.NewValidationError(fmt.Sprintf("document is malformed: %v", err))
+	}
+
+	return nil
+}
+
+func (d *Document) Validate() error {
+	invalid := []string{}
+
+	providerNames := map[string]bool{}
+	for index, p := range d.CustomProviders {
+		if p == nil || len(p.Provider) == 0 || providerNames[strings.ToLower(p.Provider)] {
+			invalid = append(invalid, fmt.Sprintf("customProviders.[%d].provider", index))
+			continue
+		}
+
+		providerNames[strings.ToLower(p.Provider)] = true
+	}
+
+	settingNames := map[string]bool{}
+	for index, s := range d.ProviderSettings {
+		if s == nil || len(s.Name) == 0 || settingNames[s.Name] {
+			invalid = append(invalid, fmt.Sprintf("providerSettings.[%d].name", index))
+			continue
+		}
+
+		settingNames[s.Name] = true
+	}
+
+	policyNames := map[string]bool{}
+	for index, p := range d.Policies {
+		if p == nil || len(p.Name) == 0 || policyNames[p.Name] {
+			invalid = append(invalid, fmt.Sprintf("policies.[%d].name", index))
+			continue
+		}
+
+		policyNames[p.Name] = true
+	}
+
+	keyNames := map[string]bool{}
+	for index, k := range d.Keys {
+		if k == nil || len(k.Name) == 0 || keyNames[k.Name] {
+			invalid = append(invalid, fmt.Sprintf("keys.[%d].name", index))
+			continue
+		}
+
+		keyNames[k.Name] = true
+
+		if len(k.SettingNames) == 0 {
+			invalid = append(invalid, fmt.Sprintf("keys.[%d].settingNames", index))
+		}
+
+		if len(k.SettingId) != 0 || len(k.SettingIds) != 0 || len(k.PolicyId) != 0 {
+			invalid = append(invalid, fmt.Sprintf("keys.[%d] must reference settings and policies by name", index))
+		}
+	}
+
+	paths := map[string]bool{}
+	for index, r := range d.Routes {
+		if r == nil || len(r.Path) == 0 || paths[r.Path] {
+			invalid = append(invalid, fmt.Sprintf("routes.[%d].path", index))
+			continue
+		}
+
+		paths[r.Path] = true
+
+		if len(r.PolicyId) != 0 {
+			invalid = append(invalid, fmt.Sprintf("routes.[%d] must reference its policy by name", index))
+		}
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewInvalidFieldsError(invalid)
+	}
+
+	return nil
+}
+
+type Change struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Id     string `json:"id,omitempty"`
+	Action string `json:"action"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type ApplyResult struct {
+	DryRun   bool      `json:"dryRun"`
+	Prune    bool      `json:"prune"`
+	Strategy string    `json:"strategy,omitempty"`
+	Changes  []*Change `json:"changes"`
+}
@@ -0,0 +1,130 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOk       = "ok"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+	// StatusUnknown is reported for upstreams that have not been probed yet.
+	StatusUnknown = "unknown"
+)
+
+type Dependency struct {
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	Critical    bool   `json:"critical"`
+	LatencyInMs int64  `json:"latencyInMs"`
+	Error       string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status       string        `json:"status"`
+	Dependencies []*Dependency `json:"dependencies"`
+}
+
+type check struct {
+	name     string
+	critical bool
+	fn       func(ctx context.Context) error
+}
+
+// Prober runs dependency checks for readiness probes. A failing critical
+// dependency makes the report down, while a failing non critical dependency or
+// a dependency slower than the slow threshold only degrades it.
+type Prober struct {
+	checks  []*check
+	timeout time.Duration
+	slow    time.Duration
+}
+
+func NewProber(timeout, slow time.Duration) *Prober {
+	return &Prober{
+		timeout: timeout,
+		slow:    slow,
+	}
+}
+
+func (p *Prober) Add(name string, critical bool, fn func(ctx context.Context) error) {
+	p.checks = append(p.checks, &check{
+		name:     name,
+		critical: critical,
+		fn:       fn,
+	})
+}
+
+func (p *Prober) Probe(ctx context.Context) *Report {
+	deps := make([]*Dependency, len(p.checks))
+	wg := sync.WaitGroup{}
+
+	for index, c := range p.checks {
+		wg.Add(1)
+
+		go func(index int, c *check) {
+			defer wg.Done()
+
+			ctxTimeout, cancel := context.WithTimeout(ctx, p.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := c.fn(ctxTimeout)
+			dur := time.Since(start)
+
+			dep := &Dependency{
+				Name:        c.name,
+				Status:      StatusOk,
+				Critical:    c.critical,
+				LatencyInMs: dur.Milliseconds(),
+			}
+
+			if err != nil {
+				dep.Status = StatusDown
+				dep.Error = err.Error()
+			} else if p.slow > 0 && dur > p.slow {
+				dep.Status = StatusDegraded
+			}
+
+			deps[index] = dep
+		}(index, c)
+	}
+
+	wg.Wait()
+
+	report := &Report{
+		Status:       StatusOk,
+		Dependencies: deps,
+	}
+
+	for _, dep := range deps {
+		if dep.Status == StatusOk {
+			continue
+		}
+
+		if dep.Critical && dep.Status == StatusDown {
+			report.Status = StatusDown
+			break
+		}
+
+		report.Status = StatusDegraded
+	}
+
+	return report
+}
+
+// Freshness fails when the last successful sync is older than maxAge. It is
+// used for in memory stores that poll the database.
+func Freshness(lastSynced func() time.Time, maxAge time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		age := time.Since(lastSynced())
+		if age > maxAge {
+			return fmt.Errorf("last successful sync was %s ago", age.Truncate(time.Millisecond))
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,176 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const English = "en"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// bundle holds the translations of one language. Messages are keyed by their
+// English text. Keys containing %s are templates whose placeholders carry ids,
+// names and other values that are copied into the translation unchanged. A
+// translation fills its placeholders in order, or refers to them as %[n]s when
+// the language puts them in a different order.
+type bundle struct {
+	exact     map[string]string
+	templates []*template
+}
+
+type template struct {
+	pattern     *regexp.Regexp
+	translation string
+}
+
+var bundles = map[string]*bundle{}
+
+func init() {
+	entries, err := locales.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, entry := range entries {
+		data, err := locales.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		messages := map[string]string{}
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(err)
+		}
+
+		bundles[strings.TrimSuffix(entry.Name(), ".json")] = newBundle(messages)
+	}
+}
+
+func newBundle(messages map[string]string) *bundle {
+	b := &bundle{
+		exact: map[string]string{},
+	}
+
+	for source, translation := range messages {
+		if !strings.Contains(source, "%s") {
+			b.exact[source] = translation
+			continue
+		}
+
+		parts := strings.Split(source, "%s")
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+
+		b.templates = append(b.templates, &template{
+			pattern:     regexp.MustCompile("^" + strings.Join(parts, "(.*?)") + "$"),
+			translation: translation,
+		})
+	}
+
+	// longer templates are more specific and are tried first
+	sort.Slice(b.templates, func(i, j int) bool {
+		return len(b.templates[i].pattern.String()) > len(b.templates[j].pattern.String())
+	})
+
+	return b
+}
+
+// Supported reports whether messages can be translated into lang.
+func Supported(lang string) bool {
+	if lang == English {
+		return true
+	}
+
+	_, ok := bundles[lang]
+	return ok
+}
+
+// Translate returns msg in lang, or msg itself when there is no translation.
+func Translate(lang, msg string) string {
+	b, ok := bundles[lang]
+	if !ok || len(msg) == 0 {
+		return msg
+	}
+
+	if translated, ok := b.exact[msg]; ok {
+		return translated
+	}
+
+	for _, t := range b.templates {
+		matches := t.pattern.FindStringSubmatch(msg)
+		if matches == nil {
+			continue
+		}
+
+		translated := t.translation
+		for i, m := range matches[1:] {
+			indexed := "%[" + strconv.Itoa(i+1) + "]s"
+			if strings.Contains(translated, indexed) {
+				translated = strings.ReplaceAll(translated, indexed, m)
+				continue
+			}
+
+			translated = strings.Replace(translated, "%s", m, 1)
+		}
+
+		return translated
+	}
+
+	return msg
+}
+
+type preference struct {
+	lang string
+	q    float64
+}
+
+// Negotiate picks the supported language preferred by an Accept-Language
+// header. Regional variants fall back to their primary language, so zh-CN is
+// served in zh. English is used when nothing else matches.
+func Negotiate(acceptLanguage string) string {
+	prefs := []preference{}
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if len(tag) == 0 {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		if q <= 0 {
+			continue
+		}
+
+		primary, _, _ := strings.Cut(tag, "-")
+		prefs = append(prefs, preference{lang: primary, q: q})
+	}
+
+	sort.SliceStable(prefs, func(i, j int) bool {
+		return prefs[i].q > prefs[j].q
+	})
+
+	for _, p := range prefs {
+		if Supported(p.lang) {
+			return p.lang
+		}
+	}
+
+	return English
+}
@@ -0,0 +1,48 @@
+package key
+
+import (
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+// BulkRevokeRequest selects active keys to revoke. Filters are combined, so a
+// key has to have every tag, be in the namespace and use the provider
+// setting. Without a confirmation token the request only previews the keys
+// and returns a token that revokes exactly those keys when sent back with the
+// same filter.
+type BulkRevokeRequest struct {
+	Tags              []string `json:"tags"`
+	Namespace         string   `json:"namespace"`
+	SettingId         string   `json:"settingId"`
+	RevokedReason     string   `json:"revokedReason"`
+	ConfirmationToken string   `json:"confirmationToken"`
+}
+
+func (r *BulkRevokeRequest) Validate() error {
+	if len(r.Tags) == 0 && len(r.Namespace) == 0 && len(r.SettingId) == 0 {
+		return internal_errors.NewValidationError("bulk revoke requires at least one of tags, namespace or settingId").WithFields(&internal_errors.FieldError{
+			Field:  "tags",
+			Reason: "required",
+		})
+	}
+
+	for _, tag := range r.Tags {
+		if len(tag) == 0 {
+			return internal_errors.NewValidationError("bulk revoke tags can not be empty").WithFields(&internal_errors.FieldError{
+				Field:  "tags",
+				Reason: "invalid",
+			})
+		}
+	}
+
+	return nil
+}
+
+// BulkRevokeResult is a preview when Revoked is false, in which case
+// ConfirmationToken confirms the revocation of KeyIds until ExpiresAt.
+type BulkRevokeResult struct {
+	Revoked           bool     `json:"revoked"`
+	Count             int      `json:"count"`
+	KeyIds            []string `json:"keyIds"`
+	ConfirmationToken string   `json:"confirmationToken,omitempty"`
+	ExpiresAt         int64    `json:"expiresAt,omitempty"`
+}
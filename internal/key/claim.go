@@ -0,0 +1,17 @@
+package key
+
+type ClaimLinkRequest struct {
+	Ttl string `json:"ttl"`
+}
+
+type ClaimLink struct {
+	KeyId     string `json:"keyId"`
+	Token     string `json:"token"`
+	Url       string `json:"url"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
+type ClaimedKey struct {
+	KeyId string `json:"keyId"`
+	Key   string `json:"key"`
+}
@@ -3,7 +3,6 @@ package key
 import (
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
@@ -30,7 +29,12 @@ type UpdateKey struct {
 	ShouldLogResponse      *bool         `json:"shouldLogResponse"`
 	RotationEnabled        *bool         `json:"rotationEnabled"`
 	PolicyId               *string       `json:"policyId"`
+	PolicyVersion          *int          `json:"policyVersion"`
 	IsKeyNotHashed         *bool         `json:"isKeyNotHashed"`
+	MaxCostPerRequest      *float64      `json:"maxCostPerRequest"`
+	MaxPriority            *Priority     `json:"maxPriority"`
+	MaxConcurrency         *int          `json:"maxConcurrency"`
+	RetryPolicy            *RetryPolicy  `json:"retryPolicy,omitempty"`
 }
 
 func (uk *UpdateKey) Validate() error {
@@ -51,6 +55,26 @@ func (uk *UpdateKey) Validate() error {
 		invalid = append(invalid, "costLimitInUsd")
 	}
 
+	if uk.MaxCostPerRequest != nil && *uk.MaxCostPerRequest < 0 {
+		invalid = append(invalid, "maxCostPerRequest")
+	}
+
+	if uk.MaxPriority != nil && len(*uk.MaxPriority) != 0 && !uk.MaxPriority.Valid() {
+		invalid = append(invalid, "maxPriority")
+	}
+
+	if uk.MaxConcurrency != nil && *uk.MaxConcurrency < 0 {
+		invalid = append(invalid, "maxConcurrency")
+	}
+
+	if uk.PolicyVersion != nil && *uk.PolicyVersion < 0 {
+		invalid = append(invalid, "policyVersion")
+	}
+
+	if uk.RetryPolicy != nil {
+		invalid = append(invalid, uk.RetryPolicy.Validate("retryPolicy")...)
+	}
+
 	if uk.UpdatedAt <= 0 {
 		invalid = append(invalid, "updatedAt")
 	}
@@ -84,7 +108,7 @@ func (uk *UpdateKey) Validate() error {
 	}
 
 	if len(invalid) > 0 {
-		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+		return internal_errors.NewInvalidFieldsError(invalid)
 	}
 
 	if uk.RateLimitUnit != nil {
@@ -171,7 +195,13 @@ type RequestKey struct {
 	ShouldLogResponse      bool         `json:"shouldLogResponse"`
 	RotationEnabled        bool         `json:"rotationEnabled"`
 	PolicyId               string       `json:"policyId"`
+	PolicyVersion          int          `json:"policyVersion"`
 	IsKeyNotHashed         bool         `json:"isKeyNotHashed"`
+	MaxCostPerRequest      float64      `json:"maxCostPerRequest"`
+	Namespace              string       `json:"namespace"`
+	MaxPriority            Priority     `json:"maxPriority"`
+	MaxConcurrency         int          `json:"maxConcurrency"`
+	RetryPolicy            *RetryPolicy `json:"retryPolicy,omitempty"`
 }
 
 func (rk *RequestKey) Validate() error {
@@ -228,6 +258,26 @@ func (rk *RequestKey) Validate() error {
 		invalid = append(invalid, "costLimitInUsdOverTime")
 	}
 
+	if rk.MaxCostPerRequest < 0 {
+		invalid = append(invalid, "maxCostPerRequest")
+	}
+
+	if len(rk.MaxPriority) != 0 && !rk.MaxPriority.Valid() {
+		invalid = append(invalid, "maxPriority")
+	}
+
+	if rk.MaxConcurrency < 0 {
+		invalid = append(invalid, "maxConcurrency")
+	}
+
+	if rk.PolicyVersion < 0 {
+		invalid = append(invalid, "policyVersion")
+	}
+
+	if rk.RetryPolicy != nil {
+		invalid = append(invalid, rk.RetryPolicy.Validate("retryPolicy")...)
+	}
+
 	if rk.RateLimitOverTime < 0 {
 		invalid = append(invalid, "rateLimitOverTime")
 	}
@@ -254,7 +304,7 @@ func (rk *RequestKey) Validate() error {
 	}
 
 	if len(invalid) > 0 {
-		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+		return internal_errors.NewInvalidFieldsError(invalid)
 	}
 
 	if len(rk.RateLimitUnit) != 0 && rk.RateLimitOverTime == 0 {
@@ -320,7 +370,13 @@ type ResponseKey struct {
 	ShouldLogResponse      bool         `json:"shouldLogResponse"`
 	RotationEnabled        bool         `json:"rotationEnabled"`
 	PolicyId               string       `json:"policyId"`
+	PolicyVersion          int          `json:"policyVersion"`
 	IsKeyNotHashed         bool         `json:"isKeyNotHashed"`
+	MaxCostPerRequest      float64      `json:"maxCostPerRequest"`
+	Namespace              string       `json:"namespace"`
+	MaxPriority            Priority     `json:"maxPriority"`
+	MaxConcurrency         int          `json:"maxConcurrency"`
+	RetryPolicy            *RetryPolicy `json:"retryPolicy,omitempty"`
 }
 
 func (rk *ResponseKey) GetSettingIds() []string {
@@ -0,0 +1,69 @@
+package key
+
+import "time"
+
+// Priority orders requests made with the same key. It is sent by clients in
+// the X-BricksLLM-Priority header and capped by the max priority of the key, so
+// a tenant can only reorder its own traffic.
+type Priority string
+
+const (
+	PriorityLow    Priority = "low"
+	PriorityNormal Priority = "normal"
+	PriorityHigh   Priority = "high"
+)
+
+var priorityRanks = map[Priority]int{
+	PriorityLow:    0,
+	PriorityNormal: 1,
+	PriorityHigh:   2,
+}
+
+func (p Priority) Valid() bool {
+	_, ok := priorityRanks[p]
+	return ok
+}
+
+// Rank is 0 for low, 1 for normal and 2 for high. Unknown priorities rank as
+// normal.
+func (p Priority) Rank() int {
+	rank, ok := priorityRanks[p]
+	if !ok {
+		return priorityRanks[PriorityNormal]
+	}
+
+	return rank
+}
+
+// Clamp caps p at max. An empty max places no cap.
+func (p Priority) Clamp(max Priority) Priority {
+	if len(max) != 0 && p.Rank() > max.Rank() {
+		return max
+	}
+
+	return p
+}
+
+// ScaleRetries adjusts the retries configured on a route step. Low priority
+// requests give up after half of them so that background jobs do not keep
+// retrying against a struggling provider.
+func (p Priority) ScaleRetries(retries int) int {
+	if p == PriorityLow {
+		return retries / 2
+	}
+
+	return retries
+}
+
+// ScaleRetryInterval adjusts the wait between retries of a route step. Low
+// priority requests back off twice as long and high priority ones half as long.
+func (p Priority) ScaleRetryInterval(interval time.Duration) time.Duration {
+	switch p {
+	case PriorityLow:
+		return interval * 2
+	case PriorityHigh:
+		return interval / 2
+	}
+
+	return interval
+}
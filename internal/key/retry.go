@@ -0,0 +1,116 @@
+package key
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultRetryInitialInterval = 500 * time.Millisecond
+	defaultRetryMaxInterval     = 10 * time.Second
+)
+
+// defaultRetryableStatusCodes are rate limits, overloaded upstreams such as
+// Anthropic's 529 and gateway errors.
+var defaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+	529,
+}
+
+// RetryPolicy retries upstream requests that fail with a retryable status or
+// do not get a response, waiting an exponential backoff randomized by Jitter
+// between attempts. Retries made for a key or route within a minute are
+// capped by BudgetPerMinute, so that retries cannot pile onto an upstream
+// that is already struggling. Streaming requests are only retried when
+// RetryStreaming is set or the client sent an Idempotency-Key header, since
+// upstreams may have started generating before failing. A policy whose
+// MaxAttempts is not set is ignored.
+type RetryPolicy struct {
+	MaxAttempts          int     `json:"maxAttempts"`
+	RetryableStatusCodes []int   `json:"retryableStatusCodes,omitempty"`
+	InitialInterval      string  `json:"initialInterval,omitempty"`
+	MaxInterval          string  `json:"maxInterval,omitempty"`
+	Jitter               float64 `json:"jitter"`
+	BudgetPerMinute      int     `json:"budgetPerMinute"`
+	RetryStreaming       bool    `json:"retryStreaming"`
+}
+
+// Enabled reports whether the policy makes more than one attempt.
+func (rp *RetryPolicy) Enabled() bool {
+	return rp != nil && rp.MaxAttempts > 1
+}
+
+// Set reports whether the policy overrides the ones below it.
+func (rp *RetryPolicy) Set() bool {
+	return rp != nil && rp.MaxAttempts > 0
+}
+
+func (rp *RetryPolicy) IsRetryable(status int) bool {
+	codes := rp.RetryableStatusCodes
+	if len(codes) == 0 {
+		codes = defaultRetryableStatusCodes
+	}
+
+	for _, code := range codes {
+		if code == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseInterval(s string, fallback time.Duration) time.Duration {
+	parsed, err := time.ParseDuration(s)
+	if err != nil || parsed <= 0 {
+		return fallback
+	}
+
+	return parsed
+}
+
+func (rp *RetryPolicy) Intervals() (time.Duration, time.Duration) {
+	return parseInterval(rp.InitialInterval, defaultRetryInitialInterval), parseInterval(rp.MaxInterval, defaultRetryMaxInterval)
+}
+
+// Validate returns the fields of the policy that are invalid, prefixed with
+// field.
+func (rp *RetryPolicy) Validate(field string) []string {
+	invalid := []string{}
+	if rp.MaxAttempts < 0 {
+		invalid = append(invalid, field+".maxAttempts")
+	}
+
+	for index, code := range rp.RetryableStatusCodes {
+		if code < 100 || code > 599 {
+			invalid = append(invalid, fmt.Sprintf("%s.retryableStatusCodes.%d", field, index))
+		}
+	}
+
+	if len(rp.InitialInterval) != 0 {
+		if parsed, err := time.ParseDuration(rp.InitialInterval); err != nil || parsed <= 0 {
+			invalid = append(invalid, field+".initialInterval")
+		}
+	}
+
+	if len(rp.MaxInterval) != 0 {
+		if parsed, err := time.ParseDuration(rp.MaxInterval); err != nil || parsed <= 0 {
+			invalid = append(invalid, field+".maxInterval")
+		}
+	}
+
+	if rp.Jitter < 0 || rp.Jitter > 1 {
+		invalid = append(invalid, field+".jitter")
+	}
+
+	if rp.BudgetPerMinute < 0 {
+		invalid = append(invalid, field+".budgetPerMinute")
+	}
+
+	return invalid
+}
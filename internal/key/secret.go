@@ -0,0 +1,125 @@
+package key
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"regexp"
+	"strings"
+)
+
+const (
+	secretBodyLength     = 64
+	secretChecksumLength = 8
+)
+
+var (
+	secretPrefixRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+	hexRegex          = regexp.MustCompile(`^[0-9a-f]+$`)
+)
+
+// SecretFormat is the shape of the key secrets generated by the gateway, such
+// as those handed out by claim links and onboarding. A secret is the prefix,
+// 32 random bytes in hex and, when enabled, the crc32 of everything before it
+// in hex. The fixed prefix and length let secret scanners match leaked keys,
+// and the checksum lets them tell real keys from look-alikes offline.
+type SecretFormat struct {
+	Prefix   string `json:"prefix"`
+	Checksum bool   `json:"checksum"`
+}
+
+func NewSecretFormat(prefix string, checksum bool) (*SecretFormat, error) {
+	if len(prefix) != 0 && !secretPrefixRegex.MatchString(prefix) {
+		return nil, fmt.Errorf("key secret prefix %s must be 1 to 32 lowercase letters, digits, underscores or hyphens", prefix)
+	}
+
+	return &SecretFormat{
+		Prefix:   prefix,
+		Checksum: checksum,
+	}, nil
+}
+
+func checksumOf(s string) string {
+	return fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(s)))
+}
+
+// Generate returns a new secret in the format.
+func (f *SecretFormat) Generate() (string, error) {
+	bs := make([]byte, secretBodyLength/2)
+	if _, err := rand.Read(bs); err != nil {
+		return "", err
+	}
+
+	secret := f.Prefix + hex.EncodeToString(bs)
+	if f.Checksum {
+		secret += checksumOf(secret)
+	}
+
+	return secret, nil
+}
+
+// Pattern is a regular expression matching secrets in the format, meant to be
+// registered with secret scanners.
+func (f *SecretFormat) Pattern() string {
+	length := secretBodyLength
+	if f.Checksum {
+		length += secretChecksumLength
+	}
+
+	return fmt.Sprintf(`%s[0-9a-f]{%d}`, regexp.QuoteMeta(f.Prefix), length)
+}
+
+// FormatVerification reports whether a string looks like a secret generated in
+// the configured format. It says nothing about whether a key with the secret
+// exists.
+type FormatVerification struct {
+	Valid         bool   `json:"valid"`
+	PrefixMatches bool   `json:"prefixMatches"`
+	ChecksumValid *bool  `json:"checksumValid,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+	Pattern       string `json:"pattern"`
+}
+
+type VerifyFormatRequest struct {
+	Key string `json:"key"`
+}
+
+// Verify checks secret against the format.
+func (f *SecretFormat) Verify(secret string) *FormatVerification {
+	v := &FormatVerification{
+		Pattern: f.Pattern(),
+	}
+
+	secret = strings.TrimSpace(secret)
+	if !strings.HasPrefix(secret, f.Prefix) {
+		v.Reason = "key does not start with the configured prefix"
+		return v
+	}
+	v.PrefixMatches = true
+
+	rest := strings.TrimPrefix(secret, f.Prefix)
+	length := secretBodyLength
+	if f.Checksum {
+		length += secretChecksumLength
+	}
+
+	if len(rest) != length || !hexRegex.MatchString(rest) {
+		v.Reason = fmt.Sprintf("key must have %d hex characters after the prefix", length)
+		return v
+	}
+
+	if f.Checksum {
+		body := secret[:len(secret)-secretChecksumLength]
+		valid := checksumOf(body) == secret[len(secret)-secretChecksumLength:]
+		v.ChecksumValid = &valid
+
+		if !valid {
+			v.Reason = "key checksum does not match"
+			return v
+		}
+	}
+
+	v.Valid = true
+	return v
+}
@@ -0,0 +1,25 @@
+package key
+
+const (
+	LimitCostLimitInUsd         = "costLimitInUsd"
+	LimitCostLimitInUsdOverTime = "costLimitInUsdOverTime"
+	LimitRateLimitOverTime      = "rateLimitOverTime"
+)
+
+// LimitUsage is how much of a limit of a key has been used. Cost limits are
+// in USD and rate limits are in requests.
+type LimitUsage struct {
+	Limit string   `json:"limit"`
+	Used  float64  `json:"used"`
+	Max   float64  `json:"max"`
+	Unit  TimeUnit `json:"unit,omitempty"`
+}
+
+// Ratio is the used fraction of the limit.
+func (u *LimitUsage) Ratio() float64 {
+	if u.Max <= 0 {
+		return 0
+	}
+
+	return u.Used / u.Max
+}
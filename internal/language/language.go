@@ -0,0 +1,167 @@
+// Package language detects the language of request texts. Detection is
+// heuristic: the script of the letters decides most languages, and languages
+// written in the Latin script are told apart by their most common words.
+package language
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Undetermined is returned when texts are too short, or too mixed, for a
+// language to be detected.
+const Undetermined = ""
+
+// minLetters is the number of letters below which texts are not detected,
+// since a few words are as likely to be names or code as language.
+const minLetters = 12
+
+// scripts maps the scripts that are written by mostly one language to the ISO
+// 639-1 code of that language. Han is shared by Chinese and Japanese, and is
+// told apart by kana.
+var scripts = []struct {
+	table *unicode.RangeTable
+	code  string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Han, "zh"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Bengali, "bn"},
+	{unicode.Tamil, "ta"},
+	{unicode.Telugu, "te"},
+	{unicode.Georgian, "ka"},
+	{unicode.Armenian, "hy"},
+	{unicode.Latin, "latin"},
+}
+
+// stopwords are frequent words of languages written in the Latin script that
+// are rare in the others.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "was", "of", "to", "in", "that", "it", "for", "with", "you", "this", "what", "how", "be", "have", "not", "on"},
+	"es": {"el", "los", "las", "es", "está", "son", "de", "que", "y", "en", "por", "para", "con", "una", "del", "cómo", "qué", "pero", "muy", "mi"},
+	"fr": {"le", "les", "est", "sont", "de", "des", "et", "que", "dans", "pour", "avec", "une", "du", "ce", "qui", "pas", "je", "vous", "mais", "comment"},
+	"de": {"der", "die", "das", "ist", "sind", "und", "zu", "den", "mit", "nicht", "ein", "eine", "ich", "sie", "auf", "für", "wie", "was", "auch", "es"},
+	"it": {"il", "gli", "è", "sono", "di", "che", "e", "per", "con", "una", "non", "del", "della", "come", "anche", "mi", "questo", "ma", "lo", "cosa"},
+	"pt": {"o", "os", "é", "são", "de", "que", "e", "em", "para", "com", "uma", "não", "do", "da", "como", "mas", "você", "está", "isso", "meu"},
+	"nl": {"de", "het", "is", "zijn", "en", "van", "een", "dat", "niet", "met", "voor", "op", "ik", "je", "wat", "hoe", "ook", "maar", "deze", "naar"},
+	"id": {"yang", "dan", "di", "ini", "itu", "dengan", "untuk", "tidak", "dari", "ada", "saya", "akan", "apa", "bagaimana", "juga", "kami", "bisa", "adalah", "ke", "pada"},
+	"tr": {"ve", "bir", "bu", "da", "de", "için", "ile", "ne", "nasıl", "değil", "çok", "ben", "sen", "mi", "gibi", "daha", "olan", "var", "ama", "şey"},
+	"pl": {"i", "w", "nie", "na", "się", "jest", "że", "do", "to", "jak", "co", "z", "czy", "ale", "dla", "są", "mnie", "tak", "przez", "jestem"},
+}
+
+var stopwordLanguages = func() map[string][]string {
+	languages := map[string][]string{}
+	for code, words := range stopwords {
+		for _, word := range words {
+			languages[word] = append(languages[word], code)
+		}
+	}
+
+	return languages
+}()
+
+// Valid reports whether code looks like a lowercase ISO 639 language code.
+func Valid(code string) bool {
+	if len(code) < 2 || len(code) > 3 {
+		return false
+	}
+
+	for _, r := range code {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Detect returns the ISO 639-1 code of the language most of texts are written
+// in, or Undetermined.
+func Detect(texts []string) string {
+	counts := map[string]int{}
+	letters := 0
+	for _, text := range texts {
+		for _, r := range text {
+			if !unicode.IsLetter(r) {
+				continue
+			}
+
+			letters++
+			for _, s := range scripts {
+				if unicode.Is(s.table, r) {
+					counts[s.code]++
+					break
+				}
+			}
+		}
+	}
+
+	if letters < minLetters {
+		return Undetermined
+	}
+
+	// kana is written alongside Han, so any amount of it means Japanese
+	if counts["ja"] != 0 && counts["ja"]+counts["zh"] > counts["latin"] {
+		return "ja"
+	}
+
+	dominant, highest := Undetermined, 0
+	for code, count := range counts {
+		if count > highest || (count == highest && code < dominant) {
+			dominant, highest = code, count
+		}
+	}
+
+	// a script has to account for most letters, so that a prompt quoting a
+	// few words of another language is detected as its own
+	if highest*2 < letters {
+		return Undetermined
+	}
+
+	if dominant == "latin" {
+		return detectLatin(texts)
+	}
+
+	return dominant
+}
+
+// detectLatin tells apart languages written in the Latin script by counting
+// their stopwords.
+func detectLatin(texts []string) string {
+	scores := map[string]int{}
+	for _, text := range texts {
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && r != '\''
+		})
+
+		for _, word := range words {
+			for _, code := range stopwordLanguages[word] {
+				scores[code]++
+			}
+		}
+	}
+
+	best, highest, second := Undetermined, 0, 0
+	for code, score := range scores {
+		if score > highest || (score == highest && code < best) {
+			second = highest
+			best, highest = code, score
+		} else if score > second {
+			second = score
+		}
+	}
+
+	// a tie between languages, or a single stopword, is not enough to tell
+	if highest < 2 || highest == second {
+		return Undetermined
+	}
+
+	return best
+}
@@ -0,0 +1,138 @@
+package manager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+const (
+	bulkRevokeConfirmationTtl = 10 * time.Minute
+	// bulk revoke confirmations share the one time token cache of claim links
+	bulkRevokeTokenPrefix = "bulk-revoke:"
+)
+
+func (m *Manager) matchBulkRevoke(r *key.BulkRevokeRequest) ([]string, error) {
+	revoked := false
+	res, err := m.s.GetKeysV2(r.Tags, nil, &revoked, 0, 0, "", "", false, r.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, k := range res.Keys {
+		if len(r.SettingId) != 0 && !contains(r.SettingId, k.GetSettingIds()) {
+			continue
+		}
+
+		ids = append(ids, k.KeyId)
+	}
+
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+// bulkRevokeDigest binds a confirmation token to the filter it was issued for
+// and to the keys that the filter matched at the time.
+func bulkRevokeDigest(r *key.BulkRevokeRequest, ids []string) (string, error) {
+	tags := append([]string{}, r.Tags...)
+	sort.Strings(tags)
+
+	data, err := json.Marshal([]any{tags, r.Namespace, r.SettingId, ids})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// BulkRevokeKeys revokes the active keys matched by a filter in two steps. The
+// first call returns the matched keys with a confirmation token, and the
+// second call with the token revokes them. The token is rejected if the keys
+// matched by the filter changed in between, so that keys created after the
+// preview are never revoked unseen.
+func (m *Manager) BulkRevokeKeys(r *key.BulkRevokeRequest) (*key.BulkRevokeResult, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	ids, err := m.matchBulkRevoke(r)
+	if err != nil {
+		return nil, err
+	}
+
+	digest, err := bulkRevokeDigest(r, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(r.ConfirmationToken) == 0 {
+		result := &key.BulkRevokeResult{
+			Count:  len(ids),
+			KeyIds: ids,
+		}
+
+		if len(ids) == 0 {
+			return result, nil
+		}
+
+		token, err := newSecret()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := m.clkc.Set(bulkRevokeTokenPrefix+token, digest, bulkRevokeConfirmationTtl); err != nil {
+			return nil, err
+		}
+
+		result.ConfirmationToken = token
+		result.ExpiresAt = time.Now().Add(bulkRevokeConfirmationTtl).Unix()
+
+		return result, nil
+	}
+
+	confirmed, err := m.clkc.GetAndDelete(bulkRevokeTokenPrefix + r.ConfirmationToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(confirmed) == 0 {
+		return nil, internal_errors.NewValidationError("confirmation token is invalid, expired or already used").WithFields(&internal_errors.FieldError{
+			Field:  "confirmationToken",
+			Reason: "invalid",
+		})
+	}
+
+	if confirmed != digest {
+		return nil, internal_errors.NewValidationError("keys matched by the filter changed since the preview, preview the revocation again").WithFields(&internal_errors.FieldError{
+			Field:  "confirmationToken",
+			Reason: "stale",
+		})
+	}
+
+	truePtr := true
+	for _, id := range ids {
+		_, err := m.UpdateKey(id, &key.UpdateKey{
+			Revoked:       &truePtr,
+			RevokedReason: r.RevokedReason,
+		})
+		if err != nil {
+			telemetry.Incr("bricksllm.manager.bulk_revoke_keys.update_key_error", nil, 1)
+			return nil, err
+		}
+	}
+
+	return &key.BulkRevokeResult{
+		Revoked: true,
+		Count:   len(ids),
+		KeyIds:  ids,
+	}, nil
+}
@@ -0,0 +1,333 @@
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/catalog"
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+// ModelCatalog tells validation whether a model has disappeared upstream.
+type ModelCatalog interface {
+	IsUnavailable(provider, model string) bool
+}
+
+type CatalogStorage interface {
+	GetCatalogModels(provider string) ([]*catalog.Model, error)
+	GetProviderSettings(withSecret bool, ids []string) ([]*provider.Setting, error)
+	GetRoutes() ([]*route.Route, error)
+	GetCatalogModel(id string) (*catalog.Model, error)
+	GetModelPricing(overriddenOnly bool) ([]*catalog.Pricing, error)
+	GetModelPricingById(id string) (*catalog.Pricing, error)
+	UpsertModelPricing(p *catalog.Pricing) (*catalog.Pricing, error)
+	UpsertModelPricings(ps []*catalog.Pricing) ([]*catalog.Pricing, error)
+}
+
+type CatalogManager struct {
+	s  CatalogStorage
+	mc ModelCatalog
+}
+
+func NewCatalogManager(s CatalogStorage, mc ModelCatalog) *CatalogManager {
+	return &CatalogManager{
+		s:  s,
+		mc: mc,
+	}
+}
+
+type modelRef struct {
+	provider string
+	model    string
+}
+
+// GetModels lists catalog models along with the provider settings and routes
+// that reference them and their pricing. Referenced models that are not
+// listed upstream by a synced provider are included and marked as missing,
+// and so are priced models that were never listed upstream.
+func (m *CatalogManager) GetModels(provider string) ([]*catalog.Model, error) {
+	models, err := m.s.GetCatalogModels(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := m.s.GetProviderSettings(false, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := m.s.GetRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	pricing, err := m.s.GetModelPricing(false)
+	if err != nil {
+		return nil, err
+	}
+
+	priced := map[modelRef]*catalog.Pricing{}
+	for _, p := range pricing {
+		priced[modelRef{p.Provider, p.Model}] = p
+	}
+
+	references := map[modelRef][]*catalog.Reference{}
+	for _, s := range settings {
+		for _, model := range s.AllowedModels {
+			k := modelRef{s.Provider, model}
+			references[k] = append(references[k], &catalog.Reference{
+				Kind: catalog.ReferenceProviderSetting,
+				Id:   s.Id,
+				Name: s.Name,
+			})
+		}
+	}
+
+	for _, r := range routes {
+		for _, step := range r.AllSteps() {
+			if len(step.Model) == 0 {
+				continue
+			}
+
+			k := modelRef{step.Provider, step.Model}
+			references[k] = append(references[k], &catalog.Reference{
+				Kind: catalog.ReferenceRoute,
+				Id:   r.Id,
+				Name: r.Name,
+			})
+		}
+	}
+
+	listed := map[modelRef]bool{}
+	for _, model := range models {
+		k := modelRef{model.Provider, model.Model}
+		listed[k] = true
+		model.References = references[k]
+		model.Missing = !model.Available && len(model.References) != 0
+		model.Pricing = priced[k]
+	}
+
+	for k, refs := range references {
+		if listed[k] {
+			continue
+		}
+
+		if len(provider) != 0 && k.provider != provider {
+			continue
+		}
+
+		if m.mc == nil || !m.mc.IsUnavailable(k.provider, k.model) {
+			continue
+		}
+
+		listed[k] = true
+		models = append(models, &catalog.Model{
+			Provider:   k.provider,
+			Model:      k.model,
+			Missing:    true,
+			References: refs,
+			Pricing:    priced[k],
+		})
+	}
+
+	for k, p := range priced {
+		if listed[k] {
+			continue
+		}
+
+		if len(provider) != 0 && k.provider != provider {
+			continue
+		}
+
+		models = append(models, &catalog.Model{
+			Id:         p.Id,
+			Provider:   k.provider,
+			Model:      k.model,
+			References: references[k],
+			Pricing:    p,
+		})
+	}
+
+	sort.SliceStable(models, func(i, j int) bool {
+		if models[i].Provider != models[j].Provider {
+			return models[i].Provider < models[j].Provider
+		}
+
+		return models[i].Model < models[j].Model
+	})
+
+	return models, nil
+}
+
+// UpdateModel overrides the pricing and capabilities of a model. The id is
+// either the id of a catalog model or the id of a pricing table entry, as
+// listed by GetModels.
+func (m *CatalogManager) UpdateModel(id string, u *catalog.UpdatePricing) (*catalog.Pricing, error) {
+	if err := validateUpdatePricing(u); err != nil {
+		return nil, err
+	}
+
+	existing, err := m.s.GetModelPricingById(id)
+	if _, ok := err.(notFoundError); ok {
+		model, err := m.s.GetCatalogModel(id)
+		if err != nil {
+			return nil, err
+		}
+
+		existing = m.pricingOf(model.Provider, model.Model)
+	} else if err != nil {
+		return nil, err
+	}
+
+	u.Apply(existing)
+	existing.UpdatedAt = time.Now().Unix()
+
+	telemetry.Incr("bricksllm.catalog_manager.update_model.overridden", []string{"provider:" + existing.Provider}, 1)
+
+	return m.s.UpsertModelPricing(existing)
+}
+
+// pricingOf returns the pricing table entry of a model, or a blank entry for
+// a model without one.
+func (m *CatalogManager) pricingOf(provider, model string) *catalog.Pricing {
+	pricing, err := m.s.GetModelPricing(false)
+	if err == nil {
+		for _, p := range pricing {
+			if p.Provider == provider && p.Model == model {
+				return p
+			}
+		}
+	}
+
+	return &catalog.Pricing{
+		Provider:          provider,
+		Model:             model,
+		SupportsStreaming: true,
+	}
+}
+
+func validateUpdatePricing(u *catalog.UpdatePricing) error {
+	fields := []*internal_errors.FieldError{}
+	if u.ContextWindow != nil && *u.ContextWindow < 0 {
+		fields = append(fields, &internal_errors.FieldError{Field: "contextWindow", Reason: "cannot be negative"})
+	}
+
+	if u.InputCostPerMillionTokens != nil && *u.InputCostPerMillionTokens < 0 {
+		fields = append(fields, &internal_errors.FieldError{Field: "inputCostPerMillionTokens", Reason: "cannot be negative"})
+	}
+
+	if u.OutputCostPerMillionTokens != nil && *u.OutputCostPerMillionTokens < 0 {
+		fields = append(fields, &internal_errors.FieldError{Field: "outputCostPerMillionTokens", Reason: "cannot be negative"})
+	}
+
+	if len(fields) != 0 {
+		return internal_errors.NewFieldsValidationError(fields)
+	}
+
+	return nil
+}
+
+// GetPricing returns every entry of the pricing table.
+func (m *CatalogManager) GetPricing() ([]*catalog.Pricing, error) {
+	return m.s.GetModelPricing(false)
+}
+
+// PutPricing overrides the pricing of the given models, adding models that
+// the gateway does not price yet. Models that are not given are left as they
+// are.
+func (m *CatalogManager) PutPricing(ps []*catalog.Pricing) ([]*catalog.Pricing, error) {
+	if err := validatePricing(ps); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	for _, p := range ps {
+		p.Id = ""
+		p.UpdatedAt = now
+	}
+
+	stored, err := m.s.UpsertModelPricings(ps)
+	if err != nil {
+		return nil, err
+	}
+
+	telemetry.Incr("bricksllm.catalog_manager.put_pricing.overridden", nil, float64(len(stored)))
+
+	return stored, nil
+}
+
+func validatePricing(ps []*catalog.Pricing) error {
+	if len(ps) == 0 {
+		return internal_errors.NewValidationError("pricing cannot be empty")
+	}
+
+	fields := []*internal_errors.FieldError{}
+	seen := map[modelRef]bool{}
+	for index, p := range ps {
+		prefix := fmt.Sprintf("[%d]", index)
+		if p == nil {
+			fields = append(fields, &internal_errors.FieldError{Field: prefix, Reason: "required"})
+			continue
+		}
+
+		if len(p.Provider) == 0 {
+			fields = append(fields, &internal_errors.FieldError{Field: prefix + ".provider", Reason: "required"})
+		}
+
+		if len(p.Model) == 0 {
+			fields = append(fields, &internal_errors.FieldError{Field: prefix + ".model", Reason: "required"})
+		}
+
+		k := modelRef{p.Provider, p.Model}
+		if seen[k] {
+			fields = append(fields, &internal_errors.FieldError{Field: prefix + ".model", Reason: "duplicated", Value: p.Model})
+		}
+
+		seen[k] = true
+
+		if p.ContextWindow < 0 {
+			fields = append(fields, &internal_errors.FieldError{Field: prefix + ".contextWindow", Reason: "cannot be negative"})
+		}
+
+		if p.InputCostPerMillionTokens < 0 {
+			fields = append(fields, &internal_errors.FieldError{Field: prefix + ".inputCostPerMillionTokens", Reason: "cannot be negative"})
+		}
+
+		if p.OutputCostPerMillionTokens < 0 {
+			fields = append(fields, &internal_errors.FieldError{Field: prefix + ".outputCostPerMillionTokens", Reason: "cannot be negative"})
+		}
+	}
+
+	if len(fields) != 0 {
+		return internal_errors.NewFieldsValidationError(fields)
+	}
+
+	return nil
+}
+
+func checkModelsListed(mc ModelCatalog, provider string, field string, models []string) error {
+	if mc == nil {
+		return nil
+	}
+
+	fields := []*internal_errors.FieldError{}
+	for index, model := range models {
+		if mc.IsUnavailable(provider, model) {
+			fields = append(fields, &internal_errors.FieldError{
+				Field:  fmt.Sprintf("%s.[%d]", field, index),
+				Reason: "not listed upstream by " + provider,
+				Value:  model,
+			})
+		}
+	}
+
+	if len(fields) != 0 {
+		return internal_errors.NewFieldsValidationError(fields)
+	}
+
+	return nil
+}
@@ -0,0 +1,250 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"go.uber.org/zap"
+)
+
+const defaultCompareConcurrency = 4
+
+type CompareStorage interface {
+	GetProviderSettings(withSecret bool, ids []string) ([]*provider.Setting, error)
+	GetRoute(id string) (*route.Route, error)
+}
+
+type Decryptor interface {
+	Decrypt(input string, headers map[string]string) (string, error)
+	Enabled() bool
+}
+
+// discardRecorder drops events of failed attempts since comparisons are not billed to any key.
+type discardRecorder struct{}
+
+func (discardRecorder) RecordEvent(e *event.Event) error {
+	return nil
+}
+
+type CompareManager struct {
+	s      CompareStorage
+	d      Decryptor
+	costs  map[string]route.CostEstimator
+	client http.Client
+	log    *zap.Logger
+}
+
+func NewCompareManager(s CompareStorage, d Decryptor, costs map[string]route.CostEstimator, log *zap.Logger) *CompareManager {
+	return &CompareManager{
+		s:     s,
+		d:     d,
+		costs: costs,
+		log:   log,
+	}
+}
+
+func (m *CompareManager) decryptSettings(settings []*provider.Setting) map[string]*provider.Setting {
+	result := map[string]*provider.Setting{}
+	for _, s := range settings {
+		copied := *s
+		copied.Setting = map[string]string{}
+		for k, v := range s.Setting {
+			copied.Setting[k] = v
+		}
+
+		if m.d != nil && m.d.Enabled() && len(copied.Setting["apikey"]) != 0 {
+			decrypted, err := m.d.Decrypt(copied.Setting["apikey"], map[string]string{"X-UPDATED-AT": strconv.FormatInt(s.UpdatedAt, 10)})
+			if err == nil {
+				copied.Setting["apikey"] = decrypted
+			}
+		}
+
+		result[copied.Id] = &copied
+	}
+
+	return result
+}
+
+func (m *CompareManager) Compare(r *route.CompareRequest) (*route.CompareResponse, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	settings, err := m.s.GetProviderSettings(true, r.SettingIds)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(settings) != len(r.SettingIds) {
+		return nil, internal_errors.NewNotFoundError("one or more provider settings are not found")
+	}
+
+	routes := make([]*route.Route, len(r.Targets))
+	for index, t := range r.Targets {
+		if len(t.RouteId) != 0 {
+			rc, err := m.s.GetRoute(t.RouteId)
+			if err != nil {
+				return nil, err
+			}
+
+			if !rc.ValidateSettings(settings) {
+				return nil, internal_errors.NewValidationError(fmt.Sprintf("provider settings are not compatible with route %s", t.RouteId))
+			}
+
+			routes[index] = rc
+			continue
+		}
+
+		timeout := t.Timeout
+		if len(timeout) == 0 {
+			timeout = "2m"
+		}
+
+		if _, err := time.ParseDuration(timeout); err != nil {
+			return nil, internal_errors.NewValidationError(fmt.Sprintf("targets.[%d].timeout is invalid", index))
+		}
+
+		routes[index] = &route.Route{
+			Steps: []*route.Step{{
+				Provider:      t.Provider,
+				Model:         t.Model,
+				Params:        t.Params,
+				RequestParams: t.RequestParams,
+				Timeout:       timeout,
+			}},
+		}
+
+		if !routes[index].ValidateSettings(settings) {
+			return nil, internal_errors.NewValidationError(fmt.Sprintf("no provider setting for %s is given", t.Provider))
+		}
+	}
+
+	decrypted := m.decryptSettings(settings)
+
+	concurrency := r.Concurrency
+	if concurrency == 0 {
+		concurrency = defaultCompareConcurrency
+	}
+
+	results := make([]*route.CompareResult, len(r.Targets))
+	sem := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+
+	for index := range r.Targets {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(index int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			results[index] = m.run(r.Targets[index], routes[index], decrypted, r.Request)
+		}(index)
+	}
+
+	wg.Wait()
+
+	return &route.CompareResponse{
+		Results: results,
+	}, nil
+}
+
+func (m *CompareManager) run(t *route.CompareTarget, rc *route.Route, settings map[string]*provider.Setting, body []byte) *route.CompareResult {
+	result := &route.CompareResult{
+		Target: t,
+	}
+
+	forwarded, err := http.NewRequest(http.MethodPost, "/api/compare", bytes.NewReader(body))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	forwarded.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	res, err := rc.RunStepsV2(&route.Request{
+		Settings:  settings,
+		Key:       &key.ResponseKey{},
+		Client:    m.client,
+		Forwarded: forwarded,
+		Start:     start,
+		Costs:     m.costs,
+	}, discardRecorder{}, m.log, &key.ResponseKey{})
+	result.LatencyInMs = int(time.Since(start).Milliseconds())
+
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	defer res.Cancel()
+
+	result.Provider = res.Provider
+	result.Model = res.Model
+	result.Status = res.Response.StatusCode
+
+	data := res.Data
+	if res.Response.StatusCode == http.StatusOK {
+		defer res.Response.Body.Close()
+
+		data, err = io.ReadAll(res.Response.Body)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+
+		result.LatencyInMs = int(time.Since(start).Milliseconds())
+	}
+
+	if json.Valid(data) {
+		result.Response = data
+	}
+
+	if res.Response.StatusCode != http.StatusOK {
+		result.Error = "provider responded with a non 200 status code"
+		return result
+	}
+
+	usage := &struct {
+		Model string `json:"model"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}{}
+
+	if err := json.Unmarshal(data, usage); err != nil {
+		return result
+	}
+
+	result.PromptTokenCount = usage.Usage.PromptTokens
+	result.CompletionTokenCount = usage.Usage.CompletionTokens
+
+	model := usage.Model
+	if len(model) == 0 {
+		model = res.Model
+	}
+
+	if ce, ok := m.costs[res.Provider]; ok && ce != nil {
+		cost, err := ce.EstimateTotalCost(model, usage.Usage.PromptTokens, usage.Usage.CompletionTokens)
+		if err == nil {
+			result.CostInUsd = cost
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,1234 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/dryrun"
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/gitops"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+)
+
+type ConfigStorage interface {
+	GetProviderSettings(withSecret bool, ids []string) ([]*provider.Setting, error)
+	GetAllPolicies() ([]*policy.Policy, error)
+	GetAllKeys() ([]*key.ResponseKey, error)
+	GetRoutes() ([]*route.Route, error)
+	GetCustomProviders() ([]*custom.Provider, error)
+}
+
+type configKeyManager interface {
+	CreateKey(rk *key.RequestKey) (*key.ResponseKey, error)
+	UpdateKey(id string, uk *key.UpdateKey) (*key.ResponseKey, error)
+	DeleteKey(id string) error
+}
+
+type configSettingsManager interface {
+	CreateSetting(setting *provider.Setting) (*provider.Setting, error)
+	UpdateSetting(id string, setting *provider.UpdateSetting) (*provider.Setting, error)
+	DeleteSetting(id string, cascade bool) ([]*dryrun.Dependent, error)
+}
+
+type configPolicyManager interface {
+	CreatePolicy(p *policy.Policy) (*policy.Policy, error)
+	UpdatePolicy(id string, p *policy.UpdatePolicy) (*policy.Policy, error)
+	DeletePolicy(id string, cascade bool) ([]*dryrun.Dependent, error)
+}
+
+type configRouteManager interface {
+	CreateRoute(r *route.Route) (*route.Route, error)
+	ReplaceRoute(id string, r *route.Route, version int) (*route.Route, error)
+	DeleteRoute(id string) error
+}
+
+type configCustomProviderManager interface {
+	CreateCustomProvider(provider *custom.Provider) (*custom.Provider, error)
+	UpdateCustomProvider(id string, provider *custom.UpdateProvider) (*custom.Provider, error)
+}
+
+type ConfigManager struct {
+	s   ConfigStorage
+	km  configKeyManager
+	psm configSettingsManager
+	pm  configPolicyManager
+	rm  configRouteManager
+	cpm configCustomProviderManager
+}
+
+func NewConfigManager(s ConfigStorage, km configKeyManager, psm configSettingsManager, pm configPolicyManager, rm configRouteManager, cpm configCustomProviderManager) *ConfigManager {
+	return &ConfigManager{
+		s:   s,
+		km:  km,
+		psm: psm,
+		pm:  pm,
+		rm:  rm,
+		cpm: cpm,
+	}
+}
+
+func jsonEqual(a, b any) bool {
+	ab, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+
+	bb, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+
+	return string(ab) == string(bb)
+}
+
+func sortedCopy(input []string) []string {
+	copied := append([]string{}, input...)
+	sort.Strings(copied)
+	return copied
+}
+
+// applier carries ids resolved while applying so that later sections can reference
+// resources declared earlier in the document. New resources get a placeholder id in
+// dry runs. Imports set a conflict strategy and skip resources that cannot be
+// created from an archive instead of failing.
+type applier struct {
+	m         *ConfigManager
+	result    *gitops.ApplyResult
+	doc       *gitops.Document
+	setting   map[string]string
+	policy    map[string]string
+	key       map[string]string
+	importing bool
+	strategy  string
+	skipped   map[string]bool
+}
+
+func (a *applier) record(kind, name, id, action string) {
+	a.result.Changes = append(a.result.Changes, &gitops.Change{
+		Kind:   kind,
+		Name:   name,
+		Id:     id,
+		Action: action,
+	})
+}
+
+func (a *applier) skip(kind, name, id, reason string) {
+	a.skipped[kind+"/"+name] = true
+	a.result.Changes = append(a.result.Changes, &gitops.Change{
+		Kind:   kind,
+		Name:   name,
+		Id:     id,
+		Action: gitops.ActionSkip,
+		Reason: reason,
+	})
+}
+
+func (a *applier) isSkipped(kind, name string) bool {
+	return a.skipped[kind+"/"+name]
+}
+
+// keepExisting reports whether an existing resource that differs from the
+// document is left as is because of the skip strategy.
+func (a *applier) keepExisting(kind, name, id string) bool {
+	if a.strategy != gitops.StrategySkip {
+		return false
+	}
+
+	a.skip(kind, name, id, "already exists")
+	return true
+}
+
+func placeholder(kind, name string) string {
+	return fmt.Sprintf("<new %s %s>", kind, name)
+}
+
+func (m *ConfigManager) newApplier(doc *gitops.Document, dryRun, prune bool) *applier {
+	return &applier{
+		m:       m,
+		doc:     doc,
+		setting: map[string]string{},
+		policy:  map[string]string{},
+		key:     map[string]string{},
+		skipped: map[string]bool{},
+		result: &gitops.ApplyResult{
+			DryRun:  dryRun,
+			Prune:   prune,
+			Changes: []*gitops.Change{},
+		},
+	}
+}
+
+func (m *ConfigManager) Apply(data []byte, dryRun, prune bool) (*gitops.ApplyResult, error) {
+	doc, err := gitops.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := doc.Validate(); err != nil {
+		return nil, err
+	}
+
+	return m.newApplier(doc, dryRun, prune).run()
+}
+
+// Import applies an exported archive without pruning. With the fail strategy
+// nothing is written when any existing resource would be changed.
+func (m *ConfigManager) Import(data []byte, strategy string, dryRun bool) (*gitops.ApplyResult, error) {
+	if len(strategy) == 0 {
+		strategy = gitops.StrategyFail
+	}
+
+	if !gitops.IsValidStrategy(strategy) {
+		return nil, internal_errors.NewValidationError(fmt.Sprintf("strategy %s is not one of skip, overwrite or fail", strategy))
+	}
+
+	archive, err := gitops.ParseArchive(data)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &archive.Document
+	if err := doc.Validate(); err != nil {
+		return nil, err
+	}
+
+	if strategy == gitops.StrategyFail {
+		preview := m.newApplier(doc, true, false)
+		preview.importing = true
+		preview.strategy = strategy
+		preview.result.Strategy = strategy
+
+		result, err := preview.run()
+		if err != nil {
+			return result, err
+		}
+
+		conflicts := []string{}
+		for _, ch := range result.Changes {
+			if ch.Action == gitops.ActionUpdate || ch.Action == gitops.ActionReplace {
+				conflicts = append(conflicts, fmt.Sprintf("%s %s", ch.Kind, ch.Name))
+			}
+		}
+
+		if len(conflicts) != 0 {
+			return result, internal_errors.NewConflictError(fmt.Sprintf("archive conflicts with existing resources: %s", strings.Join(conflicts, ", ")))
+		}
+
+		if dryRun {
+			return result, nil
+		}
+	}
+
+	a := m.newApplier(doc, dryRun, false)
+	a.importing = true
+	a.strategy = strategy
+	a.result.Strategy = strategy
+
+	return a.run()
+}
+
+func (a *applier) run() (*gitops.ApplyResult, error) {
+	m := a.m
+
+	settings, err := m.s.GetProviderSettings(true, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := m.s.GetAllPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := m.s.GetAllKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := m.s.GetRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	providers, err := m.s.GetCustomProviders()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.applyCustomProviders(providers); err != nil {
+		return a.result, err
+	}
+
+	if err := a.applySettings(settings); err != nil {
+		return a.result, err
+	}
+
+	if err := a.applyPolicies(policies); err != nil {
+		return a.result, err
+	}
+
+	if err := a.applyKeys(keys); err != nil {
+		return a.result, err
+	}
+
+	if err := a.applyRoutes(routes); err != nil {
+		return a.result, err
+	}
+
+	if a.result.Prune {
+		if err := a.prune(settings, policies, keys, routes); err != nil {
+			return a.result, err
+		}
+	}
+
+	return a.result, nil
+}
+
+func (a *applier) applyCustomProviders(existing []*custom.Provider) error {
+	byName := map[string]*custom.Provider{}
+	for _, p := range existing {
+		byName[p.Provider] = p
+	}
+
+	for _, desired := range a.doc.CustomProviders {
+		name := strings.ToLower(desired.Provider)
+
+		current, ok := byName[name]
+		if !ok {
+			id := placeholder(gitops.KindCustomProvider, name)
+			if !a.result.DryRun {
+				p := *desired
+				created, err := a.m.cpm.CreateCustomProvider(&p)
+				if err != nil {
+					return fmt.Errorf("failed to create custom provider %s: %w", name, err)
+				}
+
+				id = created.Id
+			}
+
+			a.record(gitops.KindCustomProvider, name, id, gitops.ActionCreate)
+			continue
+		}
+
+		authParam := strings.ToLower(desired.AuthenticationParam)
+		if jsonEqual(desired.RouteConfigs, current.RouteConfigs) && authParam == current.AuthenticationParam {
+			a.record(gitops.KindCustomProvider, name, current.Id, gitops.ActionUnchanged)
+			continue
+		}
+
+		if a.keepExisting(gitops.KindCustomProvider, name, current.Id) {
+			continue
+		}
+
+		if !a.result.DryRun {
+			_, err := a.m.cpm.UpdateCustomProvider(current.Id, &custom.UpdateProvider{
+				RouteConfigs:        desired.RouteConfigs,
+				AuthenticationParam: &authParam,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to update custom provider %s: %w", name, err)
+			}
+		}
+
+		a.record(gitops.KindCustomProvider, name, current.Id, gitops.ActionUpdate)
+	}
+
+	return nil
+}
+
+func (a *applier) applySettings(existing []*provider.Setting) error {
+	byName := map[string]*provider.Setting{}
+	for _, s := range existing {
+		if _, ok := byName[s.Name]; ok && len(s.Name) != 0 {
+			byName[s.Name] = nil
+			continue
+		}
+
+		byName[s.Name] = s
+	}
+
+	for _, desired := range a.doc.ProviderSettings {
+		current, ok := byName[desired.Name]
+		if ok && current == nil {
+			return internal_errors.NewValidationError(fmt.Sprintf("provider setting name %s is used by more than one provider setting", desired.Name))
+		}
+
+		if !ok {
+			if missing := findMissingAuthParams(desired.Provider, desired.Setting); a.importing && len(missing) != 0 {
+				a.skip(gitops.KindProviderSetting, desired.Name, "", fmt.Sprintf("credentials are not exported, add %s to the archive to create it", missing))
+				continue
+			}
+
+			id := placeholder(gitops.KindProviderSetting, desired.Name)
+			if !a.result.DryRun {
+				created, err := a.m.psm.CreateSetting(desired)
+				if err != nil {
+					return fmt.Errorf("failed to create provider setting %s: %w", desired.Name, err)
+				}
+
+				id = created.Id
+			}
+
+			a.setting[desired.Name] = id
+			a.record(gitops.KindProviderSetting, desired.Name, id, gitops.ActionCreate)
+			continue
+		}
+
+		a.setting[desired.Name] = current.Id
+
+		if desired.Provider != current.Provider {
+			return internal_errors.NewValidationError(fmt.Sprintf("provider of provider setting %s cannot be changed", desired.Name))
+		}
+
+		// secrets are only compared when given since they are never returned in plain text
+		settingChanged := len(desired.Setting) != 0 && !jsonEqual(desired.Setting, current.Setting)
+		labelsChanged := (len(desired.Labels) != 0 || len(current.Labels) != 0) && !jsonEqual(desired.Labels, current.Labels)
+		headersChanged := (len(desired.Headers) != 0 || len(current.Headers) != 0) && !jsonEqual(desired.Headers, current.Headers)
+		queryParamsChanged := (len(desired.QueryParams) != 0 || len(current.QueryParams) != 0) && !jsonEqual(desired.QueryParams, current.QueryParams)
+		spendLimitChanged := (desired.SpendLimit != nil || current.SpendLimit != nil) && !jsonEqual(desired.SpendLimit, current.SpendLimit)
+		transportChanged := (desired.Transport.Set() || current.Transport.Set()) && !jsonEqual(desired.Transport, current.Transport)
+		tokenBudgetChanged := (desired.TokenBudget.Set() || current.TokenBudget.Set()) && !jsonEqual(desired.TokenBudget, current.TokenBudget)
+		if !settingChanged && !labelsChanged && !headersChanged && !queryParamsChanged && !spendLimitChanged && !transportChanged && !tokenBudgetChanged && desired.Environment == current.Environment && jsonEqual(desired.AllowedModels, current.AllowedModels) && jsonEqual(desired.CostMap, current.CostMap) {
+			a.record(gitops.KindProviderSetting, desired.Name, current.Id, gitops.ActionUnchanged)
+			continue
+		}
+
+		if a.keepExisting(gitops.KindProviderSetting, desired.Name, current.Id) {
+			continue
+		}
+
+		if !a.result.DryRun {
+			allowed := desired.AllowedModels
+			if allowed == nil {
+				allowed = []string{}
+			}
+
+			labels := desired.Labels
+			if labels == nil {
+				labels = map[string]string{}
+			}
+
+			headers := desired.Headers
+			if headers == nil {
+				headers = map[string]string{}
+			}
+
+			queryParams := desired.QueryParams
+			if queryParams == nil {
+				queryParams = map[string]string{}
+			}
+
+			us := &provider.UpdateSetting{
+				AllowedModels: &allowed,
+				CostMap:       desired.CostMap,
+				Labels:        &labels,
+				Headers:       &headers,
+				QueryParams:   &queryParams,
+				Environment:   &desired.Environment,
+			}
+
+			if spendLimitChanged {
+				// an empty spend limit removes the stored one
+				us.SpendLimit = &provider.SpendLimit{}
+				if desired.SpendLimit != nil {
+					us.SpendLimit = desired.SpendLimit
+				}
+			}
+
+			if transportChanged {
+				// an empty transport config removes the stored one
+				us.Transport = &provider.TransportConfig{}
+				if desired.Transport != nil {
+					us.Transport = desired.Transport
+				}
+			}
+
+			if tokenBudgetChanged {
+				// a budget without tokens per minute removes the stored one
+				us.TokenBudget = &provider.TokenBudget{}
+				if desired.TokenBudget != nil {
+					us.TokenBudget = desired.TokenBudget
+				}
+			}
+
+			if settingChanged {
+				us.Setting = desired.Setting
+			}
+
+			if _, err := a.m.psm.UpdateSetting(current.Id, us); err != nil {
+				return fmt.Errorf("failed to update provider setting %s: %w", desired.Name, err)
+			}
+		}
+
+		a.record(gitops.KindProviderSetting, desired.Name, current.Id, gitops.ActionUpdate)
+	}
+
+	return nil
+}
+
+func (a *applier) applyPolicies(existing []*policy.Policy) error {
+	byName := map[string]*policy.Policy{}
+	for _, p := range existing {
+		if _, ok := byName[p.Name]; ok && len(p.Name) != 0 {
+			byName[p.Name] = nil
+			continue
+		}
+
+		byName[p.Name] = p
+	}
+
+	for _, desired := range a.doc.Policies {
+		if desired.Config == nil {
+			desired.Config = &policy.Config{}
+		}
+
+		if desired.RegexConfig == nil {
+			desired.RegexConfig = &policy.RegexConfig{}
+		}
+
+		if desired.CustomConfig == nil {
+			desired.CustomConfig = &policy.CustomConfig{}
+		}
+
+		if desired.ResponseConfig == nil {
+			desired.ResponseConfig = &policy.ResponseConfig{}
+		}
+
+		if desired.ModerationConfig == nil {
+			desired.ModerationConfig = &policy.ModerationConfig{}
+		}
+
+		if desired.InjectionConfig == nil {
+			desired.InjectionConfig = &policy.InjectionConfig{}
+		}
+
+		if desired.LimitConfig == nil {
+			desired.LimitConfig = &policy.LimitConfig{}
+		}
+
+		if desired.TopicConfig == nil {
+			desired.TopicConfig = &policy.TopicConfig{}
+		}
+
+		if desired.LanguageConfig == nil {
+			desired.LanguageConfig = &policy.LanguageConfig{}
+		}
+
+		if desired.LatencyBudget == nil {
+			desired.LatencyBudget = &policy.LatencyBudget{}
+		}
+
+		if desired.GuardrailConfig == nil {
+			desired.GuardrailConfig = &policy.GuardrailConfig{}
+		}
+
+		if desired.SchemaConfig == nil {
+			desired.SchemaConfig = &policy.SchemaConfig{}
+		}
+
+		current, ok := byName[desired.Name]
+		if ok && current == nil {
+			return internal_errors.NewValidationError(fmt.Sprintf("policy name %s is used by more than one policy", desired.Name))
+		}
+
+		if !ok {
+			id := placeholder(gitops.KindPolicy, desired.Name)
+			if !a.result.DryRun {
+				created, err := a.m.pm.CreatePolicy(desired)
+				if err != nil {
+					return fmt.Errorf("failed to create policy %s: %w", desired.Name, err)
+				}
+
+				id = created.Id
+			}
+
+			a.policy[desired.Name] = id
+			a.record(gitops.KindPolicy, desired.Name, id, gitops.ActionCreate)
+			continue
+		}
+
+		a.policy[desired.Name] = current.Id
+
+		currentResponseConfig := current.ResponseConfig
+		if currentResponseConfig == nil {
+			currentResponseConfig = &policy.ResponseConfig{}
+		}
+
+		currentModerationConfig := current.ModerationConfig
+		if currentModerationConfig == nil {
+			currentModerationConfig = &policy.ModerationConfig{}
+		}
+
+		currentInjectionConfig := current.InjectionConfig
+		if currentInjectionConfig == nil {
+			currentInjectionConfig = &policy.InjectionConfig{}
+		}
+
+		currentLimitConfig := current.LimitConfig
+		if currentLimitConfig == nil {
+			currentLimitConfig = &policy.LimitConfig{}
+		}
+
+		currentTopicConfig := current.TopicConfig
+		if currentTopicConfig == nil {
+			currentTopicConfig = &policy.TopicConfig{}
+		}
+
+		currentLanguageConfig := current.LanguageConfig
+		if currentLanguageConfig == nil {
+			currentLanguageConfig = &policy.LanguageConfig{}
+		}
+
+		currentLatencyBudget := current.LatencyBudget
+		if currentLatencyBudget == nil {
+			currentLatencyBudget = &policy.LatencyBudget{}
+		}
+
+		currentGuardrailConfig := current.GuardrailConfig
+		if currentGuardrailConfig == nil {
+			currentGuardrailConfig = &policy.GuardrailConfig{}
+		}
+
+		currentSchemaConfig := current.SchemaConfig
+		if currentSchemaConfig == nil {
+			currentSchemaConfig = &policy.SchemaConfig{}
+		}
+
+		if jsonEqual(desired.Tags, current.Tags) && jsonEqual(desired.Config, current.Config) && jsonEqual(desired.RegexConfig, current.RegexConfig) && jsonEqual(desired.CustomConfig, current.CustomConfig) && jsonEqual(desired.Conditions, current.Conditions) && jsonEqual(desired.ResponseConfig, currentResponseConfig) && jsonEqual(desired.ModerationConfig, currentModerationConfig) && jsonEqual(desired.InjectionConfig, currentInjectionConfig) && jsonEqual(desired.LimitConfig, currentLimitConfig) && jsonEqual(desired.TopicConfig, currentTopicConfig) && jsonEqual(desired.LanguageConfig, currentLanguageConfig) && jsonEqual(desired.LatencyBudget, currentLatencyBudget) && jsonEqual(desired.GuardrailConfig, currentGuardrailConfig) && jsonEqual(desired.SchemaConfig, currentSchemaConfig) {
+			a.record(gitops.KindPolicy, desired.Name, current.Id, gitops.ActionUnchanged)
+			continue
+		}
+
+		if a.keepExisting(gitops.KindPolicy, desired.Name, current.Id) {
+			continue
+		}
+
+		if !a.result.DryRun {
+			conditions := desired.Conditions
+			if conditions == nil {
+				conditions = []*policy.Condition{}
+			}
+
+			tags := desired.Tags
+			if tags == nil {
+				tags = []string{}
+			}
+
+			_, err := a.m.pm.UpdatePolicy(current.Id, &policy.UpdatePolicy{
+				Name:             desired.Name,
+				Tags:             tags,
+				Config:           desired.Config,
+				RegexConfig:      desired.RegexConfig,
+				CustomConfig:     desired.CustomConfig,
+				Conditions:       conditions,
+				ResponseConfig:   desired.ResponseConfig,
+				ModerationConfig: desired.ModerationConfig,
+				InjectionConfig:  desired.InjectionConfig,
+				LimitConfig:      desired.LimitConfig,
+				TopicConfig:      desired.TopicConfig,
+				LanguageConfig:   desired.LanguageConfig,
+				LatencyBudget:    desired.LatencyBudget,
+				GuardrailConfig:  desired.GuardrailConfig,
+				SchemaConfig:     desired.SchemaConfig,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to update policy %s: %w", desired.Name, err)
+			}
+		}
+
+		a.record(gitops.KindPolicy, desired.Name, current.Id, gitops.ActionUpdate)
+	}
+
+	return nil
+}
+
+func (a *applier) resolve(kind string, names []string, ids map[string]string) ([]string, error) {
+	resolved := []string{}
+	for _, name := range names {
+		id, ok := ids[name]
+		if !ok {
+			return nil, internal_errors.NewValidationError(fmt.Sprintf("%s %s is not declared in the document", kind, name))
+		}
+
+		resolved = append(resolved, id)
+	}
+
+	return resolved, nil
+}
+
+func (a *applier) firstSkipped(kind string, names []string) string {
+	for _, name := range names {
+		if a.isSkipped(kind, name) {
+			return name
+		}
+	}
+
+	return ""
+}
+
+func (a *applier) applyKeys(existing []*key.ResponseKey) error {
+	byName := map[string]*key.ResponseKey{}
+	for _, k := range existing {
+		if _, ok := byName[k.Name]; ok && len(k.Name) != 0 {
+			byName[k.Name] = nil
+			continue
+		}
+
+		byName[k.Name] = k
+	}
+
+	for _, desired := range a.doc.Keys {
+		if skippedSetting := a.firstSkipped(gitops.KindProviderSetting, desired.SettingNames); len(skippedSetting) != 0 {
+			id := ""
+			if current := byName[desired.Name]; current != nil {
+				id = current.KeyId
+				a.key[desired.Name] = id
+			}
+
+			a.skip(gitops.KindKey, desired.Name, id, fmt.Sprintf("provider setting %s was skipped", skippedSetting))
+			continue
+		}
+
+		settingIds, err := a.resolve(gitops.KindProviderSetting, desired.SettingNames, a.setting)
+		if err != nil {
+			return err
+		}
+
+		policyId := ""
+		if len(desired.PolicyName) != 0 {
+			resolved, err := a.resolve(gitops.KindPolicy, []string{desired.PolicyName}, a.policy)
+			if err != nil {
+				return err
+			}
+
+			policyId = resolved[0]
+		}
+
+		current, ok := byName[desired.Name]
+		if ok && current == nil {
+			return internal_errors.NewValidationError(fmt.Sprintf("key name %s is used by more than one key", desired.Name))
+		}
+
+		if !ok {
+			if len(desired.Key) == 0 && a.importing {
+				a.skip(gitops.KindKey, desired.Name, "", "key secrets are not exported, add the key to the archive to create it")
+				continue
+			}
+
+			if len(desired.Key) == 0 {
+				return internal_errors.NewValidationError(fmt.Sprintf("key %s does not exist and requires a key secret to be created", desired.Name))
+			}
+
+			id := placeholder(gitops.KindKey, desired.Name)
+			if !a.result.DryRun {
+				rk := desired.RequestKey
+				rk.SettingIds = settingIds
+				rk.PolicyId = policyId
+
+				created, err := a.m.km.CreateKey(&rk)
+				if err != nil {
+					return fmt.Errorf("failed to create key %s: %w", desired.Name, err)
+				}
+
+				id = created.KeyId
+			}
+
+			a.key[desired.Name] = id
+			a.record(gitops.KindKey, desired.Name, id, gitops.ActionCreate)
+			continue
+		}
+
+		a.key[desired.Name] = current.KeyId
+
+		tags := desired.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+
+		allowedPaths := desired.AllowedPaths
+		if allowedPaths == nil {
+			allowedPaths = []key.PathConfig{}
+		}
+
+		currentTags := current.Tags
+		if currentTags == nil {
+			currentTags = []string{}
+		}
+
+		currentPaths := current.AllowedPaths
+		if currentPaths == nil {
+			currentPaths = []key.PathConfig{}
+		}
+
+		// retry policies that are not set are stored as empty ones
+		retryPolicy := desired.RetryPolicy
+		if !retryPolicy.Set() {
+			retryPolicy = &key.RetryPolicy{}
+		}
+
+		currentRetryPolicy := current.RetryPolicy
+		if !currentRetryPolicy.Set() {
+			currentRetryPolicy = &key.RetryPolicy{}
+		}
+
+		// key secrets are hashed and cannot be rotated through updates so they are not compared
+		unchanged := jsonEqual(tags, currentTags) &&
+			jsonEqual(allowedPaths, currentPaths) &&
+			jsonEqual(sortedCopy(settingIds), sortedCopy(current.GetSettingIds())) &&
+			desired.CostLimitInUsd == current.CostLimitInUsd &&
+			desired.CostLimitInUsdOverTime == current.CostLimitInUsdOverTime &&
+			desired.CostLimitInUsdUnit == current.CostLimitInUsdUnit &&
+			desired.RateLimitOverTime == current.RateLimitOverTime &&
+			desired.RateLimitUnit == current.RateLimitUnit &&
+			desired.ShouldLogRequest == current.ShouldLogRequest &&
+			desired.ShouldLogResponse == current.ShouldLogResponse &&
+			desired.RotationEnabled == current.RotationEnabled &&
+			desired.MaxCostPerRequest == current.MaxCostPerRequest &&
+			desired.MaxPriority == current.MaxPriority &&
+			desired.MaxConcurrency == current.MaxConcurrency &&
+			jsonEqual(retryPolicy, currentRetryPolicy) &&
+			policyId == current.PolicyId
+
+		if unchanged {
+			a.record(gitops.KindKey, desired.Name, current.KeyId, gitops.ActionUnchanged)
+			continue
+		}
+
+		if a.keepExisting(gitops.KindKey, desired.Name, current.KeyId) {
+			continue
+		}
+
+		if !a.result.DryRun {
+			uk := &key.UpdateKey{
+				Tags:                   tags,
+				SettingIds:             settingIds,
+				CostLimitInUsd:         &desired.CostLimitInUsd,
+				CostLimitInUsdOverTime: &desired.CostLimitInUsdOverTime,
+				CostLimitInUsdUnit:     &desired.CostLimitInUsdUnit,
+				RateLimitOverTime:      &desired.RateLimitOverTime,
+				RateLimitUnit:          &desired.RateLimitUnit,
+				AllowedPaths:           &allowedPaths,
+				ShouldLogRequest:       &desired.ShouldLogRequest,
+				ShouldLogResponse:      &desired.ShouldLogResponse,
+				RotationEnabled:        &desired.RotationEnabled,
+				PolicyId:               &policyId,
+				MaxCostPerRequest:      &desired.MaxCostPerRequest,
+				MaxPriority:            &desired.MaxPriority,
+				MaxConcurrency:         &desired.MaxConcurrency,
+				RetryPolicy:            retryPolicy,
+			}
+
+			if _, err := a.m.km.UpdateKey(current.KeyId, uk); err != nil {
+				return fmt.Errorf("failed to update key %s: %w", desired.Name, err)
+			}
+		}
+
+		a.record(gitops.KindKey, desired.Name, current.KeyId, gitops.ActionUpdate)
+	}
+
+	return nil
+}
+
+func routeSpecOf(r *route.Route) any {
+	return []any{r.Name, r.RetryStrategy, r.Strategy, r.StrategyConfig, r.RequestFormat, sortedCopy(r.KeyIds), r.Steps, r.CacheConfig, r.SnippetConfig, r.CircuitBreaker, r.RetryPolicy, r.Split, r.Shadow, r.Transport, r.Sticky, r.Hedge, r.Queue, r.RateLimit, r.Region, r.Transform, r.PolicyId}
+}
+
+func (a *applier) applyRoutes(existing []*route.Route) error {
+	byPath := map[string]*route.Route{}
+	for _, r := range existing {
+		byPath[r.Path] = r
+	}
+
+	for _, desired := range a.doc.Routes {
+		// keys skipped by an import are left out rather than failing the route
+		keyNames := []string{}
+		for _, name := range desired.KeyNames {
+			if _, ok := a.key[name]; ok || !a.isSkipped(gitops.KindKey, name) {
+				keyNames = append(keyNames, name)
+			}
+		}
+
+		keyIds, err := a.resolve(gitops.KindKey, keyNames, a.key)
+		if err != nil {
+			return err
+		}
+
+		r := desired.Route
+		r.KeyIds = append(r.KeyIds, keyIds...)
+
+		if len(desired.PolicyName) != 0 {
+			resolved, err := a.resolve(gitops.KindPolicy, []string{desired.PolicyName}, a.policy)
+			if err != nil {
+				return err
+			}
+
+			r.PolicyId = resolved[0]
+		}
+
+		current, ok := byPath[desired.Path]
+		if ok {
+			normalized := r
+			addDefaultValues(&normalized)
+
+			if jsonEqual(routeSpecOf(&normalized), routeSpecOf(current)) {
+				a.record(gitops.KindRoute, desired.Path, current.Id, gitops.ActionUnchanged)
+				continue
+			}
+		}
+
+		if ok && a.keepExisting(gitops.KindRoute, desired.Path, current.Id) {
+			continue
+		}
+
+		if ok {
+			// policy version pins are specific to the gateway and are kept
+			r.PolicyVersions = current.PolicyVersions
+
+			if !a.result.DryRun {
+				if _, err := a.m.rm.ReplaceRoute(current.Id, &r, current.Version); err != nil {
+					return fmt.Errorf("failed to update route %s: %w", desired.Path, err)
+				}
+			}
+
+			a.record(gitops.KindRoute, desired.Path, current.Id, gitops.ActionUpdate)
+			continue
+		}
+
+		id := placeholder(gitops.KindRoute, desired.Path)
+		if !a.result.DryRun {
+			created, err := a.m.rm.CreateRoute(&r)
+			if err != nil {
+				return fmt.Errorf("failed to create route %s: %w", desired.Path, err)
+			}
+
+			id = created.Id
+		}
+
+		a.record(gitops.KindRoute, desired.Path, id, gitops.ActionCreate)
+	}
+
+	return nil
+}
+
+// prune deletes resources missing from the document in reverse dependency order.
+func (a *applier) prune(settings []*provider.Setting, policies []*policy.Policy, keys []*key.ResponseKey, routes []*route.Route) error {
+	if a.doc.Routes != nil {
+		declared := map[string]bool{}
+		for _, r := range a.doc.Routes {
+			declared[r.Path] = true
+		}
+
+		for _, r := range routes {
+			if declared[r.Path] {
+				continue
+			}
+
+			if !a.result.DryRun {
+				if err := a.m.rm.DeleteRoute(r.Id); err != nil {
+					return fmt.Errorf("failed to prune route %s: %w", r.Path, err)
+				}
+			}
+
+			a.record(gitops.KindRoute, r.Path, r.Id, gitops.ActionDelete)
+		}
+	}
+
+	if a.doc.Keys != nil {
+		for _, k := range keys {
+			if _, ok := a.key[k.Name]; ok && a.key[k.Name] == k.KeyId {
+				continue
+			}
+
+			if !a.result.DryRun {
+				if err := a.m.km.DeleteKey(k.KeyId); err != nil {
+					return fmt.Errorf("failed to prune key %s: %w", k.Name, err)
+				}
+			}
+
+			a.record(gitops.KindKey, k.Name, k.KeyId, gitops.ActionDelete)
+		}
+	}
+
+	if a.doc.Policies != nil {
+		for _, p := range policies {
+			if a.policy[p.Name] == p.Id {
+				continue
+			}
+
+			if !a.result.DryRun {
+				if _, err := a.m.pm.DeletePolicy(p.Id, false); err != nil {
+					return fmt.Errorf("failed to prune policy %s: %w", p.Name, err)
+				}
+			}
+
+			a.record(gitops.KindPolicy, p.Name, p.Id, gitops.ActionDelete)
+		}
+	}
+
+	if a.doc.ProviderSettings != nil {
+		for _, s := range settings {
+			if a.setting[s.Name] == s.Id {
+				continue
+			}
+
+			if !a.result.DryRun {
+				if _, err := a.m.psm.DeleteSetting(s.Id, false); err != nil {
+					return fmt.Errorf("failed to prune provider setting %s: %w", s.Name, err)
+				}
+			}
+
+			a.record(gitops.KindProviderSetting, s.Name, s.Id, gitops.ActionDelete)
+		}
+	}
+
+	return nil
+}
+
+// exportedSecrets are stripped from provider settings on export in addition to
+// the api keys that storage already leaves out.
+var exportedSecrets = []string{"apikey", "awsAccessKeyId", "awsSecretAccessKey"}
+
+func duplicatedNames(names []string) map[string]bool {
+	seen := map[string]bool{}
+	duplicated := map[string]bool{}
+	for _, name := range names {
+		if seen[name] {
+			duplicated[name] = true
+		}
+
+		seen[name] = true
+	}
+
+	return duplicated
+}
+
+// Export snapshots the configuration as an archive that Import accepts. Since
+// archives reference resources by name, unnamed resources, resources sharing a
+// name and revoked keys are left out and reported as omitted.
+func (m *ConfigManager) Export() (*gitops.Archive, error) {
+	providers, err := m.s.GetCustomProviders()
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := m.s.GetProviderSettings(false, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := m.s.GetAllPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := m.s.GetAllKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := m.s.GetRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &gitops.Archive{
+		Version:    gitops.ArchiveVersion,
+		ExportedAt: time.Now().Unix(),
+		Document: gitops.Document{
+			CustomProviders:  []*custom.Provider{},
+			ProviderSettings: []*provider.Setting{},
+			Policies:         []*policy.Policy{},
+			Keys:             []*gitops.KeySpec{},
+			Routes:           []*gitops.RouteSpec{},
+		},
+		Omitted: []*gitops.Change{},
+	}
+
+	omit := func(kind, name, id, reason string) {
+		archive.Omitted = append(archive.Omitted, &gitops.Change{
+			Kind:   kind,
+			Name:   name,
+			Id:     id,
+			Action: gitops.ActionSkip,
+			Reason: reason,
+		})
+	}
+
+	for _, p := range providers {
+		copied := *p
+		copied.Id = ""
+		copied.CreatedAt = 0
+		copied.UpdatedAt = 0
+
+		archive.CustomProviders = append(archive.CustomProviders, &copied)
+	}
+
+	names := []string{}
+	for _, s := range settings {
+		names = append(names, s.Name)
+	}
+
+	duplicated := duplicatedNames(names)
+	settingNames := map[string]string{}
+	for _, s := range settings {
+		if len(s.Name) == 0 || duplicated[s.Name] {
+			omit(gitops.KindProviderSetting, s.Name, s.Id, "provider setting does not have a unique name")
+			continue
+		}
+
+		copied := *s
+		copied.Id = ""
+		copied.CreatedAt = 0
+		copied.UpdatedAt = 0
+		copied.Setting = map[string]string{}
+		for k, v := range s.Setting {
+			copied.Setting[k] = v
+		}
+
+		for _, secret := range exportedSecrets {
+			delete(copied.Setting, secret)
+		}
+
+		settingNames[s.Id] = s.Name
+		archive.ProviderSettings = append(archive.ProviderSettings, &copied)
+	}
+
+	names = []string{}
+	for _, p := range policies {
+		names = append(names, p.Name)
+	}
+
+	duplicated = duplicatedNames(names)
+	policyNames := map[string]string{}
+	for _, p := range policies {
+		if len(p.Name) == 0 || duplicated[p.Name] {
+			omit(gitops.KindPolicy, p.Name, p.Id, "policy does not have a unique name")
+			continue
+		}
+
+		copied := *p
+		copied.Id = ""
+		copied.CreatedAt = 0
+		copied.UpdatedAt = 0
+		copied.Version = 0
+		copied.Rollout = nil
+
+		policyNames[p.Id] = p.Name
+		archive.Policies = append(archive.Policies, &copied)
+	}
+
+	names = []string{}
+	for _, k := range keys {
+		if !k.Revoked {
+			names = append(names, k.Name)
+		}
+	}
+
+	duplicated = duplicatedNames(names)
+	keyNames := map[string]string{}
+	for _, k := range keys {
+		if k.Revoked {
+			omit(gitops.KindKey, k.Name, k.KeyId, "key is revoked")
+			continue
+		}
+
+		if len(k.Name) == 0 || duplicated[k.Name] {
+			omit(gitops.KindKey, k.Name, k.KeyId, "key does not have a unique name")
+			continue
+		}
+
+		spec := &gitops.KeySpec{
+			RequestKey: key.RequestKey{
+				Name:                   k.Name,
+				Tags:                   k.Tags,
+				CostLimitInUsd:         k.CostLimitInUsd,
+				CostLimitInUsdOverTime: k.CostLimitInUsdOverTime,
+				CostLimitInUsdUnit:     k.CostLimitInUsdUnit,
+				RateLimitOverTime:      k.RateLimitOverTime,
+				RateLimitUnit:          k.RateLimitUnit,
+				Ttl:                    k.Ttl,
+				AllowedPaths:           k.AllowedPaths,
+				ShouldLogRequest:       k.ShouldLogRequest,
+				ShouldLogResponse:      k.ShouldLogResponse,
+				RotationEnabled:        k.RotationEnabled,
+				MaxCostPerRequest:      k.MaxCostPerRequest,
+				Namespace:              k.Namespace,
+				MaxPriority:            k.MaxPriority,
+				MaxConcurrency:         k.MaxConcurrency,
+				RetryPolicy:            k.RetryPolicy,
+			},
+			SettingNames: []string{},
+		}
+
+		missing := ""
+		for _, id := range k.GetSettingIds() {
+			name, ok := settingNames[id]
+			if !ok {
+				missing = fmt.Sprintf("provider setting %s is not exported", id)
+				break
+			}
+
+			spec.SettingNames = append(spec.SettingNames, name)
+		}
+
+		if len(k.PolicyId) != 0 {
+			name, ok := policyNames[k.PolicyId]
+			if !ok {
+				missing = fmt.Sprintf("policy %s is not exported", k.PolicyId)
+			}
+
+			spec.PolicyName = name
+		}
+
+		if len(missing) != 0 {
+			omit(gitops.KindKey, k.Name, k.KeyId, missing)
+			continue
+		}
+
+		keyNames[k.KeyId] = k.Name
+		archive.Keys = append(archive.Keys, spec)
+	}
+
+	for _, r := range routes {
+		spec := &gitops.RouteSpec{
+			Route:    *r,
+			KeyNames: []string{},
+		}
+
+		// keys that are not exported cannot be referenced and are dropped from the route
+		for _, id := range r.KeyIds {
+			if name, ok := keyNames[id]; ok {
+				spec.KeyNames = append(spec.KeyNames, name)
+			}
+		}
+
+		if len(r.PolicyId) != 0 {
+			name, ok := policyNames[r.PolicyId]
+			if !ok {
+				omit(gitops.KindRoute, r.Path, r.Id, fmt.Sprintf("policy %s is not exported", r.PolicyId))
+				continue
+			}
+
+			spec.PolicyName = name
+		}
+
+		spec.Id = ""
+		spec.CreatedAt = 0
+		spec.UpdatedAt = 0
+		spec.Version = 0
+		spec.KeyIds = nil
+		spec.PolicyId = ""
+		spec.PolicyVersions = nil
+
+		archive.Routes = append(archive.Routes, spec)
+	}
+
+	return archive, nil
+}
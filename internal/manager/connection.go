@@ -0,0 +1,73 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/catalog"
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+const connectionTestTimeout = 10 * time.Second
+
+// getSettingWithSecrets returns a provider setting with its api key decrypted
+// so that it can be used to call the upstream.
+func (m *ProviderSettingsManager) getSettingWithSecrets(id string) (*provider.Setting, error) {
+	if len(id) == 0 {
+		return nil, internal_errors.NewValidationError("id cannot be empty")
+	}
+
+	existing, err := m.Storage.GetProviderSetting(id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	apikey := existing.Setting["apikey"]
+	if len(apikey) == 0 || !m.Encryptor.Enabled() {
+		return existing, nil
+	}
+
+	decrypted, err := m.Encryptor.Decrypt(apikey, map[string]string{"X-UPDATED-AT": strconv.FormatInt(existing.UpdatedAt, 10)})
+	if err != nil {
+		return nil, err
+	}
+
+	copied := *existing
+	copied.Setting = map[string]string{}
+	for k, v := range existing.Setting {
+		copied.Setting[k] = v
+	}
+	copied.Setting["apikey"] = decrypted
+
+	return &copied, nil
+}
+
+// TestConnection makes a cheap call to the upstream of a provider setting so
+// that a newly stored api key can be checked before traffic is routed to it.
+func (m *ProviderSettingsManager) TestConnection(ctx context.Context, id string) (*catalog.ConnectionTest, error) {
+	existing, err := m.getSettingWithSecrets(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !catalog.CanTest(existing.Provider) {
+		return nil, internal_errors.NewValidationError(fmt.Sprintf("connection test is not supported for provider %s", existing.Provider))
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, connectionTestTimeout)
+	defer cancel()
+
+	t := catalog.TestConnection(ctxTimeout, &http.Client{}, existing)
+	telemetry.Incr("bricksllm.provider_settings_manager.test_connection.results", []string{
+		"provider:" + existing.Provider,
+		"reachable:" + strconv.FormatBool(t.Reachable),
+		"authenticated:" + strconv.FormatBool(t.Authenticated),
+	}, 1)
+
+	return t, nil
+}
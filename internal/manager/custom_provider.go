@@ -7,6 +7,7 @@ import (
 	"time"
 	"unicode"
 
+	"github.com/bricks-cloud/bricksllm/internal/dryrun"
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
 	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
 	"github.com/bricks-cloud/bricksllm/internal/util"
@@ -18,22 +19,34 @@ type CustomProvidersStorage interface {
 	GetCustomProviderByName(name string) (*custom.Provider, error)
 	GetCustomProvider(id string) (*custom.Provider, error)
 	UpdateCustomProvider(id string, provider *custom.UpdateProvider) (*custom.Provider, error)
+	DeleteCustomProvider(id string) error
+	GetProviderSettingIdsByProvider(name string) ([]string, error)
 }
 
 type CustomProvidersMemStorage interface {
 	GetProvider(name string) *custom.Provider
 	GetRouteConfig(name, path string) *custom.RouteConfig
+	DeleteProvider(name string)
+}
+
+// CustomProviderSettings deletes the provider settings of a custom provider
+// when its deletion is cascaded.
+type CustomProviderSettings interface {
+	SettingDependents(id string) ([]*dryrun.Dependent, error)
+	DeleteSetting(id string, cascade bool) ([]*dryrun.Dependent, error)
 }
 
 type CustomProvidersManager struct {
-	Storage CustomProvidersStorage
-	Mem     CustomProvidersMemStorage
+	Storage  CustomProvidersStorage
+	Mem      CustomProvidersMemStorage
+	Settings CustomProviderSettings
 }
 
-func NewCustomProvidersManager(s CustomProvidersStorage, mem CustomProvidersMemStorage) *CustomProvidersManager {
+func NewCustomProvidersManager(s CustomProvidersStorage, mem CustomProvidersMemStorage, settings CustomProviderSettings) *CustomProvidersManager {
 	return &CustomProvidersManager{
-		Storage: s,
-		Mem:     mem,
+		Storage:  s,
+		Mem:      mem,
+		Settings: settings,
 	}
 }
 
@@ -61,11 +74,11 @@ func gatherEmptyFieldsFromRouteConfig(index int, rc *custom.RouteConfig) []strin
 		invalidFields = append(invalidFields, fmt.Sprintf("route_configs.[%d].model_location", index))
 	}
 
-	if len(rc.RequestPromptLocation) == 0 {
+	if len(rc.RequestPromptLocation) == 0 && len(rc.PromptTokensLocation) == 0 {
 		invalidFields = append(invalidFields, fmt.Sprintf("route_configs.[%d].request_prompt_location", index))
 	}
 
-	if len(rc.ResponseCompletionLocation) == 0 {
+	if len(rc.ResponseCompletionLocation) == 0 && len(rc.CompletionTokensLocation) == 0 {
 		invalidFields = append(invalidFields, fmt.Sprintf("route_configs.[%d].response_completion_location", index))
 	}
 
@@ -73,6 +86,22 @@ func gatherEmptyFieldsFromRouteConfig(index int, rc *custom.RouteConfig) []strin
 
 }
 
+func validateRouteConfigTemplates(index int, rc *custom.RouteConfig) error {
+	if len(rc.RequestTemplate) != 0 {
+		if err := custom.ValidateTemplate(rc.RequestTemplate); err != nil {
+			return internal_errors.NewValidationError(fmt.Sprintf("route_configs.[%d].request_template is not a valid template: %v", index, err))
+		}
+	}
+
+	if len(rc.ResponseTemplate) != 0 {
+		if err := custom.ValidateTemplate(rc.ResponseTemplate); err != nil {
+			return internal_errors.NewValidationError(fmt.Sprintf("route_configs.[%d].response_template is not a valid template: %v", index, err))
+		}
+	}
+
+	return nil
+}
+
 func validateCustomProviderUpdate(existing *custom.Provider, updated *custom.UpdateProvider) error {
 	invalidFields := []string{}
 	pathToRouteMap := map[string]*custom.RouteConfig{}
@@ -84,6 +113,10 @@ func validateCustomProviderUpdate(existing *custom.Provider, updated *custom.Upd
 	for index, rc := range updated.RouteConfigs {
 		_, ok := pathToRouteMap[rc.Path]
 
+		if err := validateRouteConfigTemplates(index, rc); err != nil {
+			return err
+		}
+
 		if len(rc.StreamLocation) != 0 {
 			if len(rc.StreamEndWord) == 0 {
 				invalidFields = append(invalidFields, fmt.Sprintf("route_configs.[%d].stream_end_word", index))
@@ -119,8 +152,8 @@ func validateCustomProviderUpdate(existing *custom.Provider, updated *custom.Upd
 func validateCustomProviderCreation(provider *custom.Provider) error {
 	invalidFields := []string{}
 
-	if provider.Provider == "openai" || provider.Provider == "anthropic" || provider.Provider == "azure" || provider.Provider == "deepinfra" || provider.Provider == "vllm" {
-		return internal_errors.NewValidationError("provider cannot be named openai or anthropic")
+	if isProviderNativelySupported(provider.Provider) {
+		return internal_errors.NewValidationError(fmt.Sprintf("provider cannot be named %s, which is natively supported", provider.Provider))
 	}
 
 	if len(provider.Provider) == 0 {
@@ -156,6 +189,10 @@ func validateCustomProviderCreation(provider *custom.Provider) error {
 				duplicates[rc.Path] = struct{}{}
 			}
 
+			if err := validateRouteConfigTemplates(index, rc); err != nil {
+				return err
+			}
+
 			if len(rc.StreamLocation) != 0 {
 				if len(rc.StreamEndWord) == 0 {
 					invalidFields = append(invalidFields, fmt.Sprintf("route_configs.[%d].stream_end_word", index))
@@ -241,3 +278,82 @@ func (m *CustomProvidersManager) UpdateCustomProvider(id string, provider *custo
 
 	return m.Storage.UpdateCustomProvider(id, provider)
 }
+
+// customProviderDependents returns the provider settings of the custom
+// provider along with the keys using those settings.
+func (m *CustomProvidersManager) customProviderDependents(existing *custom.Provider) ([]*dryrun.Dependent, error) {
+	settingIds, err := m.Storage.GetProviderSettingIdsByProvider(existing.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	dependents := dryrun.AddDependents(nil, "provider_setting", settingIds)
+	for _, sid := range settingIds {
+		settingDependents, err := m.Settings.SettingDependents(sid)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, d := range settingDependents {
+			dependents = dryrun.AddDependents(dependents, d.Resource, d.Ids)
+		}
+	}
+
+	return dependents, nil
+}
+
+func (m *CustomProvidersManager) checkCustomProviderDeletion(id string, cascade bool) (*custom.Provider, []*dryrun.Dependent, error) {
+	existing, err := m.Storage.GetCustomProvider(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dependents, err := m.customProviderDependents(existing)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if settingIds := dryrun.IdsOf(dependents, "provider_setting"); !cascade && len(settingIds) != 0 {
+		return nil, nil, internal_errors.NewConflictError(fmt.Sprintf("custom provider is still referenced by provider settings: %s", strings.Join(settingIds, ",")))
+	}
+
+	return existing, dependents, nil
+}
+
+func (m *CustomProvidersManager) PreviewDeleteCustomProvider(id string, cascade bool) (*dryrun.Result, error) {
+	existing, err := m.Storage.GetCustomProvider(id)
+	if err != nil {
+		return nil, err
+	}
+
+	dependents, err := m.customProviderDependents(existing)
+	if err != nil {
+		return nil, err
+	}
+
+	return dryrun.NewDeleteResult("custom_provider", []string{id}, dependents, cascade), nil
+}
+
+// DeleteCustomProvider deletes a custom provider. With cascade, its provider
+// settings and the keys using them are deleted as well.
+func (m *CustomProvidersManager) DeleteCustomProvider(id string, cascade bool) ([]*dryrun.Dependent, error) {
+	existing, dependents, err := m.checkCustomProviderDeletion(id, cascade)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sid := range dryrun.IdsOf(dependents, "provider_setting") {
+		if _, err := m.Settings.DeleteSetting(sid, true); err != nil {
+			return nil, err
+		}
+	}
+
+	err = m.Storage.DeleteCustomProvider(id)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Mem.DeleteProvider(existing.Provider)
+
+	return dependents, nil
+}
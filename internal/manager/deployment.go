@@ -0,0 +1,175 @@
+package manager
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+var (
+	deploymentNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]{1,64}$`)
+	apiVersionRegex     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(-preview)?$`)
+)
+
+func validateApiVersion(field, version string) error {
+	if len(version) == 0 || apiVersionRegex.MatchString(version) {
+		return nil
+	}
+
+	return internal_errors.NewValidationError(fmt.Sprintf("api version %s is not in the form of YYYY-MM-DD or YYYY-MM-DD-preview", version)).WithFields(&internal_errors.FieldError{
+		Field:  field,
+		Reason: "invalid",
+		Value:  version,
+	})
+}
+
+// validateDeployments checks the deployment mapping of a provider setting.
+// Mappings are only meaningful for azure, where the deployment is part of the
+// url, and every model can be mapped once.
+func validateDeployments(providerName string, deployments []*provider.Deployment) error {
+	if len(deployments) == 0 {
+		return nil
+	}
+
+	if providerName != "azure" {
+		return internal_errors.NewValidationError("deployments can only be mapped on azure provider settings").WithFields(&internal_errors.FieldError{
+			Field:  "deployments",
+			Reason: "not supported",
+		})
+	}
+
+	seen := map[string]bool{}
+	for i, d := range deployments {
+		field := fmt.Sprintf("deployments[%d]", i)
+		if d == nil || len(d.Model) == 0 {
+			return internal_errors.NewValidationError("deployment model cannot be empty").WithFields(&internal_errors.FieldError{
+				Field:  field + ".model",
+				Reason: "required",
+			})
+		}
+
+		if seen[d.Model] {
+			return internal_errors.NewValidationError(fmt.Sprintf("model %s is mapped to more than one deployment", d.Model)).WithFields(&internal_errors.FieldError{
+				Field:  field + ".model",
+				Reason: "duplicated",
+				Value:  d.Model,
+			})
+		}
+		seen[d.Model] = true
+
+		if !deploymentNameRegex.MatchString(d.Deployment) {
+			return internal_errors.NewValidationError(fmt.Sprintf("deployment name %s must be 1 to 64 letters, digits, underscores, dots or hyphens", d.Deployment)).WithFields(&internal_errors.FieldError{
+				Field:  field + ".deployment",
+				Reason: "invalid",
+				Value:  d.Deployment,
+			})
+		}
+
+		if err := validateApiVersion(field+".apiVersion", d.ApiVersion); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *ProviderSettingsManager) getAzureSetting(id string) (*provider.Setting, error) {
+	if len(id) == 0 {
+		return nil, internal_errors.NewValidationError("id cannot be empty")
+	}
+
+	existing, err := m.Storage.GetProviderSetting(id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing.Provider != "azure" {
+		return nil, internal_errors.NewValidationError("deployments can only be mapped on azure provider settings")
+	}
+
+	return existing, nil
+}
+
+func (m *ProviderSettingsManager) saveDeployments(id string, deployments []*provider.Deployment) ([]*provider.Deployment, error) {
+	updated, err := m.Storage.UpdateProviderSetting(id, &provider.UpdateSetting{
+		UpdatedAt:   time.Now().Unix(),
+		Deployments: &deployments,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.Cache.Delete(id); err != nil {
+		telemetry.Incr("bricksllm.provider_settings_manager.save_deployments.delete_cache_error", nil, 1)
+	}
+
+	m.recordVersion(id)
+
+	if updated.Deployments == nil {
+		return []*provider.Deployment{}, nil
+	}
+
+	return updated.Deployments, nil
+}
+
+// GetDeployments returns the deployment mapping of an azure provider setting.
+func (m *ProviderSettingsManager) GetDeployments(id string) ([]*provider.Deployment, error) {
+	existing, err := m.getAzureSetting(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing.Deployments == nil {
+		return []*provider.Deployment{}, nil
+	}
+
+	return existing.Deployments, nil
+}
+
+// PutDeployment maps d.Model to a deployment, replacing the existing mapping
+// of the model if there is one, and returns the resulting mapping.
+func (m *ProviderSettingsManager) PutDeployment(id string, d *provider.Deployment) ([]*provider.Deployment, error) {
+	existing, err := m.getAzureSetting(id)
+	if err != nil {
+		return nil, err
+	}
+
+	deployments := []*provider.Deployment{}
+	for _, ed := range existing.Deployments {
+		if ed.Model != d.Model {
+			deployments = append(deployments, ed)
+		}
+	}
+	deployments = append(deployments, d)
+
+	if err := validateDeployments(existing.Provider, deployments); err != nil {
+		return nil, err
+	}
+
+	return m.saveDeployments(id, deployments)
+}
+
+// DeleteDeployment removes the mapping of model and returns the remaining ones.
+func (m *ProviderSettingsManager) DeleteDeployment(id, model string) ([]*provider.Deployment, error) {
+	existing, err := m.getAzureSetting(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing.DeploymentOf(model) == nil {
+		return nil, internal_errors.NewNotFoundError(fmt.Sprintf("model %s is not mapped to a deployment", model))
+	}
+
+	deployments := []*provider.Deployment{}
+	for _, ed := range existing.Deployments {
+		if ed.Model != model {
+			deployments = append(deployments, ed)
+		}
+	}
+
+	return m.saveDeployments(id, deployments)
+}
@@ -1,11 +1,14 @@
 package manager
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"strings"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/dryrun"
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
 	"github.com/bricks-cloud/bricksllm/internal/hasher"
 	"github.com/bricks-cloud/bricksllm/internal/key"
@@ -17,7 +20,7 @@ import (
 
 type Storage interface {
 	GetKeys(tags, keyIds []string, provider string) ([]*key.ResponseKey, error)
-	GetKeysV2(tags, keyIds []string, revoked *bool, limit, offset int, name, order string, returnCount bool) (*key.GetKeysResponse, error)
+	GetKeysV2(tags, keyIds []string, revoked *bool, limit, offset int, name, order string, returnCount bool, namespace string) (*key.GetKeysResponse, error)
 	UpdateKey(id string, key *key.UpdateKey) (*key.ResponseKey, error)
 	CreateKey(key *key.RequestKey) (*key.ResponseKey, error)
 	DeleteKey(id string) error
@@ -46,30 +49,39 @@ type keyCache interface {
 	Get(keyId string) (*key.ResponseKey, error)
 }
 
+type claimLinkCache interface {
+	Set(token string, keyId string, ttl time.Duration) error
+	GetAndDelete(token string) (string, error)
+}
+
 type Manager struct {
-	s   Storage
-	clc costLimitCache
-	rlc rateLimitCache
-	ac  accessCache
-	kc  keyCache
+	s    Storage
+	clc  costLimitCache
+	rlc  rateLimitCache
+	ac   accessCache
+	kc   keyCache
+	clkc claimLinkCache
+	sf   *key.SecretFormat
 }
 
-func NewManager(s Storage, clc costLimitCache, rlc rateLimitCache, ac accessCache, kc keyCache) *Manager {
+func NewManager(s Storage, clc costLimitCache, rlc rateLimitCache, ac accessCache, kc keyCache, clkc claimLinkCache, sf *key.SecretFormat) *Manager {
 	return &Manager{
-		s:   s,
-		clc: clc,
-		rlc: rlc,
-		ac:  ac,
-		kc:  kc,
+		s:    s,
+		clc:  clc,
+		rlc:  rlc,
+		ac:   ac,
+		kc:   kc,
+		clkc: clkc,
+		sf:   sf,
 	}
 }
 
-func (m *Manager) GetKeysV2(tags, keyIds []string, revoked *bool, limit, offset int, name, order string, returnCount bool) (*key.GetKeysResponse, error) {
+func (m *Manager) GetKeysV2(tags, keyIds []string, revoked *bool, limit, offset int, name, order string, returnCount bool, namespace string) (*key.GetKeysResponse, error) {
 	if len(order) != 0 && strings.ToUpper(order) != "DESC" && strings.ToUpper(order) != "ASC" {
 		return nil, internal_errors.NewValidationError("get keys request order can only be desc or asc")
 	}
 
-	return m.s.GetKeysV2(tags, keyIds, revoked, limit, offset, name, order, returnCount)
+	return m.s.GetKeysV2(tags, keyIds, revoked, limit, offset, name, order, returnCount, namespace)
 }
 
 func (m *Manager) GetKeys(tags, keyIds []string, provider string) ([]*key.ResponseKey, error) {
@@ -232,3 +244,129 @@ func (m *Manager) GetKeyViaCache(raw string) (*key.ResponseKey, error) {
 func (m *Manager) DeleteKey(id string) error {
 	return m.s.DeleteKey(id)
 }
+
+func (m *Manager) PreviewDeleteKey(id string) (*dryrun.Result, error) {
+	existing, err := m.s.GetKey(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		return nil, internal_errors.NewNotFoundError("key is not found for id: " + id)
+	}
+
+	return dryrun.NewResult(dryrun.ActionDelete, "key", []string{id}), nil
+}
+
+func (m *Manager) CreateClaimLink(id string, r *key.ClaimLinkRequest) (*key.ClaimLink, error) {
+	ttl := 24 * time.Hour
+	if len(r.Ttl) != 0 {
+		parsed, err := time.ParseDuration(r.Ttl)
+		if err != nil || parsed <= 0 {
+			return nil, internal_errors.NewValidationError("claim link ttl is invalid")
+		}
+
+		ttl = parsed
+	}
+
+	existing, err := m.s.GetKey(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		return nil, internal_errors.NewNotFoundError("key is not found for id: " + id)
+	}
+
+	if existing.Revoked {
+		return nil, internal_errors.NewValidationError("can not create a claim link for a revoked key")
+	}
+
+	token, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.clkc.Set(token, id, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &key.ClaimLink{
+		KeyId:     id,
+		Token:     token,
+		ExpiresAt: time.Now().Add(ttl).Unix(),
+	}, nil
+}
+
+// ClaimKey redeems a claim link token. A new secret is generated for the key on
+// redemption and only returned once, so the previous secret stops working.
+func (m *Manager) ClaimKey(token string) (*key.ClaimedKey, error) {
+	id, err := m.clkc.GetAndDelete(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(id) == 0 {
+		return nil, internal_errors.NewNotFoundError("claim link is invalid, expired or already used")
+	}
+
+	existing, err := m.s.GetKey(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == nil || existing.Revoked {
+		return nil, internal_errors.NewNotFoundError("key is no longer available for id: " + id)
+	}
+
+	secret, err := m.sf.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	stored := secret
+	if !existing.IsKeyNotHashed {
+		stored = hasher.Hash(secret)
+	}
+
+	_, err = m.s.UpdateKey(id, &key.UpdateKey{
+		UpdatedAt: time.Now().Unix(),
+		Key:       stored,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.kc.Delete(existing.Key)
+	if err != nil {
+		telemetry.Incr("bricksllm.manager.claim_key.delete_cache_error", nil, 1)
+	}
+
+	return &key.ClaimedKey{
+		KeyId: id,
+		Key:   secret,
+	}, nil
+}
+
+// VerifyKeyFormat tells secret scanners whether a string has the shape of a
+// key secret generated by the gateway.
+func (m *Manager) VerifyKeyFormat(secret string) (*key.FormatVerification, error) {
+	if len(secret) == 0 {
+		return nil, internal_errors.NewValidationError("key cannot be empty").WithFields(&internal_errors.FieldError{
+			Field:  "key",
+			Reason: "required",
+		})
+	}
+
+	return m.sf.Verify(secret), nil
+}
+
+func newSecret() (string, error) {
+	bs := make([]byte, 32)
+	if _, err := rand.Read(bs); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(bs), nil
+}
@@ -0,0 +1,138 @@
+package manager
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+)
+
+func canPoolKeys(providerName string) bool {
+	return providerName == "openai" || providerName == "anthropic" || providerName == "azure" || providerName == "deepinfra" || providerName == "mistral" || providerName == "cohere" || providerName == "groq" || providerName == "vllm" || providerName == "self-hosted" || providerName == "bricksllm"
+}
+
+func hasPooledKeys(kp *provider.KeyPool) bool {
+	return kp != nil && len(kp.Keys) != 0
+}
+
+// withoutParam removes name from a list of missing params as returned by
+// findMissingAuthParams.
+func withoutParam(missing, name string) string {
+	left := []string{}
+	for _, param := range strings.Split(missing, ",") {
+		param = strings.TrimSpace(param)
+		if len(param) != 0 && param != name {
+			left = append(left, param)
+		}
+	}
+
+	return strings.Join(left, ",")
+}
+
+// validateKeyPool checks the key pool of a provider setting. Keys without an
+// id are given one, so that they can be referred to in later updates.
+func validateKeyPool(providerName string, kp *provider.KeyPool) error {
+	if kp == nil {
+		return nil
+	}
+
+	if !canPoolKeys(providerName) {
+		return internal_errors.NewValidationError(fmt.Sprintf("keys cannot be pooled on %s provider settings", providerName)).WithFields(&internal_errors.FieldError{
+			Field:  "keyPool",
+			Reason: "not supported",
+		})
+	}
+
+	if len(kp.Strategy) == 0 {
+		kp.Strategy = provider.KeyRotationRoundRobin
+	}
+
+	if kp.Strategy != provider.KeyRotationRoundRobin && kp.Strategy != provider.KeyRotationLeastRecentlyUsed && kp.Strategy != provider.KeyRotationWeighted {
+		return internal_errors.NewValidationError(fmt.Sprintf("key rotation strategy must be one of %s, %s or %s", provider.KeyRotationRoundRobin, provider.KeyRotationLeastRecentlyUsed, provider.KeyRotationWeighted)).WithFields(&internal_errors.FieldError{
+			Field:  "keyPool.strategy",
+			Reason: "invalid",
+			Value:  kp.Strategy,
+		})
+	}
+
+	seen := map[string]bool{}
+	for i, k := range kp.Keys {
+		field := fmt.Sprintf("keyPool.keys[%d]", i)
+		if k == nil || len(k.Key) == 0 {
+			return internal_errors.NewValidationError("pooled key cannot be empty").WithFields(&internal_errors.FieldError{
+				Field:  field + ".key",
+				Reason: "required",
+			})
+		}
+
+		if len(k.Id) == 0 {
+			k.Id = util.NewUuid()
+		}
+
+		if seen[k.Id] {
+			return internal_errors.NewValidationError(fmt.Sprintf("key id %s is used by more than one pooled key", k.Id)).WithFields(&internal_errors.FieldError{
+				Field:  field + ".id",
+				Reason: "duplicated",
+				Value:  k.Id,
+			})
+		}
+		seen[k.Id] = true
+
+		if k.Weight < 0 {
+			return internal_errors.NewValidationError("pooled key weight cannot be negative").WithFields(&internal_errors.FieldError{
+				Field:  field + ".weight",
+				Reason: "invalid",
+				Value:  strconv.Itoa(k.Weight),
+			})
+		}
+	}
+
+	return nil
+}
+
+// retainPooledKeys fills in the keys that an update refers to by id only
+// with their stored secrets, so that a pool can be reordered or reweighted
+// without sending every key again. Secrets are decrypted because the pool is
+// encrypted again under the new update time.
+func (m *ProviderSettingsManager) retainPooledKeys(existing *provider.Setting, kp *provider.KeyPool) error {
+	for _, k := range kp.Keys {
+		if k == nil || len(k.Key) != 0 || len(k.Id) == 0 {
+			continue
+		}
+
+		stored := existing.KeyPool.KeyOf(k.Id)
+		if stored == nil {
+			continue
+		}
+
+		k.Key = stored.Key
+		if !m.Encryptor.Enabled() {
+			continue
+		}
+
+		decrypted, err := m.Encryptor.Decrypt(stored.Key, map[string]string{"X-UPDATED-AT": strconv.FormatInt(existing.UpdatedAt, 10)})
+		if err != nil {
+			return err
+		}
+
+		k.Key = decrypted
+	}
+
+	return nil
+}
+
+func (m *ProviderSettingsManager) encryptKeyPool(updatedAt int64, kp *provider.KeyPool) error {
+	for _, k := range kp.Keys {
+		encrypted, err := m.Encryptor.Encrypt(k.Key, map[string]string{"X-UPDATED-AT": strconv.FormatInt(updatedAt, 10)})
+		if err != nil {
+			return err
+		}
+
+		k.Key = encrypted
+	}
+
+	return nil
+}
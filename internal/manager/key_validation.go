@@ -0,0 +1,33 @@
+package manager
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+// replacesCredentials reports whether an update sets new credentials for the
+// upstream of a provider setting.
+func replacesCredentials(setting *provider.UpdateSetting) bool {
+	return len(setting.Setting["apikey"]) != 0 || len(setting.Setting["awsSecretAccessKey"]) != 0 || setting.KeyPool != nil
+}
+
+// DisableSetting stops a provider setting from being used to forward requests.
+// It reports false when the setting was already disabled.
+func (m *ProviderSettingsManager) DisableSetting(id, reason string) (bool, error) {
+	disabled, err := m.Storage.DisableProviderSetting(id, reason)
+	if err != nil {
+		return false, err
+	}
+
+	if !disabled {
+		return false, nil
+	}
+
+	if err := m.Cache.Delete(id); err != nil {
+		telemetry.Incr("bricksllm.provider_settings_manager.disable_setting.delete_cache_error", nil, 1)
+	}
+
+	m.recordVersion(id)
+
+	return true, nil
+}
@@ -0,0 +1,148 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/alias"
+	"github.com/bricks-cloud/bricksllm/internal/dryrun"
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+)
+
+type ModelAliasStorage interface {
+	CreateModelAlias(a *alias.Alias) (*alias.Alias, error)
+	GetModelAlias(id string) (*alias.Alias, error)
+	GetModelAliases() ([]*alias.Alias, error)
+	UpdateModelAlias(id string, a *alias.UpdateAlias) (*alias.Alias, error)
+	DeleteModelAlias(id string) error
+}
+
+type aliasKeyStorage interface {
+	GetKeys(tags, keyIds []string, provider string) ([]*key.ResponseKey, error)
+}
+
+type ModelAliasManager struct {
+	s  ModelAliasStorage
+	ks aliasKeyStorage
+}
+
+func NewModelAliasManager(s ModelAliasStorage, ks aliasKeyStorage) *ModelAliasManager {
+	return &ModelAliasManager{
+		s:  s,
+		ks: ks,
+	}
+}
+
+func (m *ModelAliasManager) validateKeyIds(keyIds []string) error {
+	if len(keyIds) == 0 {
+		return nil
+	}
+
+	keys, err := m.ks.GetKeys(nil, keyIds, "")
+	if err != nil {
+		return err
+	}
+
+	found := map[string]bool{}
+	for _, k := range keys {
+		found[k.KeyId] = true
+	}
+
+	for _, id := range keyIds {
+		if !found[id] {
+			return internal_errors.NewValidationError("key of model alias is not found: " + id)
+		}
+	}
+
+	return nil
+}
+
+// checkOverlaps makes sure that no other alias resolves the same model name
+// for any of the keys of a.
+func (m *ModelAliasManager) checkOverlaps(a *alias.Alias) error {
+	existing, err := m.s.GetModelAliases()
+	if err != nil {
+		return err
+	}
+
+	for _, e := range existing {
+		if a.Overlaps(e) {
+			return internal_errors.NewConflictError("model alias overlaps with existing alias: " + e.Id)
+		}
+	}
+
+	return nil
+}
+
+func (m *ModelAliasManager) CreateModelAlias(a *alias.Alias) (*alias.Alias, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := m.validateKeyIds(a.KeyIds); err != nil {
+		return nil, err
+	}
+
+	if err := m.checkOverlaps(a); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	a.Id = util.NewUuid()
+	a.CreatedAt = now
+	a.UpdatedAt = now
+
+	return m.s.CreateModelAlias(a)
+}
+
+func (m *ModelAliasManager) GetModelAliases() ([]*alias.Alias, error) {
+	return m.s.GetModelAliases()
+}
+
+// UpdateModelAlias points an alias at another model, or changes the keys it
+// applies to.
+func (m *ModelAliasManager) UpdateModelAlias(id string, ua *alias.UpdateAlias) (*alias.Alias, error) {
+	existing, err := m.s.GetModelAlias(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ua.Model) != 0 {
+		existing.Model = ua.Model
+	}
+
+	if ua.KeyIds != nil {
+		existing.KeyIds = *ua.KeyIds
+	}
+
+	if err := existing.Validate(); err != nil {
+		return nil, err
+	}
+
+	if ua.KeyIds != nil {
+		if err := m.validateKeyIds(*ua.KeyIds); err != nil {
+			return nil, err
+		}
+
+		if err := m.checkOverlaps(existing); err != nil {
+			return nil, err
+		}
+	}
+
+	ua.UpdatedAt = time.Now().Unix()
+
+	return m.s.UpdateModelAlias(id, ua)
+}
+
+func (m *ModelAliasManager) DeleteModelAlias(id string) error {
+	return m.s.DeleteModelAlias(id)
+}
+
+func (m *ModelAliasManager) PreviewDeleteModelAlias(id string) (*dryrun.Result, error) {
+	if _, err := m.s.GetModelAlias(id); err != nil {
+		return nil, err
+	}
+
+	return dryrun.NewResult(dryrun.ActionDelete, "model alias", []string{id}), nil
+}
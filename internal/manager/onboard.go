@@ -0,0 +1,143 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/hasher"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/user"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+)
+
+type OnboardStorage interface {
+	GetUsers(tags, keyIds, userIds []string, offset int, limit int) ([]*user.User, error)
+	GetProviderSetting(id string, withSecret bool) (*provider.Setting, error)
+	GetProviderSettings(withSecret bool, ids []string) ([]*provider.Setting, error)
+	GetPolicyById(id string) (*policy.Policy, error)
+	CreateUserWithKey(u *user.User, rk *key.RequestKey) (*user.User, *key.ResponseKey, error)
+}
+
+type OnboardManager struct {
+	s  OnboardStorage
+	sf *key.SecretFormat
+}
+
+func NewOnboardManager(s OnboardStorage, sf *key.SecretFormat) *OnboardManager {
+	return &OnboardManager{
+		s:  s,
+		sf: sf,
+	}
+}
+
+func (m *OnboardManager) Onboard(r *user.OnboardRequest) (*user.OnboardResponse, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	secret, err := m.sf.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+
+	rk := r.Template
+	rk.CreatedAt = now
+	rk.UpdatedAt = now
+	rk.KeyId = util.NewUuid()
+	rk.Key = secret
+	rk.Tags = appendTag(rk.Tags, r.Org)
+
+	if len(rk.Name) == 0 {
+		rk.Name = r.User.Name
+	}
+
+	if len(r.PolicyId) != 0 {
+		rk.PolicyId = r.PolicyId
+	}
+
+	if err := rk.Validate(); err != nil {
+		return nil, err
+	}
+
+	if !rk.IsKeyNotHashed {
+		rk.Key = hasher.Hash(secret)
+	}
+
+	if len(rk.SettingId) != 0 {
+		if _, err := m.s.GetProviderSetting(rk.SettingId, false); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rk.SettingIds) != 0 {
+		existing, err := m.s.GetProviderSettings(false, rk.SettingIds)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(existing) == 0 {
+			return nil, errors.New("provider settings not found")
+		}
+	}
+
+	if len(rk.PolicyId) != 0 {
+		if _, err := m.s.GetPolicyById(rk.PolicyId); err != nil {
+			return nil, err
+		}
+	}
+
+	u := r.User
+	u.Id = util.NewUuid()
+	u.CreatedAt = now
+	u.UpdatedAt = now
+	u.Tags = appendTag(u.Tags, r.Org)
+	u.KeyIds = []string{rk.KeyId}
+
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+
+	if len(u.UserId) != 0 {
+		existing, err := m.s.GetUsers(u.Tags, nil, []string{u.UserId}, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(existing) != 0 {
+			return nil, internal_errors.NewValidationError(fmt.Sprintf("user id exists for tags: [%s]", strings.Join(u.Tags, ",")))
+		}
+	}
+
+	if len(u.UserId) == 0 {
+		u.UserId = util.NewUuid()
+	}
+
+	createdUser, createdKey, err := m.s.CreateUserWithKey(u, rk)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user.OnboardResponse{
+		Org:    r.Org,
+		User:   createdUser,
+		Key:    createdKey,
+		Secret: secret,
+	}, nil
+}
+
+func appendTag(tags []string, tag string) []string {
+	for _, t := range tags {
+		if t == tag {
+			return tags
+		}
+	}
+
+	return append(tags, tag)
+}
@@ -1,10 +1,19 @@
 package manager
 
 import (
+	"fmt"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/dryrun"
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/key"
 	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
 	"github.com/bricks-cloud/bricksllm/internal/util"
+	"go.uber.org/zap"
 )
 
 type PoliciesStorage interface {
@@ -12,21 +21,52 @@ type PoliciesStorage interface {
 	UpdatePolicy(id string, p *policy.UpdatePolicy) (*policy.Policy, error)
 	GetPolicyById(id string) (*policy.Policy, error)
 	GetPoliciesByTags(tags []string) ([]*policy.Policy, error)
+	DeletePolicyWithDependents(id string, keyIds, routeIds []string) ([]string, []string, error)
+	GetKeyIdsByPolicyId(policyId string) ([]string, error)
+	InsertPolicyVersion(p *policy.Policy, createdAt int64) error
+	GetPolicyVersions(policyId string) ([]*policy.PolicyVersion, error)
+	GetPolicyVersion(policyId string, version int) (*policy.PolicyVersion, error)
+	SetPolicyRollout(id string, r *policy.Rollout, updatedAt int64) (*policy.Policy, error)
+	GetKey(keyId string) (*key.ResponseKey, error)
+	GetRoute(id string) (*route.Route, error)
+	GetRouteIdsByPolicyId(policyId string) ([]string, error)
+	InsertPiiToken(policyId, token, encrypted string, createdAt int64) error
+	GetPiiTokens(policyId string, tokens []string) ([]*policy.VaultToken, error)
 }
 
 type PoliciesMemStorage interface {
 	GetPolicy(id string) *policy.Policy
+	GetPoliciesByTags(namespace string, tags []string) []*policy.Policy
+	DeletePolicy(id string)
+	DeleteRoute(path string)
 }
 
 type PolicyManager struct {
 	Storage PoliciesStorage
 	Memdb   PoliciesMemStorage
+	kc      keyCache
+	scanner policy.Scanner
+	cd      policy.CustomPolicyDetector
+	mod     policy.Moderator
+
+	versions     map[string]*policy.Policy
+	versionsLock sync.RWMutex
+
+	encryptor Encryptor
+	stored    *storedTokens
 }
 
-func NewPolicyManager(s PoliciesStorage, memdb PoliciesMemStorage) *PolicyManager {
+func NewPolicyManager(s PoliciesStorage, memdb PoliciesMemStorage, kc keyCache, scanner policy.Scanner, cd policy.CustomPolicyDetector, mod policy.Moderator, encryptor Encryptor) *PolicyManager {
 	return &PolicyManager{
-		Storage: s,
-		Memdb:   memdb,
+		Storage:   s,
+		Memdb:     memdb,
+		kc:        kc,
+		scanner:   scanner,
+		cd:        cd,
+		mod:       mod,
+		versions:  map[string]*policy.Policy{},
+		encryptor: encryptor,
+		stored:    &storedTokens{tokens: map[string]bool{}},
 	}
 }
 
@@ -52,7 +92,14 @@ func (m *PolicyManager) CreatePolicy(p *policy.Policy) (*policy.Policy, error) {
 		p.CustomConfig = &policy.CustomConfig{}
 	}
 
-	return m.Storage.CreatePolicy(p)
+	created, err := m.Storage.CreatePolicy(p)
+	if err != nil {
+		return nil, err
+	}
+
+	m.recordVersion(created)
+
+	return created, nil
 }
 
 func (m *PolicyManager) UpdatePolicy(id string, p *policy.UpdatePolicy) (*policy.Policy, error) {
@@ -61,15 +108,124 @@ func (m *PolicyManager) UpdatePolicy(id string, p *policy.UpdatePolicy) (*policy
 		return nil, err
 	}
 
+	existing, err := m.Storage.GetPolicyById(id)
+	if err != nil {
+		return nil, err
+	}
+
 	p.UpdatedAt = time.Now().Unix()
 
-	return m.Storage.UpdatePolicy(id, p)
+	// policies created before versions were kept have no snapshot of the
+	// version being replaced
+	m.recordVersion(existing)
+
+	updated, err := m.Storage.UpdatePolicy(id, p)
+	if err != nil {
+		return nil, err
+	}
+
+	m.recordVersion(updated)
+
+	return updated, nil
 }
 
 func (m *PolicyManager) GetPoliciesByTags(tags []string) ([]*policy.Policy, error) {
 	return m.Storage.GetPoliciesByTags(tags)
 }
 
+func (m *PolicyManager) GetPolicy(id string) (*policy.Policy, error) {
+	return m.Storage.GetPolicyById(id)
+}
+
+// EvaluatePolicy runs a sample request through a policy with the same
+// scanner, custom detector and moderator the proxy uses, without forwarding
+// it.
+func (m *PolicyManager) EvaluatePolicy(id string, er *policy.EvaluateRequest, log *zap.Logger) (*policy.Evaluation, error) {
+	if err := er.Validate(); err != nil {
+		return nil, err
+	}
+
+	p, err := m.Storage.GetPolicyById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Evaluate(er, m.scanner, m.cd, m.mod, log)
+}
+
 func (m *PolicyManager) GetPolicyByIdFromMemdb(id string) *policy.Policy {
 	return m.Memdb.GetPolicy(id)
 }
+
+func (m *PolicyManager) policyDependents(id string) ([]*dryrun.Dependent, error) {
+	keyIds, err := m.Storage.GetKeyIdsByPolicyId(id)
+	if err != nil {
+		return nil, err
+	}
+
+	routeIds, err := m.Storage.GetRouteIdsByPolicyId(id)
+	if err != nil {
+		return nil, err
+	}
+
+	dependents := dryrun.AddDependents(nil, "key", keyIds)
+	return dryrun.AddDependents(dependents, "route", routeIds), nil
+}
+
+func (m *PolicyManager) checkPolicyDeletion(id string, cascade bool) ([]*dryrun.Dependent, error) {
+	dependents, err := m.policyDependents(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if keyIds := dryrun.IdsOf(dependents, "key"); !cascade && len(keyIds) != 0 {
+		return nil, internal_errors.NewConflictError(fmt.Sprintf("policy is still referenced by keys: %s", strings.Join(keyIds, ",")))
+	}
+
+	if routeIds := dryrun.IdsOf(dependents, "route"); !cascade && len(routeIds) != 0 {
+		return nil, internal_errors.NewConflictError(fmt.Sprintf("policy is still referenced by routes: %s", strings.Join(routeIds, ",")))
+	}
+
+	return dependents, nil
+}
+
+func (m *PolicyManager) PreviewDeletePolicy(id string, cascade bool) (*dryrun.Result, error) {
+	if _, err := m.Storage.GetPolicyById(id); err != nil {
+		return nil, err
+	}
+
+	dependents, err := m.policyDependents(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return dryrun.NewDeleteResult("policy", []string{id}, dependents, cascade), nil
+}
+
+// DeletePolicy deletes a policy. With cascade, the keys and routes the policy
+// is attached to are deleted as well instead of failing the deletion.
+func (m *PolicyManager) DeletePolicy(id string, cascade bool) ([]*dryrun.Dependent, error) {
+	dependents, err := m.checkPolicyDeletion(id, cascade)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes, paths, err := m.Storage.DeletePolicyWithDependents(id, dryrun.IdsOf(dependents, "key"), dryrun.IdsOf(dependents, "route"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hash := range hashes {
+		if err := m.kc.Delete(hash); err != nil {
+			telemetry.Incr("bricksllm.policy_manager.delete_policy.delete_key_cache_error", nil, 1)
+		}
+	}
+
+	for _, path := range paths {
+		m.Memdb.DeleteRoute(path)
+	}
+
+	m.Memdb.DeletePolicy(id)
+
+	return dependents, nil
+}
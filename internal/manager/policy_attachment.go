@@ -0,0 +1,79 @@
+package manager
+
+import (
+	"fmt"
+	"slices"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+// GetEffectivePolicyFromMemdb returns the policy that applies to requests of
+// k, sent through r if it is not nil. A policy attached to the key or the route
+// that is no longer in the memdb is skipped, so that the next one in order
+// still applies.
+func (m *PolicyManager) GetEffectivePolicyFromMemdb(k *key.ResponseKey, r *route.Route) *policy.EffectivePolicy {
+	ep := &policy.EffectivePolicy{
+		KeyId: k.KeyId,
+	}
+
+	if r != nil {
+		ep.RouteId = r.Id
+	}
+
+	if len(k.PolicyId) != 0 {
+		if p := m.Memdb.GetPolicy(k.PolicyId); p != nil {
+			ep.Policy, ep.AttachedTo = p, policy.KeyAttachment
+			return ep
+		}
+
+		telemetry.Incr("bricksllm.policy_manager.get_effective_policy_from_memdb.key_policy_not_found", nil, 1)
+	}
+
+	if r != nil && len(r.PolicyId) != 0 {
+		if p := m.Memdb.GetPolicy(r.PolicyId); p != nil {
+			ep.Policy, ep.AttachedTo = p, policy.RouteAttachment
+			return ep
+		}
+
+		telemetry.Incr("bricksllm.policy_manager.get_effective_policy_from_memdb.route_policy_not_found", nil, 1)
+	}
+
+	if len(k.Tags) != 0 {
+		if p := policy.Earliest(m.Memdb.GetPoliciesByTags(k.Namespace, k.Tags)); p != nil {
+			ep.Policy, ep.AttachedTo = p, policy.TagAttachment
+		}
+	}
+
+	return ep
+}
+
+// GetEffectivePolicy returns the policy the gateway applies to requests of a
+// key, sent through the route with routeId if it is not empty.
+func (m *PolicyManager) GetEffectivePolicy(keyId, routeId string) (*policy.EffectivePolicy, error) {
+	k, err := m.Storage.GetKey(keyId)
+	if err != nil {
+		return nil, err
+	}
+
+	if k == nil {
+		return nil, internal_errors.NewNotFoundError("key is not found for id: " + keyId)
+	}
+
+	var r *route.Route
+	if len(routeId) != 0 {
+		r, err = m.Storage.GetRoute(routeId)
+		if err != nil {
+			return nil, err
+		}
+
+		if !slices.Contains(r.KeyIds, k.KeyId) {
+			return nil, internal_errors.NewValidationError(fmt.Sprintf("key %s cannot access route %s", k.KeyId, r.Id))
+		}
+	}
+
+	return m.GetEffectivePolicyFromMemdb(k, r), nil
+}
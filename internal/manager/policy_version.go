@@ -0,0 +1,196 @@
+package manager
+
+import (
+	"fmt"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+// recordVersion snapshots a policy after it has been written. Failing to do
+// so does not undo the write, so it is only counted.
+func (m *PolicyManager) recordVersion(p *policy.Policy) {
+	if err := m.Storage.InsertPolicyVersion(p, time.Now().Unix()); err != nil {
+		telemetry.Incr("bricksllm.policy_manager.record_version.insert_policy_version_error", nil, 1)
+	}
+}
+
+// GetPolicyVersions returns the version history of a policy, newest first.
+func (m *PolicyManager) GetPolicyVersions(id string) ([]*policy.PolicyVersion, error) {
+	if _, err := m.Storage.GetPolicyById(id); err != nil {
+		return nil, err
+	}
+
+	return m.Storage.GetPolicyVersions(id)
+}
+
+// RollbackPolicy restores the config a policy had at version. The restored
+// config becomes a new version of the policy, and a rollout in progress is
+// ended.
+func (m *PolicyManager) RollbackPolicy(id string, version int) (*policy.Policy, error) {
+	existing, err := m.Storage.GetPolicyById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := m.Storage.GetPolicyVersion(id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	restored := &policy.UpdatePolicy{
+		Name:             v.Policy.Name,
+		Tags:             v.Policy.Tags,
+		Config:           v.Policy.Config,
+		RegexConfig:      v.Policy.RegexConfig,
+		CustomConfig:     v.Policy.CustomConfig,
+		Conditions:       v.Policy.Conditions,
+		ResponseConfig:   v.Policy.ResponseConfig,
+		ModerationConfig: v.Policy.ModerationConfig,
+		InjectionConfig:  v.Policy.InjectionConfig,
+		LimitConfig:      v.Policy.LimitConfig,
+		TopicConfig:      v.Policy.TopicConfig,
+		LanguageConfig:   v.Policy.LanguageConfig,
+		LatencyBudget:    v.Policy.LatencyBudget,
+		GuardrailConfig:  v.Policy.GuardrailConfig,
+		SchemaConfig:     v.Policy.SchemaConfig,
+	}
+
+	// configs left out of the update are kept, so the ones the version did
+	// not have are cleared explicitly
+	if restored.Config == nil {
+		restored.Config = &policy.Config{}
+	}
+
+	if restored.RegexConfig == nil {
+		restored.RegexConfig = &policy.RegexConfig{}
+	}
+
+	if restored.CustomConfig == nil {
+		restored.CustomConfig = &policy.CustomConfig{}
+	}
+
+	if restored.Conditions == nil {
+		restored.Conditions = []*policy.Condition{}
+	}
+
+	if restored.ResponseConfig == nil {
+		restored.ResponseConfig = &policy.ResponseConfig{}
+	}
+
+	if restored.ModerationConfig == nil {
+		restored.ModerationConfig = &policy.ModerationConfig{}
+	}
+
+	if restored.InjectionConfig == nil {
+		restored.InjectionConfig = &policy.InjectionConfig{}
+	}
+
+	if restored.LimitConfig == nil {
+		restored.LimitConfig = &policy.LimitConfig{}
+	}
+
+	if restored.TopicConfig == nil {
+		restored.TopicConfig = &policy.TopicConfig{}
+	}
+
+	if restored.LanguageConfig == nil {
+		restored.LanguageConfig = &policy.LanguageConfig{}
+	}
+
+	if restored.LatencyBudget == nil {
+		restored.LatencyBudget = &policy.LatencyBudget{}
+	}
+
+	if restored.GuardrailConfig == nil {
+		restored.GuardrailConfig = &policy.GuardrailConfig{}
+	}
+
+	if restored.SchemaConfig == nil {
+		restored.SchemaConfig = &policy.SchemaConfig{}
+	}
+
+	updated, err := m.UpdatePolicy(id, restored)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing.Rollout == nil {
+		return updated, nil
+	}
+
+	return m.Storage.SetPolicyRollout(id, nil, updated.UpdatedAt+1)
+}
+
+// SetPolicyRollout starts or changes the rollout of the current version of a
+// policy. Rolling it out to every request promotes it and ends the rollout.
+func (m *PolicyManager) SetPolicyRollout(id string, ur *policy.UpdateRollout) (*policy.Policy, error) {
+	existing, err := m.Storage.GetPolicyById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if ur.BaseVersion == 0 && existing.Rollout != nil {
+		ur.BaseVersion = existing.Rollout.BaseVersion
+	}
+
+	if ur.BaseVersion == 0 {
+		ur.BaseVersion = existing.Version - 1
+	}
+
+	if err := ur.Validate(existing.Version); err != nil {
+		return nil, err
+	}
+
+	// the memdb only picks up policies updated after the copy it has
+	updatedAt := time.Now().Unix()
+	if updatedAt <= existing.UpdatedAt {
+		updatedAt = existing.UpdatedAt + 1
+	}
+
+	if ur.Percentage == 100 {
+		return m.Storage.SetPolicyRollout(id, nil, updatedAt)
+	}
+
+	if _, err := m.Storage.GetPolicyVersion(id, ur.BaseVersion); err != nil {
+		if _, ok := err.(notFoundError); ok {
+			return nil, internal_errors.NewValidationError(fmt.Sprintf("rollout base version %d is not kept for the policy", ur.BaseVersion))
+		}
+
+		return nil, err
+	}
+
+	return m.Storage.SetPolicyRollout(id, &policy.Rollout{
+		BaseVersion: ur.BaseVersion,
+		Percentage:  ur.Percentage,
+	}, updatedAt)
+}
+
+// GetPolicyVersionFromCache returns a policy as it was at version, for
+// requests pinned to the version or held back by a rollout. Versions never
+// change, so they are cached once loaded. It returns nil if the version is
+// not kept.
+func (m *PolicyManager) GetPolicyVersionFromCache(id string, version int) *policy.Policy {
+	k := fmt.Sprintf("%s/%d", id, version)
+
+	m.versionsLock.RLock()
+	p, ok := m.versions[k]
+	m.versionsLock.RUnlock()
+	if ok {
+		return p
+	}
+
+	v, err := m.Storage.GetPolicyVersion(id, version)
+	if err != nil {
+		telemetry.Incr("bricksllm.policy_manager.get_policy_version_from_cache.get_policy_version_error", nil, 1)
+		return nil
+	}
+
+	m.versionsLock.Lock()
+	m.versions[k] = v.Policy
+	m.versionsLock.Unlock()
+
+	return v.Policy
+}
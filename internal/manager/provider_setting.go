@@ -7,6 +7,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/currency"
+	"github.com/bricks-cloud/bricksllm/internal/dryrun"
+	"github.com/bricks-cloud/bricksllm/internal/encryptor"
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
 	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
@@ -20,6 +23,12 @@ type ProviderSettingsStorage interface {
 	GetProviderSetting(id string, withSecret bool) (*provider.Setting, error)
 	GetCustomProviderByName(name string) (*custom.Provider, error)
 	GetProviderSettings(withSecret bool, ids []string) ([]*provider.Setting, error)
+	DeleteProviderSettingWithKeys(id string, keyIds []string) ([]string, error)
+	InsertProviderSettingVersion(setting *provider.Setting, createdAt int64) (*provider.SettingVersion, error)
+	GetProviderSettingVersions(settingId string) ([]*provider.SettingVersion, error)
+	GetProviderSettingVersion(settingId string, version int) (*provider.SettingVersion, error)
+	DisableProviderSetting(id, reason string) (bool, error)
+	GetKeyIdsBySettingId(settingId string) ([]string, error)
 }
 
 type ProviderSettingsCache interface {
@@ -30,31 +39,45 @@ type ProviderSettingsCache interface {
 
 type Encryptor interface {
 	Encrypt(input string, headers map[string]string) (string, error)
+	Decrypt(input string, headers map[string]string) (string, error)
 	Enabled() bool
 }
 
+type CurrencyConverter interface {
+	Supported(code string) bool
+	FromUsd(amount float64, code string) (float64, error)
+}
+
 type ProviderSettingsManager struct {
 	Storage   ProviderSettingsStorage
 	Cache     ProviderSettingsCache
+	Keys      keyCache
 	Encryptor Encryptor
+	Catalog   ModelCatalog
+	Fx        CurrencyConverter
+	Monitor   SettingHealthMonitor
 }
 
-func NewProviderSettingsManager(s ProviderSettingsStorage, cache ProviderSettingsCache, encryptor Encryptor) *ProviderSettingsManager {
+func NewProviderSettingsManager(s ProviderSettingsStorage, cache ProviderSettingsCache, kc keyCache, encryptor Encryptor, mc ModelCatalog, fx CurrencyConverter, hm SettingHealthMonitor) *ProviderSettingsManager {
 	return &ProviderSettingsManager{
 		Storage:   s,
 		Cache:     cache,
+		Keys:      kc,
 		Encryptor: encryptor,
+		Catalog:   mc,
+		Fx:        fx,
+		Monitor:   hm,
 	}
 }
 
 func isProviderNativelySupported(provider string) bool {
-	return provider == "openai" || provider == "anthropic" || provider == "azure" || provider == "vllm" || provider == "deepinfra" || provider == "bedrock"
+	return provider == "openai" || provider == "anthropic" || provider == "azure" || provider == "vllm" || provider == "deepinfra" || provider == "bedrock" || provider == "mistral" || provider == "cohere" || provider == "groq" || provider == "self-hosted" || provider == "bricksllm"
 }
 
 func findMissingAuthParams(providerName string, params map[string]string) string {
 	missingFields := []string{}
 
-	if providerName == "openai" || providerName == "anthropic" || providerName == "deepinfra" {
+	if providerName == "openai" || providerName == "anthropic" || providerName == "deepinfra" || providerName == "mistral" || providerName == "cohere" || providerName == "groq" {
 		val := params["apikey"]
 		if len(val) == 0 {
 			missingFields = append(missingFields, "apikey")
@@ -64,17 +87,19 @@ func findMissingAuthParams(providerName string, params map[string]string) string
 	}
 
 	if providerName == "bedrock" {
-		val := params["awsAccessKeyId"]
-		if len(val) == 0 {
-			missingFields = append(missingFields, "awsAccessKeyId")
-		}
+		if params["awsCredentialSource"] != provider.AwsCredentialSourceDefault {
+			val := params["awsAccessKeyId"]
+			if len(val) == 0 {
+				missingFields = append(missingFields, "awsAccessKeyId")
+			}
 
-		val = params["awsSecretAccessKey"]
-		if len(val) == 0 {
-			missingFields = append(missingFields, "awsSecretAccessKey")
+			val = params["awsSecretAccessKey"]
+			if len(val) == 0 {
+				missingFields = append(missingFields, "awsSecretAccessKey")
+			}
 		}
 
-		val = params["awsRegion"]
+		val := params["awsRegion"]
 		if len(val) == 0 {
 			missingFields = append(missingFields, "awsRegion")
 		}
@@ -92,40 +117,127 @@ func findMissingAuthParams(providerName string, params map[string]string) string
 		}
 	}
 
-	if providerName == "vllm" {
+	if providerName == "vllm" || providerName == "self-hosted" {
+		val := params["url"]
+		if len(val) == 0 {
+			missingFields = append(missingFields, "url")
+		}
+	}
+
+	if providerName == "bricksllm" {
 		val := params["url"]
 		if len(val) == 0 {
 			missingFields = append(missingFields, "url")
 		}
+
+		val = params["apikey"]
+		if len(val) == 0 {
+			missingFields = append(missingFields, "apikey")
+		}
 	}
 
 	return strings.Join(missingFields, ",")
 }
 
-func (m *ProviderSettingsManager) validateSettings(providerName string, setting map[string]string) error {
+// validateSettings checks the params of a provider setting. The apikey param
+// is optional when the setting has pooled keys.
+func (m *ProviderSettingsManager) validateSettings(providerName string, setting map[string]string, pooled bool) error {
 	if !isProviderNativelySupported(providerName) {
 		provider, err := m.Storage.GetCustomProviderByName(providerName)
 		_, ok := err.(notFoundError)
 		if ok {
-			return internal_errors.NewValidationError(fmt.Sprintf("provider %s is not supported", providerName))
+			return internal_errors.NewValidationError(fmt.Sprintf("provider %s is not supported", providerName)).WithFields(&internal_errors.FieldError{
+				Field:  "provider",
+				Reason: "not supported",
+				Value:  providerName,
+			})
 		}
 
 		if len(provider.AuthenticationParam) != 0 {
 			val := setting[provider.AuthenticationParam]
 			if len(val) == 0 {
-				return internal_errors.NewValidationError(fmt.Sprintf("provider %s is missing value for field %s", providerName, provider.AuthenticationParam))
+				return internal_errors.NewValidationError(fmt.Sprintf("provider %s is missing value for field %s", providerName, provider.AuthenticationParam)).WithFields(&internal_errors.FieldError{
+					Field:  "setting." + provider.AuthenticationParam,
+					Reason: "required",
+				})
 			}
 		}
 	}
 
+	if providerName == "azure" {
+		if err := validateApiVersion("setting.apiVersion", setting["apiVersion"]); err != nil {
+			return err
+		}
+	}
+
+	if source := setting["awsCredentialSource"]; providerName == "bedrock" && len(source) != 0 && source != provider.AwsCredentialSourceStatic && source != provider.AwsCredentialSourceDefault {
+		return internal_errors.NewValidationError(fmt.Sprintf("awsCredentialSource must be either %s or %s", provider.AwsCredentialSourceStatic, provider.AwsCredentialSourceDefault)).WithFields(&internal_errors.FieldError{
+			Field:  "setting.awsCredentialSource",
+			Reason: "invalid",
+			Value:  source,
+		})
+	}
+
+	if providerName == "self-hosted" || providerName == "bricksllm" {
+		if err := validateProviderUrl(providerName, setting["url"]); err != nil {
+			return err
+		}
+	}
+
 	missing := findMissingAuthParams(providerName, setting)
+	if pooled {
+		missing = withoutParam(missing, "apikey")
+	}
+
 	if len(missing) != 0 {
-		return internal_errors.NewValidationError(fmt.Sprintf("provider %s is missing fields %s", providerName, missing))
+		fields := []*internal_errors.FieldError{}
+		for _, name := range strings.Split(missing, ",") {
+			fields = append(fields, &internal_errors.FieldError{
+				Field:  "setting." + name,
+				Reason: "required",
+			})
+		}
+
+		return internal_errors.NewValidationError(fmt.Sprintf("provider %s is missing fields %s", providerName, missing)).WithFields(fields...)
+	}
+
+	return nil
+}
+
+// validateCostMap normalizes the currency of a cost map. Prices in a currency
+// other than USD can only be accepted when there is a rate to convert them,
+// since spend is limited in USD.
+func (m *ProviderSettingsManager) validateCostMap(cm *provider.CostMap) error {
+	if cm == nil || len(cm.Currency) == 0 {
+		return nil
+	}
+
+	if !currency.Valid(cm.Currency) {
+		return internal_errors.NewValidationError(fmt.Sprintf("cost map currency %s is not a valid ISO 4217 code", cm.Currency)).WithFields(&internal_errors.FieldError{
+			Field:  "costMap.currency",
+			Reason: "invalid",
+			Value:  cm.Currency,
+		})
+	}
+
+	cm.Currency = currency.Normalize(cm.Currency)
+	if m.Fx != nil && !m.Fx.Supported(cm.Currency) {
+		return internal_errors.NewValidationError(fmt.Sprintf("no fx rate is configured for currency %s", cm.Currency)).WithFields(&internal_errors.FieldError{
+			Field:  "costMap.currency",
+			Reason: "not supported",
+			Value:  cm.Currency,
+		})
 	}
 
 	return nil
 }
 
+// encryptsApiKey reports whether the apikey param of settings of provider is
+// stored encrypted when encryption is enabled.
+func encryptsApiKey(provider string) bool {
+	return provider == "openai" || provider == "anthropic" || provider == "deepinfra" || provider == "azure" || provider == "mistral" || provider == "cohere" || provider == "groq" || provider == "self-hosted" || provider == "bricksllm"
+}
+
 func (m *ProviderSettingsManager) EncryptParams(updatedAt int64, provider string, params map[string]string) (map[string]string, error) {
 	if provider == "amazon" {
 		encryted, err := m.Encryptor.Encrypt(params["awsSecretAccessKey"], map[string]string{"X-UPDATED-AT": strconv.FormatInt(updatedAt, 10)})
@@ -135,7 +247,7 @@ func (m *ProviderSettingsManager) EncryptParams(updatedAt int64, provider string
 
 		params["awsSecretAccessKey"] = encryted
 
-	} else if provider == "openai" || provider == "anthropic" || provider == "deepinfra" || provider == "azure" {
+	} else if encryptsApiKey(provider) && len(params["apikey"]) != 0 && !encryptor.IsEncrypted(params["apikey"]) {
 		encryted, err := m.Encryptor.Encrypt(params["apikey"], map[string]string{"X-UPDATED-AT": strconv.FormatInt(updatedAt, 10)})
 		if err != nil {
 			return nil, err
@@ -152,7 +264,55 @@ func (m *ProviderSettingsManager) CreateSetting(setting *provider.Setting) (*pro
 		return nil, internal_errors.NewValidationError("provider field cannot be empty")
 	}
 
-	if err := m.validateSettings(setting.Provider, setting.Setting); err != nil {
+	if setting.Setting == nil {
+		setting.Setting = map[string]string{}
+	}
+
+	if err := m.validateSettings(setting.Provider, setting.Setting, hasPooledKeys(setting.KeyPool)); err != nil {
+		return nil, err
+	}
+
+	if err := validateKeyPool(setting.Provider, setting.KeyPool); err != nil {
+		return nil, err
+	}
+
+	if err := m.validateCostMap(setting.CostMap); err != nil {
+		return nil, err
+	}
+
+	if err := validateDeployments(setting.Provider, setting.Deployments); err != nil {
+		return nil, err
+	}
+
+	if err := validateLabels(setting.Labels); err != nil {
+		return nil, err
+	}
+
+	if err := validateEnvironment(setting.Environment); err != nil {
+		return nil, err
+	}
+
+	if err := validateHeaders(setting.Headers); err != nil {
+		return nil, err
+	}
+
+	if err := validateQueryParams(setting.QueryParams); err != nil {
+		return nil, err
+	}
+
+	if err := validateSpendLimit(setting.SpendLimit); err != nil {
+		return nil, err
+	}
+
+	if fields := setting.Transport.Validate("transport"); len(fields) != 0 {
+		return nil, internal_errors.NewInvalidFieldsError(fields)
+	}
+
+	if fields := setting.TokenBudget.Validate("tokenBudget"); len(fields) != 0 {
+		return nil, internal_errors.NewInvalidFieldsError(fields)
+	}
+
+	if err := checkModelsListed(m.Catalog, setting.Provider, "allowedModels", setting.AllowedModels); err != nil {
 		return nil, err
 	}
 
@@ -167,9 +327,22 @@ func (m *ProviderSettingsManager) CreateSetting(setting *provider.Setting) (*pro
 		}
 
 		setting.Setting = params
+
+		if hasPooledKeys(setting.KeyPool) {
+			if err := m.encryptKeyPool(setting.UpdatedAt, setting.KeyPool); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	created, err := m.Storage.CreateProviderSetting(setting)
+	if err != nil {
+		return nil, err
 	}
 
-	return m.Storage.CreateProviderSetting(setting)
+	m.recordVersion(created.Id)
+
+	return created, nil
 }
 
 func (m *ProviderSettingsManager) UpdateSetting(id string, setting *provider.UpdateSetting) (*provider.Setting, error) {
@@ -182,8 +355,44 @@ func (m *ProviderSettingsManager) UpdateSetting(id string, setting *provider.Upd
 		return nil, internal_errors.NewNotFoundError("provider setting is not found")
 	}
 
+	// replacing the credentials of a disabled setting enables it again unless
+	// the update says otherwise
+	if existing.Disabled && setting.Disabled == nil && replacesCredentials(setting) {
+		enabled := false
+		setting.Disabled = &enabled
+	}
+
+	if setting.Disabled != nil && !*setting.Disabled {
+		reason := ""
+		setting.DisabledReason = &reason
+	}
+
+	// an update that leaves the pool alone still has to encrypt it again
+	// under the new update time
+	if setting.KeyPool == nil && hasPooledKeys(existing.KeyPool) && m.Encryptor.Enabled() {
+		setting.KeyPool = &provider.KeyPool{Strategy: existing.KeyPool.Strategy}
+		for _, k := range existing.KeyPool.Keys {
+			setting.KeyPool.Keys = append(setting.KeyPool.Keys, &provider.PooledKey{Id: k.Id, Weight: k.Weight})
+		}
+	}
+
+	if setting.KeyPool != nil {
+		if err := m.retainPooledKeys(existing, setting.KeyPool); err != nil {
+			return nil, err
+		}
+
+		if err := validateKeyPool(existing.Provider, setting.KeyPool); err != nil {
+			return nil, err
+		}
+	}
+
 	if len(setting.Setting) != 0 {
-		if err := m.validateSettings(existing.Provider, setting.Setting); err != nil {
+		pooled := hasPooledKeys(existing.KeyPool)
+		if setting.KeyPool != nil {
+			pooled = hasPooledKeys(setting.KeyPool)
+		}
+
+		if err := m.validateSettings(existing.Provider, setting.Setting, pooled); err != nil {
 			return nil, err
 		}
 
@@ -195,6 +404,58 @@ func (m *ProviderSettingsManager) UpdateSetting(id string, setting *provider.Upd
 		setting.Setting = merged
 	}
 
+	if err := m.validateCostMap(setting.CostMap); err != nil {
+		return nil, err
+	}
+
+	if setting.Deployments != nil {
+		if err := validateDeployments(existing.Provider, *setting.Deployments); err != nil {
+			return nil, err
+		}
+	}
+
+	if setting.AllowedModels != nil {
+		if err := checkModelsListed(m.Catalog, existing.Provider, "allowedModels", *setting.AllowedModels); err != nil {
+			return nil, err
+		}
+	}
+
+	if setting.Labels != nil {
+		if err := validateLabels(*setting.Labels); err != nil {
+			return nil, err
+		}
+	}
+
+	if setting.Environment != nil {
+		if err := validateEnvironment(*setting.Environment); err != nil {
+			return nil, err
+		}
+	}
+
+	if setting.Headers != nil {
+		if err := validateHeaders(*setting.Headers); err != nil {
+			return nil, err
+		}
+	}
+
+	if setting.QueryParams != nil {
+		if err := validateQueryParams(*setting.QueryParams); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateSpendLimit(setting.SpendLimit); err != nil {
+		return nil, err
+	}
+
+	if fields := setting.Transport.Validate("transport"); len(fields) != 0 {
+		return nil, internal_errors.NewInvalidFieldsError(fields)
+	}
+
+	if fields := setting.TokenBudget.Validate("tokenBudget"); len(fields) != 0 {
+		return nil, internal_errors.NewInvalidFieldsError(fields)
+	}
+
 	setting.UpdatedAt = time.Now().Unix()
 
 	err := m.Cache.Delete(id)
@@ -209,9 +470,94 @@ func (m *ProviderSettingsManager) UpdateSetting(id string, setting *provider.Upd
 		}
 
 		setting.Setting = params
+
+		if setting.KeyPool != nil {
+			if err := m.encryptKeyPool(setting.UpdatedAt, setting.KeyPool); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	updated, err := m.Storage.UpdateProviderSetting(id, setting)
+	if err != nil {
+		return nil, err
+	}
+
+	m.recordVersion(id)
+
+	return updated, nil
+}
+
+// SettingDependents returns the keys that still use the provider setting.
+func (m *ProviderSettingsManager) SettingDependents(id string) ([]*dryrun.Dependent, error) {
+	keyIds, err := m.Storage.GetKeyIdsBySettingId(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return dryrun.AddDependents(nil, "key", keyIds), nil
+}
+
+func (m *ProviderSettingsManager) checkSettingDeletion(id string, cascade bool) ([]*dryrun.Dependent, error) {
+	if len(id) == 0 {
+		return nil, internal_errors.NewValidationError("id cannot be empty")
+	}
+
+	dependents, err := m.SettingDependents(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if keyIds := dryrun.IdsOf(dependents, "key"); !cascade && len(keyIds) != 0 {
+		return nil, internal_errors.NewConflictError(fmt.Sprintf("provider setting is still referenced by keys: %s", strings.Join(keyIds, ",")))
+	}
+
+	return dependents, nil
+}
+
+func (m *ProviderSettingsManager) PreviewDeleteSetting(id string, cascade bool) (*dryrun.Result, error) {
+	if len(id) == 0 {
+		return nil, internal_errors.NewValidationError("id cannot be empty")
+	}
+
+	if _, err := m.Storage.GetProviderSetting(id, false); err != nil {
+		return nil, err
+	}
+
+	dependents, err := m.SettingDependents(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return dryrun.NewDeleteResult("provider_setting", []string{id}, dependents, cascade), nil
+}
+
+// DeleteSetting deletes a provider setting. Keys using the setting make the
+// deletion fail with a conflict unless cascade is set, in which case they are
+// deleted first. The deleted dependents are returned.
+func (m *ProviderSettingsManager) DeleteSetting(id string, cascade bool) ([]*dryrun.Dependent, error) {
+	dependents, err := m.checkSettingDeletion(id, cascade)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes, err := m.Storage.DeleteProviderSettingWithKeys(id, dryrun.IdsOf(dependents, "key"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hash := range hashes {
+		if err := m.Keys.Delete(hash); err != nil {
+			telemetry.Incr("bricksllm.provider_settings_manager.delete_setting.delete_key_cache_error", nil, 1)
+		}
+	}
+
+	err = m.Cache.Delete(id)
+	if err != nil {
+		telemetry.Incr("bricksllm.provider_settings_manager.delete_setting.delete_cache_error", nil, 1)
 	}
 
-	return m.Storage.UpdateProviderSetting(id, setting)
+	return dependents, nil
 }
 
 func (m *ProviderSettingsManager) GetSettingViaCache(id string) (*provider.Setting, error) {
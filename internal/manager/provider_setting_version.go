@@ -0,0 +1,172 @@
+package manager
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+// recordVersion snapshots a provider setting after it has been written. The
+// write has already succeeded by then, so failing to record it is only
+// counted.
+func (m *ProviderSettingsManager) recordVersion(id string) {
+	setting, err := m.Storage.GetProviderSetting(id, true)
+	if err != nil {
+		telemetry.Incr("bricksllm.provider_settings_manager.record_version.get_provider_setting_error", nil, 1)
+		return
+	}
+
+	if _, err := m.Storage.InsertProviderSettingVersion(setting, time.Now().Unix()); err != nil {
+		telemetry.Incr("bricksllm.provider_settings_manager.record_version.insert_provider_setting_version_error", nil, 1)
+	}
+}
+
+// GetSettingVersions returns the version history of a provider setting, newest
+// first, without the secrets of the versions.
+func (m *ProviderSettingsManager) GetSettingVersions(id string) ([]*provider.SettingVersion, error) {
+	if _, err := m.Storage.GetProviderSetting(id, false); err != nil {
+		return nil, err
+	}
+
+	versions, err := m.Storage.GetProviderSettingVersions(id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range versions {
+		delete(v.Setting.Setting, "apikey")
+		delete(v.Setting.Setting, "awsSecretAccessKey")
+		v.Setting.KeyPool.RemoveSecrets()
+	}
+
+	return versions, nil
+}
+
+// RollbackSetting restores a provider setting, secrets included, to how it was
+// at version. The restored setting is recorded as a new version.
+func (m *ProviderSettingsManager) RollbackSetting(id string, version int) (*provider.Setting, error) {
+	existing, err := m.Storage.GetProviderSetting(id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := m.Storage.GetProviderSettingVersion(id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := v.Setting
+	if snapshot.Setting == nil {
+		snapshot.Setting = map[string]string{}
+	}
+
+	// secrets of the version are encrypted under the update time it had, and
+	// are encrypted again under the new one
+	if m.Encryptor.Enabled() {
+		headers := map[string]string{"X-UPDATED-AT": strconv.FormatInt(snapshot.UpdatedAt, 10)}
+		for _, param := range []string{"apikey", "awsSecretAccessKey"} {
+			if len(snapshot.Setting[param]) == 0 {
+				continue
+			}
+
+			decrypted, err := m.Encryptor.Decrypt(snapshot.Setting[param], headers)
+			if err != nil {
+				return nil, err
+			}
+
+			snapshot.Setting[param] = decrypted
+		}
+
+		if snapshot.KeyPool != nil {
+			for _, k := range snapshot.KeyPool.Keys {
+				decrypted, err := m.Encryptor.Decrypt(k.Key, headers)
+				if err != nil {
+					return nil, err
+				}
+
+				k.Key = decrypted
+			}
+		}
+	}
+
+	restored := &provider.UpdateSetting{
+		UpdatedAt:      time.Now().Unix(),
+		Setting:        snapshot.Setting,
+		Name:           &snapshot.Name,
+		AllowedModels:  &snapshot.AllowedModels,
+		CostMap:        snapshot.CostMap,
+		Deployments:    &snapshot.Deployments,
+		KeyPool:        snapshot.KeyPool,
+		Labels:         &snapshot.Labels,
+		Environment:    &snapshot.Environment,
+		Headers:        &snapshot.Headers,
+		QueryParams:    &snapshot.QueryParams,
+		SpendLimit:     snapshot.SpendLimit,
+		Transport:      snapshot.Transport,
+		TokenBudget:    snapshot.TokenBudget,
+		Disabled:       &snapshot.Disabled,
+		DisabledReason: &snapshot.DisabledReason,
+	}
+
+	// fields that are left out of an update are kept, so the ones the version
+	// did not have are cleared explicitly
+	if snapshot.AllowedModels == nil {
+		restored.AllowedModels = &[]string{}
+	}
+
+	for _, field := range []*map[string]string{restored.Labels, restored.Headers, restored.QueryParams} {
+		if *field == nil {
+			*field = map[string]string{}
+		}
+	}
+
+	if restored.CostMap == nil {
+		restored.CostMap = &provider.CostMap{}
+	}
+
+	if restored.KeyPool == nil && existing.KeyPool != nil {
+		restored.KeyPool = &provider.KeyPool{Keys: []*provider.PooledKey{}}
+	}
+
+	if restored.SpendLimit == nil {
+		restored.SpendLimit = &provider.SpendLimit{}
+	}
+
+	if restored.Transport == nil {
+		restored.Transport = &provider.TransportConfig{}
+	}
+
+	if restored.TokenBudget == nil {
+		restored.TokenBudget = &provider.TokenBudget{}
+	}
+
+	if m.Encryptor.Enabled() {
+		params, err := m.EncryptParams(restored.UpdatedAt, existing.Provider, restored.Setting)
+		if err != nil {
+			return nil, err
+		}
+
+		restored.Setting = params
+
+		if restored.KeyPool != nil {
+			if err := m.encryptKeyPool(restored.UpdatedAt, restored.KeyPool); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	updated, err := m.Storage.UpdateProviderSetting(id, restored)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.Cache.Delete(id); err != nil {
+		telemetry.Incr("bricksllm.provider_settings_manager.rollback_setting.delete_cache_error", nil, 1)
+	}
+
+	m.recordVersion(id)
+
+	return updated, nil
+}
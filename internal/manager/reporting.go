@@ -1,11 +1,17 @@
 package manager
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/batch"
+	"github.com/bricks-cloud/bricksllm/internal/currency"
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
 	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/finetune"
 	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
 )
 
 type costStorage interface {
@@ -14,6 +20,7 @@ type costStorage interface {
 
 type keyStorage interface {
 	GetKey(keyId string) (*key.ResponseKey, error)
+	GetKeyCounts(keyIds []string) (*event.KeyCounts, error)
 }
 
 type eventStorage interface {
@@ -25,23 +32,123 @@ type eventStorage interface {
 	GetUserIds(keyId string) ([]string, error)
 	GetCustomIds(keyId string) ([]string, error)
 	GetTopKeyDataPoints(start, end int64, tags, keyIds []string, order string, limit, offset int, name string, revoked *bool) ([]*event.KeyDataPoint, error)
+	GetTotalCost(start, end int64, keyIds []string) (float64, error)
+	GetProviderHealthDataPoints(start, end int64, keyIds []string) ([]*event.ProviderHealth, error)
+	GetRecentErrorEvents(limit int, keyIds []string) ([]*event.Event, error)
+	GetSigningDataPoints(start, end int64, keyIds, identities []string) ([]*event.SigningDataPoint, error)
+	GetPolicyViolationDataPoints(req *event.PolicyViolationReportingRequest) ([]*event.PolicyViolationDataPoint, error)
+	GetPolicyVersionDataPoints(req *event.PolicyVersionReportingRequest) ([]*event.PolicyVersionDataPoint, error)
+	GetBatches(keyIds []string, status string) ([]*batch.Batch, error)
+	GetFineTuningJobs(keyIds []string, status string) ([]*finetune.Job, error)
+}
+
+type settingGetter interface {
+	GetSettingViaCache(id string) (*provider.Setting, error)
 }
 
 type ReportingManager struct {
-	es eventStorage
-	cs costStorage
-	ks keyStorage
+	es       eventStorage
+	cs       costStorage
+	ks       keyStorage
+	sg       settingGetter
+	fx       CurrencyConverter
+	currency string
 }
 
-func NewReportingManager(cs costStorage, ks keyStorage, es eventStorage) *ReportingManager {
+// NewReportingManager creates a reporting manager that reports costs in the
+// given currency unless a request asks for another one.
+func NewReportingManager(cs costStorage, ks keyStorage, es eventStorage, sg settingGetter, fx CurrencyConverter, reportingCurrency string) *ReportingManager {
 	return &ReportingManager{
-		cs: cs,
-		ks: ks,
-		es: es,
+		cs:       cs,
+		ks:       ks,
+		es:       es,
+		sg:       sg,
+		fx:       fx,
+		currency: currency.Normalize(reportingCurrency),
 	}
 }
 
+// settingNames looks up the names of provider settings, so that reports show
+// something people recognize next to setting ids. Settings that have been
+// deleted since are left out.
+func (rm *ReportingManager) settingNames(ids []string) map[string]string {
+	names := map[string]string{}
+	if rm.sg == nil {
+		return names
+	}
+
+	for _, id := range ids {
+		if _, ok := names[id]; ok || len(id) == 0 {
+			continue
+		}
+
+		setting, err := rm.sg.GetSettingViaCache(id)
+		if err != nil || setting == nil {
+			continue
+		}
+
+		names[id] = setting.Name
+	}
+
+	return names
+}
+
+func (rm *ReportingManager) nameEventSettings(events []*event.Event) {
+	ids := []string{}
+	for _, e := range events {
+		ids = append(ids, e.ProviderSettingId)
+	}
+
+	names := rm.settingNames(ids)
+	for _, e := range events {
+		e.ProviderSettingName = names[e.ProviderSettingId]
+	}
+}
+
+// reportingCurrency resolves the currency of a reporting request. An empty
+// string is returned for USD, in which case costs are only reported in USD.
+// The configured currency falls back to USD while its rate is unavailable, a
+// requested one without a rate is rejected.
+func (rm *ReportingManager) reportingCurrency(requested string) (string, error) {
+	if len(requested) == 0 {
+		if rm.currency == currency.USD || rm.fx == nil || !rm.fx.Supported(rm.currency) {
+			return "", nil
+		}
+
+		return rm.currency, nil
+	}
+
+	if !currency.Valid(requested) {
+		return "", internal_errors.NewValidationError(fmt.Sprintf("reporting currency %s is not a valid ISO 4217 code", requested))
+	}
+
+	code := currency.Normalize(requested)
+	if code == currency.USD {
+		return "", nil
+	}
+
+	if rm.fx == nil || !rm.fx.Supported(code) {
+		return "", internal_errors.NewValidationError(fmt.Sprintf("no fx rate is configured for currency %s", code))
+	}
+
+	return code, nil
+}
+
+func (rm *ReportingManager) convert(usd float64, code string) float64 {
+	converted, err := rm.fx.FromUsd(usd, code)
+	if err != nil {
+		return 0
+	}
+
+	return converted
+}
+
 func (rm *ReportingManager) GetEventReporting(e *event.ReportingRequest) (*event.ReportingResponse, error) {
+	code, err := rm.reportingCurrency(e.Currency)
+	if err != nil {
+		return nil, err
+	}
+
 	dataPoints, err := rm.es.GetEventDataPoints(e.Start, e.End, e.Increment, e.Tags, e.KeyIds, e.CustomIds, e.UserIds, e.Filters)
 	if err != nil {
 		return nil, err
@@ -56,21 +163,50 @@ func (rm *ReportingManager) GetEventReporting(e *event.ReportingRequest) (*event
 		return nil, internal_errors.NewNotFoundError("latency percentiles are not found")
 	}
 
+	if len(code) != 0 {
+		for _, dp := range dataPoints {
+			dp.Cost = rm.convert(dp.CostInUsd, code)
+		}
+	}
+
+	ids := []string{}
+	for _, dp := range dataPoints {
+		ids = append(ids, dp.ProviderSettingId)
+	}
+
+	names := rm.settingNames(ids)
+	for _, dp := range dataPoints {
+		dp.ProviderSettingName = names[dp.ProviderSettingId]
+	}
+
 	return &event.ReportingResponse{
 		DataPoints:        dataPoints,
 		LatencyInMsMedian: percentiles[0],
 		LatencyInMs99th:   percentiles[1],
+		Currency:          code,
 	}, nil
 }
 
 func (rm *ReportingManager) GetAggregatedEventByDayReporting(e *event.ReportingRequest) (*event.ReportingResponseV2, error) {
+	code, err := rm.reportingCurrency(e.Currency)
+	if err != nil {
+		return nil, err
+	}
+
 	dataPoints, err := rm.es.GetAggregatedEventByDayDataPoints(e.Start, e.End, e.KeyIds)
 	if err != nil {
 		return nil, err
 	}
 
+	if len(code) != 0 {
+		for _, dp := range dataPoints {
+			dp.Cost = rm.convert(dp.CostInUsd, code)
+		}
+	}
+
 	return &event.ReportingResponseV2{
 		DataPoints: dataPoints,
+		Currency:   code,
 	}, nil
 }
 
@@ -95,13 +231,25 @@ func (rm *ReportingManager) GetTopKeyReporting(r *event.KeyReportingRequest) (*e
 		return nil, internal_errors.NewValidationError("key reporting request order can only be desc or asc")
 	}
 
+	code, err := rm.reportingCurrency(r.Currency)
+	if err != nil {
+		return nil, err
+	}
+
 	dataPoints, err := rm.es.GetTopKeyDataPoints(r.Start, r.End, r.Tags, r.KeyIds, r.Order, r.Limit, r.Offset, r.Name, r.Revoked)
 	if err != nil {
 		return nil, err
 	}
 
+	if len(code) != 0 {
+		for _, dp := range dataPoints {
+			dp.Cost = rm.convert(dp.CostInUsd, code)
+		}
+	}
+
 	return &event.KeyReportingResponse{
 		DataPoints: dataPoints,
+		Currency:   code,
 	}, nil
 }
 
@@ -113,6 +261,18 @@ func (rm *ReportingManager) GetUserIds(keyId string) ([]string, error) {
 	return rm.es.GetUserIds(keyId)
 }
 
+// GetBatches returns the batches created by keys along with the usage billed
+// to them once they finished.
+func (rm *ReportingManager) GetBatches(keyIds []string, status string) ([]*batch.Batch, error) {
+	return rm.es.GetBatches(keyIds, status)
+}
+
+// GetFineTuningJobs returns the fine-tuning jobs created by keys with their
+// status and the training cost billed once they stopped.
+func (rm *ReportingManager) GetFineTuningJobs(keyIds []string, status string) ([]*finetune.Job, error) {
+	return rm.es.GetFineTuningJobs(keyIds, status)
+}
+
 func (rm *ReportingManager) GetKeyReporting(keyId string) (*key.KeyReporting, error) {
 	k, err := rm.ks.GetKey(keyId)
 	if err != nil {
@@ -140,6 +300,9 @@ func (rm *ReportingManager) GetEvents(userId, customId string, keyIds []string,
 		return nil, err
 	}
 
+	event.UpgradeAll(events)
+	rm.nameEventSettings(events)
+
 	return events, nil
 }
 
@@ -153,5 +316,149 @@ func (rm *ReportingManager) GetEventsV2(req *event.EventRequest) (*event.EventRe
 		return nil, err
 	}
 
+	event.UpgradeAll(resp.Events)
+	rm.nameEventSettings(resp.Events)
+	resp.SchemaVersion = event.SchemaVersion
+
 	return resp, nil
 }
+
+func (rm *ReportingManager) GetSigningReporting(req *event.SigningReportingRequest) (*event.SigningReportingResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	dataPoints, err := rm.es.GetSigningDataPoints(req.Start, req.End, req.KeyIds, req.Identities)
+	if err != nil {
+		return nil, err
+	}
+
+	failures, err := rm.es.GetEventsV2(&event.EventRequest{
+		Start:             req.Start,
+		End:               req.End,
+		KeyIds:            req.KeyIds,
+		SigningIdentities: req.Identities,
+		SigningFailed:     true,
+		DateOrder:         "desc",
+		Limit:             10,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rm.nameEventSettings(failures.Events)
+
+	return &event.SigningReportingResponse{
+		DataPoints:     dataPoints,
+		RecentFailures: failures.Events,
+	}, nil
+}
+
+func (rm *ReportingManager) GetPolicyViolationReporting(req *event.PolicyViolationReportingRequest) (*event.PolicyViolationReportingResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	dataPoints, err := rm.es.GetPolicyViolationDataPoints(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &event.PolicyViolationReportingResponse{
+		DataPoints: dataPoints,
+	}, nil
+}
+
+func (rm *ReportingManager) GetPolicyVersionReporting(req *event.PolicyVersionReportingRequest) (*event.PolicyVersionReportingResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	dataPoints, err := rm.es.GetPolicyVersionDataPoints(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &event.PolicyVersionReportingResponse{
+		DataPoints: dataPoints,
+	}, nil
+}
+
+func getProviderStatus(errorRate float64) string {
+	if errorRate >= 0.5 {
+		return "down"
+	}
+
+	if errorRate >= 0.1 {
+		return "degraded"
+	}
+
+	return "healthy"
+}
+
+// GetSummary returns the overview of the gateway, limited to the events and
+// keys of keyIds unless they are empty.
+func (rm *ReportingManager) GetSummary(keyIds []string) (*event.Summary, error) {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := now.Unix() + 1
+
+	counts, err := rm.ks.GetKeyCounts(keyIds)
+	if err != nil {
+		return nil, err
+	}
+
+	spendToday, err := rm.es.GetTotalCost(today.Unix(), end, keyIds)
+	if err != nil {
+		return nil, err
+	}
+
+	spendThisMonth, err := rm.es.GetTotalCost(month.Unix(), end, keyIds)
+	if err != nil {
+		return nil, err
+	}
+
+	topKeys, err := rm.es.GetTopKeyDataPoints(month.Unix(), end, nil, keyIds, "DESC", 5, 0, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	providers, err := rm.es.GetProviderHealthDataPoints(now.Add(-time.Hour).Unix(), end, keyIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range providers {
+		if p.NumberOfRequests != 0 {
+			p.ErrorRate = float64(p.ErrorCount) / float64(p.NumberOfRequests)
+		}
+
+		p.Status = getProviderStatus(p.ErrorRate)
+	}
+
+	recentErrors, err := rm.es.GetRecentErrorEvents(10, keyIds)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &event.Summary{
+		Keys:                counts,
+		SpendTodayInUsd:     spendToday,
+		SpendThisMonthInUsd: spendThisMonth,
+		TopKeys:             topKeys,
+		Providers:           providers,
+		RecentErrors:        recentErrors,
+	}
+
+	if code, _ := rm.reportingCurrency(""); len(code) != 0 {
+		summary.Currency = code
+		summary.SpendToday = rm.convert(spendToday, code)
+		summary.SpendThisMonth = rm.convert(spendThisMonth, code)
+		for _, dp := range topKeys {
+			dp.Cost = rm.convert(dp.CostInUsd, code)
+		}
+	}
+
+	return summary, nil
+}
@@ -6,9 +6,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/dryrun"
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/language"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
 	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
 	"github.com/bricks-cloud/bricksllm/internal/util"
 )
 
@@ -18,10 +21,16 @@ type RoutesStorage interface {
 	GetRoutes() ([]*route.Route, error)
 	GetRouteByPath(path string) (*route.Route, error)
 	DeleteRoute(id string) error
+	UpdateRoute(r *route.Route, version int) (*route.Route, error)
+	InsertRouteVersion(r *route.Route, createdAt int64) error
+	GetRouteVersions(routeId string) ([]*route.RouteVersion, error)
+	GetRouteVersion(routeId string, version int) (*route.RouteVersion, error)
+	DeleteRouteVersions(routeId string) error
 }
 
 type RoutesMemStorage interface {
 	GetRoute(id string) *route.Route
+	GetRoutes() []*route.Route
 }
 
 type PsManager interface {
@@ -33,14 +42,18 @@ type RouteManager struct {
 	ks Storage
 	ms RoutesMemStorage
 	ps PsManager
+	mc ModelCatalog
+	bs *route.Breakers
 }
 
-func NewRouteManager(s RoutesStorage, ks Storage, ms RoutesMemStorage, psm PsManager) *RouteManager {
+func NewRouteManager(s RoutesStorage, ks Storage, ms RoutesMemStorage, psm PsManager, mc ModelCatalog, bs *route.Breakers) *RouteManager {
 	return &RouteManager{
 		s:  s,
 		ks: ks,
 		ms: ms,
 		ps: psm,
+		mc: mc,
+		bs: bs,
 	}
 }
 
@@ -48,12 +61,43 @@ func (m *RouteManager) GetRouteFromMemDb(path string) *route.Route {
 	return m.ms.GetRoute(path)
 }
 
+func (m *RouteManager) GetRoutesFromMemDb() []*route.Route {
+	return m.ms.GetRoutes()
+}
+
 func (m *RouteManager) GetRoute(id string) (*route.Route, error) {
 	return m.s.GetRoute(id)
 }
 
+// GetRouteStatus returns the state of the circuit breakers of the steps of a
+// route. Breakers are kept in memory, so the state is the one of this gateway.
+func (m *RouteManager) GetRouteStatus(id string) (*route.RouteStatus, error) {
+	r, err := m.s.GetRoute(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.bs.Status(r), nil
+}
+
 func (m *RouteManager) DeleteRoute(id string) error {
-	return m.s.DeleteRoute(id)
+	if err := m.s.DeleteRoute(id); err != nil {
+		return err
+	}
+
+	if err := m.s.DeleteRouteVersions(id); err != nil {
+		telemetry.Incr("bricksllm.route_manager.delete_route.delete_route_versions_error", nil, 1)
+	}
+
+	return nil
+}
+
+func (m *RouteManager) PreviewDeleteRoute(id string) (*dryrun.Result, error) {
+	if _, err := m.s.GetRoute(id); err != nil {
+		return nil, err
+	}
+
+	return dryrun.NewResult(dryrun.ActionDelete, "route", []string{id}), nil
 }
 
 func (m *RouteManager) GetRoutes() ([]*route.Route, error) {
@@ -64,6 +108,7 @@ func (m *RouteManager) CreateRoute(r *route.Route) (*route.Route, error) {
 	r.CreatedAt = time.Now().Unix()
 	r.UpdatedAt = time.Now().Unix()
 	r.Id = util.NewUuid()
+	r.Version = 1
 
 	if err := m.validateRoute(r); err != nil {
 		return nil, err
@@ -71,7 +116,14 @@ func (m *RouteManager) CreateRoute(r *route.Route) (*route.Route, error) {
 
 	addDefaultValues(r)
 
-	return m.s.CreateRoute(r)
+	created, err := m.s.CreateRoute(r)
+	if err != nil {
+		return nil, err
+	}
+
+	m.recordVersion(created)
+
+	return created, nil
 }
 
 func addDefaultValues(r *route.Route) {
@@ -79,7 +131,7 @@ func addDefaultValues(r *route.Route) {
 		r.CacheConfig.Ttl = "168h"
 	}
 
-	for _, step := range r.Steps {
+	for _, step := range r.AllSteps() {
 		if len(step.Timeout) == 0 {
 			step.Timeout = "5m"
 		}
@@ -96,9 +148,24 @@ func checkModelValidity(provider, model string) bool {
 		return contains(model, openaiSupportedModels)
 	}
 
+	if provider == "anthropic" {
+		return strings.HasPrefix(model, "claude")
+	}
+
+	if provider == "vllm" || provider == "self-hosted" {
+		return len(model) != 0
+	}
+
 	return false
 }
 
+// translatedProvider reports whether steps of provider are served through
+// a format other than the one of OpenAI. Those steps only take chat
+// completion requests.
+func translatedProvider(provider string) bool {
+	return provider != "openai" && provider != "azure"
+}
+
 var (
 	azureSupportedModels = []string{
 		"gpt-4o-2024-08-26",
@@ -247,6 +314,9 @@ var (
 	supportedProviders = []string{
 		"openai",
 		"azure",
+		"anthropic",
+		"vllm",
+		"self-hosted",
 	}
 )
 
@@ -260,6 +330,44 @@ func contains(target string, source []string) bool {
 	return false
 }
 
+type namedStep struct {
+	field string
+	step  *route.Step
+}
+
+// namedSteps pairs every step of a route with the field it is reported under
+// when invalid.
+func namedSteps(r *route.Route) []*namedStep {
+	named := []*namedStep{}
+	for index, step := range r.Steps {
+		named = append(named, &namedStep{field: fmt.Sprintf("steps.[%d]", index), step: step})
+	}
+
+	if r.Split != nil {
+		for index, step := range r.Split.Steps {
+			named = append(named, &namedStep{field: fmt.Sprintf("split.steps.[%d]", index), step: step})
+		}
+	}
+
+	if r.Shadow != nil && r.Shadow.Step != nil {
+		named = append(named, &namedStep{field: "shadow.step", step: r.Shadow.Step})
+	}
+
+	return named
+}
+
+// hasStepModel returns whether a step of the route, or of its split, sends
+// requests to model.
+func hasStepModel(r *route.Route, model string) bool {
+	for _, ns := range namedSteps(r) {
+		if ns.field != "shadow.step" && ns.step.Model == model {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (m *RouteManager) validateRoute(r *route.Route) error {
 	fields := []string{}
 
@@ -283,145 +391,177 @@ func (m *RouteManager) validateRoute(r *route.Route) error {
 		fields = append(fields, "retryStrategy")
 	}
 
+	if len(r.Strategy) != 0 && r.Strategy != route.StrategyFallback && r.Strategy != route.StrategyLatency && r.Strategy != route.StrategyCheapestCapable {
+		fields = append(fields, "strategy")
+	}
+
+	if r.StrategyConfig != nil && (r.StrategyConfig.ErrorRateThreshold < 0 || r.StrategyConfig.ErrorRateThreshold > 1) {
+		fields = append(fields, "strategyConfig.errorRateThreshold")
+	}
+
+	if r.StrategyConfig != nil && len(r.StrategyConfig.PremiumModel) != 0 && !hasStepModel(r, r.StrategyConfig.PremiumModel) {
+		fields = append(fields, "strategyConfig.premiumModel")
+	}
+
 	containAda := false
 
-	for index, step := range r.Steps {
+	for _, ns := range namedSteps(r) {
+		step := ns.step
 		if len(step.Provider) == 0 {
-			fields = append(fields, fmt.Sprintf("steps.[%d].provider", index))
+			fields = append(fields, fmt.Sprintf("%s.provider", ns.field))
+		}
+
+		for _, code := range step.Languages {
+			if !language.Valid(code) {
+				fields = append(fields, fmt.Sprintf("%s.languages", ns.field))
+				break
+			}
 		}
 
 		if len(step.RetryInterval) != 0 {
 			_, err := time.ParseDuration(step.RetryInterval)
 			if err != nil {
-				fields = append(fields, fmt.Sprintf("steps.[%d].retryInterval", index))
+				fields = append(fields, fmt.Sprintf("%s.retryInterval", ns.field))
 			}
 
 			if !strings.HasSuffix(step.RetryInterval, "s") && !strings.HasSuffix(step.RetryInterval, "ms") {
-				fields = append(fields, fmt.Sprintf("steps.[%d].retryInterval", index))
+				fields = append(fields, fmt.Sprintf("%s.retryInterval", ns.field))
 			}
 		}
 
 		if !contains(step.Provider, supportedProviders) {
-			return fmt.Errorf("steps.[%d].provider is not supported. Only azure and openai are supported", index)
+			return fmt.Errorf("%s.provider is not supported. Only %s are supported", ns.field, strings.Join(supportedProviders, ", "))
 		}
 
 		if step.Provider == "azure" {
 			apiVersion := step.Params["apiVersion"]
 			if len(apiVersion) == 0 {
-				fields = append(fields, fmt.Sprintf("steps.[%d].params.apiVersion", index))
+				fields = append(fields, fmt.Sprintf("%s.params.apiVersion", ns.field))
 			}
 
 			deploymentId := step.Params["deploymentId"]
 			if len(deploymentId) == 0 {
-				fields = append(fields, fmt.Sprintf("steps.[%d].params.deploymentId", index))
+				fields = append(fields, fmt.Sprintf("%s.params.deploymentId", ns.field))
 			}
 		}
 
 		if len(step.Model) == 0 {
-			fields = append(fields, fmt.Sprintf("steps.[%d].model", index))
+			fields = append(fields, fmt.Sprintf("%s.model", ns.field))
+		}
+
+		if step.ContextWindow < 0 {
+			fields = append(fields, fmt.Sprintf("%s.contextWindow", ns.field))
 		}
 
 		if val, ok := step.RequestParams["frequency_penalty"]; ok {
 			if _, ok := val.(float64); !ok {
-				fields = append(fields, fmt.Sprintf("steps.[%d].requestParams.frequency_penalty", index))
+				fields = append(fields, fmt.Sprintf("%s.requestParams.frequency_penalty", ns.field))
 			}
 		}
 
 		if val, ok := step.RequestParams["max_tokens"]; ok {
 			if _, ok := val.(float64); !ok {
-				fields = append(fields, fmt.Sprintf("steps.[%d].requestParams.max_tokens", index))
+				fields = append(fields, fmt.Sprintf("%s.requestParams.max_tokens", ns.field))
 			}
 		}
 
 		if val, ok := step.RequestParams["temperature"]; ok {
 			if _, ok := val.(float64); !ok {
-				fields = append(fields, fmt.Sprintf("steps.[%d].requestParams.temperature", index))
+				fields = append(fields, fmt.Sprintf("%s.requestParams.temperature", ns.field))
 			}
 		}
 
 		if val, ok := step.RequestParams["top_p"]; ok {
 			if _, ok := val.(float64); !ok {
-				fields = append(fields, fmt.Sprintf("steps.[%d].requestParams.top_p", index))
+				fields = append(fields, fmt.Sprintf("%s.requestParams.top_p", ns.field))
 			}
 		}
 
 		if val, ok := step.RequestParams["n"]; ok {
 			if _, ok := val.(float64); !ok {
-				fields = append(fields, fmt.Sprintf("steps.[%d].requestParams.n", index))
+				fields = append(fields, fmt.Sprintf("%s.requestParams.n", ns.field))
 			}
 		}
 
 		if val, ok := step.RequestParams["stop"]; ok {
 			parsed, ok := val.([]any)
 			if !ok {
-				fields = append(fields, fmt.Sprintf("steps.[%d].requestParams.stop", index))
+				fields = append(fields, fmt.Sprintf("%s.requestParams.stop", ns.field))
 			}
 
 			if ok {
 				converted := route.ConvertToArrayOfStrings(parsed)
 				if len(converted) == 0 {
-					fields = append(fields, fmt.Sprintf("steps.[%d].requestParams.stop", index))
+					fields = append(fields, fmt.Sprintf("%s.requestParams.stop", ns.field))
 				}
 			}
 		}
 
 		if val, ok := step.RequestParams["presence_penalty"]; ok {
 			if _, ok := val.(float64); !ok {
-				fields = append(fields, fmt.Sprintf("steps.[%d].requestParams.presence_penalty", index))
+				fields = append(fields, fmt.Sprintf("%s.requestParams.presence_penalty", ns.field))
 			}
 		}
 
 		if val, ok := step.RequestParams["seed"]; ok {
 			if _, ok := val.(float64); !ok {
-				fields = append(fields, fmt.Sprintf("steps.[%d].requestParams.seed", index))
+				fields = append(fields, fmt.Sprintf("%s.requestParams.seed", ns.field))
 			}
 		}
 
 		if val, ok := step.RequestParams["logit_bias"]; ok {
 			parsed, ok := val.(map[string]any)
 			if !ok {
-				fields = append(fields, fmt.Sprintf("steps.[%d].requestParams.logit_bias", index))
+				fields = append(fields, fmt.Sprintf("%s.requestParams.logit_bias", ns.field))
 			}
 
 			if ok {
 				converted := route.ConvertToMapOfIntegers(parsed)
 				if len(converted) == 0 {
-					fields = append(fields, fmt.Sprintf("steps.[%d].requestParams.logit_bias", index))
+					fields = append(fields, fmt.Sprintf("%s.requestParams.logit_bias", ns.field))
 				}
 			}
 		}
 
 		if val, ok := step.RequestParams["logprobs"]; ok {
 			if _, ok := val.(bool); !ok {
-				fields = append(fields, fmt.Sprintf("steps.[%d].requestParams.logprobs", index))
+				fields = append(fields, fmt.Sprintf("%s.requestParams.logprobs", ns.field))
 			}
 		}
 
 		if val, ok := step.RequestParams["top_logprobs"]; ok {
 			if _, ok := val.(float64); !ok {
-				fields = append(fields, fmt.Sprintf("steps.[%d].requestParams.top_logprobs", index))
+				fields = append(fields, fmt.Sprintf("%s.requestParams.top_logprobs", ns.field))
 			}
 		}
 
-		if !contains(step.Model, supportedModels) {
-			return fmt.Errorf("steps.[%d].model is not supported. Only chat completion and embeddings model are supported", index)
+		if !translatedProvider(step.Provider) && !contains(step.Model, supportedModels) {
+			return fmt.Errorf("%s.model is not supported. Only chat completion and embeddings model are supported", ns.field)
 		}
 
 		if !checkModelValidity(step.Provider, step.Model) {
 			return fmt.Errorf("model: %s is not supported for provider: %s", step.Model, step.Provider)
 		}
 
+		if step.Provider != "azure" && m.mc != nil && m.mc.IsUnavailable(step.Provider, step.Model) {
+			return internal_errors.NewValidationError(fmt.Sprintf("model: %s is no longer listed by provider: %s", step.Model, step.Provider)).WithFields(&internal_errors.FieldError{
+				Field:  fmt.Sprintf("%s.model", ns.field),
+				Reason: "not listed upstream by " + step.Provider,
+				Value:  step.Model,
+			})
+		}
+
 		if !containAda && contains(step.Model, adaModels) {
 			containAda = true
 		}
 	}
 
-	for _, step := range r.Steps {
+	for _, step := range r.AllSteps() {
 		if containAda && !contains(step.Model, adaModels) {
 			return errors.New("steps must have congruent models. Chat completion and embedding models cannot be in the same route config")
 		}
 
-		if !containAda && !contains(step.Model, chatCompletionModels) {
+		if !containAda && !translatedProvider(step.Provider) && !contains(step.Model, chatCompletionModels) {
 			return errors.New("steps must have congruent models. Chat completion and embedding models cannot be in the same route config")
 		}
 	}
@@ -443,6 +583,69 @@ func (m *RouteManager) validateRoute(r *route.Route) error {
 		}
 	}
 
+	if r.CacheConfig != nil {
+		fields = append(fields, r.CacheConfig.Validate()...)
+	}
+
+	if r.CircuitBreaker != nil {
+		fields = append(fields, r.CircuitBreaker.Validate()...)
+	}
+
+	if r.RetryPolicy != nil {
+		fields = append(fields, r.RetryPolicy.Validate("retryPolicy")...)
+	}
+
+	if r.Split != nil {
+		fields = append(fields, r.Split.Validate()...)
+	}
+
+	if r.Shadow != nil {
+		fields = append(fields, r.Shadow.Validate()...)
+	}
+
+	fields = append(fields, r.Transport.Validate("transport")...)
+
+	if r.Sticky != nil {
+		fields = append(fields, r.Sticky.Validate()...)
+	}
+
+	if r.Hedge != nil {
+		fields = append(fields, r.Hedge.Validate()...)
+	}
+
+	if r.Queue != nil {
+		fields = append(fields, r.Queue.Validate()...)
+	}
+
+	if r.RateLimit != nil {
+		fields = append(fields, r.RateLimit.Validate()...)
+	}
+
+	if r.Region != nil {
+		fields = append(fields, r.Region.Validate()...)
+	}
+
+	if r.Transform != nil {
+		fields = append(fields, r.Transform.Validate()...)
+	}
+
+	for _, v := range r.PolicyVersions {
+		if v <= 0 {
+			fields = append(fields, "policyVersions")
+			break
+		}
+	}
+
+	if sc := r.SnippetConfig; sc != nil && sc.Enabled {
+		if sc.SampleRate <= 0 || sc.SampleRate > 1 {
+			fields = append(fields, "snippetConfig.sampleRate")
+		}
+
+		if sc.HeadChars < 0 || sc.TailChars < 0 || sc.HeadChars+sc.TailChars == 0 {
+			fields = append(fields, "snippetConfig.headChars")
+		}
+	}
+
 	found, err := m.ks.GetKeys(nil, r.KeyIds, "")
 	if err != nil {
 		return err
@@ -460,6 +663,16 @@ func (m *RouteManager) validateRoute(r *route.Route) error {
 		}
 	}
 
+	if len(r.PolicyId) != 0 {
+		if _, err := m.ks.GetPolicyById(r.PolicyId); err != nil {
+			if _, ok := err.(notFoundError); !ok {
+				return err
+			}
+
+			fields = append(fields, "policyId")
+		}
+	}
+
 	_, err = m.s.GetRouteByPath(r.Path)
 	if err == nil {
 		return internal_errors.NewValidationError("path is not unique")
@@ -474,7 +687,7 @@ func (m *RouteManager) validateRoute(r *route.Route) error {
 	}
 
 	if len(fields) != 0 {
-		return internal_errors.NewValidationError(fmt.Sprintf("invalid fields in route: %s", strings.Join(fields, ",")))
+		return internal_errors.NewValidationError(fmt.Sprintf("invalid fields in route: %s", strings.Join(fields, ","))).WithFields(internal_errors.NewInvalidFieldsError(fields).Fields()...)
 	}
 
 	return nil
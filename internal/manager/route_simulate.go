@@ -0,0 +1,221 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/pii"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	goopenai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+)
+
+// noopScanner finds nothing, so that only the regular expression rules of a
+// policy are evaluated when testing a route.
+type noopScanner struct{}
+
+func (noopScanner) Scan(input []string) (*pii.Result, error) {
+	return &pii.Result{}, nil
+}
+
+// TestRoute sends a synthetic request through the policy of the key and the
+// target selection of a route, and builds the request the chosen target
+// would be sent. Nothing is sent upstream, cached or charged.
+func (m *RouteManager) TestRoute(id string, tr *route.TestRequest, log *zap.Logger) (*route.TestResult, error) {
+	if err := tr.Validate(); err != nil {
+		return nil, err
+	}
+
+	r, err := m.s.GetRoute(id)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	result := &route.TestResult{Timings: &route.TestTimings{}}
+	defer func() {
+		result.Timings.TotalInMicros = time.Since(start).Microseconds()
+	}()
+
+	settingIds := tr.SettingIds
+	body := []byte(tr.Request)
+	userId := tr.UserId
+
+	var kc *key.ResponseKey
+	if len(tr.KeyId) != 0 {
+		if !contains(tr.KeyId, r.KeyIds) {
+			return nil, internal_errors.NewValidationError(fmt.Sprintf("key %s cannot access route %s", tr.KeyId, id))
+		}
+
+		k, err := m.ks.GetKey(tr.KeyId)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(settingIds) == 0 {
+			settingIds = k.GetSettingIds()
+		}
+
+		kc = k
+
+		// the policy of the key takes precedence over the one of the route
+		policyId := k.PolicyId
+		if len(policyId) == 0 {
+			policyId = r.PolicyId
+		}
+
+		if len(policyId) != 0 {
+			policyStart := time.Now()
+			p, err := m.ks.GetPolicyById(policyId)
+			if err != nil {
+				return nil, err
+			}
+
+			result.Policy, body = testPolicy(p, r, body, log)
+			result.Timings.PolicyInMicros = time.Since(policyStart).Microseconds()
+
+			if result.Policy.Action == "blocked" {
+				result.RouteId = r.Id
+				result.Fallbacks = []*route.TestTarget{}
+				return result, nil
+			}
+		}
+	}
+
+	settings := map[string]*provider.Setting{}
+	if len(settingIds) != 0 {
+		found, err := m.ks.GetProviderSettings(true, settingIds)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range found {
+			settings[s.Id] = s
+		}
+	}
+
+	forwarded, err := http.NewRequest(http.MethodPost, r.Path, io.NopCloser(bytes.NewReader(body)))
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range tr.Headers {
+		forwarded.Header.Set(name, value)
+	}
+
+	language := ""
+	if result.Policy != nil {
+		language = result.Policy.Language
+	}
+
+	r.Simulate(&route.Request{
+		Settings:  settings,
+		Key:       kc,
+		Forwarded: forwarded,
+		UserId:    userId,
+		Breakers:  m.bs,
+		Language:  language,
+	}, body, result, log)
+
+	return result, nil
+}
+
+// testPolicy evaluates the limits, regular expression rules, prompt
+// injection heuristics and language actions of p against body, and returns the body as the route
+// would receive it. Prompt tokens are approximated for the limits.
+func testPolicy(p *policy.Policy, r *route.Route, body []byte, log *zap.Logger) (*route.TestPolicy, []byte) {
+	targets := []policy.Target{}
+	for _, step := range r.AllSteps() {
+		targets = append(targets, policy.Target{
+			Provider: step.Provider,
+			Model:    step.Model,
+		})
+	}
+
+	resolved := p.Resolve(targets)
+
+	tp := &route.TestPolicy{
+		PolicyId: p.Id,
+		Action:   "allowed",
+	}
+
+	if resolved.Config != nil && (len(resolved.Config.Rules) != 0 || len(resolved.Config.Denylist) != 0) {
+		tp.NotEvaluated = append(tp.NotEvaluated, "config")
+	}
+
+	if resolved.CustomConfig != nil && len(resolved.CustomConfig.CustomRules) != 0 {
+		tp.NotEvaluated = append(tp.NotEvaluated, "customConfig")
+	}
+
+	if resolved.ModerationConfig != nil && len(resolved.ModerationConfig.Provider) != 0 {
+		tp.NotEvaluated = append(tp.NotEvaluated, "moderationConfig")
+	}
+
+	if resolved.InjectionConfig != nil && resolved.InjectionConfig.Classifier {
+		tp.NotEvaluated = append(tp.NotEvaluated, "injectionConfig.classifier")
+	}
+
+	if resolved.TopicConfig != nil && len(resolved.TopicConfig.Topics) != 0 {
+		tp.NotEvaluated = append(tp.NotEvaluated, "topicConfig")
+	}
+
+	if resolved.GuardrailConfig != nil && len(resolved.GuardrailConfig.Url) != 0 {
+		tp.NotEvaluated = append(tp.NotEvaluated, "guardrailConfig")
+	}
+
+	local := &policy.Policy{
+		Id:              resolved.Id,
+		RegexConfig:     resolved.RegexConfig,
+		InjectionConfig: resolved.InjectionConfig,
+		LanguageConfig:  resolved.LanguageConfig,
+	}
+
+	var input any
+	if r.ShouldRunEmbeddings() {
+		input = &goopenai.EmbeddingRequest{}
+	} else {
+		input = &goopenai.ChatCompletionRequest{}
+	}
+
+	if err := json.Unmarshal(body, input); err != nil {
+		tp.Detail = "request cannot be inspected: " + err.Error()
+		return tp, body
+	}
+
+	if le := resolved.CheckLimits(input, 0); le != nil {
+		tp.Action = "blocked"
+		tp.Detail = le.Error()
+		return tp, body
+	}
+
+	signals, err := local.FilterWithSignals(http.Client{}, input, noopScanner{}, nil, nil, log)
+	tp.Language = signals.Language
+	if err == nil {
+		return tp, body
+	}
+
+	tp.Detail = err.Error()
+
+	switch err.(type) {
+	case *internal_errors.BlockedError:
+		tp.Action = "blocked"
+	case *internal_errors.WarningError:
+		tp.Action = "warned"
+	case *internal_errors.RedactError:
+		tp.Action = "redacted"
+	}
+
+	if data, err := json.Marshal(input); err == nil {
+		body = data
+	}
+
+	return tp, body
+}
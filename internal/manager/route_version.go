@@ -0,0 +1,100 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+// recordVersion snapshots a route after it has been written. Failing to do so
+// does not undo the write, so it is only counted.
+func (m *RouteManager) recordVersion(r *route.Route) {
+	if err := m.s.InsertRouteVersion(r, time.Now().Unix()); err != nil {
+		telemetry.Incr("bricksllm.route_manager.record_version.insert_route_version_error", nil, 1)
+	}
+}
+
+// UpdateRoute applies ur to a route. The update is rejected with a conflict
+// error if ur names a version other than the current one of the route.
+func (m *RouteManager) UpdateRoute(id string, ur *route.UpdateRoute) (*route.Route, error) {
+	existing, err := m.s.GetRoute(id)
+	if err != nil {
+		return nil, err
+	}
+
+	version := existing.Version
+	if ur.Version != nil {
+		version = *ur.Version
+	}
+
+	updated := *existing
+	ur.Apply(&updated)
+
+	return m.ReplaceRoute(id, &updated, version)
+}
+
+// ReplaceRoute overwrites the config of a route with the one of r, provided
+// that the route is still at version. The id, path and namespace of the route
+// are kept.
+func (m *RouteManager) ReplaceRoute(id string, r *route.Route, version int) (*route.Route, error) {
+	existing, err := m.s.GetRoute(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Id = existing.Id
+	r.Path = existing.Path
+	r.Namespace = existing.Namespace
+	r.CreatedAt = existing.CreatedAt
+
+	// the routes memdb only picks up routes updated after the copy it has
+	r.UpdatedAt = time.Now().Unix()
+	if r.UpdatedAt <= existing.UpdatedAt {
+		r.UpdatedAt = existing.UpdatedAt + 1
+	}
+
+	if err := m.validateRoute(r); err != nil {
+		return nil, err
+	}
+
+	addDefaultValues(r)
+
+	// routes created before versions were kept have no snapshot of the
+	// version being replaced
+	m.recordVersion(existing)
+
+	updated, err := m.s.UpdateRoute(r, version)
+	if err != nil {
+		return nil, err
+	}
+
+	m.recordVersion(updated)
+
+	return updated, nil
+}
+
+// GetRouteVersions returns the version history of a route, newest first.
+func (m *RouteManager) GetRouteVersions(id string) ([]*route.RouteVersion, error) {
+	if _, err := m.s.GetRoute(id); err != nil {
+		return nil, err
+	}
+
+	return m.s.GetRouteVersions(id)
+}
+
+// RollbackRoute restores the config a route had at version. The restored
+// config becomes a new version of the route.
+func (m *RouteManager) RollbackRoute(id string, version int) (*route.Route, error) {
+	existing, err := m.s.GetRoute(id)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := m.s.GetRouteVersion(id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.ReplaceRoute(id, v.Route, existing.Version)
+}
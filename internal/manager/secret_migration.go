@@ -0,0 +1,124 @@
+package manager
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/encryptor"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+// SecretMigration counts the secrets touched by MigrateSecrets.
+type SecretMigration struct {
+	Settings  int
+	Encrypted int
+	Rewrapped int
+	Skipped   int
+}
+
+type rewrapper interface {
+	Rewrap(input string) (string, error)
+}
+
+// MigrateSecrets envelope encrypts the secrets of every provider setting that
+// are still stored in plain text. Secrets encrypted by the legacy encryption
+// service are decrypted with it first when it is enabled. With rewrap, secrets
+// that are already envelope encrypted get their data keys wrapped with the
+// current kms key, which is how secrets are moved off a retired key.
+func (m *ProviderSettingsManager) MigrateSecrets(legacy Encryptor, rewrap bool) (*SecretMigration, error) {
+	settings, err := m.Storage.GetProviderSettings(true, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	migration := &SecretMigration{}
+	for _, setting := range settings {
+		headers := map[string]string{"X-UPDATED-AT": strconv.FormatInt(setting.UpdatedAt, 10)}
+		changed := false
+
+		migrate := func(secret string) (string, error) {
+			if len(secret) == 0 {
+				return secret, nil
+			}
+
+			if encryptor.IsEncrypted(secret) {
+				rw, ok := m.Encryptor.(rewrapper)
+				if !rewrap || !ok {
+					migration.Skipped++
+					return secret, nil
+				}
+
+				rewrapped, err := rw.Rewrap(secret)
+				if err != nil {
+					return "", err
+				}
+
+				migration.Rewrapped++
+				changed = true
+				return rewrapped, nil
+			}
+
+			plain := secret
+			if legacy != nil && legacy.Enabled() {
+				decrypted, err := legacy.Decrypt(secret, headers)
+				if err != nil {
+					return "", err
+				}
+
+				plain = decrypted
+			}
+
+			encrypted, err := m.Encryptor.Encrypt(plain, headers)
+			if err != nil {
+				return "", err
+			}
+
+			migration.Encrypted++
+			changed = true
+			return encrypted, nil
+		}
+
+		if encryptsApiKey(setting.Provider) {
+			migrated, err := migrate(setting.Setting["apikey"])
+			if err != nil {
+				return nil, err
+			}
+
+			if len(migrated) != 0 {
+				setting.Setting["apikey"] = migrated
+			}
+		}
+
+		if setting.KeyPool != nil {
+			for _, k := range setting.KeyPool.Keys {
+				migrated, err := migrate(k.Key)
+				if err != nil {
+					return nil, err
+				}
+
+				k.Key = migrated
+			}
+		}
+
+		if !changed {
+			continue
+		}
+
+		if _, err := m.Storage.UpdateProviderSetting(setting.Id, &provider.UpdateSetting{
+			UpdatedAt: time.Now().Unix(),
+			Setting:   setting.Setting,
+			KeyPool:   setting.KeyPool,
+		}); err != nil {
+			return nil, err
+		}
+
+		if err := m.Cache.Delete(setting.Id); err != nil {
+			telemetry.Incr("bricksllm.provider_settings_manager.migrate_secrets.delete_cache_error", nil, 1)
+		}
+
+		migration.Settings++
+	}
+
+	return migration, nil
+}
@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+var headerNameRegex = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// reservedHeaders are set by the proxy for every request, either from the
+// secrets of the setting or from the body, so settings cannot override them.
+var reservedHeaders = map[string]bool{
+	"Authorization":     true,
+	"Api-Key":           true,
+	"X-Api-Key":         true,
+	"Host":              true,
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+}
+
+// validateHeaders checks the headers that a provider setting adds to upstream
+// requests.
+func validateHeaders(headers map[string]string) error {
+	for k, v := range headers {
+		if !headerNameRegex.MatchString(k) {
+			return internal_errors.NewValidationError(fmt.Sprintf("header %s is not a valid header name", k)).WithFields(&internal_errors.FieldError{
+				Field:  "headers",
+				Reason: "invalid",
+				Value:  k,
+			})
+		}
+
+		if reservedHeaders[http.CanonicalHeaderKey(k)] {
+			return internal_errors.NewValidationError(fmt.Sprintf("header %s is set by the proxy and cannot be overridden", k)).WithFields(&internal_errors.FieldError{
+				Field:  "headers",
+				Reason: "reserved",
+				Value:  k,
+			})
+		}
+
+		if strings.ContainsAny(v, "\r\n") {
+			return internal_errors.NewValidationError(fmt.Sprintf("value of header %s cannot contain line breaks", k)).WithFields(&internal_errors.FieldError{
+				Field:  "headers." + k,
+				Reason: "invalid",
+			})
+		}
+	}
+
+	return nil
+}
+
+// validateQueryParams checks the query params that a provider setting adds to
+// upstream requests.
+func validateQueryParams(params map[string]string) error {
+	for k := range params {
+		if len(strings.TrimSpace(k)) == 0 {
+			return internal_errors.NewValidationError("query param names cannot be empty").WithFields(&internal_errors.FieldError{
+				Field:  "queryParams",
+				Reason: "invalid",
+				Value:  k,
+			})
+		}
+	}
+
+	return nil
+}
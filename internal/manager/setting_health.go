@@ -0,0 +1,46 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bricks-cloud/bricksllm/internal/catalog"
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+type SettingHealthMonitor interface {
+	Health(id string) *catalog.SettingHealth
+	Probe(ctx context.Context, setting *provider.Setting) *catalog.SettingHealth
+}
+
+// GetSettingHealth returns the health of the upstream of a provider setting
+// over the monitoring window. A setting that has not been probed yet, for
+// example right after it was created, is probed on the spot.
+func (m *ProviderSettingsManager) GetSettingHealth(ctx context.Context, id string) (*catalog.SettingHealth, error) {
+	if len(id) == 0 {
+		return nil, internal_errors.NewValidationError("id cannot be empty")
+	}
+
+	existing, err := m.Storage.GetProviderSetting(id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if !catalog.CanTest(existing.Provider) {
+		return nil, internal_errors.NewValidationError(fmt.Sprintf("health monitoring is not supported for provider %s", existing.Provider))
+	}
+
+	h := m.Monitor.Health(id)
+	if h.Samples != 0 {
+		return h, nil
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, connectionTestTimeout)
+	defer cancel()
+
+	telemetry.Incr("bricksllm.provider_settings_manager.get_setting_health.probed_on_demand", []string{"provider:" + existing.Provider}, 1)
+
+	return m.Monitor.Probe(ctxTimeout, existing), nil
+}
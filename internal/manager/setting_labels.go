@@ -0,0 +1,82 @@
+package manager
+
+import (
+	"fmt"
+	"regexp"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+)
+
+const maxLabelValueLength = 255
+
+var (
+	labelKeyRegex    = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_./-]{0,62}$`)
+	environmentRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+)
+
+// validateLabels checks the labels of a provider setting. Labels are matched
+// exactly when listing settings, so keys are kept to a small character set.
+func validateLabels(labels map[string]string) error {
+	for k, v := range labels {
+		if !labelKeyRegex.MatchString(k) {
+			return internal_errors.NewValidationError(fmt.Sprintf("label %s must start with a letter or digit and contain up to 63 letters, digits, underscores, dots, slashes or hyphens", k)).WithFields(&internal_errors.FieldError{
+				Field:  "labels",
+				Reason: "invalid",
+				Value:  k,
+			})
+		}
+
+		if len(v) > maxLabelValueLength {
+			return internal_errors.NewValidationError(fmt.Sprintf("value of label %s cannot be longer than %d characters", k, maxLabelValueLength)).WithFields(&internal_errors.FieldError{
+				Field:  "labels." + k,
+				Reason: "too long",
+			})
+		}
+	}
+
+	return nil
+}
+
+func validateEnvironment(env string) error {
+	if len(env) != 0 && !environmentRegex.MatchString(env) {
+		return internal_errors.NewValidationError(fmt.Sprintf("environment %s must start with a lowercase letter or digit and contain up to 63 lowercase letters, digits, underscores or hyphens", env)).WithFields(&internal_errors.FieldError{
+			Field:  "environment",
+			Reason: "invalid",
+			Value:  env,
+		})
+	}
+
+	return nil
+}
+
+// ListSettings returns the provider settings matching f. Settings are looked
+// up through the cache when ids are given and read from storage when only a
+// filter is. Nothing is listed when neither is given.
+func (m *ProviderSettingsManager) ListSettings(ids []string, f *provider.SettingFilter) ([]*provider.Setting, error) {
+	var settings []*provider.Setting
+	if len(ids) != 0 || f.Empty() {
+		cached, err := m.GetSettingsViaCache(ids)
+		if err != nil {
+			return nil, err
+		}
+
+		settings = cached
+	} else {
+		stored, err := m.Storage.GetProviderSettings(false, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		settings = stored
+	}
+
+	matched := []*provider.Setting{}
+	for _, s := range settings {
+		if f.Matches(s) {
+			matched = append(matched, s)
+		}
+	}
+
+	return matched, nil
+}
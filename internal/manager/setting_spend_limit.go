@@ -0,0 +1,48 @@
+package manager
+
+import (
+	"fmt"
+	"net/url"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+)
+
+// validateSpendLimit checks the spend limit of a provider setting. A limit of
+// zero is allowed on update, where it removes the spend limit.
+func validateSpendLimit(sl *provider.SpendLimit) error {
+	if sl == nil {
+		return nil
+	}
+
+	if sl.MonthlyCostLimitInUsd < 0 {
+		return internal_errors.NewValidationError("monthly cost limit of spend limit cannot be negative").WithFields(&internal_errors.FieldError{
+			Field:  "spendLimit.monthlyCostLimitInUsd",
+			Reason: "invalid",
+		})
+	}
+
+	for _, t := range sl.AlertThresholds {
+		if t <= 0 || t >= 1 {
+			value := fmt.Sprintf("%g", t)
+			return internal_errors.NewValidationError(fmt.Sprintf("alert threshold %s of spend limit must be between 0 and 1", value)).WithFields(&internal_errors.FieldError{
+				Field:  "spendLimit.alertThresholds",
+				Reason: "invalid",
+				Value:  value,
+			})
+		}
+	}
+
+	if len(sl.AlertUrl) != 0 {
+		parsed, err := url.Parse(sl.AlertUrl)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || len(parsed.Host) == 0 {
+			return internal_errors.NewValidationError("alert url of spend limit must be an absolute http or https url").WithFields(&internal_errors.FieldError{
+				Field:  "spendLimit.alertUrl",
+				Reason: "invalid",
+				Value:  sl.AlertUrl,
+			})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package manager
+
+import (
+	"fmt"
+	"net/url"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+// validateProviderUrl checks that the url of a self hosted or bricksllm
+// provider setting is an absolute http or https url. A missing url is
+// reported together with other missing params.
+func validateProviderUrl(providerName, raw string) error {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || len(parsed.Host) == 0 {
+		return internal_errors.NewValidationError(fmt.Sprintf("%s url must be an absolute http or https url", providerName)).WithFields(&internal_errors.FieldError{
+			Field:  "setting.url",
+			Reason: "invalid",
+			Value:  raw,
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package manager
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+// storedTokensSize bounds the tokens remembered as already in the vault, so
+// that values repeated across turns are not encrypted again.
+const storedTokensSize = 10000
+
+type storedTokens struct {
+	mu     sync.RWMutex
+	tokens map[string]bool
+}
+
+func (st *storedTokens) has(key string) bool {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	return st.tokens[key]
+}
+
+func (st *storedTokens) add(key string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if len(st.tokens) >= storedTokensSize {
+		st.tokens = map[string]bool{}
+	}
+
+	st.tokens[key] = true
+}
+
+// StoreTokens keeps the values of the tokens of a policy encrypted in the
+// token vault, so that tokens the model repeats after the request that
+// introduced them can still be put back. Values are only kept when secrets are
+// encrypted.
+func (m *PolicyManager) StoreTokens(policyId string, tokens map[string]string) error {
+	if m.encryptor == nil || !m.encryptor.Enabled() {
+		telemetry.Incr("bricksllm.policy_manager.store_tokens.encryption_disabled", nil, 1)
+		return nil
+	}
+
+	for token, value := range tokens {
+		if m.stored.has(policyId + token) {
+			continue
+		}
+
+		createdAt := time.Now().Unix()
+		encrypted, err := m.encryptor.Encrypt(value, map[string]string{"X-UPDATED-AT": strconv.FormatInt(createdAt, 10)})
+		if err != nil {
+			return err
+		}
+
+		if err := m.Storage.InsertPiiToken(policyId, token, encrypted, createdAt); err != nil {
+			return err
+		}
+
+		m.stored.add(policyId + token)
+	}
+
+	return nil
+}
+
+// GetTokenValues returns the values of the tokens of a policy that are in the
+// token vault.
+func (m *PolicyManager) GetTokenValues(policyId string, tokens []string) (map[string]string, error) {
+	values := map[string]string{}
+	if len(tokens) == 0 || m.encryptor == nil || !m.encryptor.Enabled() {
+		return values, nil
+	}
+
+	found, err := m.Storage.GetPiiTokens(policyId, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range found {
+		decrypted, err := m.encryptor.Decrypt(t.Value, map[string]string{"X-UPDATED-AT": strconv.FormatInt(t.CreatedAt, 10)})
+		if err != nil {
+			return nil, err
+		}
+
+		values[t.Token] = decrypted
+	}
+
+	return values, nil
+}
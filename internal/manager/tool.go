@@ -0,0 +1,95 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/dryrun"
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/tool"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+)
+
+const defaultToolExecutionsLimit = 100
+
+type ToolStorage interface {
+	CreateTool(t *tool.Tool) (*tool.Tool, error)
+	GetTool(id string) (*tool.Tool, error)
+	GetTools(withAuth bool) ([]*tool.Tool, error)
+	DeleteTool(id string) error
+	GetToolExecutions(toolId string, limit int) ([]*tool.Execution, error)
+}
+
+type ToolManager struct {
+	s ToolStorage
+}
+
+func NewToolManager(s ToolStorage) *ToolManager {
+	return &ToolManager{
+		s: s,
+	}
+}
+
+// CreateTool registers a tool under a name that is unique across the gateway,
+// since models refer to tools by name only.
+func (m *ToolManager) CreateTool(t *tool.Tool) (*tool.Tool, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	existing, err := m.s.GetTools(false)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range existing {
+		if e.Name == t.Name {
+			return nil, internal_errors.NewConflictError("tool name is already registered: " + t.Name)
+		}
+	}
+
+	now := time.Now().Unix()
+	t.Id = util.NewUuid()
+	t.CreatedAt = now
+	t.UpdatedAt = now
+
+	created, err := m.s.CreateTool(t)
+	if err != nil {
+		return nil, err
+	}
+
+	created.AuthValue = ""
+
+	return created, nil
+}
+
+func (m *ToolManager) GetTools() ([]*tool.Tool, error) {
+	return m.s.GetTools(false)
+}
+
+func (m *ToolManager) DeleteTool(id string) error {
+	return m.s.DeleteTool(id)
+}
+
+func (m *ToolManager) PreviewDeleteTool(id string) (*dryrun.Result, error) {
+	if _, err := m.s.GetTool(id); err != nil {
+		return nil, err
+	}
+
+	return dryrun.NewResult(dryrun.ActionDelete, "tool", []string{id}), nil
+}
+
+func (m *ToolManager) GetToolExecutions(id string, limit int) ([]*tool.Execution, error) {
+	if limit < 0 {
+		return nil, internal_errors.NewValidationError("limit cannot be negative")
+	}
+
+	if limit == 0 {
+		limit = defaultToolExecutionsLimit
+	}
+
+	if _, err := m.s.GetTool(id); err != nil {
+		return nil, err
+	}
+
+	return m.s.GetToolExecutions(id, limit)
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/dryrun"
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
 	"github.com/bricks-cloud/bricksllm/internal/user"
 	"github.com/bricks-cloud/bricksllm/internal/util"
@@ -15,6 +16,8 @@ type UserStorage interface {
 	CreateUser(u *user.User) (*user.User, error)
 	UpdateUser(id string, uu *user.UpdateUser) (*user.User, error)
 	UpdateUserViaTagsAndUserId(tags []string, uid string, uu *user.UpdateUser) (*user.User, error)
+	GetUser(id string) (*user.User, error)
+	DeleteUser(id string) error
 }
 
 type UserManager struct {
@@ -92,23 +95,113 @@ func (m *UserManager) UpdateUser(id string, uu *user.UpdateUser) (*user.User, er
 	return m.us.UpdateUser(id, uu)
 }
 
-func (m *UserManager) UpdateUserViaTagsAndUserId(tags []string, uid string, uu *user.UpdateUser) (*user.User, error) {
-	uu.UpdatedAt = time.Now().Unix()
-
+func (m *UserManager) checkUserUpdate(uu *user.UpdateUser) error {
 	if err := uu.Validate(); err != nil {
-		return nil, err
+		return err
 	}
 
 	if uu.KeyIds != nil && len(uu.KeyIds) != 0 {
 		existing, err := m.ks.GetKeys(nil, uu.KeyIds, "")
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		if len(existing) == 0 {
-			return nil, internal_errors.NewNotFoundError("keys are not found")
+			return internal_errors.NewNotFoundError("keys are not found")
 		}
 	}
 
+	return nil
+}
+
+func (m *UserManager) UpdateUserViaTagsAndUserId(tags []string, uid string, uu *user.UpdateUser) (*user.User, error) {
+	uu.UpdatedAt = time.Now().Unix()
+
+	if err := m.checkUserUpdate(uu); err != nil {
+		return nil, err
+	}
+
 	return m.us.UpdateUserViaTagsAndUserId(tags, uid, uu)
 }
+
+func (m *UserManager) PreviewUpdateUserViaTagsAndUserId(tags []string, uid string, uu *user.UpdateUser) (*dryrun.Result, error) {
+	if err := m.checkUserUpdate(uu); err != nil {
+		return nil, err
+	}
+
+	users, err := m.us.GetUsers(tags, nil, []string{uid}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, u := range users {
+		ids = append(ids, u.Id)
+	}
+
+	return dryrun.NewResult(dryrun.ActionUpdate, "user", ids), nil
+}
+
+// userDependents returns the keys of the user that still exist.
+func (m *UserManager) userDependents(u *user.User) ([]*dryrun.Dependent, error) {
+	if len(u.KeyIds) == 0 {
+		return nil, nil
+	}
+
+	keys, err := m.ks.GetKeys(nil, u.KeyIds, "")
+	if err != nil {
+		return nil, err
+	}
+
+	keyIds := []string{}
+	for _, k := range keys {
+		keyIds = append(keyIds, k.KeyId)
+	}
+
+	return dryrun.AddDependents(nil, "key", keyIds), nil
+}
+
+func (m *UserManager) PreviewDeleteUser(id string, cascade bool) (*dryrun.Result, error) {
+	u, err := m.us.GetUser(id)
+	if err != nil {
+		return nil, err
+	}
+
+	dependents, err := m.userDependents(u)
+	if err != nil {
+		return nil, err
+	}
+
+	return dryrun.NewDeleteResult("user", []string{id}, dependents, cascade), nil
+}
+
+// DeleteUser deletes a user. A user whose keys still exist can only be deleted
+// with cascade, which deletes the keys as well.
+func (m *UserManager) DeleteUser(id string, cascade bool) ([]*dryrun.Dependent, error) {
+	u, err := m.us.GetUser(id)
+	if err != nil {
+		return nil, err
+	}
+
+	dependents, err := m.userDependents(u)
+	if err != nil {
+		return nil, err
+	}
+
+	keyIds := dryrun.IdsOf(dependents, "key")
+	if !cascade && len(keyIds) != 0 {
+		return nil, internal_errors.NewConflictError(fmt.Sprintf("user still owns keys: %s", strings.Join(keyIds, ",")))
+	}
+
+	for _, kid := range keyIds {
+		if err := m.ks.DeleteKey(kid); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := m.us.DeleteUser(id); err != nil {
+		return nil, err
+	}
+
+	return dependents, nil
+}
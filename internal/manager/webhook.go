@@ -0,0 +1,63 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/dryrun"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/bricks-cloud/bricksllm/internal/webhook"
+)
+
+type WebhookStorage interface {
+	CreateWebhook(w *webhook.Webhook) (*webhook.Webhook, error)
+	GetWebhook(id string) (*webhook.Webhook, error)
+	GetWebhooks(org string, withSecret bool) ([]*webhook.Webhook, error)
+	DeleteWebhook(id string) error
+}
+
+type WebhookManager struct {
+	s WebhookStorage
+}
+
+func NewWebhookManager(s WebhookStorage) *WebhookManager {
+	return &WebhookManager{
+		s: s,
+	}
+}
+
+// CreateWebhook generates the signing secret and returns it once. Later reads
+// never expose it.
+func (m *WebhookManager) CreateWebhook(w *webhook.Webhook) (*webhook.Webhook, error) {
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	w.Id = util.NewUuid()
+	w.CreatedAt = now
+	w.UpdatedAt = now
+	w.Secret = secret
+
+	return m.s.CreateWebhook(w)
+}
+
+func (m *WebhookManager) GetWebhooks(org string) ([]*webhook.Webhook, error) {
+	return m.s.GetWebhooks(org, false)
+}
+
+func (m *WebhookManager) DeleteWebhook(id string) error {
+	return m.s.DeleteWebhook(id)
+}
+
+func (m *WebhookManager) PreviewDeleteWebhook(id string) (*dryrun.Result, error) {
+	if _, err := m.s.GetWebhook(id); err != nil {
+		return nil, err
+	}
+
+	return dryrun.NewResult(dryrun.ActionDelete, "webhook", []string{id}), nil
+}
@@ -17,6 +17,7 @@ type Consumer struct {
 type recorder interface {
 	RecordKeySpend(keyId string, micros int64, costLimitUnit key.TimeUnit) error
 	RecordUserSpend(userId string, micros int64, costLimitUnit key.TimeUnit) error
+	RecordProviderSettingSpend(settingId string, micros int64) (int64, error)
 	RecordEvent(e *event.Event) error
 }
 
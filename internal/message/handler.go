@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/currency"
 	"github.com/bricks-cloud/bricksllm/internal/event"
 	"github.com/bricks-cloud/bricksllm/internal/key"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
@@ -87,6 +88,15 @@ type userAccessCache interface {
 	Set(key string, timeUnit key.TimeUnit) error
 }
 
+type notifier interface {
+	Notify(e *event.Event)
+	AlertSpend(url string, a *provider.SpendAlert)
+}
+
+type currencyConverter interface {
+	Rate(code string) (float64, error)
+}
+
 type Handler struct {
 	recorder recorder
 	log      *zap.Logger
@@ -101,9 +111,11 @@ type Handler struct {
 	rlm      rateLimitManager
 	ac       accessCache
 	uac      userAccessCache
+	n        notifier
+	fx       currencyConverter
 }
 
-func NewHandler(r recorder, log *zap.Logger, ae anthropicEstimator, e estimator, vllme vllmEstimator, aze azureEstimator, v validator, uv userValidator, km keyManager, um userManager, rlm rateLimitManager, ac accessCache, uac accessCache) *Handler {
+func NewHandler(r recorder, log *zap.Logger, ae anthropicEstimator, e estimator, vllme vllmEstimator, aze azureEstimator, v validator, uv userValidator, km keyManager, um userManager, rlm rateLimitManager, ac accessCache, uac accessCache, n notifier, fx currencyConverter) *Handler {
 	return &Handler{
 		recorder: r,
 		log:      log,
@@ -118,9 +130,16 @@ func NewHandler(r recorder, log *zap.Logger, ae anthropicEstimator, e estimator,
 		rlm:      rlm,
 		ac:       ac,
 		uac:      uac,
+		n:        n,
+		fx:       fx,
 	}
 }
 
+// eventWriteTiming is the histogram of the last segment of a request, writing
+// its event. It cannot be stored on the event being written, so it is only
+// reported next to the segments the proxy reports.
+const eventWriteTiming = "bricksllm.proxy.timings.event_write"
+
 func (h *Handler) HandleEvent(m Message) error {
 	telemetry.Incr("bricksllm.message.handler.handle_event.requests", nil, 1)
 
@@ -141,6 +160,7 @@ func (h *Handler) HandleEvent(m Message) error {
 	}
 
 	telemetry.Timing("bricksllm.message.handler.handle_event.record_event_latency", time.Since(start), nil, 1)
+	telemetry.Timing(eventWriteTiming, time.Since(start), nil, 1)
 	telemetry.Incr("bricksllm.message.handler.handle_event.success", nil, 1)
 
 	return nil
@@ -328,6 +348,8 @@ func (h *Handler) HandleEventWithRequestAndResponse(m Message) error {
 			h.log.Debug("error when decorating event", zap.Error(err))
 		}
 
+		h.convertCost(e)
+
 		var u *user.User
 
 		if e.Event.CostInUsd != 0 {
@@ -338,6 +360,8 @@ func (h *Handler) HandleEventWithRequestAndResponse(m Message) error {
 				h.log.Debug("error when recording key spend", zap.Error(err))
 			}
 
+			h.recordSettingSpend(e, micros)
+
 			if len(e.Event.UserId) != 0 {
 				us, err := h.um.GetUsers(e.Key.Tags, nil, []string{e.Event.UserId}, 0, 0)
 				if err != nil {
@@ -397,12 +421,78 @@ func (h *Handler) HandleEventWithRequestAndResponse(m Message) error {
 		return err
 	}
 
+	telemetry.Timing(eventWriteTiming, time.Since(start), nil, 1)
+
+	if h.n != nil {
+		h.n.Notify(e.Event)
+	}
+
 	telemetry.Timing("bricksllm.message.handler.handle_event_with_request_and_response.latency", time.Since(start), nil, 1)
 	telemetry.Incr("bricksllm.message.handler.handle_event_with_request_and_response.success", nil, 1)
 
 	return nil
 }
 
+// recordSettingSpend adds the cost of an event to the monthly spend of its
+// provider setting, and alerts on the thresholds of the spend limit that the
+// cost crossed. Since the spend is incremented atomically, every threshold is
+// alerted once a month even with several gateways.
+func (h *Handler) recordSettingSpend(e *event.EventWithRequestAndContent, micros int64) {
+	if len(e.Event.ProviderSettingId) == 0 {
+		return
+	}
+
+	total, err := h.recorder.RecordProviderSettingSpend(e.Event.ProviderSettingId, micros)
+	if err != nil {
+		telemetry.Incr("bricksllm.message.handler.record_setting_spend.record_provider_setting_spend_error", nil, 1)
+		h.log.Debug("error when recording provider setting spend", zap.Error(err))
+		return
+	}
+
+	sl := e.SpendLimit
+	if sl == nil || len(sl.AlertUrl) == 0 || h.n == nil {
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, threshold := range sl.Crossed(total-micros, total) {
+		telemetry.Incr("bricksllm.message.handler.record_setting_spend.alert", nil, 1)
+
+		h.n.AlertSpend(sl.AlertUrl, &provider.SpendAlert{
+			ProviderSettingId:     e.Event.ProviderSettingId,
+			Provider:              e.Event.Provider,
+			Month:                 now.Format("2006-01"),
+			Threshold:             threshold,
+			SpendInUsd:            float64(total) / 1000000,
+			MonthlyCostLimitInUsd: sl.MonthlyCostLimitInUsd,
+			LimitReached:          sl.Reached(total),
+			CreatedAt:             now.Unix(),
+		})
+	}
+}
+
+// convertCost turns a cost priced from a cost map in another currency into
+// USD, which spend is recorded and limited in, and keeps the priced amount and
+// the rate used on the event for reconciliation.
+func (h *Handler) convertCost(e *event.EventWithRequestAndContent) {
+	cm := e.CostMap
+	if cm == nil || h.fx == nil || currency.Normalize(cm.Currency) == currency.USD || !cm.Prices(e.Event.Model) {
+		return
+	}
+
+	rate, err := h.fx.Rate(cm.Currency)
+	if err != nil {
+		telemetry.Incr("bricksllm.message.handler.convert_cost.rate_error", nil, 1)
+		h.log.Debug("error when getting fx rate of cost map currency", zap.Error(err))
+		return
+	}
+
+	e.Event.Currency = currency.Normalize(cm.Currency)
+	e.Event.CostInCurrency = e.Event.CostInUsd
+	e.Event.FxRate = rate
+	e.Event.CostInUsd = e.Event.CostInUsd / rate
+}
+
 func (h *Handler) decorateEvent(m Message) error {
 	telemetry.Incr("bricksllm.message.handler.decorate_event.request", nil, 1)
 
@@ -509,7 +599,7 @@ func (h *Handler) decorateEvent(m Message) error {
 		}
 	}
 
-	if strings.HasPrefix(e.Event.Path, "/api/providers/azure/openai/deployments") && strings.HasSuffix(e.Event.Path, "/chat/completions") {
+	if strings.HasPrefix(e.Event.Path, "/api/providers/azure/openai/") && strings.HasSuffix(e.Event.Path, "/chat/completions") {
 		ccr, ok := e.Request.(*goopenai.ChatCompletionRequest)
 		if !ok {
 			telemetry.Incr("bricksllm.message.handler.decorate_event.event_request_parsing_error", nil, 1)
@@ -561,7 +651,7 @@ func (h *Handler) decorateEvent(m Message) error {
 		}
 	}
 
-	if strings.HasPrefix(e.Event.Path, "/api/providers/azure/openai/deployments") && strings.HasSuffix(e.Event.Path, "/completions") {
+	if strings.HasPrefix(e.Event.Path, "/api/providers/azure/openai/") && strings.HasSuffix(e.Event.Path, "/completions") {
 		cr, ok := e.Request.(*goopenai.CompletionRequest)
 		if !ok {
 			telemetry.Incr("bricksllm.message.handler.decorate_event.event_request_parsing_error", nil, 1)
@@ -766,14 +856,21 @@ func (h *Handler) decorateEvent(m Message) error {
 			return errors.New("event request data cannot be parsed as anthropic completion request")
 		}
 
-		tks, err := countTokensFromJson(body, e.RouteConfig.RequestPromptLocation)
-		if err != nil {
-			telemetry.Incr("bricksllm.message.handler.decorate_event.count_tokens_from_json_error", nil, 1)
+		// token counts reported by the upstream are already on the event
+		if len(e.RouteConfig.PromptTokensLocation) == 0 {
+			tks, err := countTokensFromJson(body, e.RouteConfig.RequestPromptLocation)
+			if err != nil {
+				telemetry.Incr("bricksllm.message.handler.decorate_event.count_tokens_from_json_error", nil, 1)
 
-			return err
+				return err
+			}
+
+			e.Event.PromptTokenCount = tks
 		}
 
-		e.Event.PromptTokenCount = tks
+		if len(e.RouteConfig.CompletionTokensLocation) != 0 {
+			return nil
+		}
 
 		result := gjson.Get(string(body), e.RouteConfig.StreamLocation)
 		if result.IsBool() {
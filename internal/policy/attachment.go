@@ -0,0 +1,50 @@
+package policy
+
+// Attachment is how a policy came to apply to the requests of a key.
+type Attachment string
+
+const (
+	KeyAttachment   Attachment = "key"
+	RouteAttachment Attachment = "route"
+	TagAttachment   Attachment = "tag"
+)
+
+// EffectivePolicy is the policy that applies to the requests of a key, if
+// any. A policy attached to the key takes precedence over one attached to the
+// route, and both over policies sharing a tag with the key.
+type EffectivePolicy struct {
+	KeyId      string     `json:"keyId"`
+	RouteId    string     `json:"routeId,omitempty"`
+	AttachedTo Attachment `json:"attachedTo,omitempty"`
+	Policy     *Policy    `json:"policy"`
+}
+
+// SharesTag reports whether p is in namespace and has one of tags.
+func (p *Policy) SharesTag(namespace string, tags []string) bool {
+	if p == nil || p.Namespace != namespace {
+		return false
+	}
+
+	for _, tag := range tags {
+		for _, pt := range p.Tags {
+			if tag == pt {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Earliest returns the policy created first, so that the policy matched by
+// tag does not change as policies are added. Ties are broken by id.
+func Earliest(ps []*Policy) *Policy {
+	var earliest *Policy
+	for _, p := range ps {
+		if earliest == nil || p.CreatedAt < earliest.CreatedAt || (p.CreatedAt == earliest.CreatedAt && p.Id < earliest.Id) {
+			earliest = p
+		}
+	}
+
+	return earliest
+}
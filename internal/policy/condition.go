@@ -0,0 +1,179 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Condition adds rules to a policy when a request targets a matching provider or model.
+// Models are matched by prefix so that a model family such as "gpt-4" covers "gpt-4o".
+type Condition struct {
+	Providers    []string      `json:"providers"`
+	Models       []string      `json:"models"`
+	Config       *Config       `json:"config"`
+	RegexConfig  *RegexConfig  `json:"regexConfig"`
+	CustomConfig *CustomConfig `json:"customConfig"`
+}
+
+type Target struct {
+	Provider string
+	Model    string
+}
+
+func (c *Condition) Matches(t Target) bool {
+	if len(c.Providers) != 0 && !containsString(c.Providers, t.Provider) {
+		return false
+	}
+
+	if len(c.Models) != 0 {
+		for _, m := range c.Models {
+			if strings.HasPrefix(t.Model, m) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+func validateConditions(conditions []*Condition) []string {
+	msgs := []string{}
+
+	for idx, c := range conditions {
+		if c == nil {
+			msgs = append(msgs, fmt.Sprintf("condition at index [%d] cannot be nil", idx))
+			continue
+		}
+
+		if len(c.Providers) == 0 && len(c.Models) == 0 {
+			msgs = append(msgs, fmt.Sprintf("condition at index [%d] must specify providers or models", idx))
+		}
+
+		if c.RegexConfig != nil {
+			for ridx, rule := range c.RegexConfig.RegularExpressionRules {
+				if rule == nil {
+					msgs = append(msgs, fmt.Sprintf("regex rule at index [%d] of condition at index [%d] cannot be nil", ridx, idx))
+					continue
+				}
+
+				for _, reason := range rule.invalidReasons() {
+					msgs = append(msgs, fmt.Sprintf("regex rule at index [%d] of condition at index [%d] %s", ridx, idx, reason))
+				}
+			}
+		}
+
+		for _, msg := range c.Config.validate() {
+			msgs = append(msgs, fmt.Sprintf("%s of condition at index [%d]", msg, idx))
+		}
+	}
+
+	return msgs
+}
+
+var actionStrictness = map[Action]int{
+	Allow:          0,
+	AllowButRedact: 1,
+	Tokenize:       1,
+	AllowButWarn:   2,
+	Block:          3,
+}
+
+// Resolve returns the policy that applies to the given targets. Rules from every condition
+// matching any of the targets are added to the base rules, with the stricter action winning
+// when both define the same rule. The policy itself is returned when no condition matches.
+func (p *Policy) Resolve(targets []Target) *Policy {
+	if p == nil || len(p.Conditions) == 0 {
+		return p
+	}
+
+	matched := []*Condition{}
+	for _, c := range p.Conditions {
+		if c == nil {
+			continue
+		}
+
+		for _, t := range targets {
+			if c.Matches(t) {
+				matched = append(matched, c)
+				break
+			}
+		}
+	}
+
+	if len(matched) == 0 {
+		return p
+	}
+
+	resolved := &Policy{
+		Id:               p.Id,
+		Name:             p.Name,
+		CreatedAt:        p.CreatedAt,
+		UpdatedAt:        p.UpdatedAt,
+		Tags:             p.Tags,
+		Config:           &Config{Rules: map[Rule]Action{}},
+		RegexConfig:      &RegexConfig{},
+		CustomConfig:     &CustomConfig{},
+		ResponseConfig:   p.ResponseConfig,
+		ModerationConfig: p.ModerationConfig,
+		InjectionConfig:  p.InjectionConfig,
+		LimitConfig:      p.LimitConfig,
+		TopicConfig:      p.TopicConfig,
+		LanguageConfig:   p.LanguageConfig,
+		LatencyBudget:    p.LatencyBudget,
+		GuardrailConfig:  p.GuardrailConfig,
+		SchemaConfig:     p.SchemaConfig,
+		Version:          p.Version,
+	}
+
+	configs := []*Config{p.Config}
+	regexConfigs := []*RegexConfig{p.RegexConfig}
+	customConfigs := []*CustomConfig{p.CustomConfig}
+
+	for _, c := range matched {
+		configs = append(configs, c.Config)
+		regexConfigs = append(regexConfigs, c.RegexConfig)
+		customConfigs = append(customConfigs, c.CustomConfig)
+	}
+
+	for _, cfg := range configs {
+		if cfg == nil {
+			continue
+		}
+
+		for rule, action := range cfg.Rules {
+			existing, ok := resolved.Config.Rules[rule]
+			if !ok || actionStrictness[action] > actionStrictness[existing] {
+				resolved.Config.Rules[rule] = action
+			}
+		}
+
+		resolved.Config.Allowlist = append(resolved.Config.Allowlist, cfg.Allowlist...)
+		resolved.Config.Denylist = append(resolved.Config.Denylist, cfg.Denylist...)
+	}
+
+	for _, cfg := range regexConfigs {
+		if cfg != nil {
+			resolved.RegexConfig.RegularExpressionRules = append(resolved.RegexConfig.RegularExpressionRules, cfg.RegularExpressionRules...)
+		}
+	}
+
+	for _, cfg := range customConfigs {
+		if cfg != nil {
+			resolved.CustomConfig.CustomRules = append(resolved.CustomConfig.CustomRules, cfg.CustomRules...)
+		}
+	}
+
+	return resolved
+}
+
+func containsString(arr []string, target string) bool {
+	for _, str := range arr {
+		if str == target {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,145 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/pii"
+)
+
+// DenylistEntry is a term that is always detected as an entity of Rule, such
+// as the name of a customer the detector does not recognize.
+type DenylistEntry struct {
+	Term string `json:"term"`
+	Rule Rule   `json:"rule"`
+}
+
+func (c *Config) validate() []string {
+	if c == nil {
+		return nil
+	}
+
+	msgs := []string{}
+	for idx, phrase := range c.Allowlist {
+		if len(strings.TrimSpace(phrase)) == 0 {
+			msgs = append(msgs, fmt.Sprintf("pii allowlist phrase at index [%d] cannot be empty", idx))
+		}
+	}
+
+	for idx, entry := range c.Denylist {
+		if entry == nil || len(strings.TrimSpace(entry.Term)) == 0 {
+			msgs = append(msgs, fmt.Sprintf("pii denylist entry at index [%d] must have a term", idx))
+			continue
+		}
+
+		if _, ok := entityMap[strings.ToUpper(string(entry.Rule))]; !ok || entry.Rule == All {
+			msgs = append(msgs, fmt.Sprintf("pii denylist entry at index [%d] has unknown rule %s", idx, entry.Rule))
+		}
+	}
+
+	return msgs
+}
+
+// rules returns the actions of c by rule. Denylisted terms are redacted unless
+// their rule has an action of its own.
+func (c *Config) rules() map[Rule]Action {
+	if c == nil {
+		return nil
+	}
+
+	if len(c.Denylist) == 0 {
+		return c.Rules
+	}
+
+	rules := map[Rule]Action{}
+	for rule, action := range c.Rules {
+		rules[rule] = action
+	}
+
+	for _, entry := range c.Denylist {
+		if entry == nil {
+			continue
+		}
+
+		if _, ok := rules[entry.Rule]; !ok {
+			rules[entry.Rule] = AllowButRedact
+		}
+	}
+
+	return rules
+}
+
+// amend applies the allowlist and the denylist of c to the entities detected
+// in input. Entities within an allowlisted phrase are dropped, and every
+// occurrence of a denylisted term is added. r is nil if the detector was not
+// called.
+func (c *Config) amend(input []string, r *pii.Result) *pii.Result {
+	if r == nil {
+		r = &pii.Result{}
+		for _, text := range input {
+			r.Detections = append(r.Detections, &pii.Detection{Input: text})
+		}
+	}
+
+	if c == nil || (len(c.Allowlist) == 0 && len(c.Denylist) == 0) {
+		return r
+	}
+
+	amended := &pii.Result{}
+	for _, detection := range r.Detections {
+		allowed := occurrences(detection.Input, c.Allowlist)
+
+		entities := []*pii.Entity{}
+		for _, entity := range detection.Entities {
+			if !within(allowed, entity.BeginOffset, entity.EndOffset) {
+				entities = append(entities, entity)
+			}
+		}
+
+		for _, entry := range c.Denylist {
+			if entry == nil {
+				continue
+			}
+
+			for _, loc := range occurrences(detection.Input, []string{entry.Term}) {
+				entities = append(entities, &pii.Entity{
+					BeginOffset: loc[0],
+					EndOffset:   loc[1],
+					Type:        strings.ToUpper(string(entry.Rule)),
+				})
+			}
+		}
+
+		amended.Detections = append(amended.Detections, &pii.Detection{
+			Input:    detection.Input,
+			Entities: entities,
+		})
+	}
+
+	return amended
+}
+
+// occurrences returns the locations of phrases in text, ignoring case.
+func occurrences(text string, phrases []string) [][]int {
+	locs := [][]int{}
+	for _, phrase := range phrases {
+		if len(strings.TrimSpace(phrase)) == 0 {
+			continue
+		}
+
+		locs = append(locs, regexp.MustCompile(`(?i)`+regexp.QuoteMeta(phrase)).FindAllStringIndex(text, -1)...)
+	}
+
+	return locs
+}
+
+func within(locs [][]int, begin, end int) bool {
+	for _, loc := range locs {
+		if begin >= loc[0] && end <= loc[1] {
+			return true
+		}
+	}
+
+	return false
+}
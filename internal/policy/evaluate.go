@@ -0,0 +1,224 @@
+package policy
+
+import (
+	"encoding/json"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/language"
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/bricks-cloud/bricksllm/internal/provider/vllm"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// EvaluateRequest is a sample request to evaluate a policy against. Provider
+// and model decide which conditional rules apply; the model defaults to the
+// one in the request body.
+type EvaluateRequest struct {
+	Provider string          `json:"provider"`
+	Model    string          `json:"model"`
+	Request  json.RawMessage `json:"request"`
+}
+
+func (er *EvaluateRequest) Validate() error {
+	if len(er.Request) == 0 || !gjson.ValidBytes(er.Request) || !gjson.ParseBytes(er.Request).IsObject() {
+		return internal_errors.NewValidationError("request must be a json object")
+	}
+
+	if _, err := er.input(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// input decodes the sample request into the type the proxy would filter for
+// the provider.
+func (er *EvaluateRequest) input() (any, error) {
+	body := gjson.ParseBytes(er.Request)
+
+	var input any
+	switch {
+	case body.Get("messages").Exists() && er.Provider == "anthropic":
+		input = &anthropic.MessagesRequest{}
+	case body.Get("messages").Exists():
+		input = &goopenai.ChatCompletionRequest{}
+	case body.Get("input").Exists():
+		input = &goopenai.EmbeddingRequest{}
+	case body.Get("prompt").Exists() && er.Provider == "anthropic":
+		input = &anthropic.CompletionRequest{}
+	case body.Get("prompt").Exists():
+		input = &vllm.CompletionRequest{}
+	default:
+		return nil, internal_errors.NewValidationError("request must contain messages, input or prompt")
+	}
+
+	if err := json.Unmarshal(er.Request, input); err != nil {
+		return nil, internal_errors.NewValidationError("request cannot be inspected: " + err.Error())
+	}
+
+	return input, nil
+}
+
+// Evaluation is what a policy would do to a request. Blocked, warned and
+// redacted list every rule that matched, including the ones the proxy does
+// not report once a request is blocked.
+type Evaluation struct {
+	PolicyId string          `json:"policyId"`
+	Action   string          `json:"action"`
+	Blocked  []string        `json:"blocked"`
+	Warned   []string        `json:"warned"`
+	Redacted []string        `json:"redacted"`
+	Detail   string          `json:"detail,omitempty"`
+	Request  json.RawMessage `json:"request,omitempty"`
+	// RiskScore is the prompt injection risk score of the request, when the
+	// policy screens for prompt injection
+	RiskScore float64 `json:"riskScore,omitempty"`
+	// Language is the detected language of the request, when the policy
+	// detects languages
+	Language string `json:"language,omitempty"`
+}
+
+// scans collects every scan of a request, so that an evaluation can report
+// what was detected even when filtering stops at the first blocked part.
+type scans []*ScanResult
+
+func (s *scans) add(sr *ScanResult, err error) (*ScanResult, error) {
+	if s != nil && sr != nil {
+		*s = append(*s, sr)
+	}
+
+	return sr, err
+}
+
+// riskScore is the highest prompt injection risk score of the scans.
+func (s scans) riskScore() float64 {
+	score := 0.0
+	for _, sr := range s {
+		score = max(score, sr.InjectionScore)
+	}
+
+	return score
+}
+
+// tokens merges the tokens of the scans.
+func (s scans) tokens() map[string]string {
+	var merged map[string]string
+	for _, sr := range s {
+		for token, value := range sr.Tokens {
+			if merged == nil {
+				merged = map[string]string{}
+			}
+
+			merged[token] = value
+		}
+	}
+
+	return merged
+}
+
+// language is the language detected in most of the scans.
+func (s scans) language() string {
+	counts := map[string]int{}
+	detected, highest := language.Undetermined, 0
+	for _, sr := range s {
+		if sr.Language == language.Undetermined {
+			continue
+		}
+
+		counts[sr.Language]++
+		if counts[sr.Language] > highest {
+			detected, highest = sr.Language, counts[sr.Language]
+		}
+	}
+
+	return detected
+}
+
+// Evaluate runs the request through the policy the way the proxy does and
+// reports the outcome. The request is returned as it would be forwarded,
+// and is left out when the request would be blocked. Errors that are not a
+// policy outcome only end up in the detail, since the proxy forwards the
+// request anyway.
+func (p *Policy) Evaluate(er *EvaluateRequest, scanner Scanner, cd CustomPolicyDetector, mod Moderator, log *zap.Logger) (*Evaluation, error) {
+	input, err := er.input()
+	if err != nil {
+		return nil, err
+	}
+
+	model := er.Model
+	if len(model) == 0 {
+		model = gjson.GetBytes(er.Request, "model").String()
+	}
+
+	resolved := p.Resolve([]Target{{Provider: er.Provider, Model: model}})
+
+	ev := &Evaluation{
+		PolicyId: p.Id,
+		Action:   "allowed",
+		Blocked:  []string{},
+		Warned:   []string{},
+		Redacted: []string{},
+	}
+
+	if le := resolved.CheckLimits(input, 0); le != nil {
+		ev.Action = "blocked"
+		ev.Blocked = append(ev.Blocked, le.Rule())
+		ev.Detail = le.Error()
+		return ev, nil
+	}
+
+	seen := scans{}
+	err = resolved.filter(input, scanner, cd, mod, log, &seen)
+	for _, sr := range seen {
+		ev.Blocked = appendUnique(ev.Blocked, sr.blockedRules()...)
+		ev.Warned = appendUnique(ev.Warned, sr.warnedRules()...)
+		ev.Redacted = appendUnique(ev.Redacted, sr.RedactedRules...)
+	}
+
+	ev.RiskScore = seen.riskScore()
+	ev.Language = seen.language()
+
+	if err != nil {
+		ev.Detail = err.Error()
+	}
+
+	switch err.(type) {
+	case *internal_errors.BlockedError:
+		ev.Action = "blocked"
+		return ev, nil
+	case *internal_errors.WarningError:
+		ev.Action = "warned"
+	case *internal_errors.RedactError:
+		ev.Action = "redacted"
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	ev.Request = data
+
+	return ev, nil
+}
+
+func appendUnique(strs []string, added ...string) []string {
+	for _, a := range added {
+		found := false
+		for _, s := range strs {
+			if s == a {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			strs = append(strs, a)
+		}
+	}
+
+	return strs
+}
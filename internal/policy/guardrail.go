@@ -0,0 +1,158 @@
+package policy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+// GuardrailRule is the rule reported for texts denied or transformed by a
+// guardrail.
+const GuardrailRule = "guardrail"
+
+const defaultGuardrailTimeout = 2 * time.Second
+
+// GuardrailStage tells a guardrail whether it is judging a request or the
+// response to it.
+type GuardrailStage string
+
+const (
+	RequestStage  GuardrailStage = "request"
+	ResponseStage GuardrailStage = "response"
+)
+
+type Verdict string
+
+const (
+	VerdictAllow     Verdict = "allow"
+	VerdictDeny      Verdict = "deny"
+	VerdictTransform Verdict = "transform"
+)
+
+// GuardrailRequest is what is posted to a guardrail.
+type GuardrailRequest struct {
+	PolicyId string         `json:"policyId"`
+	Stage    GuardrailStage `json:"stage"`
+	Input    []string       `json:"input"`
+}
+
+// GuardrailVerdict is what a guardrail responds with. Input replaces the texts
+// of a transform verdict, one for one.
+type GuardrailVerdict struct {
+	Verdict Verdict  `json:"verdict"`
+	Input   []string `json:"input,omitempty"`
+	Reason  string   `json:"reason,omitempty"`
+}
+
+// Guardrail asks an external guardrail service for its verdict on texts.
+type Guardrail interface {
+	Guard(cfg *GuardrailConfig, req *GuardrailRequest) (*GuardrailVerdict, error)
+}
+
+// GuardrailConfig plugs an external policy engine, such as an OPA server or an
+// in-house safety service, into the policy. Requests, and responses when
+// Responses is set, are posted to Url after the other detectors of the policy
+// ran, so the service sees them with PII already redacted. Its verdict is
+// obeyed: deny blocks, transform replaces the texts and allow lets them
+// through. When the service fails, times out or answers with anything else,
+// OnError decides between failing open with allow and failing closed with
+// block.
+type GuardrailConfig struct {
+	Url       string `json:"url"`
+	Timeout   string `json:"timeout"`
+	OnError   Action `json:"onError"`
+	Responses bool   `json:"responses"`
+}
+
+func (gc *GuardrailConfig) enabled() bool {
+	return gc != nil && len(gc.Url) != 0
+}
+
+func (gc *GuardrailConfig) validate() []string {
+	if gc == nil {
+		return nil
+	}
+
+	msgs := []string{}
+	if len(gc.Url) != 0 && !isHttpUrl(gc.Url) {
+		msgs = append(msgs, "guardrail url must be an http or https url")
+	}
+
+	if len(gc.Timeout) != 0 {
+		if d, err := time.ParseDuration(gc.Timeout); err != nil || d <= 0 {
+			msgs = append(msgs, "guardrail timeout must be a positive duration")
+		}
+	}
+
+	switch gc.OnError {
+	case "", Allow, Block:
+	default:
+		msgs = append(msgs, "guardrail on error must be one of allow or block")
+	}
+
+	return msgs
+}
+
+// RequestTimeout is how long the guardrail is waited for.
+func (gc *GuardrailConfig) RequestTimeout() time.Duration {
+	if d, err := time.ParseDuration(gc.Timeout); err == nil && d > 0 {
+		return d
+	}
+
+	return defaultGuardrailTimeout
+}
+
+// guard applies the verdict of the guardrail on the texts of sr.
+func (p *Policy) guard(sr *ScanResult, g Guardrail, log *zap.Logger) {
+	gc := p.GuardrailConfig
+
+	stage := RequestStage
+	if p.responses {
+		stage = ResponseStage
+	}
+
+	v, err := g.Guard(gc, &GuardrailRequest{
+		PolicyId: p.Id,
+		Stage:    stage,
+		Input:    sr.Updated,
+	})
+	if err == nil && v.Verdict == VerdictTransform && len(v.Input) != len(sr.Updated) {
+		err = fmt.Errorf("guardrail transformed %d texts into %d", len(sr.Updated), len(v.Input))
+	}
+
+	if err == nil && v.Verdict != VerdictAllow && v.Verdict != VerdictDeny && v.Verdict != VerdictTransform {
+		err = fmt.Errorf("guardrail responded with unknown verdict: %s", v.Verdict)
+	}
+
+	if err != nil {
+		telemetry.Incr("bricksllm.policy.guard.guard_error", []string{"stage:" + string(stage)}, 1)
+		log.Debug("error when asking a guardrail for its verdict", zap.Error(err))
+
+		if gc.OnError == Block {
+			sr.BlockedGuardrail = true
+			sr.Action = Block
+		}
+
+		return
+	}
+
+	telemetry.Incr("bricksllm.policy.guard.verdict", []string{"stage:" + string(stage), "verdict:" + string(v.Verdict)}, 1)
+
+	switch v.Verdict {
+	case VerdictDeny:
+		if len(v.Reason) != 0 {
+			log.Debug("guardrail denied", zap.String("reason", v.Reason))
+		}
+
+		sr.BlockedGuardrail = true
+		sr.Action = Block
+	case VerdictTransform:
+		sr.Updated = v.Input
+		sr.RedactedRules = append(sr.RedactedRules, GuardrailRule)
+		if sr.Action == Allow {
+			sr.Action = AllowButRedact
+		}
+	}
+}
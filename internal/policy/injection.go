@@ -0,0 +1,148 @@
+package policy
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// PromptInjection is the rule reported when a request is flagged as a prompt
+// injection or jailbreak attempt.
+const PromptInjection = "prompt_injection"
+
+// InjectionSensitivity is how readily a request is flagged. Higher
+// sensitivities flag requests at lower risk scores.
+type InjectionSensitivity string
+
+const (
+	LowSensitivity    InjectionSensitivity = "low"
+	MediumSensitivity InjectionSensitivity = "medium"
+	HighSensitivity   InjectionSensitivity = "high"
+)
+
+var injectionThresholds = map[InjectionSensitivity]float64{
+	LowSensitivity:    0.85,
+	MediumSensitivity: 0.6,
+	HighSensitivity:   0.4,
+}
+
+// classifierScore is the weight of the classifier agreeing that a request is
+// an injection attempt, combined with the heuristics like any other signal.
+const classifierScore = 0.9
+
+const injectionRequirement = "an attempt to make the AI assistant ignore, override or reveal its instructions or safety guidelines, such as a prompt injection or a jailbreak"
+
+type injectionHeuristic struct {
+	regex  *regexp.Regexp
+	weight float64
+}
+
+// injectionHeuristics are phrasings common to prompt injection and jailbreak
+// attempts. Each one that matches raises the risk score by its weight.
+var injectionHeuristics = []injectionHeuristic{
+	{regexp.MustCompile(`(?i)\b(ignore|disregard|skip|override)\b.{0,20}\b(all|any|the|your|previous|prior|above|earlier|preceding)\b.{0,20}\b(instructions?|prompts?|rules|directions|guidelines)\b`), 0.8},
+	{regexp.MustCompile(`(?i)\bforget\b.{0,20}\b(everything|all|your|previous|prior)\b.{0,20}\b(instructions?|rules|told|said)\b`), 0.6},
+	{regexp.MustCompile(`(?i)\b(reveal|print|show|repeat|output|leak|display)\b.{0,20}\b(system prompt|initial instructions|hidden instructions|instructions above|developer message)\b`), 0.7},
+	{regexp.MustCompile(`(?i)\byou are (now|no longer)\b.{0,30}\b(DAN|developer mode|jailbroken|unrestricted|unfiltered|uncensored|bound by)\b`), 0.7},
+	{regexp.MustCompile(`(?i)\b(do anything now|DAN mode|developer mode (enabled|on)|jailbreak(ed)?)\b`), 0.6},
+	{regexp.MustCompile(`(?i)\b(pretend|act|behave|roleplay)\b.{0,30}\b(without|no|free of)\b.{0,20}\b(restrictions|rules|filters|limitations|guidelines|censorship)\b`), 0.6},
+	{regexp.MustCompile(`(?i)\b(bypass|disable|turn off|circumvent)\b.{0,20}\b(safety|content|filters?|guidelines|restrictions|guardrails|moderation)\b`), 0.6},
+	{regexp.MustCompile(`(?i)(^|\n)\s*(new instructions|system|###\s*instructions?)\s*:`), 0.4},
+	{regexp.MustCompile(`(?i)<\|?(im_start|system|endoftext)\|?>|\[/?(system|INST)\]`), 0.5},
+}
+
+// InjectionConfig screens requests for prompt injection and jailbreak
+// attempts. Heuristics give every request a risk score between 0 and 1, and
+// the custom policy classifier can be asked as well. Requests scoring at or
+// above the threshold of the sensitivity are blocked or warned about; with
+// allow, they are only annotated with the score. Allowlisted phrases are left
+// out of screening. Without an action, nothing is screened.
+type InjectionConfig struct {
+	Action      Action               `json:"action"`
+	Sensitivity InjectionSensitivity `json:"sensitivity"`
+	Allowlist   []string             `json:"allowlist"`
+	Classifier  bool                 `json:"classifier"`
+}
+
+func (ic *InjectionConfig) enabled() bool {
+	return ic != nil && len(ic.Action) != 0
+}
+
+func (ic *InjectionConfig) validate() []string {
+	if !ic.enabled() {
+		return nil
+	}
+
+	msgs := []string{}
+
+	if ic.Action != Allow && ic.Action != AllowButWarn && ic.Action != Block {
+		msgs = append(msgs, "injection action must be one of allow, allow_but_warn or block")
+	}
+
+	if _, ok := injectionThresholds[ic.Sensitivity]; !ok && len(ic.Sensitivity) != 0 {
+		msgs = append(msgs, "injection sensitivity must be one of low, medium or high")
+	}
+
+	for idx, phrase := range ic.Allowlist {
+		if len(strings.TrimSpace(phrase)) == 0 {
+			msgs = append(msgs, fmt.Sprintf("injection allowlist phrase at index [%d] cannot be empty", idx))
+		}
+	}
+
+	return msgs
+}
+
+func (ic *InjectionConfig) threshold() float64 {
+	if threshold, ok := injectionThresholds[ic.Sensitivity]; ok {
+		return threshold
+	}
+
+	return injectionThresholds[MediumSensitivity]
+}
+
+// screened returns input without the allowlisted phrases.
+func (ic *InjectionConfig) screened(input []string) []string {
+	if len(ic.Allowlist) == 0 {
+		return input
+	}
+
+	screened := make([]string, len(input))
+	for i, text := range input {
+		for _, phrase := range ic.Allowlist {
+			if len(strings.TrimSpace(phrase)) == 0 {
+				continue
+			}
+
+			text = regexp.MustCompile(`(?i)`+regexp.QuoteMeta(phrase)).ReplaceAllString(text, " ")
+		}
+
+		screened[i] = text
+	}
+
+	return screened
+}
+
+// heuristicScore combines the weights of every heuristic matching input as
+// independent signals, so that the score only nears 1 as more of them match.
+func heuristicScore(input []string) float64 {
+	missed := 1.0
+	for _, h := range injectionHeuristics {
+		for _, text := range input {
+			if h.regex.MatchString(text) {
+				missed *= 1 - h.weight
+				break
+			}
+		}
+	}
+
+	return roundScore(1 - missed)
+}
+
+func withClassifier(score float64) float64 {
+	return roundScore(1 - (1-score)*(1-classifierScore))
+}
+
+func roundScore(score float64) float64 {
+	return math.Round(score*100) / 100
+}
@@ -0,0 +1,69 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/bricks-cloud/bricksllm/internal/language"
+)
+
+// LanguageConfig detects the language of requests and decides what happens
+// to them by language, such as blocking languages that moderation does not
+// cover. Languages are ISO 639-1 codes. The detected language is recorded on
+// events and picks the steps of routes serving it. Requests whose language
+// cannot be detected are allowed.
+type LanguageConfig struct {
+	Enabled       bool              `json:"enabled"`
+	Actions       map[string]Action `json:"actions"`
+	DefaultAction Action            `json:"defaultAction"`
+}
+
+func (lc *LanguageConfig) enabled() bool {
+	return lc != nil && lc.Enabled
+}
+
+func (lc *LanguageConfig) validate() []string {
+	if lc == nil {
+		return nil
+	}
+
+	msgs := []string{}
+	for code, action := range lc.Actions {
+		if !language.Valid(code) {
+			msgs = append(msgs, fmt.Sprintf("language %s must be a lowercase iso 639-1 code", code))
+		}
+
+		if action != Allow && action != AllowButWarn && action != Block {
+			msgs = append(msgs, fmt.Sprintf("language action of %s must be one of allow, allow_but_warn or block", code))
+		}
+	}
+
+	if len(lc.DefaultAction) != 0 && lc.DefaultAction != Allow && lc.DefaultAction != AllowButWarn && lc.DefaultAction != Block {
+		msgs = append(msgs, "language default action must be one of allow, allow_but_warn or block")
+	}
+
+	return msgs
+}
+
+// action returns what happens to a request in the given language. Languages
+// without an action get the default one, and are allowed without it.
+func (lc *LanguageConfig) action(code string) Action {
+	if code == language.Undetermined {
+		return Allow
+	}
+
+	if action, ok := lc.Actions[code]; ok {
+		return action
+	}
+
+	if len(lc.DefaultAction) != 0 {
+		return lc.DefaultAction
+	}
+
+	return Allow
+}
+
+// languageRule is the rule reported for a request blocked or warned about
+// because of its language.
+func languageRule(code string) string {
+	return "language:" + code
+}
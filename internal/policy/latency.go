@@ -0,0 +1,91 @@
+package policy
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+// LatencyBudgetExceeded is the rule reported for requests blocked or warned
+// about because the detectors of their policy ran out of time.
+const LatencyBudgetExceeded = "latency_budget_exceeded"
+
+// LatencyBudget bounds the time the detectors of a policy that call external
+// services, such as PII detection, custom rules, moderation, the injection
+// classifier and banned topics, can add to a request. Once it runs out, the
+// results of the detectors that finished are used and OnTimeout decides what
+// happens to the request: allow fails open, block fails closed and
+// allow_but_warn fails open while reporting the request. Local checks such as
+// regular expressions and languages always run.
+type LatencyBudget struct {
+	Timeout   string `json:"timeout"`
+	OnTimeout Action `json:"onTimeout"`
+}
+
+func (lb *LatencyBudget) enabled() bool {
+	return lb != nil && len(lb.Timeout) != 0
+}
+
+func (lb *LatencyBudget) validate() []string {
+	if lb == nil {
+		return nil
+	}
+
+	msgs := []string{}
+	if len(lb.Timeout) != 0 {
+		if d, err := time.ParseDuration(lb.Timeout); err != nil || d <= 0 {
+			msgs = append(msgs, "latency budget timeout must be a positive duration")
+		}
+	}
+
+	switch lb.OnTimeout {
+	case "", Allow, AllowButWarn, Block:
+	default:
+		msgs = append(msgs, "latency budget on timeout must be one of allow, allow_but_warn or block")
+	}
+
+	return msgs
+}
+
+func (lb *LatencyBudget) onTimeout() Action {
+	if len(lb.OnTimeout) == 0 {
+		return Allow
+	}
+
+	return lb.OnTimeout
+}
+
+// wait waits for the detectors of a scan for as long as the budget allows, and
+// reports whether they all finished.
+func (lb *LatencyBudget) wait(wg *sync.WaitGroup) bool {
+	if !lb.enabled() {
+		wg.Wait()
+		return true
+	}
+
+	timeout, err := time.ParseDuration(lb.Timeout)
+	if err != nil {
+		wg.Wait()
+		return true
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	telemetry.Incr("bricksllm.policy.latency_budget.wait.requests", nil, 1)
+
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		telemetry.Incr("bricksllm.policy.latency_budget.wait.exceeded", []string{"on_timeout:" + string(lb.onTimeout())}, 1)
+		return false
+	}
+}
@@ -0,0 +1,212 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/bricks-cloud/bricksllm/internal/provider/vllm"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// LimitConfig caps the size of the requests a policy applies to, so that a
+// runaway client cannot send huge prompts to an expensive model. Requests over
+// a limit are rejected before they are inspected. Zero leaves a limit unset.
+type LimitConfig struct {
+	MaxPromptTokens    int   `json:"maxPromptTokens"`
+	MaxTokens          int   `json:"maxTokens"`
+	MaxMessages        int   `json:"maxMessages"`
+	MaxAttachmentBytes int64 `json:"maxAttachmentBytes"`
+}
+
+func (lc *LimitConfig) enabled() bool {
+	return lc != nil && (lc.MaxPromptTokens > 0 || lc.MaxTokens > 0 || lc.MaxMessages > 0 || lc.MaxAttachmentBytes > 0)
+}
+
+func (lc *LimitConfig) validate() []string {
+	if lc == nil {
+		return nil
+	}
+
+	msgs := []string{}
+	if lc.MaxPromptTokens < 0 {
+		msgs = append(msgs, "limit maxPromptTokens cannot be negative")
+	}
+
+	if lc.MaxTokens < 0 {
+		msgs = append(msgs, "limit maxTokens cannot be negative")
+	}
+
+	if lc.MaxMessages < 0 {
+		msgs = append(msgs, "limit maxMessages cannot be negative")
+	}
+
+	if lc.MaxAttachmentBytes < 0 {
+		msgs = append(msgs, "limit maxAttachmentBytes cannot be negative")
+	}
+
+	return msgs
+}
+
+// LimitError is returned for a request over a limit of a policy. Limit is the
+// name of the limit in the policy.
+type LimitError struct {
+	Limit  string
+	Max    int64
+	Actual int64
+}
+
+func (le *LimitError) Error() string {
+	return fmt.Sprintf("%s of the request is %d, over the limit of %d", le.Limit, le.Actual, le.Max)
+}
+
+// Rule is the rule reported for the request in events.
+func (le *LimitError) Rule() string {
+	return "limit:" + le.Limit
+}
+
+// requestSize is what the limits of a policy are checked against.
+type requestSize struct {
+	texts       []string
+	messages    int
+	maxTokens   int
+	attachments []int64
+}
+
+// sizeOf measures the requests that limits apply to. Other requests are not
+// limited.
+func sizeOf(input any) (*requestSize, bool) {
+	switch r := input.(type) {
+	case *goopenai.ChatCompletionRequest:
+		return chatSizeOf(r), true
+	case *vllm.ChatRequest:
+		return chatSizeOf(&r.ChatCompletionRequest), true
+	case *vllm.CompletionRequest:
+		size := &requestSize{maxTokens: r.MaxTokens}
+		switch prompt := r.Prompt.(type) {
+		case string:
+			size.texts = []string{prompt}
+		case []string:
+			size.texts = prompt
+		}
+
+		return size, true
+	case *anthropic.MessagesRequest:
+		size := &requestSize{
+			messages:  len(r.Messages),
+			maxTokens: r.MaxTokens,
+		}
+
+		for _, m := range r.Messages {
+			size.texts = append(size.texts, m.Content)
+		}
+
+		return size, true
+	case *anthropic.CompletionRequest:
+		return &requestSize{
+			texts:     []string{r.Prompt},
+			maxTokens: r.MaxTokensToSample,
+		}, true
+	}
+
+	return nil, false
+}
+
+func chatSizeOf(r *goopenai.ChatCompletionRequest) *requestSize {
+	size := &requestSize{
+		messages:  len(r.Messages),
+		maxTokens: r.MaxCompletionTokens,
+	}
+
+	if size.maxTokens == 0 {
+		size.maxTokens = r.MaxTokens
+	}
+
+	for _, m := range r.Messages {
+		size.texts = append(size.texts, m.Content)
+
+		for _, part := range m.MultiContent {
+			size.texts = append(size.texts, part.Text)
+
+			if part.ImageURL != nil {
+				if n, ok := dataURLSize(part.ImageURL.URL); ok {
+					size.attachments = append(size.attachments, n)
+				}
+			}
+		}
+	}
+
+	return size
+}
+
+// dataURLSize returns the decoded size of a base64 data URL. Attachments sent
+// by URL are fetched by the provider and are not limited.
+func dataURLSize(url string) (int64, bool) {
+	if !strings.HasPrefix(url, "data:") {
+		return 0, false
+	}
+
+	idx := strings.Index(url, ";base64,")
+	if idx < 0 {
+		return 0, false
+	}
+
+	encoded := strings.TrimRight(url[idx+len(";base64,"):], "=")
+	return int64(len(encoded)) * 3 / 4, true
+}
+
+// approximateTokens counts four characters as a token, for requests whose
+// prompt tokens cannot be counted with the tokenizer of the model.
+func approximateTokens(texts []string) int {
+	chars := 0
+	for _, text := range texts {
+		chars += utf8.RuneCountInString(text)
+	}
+
+	return (chars + 3) / 4
+}
+
+// CheckLimits returns the first limit of p that the request is over, or nil.
+// promptTokens is the number of prompt tokens of the request, or 0 if it is
+// not known, in which case it is approximated from the text of the request.
+func (p *Policy) CheckLimits(input any, promptTokens int) *LimitError {
+	lc := p.LimitConfig
+	if !lc.enabled() {
+		return nil
+	}
+
+	size, ok := sizeOf(input)
+	if !ok {
+		return nil
+	}
+
+	if lc.MaxMessages > 0 && size.messages > lc.MaxMessages {
+		return &LimitError{Limit: "maxMessages", Max: int64(lc.MaxMessages), Actual: int64(size.messages)}
+	}
+
+	if lc.MaxTokens > 0 && size.maxTokens > lc.MaxTokens {
+		return &LimitError{Limit: "maxTokens", Max: int64(lc.MaxTokens), Actual: int64(size.maxTokens)}
+	}
+
+	if lc.MaxAttachmentBytes > 0 {
+		for _, n := range size.attachments {
+			if n > lc.MaxAttachmentBytes {
+				return &LimitError{Limit: "maxAttachmentBytes", Max: lc.MaxAttachmentBytes, Actual: n}
+			}
+		}
+	}
+
+	if lc.MaxPromptTokens > 0 {
+		if promptTokens == 0 {
+			promptTokens = approximateTokens(size.texts)
+		}
+
+		if promptTokens > lc.MaxPromptTokens {
+			return &LimitError{Limit: "maxPromptTokens", Max: int64(lc.MaxPromptTokens), Actual: int64(promptTokens)}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package policy
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+type ModerationProvider string
+
+const (
+	OpenAiModeration  ModerationProvider = "openai"
+	AzureModeration   ModerationProvider = "azure"
+	WebhookModeration ModerationProvider = "webhook"
+)
+
+// Severity buckets how strongly a flagged category scored.
+type Severity string
+
+const (
+	Low    Severity = "low"
+	Medium Severity = "medium"
+	High   Severity = "high"
+)
+
+const defaultModerationThreshold = 0.5
+
+// Moderator scores input against the categories of a moderation provider.
+// Scores are between 0 and 1, whatever scale the provider uses. It also
+// embeds input for banned topics and calls guardrails.
+type Moderator interface {
+	Moderate(cfg *ModerationConfig, input []string) (map[string]float64, error)
+	Embedder
+	Guardrail
+}
+
+// ModerationConfig sends requests to an external moderation endpoint. A
+// category is flagged once its score reaches its threshold, and what happens
+// to the request depends on the severity of the score. Without a provider,
+// nothing is sent.
+type ModerationConfig struct {
+	Provider         ModerationProvider  `json:"provider"`
+	Url              string              `json:"url"`
+	Model            string              `json:"model"`
+	Thresholds       map[string]float64  `json:"thresholds"`
+	DefaultThreshold float64             `json:"defaultThreshold"`
+	Actions          map[Severity]Action `json:"actions"`
+}
+
+func (mc *ModerationConfig) enabled() bool {
+	return mc != nil && len(mc.Provider) != 0
+}
+
+func (mc *ModerationConfig) validate() []string {
+	if !mc.enabled() {
+		return nil
+	}
+
+	msgs := []string{}
+
+	switch mc.Provider {
+	case OpenAiModeration:
+		if len(mc.Url) != 0 && !isHttpUrl(mc.Url) {
+			msgs = append(msgs, "moderation url must be an http or https url")
+		}
+	case AzureModeration, WebhookModeration:
+		if !isHttpUrl(mc.Url) {
+			msgs = append(msgs, fmt.Sprintf("moderation url must be an http or https url for provider %s", mc.Provider))
+		}
+	default:
+		msgs = append(msgs, "moderation provider must be one of openai, azure or webhook")
+	}
+
+	if mc.DefaultThreshold < 0 || mc.DefaultThreshold > 1 {
+		msgs = append(msgs, "moderation default threshold must be between 0 and 1")
+	}
+
+	for category, threshold := range mc.Thresholds {
+		if threshold < 0 || threshold > 1 {
+			msgs = append(msgs, fmt.Sprintf("moderation threshold of category %s must be between 0 and 1", category))
+		}
+	}
+
+	for severity, action := range mc.Actions {
+		if severity != Low && severity != Medium && severity != High {
+			msgs = append(msgs, fmt.Sprintf("moderation severity %s must be one of low, medium or high", severity))
+		}
+
+		if action != Allow && action != AllowButWarn && action != Block {
+			msgs = append(msgs, fmt.Sprintf("moderation action of severity %s must be one of allow, allow_but_warn or block", severity))
+		}
+	}
+
+	return msgs
+}
+
+func isHttpUrl(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && len(u.Host) != 0
+}
+
+func (mc *ModerationConfig) threshold(category string) float64 {
+	if threshold, ok := mc.Thresholds[category]; ok {
+		return threshold
+	}
+
+	if mc.DefaultThreshold != 0 {
+		return mc.DefaultThreshold
+	}
+
+	return defaultModerationThreshold
+}
+
+func severityOf(score float64) Severity {
+	if score >= 0.8 {
+		return High
+	}
+
+	if score >= 0.5 {
+		return Medium
+	}
+
+	return Low
+}
+
+// action returns what happens to a request with a category flagged at the
+// given severity. Severities without an action block the request.
+func (mc *ModerationConfig) action(severity Severity) Action {
+	if action, ok := mc.Actions[severity]; ok {
+		return action
+	}
+
+	return Block
+}
+
+// flagged returns the categories of scores that block and warn, reported as
+// "moderation:<category>".
+func (mc *ModerationConfig) flagged(scores map[string]float64) ([]string, []string) {
+	categories := make([]string, 0, len(scores))
+	for category := range scores {
+		categories = append(categories, category)
+	}
+
+	sort.Strings(categories)
+
+	blocked := []string{}
+	warned := []string{}
+	for _, category := range categories {
+		score := scores[category]
+		if score < mc.threshold(category) {
+			continue
+		}
+
+		switch mc.action(severityOf(score)) {
+		case Block:
+			blocked = append(blocked, "moderation:"+category)
+		case AllowButWarn:
+			warned = append(warned, "moderation:"+category)
+		}
+	}
+
+	return blocked, warned
+}
@@ -0,0 +1,125 @@
+package moderation
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+const (
+	openAiEmbeddingUrl   = "https://api.openai.com/v1/embeddings"
+	openAiEmbeddingModel = "text-embedding-3-small"
+	maxCachedEmbeddings  = 10000
+)
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed returns the OpenAI embeddings of input. Cached texts embedded before
+// with the same model are not sent again, so the exemplars of banned topics
+// are only embedded once.
+func (c *Client) Embed(model string, input []string, cache bool) ([][]float64, error) {
+	if len(c.openAiKey) == 0 {
+		return nil, errors.New("openai api key is not configured for embeddings")
+	}
+
+	if len(model) == 0 {
+		model = openAiEmbeddingModel
+	}
+
+	embeddings := make([][]float64, len(input))
+	missing := []string{}
+	indexes := []int{}
+	for i, text := range input {
+		if embedding, ok := c.embeddings.get(model, text); ok && cache {
+			embeddings[i] = embedding
+			continue
+		}
+
+		missing = append(missing, text)
+		indexes = append(indexes, i)
+	}
+
+	if len(missing) == 0 {
+		return embeddings, nil
+	}
+
+	data, err := json.Marshal(&embeddingRequest{Model: model, Input: missing})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &embeddingResponse{}
+	err = c.post(openAiEmbeddingUrl, data, map[string]string{
+		"Authorization": "Bearer " + c.openAiKey,
+	}, res)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Data) != len(missing) {
+		return nil, errors.New("embedding endpoint responded with a different number of embeddings")
+	}
+
+	for _, d := range res.Data {
+		if d.Index < 0 || d.Index >= len(missing) {
+			return nil, errors.New("embedding endpoint responded with an unknown index")
+		}
+
+		embeddings[indexes[d.Index]] = d.Embedding
+		if cache {
+			c.embeddings.set(model, missing[d.Index], d.Embedding)
+		}
+	}
+
+	return embeddings, nil
+}
+
+type embeddingKey struct {
+	model string
+	text  string
+}
+
+// embeddingCache keeps embeddings in memory. Once full, it is emptied rather
+// than evicting one embedding at a time, since the exemplars it is meant for
+// are few and are embedded again on the next request.
+type embeddingCache struct {
+	mu         sync.RWMutex
+	size       int
+	embeddings map[embeddingKey][]float64
+}
+
+func newEmbeddingCache(size int) *embeddingCache {
+	return &embeddingCache{
+		size:       size,
+		embeddings: map[embeddingKey][]float64{},
+	}
+}
+
+func (ec *embeddingCache) get(model, text string) ([]float64, bool) {
+	ec.mu.RLock()
+	defer ec.mu.RUnlock()
+
+	embedding, ok := ec.embeddings[embeddingKey{model: model, text: text}]
+	return embedding, ok
+}
+
+func (ec *embeddingCache) set(model, text string, embedding []float64) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+
+	if len(ec.embeddings) >= ec.size {
+		ec.embeddings = map[embeddingKey][]float64{}
+	}
+
+	ec.embeddings[embeddingKey{model: model, text: text}] = embedding
+}
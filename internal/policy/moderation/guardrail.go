@@ -0,0 +1,114 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/webhook"
+)
+
+// NewGuardrailTls returns the tls config guardrails are called with: the
+// client certificate of the gateway for services that require mTLS, and the
+// CAs their certificates are verified with. It is nil when neither is set.
+func NewGuardrailTls(certFile, keyFile, caFile string) (*tls.Config, error) {
+	if len(certFile) == 0 && len(caFile) == 0 {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if len(certFile) != 0 {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(caFile) != 0 {
+		ca, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("guardrail tls ca file does not contain valid certificates")
+		}
+
+		cfg.RootCAs = pool
+	}
+
+	return cfg, nil
+}
+
+func newGuardrailClient(tlsCfg *tls.Config) http.Client {
+	if tlsCfg == nil {
+		return http.Client{}
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = tlsCfg
+
+	return http.Client{Transport: t}
+}
+
+// Guard posts the texts of a request or response to a guardrail, signed the
+// same way as moderation webhooks, and returns its verdict.
+func (c *Client) Guard(cfg *policy.GuardrailConfig, gr *policy.GuardrailRequest) (*policy.GuardrailVerdict, error) {
+	data, err := json.Marshal(gr)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.RequestTimeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.Url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if len(c.webhookSecret) != 0 {
+		ts := time.Now().Unix()
+		req.Header.Set(timestampHeader, strconv.FormatInt(ts, 10))
+		req.Header.Set(signatureHeader, signatureVersion+webhook.Sign(c.webhookSecret, ts, data))
+	}
+
+	res, err := c.guardrails.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(io.LimitReader(res.Body, maxModerationResponse))
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("guardrail responded with status code %d", res.StatusCode)
+	}
+
+	v := &policy.GuardrailVerdict{}
+	if err := json.Unmarshal(resBody, v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
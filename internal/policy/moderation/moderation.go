@@ -0,0 +1,238 @@
+package moderation
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/webhook"
+)
+
+const (
+	openAiModerationUrl     = "https://api.openai.com/v1/moderations"
+	openAiModerationModel   = "omni-moderation-latest"
+	azureApiVersion         = "2023-10-01"
+	azureMaxTextLength      = 10000
+	azureMaxSeverity        = 6
+	maxModerationResponse   = 1 << 20
+	signatureHeader         = "X-BRICKS-SIGNATURE"
+	timestampHeader         = "X-BRICKS-TIMESTAMP"
+	signatureVersion        = "sha256="
+	contentSafetyKeyHeader  = "Ocp-Apim-Subscription-Key"
+	azureTextAnalyzeSubpath = "/contentsafety/text:analyze"
+)
+
+// Client calls the moderation endpoint and the guardrail a policy is
+// configured with. The credentials of OpenAI and Azure Content Safety, the
+// secret webhooks are signed with and the client certificate of guardrails
+// belong to the gateway rather than to policies.
+type Client struct {
+	client        http.Client
+	guardrails    http.Client
+	openAiKey     string
+	azureKey      string
+	webhookSecret string
+	embeddings    *embeddingCache
+}
+
+func NewClient(timeout time.Duration, openAiKey, azureKey, webhookSecret string, guardrailTls *tls.Config) *Client {
+	return &Client{
+		client:        http.Client{Timeout: timeout},
+		guardrails:    newGuardrailClient(guardrailTls),
+		openAiKey:     openAiKey,
+		azureKey:      azureKey,
+		webhookSecret: webhookSecret,
+		embeddings:    newEmbeddingCache(maxCachedEmbeddings),
+	}
+}
+
+// Moderate returns the highest score of every category across input.
+func (c *Client) Moderate(cfg *policy.ModerationConfig, input []string) (map[string]float64, error) {
+	switch cfg.Provider {
+	case policy.OpenAiModeration:
+		return c.openAi(cfg, input)
+	case policy.AzureModeration:
+		return c.azure(cfg, input)
+	case policy.WebhookModeration:
+		return c.webhook(cfg, input)
+	}
+
+	return nil, fmt.Errorf("moderation provider %s is not supported", cfg.Provider)
+}
+
+type openAiRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type openAiResponse struct {
+	Results []struct {
+		CategoryScores map[string]float64 `json:"category_scores"`
+	} `json:"results"`
+}
+
+func (c *Client) openAi(cfg *policy.ModerationConfig, input []string) (map[string]float64, error) {
+	if len(c.openAiKey) == 0 {
+		return nil, errors.New("openai api key is not configured for moderation")
+	}
+
+	u := cfg.Url
+	if len(u) == 0 {
+		u = openAiModerationUrl
+	}
+
+	model := cfg.Model
+	if len(model) == 0 {
+		model = openAiModerationModel
+	}
+
+	data, err := json.Marshal(&openAiRequest{Model: model, Input: input})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &openAiResponse{}
+	err = c.post(u, data, map[string]string{
+		"Authorization": "Bearer " + c.openAiKey,
+	}, res)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := map[string]float64{}
+	for _, result := range res.Results {
+		for category, score := range result.CategoryScores {
+			scores[category] = max(scores[category], score)
+		}
+	}
+
+	return scores, nil
+}
+
+type azureRequest struct {
+	Text       string `json:"text"`
+	OutputType string `json:"outputType"`
+}
+
+type azureResponse struct {
+	CategoriesAnalysis []struct {
+		Category string `json:"category"`
+		Severity int    `json:"severity"`
+	} `json:"categoriesAnalysis"`
+}
+
+// azure analyzes input in pieces of at most the length Azure Content Safety
+// accepts, and scales its severity levels from 0 to 6 onto scores.
+func (c *Client) azure(cfg *policy.ModerationConfig, input []string) (map[string]float64, error) {
+	if len(c.azureKey) == 0 {
+		return nil, errors.New("azure content safety key is not configured for moderation")
+	}
+
+	u := strings.TrimSuffix(cfg.Url, "/") + azureTextAnalyzeSubpath + "?api-version=" + azureApiVersion
+
+	scores := map[string]float64{}
+	for _, text := range chunk(strings.Join(input, "\n"), azureMaxTextLength) {
+		data, err := json.Marshal(&azureRequest{Text: text, OutputType: "FourSeverityLevels"})
+		if err != nil {
+			return nil, err
+		}
+
+		res := &azureResponse{}
+		err = c.post(u, data, map[string]string{
+			contentSafetyKeyHeader: c.azureKey,
+		}, res)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, analysis := range res.CategoriesAnalysis {
+			score := min(float64(analysis.Severity)/azureMaxSeverity, 1)
+			scores[analysis.Category] = max(scores[analysis.Category], score)
+		}
+	}
+
+	return scores, nil
+}
+
+func chunk(text string, size int) []string {
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return nil
+	}
+
+	chunks := []string{}
+	for len(runes) > size {
+		chunks = append(chunks, string(runes[:size]))
+		runes = runes[size:]
+	}
+
+	return append(chunks, string(runes))
+}
+
+type webhookRequest struct {
+	Input []string `json:"input"`
+}
+
+type webhookResponse struct {
+	Scores map[string]float64 `json:"scores"`
+}
+
+// webhook posts input to a custom endpoint, signed the same way as usage
+// webhooks when a secret is configured, and expects scores back by category.
+func (c *Client) webhook(cfg *policy.ModerationConfig, input []string) (map[string]float64, error) {
+	data, err := json.Marshal(&webhookRequest{Input: input})
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{}
+	if len(c.webhookSecret) != 0 {
+		ts := time.Now().Unix()
+		headers[timestampHeader] = strconv.FormatInt(ts, 10)
+		headers[signatureHeader] = signatureVersion + webhook.Sign(c.webhookSecret, ts, data)
+	}
+
+	res := &webhookResponse{}
+	if err := c.post(cfg.Url, data, headers, res); err != nil {
+		return nil, err
+	}
+
+	return res.Scores, nil
+}
+
+func (c *Client) post(u string, data []byte, headers map[string]string, out any) error {
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(io.LimitReader(res.Body, maxModerationResponse))
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("moderation endpoint responded with status code %d", res.StatusCode)
+	}
+
+	return json.Unmarshal(resBody, out)
+}
@@ -9,6 +9,7 @@ import (
 	"sync"
 
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/language"
 	"github.com/bricks-cloud/bricksllm/internal/pii"
 	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
 	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
@@ -26,6 +27,15 @@ const (
 	AllowButWarn   Action = "allow_but_warn"
 	AllowButRedact Action = "allow_but_redact"
 	Allow          Action = "allow"
+
+	// Hash replaces what a regular expression rule matches with a digest, so
+	// that the same value can still be told apart without being revealed.
+	Hash Action = "hash"
+
+	// Tokenize replaces what a rule matches with a deterministic token, and
+	// the proxy puts the value back when the token shows up in the response.
+	// Values are kept encrypted in the token vault.
+	Tokenize Action = "tokenize"
 )
 
 type Rule string
@@ -76,12 +86,20 @@ type CustomRule struct {
 }
 
 type RegularExpressionRule struct {
+	Name       string `json:"name,omitempty"`
 	Definition string `json:"definition"`
 	Action     Action `json:"action"`
+	Responses  bool   `json:"responses,omitempty"`
 }
 
+// Config sets actions for the PII entities found in requests. Entities that
+// are part of an allowlisted phrase, such as the name of the company, are not
+// acted on, and denylisted terms are acted on even if the detector misses
+// them.
 type Config struct {
-	Rules map[Rule]Action `json:"rules"`
+	Rules     map[Rule]Action  `json:"rules"`
+	Allowlist []string         `json:"allowlist,omitempty"`
+	Denylist  []*DenylistEntry `json:"denylist,omitempty"`
 }
 
 type RegexConfig struct {
@@ -93,23 +111,50 @@ type CustomConfig struct {
 }
 
 type Policy struct {
-	Id           string        `json:"id"`
-	Name         string        `json:"name"`
-	CreatedAt    int64         `json:"createdAt"`
-	UpdatedAt    int64         `json:"updatedAt"`
-	Tags         []string      `json:"tags"`
-	Config       *Config       `json:"config"`
-	RegexConfig  *RegexConfig  `json:"regexConfig"`
-	CustomConfig *CustomConfig `json:"customConfig"`
+	Id               string            `json:"id"`
+	Name             string            `json:"name"`
+	CreatedAt        int64             `json:"createdAt"`
+	UpdatedAt        int64             `json:"updatedAt"`
+	Tags             []string          `json:"tags"`
+	Config           *Config           `json:"config"`
+	RegexConfig      *RegexConfig      `json:"regexConfig"`
+	CustomConfig     *CustomConfig     `json:"customConfig"`
+	Conditions       []*Condition      `json:"conditions"`
+	Namespace        string            `json:"namespace"`
+	ResponseConfig   *ResponseConfig   `json:"responseConfig"`
+	ModerationConfig *ModerationConfig `json:"moderationConfig"`
+	InjectionConfig  *InjectionConfig  `json:"injectionConfig"`
+	LimitConfig      *LimitConfig      `json:"limitConfig"`
+	TopicConfig      *TopicConfig      `json:"topicConfig"`
+	LanguageConfig   *LanguageConfig   `json:"languageConfig"`
+	LatencyBudget    *LatencyBudget    `json:"latencyBudget"`
+	GuardrailConfig  *GuardrailConfig  `json:"guardrailConfig"`
+	SchemaConfig     *SchemaConfig     `json:"schemaConfig"`
+	Version          int               `json:"version"`
+	Rollout          *Rollout          `json:"rollout,omitempty"`
+
+	// responses is set on the policy that inspects responses, so that the
+	// guardrail knows which stage it is judging.
+	responses bool
 }
 
 type UpdatePolicy struct {
-	Name         string        `json:"name"`
-	UpdatedAt    int64         `json:"updatedAt"`
-	Tags         []string      `json:"tags"`
-	Config       *Config       `json:"config"`
-	RegexConfig  *RegexConfig  `json:"regexConfig"`
-	CustomConfig *CustomConfig `json:"customConfig"`
+	Name             string            `json:"name"`
+	UpdatedAt        int64             `json:"updatedAt"`
+	Tags             []string          `json:"tags"`
+	Config           *Config           `json:"config"`
+	RegexConfig      *RegexConfig      `json:"regexConfig"`
+	CustomConfig     *CustomConfig     `json:"customConfig"`
+	Conditions       []*Condition      `json:"conditions"`
+	ResponseConfig   *ResponseConfig   `json:"responseConfig"`
+	ModerationConfig *ModerationConfig `json:"moderationConfig"`
+	InjectionConfig  *InjectionConfig  `json:"injectionConfig"`
+	LimitConfig      *LimitConfig      `json:"limitConfig"`
+	TopicConfig      *TopicConfig      `json:"topicConfig"`
+	LanguageConfig   *LanguageConfig   `json:"languageConfig"`
+	LatencyBudget    *LatencyBudget    `json:"latencyBudget"`
+	GuardrailConfig  *GuardrailConfig  `json:"guardrailConfig"`
+	SchemaConfig     *SchemaConfig     `json:"schemaConfig"`
 }
 
 func extractTextContents(input any) []string {
@@ -139,7 +184,7 @@ func (p *UpdatePolicy) Validate() error {
 		return internal_errors.NewValidationError("regex rule at index [%d] cannot be nil")
 	}
 
-	msgs := []string{}
+	msgs := validateConditions(p.Conditions)
 
 	if p.RegexConfig != nil {
 		for idx, rule := range p.RegexConfig.RegularExpressionRules {
@@ -148,13 +193,27 @@ func (p *UpdatePolicy) Validate() error {
 				continue
 			}
 
-			_, err := regexp.Compile(rule.Definition)
-			if err != nil {
-				msgs = append(msgs, fmt.Sprintf("regex rule at index [%d] cannot be compiled", idx))
+			for _, reason := range rule.invalidReasons() {
+				msgs = append(msgs, fmt.Sprintf("regex rule at index [%d] %s", idx, reason))
 			}
 		}
 	}
 
+	msgs = append(msgs, p.Config.validate()...)
+	msgs = append(msgs, p.ResponseConfig.validate()...)
+	msgs = append(msgs, p.ModerationConfig.validate()...)
+	msgs = append(msgs, p.InjectionConfig.validate()...)
+	msgs = append(msgs, p.LimitConfig.validate()...)
+	msgs = append(msgs, p.TopicConfig.validate()...)
+	msgs = append(msgs, p.LanguageConfig.validate()...)
+	msgs = append(msgs, p.LatencyBudget.validate()...)
+	msgs = append(msgs, p.GuardrailConfig.validate()...)
+	msgs = append(msgs, p.SchemaConfig.validate()...)
+
+	if tokenizes(p.Config, p.RegexConfig) && !TokenSecretSet() {
+		msgs = append(msgs, "tokenize can not be used without a pii token secret")
+	}
+
 	if len(msgs) != 0 {
 		return internal_errors.NewValidationError("policy is not valid: " + strings.Join(msgs, " ,"))
 	}
@@ -162,6 +221,14 @@ func (p *UpdatePolicy) Validate() error {
 	return nil
 }
 
+// Transcript is the text of a transcription or translation of audio. Requests
+// only carry the audio, so policies inspect the text once the provider has
+// responded.
+type Transcript struct {
+	Text     string
+	Segments []string
+}
+
 type Request struct {
 	Contents []string `json:"contents"`
 	Policy   *Policy  `json:"policy"`
@@ -179,7 +246,7 @@ func (p *Policy) Validate() error {
 		return internal_errors.NewValidationError("regex rule at index [%d] cannot be nil")
 	}
 
-	msgs := []string{}
+	msgs := validateConditions(p.Conditions)
 
 	if p.RegexConfig != nil {
 		for idx, rule := range p.RegexConfig.RegularExpressionRules {
@@ -188,13 +255,27 @@ func (p *Policy) Validate() error {
 				continue
 			}
 
-			_, err := regexp.Compile(rule.Definition)
-			if err != nil {
-				msgs = append(msgs, fmt.Sprintf("regex rule at index [%d] cannot be compiled", idx))
+			for _, reason := range rule.invalidReasons() {
+				msgs = append(msgs, fmt.Sprintf("regex rule at index [%d] %s", idx, reason))
 			}
 		}
 	}
 
+	msgs = append(msgs, p.Config.validate()...)
+	msgs = append(msgs, p.ResponseConfig.validate()...)
+	msgs = append(msgs, p.ModerationConfig.validate()...)
+	msgs = append(msgs, p.InjectionConfig.validate()...)
+	msgs = append(msgs, p.LimitConfig.validate()...)
+	msgs = append(msgs, p.TopicConfig.validate()...)
+	msgs = append(msgs, p.LanguageConfig.validate()...)
+	msgs = append(msgs, p.LatencyBudget.validate()...)
+	msgs = append(msgs, p.GuardrailConfig.validate()...)
+	msgs = append(msgs, p.SchemaConfig.validate()...)
+
+	if tokenizes(p.Config, p.RegexConfig) && !TokenSecretSet() {
+		msgs = append(msgs, "tokenize can not be used without a pii token secret")
+	}
+
 	if len(msgs) != 0 {
 		return internal_errors.NewValidationError("policy is not valid: " + strings.Join(msgs, " ,"))
 	}
@@ -202,17 +283,45 @@ func (p *Policy) Validate() error {
 	return nil
 }
 
-func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd CustomPolicyDetector, log *zap.Logger) error {
+func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd CustomPolicyDetector, mod Moderator, log *zap.Logger) error {
+	return p.filter(input, scanner, cd, mod, log, nil)
+}
+
+// Signals are what filtering learns about a request besides its outcome, to
+// be recorded on its event.
+type Signals struct {
+	// RiskScore is zero when the policy does not screen for prompt injection.
+	RiskScore float64
+	// Language is empty when the policy does not detect languages, or when
+	// the language of the request cannot be detected.
+	Language string
+	// Tokens maps the tokens the request was tokenized with to their values.
+	Tokens map[string]string
+}
+
+// FilterWithSignals is Filter that also returns the signals of the request.
+func (p *Policy) FilterWithSignals(client http.Client, input any, scanner Scanner, cd CustomPolicyDetector, mod Moderator, log *zap.Logger) (*Signals, error) {
+	seen := scans{}
+	err := p.filter(input, scanner, cd, mod, log, &seen)
+
+	return &Signals{
+		RiskScore: seen.riskScore(),
+		Language:  seen.language(),
+		Tokens:    seen.tokens(),
+	}, err
+}
+
+// filter is Filter with every scan of the request recorded into seen, which
+// may be nil.
+func (p *Policy) filter(input any, scanner Scanner, cd CustomPolicyDetector, mod Moderator, log *zap.Logger, seen *scans) error {
 	if p == nil || scanner == nil || input == nil {
 		return nil
 	}
 
 	shouldInspect := false
-	if p.Config != nil {
-		for _, action := range p.Config.Rules {
-			if action != Allow {
-				shouldInspect = true
-			}
+	for _, action := range p.Config.rules() {
+		if action != Allow {
+			shouldInspect = true
 		}
 	}
 
@@ -232,6 +341,26 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 		}
 	}
 
+	if p.ModerationConfig.enabled() && mod != nil {
+		shouldInspect = true
+	}
+
+	if p.InjectionConfig.enabled() {
+		shouldInspect = true
+	}
+
+	if p.TopicConfig.enabled() && mod != nil {
+		shouldInspect = true
+	}
+
+	if p.LanguageConfig.enabled() {
+		shouldInspect = true
+	}
+
+	if p.GuardrailConfig.enabled() && mod != nil {
+		shouldInspect = true
+	}
+
 	if !shouldInspect {
 		return nil
 	}
@@ -251,17 +380,17 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 				inputsToInspect = append(inputsToInspect, stringified)
 			}
 
-			result, err := p.scan(inputsToInspect, scanner, cd, log)
+			result, err := seen.add(p.scan(inputsToInspect, scanner, cd, mod, log))
 			if err != nil {
 				return err
 			}
 
 			if result.Action == Block {
-				return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
+				return result.blockedError()
 			}
 
 			if result.Action == AllowButWarn {
-				return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
+				return result.warnedError()
 			}
 
 			if len(result.Updated) == 1 {
@@ -269,20 +398,20 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 			}
 
 			if result.Action == AllowButRedact {
-				return internal_errors.NewRedactError("request redacted due to detected entities")
+				return result.redactedError()
 			}
 		} else if input, ok := converted.Input.(string); ok {
-			result, err := p.scan([]string{input}, scanner, cd, log)
+			result, err := seen.add(p.scan([]string{input}, scanner, cd, mod, log))
 			if err != nil {
 				return err
 			}
 
 			if result.Action == Block {
-				return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
+				return result.blockedError()
 			}
 
 			if result.Action == AllowButWarn {
-				return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
+				return result.warnedError()
 			}
 
 			if len(result.Updated) == 1 {
@@ -290,7 +419,7 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 			}
 
 			if result.Action == AllowButRedact {
-				return internal_errors.NewRedactError("request redacted due to detected entities")
+				return result.redactedError()
 			}
 		}
 
@@ -304,17 +433,17 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 			contents = append(contents, message.Content)
 		}
 
-		result, err := p.scan(contents, scanner, cd, log)
+		result, err := seen.add(p.scan(contents, scanner, cd, mod, log))
 		if err != nil {
 			return err
 		}
 
 		if result.Action == Block {
-			return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
+			return result.blockedError()
 		}
 
 		if result.Action == AllowButWarn {
-			return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
+			return result.warnedError()
 		}
 
 		if len(result.Updated) != len(converted.Messages) {
@@ -335,24 +464,24 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 		converted.Messages = newMessages
 
 		if result.Action == AllowButRedact {
-			return internal_errors.NewRedactError("request redacted due to detected entities")
+			return result.redactedError()
 		}
 
 		return nil
 	case *vllm.CompletionRequest:
 		converted := input.(*vllm.CompletionRequest)
 		if inputs, ok := converted.Prompt.([]string); ok {
-			result, err := p.scan(inputs, scanner, cd, log)
+			result, err := seen.add(p.scan(inputs, scanner, cd, mod, log))
 			if err != nil {
 				return err
 			}
 
 			if result.Action == Block {
-				return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
+				return result.blockedError()
 			}
 
 			if result.Action == AllowButWarn {
-				return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
+				return result.warnedError()
 			}
 
 			if len(result.Updated) == 1 {
@@ -360,21 +489,21 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 			}
 
 			if result.Action == AllowButRedact {
-				return internal_errors.NewRedactError("request redacted due to detected entities")
+				return result.redactedError()
 			}
 
 		} else if input, ok := converted.Prompt.(string); ok {
-			result, err := p.scan([]string{input}, scanner, cd, log)
+			result, err := seen.add(p.scan([]string{input}, scanner, cd, mod, log))
 			if err != nil {
 				return err
 			}
 
 			if result.Action == Block {
-				return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
+				return result.blockedError()
 			}
 
 			if result.Action == AllowButWarn {
-				return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
+				return result.warnedError()
 			}
 
 			if len(result.Updated) == 1 {
@@ -382,7 +511,7 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 			}
 
 			if result.Action == AllowButRedact {
-				return internal_errors.NewRedactError("request redacted due to detected entities")
+				return result.redactedError()
 			}
 		}
 
@@ -396,17 +525,17 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 			contents = append(contents, message.Content)
 		}
 
-		result, err := p.scan(contents, scanner, cd, log)
+		result, err := seen.add(p.scan(contents, scanner, cd, mod, log))
 		if err != nil {
 			return err
 		}
 
 		if result.Action == Block {
-			return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
+			return result.blockedError()
 		}
 
 		if result.Action == AllowButWarn {
-			return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
+			return result.warnedError()
 		}
 
 		if len(result.Updated) != len(converted.Messages) {
@@ -427,7 +556,7 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 		converted.Messages = newMessages
 
 		if result.Action == AllowButRedact {
-			return internal_errors.NewRedactError("request redacted due to detected entities")
+			return result.redactedError()
 		}
 
 		return nil
@@ -441,17 +570,17 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 			contents = append(contents, message.Content)
 		}
 
-		result, err := p.scan(contents, scanner, cd, log)
+		result, err := seen.add(p.scan(contents, scanner, cd, mod, log))
 		if err != nil {
 			return err
 		}
 
 		if result.Action == Block {
-			return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
+			return result.blockedError()
 		}
 
 		if result.Action == AllowButWarn {
-			return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
+			return result.warnedError()
 		}
 
 		if len(result.Updated) != len(converted.Messages) {
@@ -468,24 +597,24 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 		converted.Messages = newMessages
 
 		if result.Action == AllowButRedact {
-			return internal_errors.NewRedactError("request redacted due to detected entities")
+			return result.redactedError()
 		}
 
 		return nil
 
 	case *anthropic.CompletionRequest:
 		converted := input.(*anthropic.CompletionRequest)
-		result, err := p.scan([]string{converted.Prompt}, scanner, cd, log)
+		result, err := seen.add(p.scan([]string{converted.Prompt}, scanner, cd, mod, log))
 		if err != nil {
 			return err
 		}
 
 		if result.Action == Block {
-			return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
+			return result.blockedError()
 		}
 
 		if result.Action == AllowButWarn {
-			return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
+			return result.warnedError()
 		}
 
 		if len(result.Updated) == 1 {
@@ -493,7 +622,7 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 		}
 
 		if result.Action == AllowButRedact {
-			return internal_errors.NewRedactError("request redacted due to detected entities")
+			return result.redactedError()
 		}
 
 		return nil
@@ -501,17 +630,17 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 		converted := input.(*goopenai.AssistantRequest)
 
 		if converted.Instructions != nil {
-			result, err := p.scan([]string{*converted.Instructions}, scanner, cd, log)
+			result, err := seen.add(p.scan([]string{*converted.Instructions}, scanner, cd, mod, log))
 			if err != nil {
 				return err
 			}
 
 			if result.Action == Block {
-				return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
+				return result.blockedError()
 			}
 
 			if result.Action == AllowButWarn {
-				return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
+				return result.warnedError()
 			}
 
 			if len(result.Updated) == 1 {
@@ -519,7 +648,7 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 			}
 
 			if result.Action == AllowButRedact {
-				return internal_errors.NewRedactError("request redacted due to detected entities")
+				return result.redactedError()
 			}
 		}
 
@@ -534,17 +663,17 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 			contents = append(contents, extractTextContents(message.Content)...)
 		}
 
-		result, err := p.scan(contents, scanner, cd, log)
+		result, err := seen.add(p.scan(contents, scanner, cd, mod, log))
 		if err != nil {
 			return err
 		}
 
 		if result.Action == Block {
-			return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
+			return result.blockedError()
 		}
 
 		if result.Action == AllowButWarn {
-			return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
+			return result.warnedError()
 		}
 
 		i := 0
@@ -605,7 +734,7 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 		converted.Messages = newMessages
 
 		if result.Action == AllowButRedact {
-			return internal_errors.NewRedactError("request redacted due to detected entities")
+			return result.redactedError()
 		}
 
 		return nil
@@ -613,17 +742,17 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 		converted := input.(*openai.MessageRequest)
 		contents := extractTextContents(converted.Content)
 
-		result, err := p.scan(contents, scanner, cd, log)
+		result, err := seen.add(p.scan(contents, scanner, cd, mod, log))
 		if err != nil {
 			return err
 		}
 
 		if result.Action == Block {
-			return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
+			return result.blockedError()
 		}
 
 		if result.Action == AllowButWarn {
-			return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
+			return result.warnedError()
 		}
 
 		i := 0
@@ -668,7 +797,7 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 		}
 
 		if result.Action == AllowButRedact {
-			return internal_errors.NewRedactError("request redacted due to detected entities")
+			return result.redactedError()
 		}
 
 		return nil
@@ -688,17 +817,17 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 			contents = append(contents, converted.AdditionalInstructions)
 		}
 
-		result, err := p.scan(contents, scanner, cd, log)
+		result, err := seen.add(p.scan(contents, scanner, cd, mod, log))
 		if err != nil {
 			return err
 		}
 
 		if result.Action == Block {
-			return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
+			return result.blockedError()
 		}
 
 		if result.Action == AllowButWarn {
-			return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
+			return result.warnedError()
 		}
 
 		if len(result.Updated) == 2 {
@@ -715,7 +844,7 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 		}
 
 		if result.Action == AllowButRedact {
-			return internal_errors.NewRedactError("request redacted due to detected entities")
+			return result.redactedError()
 		}
 
 		return nil
@@ -733,17 +862,17 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 			contents = append(contents, converted.Instructions)
 		}
 
-		result, err := p.scan(contents, scanner, cd, log)
+		result, err := seen.add(p.scan(contents, scanner, cd, mod, log))
 		if err != nil {
 			return err
 		}
 
 		if result.Action == Block {
-			return internal_errors.NewBlockedError("request blocked due to detected entities: " + join(result.BlockedEntities, result.BlockedRegexDefinitions, result.BlockedCustomDefinitions))
+			return result.blockedError()
 		}
 
 		if result.Action == AllowButWarn {
-			return internal_errors.NewWarningError("request warned due to detected entities: " + join(result.WarnedEntities, result.WarnedRegexDefinitions, []string{}))
+			return result.warnedError()
 		}
 
 		i := 0
@@ -809,30 +938,64 @@ func (p *Policy) Filter(client http.Client, input any, scanner Scanner, cd Custo
 		log.Info("", zap.Any("", converted))
 
 		if result.Action == AllowButRedact {
-			return internal_errors.NewRedactError("request redacted due to detected entities")
+			return result.redactedError()
 		}
 
 		return nil
-	}
+	case *goopenai.CreateSpeechRequest:
+		converted := input.(*goopenai.CreateSpeechRequest)
+		result, err := seen.add(p.scan([]string{converted.Input}, scanner, cd, mod, log))
+		if err != nil {
+			return err
+		}
 
-	return nil
-}
+		if result.Action == Block {
+			return result.blockedError()
+		}
 
-func join(entities []Rule, regexDefitions []string, customDefinitions []string) string {
-	strs := []string{}
-	for _, entity := range entities {
-		strs = append(strs, string(entity))
-	}
+		if result.Action == AllowButWarn {
+			return result.warnedError()
+		}
 
-	for _, def := range regexDefitions {
-		strs = append(strs, def)
-	}
+		if len(result.Updated) == 1 {
+			converted.Input = result.Updated[0]
+		}
+
+		if result.Action == AllowButRedact {
+			return result.redactedError()
+		}
+
+		return nil
+	case *Transcript:
+		converted := input.(*Transcript)
+		result, err := seen.add(p.scan(append([]string{converted.Text}, converted.Segments...), scanner, cd, mod, log))
+		if err != nil {
+			return err
+		}
+
+		if result.Action == Block {
+			return result.blockedError()
+		}
+
+		if result.Action == AllowButWarn {
+			return result.warnedError()
+		}
+
+		if len(result.Updated) != len(converted.Segments)+1 {
+			return errors.New("updated contents length not consistent with existing content length")
+		}
+
+		converted.Text = result.Updated[0]
+		converted.Segments = result.Updated[1:]
 
-	for _, def := range customDefinitions {
-		strs = append(strs, def)
+		if result.Action == AllowButRedact {
+			return result.redactedError()
+		}
+
+		return nil
 	}
 
-	return strings.Join(strs, " ,")
+	return nil
 }
 
 type Scanner interface {
@@ -891,10 +1054,99 @@ type ScanResult struct {
 	BlockedRegexDefinitions  []string
 	WarnedRegexDefinitions   []string
 	BlockedCustomDefinitions []string
-	Updated                  []string
+	BlockedModeration        []string
+	WarnedModeration         []string
+	BlockedInjection         bool
+	WarnedInjection          bool
+	InjectionScore           float64
+	BlockedTopics            []string
+	WarnedTopics             []string
+	Language                 string
+	BlockedLanguage          bool
+	WarnedLanguage           bool
+	BlockedLatency           bool
+	WarnedLatency            bool
+	BlockedGuardrail         bool
+	RedactedRules            []string
+	// Tokens maps the tokens put in Updated to the values they replaced.
+	Tokens  map[string]string
+	Updated []string
+
+	// expired is set once the latency budget runs out, after which detectors
+	// that are still running leave the result alone.
+	expired bool
+}
+
+// rules lists entity rules, regex definitions and custom definitions under
+// one name each, the way they are reported on events.
+func rules(entities []Rule, regexDefinitions []string, customDefinitions []string) []string {
+	strs := []string{}
+	for _, entity := range entities {
+		strs = append(strs, string(entity))
+	}
+
+	strs = append(strs, regexDefinitions...)
+	return append(strs, customDefinitions...)
+}
+
+// blockedRules lists every rule the scan blocked on, including moderation
+// categories, prompt injection, banned topics and languages.
+func (sr *ScanResult) blockedRules() []string {
+	blocked := rules(sr.BlockedEntities, sr.BlockedRegexDefinitions, sr.BlockedCustomDefinitions)
+	blocked = append(blocked, sr.BlockedModeration...)
+	blocked = append(blocked, sr.BlockedTopics...)
+	if sr.BlockedInjection {
+		blocked = append(blocked, PromptInjection)
+	}
+
+	if sr.BlockedLanguage {
+		blocked = append(blocked, languageRule(sr.Language))
+	}
+
+	if sr.BlockedLatency {
+		blocked = append(blocked, LatencyBudgetExceeded)
+	}
+
+	if sr.BlockedGuardrail {
+		blocked = append(blocked, GuardrailRule)
+	}
+
+	return blocked
+}
+
+func (sr *ScanResult) warnedRules() []string {
+	warned := rules(sr.WarnedEntities, sr.WarnedRegexDefinitions, sr.WarnedModeration)
+	warned = append(warned, sr.WarnedTopics...)
+	if sr.WarnedInjection {
+		warned = append(warned, PromptInjection)
+	}
+
+	if sr.WarnedLanguage {
+		warned = append(warned, languageRule(sr.Language))
+	}
+
+	if sr.WarnedLatency {
+		warned = append(warned, LatencyBudgetExceeded)
+	}
+
+	return warned
 }
 
-func (p *Policy) scan(input []string, scanner Scanner, cd CustomPolicyDetector, log *zap.Logger) (*ScanResult, error) {
+func (sr *ScanResult) blockedError() error {
+	blocked := sr.blockedRules()
+	return internal_errors.NewBlockedError("request blocked due to detected entities: " + strings.Join(blocked, " ,")).WithRules(blocked...)
+}
+
+func (sr *ScanResult) warnedError() error {
+	warned := sr.warnedRules()
+	return internal_errors.NewWarningError("request warned due to detected entities: " + strings.Join(warned, " ,")).WithRules(warned...)
+}
+
+func (sr *ScanResult) redactedError() error {
+	return internal_errors.NewRedactError("request redacted due to detected entities").WithRules(sr.RedactedRules...)
+}
+
+func (p *Policy) scan(input []string, scanner Scanner, cd CustomPolicyDetector, mod Moderator, log *zap.Logger) (*ScanResult, error) {
 	sr := &ScanResult{
 		Action:  Allow,
 		Updated: input,
@@ -902,20 +1154,33 @@ func (p *Policy) scan(input []string, scanner Scanner, cd CustomPolicyDetector,
 
 	var wg sync.WaitGroup
 
-	if p.Config != nil && len(p.Config.Rules) != 0 {
+	if configured := p.Config.rules(); len(configured) != 0 {
 		wg.Add(1)
 		go func(result *ScanResult) {
 			defer wg.Done()
 
-			r, err := scanner.Scan(result.Updated)
-			if err != nil {
-				telemetry.Incr("bricksllm.policy.scanner.scan.scan_error", nil, 1)
-				return
+			// a config with only a denylist does not need the detector
+			var r *pii.Result
+			if len(p.Config.Rules) != 0 {
+				detected, err := scanner.Scan(input)
+				if err != nil {
+					telemetry.Incr("bricksllm.policy.scanner.scan.scan_error", nil, 1)
+					return
+				}
+
+				r = detected
 			}
 
+			r = p.Config.amend(input, r)
+
 			result.ActionLock.Lock()
 			defer result.ActionLock.Unlock()
 
+			// the scan has moved on without this detector
+			if result.expired {
+				return
+			}
+
 			found := map[string]bool{}
 			for _, detection := range r.Detections {
 				for _, entity := range detection.Entities {
@@ -930,18 +1195,16 @@ func (p *Policy) scan(input []string, scanner Scanner, cd CustomPolicyDetector,
 
 			blockedEntities := []Rule{}
 			warnedEntities := []Rule{}
-			redactedEntities := map[Rule]bool{}
-
-			if p.Config != nil {
-				for rule, action := range p.Config.Rules {
-					_, ok := found[string(rule)]
-					if action == Block && ok {
-						blockedEntities = append(blockedEntities, rule)
-					} else if action == AllowButWarn && ok {
-						warnedEntities = append(warnedEntities, rule)
-					} else if action == AllowButRedact && ok {
-						redactedEntities[rule] = true
-					}
+			redactedEntities := map[Rule]Action{}
+
+			for rule, action := range configured {
+				_, ok := found[string(rule)]
+				if action == Block && ok {
+					blockedEntities = append(blockedEntities, rule)
+				} else if action == AllowButWarn && ok {
+					warnedEntities = append(warnedEntities, rule)
+				} else if (action == AllowButRedact || action == Tokenize) && ok {
+					redactedEntities[rule] = action
 				}
 			}
 
@@ -959,6 +1222,7 @@ func (p *Policy) scan(input []string, scanner Scanner, cd CustomPolicyDetector,
 			}
 
 			updated := []string{}
+			redacted := map[Rule]bool{}
 			for _, detection := range r.Detections {
 				replaced := detection.Input
 
@@ -968,14 +1232,23 @@ func (p *Policy) scan(input []string, scanner Scanner, cd CustomPolicyDetector,
 						continue
 					}
 
-					_, ok = redactedEntities[Rule(converted)]
+					action, ok := redactedEntities[Rule(converted)]
 					if ok {
 						if result.Action != Block && result.Action != AllowButWarn {
 							result.Action = AllowButRedact
 						}
 
+						if !redacted[Rule(converted)] {
+							redacted[Rule(converted)] = true
+							result.RedactedRules = append(result.RedactedRules, converted)
+						}
+
 						old := detection.Input[entity.BeginOffset:entity.EndOffset]
-						replaced = strings.ReplaceAll(replaced, old, "***")
+						if action == Tokenize {
+							replaced = strings.ReplaceAll(replaced, old, result.tokenize(p, converted, old))
+						} else {
+							replaced = strings.ReplaceAll(replaced, old, "***")
+						}
 					}
 				}
 
@@ -1016,6 +1289,10 @@ func (p *Policy) scan(input []string, scanner Scanner, cd CustomPolicyDetector,
 				result.ActionLock.Lock()
 				defer result.ActionLock.Unlock()
 
+				if result.expired {
+					return
+				}
+
 				if action == Block && found {
 					result.BlockedCustomDefinitions = append(result.BlockedCustomDefinitions, reqs...)
 					result.Action = Block
@@ -1025,7 +1302,170 @@ func (p *Policy) scan(input []string, scanner Scanner, cd CustomPolicyDetector,
 		}
 	}
 
-	wg.Wait()
+	if p.ModerationConfig.enabled() && mod != nil {
+		wg.Add(1)
+
+		go func(result *ScanResult) {
+			defer wg.Done()
+
+			scores, err := mod.Moderate(p.ModerationConfig, input)
+			if err != nil {
+				log.Debug("error when moderating using moderation provider", zap.Error(err))
+				telemetry.Incr("bricksllm.policy.scanner.scan.moderate_error", []string{"provider:" + string(p.ModerationConfig.Provider)}, 1)
+				return
+			}
+
+			blocked, warned := p.ModerationConfig.flagged(scores)
+
+			result.ActionLock.Lock()
+			defer result.ActionLock.Unlock()
+
+			if result.expired {
+				return
+			}
+
+			if len(blocked) != 0 {
+				result.BlockedModeration = blocked
+				result.Action = Block
+			}
+
+			if len(warned) != 0 {
+				result.WarnedModeration = warned
+				if result.Action != Block {
+					result.Action = AllowButWarn
+				}
+			}
+		}(sr)
+	}
+
+	if p.InjectionConfig.enabled() {
+		wg.Add(1)
+
+		go func(result *ScanResult) {
+			defer wg.Done()
+
+			ic := p.InjectionConfig
+			screened := ic.screened(input)
+			score := heuristicScore(screened)
+
+			// the classifier is only asked when the heuristics alone do not
+			// flag the request, since it costs a call to OpenAI
+			if ic.Classifier && cd != nil && score < ic.threshold() {
+				found, err := cd.Detect(screened, []string{injectionRequirement})
+				if err != nil {
+					log.Debug("error when classifying a prompt injection", zap.Error(err))
+					telemetry.Incr("bricksllm.policy.scanner.scan.classify_injection_error", nil, 1)
+				}
+
+				if found {
+					score = withClassifier(score)
+				}
+			}
+
+			result.ActionLock.Lock()
+			defer result.ActionLock.Unlock()
+
+			if result.expired {
+				return
+			}
+
+			result.InjectionScore = score
+			if score < ic.threshold() {
+				return
+			}
+
+			switch ic.Action {
+			case Block:
+				result.BlockedInjection = true
+				result.Action = Block
+			case AllowButWarn:
+				result.WarnedInjection = true
+				if result.Action != Block {
+					result.Action = AllowButWarn
+				}
+			}
+		}(sr)
+	}
+
+	if p.TopicConfig.enabled() && mod != nil {
+		wg.Add(1)
+
+		go func(result *ScanResult) {
+			defer wg.Done()
+
+			tc := p.TopicConfig
+			exemplars, err := mod.Embed(tc.Model, tc.exemplars(), true)
+			if err != nil {
+				log.Debug("error when embedding banned topic exemplars", zap.Error(err))
+				telemetry.Incr("bricksllm.policy.scanner.scan.embed_error", nil, 1)
+				return
+			}
+
+			embeddings, err := mod.Embed(tc.Model, input, false)
+			if err != nil {
+				log.Debug("error when embedding for banned topics", zap.Error(err))
+				telemetry.Incr("bricksllm.policy.scanner.scan.embed_error", nil, 1)
+				return
+			}
+
+			blocked, warned := tc.matched(embeddings, exemplars)
+
+			result.ActionLock.Lock()
+			defer result.ActionLock.Unlock()
+
+			if result.expired {
+				return
+			}
+
+			if len(blocked) != 0 {
+				result.BlockedTopics = blocked
+				result.Action = Block
+			}
+
+			if len(warned) != 0 {
+				result.WarnedTopics = warned
+				if result.Action != Block {
+					result.Action = AllowButWarn
+				}
+			}
+		}(sr)
+	}
+
+	if !p.LatencyBudget.wait(&wg) {
+		sr.ActionLock.Lock()
+		sr.expired = true
+		sr.ActionLock.Unlock()
+
+		switch p.LatencyBudget.onTimeout() {
+		case Block:
+			sr.BlockedLatency = true
+			sr.Action = Block
+		case AllowButWarn:
+			sr.WarnedLatency = true
+			if sr.Action != Block {
+				sr.Action = AllowButWarn
+			}
+		}
+	}
+
+	if p.GuardrailConfig.enabled() && mod != nil && sr.Action != Block {
+		p.guard(sr, mod, log)
+	}
+
+	if p.LanguageConfig.enabled() {
+		sr.Language = language.Detect(input)
+
+		switch p.LanguageConfig.action(sr.Language) {
+		case Block:
+			sr.BlockedLanguage = true
+			sr.Action = Block
+		case AllowButWarn:
+			sr.WarnedLanguage = true
+			if sr.Action != Block {
+				sr.Action = AllowButWarn
+			}
+		}
+	}
 
 	if p.RegexConfig != nil && len(p.RegexConfig.RegularExpressionRules) != 0 {
 		found := map[string]bool{}
@@ -1050,11 +1490,11 @@ func (p *Policy) scan(input []string, scanner Scanner, cd CustomPolicyDetector,
 		for _, rule := range p.RegexConfig.RegularExpressionRules {
 			_, ok := found[rule.Definition]
 			if ok && rule.Action == Block {
-				blockedRegexDefinitions = append(blockedRegexDefinitions, rule.Definition)
+				blockedRegexDefinitions = append(blockedRegexDefinitions, rule.label())
 			}
 
 			if ok && rule.Action == AllowButWarn {
-				warnedRegexDefinitions = append(warnedRegexDefinitions, rule.Definition)
+				warnedRegexDefinitions = append(warnedRegexDefinitions, rule.label())
 			}
 		}
 
@@ -1072,23 +1512,31 @@ func (p *Policy) scan(input []string, scanner Scanner, cd CustomPolicyDetector,
 		}
 
 		updated := []string{}
+		redacted := map[string]bool{}
 		for _, text := range sr.Updated {
 			replaced := text
 
 			for _, rule := range p.RegexConfig.RegularExpressionRules {
-				if rule.Action == AllowButRedact {
-					regex, err := regexp.Compile(rule.Definition)
-					if err != nil {
-						telemetry.Incr("bricksllm.policy.scanner.scan.regex_compile_error", nil, 1)
-						continue
-					}
+				if !rule.rewrites() {
+					continue
+				}
+
+				regex, err := regexp.Compile(rule.Definition)
+				if err != nil {
+					telemetry.Incr("bricksllm.policy.scanner.scan.regex_compile_error", nil, 1)
+					continue
+				}
 
-					if regex.MatchString(text) {
-						replaced = regex.ReplaceAllString(text, "***")
+				if regex.MatchString(replaced) {
+					replaced = p.rewrite(rule, regex, replaced, sr)
 
-						if sr.Action != Block && sr.Action != AllowButWarn {
-							sr.Action = AllowButRedact
-						}
+					if sr.Action != Block && sr.Action != AllowButWarn {
+						sr.Action = AllowButRedact
+					}
+
+					if !redacted[rule.label()] {
+						redacted[rule.label()] = true
+						sr.RedactedRules = append(sr.RedactedRules, rule.label())
 					}
 				}
 			}
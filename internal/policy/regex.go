@@ -0,0 +1,62 @@
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+)
+
+// label is how a rule is reported on events and errors: its name when it has
+// one, so that patterns for internal identifiers are not spread around.
+func (r *RegularExpressionRule) label() string {
+	if len(r.Name) != 0 {
+		return r.Name
+	}
+
+	return r.Definition
+}
+
+// rewrites reports whether the rule replaces what it matches.
+func (r *RegularExpressionRule) rewrites() bool {
+	return r.Action == AllowButRedact || r.Action == Hash || r.Action == Tokenize
+}
+
+func (r *RegularExpressionRule) invalidReasons() []string {
+	reasons := []string{}
+
+	if _, err := regexp.Compile(r.Definition); err != nil {
+		reasons = append(reasons, "cannot be compiled")
+	}
+
+	switch r.Action {
+	case Allow, AllowButWarn, AllowButRedact, Block, Hash, Tokenize:
+	default:
+		reasons = append(reasons, "has an unknown action: "+string(r.Action))
+	}
+
+	if r.Responses && (!r.rewrites() || r.Action == Tokenize) {
+		reasons = append(reasons, "can only apply to responses when its action is allow_but_redact or hash")
+	}
+
+	return reasons
+}
+
+// rewrite replaces every match of rule in text. Hashes are scoped to the
+// policy, so the same value hashes differently under different policies.
+// Tokens are recorded on sr.
+func (p *Policy) rewrite(rule *RegularExpressionRule, regex *regexp.Regexp, text string, sr *ScanResult) string {
+	if rule.Action == Tokenize {
+		return regex.ReplaceAllStringFunc(text, func(match string) string {
+			return sr.tokenize(p, rule.Name, match)
+		})
+	}
+
+	if rule.Action != Hash {
+		return regex.ReplaceAllString(text, "***")
+	}
+
+	return regex.ReplaceAllStringFunc(text, func(match string) string {
+		sum := sha256.Sum256([]byte(p.Id + ":" + match))
+		return "[hash:" + hex.EncodeToString(sum[:8]) + "]"
+	})
+}
@@ -0,0 +1,270 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"go.uber.org/zap"
+)
+
+// StreamFlush is when text held back from a streamed response is inspected
+// and sent on. A match split across two flushes is not caught, so flushing
+// less often is stricter but delays the stream more.
+type StreamFlush string
+
+const (
+	// FlushOnEnd holds back the whole stream until the model is done.
+	FlushOnEnd StreamFlush = "end"
+	// FlushOnSentence sends text on at the end of every sentence or line.
+	FlushOnSentence StreamFlush = "sentence"
+	// FlushOnSize sends text on once FlushSize characters are held back.
+	FlushOnSize StreamFlush = "size"
+)
+
+const defaultFlushSize = 200
+
+// ResponseConfig inspects model responses before they reach the client. Rules
+// map PII entities to actions like Config does, and banned terms are matched
+// case insensitively. Regular expression rules of the policy marked for
+// responses are applied as well.
+type ResponseConfig struct {
+	Rules            map[Rule]Action `json:"rules"`
+	BannedTerms      []string        `json:"bannedTerms"`
+	BannedTermAction Action          `json:"bannedTermAction"`
+	Flush            StreamFlush     `json:"flush"`
+	FlushSize        int             `json:"flushSize"`
+}
+
+func (rc *ResponseConfig) validate() []string {
+	if rc == nil {
+		return nil
+	}
+
+	msgs := []string{}
+	for rule, action := range rc.Rules {
+		if _, ok := actionStrictness[action]; !ok || action == Tokenize {
+			msgs = append(msgs, fmt.Sprintf("response rule %s has an unknown action: %s", rule, action))
+		}
+	}
+
+	for idx, term := range rc.BannedTerms {
+		if len(term) == 0 {
+			msgs = append(msgs, fmt.Sprintf("banned term at index [%d] cannot be empty", idx))
+		}
+	}
+
+	switch rc.BannedTermAction {
+	case "", Block, AllowButWarn, AllowButRedact, Hash:
+	default:
+		msgs = append(msgs, "banned term action must be one of block, allow_but_warn, allow_but_redact or hash")
+	}
+
+	switch rc.Flush {
+	case "", FlushOnEnd, FlushOnSentence:
+	case FlushOnSize:
+		if rc.FlushSize < 0 {
+			msgs = append(msgs, "flush size cannot be negative")
+		}
+	default:
+		msgs = append(msgs, "flush must be one of end, sentence or size")
+	}
+
+	return msgs
+}
+
+// StreamFlush returns when held back text of a streamed response is sent on,
+// and after how many characters for FlushOnSize.
+func (p *Policy) StreamFlush() (StreamFlush, int) {
+	if p == nil || p.ResponseConfig == nil || len(p.ResponseConfig.Flush) == 0 {
+		return FlushOnEnd, 0
+	}
+
+	if p.ResponseConfig.Flush == FlushOnSize && p.ResponseConfig.FlushSize == 0 {
+		return FlushOnSize, defaultFlushSize
+	}
+
+	return p.ResponseConfig.Flush, p.ResponseConfig.FlushSize
+}
+
+// responsePolicy is the policy that applies to responses: the PII rules and
+// banned terms of the response config along with the regular expression rules
+// marked for responses, and the guardrail when it judges responses. It is nil
+// when responses are not inspected.
+func (p *Policy) responsePolicy() *Policy {
+	if p == nil {
+		return nil
+	}
+
+	rp := &Policy{
+		Id:          p.Id,
+		Config:      &Config{Rules: map[Rule]Action{}},
+		RegexConfig: &RegexConfig{},
+		responses:   true,
+	}
+
+	if p.GuardrailConfig.enabled() && p.GuardrailConfig.Responses {
+		rp.GuardrailConfig = p.GuardrailConfig
+	}
+
+	if p.RegexConfig != nil {
+		for _, rule := range p.RegexConfig.RegularExpressionRules {
+			if rule != nil && rule.Responses && rule.rewrites() {
+				rp.RegexConfig.RegularExpressionRules = append(rp.RegexConfig.RegularExpressionRules, rule)
+			}
+		}
+	}
+
+	if rc := p.ResponseConfig; rc != nil {
+		for rule, action := range rc.Rules {
+			if action != Allow {
+				rp.Config.Rules[rule] = action
+			}
+		}
+
+		action := rc.BannedTermAction
+		if len(action) == 0 {
+			action = AllowButRedact
+		}
+
+		for _, term := range rc.BannedTerms {
+			if len(term) == 0 {
+				continue
+			}
+
+			rp.RegexConfig.RegularExpressionRules = append(rp.RegexConfig.RegularExpressionRules, &RegularExpressionRule{
+				Name:       term,
+				Definition: "(?i)" + regexp.QuoteMeta(term),
+				Action:     action,
+			})
+		}
+	}
+
+	// phrases allowed in requests are allowed in responses too
+	if p.Config != nil {
+		rp.Config.Allowlist = p.Config.Allowlist
+	}
+
+	if len(rp.Config.Rules) == 0 && len(rp.RegexConfig.RegularExpressionRules) == 0 && rp.GuardrailConfig == nil {
+		return nil
+	}
+
+	return rp
+}
+
+// InspectsResponses reports whether responses are inspected under the policy.
+func (p *Policy) InspectsResponses() bool {
+	return p.responsePolicy() != nil
+}
+
+// InspectResponse scans the texts of a response. Updated holds the texts with
+// redactions applied, and Action what should happen to the response.
+func (p *Policy) InspectResponse(texts []string, scanner Scanner, mod Moderator, log *zap.Logger) (*ScanResult, error) {
+	rp := p.responsePolicy()
+	if rp == nil {
+		return &ScanResult{Action: Allow, Updated: texts}, nil
+	}
+
+	if scanner == nil {
+		rp.Config = nil
+	}
+
+	return rp.scan(texts, scanner, nil, mod, log)
+}
+
+// responseTextKeys are the fields that carry generated text in the response
+// formats of the supported providers. Ids, roles and model names are left out
+// of inspection.
+var responseTextKeys = map[string]bool{
+	"content":    true,
+	"text":       true,
+	"completion": true,
+	"refusal":    true,
+	"arguments":  true,
+}
+
+// walkResponseText calls f with every string under a text key of v, and puts
+// back what f returns.
+func walkResponseText(v any, inText bool, f func(string) string) any {
+	switch converted := v.(type) {
+	case string:
+		if inText {
+			return f(converted)
+		}
+	case []any:
+		for i := range converted {
+			converted[i] = walkResponseText(converted[i], inText, f)
+		}
+	case map[string]any:
+		for k := range converted {
+			converted[k] = walkResponseText(converted[k], responseTextKeys[k], f)
+		}
+	}
+
+	return v
+}
+
+// InspectResponseBody scans the generated text of a JSON response body at
+// once and returns the body with redactions applied. Bodies that are not JSON
+// are returned as is.
+func (p *Policy) InspectResponseBody(body []byte, scanner Scanner, mod Moderator, log *zap.Logger) ([]byte, *ScanResult, error) {
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return body, &ScanResult{Action: Allow}, nil
+	}
+
+	texts := []string{}
+	walkResponseText(v, false, func(text string) string {
+		texts = append(texts, text)
+		return text
+	})
+
+	sr, err := p.InspectResponse(texts, scanner, mod, log)
+	if err != nil {
+		return body, nil, err
+	}
+
+	if sr.Action == Block || len(sr.Updated) != len(texts) {
+		return body, sr, nil
+	}
+
+	// map iteration order is not stable, so texts are put back by value
+	// rather than by position.
+	replacements := map[string]string{}
+	for i, text := range texts {
+		if sr.Updated[i] != text {
+			replacements[text] = sr.Updated[i]
+		}
+	}
+
+	if len(replacements) == 0 {
+		return body, sr, nil
+	}
+
+	data, err := json.Marshal(walkResponseText(v, false, func(text string) string {
+		if updated, ok := replacements[text]; ok {
+			return updated
+		}
+
+		return text
+	}))
+	if err != nil {
+		return body, nil, err
+	}
+
+	return data, sr, nil
+}
+
+// Rules lists every rule that matched in the scan.
+func (sr *ScanResult) Rules() []string {
+	if sr == nil {
+		return nil
+	}
+
+	matched := append(sr.blockedRules(), sr.warnedRules()...)
+	return append(matched, sr.RedactedRules...)
+}
@@ -0,0 +1,407 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// SchemaViolation is the rule reported for responses that do not match the
+// output schema of their policy.
+const SchemaViolation = "schema_violation"
+
+const maxSchemaRetries = 5
+
+// SchemaConfig makes the policy check that the text of chat completion
+// responses is JSON matching Schema, which protects the parsers of clients
+// using JSON mode from malformed output. A response that does not match is
+// sent back to the model with a corrective system message up to MaxRetries
+// times. When it still does not match, OnFailure decides between returning a
+// structured error with block and returning it anyway with allow_but_warn.
+//
+// Schema is a JSON Schema supporting type, enum, const, properties, required,
+// additionalProperties, items, minItems, maxItems, minLength, maxLength,
+// pattern, minimum, maximum, exclusiveMinimum, exclusiveMaximum, anyOf and
+// allOf. Other keywords are ignored.
+type SchemaConfig struct {
+	Schema     map[string]any `json:"schema"`
+	MaxRetries int            `json:"maxRetries"`
+	OnFailure  Action         `json:"onFailure"`
+}
+
+// Enabled reports whether responses are checked against the schema.
+func (sc *SchemaConfig) Enabled() bool {
+	return sc != nil && len(sc.Schema) != 0
+}
+
+func (sc *SchemaConfig) validate() []string {
+	if sc == nil {
+		return nil
+	}
+
+	msgs := []string{}
+	if len(sc.Schema) != 0 {
+		if err := checkSchema(sc.Schema, "schema"); err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+
+	if sc.MaxRetries < 0 || sc.MaxRetries > maxSchemaRetries {
+		msgs = append(msgs, fmt.Sprintf("schema maxRetries must be between 0 and %d", maxSchemaRetries))
+	}
+
+	switch sc.OnFailure {
+	case "", AllowButWarn, Block:
+	default:
+		msgs = append(msgs, "schema on failure must be one of allow_but_warn or block")
+	}
+
+	return msgs
+}
+
+// FailureAction returns what happens to a response that still does not match
+// once retries run out.
+func (sc *SchemaConfig) FailureAction() Action {
+	if len(sc.OnFailure) == 0 {
+		return Block
+	}
+
+	return sc.OnFailure
+}
+
+// Check returns how text fails to match the schema, or nothing when it does.
+func (sc *SchemaConfig) Check(text string) []string {
+	var v any
+	if err := json.Unmarshal([]byte(text), &v); err != nil {
+		return []string{"response is not valid JSON"}
+	}
+
+	return matchSchema(sc.Schema, v, "$")
+}
+
+// CorrectiveMessage is the system message a response that does not match the
+// schema is sent back to the model with.
+func (sc *SchemaConfig) CorrectiveMessage(violations []string) string {
+	schema, _ := json.Marshal(sc.Schema)
+	return fmt.Sprintf("Your previous response did not match the required JSON schema: %s. Respond again with only a JSON document matching this schema: %s", strings.Join(violations, "; "), schema)
+}
+
+var schemaTypes = map[string]bool{
+	"object":  true,
+	"array":   true,
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"boolean": true,
+	"null":    true,
+}
+
+// checkSchema reports the first keyword of schema that cannot be applied.
+func checkSchema(schema map[string]any, path string) error {
+	switch t := schema["type"].(type) {
+	case nil:
+	case string:
+		if !schemaTypes[t] {
+			return fmt.Errorf("%s has unknown type %s", path, t)
+		}
+	case []any:
+		for _, v := range t {
+			if s, ok := v.(string); !ok || !schemaTypes[s] {
+				return fmt.Errorf("%s has unknown type %v", path, v)
+			}
+		}
+	default:
+		return fmt.Errorf("%s type must be a string or an array of strings", path)
+	}
+
+	if raw, ok := schema["properties"]; ok {
+		props, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s properties must be an object", path)
+		}
+
+		for name, prop := range props {
+			sub, ok := prop.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s.properties.%s must be a schema", path, name)
+			}
+
+			if err := checkSchema(sub, path+".properties."+name); err != nil {
+				return err
+			}
+		}
+	}
+
+	if raw, ok := schema["required"]; ok {
+		required, ok := raw.([]any)
+		if !ok {
+			return fmt.Errorf("%s required must be an array of strings", path)
+		}
+
+		for _, name := range required {
+			if _, ok := name.(string); !ok {
+				return fmt.Errorf("%s required must be an array of strings", path)
+			}
+		}
+	}
+
+	for _, keyword := range []string{"items", "additionalProperties"} {
+		switch sub := schema[keyword].(type) {
+		case nil, bool:
+		case map[string]any:
+			if err := checkSchema(sub, path+"."+keyword); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s %s must be a schema", path, keyword)
+		}
+	}
+
+	for _, keyword := range []string{"anyOf", "allOf"} {
+		raw, ok := schema[keyword]
+		if !ok {
+			continue
+		}
+
+		subs, ok := raw.([]any)
+		if !ok || len(subs) == 0 {
+			return fmt.Errorf("%s %s must be a non empty array of schemas", path, keyword)
+		}
+
+		for i, s := range subs {
+			sub, ok := s.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s.%s.%d must be a schema", path, keyword, i)
+			}
+
+			if err := checkSchema(sub, fmt.Sprintf("%s.%s.%d", path, keyword, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if raw, ok := schema["enum"]; ok {
+		if _, ok := raw.([]any); !ok {
+			return fmt.Errorf("%s enum must be an array", path)
+		}
+	}
+
+	for _, keyword := range []string{"minItems", "maxItems", "minLength", "maxLength", "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum"} {
+		if raw, ok := schema[keyword]; ok {
+			if _, ok := raw.(float64); !ok {
+				return fmt.Errorf("%s %s must be a number", path, keyword)
+			}
+		}
+	}
+
+	if raw, ok := schema["pattern"]; ok {
+		pattern, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("%s pattern must be a string", path)
+		}
+
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("%s pattern is not a valid regular expression", path)
+		}
+	}
+
+	return nil
+}
+
+func typeOf(v any) string {
+	switch n := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if n == math.Trunc(n) {
+			return "integer"
+		}
+
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+
+	return "unknown"
+}
+
+func hasType(v any, t string) bool {
+	actual := typeOf(v)
+	return actual == t || (t == "number" && actual == "integer")
+}
+
+func number(schema map[string]any, keyword string) (float64, bool) {
+	n, ok := schema[keyword].(float64)
+	return n, ok
+}
+
+// matchSchema returns how v fails to match schema, with the JSON path of each
+// failure.
+func matchSchema(schema map[string]any, v any, path string) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		if !hasType(v, t) {
+			return []string{fmt.Sprintf("%s must be of type %s", path, t)}
+		}
+	case []any:
+		matched := false
+		for _, s := range t {
+			if name, ok := s.(string); ok && hasType(v, name) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return []string{fmt.Sprintf("%s must be of type %v", path, t)}
+		}
+	}
+
+	violations := []string{}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, v) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			violations = append(violations, fmt.Sprintf("%s must be one of %v", path, enum))
+		}
+	}
+
+	if c, ok := schema["const"]; ok && !jsonEqual(c, v) {
+		violations = append(violations, fmt.Sprintf("%s must be %v", path, c))
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				name, _ := r.(string)
+				if _, ok := val[name]; !ok {
+					violations = append(violations, fmt.Sprintf("%s.%s is required", path, name))
+				}
+			}
+		}
+
+		names := make([]string, 0, len(val))
+		for name := range val {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			field := val[name]
+			if sub, ok := props[name].(map[string]any); ok {
+				violations = append(violations, matchSchema(sub, field, path+"."+name)...)
+				continue
+			}
+
+			switch ap := schema["additionalProperties"].(type) {
+			case bool:
+				if !ap {
+					violations = append(violations, fmt.Sprintf("%s.%s is not allowed", path, name))
+				}
+			case map[string]any:
+				violations = append(violations, matchSchema(ap, field, path+"."+name)...)
+			}
+		}
+	case []any:
+		if min, ok := number(schema, "minItems"); ok && float64(len(val)) < min {
+			violations = append(violations, fmt.Sprintf("%s must have at least %v items", path, min))
+		}
+
+		if max, ok := number(schema, "maxItems"); ok && float64(len(val)) > max {
+			violations = append(violations, fmt.Sprintf("%s must have at most %v items", path, max))
+		}
+
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range val {
+				violations = append(violations, matchSchema(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(val))
+		if min, ok := number(schema, "minLength"); ok && length < min {
+			violations = append(violations, fmt.Sprintf("%s must be at least %v characters long", path, min))
+		}
+
+		if max, ok := number(schema, "maxLength"); ok && length > max {
+			violations = append(violations, fmt.Sprintf("%s must be at most %v characters long", path, max))
+		}
+
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(val) {
+				violations = append(violations, fmt.Sprintf("%s must match %s", path, pattern))
+			}
+		}
+	case float64:
+		if min, ok := number(schema, "minimum"); ok && val < min {
+			violations = append(violations, fmt.Sprintf("%s must be at least %v", path, min))
+		}
+
+		if max, ok := number(schema, "maximum"); ok && val > max {
+			violations = append(violations, fmt.Sprintf("%s must be at most %v", path, max))
+		}
+
+		if min, ok := number(schema, "exclusiveMinimum"); ok && val <= min {
+			violations = append(violations, fmt.Sprintf("%s must be greater than %v", path, min))
+		}
+
+		if max, ok := number(schema, "exclusiveMaximum"); ok && val >= max {
+			violations = append(violations, fmt.Sprintf("%s must be less than %v", path, max))
+		}
+	}
+
+	if subs, ok := schema["allOf"].([]any); ok {
+		for _, s := range subs {
+			if sub, ok := s.(map[string]any); ok {
+				violations = append(violations, matchSchema(sub, v, path)...)
+			}
+		}
+	}
+
+	if subs, ok := schema["anyOf"].([]any); ok {
+		matched := false
+		for _, s := range subs {
+			if sub, ok := s.(map[string]any); ok && len(matchSchema(sub, v, path)) == 0 {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			violations = append(violations, fmt.Sprintf("%s must match at least one schema of anyOf", path))
+		}
+	}
+
+	return violations
+}
+
+func jsonEqual(a, b any) bool {
+	ad, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+
+	bd, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+
+	return string(ad) == string(bd)
+}
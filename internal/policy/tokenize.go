@@ -0,0 +1,163 @@
+package policy
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+var (
+	tokenRegex       = regexp.MustCompile(`\[pii:[a-z_]+:[0-9a-f]{32}\]`)
+	tokenKindRegex   = regexp.MustCompile(`^[a-z_]+$`)
+	tokenPrefixRegex = regexp.MustCompile(`^\[(p(i(i(:[a-z_]*(:[0-9a-f]{0,32})?)?)?)?)?$`)
+)
+
+// tokenSecret keys the tokens of every policy. Without it, anyone who sees a
+// token, the model provider included, could hash guesses of low entropy
+// values such as phone numbers until one matches.
+var tokenSecret []byte
+
+// SetTokenSecret sets the secret tokens are keyed with. Tokens only stay the
+// same across restarts and gateways that share the secret.
+func SetTokenSecret(secret []byte) {
+	tokenSecret = secret
+}
+
+// TokenSecretSet reports whether a token secret is set. Policies can not
+// tokenize without one.
+func TokenSecretSet() bool {
+	return len(tokenSecret) != 0
+}
+
+// VaultToken is a token kept in the token vault, with the value it stands for
+// encrypted.
+type VaultToken struct {
+	Token     string
+	Value     string
+	CreatedAt int64
+}
+
+// token returns the token that replaces value under the policy. Tokens are
+// deterministic, so that the same value gets the same token in every turn of a
+// conversation, scoped to the policy like hashes are and keyed with the token
+// secret. They carry 128 bits of the HMAC so that tokens of different values
+// do not collide in a large vault.
+func (p *Policy) token(kind, value string) string {
+	if !tokenKindRegex.MatchString(kind) {
+		kind = "regex"
+	}
+
+	mac := hmac.New(sha256.New, tokenSecret)
+	mac.Write([]byte(p.Id + ":token:" + value))
+	sum := mac.Sum(nil)
+
+	return "[pii:" + kind + ":" + hex.EncodeToString(sum[:16]) + "]"
+}
+
+// tokenize returns the token of value and remembers what it stands for, so
+// that it can be put back in the response.
+func (sr *ScanResult) tokenize(p *Policy, kind, value string) string {
+	token := p.token(kind, value)
+	if sr.Tokens == nil {
+		sr.Tokens = map[string]string{}
+	}
+
+	sr.Tokens[token] = value
+
+	return token
+}
+
+// FindTokens returns the distinct tokens in text.
+func FindTokens(text string) []string {
+	found := []string{}
+	seen := map[string]bool{}
+	for _, token := range tokenRegex.FindAllString(text, -1) {
+		if !seen[token] {
+			seen[token] = true
+			found = append(found, token)
+		}
+	}
+
+	return found
+}
+
+// Detokenize puts the values of the tokens in text back. Tokens without a
+// value are left as they are.
+func Detokenize(text string, values map[string]string) string {
+	if len(values) == 0 || !strings.Contains(text, "[pii:") {
+		return text
+	}
+
+	return tokenRegex.ReplaceAllStringFunc(text, func(token string) string {
+		if value, ok := values[token]; ok {
+			return value
+		}
+
+		return token
+	})
+}
+
+// PendingToken reports whether text ends with what could be the beginning of
+// a token, so that a streamed response is not sent on in the middle of one.
+func PendingToken(text string) bool {
+	idx := strings.LastIndex(text, "[")
+	if idx < 0 {
+		return false
+	}
+
+	return tokenPrefixRegex.MatchString(text[idx:])
+}
+
+// DetokenizeResponseBody puts the values of the tokens in the generated text
+// of a JSON response body back. Bodies that are not JSON are returned as is.
+func DetokenizeResponseBody(body []byte, values map[string]string) ([]byte, error) {
+	if len(values) == 0 || !bytes.Contains(body, []byte("[pii:")) {
+		return body, nil
+	}
+
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return body, nil
+	}
+
+	return json.Marshal(walkResponseText(v, false, func(text string) string {
+		return Detokenize(text, values)
+	}))
+}
+
+// Tokenizes reports whether any rule of the policy tokenizes what it matches,
+// in which case tokens in responses are put back.
+func (p *Policy) Tokenizes() bool {
+	if p == nil {
+		return false
+	}
+
+	return tokenizes(p.Config, p.RegexConfig)
+}
+
+func tokenizes(c *Config, rc *RegexConfig) bool {
+	if c != nil {
+		for _, action := range c.Rules {
+			if action == Tokenize {
+				return true
+			}
+		}
+	}
+
+	if rc != nil {
+		for _, rule := range rc.RegularExpressionRules {
+			if rule != nil && rule.Action == Tokenize {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,68 @@
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestTokenIsKeyedAndDeterministicPerPolicy(t *testing.T) {
+	SetTokenSecret([]byte("test-secret"))
+	defer SetTokenSecret(nil)
+
+	p := &Policy{Id: "policy-a"}
+	other := &Policy{Id: "policy-b"}
+	value := "123-45-6789"
+
+	token := p.token("ssn", value)
+	if token != p.token("ssn", value) {
+		t.Fatalf("expected the same value to get the same token under a policy")
+	}
+
+	if token == other.token("ssn", value) {
+		t.Fatalf("expected tokens to differ between policies")
+	}
+
+	for _, input := range []string{p.Id + ":token:" + value, value} {
+		sum := sha256.Sum256([]byte(input))
+		if strings.Contains(token, hex.EncodeToString(sum[:8])) {
+			t.Fatalf("expected token %s not to be the bare hash of %q", token, input)
+		}
+	}
+
+	SetTokenSecret([]byte("another-secret"))
+	if token == p.token("ssn", value) {
+		t.Fatalf("expected tokens to depend on the token secret")
+	}
+}
+
+func TestTokenCarriesHalfOfTheMac(t *testing.T) {
+	SetTokenSecret([]byte("test-secret"))
+	defer SetTokenSecret(nil)
+
+	token := (&Policy{Id: "policy-a"}).token("email", "someone@example.com")
+	if !tokenRegex.MatchString(token) || len(token) != len("[pii:email:]")+32 {
+		t.Fatalf("expected a token with 32 hex digits, got: %s", token)
+	}
+
+	if found := FindTokens("reach me at " + token); len(found) != 1 || found[0] != token {
+		t.Fatalf("expected the token to be found in text, got: %v", found)
+	}
+}
+
+func TestValidateRejectsTokenizeWithoutSecret(t *testing.T) {
+	p := &Policy{Config: &Config{Rules: map[Rule]Action{"email": Tokenize}}}
+
+	SetTokenSecret(nil)
+	if err := p.Validate(); err == nil {
+		t.Fatalf("expected a policy that tokenizes to be invalid without a token secret")
+	}
+
+	SetTokenSecret([]byte("test-secret"))
+	defer SetTokenSecret(nil)
+
+	if err := p.Validate(); err != nil {
+		t.Fatalf("expected a policy that tokenizes to be valid with a token secret, got: %v", err)
+	}
+}
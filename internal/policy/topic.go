@@ -0,0 +1,176 @@
+package policy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const defaultTopicThreshold = 0.8
+
+// Embedder turns input into embeddings with the given model, one for every
+// text of input and in the same order. An empty model is the default one of
+// the embedder. With cache, the embeddings are kept for the next time the same
+// texts are embedded, which is meant for exemplars rather than for requests.
+type Embedder interface {
+	Embed(model string, input []string, cache bool) ([][]float64, error)
+}
+
+// BannedTopic is a subject requests are not allowed to discuss, described by
+// exemplars of prompts about it. A request is about the topic once any of its
+// texts is at least as similar to an exemplar as the threshold.
+type BannedTopic struct {
+	Name      string   `json:"name"`
+	Exemplars []string `json:"exemplars"`
+	Threshold float64  `json:"threshold"`
+	Action    Action   `json:"action"`
+}
+
+func (bt *BannedTopic) threshold() float64 {
+	if bt.Threshold != 0 {
+		return bt.Threshold
+	}
+
+	return defaultTopicThreshold
+}
+
+// action returns what happens to a request about the topic. Topics without an
+// action block the request.
+func (bt *BannedTopic) action() Action {
+	if len(bt.Action) != 0 {
+		return bt.Action
+	}
+
+	return Block
+}
+
+// TopicConfig compares the embeddings of requests with the embeddings of the
+// exemplars of banned topics, which catches paraphrases that regular
+// expressions miss. Without topics, nothing is embedded.
+type TopicConfig struct {
+	Model  string         `json:"model"`
+	Topics []*BannedTopic `json:"topics"`
+}
+
+func (tc *TopicConfig) enabled() bool {
+	return tc != nil && len(tc.Topics) != 0
+}
+
+func (tc *TopicConfig) validate() []string {
+	if tc == nil {
+		return nil
+	}
+
+	msgs := []string{}
+	names := map[string]bool{}
+	for idx, topic := range tc.Topics {
+		if topic == nil || len(strings.TrimSpace(topic.Name)) == 0 {
+			msgs = append(msgs, fmt.Sprintf("banned topic at index [%d] must have a name", idx))
+			continue
+		}
+
+		if names[topic.Name] {
+			msgs = append(msgs, fmt.Sprintf("banned topic %s is defined more than once", topic.Name))
+		}
+
+		names[topic.Name] = true
+
+		if len(topic.Exemplars) == 0 {
+			msgs = append(msgs, fmt.Sprintf("banned topic %s must have exemplars", topic.Name))
+		}
+
+		for eidx, exemplar := range topic.Exemplars {
+			if len(strings.TrimSpace(exemplar)) == 0 {
+				msgs = append(msgs, fmt.Sprintf("banned topic %s exemplar at index [%d] cannot be empty", topic.Name, eidx))
+			}
+		}
+
+		if topic.Threshold < 0 || topic.Threshold > 1 {
+			msgs = append(msgs, fmt.Sprintf("banned topic %s threshold must be between 0 and 1", topic.Name))
+		}
+
+		if len(topic.Action) != 0 && topic.Action != AllowButWarn && topic.Action != Block {
+			msgs = append(msgs, fmt.Sprintf("banned topic %s action must be one of allow_but_warn or block", topic.Name))
+		}
+	}
+
+	return msgs
+}
+
+// exemplars returns the exemplars of every topic, in the order of the topics.
+func (tc *TopicConfig) exemplars() []string {
+	exemplars := []string{}
+	for _, topic := range tc.Topics {
+		if topic == nil {
+			continue
+		}
+
+		exemplars = append(exemplars, topic.Exemplars...)
+	}
+
+	return exemplars
+}
+
+// matched returns the topics that block and warn, reported as
+// "topic:<name>", given the embeddings of the input and of the exemplars of
+// tc.
+func (tc *TopicConfig) matched(input [][]float64, exemplars [][]float64) ([]string, []string) {
+	blocked, warned := []string{}, []string{}
+
+	offset := 0
+	for _, topic := range tc.Topics {
+		if topic == nil {
+			continue
+		}
+
+		end := min(offset+len(topic.Exemplars), len(exemplars))
+		if similarity(input, exemplars[offset:end]) >= topic.threshold() {
+			switch topic.action() {
+			case Block:
+				blocked = append(blocked, "topic:"+topic.Name)
+			case AllowButWarn:
+				warned = append(warned, "topic:"+topic.Name)
+			}
+		}
+
+		offset = end
+	}
+
+	sort.Strings(blocked)
+	sort.Strings(warned)
+
+	return blocked, warned
+}
+
+// similarity returns the highest cosine similarity between any embedding of
+// input and any exemplar.
+func similarity(input [][]float64, exemplars [][]float64) float64 {
+	highest := 0.0
+	for _, a := range input {
+		for _, b := range exemplars {
+			highest = max(highest, cosine(a, b))
+		}
+	}
+
+	return highest
+}
+
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	dot, na, nb := 0.0, 0.0, 0.0
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+
+	if na == 0 || nb == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
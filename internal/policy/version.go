@@ -0,0 +1,69 @@
+package policy
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+// PolicyVersion is a snapshot of a policy at one of its versions. Versions are
+// never changed once stored; rolling back makes a new version with the config
+// of an older one.
+type PolicyVersion struct {
+	PolicyId  string  `json:"policyId"`
+	Version   int     `json:"version"`
+	Policy    *Policy `json:"policy"`
+	CreatedAt int64   `json:"createdAt"`
+}
+
+// Rollout serves the current version of a policy to Percentage percent of
+// requests, and BaseVersion to the rest, so that a new version can be compared
+// with the one it replaces before it applies to every request. Requests of a
+// user stick to one version.
+type Rollout struct {
+	BaseVersion int     `json:"baseVersion"`
+	Percentage  float64 `json:"percentage"`
+}
+
+// UpdateRollout starts, changes or ends the rollout of the current version of
+// a policy. BaseVersion defaults to the version before the current one, and
+// a percentage of 100 promotes the current version to every request.
+type UpdateRollout struct {
+	BaseVersion int     `json:"baseVersion"`
+	Percentage  float64 `json:"percentage"`
+}
+
+func (ur *UpdateRollout) Validate(current int) error {
+	if ur.Percentage < 0 || ur.Percentage > 100 {
+		return internal_errors.NewValidationError("rollout percentage must be between 0 and 100")
+	}
+
+	if ur.BaseVersion < 0 || ur.BaseVersion >= current {
+		return internal_errors.NewValidationError(fmt.Sprintf("rollout base version must be older than the current version %d", current))
+	}
+
+	return nil
+}
+
+// Select returns the version of p that applies to a request of userId: the
+// current one unless a rollout holds the request back on its base version.
+func (p *Policy) Select(userId string) int {
+	if p.Rollout == nil || p.Rollout.BaseVersion == 0 {
+		return p.Version
+	}
+
+	roll := rand.Float64() * 100
+	if len(userId) != 0 {
+		h := fnv.New32a()
+		h.Write([]byte(fmt.Sprintf("%s/%d/%s", p.Id, p.Version, userId)))
+		roll = float64(h.Sum32()%10000) / 100
+	}
+
+	if roll < p.Rollout.Percentage {
+		return p.Version
+	}
+
+	return p.Rollout.BaseVersion
+}
@@ -23,11 +23,12 @@ type Message struct {
 
 type MessagesRequest struct {
 	Model         string    `json:"model"`
+	System        string    `json:"system,omitempty"`
 	Messages      []Message `json:"messages"`
 	MaxTokens     int       `json:"max_tokens"`
 	StopSequences []string  `json:"stop_sequences,omitempty"`
 	Temperature   float32   `json:"temperature,omitempty"`
-	TopP          int       `json:"top_p,omitempty"`
+	TopP          float32   `json:"top_p,omitempty"`
 	TopK          int       `json:"top_k,omitempty"`
 	Metadata      *Metadata `json:"metadata,omitempty"`
 	Stream        bool      `json:"stream,omitempty"`
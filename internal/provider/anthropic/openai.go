@@ -0,0 +1,169 @@
+package anthropic
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// DefaultMaxTokens is sent to Anthropic when an OpenAI-compatible request does
+// not limit completion tokens, since Anthropic requires a limit.
+const DefaultMaxTokens = 4096
+
+func contentOf(m goopenai.ChatCompletionMessage) (string, error) {
+	if len(m.MultiContent) == 0 {
+		return m.Content, nil
+	}
+
+	texts := []string{}
+	for _, part := range m.MultiContent {
+		if part.Type != goopenai.ChatMessagePartTypeText {
+			return "", errors.New("only text content is supported by anthropic chat completions")
+		}
+
+		texts = append(texts, part.Text)
+	}
+
+	return strings.Join(texts, "\n"), nil
+}
+
+// FromChatCompletionRequest converts an OpenAI chat completion request into an
+// Anthropic messages request. System messages are moved into the system prompt
+// and consecutive messages of the same role are merged since Anthropic requires
+// roles to alternate.
+func FromChatCompletionRequest(r *goopenai.ChatCompletionRequest) (*MessagesRequest, error) {
+	if len(r.Tools) != 0 || len(r.Functions) != 0 {
+		return nil, errors.New("tools are not supported by anthropic chat completions")
+	}
+
+	if r.N > 1 {
+		return nil, errors.New("n larger than 1 is not supported by anthropic chat completions")
+	}
+
+	mr := &MessagesRequest{
+		Model:         r.Model,
+		MaxTokens:     r.MaxCompletionTokens,
+		StopSequences: r.Stop,
+		Temperature:   r.Temperature,
+		TopP:          r.TopP,
+		Stream:        r.Stream,
+		Messages:      []Message{},
+	}
+
+	if mr.MaxTokens == 0 {
+		mr.MaxTokens = r.MaxTokens
+	}
+
+	if mr.MaxTokens == 0 {
+		mr.MaxTokens = DefaultMaxTokens
+	}
+
+	if len(r.User) != 0 {
+		mr.Metadata = &Metadata{
+			UserId: r.User,
+		}
+	}
+
+	system := []string{}
+	for _, m := range r.Messages {
+		content, err := contentOf(m)
+		if err != nil {
+			return nil, err
+		}
+
+		switch m.Role {
+		case goopenai.ChatMessageRoleSystem, "developer":
+			system = append(system, content)
+			continue
+		case goopenai.ChatMessageRoleUser, goopenai.ChatMessageRoleAssistant:
+		default:
+			return nil, errors.New("message role " + m.Role + " is not supported by anthropic chat completions")
+		}
+
+		if last := len(mr.Messages) - 1; last >= 0 && mr.Messages[last].Role == m.Role {
+			mr.Messages[last].Content += "\n\n" + content
+			continue
+		}
+
+		mr.Messages = append(mr.Messages, Message{
+			Role:    m.Role,
+			Content: content,
+		})
+	}
+
+	mr.System = strings.Join(system, "\n\n")
+
+	if len(mr.Messages) == 0 {
+		return nil, errors.New("at least one user or assistant message is required")
+	}
+
+	return mr, nil
+}
+
+// FinishReasonOf maps Anthropic stop reasons onto OpenAI finish reasons.
+func FinishReasonOf(stopReason string) goopenai.FinishReason {
+	switch stopReason {
+	case "":
+		return ""
+	case "max_tokens":
+		return goopenai.FinishReasonLength
+	case "tool_use":
+		return goopenai.FinishReasonToolCalls
+	default:
+		return goopenai.FinishReasonStop
+	}
+}
+
+// ToChatCompletionResponse converts an Anthropic messages response into the
+// OpenAI chat completion shape.
+func ToChatCompletionResponse(mr *MessagesResponse) *goopenai.ChatCompletionResponse {
+	texts := []string{}
+	for _, content := range mr.Content {
+		if content.Type == "text" {
+			texts = append(texts, content.Text)
+		}
+	}
+
+	return &goopenai.ChatCompletionResponse{
+		ID:      mr.Id,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   mr.Model,
+		Choices: []goopenai.ChatCompletionChoice{
+			{
+				Message: goopenai.ChatCompletionMessage{
+					Role:    goopenai.ChatMessageRoleAssistant,
+					Content: strings.Join(texts, ""),
+				},
+				FinishReason: FinishReasonOf(mr.StopReason),
+			},
+		},
+		Usage: goopenai.Usage{
+			PromptTokens:     mr.Usage.InputTokens,
+			CompletionTokens: mr.Usage.OutputTokens,
+			TotalTokens:      mr.Usage.InputTokens + mr.Usage.OutputTokens,
+		},
+	}
+}
+
+// ToChatCompletionErrorResponse converts an Anthropic error into the OpenAI
+// error shape.
+func ToChatCompletionErrorResponse(er *ErrorResponse) *goopenai.ErrorResponse {
+	if er == nil || er.Error == nil {
+		return &goopenai.ErrorResponse{
+			Error: &goopenai.APIError{
+				Type:    "api_error",
+				Message: "unknown anthropic error",
+			},
+		}
+	}
+
+	return &goopenai.ErrorResponse{
+		Error: &goopenai.APIError{
+			Type:    er.Error.Type,
+			Message: er.Error.Message,
+		},
+	}
+}
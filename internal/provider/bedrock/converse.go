@@ -0,0 +1,175 @@
+package bedrock
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// FromChatCompletionRequest translates an OpenAI chat completion request into a
+// Converse request. System and developer messages become system prompts and
+// consecutive messages of the same role are merged, since Converse requires
+// user and assistant turns to alternate. Only text content is supported.
+func FromChatCompletionRequest(ccr *goopenai.ChatCompletionRequest) (*bedrockruntime.ConverseInput, error) {
+	if len(ccr.Model) == 0 {
+		return nil, errors.New("model is required")
+	}
+
+	if len(ccr.Tools) != 0 || len(ccr.Functions) != 0 {
+		return nil, errors.New("tools are not supported for bedrock chat completions")
+	}
+
+	if ccr.N > 1 {
+		return nil, errors.New("n greater than 1 is not supported for bedrock chat completions")
+	}
+
+	input := &bedrockruntime.ConverseInput{
+		ModelId: aws.String(ccr.Model),
+	}
+
+	for i, msg := range ccr.Messages {
+		text, err := textOf(msg)
+		if err != nil {
+			return nil, fmt.Errorf("messages[%d]: %w", i, err)
+		}
+
+		var role types.ConversationRole
+		switch msg.Role {
+		case goopenai.ChatMessageRoleSystem, "developer":
+			input.System = append(input.System, &types.SystemContentBlockMemberText{Value: text})
+			continue
+		case goopenai.ChatMessageRoleUser:
+			role = types.ConversationRoleUser
+		case goopenai.ChatMessageRoleAssistant:
+			role = types.ConversationRoleAssistant
+		default:
+			return nil, fmt.Errorf("messages[%d]: role %s is not supported for bedrock chat completions", i, msg.Role)
+		}
+
+		block := &types.ContentBlockMemberText{Value: text}
+		if last := len(input.Messages) - 1; last >= 0 && input.Messages[last].Role == role {
+			input.Messages[last].Content = append(input.Messages[last].Content, block)
+			continue
+		}
+
+		input.Messages = append(input.Messages, types.Message{
+			Role:    role,
+			Content: []types.ContentBlock{block},
+		})
+	}
+
+	if len(input.Messages) == 0 {
+		return nil, errors.New("at least one user or assistant message is required")
+	}
+
+	cfg := &types.InferenceConfiguration{}
+	if ccr.MaxTokens > 0 {
+		cfg.MaxTokens = aws.Int32(int32(ccr.MaxTokens))
+	}
+
+	if ccr.MaxCompletionTokens > 0 {
+		cfg.MaxTokens = aws.Int32(int32(ccr.MaxCompletionTokens))
+	}
+
+	if ccr.Temperature != 0 {
+		cfg.Temperature = aws.Float32(ccr.Temperature)
+	}
+
+	if ccr.TopP != 0 {
+		cfg.TopP = aws.Float32(ccr.TopP)
+	}
+
+	if len(ccr.Stop) != 0 {
+		cfg.StopSequences = ccr.Stop
+	}
+
+	input.InferenceConfig = cfg
+
+	return input, nil
+}
+
+func textOf(msg goopenai.ChatCompletionMessage) (string, error) {
+	if len(msg.MultiContent) == 0 {
+		return msg.Content, nil
+	}
+
+	parts := []string{}
+	for _, part := range msg.MultiContent {
+		if part.Type != goopenai.ChatMessagePartTypeText {
+			return "", fmt.Errorf("content part type %s is not supported for bedrock chat completions", part.Type)
+		}
+
+		parts = append(parts, part.Text)
+	}
+
+	return strings.Join(parts, "\n"), nil
+}
+
+// ToStreamInput reuses a translated request for ConverseStream.
+func ToStreamInput(input *bedrockruntime.ConverseInput) *bedrockruntime.ConverseStreamInput {
+	return &bedrockruntime.ConverseStreamInput{
+		ModelId:         input.ModelId,
+		Messages:        input.Messages,
+		System:          input.System,
+		InferenceConfig: input.InferenceConfig,
+	}
+}
+
+func FinishReasonOf(reason types.StopReason) goopenai.FinishReason {
+	switch reason {
+	case types.StopReasonMaxTokens:
+		return goopenai.FinishReasonLength
+	case types.StopReasonToolUse:
+		return goopenai.FinishReasonToolCalls
+	case types.StopReasonGuardrailIntervened, types.StopReasonContentFiltered:
+		return goopenai.FinishReasonContentFilter
+	}
+
+	return goopenai.FinishReasonStop
+}
+
+// Usage converts Converse token usage. Missing counts are reported as zero.
+func Usage(u *types.TokenUsage) goopenai.Usage {
+	usage := goopenai.Usage{}
+	if u == nil {
+		return usage
+	}
+
+	usage.PromptTokens = int(aws.ToInt32(u.InputTokens))
+	usage.CompletionTokens = int(aws.ToInt32(u.OutputTokens))
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+
+	return usage
+}
+
+func ToChatCompletionResponse(id, model string, out *bedrockruntime.ConverseOutput) *goopenai.ChatCompletionResponse {
+	content := []string{}
+	if msg, ok := out.Output.(*types.ConverseOutputMemberMessage); ok {
+		for _, block := range msg.Value.Content {
+			if text, ok := block.(*types.ContentBlockMemberText); ok {
+				content = append(content, text.Value)
+			}
+		}
+	}
+
+	return &goopenai.ChatCompletionResponse{
+		ID:      id,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: []goopenai.ChatCompletionChoice{{
+			Message: goopenai.ChatCompletionMessage{
+				Role:    goopenai.ChatMessageRoleAssistant,
+				Content: strings.Join(content, ""),
+			},
+			FinishReason: FinishReasonOf(out.StopReason),
+		}},
+		Usage: Usage(out.Usage),
+	}
+}
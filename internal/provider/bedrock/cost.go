@@ -0,0 +1,85 @@
+package bedrock
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TitanPerMillionTokenCost holds on-demand prices of Amazon Titan text models.
+// Claude models are priced from the Anthropic cost map.
+var TitanPerMillionTokenCost = map[string]map[string]float64{
+	"prompt": {
+		"amazon.titan-text-lite":    0.15,
+		"amazon.titan-text-express": 0.2,
+		"amazon.titan-text-premier": 0.5,
+	},
+	"completion": {
+		"amazon.titan-text-lite":    0.2,
+		"amazon.titan-text-express": 0.6,
+		"amazon.titan-text-premier": 1.5,
+	},
+}
+
+// crossRegionPrefixes are the prefixes of cross-region inference profile ids
+// such as us.anthropic.claude-3-5-sonnet-20240620-v1:0.
+var crossRegionPrefixes = []string{"us.", "eu.", "apac."}
+
+type anthropicEstimator interface {
+	EstimateTotalCost(model string, promptTks, completionTks int) (float64, error)
+}
+
+type CostEstimator struct {
+	tokenCostMap map[string]map[string]float64
+	ae           anthropicEstimator
+}
+
+func NewCostEstimator(ae anthropicEstimator) *CostEstimator {
+	return &CostEstimator{
+		tokenCostMap: TitanPerMillionTokenCost,
+		ae:           ae,
+	}
+}
+
+// BaseModelId strips the cross-region inference profile prefix of a model id.
+func BaseModelId(model string) string {
+	for _, prefix := range crossRegionPrefixes {
+		if strings.HasPrefix(model, prefix) {
+			return strings.TrimPrefix(model, prefix)
+		}
+	}
+
+	return model
+}
+
+func (ce *CostEstimator) EstimateTotalCost(model string, promptTks, completionTks int) (float64, error) {
+	id := BaseModelId(model)
+
+	if strings.HasPrefix(id, "anthropic.") {
+		return ce.ae.EstimateTotalCost(strings.TrimPrefix(id, "anthropic."), promptTks, completionTks)
+	}
+
+	selected := selectTitanModel(id)
+	promptCost, ok := ce.tokenCostMap["prompt"][selected]
+	if !ok {
+		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
+	}
+
+	completionCost, ok := ce.tokenCostMap["completion"][selected]
+	if !ok {
+		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
+	}
+
+	return float64(promptTks)/1000000*promptCost + float64(completionTks)/1000000*completionCost, nil
+}
+
+// selectTitanModel maps versioned ids like amazon.titan-text-express-v1 to the
+// model family they are priced by.
+func selectTitanModel(id string) string {
+	for family := range TitanPerMillionTokenCost["prompt"] {
+		if strings.HasPrefix(id, family) {
+			return family
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,103 @@
+package cohere
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CoherePerMillionTokenCost holds prices of cohere models by model family.
+// Rerank models are billed per search unit rather than per token, so their
+// prices are per thousand searches.
+var CoherePerMillionTokenCost = map[string]map[string]float64{
+	"prompt": {
+		"command-a":      2.5,
+		"command-r-plus": 2.5,
+		"command-r7b":    0.0375,
+		"command-r":      0.15,
+		"command-light":  0.3,
+		"command":        1,
+	},
+	"completion": {
+		"command-a":      10,
+		"command-r-plus": 10,
+		"command-r7b":    0.15,
+		"command-r":      0.6,
+		"command-light":  0.6,
+		"command":        2,
+	},
+	"embeddings": {
+		"embed-v4.0":                    0.12,
+		"embed-english-v3.0":            0.1,
+		"embed-multilingual-v3.0":       0.1,
+		"embed-english-light-v3.0":      0.1,
+		"embed-multilingual-light-v3.0": 0.1,
+	},
+	"rerank": {
+		"rerank-v3.5":              2,
+		"rerank-english-v3.0":      2,
+		"rerank-multilingual-v3.0": 2,
+	},
+}
+
+type CostEstimator struct {
+	tokenCostMap map[string]map[string]float64
+}
+
+func NewCostEstimator() *CostEstimator {
+	return &CostEstimator{
+		tokenCostMap: CoherePerMillionTokenCost,
+	}
+}
+
+// selectModel returns the longest model family that model starts with, so
+// that command-r-plus-08-2024 is not priced as command-r.
+func selectModel(costMap map[string]float64, model string) string {
+	lowerCased := strings.ToLower(model)
+
+	selected := ""
+	for family := range costMap {
+		if strings.HasPrefix(lowerCased, family) && len(family) > len(selected) {
+			selected = family
+		}
+	}
+
+	return selected
+}
+
+func (ce *CostEstimator) EstimateTotalCost(model string, promptTks, completionTks int) (float64, error) {
+	selected := selectModel(ce.tokenCostMap["prompt"], model)
+
+	promptCost, ok := ce.tokenCostMap["prompt"][selected]
+	if !ok {
+		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
+	}
+
+	completionCost, ok := ce.tokenCostMap["completion"][selected]
+	if !ok {
+		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
+	}
+
+	return float64(promptTks)/1000000*promptCost + float64(completionTks)/1000000*completionCost, nil
+}
+
+func (ce *CostEstimator) EstimateEmbeddingsCost(model string, tks int) (float64, error) {
+	costMap := ce.tokenCostMap["embeddings"]
+
+	cost, ok := costMap[selectModel(costMap, model)]
+	if !ok {
+		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
+	}
+
+	return float64(tks) / 1000000 * cost, nil
+}
+
+func (ce *CostEstimator) EstimateRerankCost(model string, searches int) (float64, error) {
+	costMap := ce.tokenCostMap["rerank"]
+
+	cost, ok := costMap[selectModel(costMap, model)]
+	if !ok {
+		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
+	}
+
+	return float64(searches) / 1000 * cost, nil
+}
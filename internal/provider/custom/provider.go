@@ -19,6 +19,26 @@ type RouteConfig struct {
 	StreamEndWord                    string `json:"stream_end_word"`
 	StreamResponseCompletionLocation string `json:"stream_response_completion_location"`
 	StreamMaxEmptyMessages           int    `json:"stream_max_empty_messages"`
+
+	// StreamDelimiter prefixes the lines of a stream that carry data, which is
+	// "data:" for server sent events when it is not set. Streams are passed
+	// through to clients line by line as they are read, while the text at
+	// StreamResponseCompletionLocation of every data line is accumulated to
+	// estimate completion tokens when the upstream does not report them.
+	StreamDelimiter string `json:"stream_delimiter,omitempty"`
+
+	// RequestTemplate and ResponseTemplate map the bodies of routes whose
+	// upstream is not OpenAI shaped. They are Go templates executed with the
+	// request as clients send it and the response as the upstream returns it.
+	// Responses of streams are not mapped.
+	RequestTemplate  string `json:"request_template,omitempty"`
+	ResponseTemplate string `json:"response_template,omitempty"`
+
+	// PromptTokensLocation and CompletionTokensLocation point at the token
+	// counts that the upstream reports in its responses, which are used in
+	// place of counting the tokens of the prompt and completion.
+	PromptTokensLocation     string `json:"prompt_tokens_location,omitempty"`
+	CompletionTokensLocation string `json:"completion_tokens_location,omitempty"`
 }
 
 type UpdateProvider struct {
@@ -0,0 +1,94 @@
+package custom
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"text/template"
+
+	"github.com/tidwall/gjson"
+)
+
+// templateFuncs are available to request and response templates. json renders
+// a value as JSON, and path looks up a gjson path in a value, returning the
+// match as raw JSON.
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"path": func(p string, v any) (string, error) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+
+		result := gjson.GetBytes(data, p)
+		if !result.Exists() {
+			return "null", nil
+		}
+
+		return result.Raw, nil
+	},
+}
+
+var parsedTemplates sync.Map
+
+func parseTemplate(text string) (*template.Template, error) {
+	if t, ok := parsedTemplates.Load(text); ok {
+		return t.(*template.Template), nil
+	}
+
+	t, err := template.New("transform").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedTemplates.Store(text, t)
+
+	return t, nil
+}
+
+// ValidateTemplate reports whether text can be used as a request or response
+// template.
+func ValidateTemplate(text string) error {
+	_, err := parseTemplate(text)
+	return err
+}
+
+// Transform renders a template with a JSON body as its data, which maps the
+// body between the shape clients use and the one an upstream expects.
+func Transform(text string, body []byte) ([]byte, error) {
+	t, err := parseTemplate(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var data any
+	if len(bytes.TrimSpace(body)) != 0 {
+		if err := json.Unmarshal(body, &data); err != nil {
+			return nil, err
+		}
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// TokenCount reads a token count that an upstream reports at loc in body.
+func TokenCount(body []byte, loc string) (int, bool) {
+	if len(loc) == 0 {
+		return 0, false
+	}
+
+	result := gjson.GetBytes(body, loc)
+	if !result.Exists() {
+		return 0, false
+	}
+
+	return int(result.Int()), true
+}
@@ -0,0 +1,61 @@
+package groq
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GroqPerMillionTokenCost holds on-demand prices of models hosted by groq.
+// Groq model ids are not versioned by date, so they are matched exactly.
+var GroqPerMillionTokenCost = map[string]map[string]float64{
+	"prompt": {
+		"llama-3.3-70b-versatile":                       0.59,
+		"llama-3.1-8b-instant":                          0.05,
+		"llama3-70b-8192":                               0.59,
+		"llama3-8b-8192":                                0.05,
+		"meta-llama/llama-4-scout-17b-16e-instruct":     0.11,
+		"meta-llama/llama-4-maverick-17b-128e-instruct": 0.2,
+		"deepseek-r1-distill-llama-70b":                 0.75,
+		"qwen-qwq-32b":                                  0.29,
+		"mixtral-8x7b-32768":                            0.24,
+		"gemma2-9b-it":                                  0.2,
+	},
+	"completion": {
+		"llama-3.3-70b-versatile":                       0.79,
+		"llama-3.1-8b-instant":                          0.08,
+		"llama3-70b-8192":                               0.79,
+		"llama3-8b-8192":                                0.08,
+		"meta-llama/llama-4-scout-17b-16e-instruct":     0.34,
+		"meta-llama/llama-4-maverick-17b-128e-instruct": 0.6,
+		"deepseek-r1-distill-llama-70b":                 0.99,
+		"qwen-qwq-32b":                                  0.39,
+		"mixtral-8x7b-32768":                            0.24,
+		"gemma2-9b-it":                                  0.2,
+	},
+}
+
+type CostEstimator struct {
+	tokenCostMap map[string]map[string]float64
+}
+
+func NewCostEstimator() *CostEstimator {
+	return &CostEstimator{
+		tokenCostMap: GroqPerMillionTokenCost,
+	}
+}
+
+func (ce *CostEstimator) EstimateTotalCost(model string, promptTks, completionTks int) (float64, error) {
+	lowerCased := strings.ToLower(model)
+
+	promptCost, ok := ce.tokenCostMap["prompt"][lowerCased]
+	if !ok {
+		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
+	}
+
+	completionCost, ok := ce.tokenCostMap["completion"][lowerCased]
+	if !ok {
+		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
+	}
+
+	return float64(promptTks)/1000000*promptCost + float64(completionTks)/1000000*completionCost, nil
+}
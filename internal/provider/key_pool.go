@@ -0,0 +1,50 @@
+package provider
+
+const (
+	// KeyRotationRoundRobin uses the keys of a pool in turn. It is used when
+	// the strategy of a pool is not set.
+	KeyRotationRoundRobin = "round-robin"
+	// KeyRotationLeastRecentlyUsed uses the key that has been idle the longest.
+	KeyRotationLeastRecentlyUsed = "least-recently-used"
+	// KeyRotationWeighted picks keys at random in proportion to their weight.
+	KeyRotationWeighted = "weighted"
+)
+
+// KeyPool holds several upstream api keys of a provider setting, such as keys
+// of different OpenAI organizations, so that load is spread across them.
+type KeyPool struct {
+	Strategy string       `json:"strategy,omitempty"`
+	Keys     []*PooledKey `json:"keys"`
+}
+
+type PooledKey struct {
+	Id     string `json:"id"`
+	Key    string `json:"key,omitempty"`
+	Weight int    `json:"weight,omitempty"`
+}
+
+// RemoveSecrets blanks the keys of the pool, leaving their ids and weights.
+func (kp *KeyPool) RemoveSecrets() {
+	if kp == nil {
+		return
+	}
+
+	for _, k := range kp.Keys {
+		k.Key = ""
+	}
+}
+
+// KeyOf returns the key with id, or nil.
+func (kp *KeyPool) KeyOf(id string) *PooledKey {
+	if kp == nil {
+		return nil
+	}
+
+	for _, k := range kp.Keys {
+		if k.Id == id {
+			return k
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,12 @@
+package provider
+
+// RevokedKeyAlert is sent when the api key of a provider setting is rejected
+// upstream and the setting is disabled because of it.
+type RevokedKeyAlert struct {
+	ProviderSettingId string `json:"providerSettingId"`
+	Name              string `json:"name"`
+	Provider          string `json:"provider"`
+	StatusCode        int    `json:"statusCode"`
+	Error             string `json:"error,omitempty"`
+	DisabledAt        int64  `json:"disabledAt"`
+}
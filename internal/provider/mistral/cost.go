@@ -0,0 +1,94 @@
+package mistral
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MistralPerMillionTokenCost holds prices of mistral models by model family.
+// Dated versions like mistral-large-2411 and -latest aliases are priced by
+// the family they start with.
+var MistralPerMillionTokenCost = map[string]map[string]float64{
+	"prompt": {
+		"mistral-large":      2,
+		"mistral-medium":     0.4,
+		"mistral-small":      0.1,
+		"codestral":          0.3,
+		"ministral-8b":       0.1,
+		"ministral-3b":       0.04,
+		"pixtral-large":      2,
+		"pixtral-12b":        0.15,
+		"open-mistral-nemo":  0.15,
+		"open-mistral-7b":    0.25,
+		"open-mixtral-8x7b":  0.7,
+		"open-mixtral-8x22b": 2,
+	},
+	"completion": {
+		"mistral-large":      6,
+		"mistral-medium":     2,
+		"mistral-small":      0.3,
+		"codestral":          0.9,
+		"ministral-8b":       0.1,
+		"ministral-3b":       0.04,
+		"pixtral-large":      6,
+		"pixtral-12b":        0.15,
+		"open-mistral-nemo":  0.15,
+		"open-mistral-7b":    0.25,
+		"open-mixtral-8x7b":  0.7,
+		"open-mixtral-8x22b": 6,
+	},
+	"embeddings": {
+		"mistral-embed": 0.1,
+	},
+}
+
+type CostEstimator struct {
+	tokenCostMap map[string]map[string]float64
+}
+
+func NewCostEstimator() *CostEstimator {
+	return &CostEstimator{
+		tokenCostMap: MistralPerMillionTokenCost,
+	}
+}
+
+// selectModel returns the longest model family that model starts with.
+func selectModel(costMap map[string]float64, model string) string {
+	lowerCased := strings.ToLower(model)
+
+	selected := ""
+	for family := range costMap {
+		if strings.HasPrefix(lowerCased, family) && len(family) > len(selected) {
+			selected = family
+		}
+	}
+
+	return selected
+}
+
+func (ce *CostEstimator) EstimateTotalCost(model string, promptTks, completionTks int) (float64, error) {
+	selected := selectModel(ce.tokenCostMap["prompt"], model)
+
+	promptCost, ok := ce.tokenCostMap["prompt"][selected]
+	if !ok {
+		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
+	}
+
+	completionCost, ok := ce.tokenCostMap["completion"][selected]
+	if !ok {
+		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
+	}
+
+	return float64(promptTks)/1000000*promptCost + float64(completionTks)/1000000*completionCost, nil
+}
+
+func (ce *CostEstimator) EstimateEmbeddingsCost(model string, tks int) (float64, error) {
+	costMap := ce.tokenCostMap["embeddings"]
+
+	cost, ok := costMap[selectModel(costMap, model)]
+	if !ok {
+		return 0, fmt.Errorf("%s is not present in the cost map provided", model)
+	}
+
+	return float64(tks) / 1000000 * cost, nil
+}
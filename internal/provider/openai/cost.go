@@ -82,12 +82,14 @@ var OpenAiPerThousandTokenCost = map[string]map[string]float64{
 		"finetune-davinci-002":        0.012,
 	},
 	"finetune": {
-		"gpt-4-0613":         0.09,
-		"gpt-3.5-turbo-0125": 0.008,
-		"gpt-3.5-turbo-1106": 0.008,
-		"gpt-3.5-turbo-0613": 0.008,
-		"babbage-002":        0.0004,
-		"davinci-002":        0.006,
+		"gpt-4o-2024-08-06":      0.025,
+		"gpt-4o-mini-2024-07-18": 0.003,
+		"gpt-4-0613":             0.09,
+		"gpt-3.5-turbo-0125":     0.008,
+		"gpt-3.5-turbo-1106":     0.008,
+		"gpt-3.5-turbo-0613":     0.008,
+		"babbage-002":            0.0004,
+		"davinci-002":            0.006,
 	},
 	"embeddings": {
 		"text-embedding-ada-002": 0.0001,
@@ -330,18 +332,20 @@ func (ce *CostEstimator) EstimateSpeechCost(input string, model string) (float64
 	return float64(len(input)) / 1000 * cost, nil
 }
 
+// EstimateFinetuningCost prices num trained tokens of a fine-tuning job of
+// model.
 func (ce *CostEstimator) EstimateFinetuningCost(num int, model string) (float64, error) {
 	costMap, ok := ce.tokenCostMap["finetune"]
 	if !ok {
-		return 0, errors.New("audio cost map is not provided")
+		return 0, errors.New("finetune cost map is not provided")
 	}
 
 	cost, ok := costMap[model]
 	if !ok {
-		return 0, errors.New("model is not present in the audio cost map")
+		return 0, errors.New("model is not present in the finetune cost map")
 	}
 
-	return cost * float64(num), nil
+	return float64(num) / 1000 * cost, nil
 }
 
 func (ce *CostEstimator) EstimateEmbeddingsCost(r *goopenai.EmbeddingRequest) (float64, error) {
@@ -443,6 +447,20 @@ func countMessageTokens(model string, r *goopenai.ChatCompletionRequest, tc toke
 			return 0, err
 		}
 
+		for _, part := range msg.MultiContent {
+			if part.Type == goopenai.ChatMessagePartTypeImageURL {
+				contentTks += countImageTokens(model, part.ImageURL)
+				continue
+			}
+
+			partTks, err := tc.Count(model, part.Text)
+			if err != nil {
+				return 0, err
+			}
+
+			contentTks += partTks
+		}
+
 		result += contentTks
 		result += roleTks
 		result += nameTks
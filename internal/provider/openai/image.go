@@ -0,0 +1,188 @@
+package openai
+
+import (
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"strings"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// OpenAiPerImageCost is the cost in USD of a generated image by model, quality
+// and size.
+var OpenAiPerImageCost = map[string]map[string]map[string]float64{
+	"dall-e-2": {
+		"standard": {
+			"256x256":   0.016,
+			"512x512":   0.018,
+			"1024x1024": 0.02,
+		},
+	},
+	"dall-e-3": {
+		"standard": {
+			"1024x1024": 0.04,
+			"1024x1792": 0.08,
+			"1792x1024": 0.08,
+		},
+		"hd": {
+			"1024x1024": 0.08,
+			"1024x1792": 0.12,
+			"1792x1024": 0.12,
+		},
+	},
+	"gpt-image-1": {
+		"low": {
+			"1024x1024": 0.011,
+			"1024x1536": 0.016,
+			"1536x1024": 0.016,
+		},
+		"medium": {
+			"1024x1024": 0.042,
+			"1024x1536": 0.063,
+			"1536x1024": 0.063,
+		},
+		"high": {
+			"1024x1024": 0.167,
+			"1024x1536": 0.25,
+			"1536x1024": 0.25,
+		},
+	},
+}
+
+// defaultImageQualities are the qualities that models generate images with
+// when the request leaves the quality up to them.
+var defaultImageQualities = map[string]string{
+	"dall-e-2":    "standard",
+	"dall-e-3":    "standard",
+	"gpt-image-1": "high",
+}
+
+const defaultImageSize = "1024x1024"
+
+// EstimateImageCost prices n images generated by model. Models default to
+// dall-e-2, and sizes and qualities to the ones OpenAI picks when they are
+// not set or set to auto.
+func (ce *CostEstimator) EstimateImageCost(model, quality, size string, n int) (float64, error) {
+	if len(model) == 0 {
+		model = "dall-e-2"
+	}
+
+	qualities, ok := OpenAiPerImageCost[model]
+	if !ok {
+		return 0, fmt.Errorf("%s is not present in the image cost map", model)
+	}
+
+	if len(quality) == 0 || quality == "auto" {
+		quality = defaultImageQualities[model]
+	}
+
+	sizes, ok := qualities[quality]
+	if !ok {
+		return 0, fmt.Errorf("quality %s of %s is not present in the image cost map", quality, model)
+	}
+
+	if len(size) == 0 || size == "auto" {
+		size = defaultImageSize
+	}
+
+	cost, ok := sizes[size]
+	if !ok {
+		return 0, fmt.Errorf("size %s of %s is not present in the image cost map", size, model)
+	}
+
+	return cost * float64(n), nil
+}
+
+type imageTokenCost struct {
+	base int
+	tile int
+}
+
+// images in chat completions are billed as a number of prompt tokens, which
+// is a base cost plus a cost for every 512px tile of the scaled image. Some
+// models bill images at a multiple of the usual token count.
+var (
+	defaultImageTokenCost = imageTokenCost{base: 85, tile: 170}
+
+	imageTokenCosts = map[string]imageTokenCost{
+		"gpt-4o-mini": {base: 2833, tile: 5667},
+	}
+)
+
+// size assumed for images that are only linked to, whose dimensions cannot be
+// told without downloading them
+const (
+	assumedImageWidth  = 1024
+	assumedImageHeight = 1024
+)
+
+func imageTokenCostOf(model string) imageTokenCost {
+	for prefix, cost := range imageTokenCosts {
+		if strings.HasPrefix(model, prefix) {
+			return cost
+		}
+	}
+
+	return defaultImageTokenCost
+}
+
+// countImageTokens returns the prompt tokens an image input is billed as.
+func countImageTokens(model string, u *goopenai.ChatMessageImageURL) int {
+	cost := imageTokenCostOf(model)
+	if u == nil || u.Detail == goopenai.ImageURLDetailLow {
+		return cost.base
+	}
+
+	width, height, ok := dataUrlImageSize(u.URL)
+	if !ok {
+		width, height = assumedImageWidth, assumedImageHeight
+	}
+
+	return cost.base + cost.tile*countImageTiles(width, height)
+}
+
+// countImageTiles scales an image to fit within 2048x2048 and then to a
+// shortest side of 768px, and counts the 512px tiles covering it.
+func countImageTiles(width, height int) int {
+	w, h := float64(width), float64(height)
+	if w <= 0 || h <= 0 {
+		return 0
+	}
+
+	if w > 2048 || h > 2048 {
+		ratio := 2048 / math.Max(w, h)
+		w, h = w*ratio, h*ratio
+	}
+
+	if shortest := math.Min(w, h); shortest > 768 {
+		ratio := 768 / shortest
+		w, h = w*ratio, h*ratio
+	}
+
+	return int(math.Ceil(w/512) * math.Ceil(h/512))
+}
+
+// dataUrlImageSize reads the dimensions of an image inlined as a base64 data
+// url from its header.
+func dataUrlImageSize(url string) (int, int, bool) {
+	if !strings.HasPrefix(url, "data:") {
+		return 0, 0, false
+	}
+
+	_, data, found := strings.Cut(url, ";base64,")
+	if !found {
+		return 0, 0, false
+	}
+
+	cfg, _, err := image.DecodeConfig(base64.NewDecoder(base64.StdEncoding, strings.NewReader(data)))
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return cfg.Width, cfg.Height, true
+}
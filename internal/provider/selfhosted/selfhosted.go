@@ -0,0 +1,28 @@
+package selfhosted
+
+import (
+	"strings"
+)
+
+// CostEstimator prices models served from infrastructure that the operator
+// runs, such as ollama, vllm or a llama.cpp server. Such models cost nothing
+// unless the provider setting gives them per token rates with a cost map.
+type CostEstimator struct{}
+
+func NewCostEstimator() *CostEstimator {
+	return &CostEstimator{}
+}
+
+func (ce *CostEstimator) EstimateTotalCost(model string, promptTks, completionTks int) (float64, error) {
+	return 0, nil
+}
+
+func (ce *CostEstimator) EstimateEmbeddingsCost(model string, tks int) (float64, error) {
+	return 0, nil
+}
+
+// BaseUrl returns the url of a self hosted server without a trailing slash.
+// Requests are sent to the openai compatible api under /v1 of it.
+func BaseUrl(url string) string {
+	return strings.TrimSuffix(url, "/")
+}
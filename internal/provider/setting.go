@@ -2,6 +2,15 @@ package provider
 
 import "fmt"
 
+const (
+	// AwsCredentialSourceStatic signs bedrock requests with the access key of
+	// the setting. It is used when awsCredentialSource is not set.
+	AwsCredentialSourceStatic = "static"
+	// AwsCredentialSourceDefault signs bedrock requests with the credential
+	// chain of the gateway, such as an IRSA web identity token.
+	AwsCredentialSourceDefault = "default"
+)
+
 type Setting struct {
 	CreatedAt     int64             `json:"createdAt"`
 	UpdatedAt     int64             `json:"updatedAt"`
@@ -11,24 +20,124 @@ type Setting struct {
 	Name          string            `json:"name"`
 	AllowedModels []string          `json:"allowedModels"`
 	CostMap       *CostMap          `json:"costMap"`
+	Namespace     string            `json:"namespace"`
+	Deployments   []*Deployment     `json:"deployments,omitempty"`
+	KeyPool       *KeyPool          `json:"keyPool,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Environment   string            `json:"environment,omitempty"`
+	// Headers and QueryParams are added to every request forwarded upstream
+	// with the setting, replacing values that clients send.
+	Headers     map[string]string `json:"headers,omitempty"`
+	QueryParams map[string]string `json:"queryParams,omitempty"`
+	SpendLimit  *SpendLimit       `json:"spendLimit,omitempty"`
+	Transport   *TransportConfig  `json:"transport,omitempty"`
+	TokenBudget *TokenBudget      `json:"tokenBudget,omitempty"`
+	// Disabled settings are not used to forward requests, such as ones whose
+	// api key was found to be rejected upstream.
+	Disabled       bool   `json:"disabled,omitempty"`
+	DisabledReason string `json:"disabledReason,omitempty"`
+}
+
+// Deployment maps a model name that clients send to the Azure OpenAI
+// deployment serving it. ApiVersion is used when the client does not ask for
+// an api-version of its own.
+type Deployment struct {
+	Model      string `json:"model"`
+	Deployment string `json:"deployment"`
+	ApiVersion string `json:"apiVersion,omitempty"`
 }
 
+// CostMap overrides the built in pricing of a provider setting. Prices are in
+// Currency, which defaults to USD.
 type CostMap struct {
 	PromptCostPerModel     map[string]float64 `json:"promptCostPerModel"`
 	CompletionCostPerModel map[string]float64 `json:"completionCostPerModel"`
 	EmbeddingsCostPerModel map[string]float64 `json:"embeddingsCostPerModel"`
+	Currency               string             `json:"currency,omitempty"`
+}
+
+// Prices reports whether the cost of model is taken from the cost map rather
+// than from the built in pricing.
+func (cm *CostMap) Prices(model string) bool {
+	if cm == nil {
+		return false
+	}
+
+	if _, ok := cm.PromptCostPerModel[model]; ok {
+		return true
+	}
+
+	_, ok := cm.EmbeddingsCostPerModel[model]
+	return ok
 }
 
 func (s *Setting) GetParam(key string) string {
 	return s.Setting[key]
 }
 
+// DeploymentOf returns the deployment mapped to model, or nil.
+func (s *Setting) DeploymentOf(model string) *Deployment {
+	for _, d := range s.Deployments {
+		if d.Model == model {
+			return d
+		}
+	}
+
+	return nil
+}
+
 type UpdateSetting struct {
-	UpdatedAt     int64             `json:"updatedAt"`
-	Setting       map[string]string `json:"setting,omitempty"`
-	Name          *string           `json:"name"`
-	AllowedModels *[]string         `json:"allowedModels,omitempty"`
-	CostMap       *CostMap          `json:"costMap,omitempty"`
+	UpdatedAt      int64              `json:"updatedAt"`
+	Setting        map[string]string  `json:"setting,omitempty"`
+	Name           *string            `json:"name"`
+	AllowedModels  *[]string          `json:"allowedModels,omitempty"`
+	CostMap        *CostMap           `json:"costMap,omitempty"`
+	Deployments    *[]*Deployment     `json:"deployments,omitempty"`
+	KeyPool        *KeyPool           `json:"keyPool,omitempty"`
+	Labels         *map[string]string `json:"labels,omitempty"`
+	Environment    *string            `json:"environment,omitempty"`
+	Headers        *map[string]string `json:"headers,omitempty"`
+	QueryParams    *map[string]string `json:"queryParams,omitempty"`
+	SpendLimit     *SpendLimit        `json:"spendLimit,omitempty"`
+	Transport      *TransportConfig   `json:"transport,omitempty"`
+	TokenBudget    *TokenBudget       `json:"tokenBudget,omitempty"`
+	Disabled       *bool              `json:"disabled,omitempty"`
+	DisabledReason *string            `json:"disabledReason,omitempty"`
+}
+
+// SettingFilter narrows down listed provider settings. A setting matches when
+// it has the name and environment of the filter, if set, and carries every
+// label of it.
+type SettingFilter struct {
+	Name        string
+	Environment string
+	Labels      map[string]string
+}
+
+func (f *SettingFilter) Empty() bool {
+	return f == nil || (len(f.Name) == 0 && len(f.Environment) == 0 && len(f.Labels) == 0)
+}
+
+func (f *SettingFilter) Matches(s *Setting) bool {
+	if f.Empty() {
+		return true
+	}
+
+	if len(f.Name) != 0 && s.Name != f.Name {
+		return false
+	}
+
+	if len(f.Environment) != 0 && s.Environment != f.Environment {
+		return false
+	}
+
+	for k, v := range f.Labels {
+		if val, ok := s.Labels[k]; !ok || val != v {
+			return false
+		}
+	}
+
+	return true
 }
 
 func EstimateCostWithCostMap(model string, tks int, div float64, costMap map[string]float64) (float64, error) {
@@ -0,0 +1,58 @@
+package provider
+
+// SpendLimit caps the cost of the requests forwarded with a provider setting
+// per calendar month in UTC, independently of the limits of keys using it.
+// AlertThresholds are ratios of the limit, such as 0.8, at which AlertUrl is
+// notified. It is also notified once the limit is reached.
+type SpendLimit struct {
+	MonthlyCostLimitInUsd float64   `json:"monthlyCostLimitInUsd"`
+	AlertThresholds       []float64 `json:"alertThresholds,omitempty"`
+	AlertUrl              string    `json:"alertUrl,omitempty"`
+}
+
+func (sl *SpendLimit) limitInMicros() int64 {
+	return int64(sl.MonthlyCostLimitInUsd * 1000000)
+}
+
+// Reached reports whether spend of the month, in micro dollars, used up the
+// limit.
+func (sl *SpendLimit) Reached(micros int64) bool {
+	if sl == nil || sl.MonthlyCostLimitInUsd <= 0 {
+		return false
+	}
+
+	return micros >= sl.limitInMicros()
+}
+
+// Crossed returns the alert thresholds, and 1 for the limit itself, that
+// spend of the month passed when it went from before to after.
+func (sl *SpendLimit) Crossed(before, after int64) []float64 {
+	if sl == nil || sl.MonthlyCostLimitInUsd <= 0 {
+		return nil
+	}
+
+	thresholds := append([]float64{}, sl.AlertThresholds...)
+
+	crossed := []float64{}
+	for _, t := range append(thresholds, 1) {
+		at := int64(t * float64(sl.limitInMicros()))
+		if before < at && after >= at {
+			crossed = append(crossed, t)
+		}
+	}
+
+	return crossed
+}
+
+// SpendAlert is sent to the alert url of a spend limit when spend of the
+// month crosses a threshold.
+type SpendAlert struct {
+	ProviderSettingId     string  `json:"providerSettingId"`
+	Provider              string  `json:"provider"`
+	Month                 string  `json:"month"`
+	Threshold             float64 `json:"threshold"`
+	SpendInUsd            float64 `json:"spendInUsd"`
+	MonthlyCostLimitInUsd float64 `json:"monthlyCostLimitInUsd"`
+	LimitReached          bool    `json:"limitReached"`
+	CreatedAt             int64   `json:"createdAt"`
+}
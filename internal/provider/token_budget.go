@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// TokenBudget paces the requests forwarded with a provider setting against
+// the tokens per minute quota of its upstream, such as the TPM limit of an
+// OpenAI organization. Each request takes its estimated prompt tokens plus
+// its max_tokens from a token bucket that refills at TokensPerMinute and is
+// shared by every gateway. Requests that find the bucket empty are delayed
+// until it has refilled enough, and rejected with 429 if that takes longer
+// than MaxDelayInMs.
+type TokenBudget struct {
+	TokensPerMinute int `json:"tokensPerMinute"`
+	MaxDelayInMs    int `json:"maxDelayInMs,omitempty"`
+}
+
+// Set reports whether the budget paces anything. Updating a setting with a
+// budget that is not set removes it.
+func (tb *TokenBudget) Set() bool {
+	return tb != nil && tb.TokensPerMinute > 0
+}
+
+// Validate returns the fields of the budget that are invalid.
+func (tb *TokenBudget) Validate(field string) []string {
+	invalid := []string{}
+	if tb == nil {
+		return invalid
+	}
+
+	if tb.TokensPerMinute < 0 {
+		invalid = append(invalid, field+".tokensPerMinute")
+	}
+
+	if tb.MaxDelayInMs < 0 {
+		invalid = append(invalid, field+".maxDelayInMs")
+	}
+
+	return invalid
+}
+
+// MaxDelay is how long a request waits for the bucket to refill.
+func (tb *TokenBudget) MaxDelay() time.Duration {
+	return time.Duration(tb.MaxDelayInMs) * time.Millisecond
+}
+
+// EstimateBudgetTokens returns the tokens a request takes from a token
+// budget, which are about a token per four bytes of its body plus the
+// completion tokens it may use.
+func EstimateBudgetTokens(body []byte) int {
+	completion := gjson.GetBytes(body, "max_tokens").Int()
+	if mct := gjson.GetBytes(body, "max_completion_tokens").Int(); mct > completion {
+		completion = mct
+	}
+
+	return len(body)/4 + int(completion)
+}
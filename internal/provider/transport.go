@@ -0,0 +1,123 @@
+package provider
+
+import (
+	"context"
+	"time"
+)
+
+// TransportConfig tunes the connections used to reach an upstream, such as a
+// self-hosted model server that needs longer timeouts than OpenAI. Durations
+// are strings like "30s" and fields left empty keep the defaults of the
+// gateway.
+type TransportConfig struct {
+	// Timeout bounds the wait for the response headers of the upstream once a
+	// request is written. Streamed bodies are not cut off by it.
+	Timeout             string `json:"timeout,omitempty"`
+	DialTimeout         string `json:"dialTimeout,omitempty"`
+	TLSHandshakeTimeout string `json:"tlsHandshakeTimeout,omitempty"`
+	KeepAlive           string `json:"keepAlive,omitempty"`
+	IdleConnTimeout     string `json:"idleConnTimeout,omitempty"`
+	MaxIdleConns        int    `json:"maxIdleConns,omitempty"`
+	MaxIdleConnsPerHost int    `json:"maxIdleConnsPerHost,omitempty"`
+	MaxConnsPerHost     int    `json:"maxConnsPerHost,omitempty"`
+
+	// DisableHTTP2 keeps connections on HTTP/1.1, for upstreams whose HTTP/2
+	// support is broken. Otherwise, idle HTTP/2 connections are health checked
+	// with a ping after HTTP2ReadIdleTimeout, and dropped if the ping is not
+	// answered within HTTP2PingTimeout.
+	DisableHTTP2         bool   `json:"disableHttp2,omitempty"`
+	HTTP2ReadIdleTimeout string `json:"http2ReadIdleTimeout,omitempty"`
+	HTTP2PingTimeout     string `json:"http2PingTimeout,omitempty"`
+}
+
+// Set reports whether the config changes anything. Updating a setting or a
+// route with a config that is not set removes it.
+func (tc *TransportConfig) Set() bool {
+	return tc != nil && *tc != TransportConfig{}
+}
+
+type namedDuration struct {
+	name  string
+	value string
+}
+
+func (tc *TransportConfig) durations() []namedDuration {
+	return []namedDuration{
+		{"timeout", tc.Timeout},
+		{"dialTimeout", tc.DialTimeout},
+		{"tlsHandshakeTimeout", tc.TLSHandshakeTimeout},
+		{"keepAlive", tc.KeepAlive},
+		{"idleConnTimeout", tc.IdleConnTimeout},
+		{"http2ReadIdleTimeout", tc.HTTP2ReadIdleTimeout},
+		{"http2PingTimeout", tc.HTTP2PingTimeout},
+	}
+}
+
+// Validate returns the fields of the config that are invalid, prefixed with
+// field.
+func (tc *TransportConfig) Validate(field string) []string {
+	invalid := []string{}
+	if tc == nil {
+		return invalid
+	}
+
+	for _, d := range tc.durations() {
+		if len(d.value) == 0 {
+			continue
+		}
+
+		if parsed, err := time.ParseDuration(d.value); err != nil || parsed <= 0 {
+			invalid = append(invalid, field+"."+d.name)
+		}
+	}
+
+	if tc.MaxIdleConns < 0 {
+		invalid = append(invalid, field+".maxIdleConns")
+	}
+
+	if tc.MaxIdleConnsPerHost < 0 {
+		invalid = append(invalid, field+".maxIdleConnsPerHost")
+	}
+
+	if tc.MaxConnsPerHost < 0 {
+		invalid = append(invalid, field+".maxConnsPerHost")
+	}
+
+	if tc.DisableHTTP2 && (len(tc.HTTP2ReadIdleTimeout) != 0 || len(tc.HTTP2PingTimeout) != 0) {
+		invalid = append(invalid, field+".disableHttp2")
+	}
+
+	return invalid
+}
+
+// Duration returns the duration of a field of the config validated with
+// Validate, or zero if it is not set.
+func (tc *TransportConfig) Duration(name string) time.Duration {
+	for _, d := range tc.durations() {
+		if d.name == name {
+			parsed, _ := time.ParseDuration(d.value)
+			return parsed
+		}
+	}
+
+	return 0
+}
+
+type transportConfigKey struct{}
+
+// WithTransportConfig carries tc in ctx, where the transport of the proxy
+// client picks it up. A config already in ctx is kept, so that the config of
+// a route wins over the ones of the provider settings of its steps.
+func WithTransportConfig(ctx context.Context, tc *TransportConfig) context.Context {
+	if !tc.Set() || TransportConfigFrom(ctx) != nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, transportConfigKey{}, tc)
+}
+
+// TransportConfigFrom returns the transport config carried in ctx, if any.
+func TransportConfigFrom(ctx context.Context) *TransportConfig {
+	tc, _ := ctx.Value(transportConfigKey{}).(*TransportConfig)
+	return tc
+}
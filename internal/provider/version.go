@@ -0,0 +1,11 @@
+package provider
+
+// SettingVersion is a snapshot of a provider setting taken every time it is
+// created or changed. Versions are numbered from 1 per setting, and rolling
+// back to one adds a new version rather than removing the later ones.
+type SettingVersion struct {
+	SettingId string   `json:"settingId"`
+	Version   int      `json:"version"`
+	Setting   *Setting `json:"setting"`
+	CreatedAt int64    `json:"createdAt"`
+}
@@ -12,6 +12,7 @@ type Recorder struct {
 	uc Cache
 	ce CostEstimator
 	es EventsStore
+	ss SettingSpendCache
 }
 
 type EventsStore interface {
@@ -22,6 +23,10 @@ type Store interface {
 	IncrementCounter(keyId string, incr int64) error
 }
 
+type SettingSpendCache interface {
+	Increment(settingId string, micros int64) (int64, error)
+}
+
 type Cache interface {
 	IncrementCounter(keyId string, rateLimitUnit key.TimeUnit, incr int64) error
 }
@@ -31,7 +36,7 @@ type CostEstimator interface {
 	EstimateCompletionCost(model string, tks int) (float64, error)
 }
 
-func NewRecorder(s, us Store, c, uc Cache, ce CostEstimator, es EventsStore) *Recorder {
+func NewRecorder(s, us Store, c, uc Cache, ce CostEstimator, es EventsStore, ss SettingSpendCache) *Recorder {
 	return &Recorder{
 		s:  s,
 		c:  c,
@@ -39,6 +44,7 @@ func NewRecorder(s, us Store, c, uc Cache, ce CostEstimator, es EventsStore) *Re
 		uc: uc,
 		ce: ce,
 		es: es,
+		ss: ss,
 	}
 }
 
@@ -74,6 +80,13 @@ func (r *Recorder) RecordKeySpend(keyId string, micros int64, costLimitUnit key.
 	return nil
 }
 
+// RecordProviderSettingSpend adds to the spend of a provider setting in the
+// current month and returns the spend of the month including micros.
+func (r *Recorder) RecordProviderSettingSpend(settingId string, micros int64) (int64, error) {
+	return r.ss.Increment(settingId, micros)
+}
+
 func (r *Recorder) RecordEvent(e *event.Event) error {
+	e.PricedInUsd()
 	return r.es.InsertEvent(e)
 }
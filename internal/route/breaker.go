@@ -0,0 +1,405 @@
+package route
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+const (
+	defaultBreakerErrorRateThreshold = 0.5
+	defaultBreakerMinimumRequests    = 10
+	defaultBreakerWindow             = time.Minute
+	defaultBreakerOpenDuration       = 30 * time.Second
+	defaultBreakerHalfOpenProbes     = 1
+)
+
+// ErrCircuitOpen is returned when the breakers of every step of a route are
+// open, so that requests fail fast instead of waiting on upstreams that are
+// known to be failing.
+var ErrCircuitOpen = errors.New("circuit breakers of all route steps are open")
+
+// CircuitBreakerConfig trips a breaker per step of a route once the share of
+// failed or slow requests to it within Window reaches ErrorRateThreshold. Steps
+// with an open breaker are skipped for OpenDuration, after which up to
+// HalfOpenProbes requests are let through and the breaker closes once they
+// all succeed. Zero values fall back on defaults.
+type CircuitBreakerConfig struct {
+	Enabled            bool    `json:"enabled"`
+	ErrorRateThreshold float64 `json:"errorRateThreshold"`
+	LatencyThreshold   string  `json:"latencyThreshold,omitempty"`
+	MinimumRequests    int     `json:"minimumRequests"`
+	Window             string  `json:"window,omitempty"`
+	OpenDuration       string  `json:"openDuration,omitempty"`
+	HalfOpenProbes     int     `json:"halfOpenProbes"`
+}
+
+type breakerSettings struct {
+	errorRateThreshold float64
+	latencyThreshold   time.Duration
+	minimumRequests    int
+	window             time.Duration
+	openDuration       time.Duration
+	halfOpenProbes     int
+}
+
+func parseDurationOr(s string, fallback time.Duration) time.Duration {
+	parsed, err := time.ParseDuration(s)
+	if err != nil || parsed <= 0 {
+		return fallback
+	}
+
+	return parsed
+}
+
+func (cc *CircuitBreakerConfig) settings() breakerSettings {
+	bs := breakerSettings{
+		errorRateThreshold: cc.ErrorRateThreshold,
+		latencyThreshold:   parseDurationOr(cc.LatencyThreshold, 0),
+		minimumRequests:    cc.MinimumRequests,
+		window:             parseDurationOr(cc.Window, defaultBreakerWindow),
+		openDuration:       parseDurationOr(cc.OpenDuration, defaultBreakerOpenDuration),
+		halfOpenProbes:     cc.HalfOpenProbes,
+	}
+
+	if bs.errorRateThreshold <= 0 {
+		bs.errorRateThreshold = defaultBreakerErrorRateThreshold
+	}
+
+	if bs.minimumRequests <= 0 {
+		bs.minimumRequests = defaultBreakerMinimumRequests
+	}
+
+	if bs.halfOpenProbes <= 0 {
+		bs.halfOpenProbes = defaultBreakerHalfOpenProbes
+	}
+
+	return bs
+}
+
+// Validate returns the fields of the config that are invalid.
+func (cc *CircuitBreakerConfig) Validate() []string {
+	fields := []string{}
+	if cc.ErrorRateThreshold < 0 || cc.ErrorRateThreshold > 1 {
+		fields = append(fields, "circuitBreaker.errorRateThreshold")
+	}
+
+	if cc.MinimumRequests < 0 {
+		fields = append(fields, "circuitBreaker.minimumRequests")
+	}
+
+	if cc.HalfOpenProbes < 0 {
+		fields = append(fields, "circuitBreaker.halfOpenProbes")
+	}
+
+	for _, d := range [][2]string{
+		{"circuitBreaker.latencyThreshold", cc.LatencyThreshold},
+		{"circuitBreaker.window", cc.Window},
+		{"circuitBreaker.openDuration", cc.OpenDuration},
+	} {
+		if len(d[1]) == 0 {
+			continue
+		}
+
+		if parsed, err := time.ParseDuration(d[1]); err != nil || parsed <= 0 {
+			fields = append(fields, d[0])
+		}
+	}
+
+	return fields
+}
+
+// IsBreakerFailure reports whether the outcome of a request counts against the
+// breaker of its step. Client errors are left out since they say nothing
+// about the health of the upstream.
+func IsBreakerFailure(status int, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	return status == 0 || status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+type outcome struct {
+	at     time.Time
+	failed bool
+}
+
+type breaker struct {
+	state    string
+	outcomes []outcome
+	openedAt time.Time
+	probing  int
+	probed   int
+	trips    int
+}
+
+func (b *breaker) prune(now time.Time, window time.Duration) {
+	cutoff := now.Add(-window)
+	kept := b.outcomes[:0]
+	for _, o := range b.outcomes {
+		if o.at.After(cutoff) {
+			kept = append(kept, o)
+		}
+	}
+
+	b.outcomes = kept
+}
+
+func (b *breaker) errorRate() float64 {
+	if len(b.outcomes) == 0 {
+		return 0
+	}
+
+	failures := 0
+	for _, o := range b.outcomes {
+		if o.failed {
+			failures++
+		}
+	}
+
+	return float64(failures) / float64(len(b.outcomes))
+}
+
+func (b *breaker) trip(now time.Time) {
+	b.state = BreakerOpen
+	b.openedAt = now
+	b.outcomes = nil
+	b.probing = 0
+	b.probed = 0
+	b.trips++
+}
+
+// Breakers keeps the circuit breakers of route steps of this gateway in
+// memory, keyed by route and target.
+type Breakers struct {
+	lock     sync.Mutex
+	breakers map[string]*breaker
+}
+
+func NewBreakers() *Breakers {
+	return &Breakers{
+		breakers: map[string]*breaker{},
+	}
+}
+
+func breakerKey(routeId string, step *Step) string {
+	return routeId + "/" + targetKey(step.Provider, step.Model)
+}
+
+func (bs *Breakers) get(routeId string, step *Step) *breaker {
+	k := breakerKey(routeId, step)
+	b, ok := bs.breakers[k]
+	if !ok {
+		b = &breaker{state: BreakerClosed}
+		bs.breakers[k] = b
+	}
+
+	return b
+}
+
+// Available reports whether a step could be attempted without taking up one
+// of its half open probes.
+func (bs *Breakers) Available(routeId string, step *Step, cc *CircuitBreakerConfig) bool {
+	if bs == nil || cc == nil || !cc.Enabled {
+		return true
+	}
+
+	bs.lock.Lock()
+	defer bs.lock.Unlock()
+
+	b := bs.get(routeId, step)
+	if b.state == BreakerOpen {
+		return time.Since(b.openedAt) >= cc.settings().openDuration
+	}
+
+	if b.state == BreakerHalfOpen {
+		return b.probing < cc.settings().halfOpenProbes
+	}
+
+	return true
+}
+
+// Allow reports whether a request to a step may go out. Breakers that have
+// been open for long enough turn half open, and let a limited number of
+// probes through. Every allowed request must be followed by Record.
+func (bs *Breakers) Allow(routeId string, step *Step, cc *CircuitBreakerConfig) bool {
+	if bs == nil || cc == nil || !cc.Enabled {
+		return true
+	}
+
+	bs.lock.Lock()
+	defer bs.lock.Unlock()
+
+	settings := cc.settings()
+	b := bs.get(routeId, step)
+
+	if b.state == BreakerOpen {
+		if time.Since(b.openedAt) < settings.openDuration {
+			return false
+		}
+
+		b.state = BreakerHalfOpen
+		b.probing = 0
+		b.probed = 0
+	}
+
+	if b.state == BreakerHalfOpen {
+		if b.probing >= settings.halfOpenProbes {
+			return false
+		}
+
+		b.probing++
+	}
+
+	return true
+}
+
+// Record feeds the outcome of an allowed request to the breaker of its step.
+// Requests slower than the latency threshold count as failures.
+func (bs *Breakers) Record(routeId string, step *Step, cc *CircuitBreakerConfig, latency time.Duration, failed bool) {
+	if bs == nil || cc == nil || !cc.Enabled {
+		return
+	}
+
+	bs.lock.Lock()
+	defer bs.lock.Unlock()
+
+	settings := cc.settings()
+	if settings.latencyThreshold > 0 && latency > settings.latencyThreshold {
+		failed = true
+	}
+
+	now := time.Now()
+	b := bs.get(routeId, step)
+
+	switch b.state {
+	case BreakerHalfOpen:
+		if b.probing > 0 {
+			b.probing--
+		}
+
+		if failed {
+			telemetry.Incr("bricksllm.route.breakers.reopened", []string{"provider:" + step.Provider}, 1)
+			b.trip(now)
+			return
+		}
+
+		b.probed++
+		if b.probed >= settings.halfOpenProbes {
+			b.state = BreakerClosed
+			b.outcomes = nil
+		}
+	case BreakerClosed:
+		b.outcomes = append(b.outcomes, outcome{at: now, failed: failed})
+		b.prune(now, settings.window)
+
+		if len(b.outcomes) >= settings.minimumRequests && b.errorRate() >= settings.errorRateThreshold {
+			telemetry.Incr("bricksllm.route.breakers.tripped", []string{"provider:" + step.Provider}, 1)
+			b.trip(now)
+		}
+	}
+}
+
+// BreakerStatus is the state of the breaker of a route step.
+type BreakerStatus struct {
+	Provider  string  `json:"provider"`
+	Model     string  `json:"model"`
+	State     string  `json:"state"`
+	ErrorRate float64 `json:"errorRate"`
+	Requests  int     `json:"requests"`
+	Trips     int     `json:"trips"`
+	OpenedAt  int64   `json:"openedAt,omitempty"`
+	RetryAt   int64   `json:"retryAt,omitempty"`
+}
+
+// RouteStatus is the state of the breakers of every step of a route as seen
+// by this gateway.
+type RouteStatus struct {
+	RouteId        string                `json:"routeId"`
+	CircuitBreaker *CircuitBreakerConfig `json:"circuitBreaker,omitempty"`
+	Steps          []*BreakerStatus      `json:"steps"`
+}
+
+// Status returns the state of the breakers of the steps of a route. Breakers
+// that have been open for long enough are reported as half open, since the
+// next request to them is a probe.
+func (bs *Breakers) Status(r *Route) *RouteStatus {
+	rs := &RouteStatus{
+		RouteId:        r.Id,
+		CircuitBreaker: r.CircuitBreaker,
+		Steps:          []*BreakerStatus{},
+	}
+
+	for _, step := range r.AllSteps() {
+		// mirrored requests never go through breakers
+		if r.Shadow != nil && step == r.Shadow.Step {
+			continue
+		}
+
+		s := &BreakerStatus{
+			Provider: step.Provider,
+			Model:    step.Model,
+			State:    BreakerClosed,
+		}
+
+		rs.Steps = append(rs.Steps, s)
+		if bs == nil || r.CircuitBreaker == nil || !r.CircuitBreaker.Enabled {
+			continue
+		}
+
+		settings := r.CircuitBreaker.settings()
+
+		bs.lock.Lock()
+		b, ok := bs.breakers[breakerKey(r.Id, step)]
+		if ok {
+			b.prune(time.Now(), settings.window)
+
+			s.State = b.state
+			s.ErrorRate = b.errorRate()
+			s.Requests = len(b.outcomes)
+			s.Trips = b.trips
+
+			if b.state == BreakerOpen {
+				retryAt := b.openedAt.Add(settings.openDuration)
+				s.OpenedAt = b.openedAt.Unix()
+				s.RetryAt = retryAt.Unix()
+
+				if !time.Now().Before(retryAt) {
+					s.State = BreakerHalfOpen
+				}
+			}
+		}
+		bs.lock.Unlock()
+	}
+
+	return rs
+}
+
+// skipOpen leaves out the steps whose breaker is open.
+func skipOpen(steps []*Step, r *Route, req *Request) []*Step {
+	if req.Breakers == nil || r.CircuitBreaker == nil || !r.CircuitBreaker.Enabled {
+		return steps
+	}
+
+	available := []*Step{}
+	for _, step := range steps {
+		if !req.Breakers.Available(r.Id, step, r.CircuitBreaker) {
+			telemetry.Incr("bricksllm.route.skip_open.skipped", []string{"provider:" + step.Provider}, 1)
+			continue
+		}
+
+		available = append(available, step)
+	}
+
+	return available
+}
@@ -0,0 +1,93 @@
+package route
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/hasher"
+	"github.com/tidwall/gjson"
+	"golang.org/x/net/http/httpguts"
+)
+
+// CacheBypassHeader makes a route neither read nor write its response cache
+// for one request. It is not forwarded upstream.
+const CacheBypassHeader = "X-BRICKS-CACHE-BYPASS"
+
+// Reasons a request to a route with caching enabled is not cached.
+const (
+	CacheSkipBypass      = "bypass"
+	CacheSkipStream      = "stream"
+	CacheSkipTemperature = "temperature"
+	CacheSkipInvalid     = "invalid_body"
+)
+
+// Validate returns the fields of the config that are invalid.
+func (cc *CacheConfig) Validate() []string {
+	fields := []string{}
+	for _, name := range cc.Vary {
+		if !httpguts.ValidHeaderFieldName(name) {
+			fields = append(fields, "cacheConfig.vary")
+			break
+		}
+	}
+
+	return fields
+}
+
+// Key returns the cache key of a request to the route at path, or the reason
+// it should not be cached. Only deterministic requests are cached, so streamed
+// requests and requests sampling with a temperature above 0 are skipped. The
+// body is normalized so that requests differing only in formatting or field
+// order share an entry, and the headers in Vary are part of the key.
+func (cc *CacheConfig) Key(path string, body []byte, header http.Header) (string, string) {
+	if bypass, err := strconv.ParseBool(header.Get(CacheBypassHeader)); err == nil && bypass {
+		return "", CacheSkipBypass
+	}
+
+	if gjson.GetBytes(body, "stream").Bool() {
+		return "", CacheSkipStream
+	}
+
+	if gjson.GetBytes(body, "temperature").Float() > 0 {
+		return "", CacheSkipTemperature
+	}
+
+	normalized, err := normalizeBody(body)
+	if err != nil {
+		return "", CacheSkipInvalid
+	}
+
+	vary := append([]string{}, cc.Vary...)
+	sort.Strings(vary)
+
+	var sb strings.Builder
+	sb.WriteString(path)
+	sb.WriteString("\n")
+	sb.Write(normalized)
+	for _, name := range vary {
+		sb.WriteString("\n")
+		sb.WriteString(strings.ToLower(name))
+		sb.WriteString(":")
+		sb.WriteString(strings.Join(header.Values(name), ","))
+	}
+
+	return hasher.Hash(sb.String()), ""
+}
+
+// normalizeBody re-encodes a JSON body with sorted object keys and without
+// insignificant whitespace. Numbers are kept as written.
+func normalizeBody(body []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var parsed any
+	if err := dec.Decode(&parsed); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(parsed)
+}
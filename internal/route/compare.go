@@ -0,0 +1,99 @@
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+const (
+	maxCompareTargets     = 10
+	maxCompareConcurrency = 10
+)
+
+type CompareTarget struct {
+	RouteId       string            `json:"routeId,omitempty"`
+	Provider      string            `json:"provider,omitempty"`
+	Model         string            `json:"model,omitempty"`
+	Params        map[string]string `json:"params,omitempty"`
+	RequestParams map[string]any    `json:"requestParams,omitempty"`
+	Timeout       string            `json:"timeout,omitempty"`
+}
+
+type CompareRequest struct {
+	Request     json.RawMessage  `json:"request"`
+	SettingIds  []string         `json:"settingIds"`
+	Targets     []*CompareTarget `json:"targets"`
+	Concurrency int              `json:"concurrency"`
+}
+
+func (r *CompareRequest) Validate() error {
+	invalid := []string{}
+
+	if len(r.Request) == 0 || !json.Valid(r.Request) {
+		invalid = append(invalid, "request")
+	}
+
+	if len(r.SettingIds) == 0 {
+		invalid = append(invalid, "settingIds")
+	}
+
+	if len(r.Targets) == 0 || len(r.Targets) > maxCompareTargets {
+		invalid = append(invalid, "targets")
+	}
+
+	if r.Concurrency < 0 || r.Concurrency > maxCompareConcurrency {
+		invalid = append(invalid, "concurrency")
+	}
+
+	for index, t := range r.Targets {
+		if t == nil {
+			invalid = append(invalid, fmt.Sprintf("targets.[%d]", index))
+			continue
+		}
+
+		if len(t.RouteId) != 0 {
+			if len(t.Provider) != 0 || len(t.Model) != 0 {
+				invalid = append(invalid, fmt.Sprintf("targets.[%d].routeId", index))
+			}
+
+			continue
+		}
+
+		if t.Provider != "openai" && t.Provider != "azure" {
+			invalid = append(invalid, fmt.Sprintf("targets.[%d].provider", index))
+		}
+
+		if len(t.Model) == 0 {
+			invalid = append(invalid, fmt.Sprintf("targets.[%d].model", index))
+		}
+
+		if t.Provider == "azure" && (len(t.Params["deploymentId"]) == 0 || len(t.Params["apiVersion"]) == 0) {
+			invalid = append(invalid, fmt.Sprintf("targets.[%d].params", index))
+		}
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewInvalidFieldsError(invalid)
+	}
+
+	return nil
+}
+
+type CompareResult struct {
+	Target               *CompareTarget  `json:"target"`
+	Provider             string          `json:"provider"`
+	Model                string          `json:"model"`
+	Status               int             `json:"status"`
+	LatencyInMs          int             `json:"latencyInMs"`
+	CostInUsd            float64         `json:"costInUsd"`
+	PromptTokenCount     int             `json:"promptTokenCount"`
+	CompletionTokenCount int             `json:"completionTokenCount"`
+	Response             json.RawMessage `json:"response,omitempty"`
+	Error                string          `json:"error,omitempty"`
+}
+
+type CompareResponse struct {
+	Results []*CompareResult `json:"results"`
+}
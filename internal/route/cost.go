@@ -0,0 +1,255 @@
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+const StrategyCheapestCapable = "cheapest-capable"
+
+// PremiumHeader asks the cheapest-capable strategy for the premium target of
+// a route instead of the cheapest one. It is not forwarded upstream.
+const PremiumHeader = "X-BRICKS-PREMIUM"
+
+const (
+	defaultErrorRateThreshold = 0.5
+	// error rates are only trusted once a target has this many samples in the window
+	minErrorRateSamples = 5
+)
+
+type StrategyConfig struct {
+	ErrorRateThreshold float64 `json:"errorRateThreshold"`
+	PremiumModel       string  `json:"premiumModel,omitempty"`
+}
+
+// wantsPremium reports whether the caller asked for the premium target.
+func wantsPremium(req *Request) bool {
+	if req.Forwarded == nil {
+		return false
+	}
+
+	premium, err := strconv.ParseBool(req.Forwarded.Header.Get(PremiumHeader))
+	return err == nil && premium
+}
+
+type promptTokenCounter interface {
+	EstimateChatCompletionPromptTokenCounts(model string, r *goopenai.ChatCompletionRequest) (int, error)
+}
+
+// longest matching prefix wins
+var contextWindows = map[string]int{
+	"gpt-4o":                 128000,
+	"gpt-4-turbo":            128000,
+	"gpt-4-1106":             128000,
+	"gpt-4-0125":             128000,
+	"gpt-4-vision":           128000,
+	"gpt-4-32k":              32768,
+	"gpt-4":                  8192,
+	"gpt-3.5-turbo":          16385,
+	"gpt-3.5-turbo-0301":     4096,
+	"gpt-3.5-turbo-0613":     4096,
+	"gpt-3.5-turbo-instruct": 4096,
+	"gpt-35-turbo":           16385,
+	"gpt-35-turbo-0301":      4096,
+	"gpt-35-turbo-0613":      4096,
+	"gpt-35-turbo-instruct":  4096,
+	"text-embedding":         8191,
+	"ada":                    8191,
+}
+
+func getContextWindow(s *Step) int {
+	if s.ContextWindow != 0 {
+		return s.ContextWindow
+	}
+
+	matched := ""
+	for prefix := range contextWindows {
+		if strings.HasPrefix(s.Model, prefix) && len(prefix) > len(matched) {
+			matched = prefix
+		}
+	}
+
+	return contextWindows[matched]
+}
+
+// estimateRequestTokens returns the prompt tokens and the completion token budget of a request.
+func estimateRequestTokens(body []byte, embeddings bool, counter promptTokenCounter) (int, int) {
+	if embeddings {
+		req := &goopenai.EmbeddingRequest{}
+		if err := json.Unmarshal(body, req); err != nil {
+			return len(body) / 4, 0
+		}
+
+		input, err := util.ConvertAnyToStr(req.Input)
+		if err != nil {
+			return len(body) / 4, 0
+		}
+
+		return len(input) / 4, 0
+	}
+
+	req := &goopenai.ChatCompletionRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return len(body) / 4, 0
+	}
+
+	if counter != nil {
+		if tks, err := counter.EstimateChatCompletionPromptTokenCounts(req.Model, req); err == nil {
+			return tks, req.MaxTokens
+		}
+	}
+
+	chars := 0
+	for _, m := range req.Messages {
+		chars += len(m.Content)
+		for _, part := range m.MultiContent {
+			chars += len(part.Text)
+		}
+	}
+
+	return chars / 4, req.MaxTokens
+}
+
+func completionBudget(s *Step, requested int) int {
+	if val, ok := s.RequestParams["max_tokens"]; ok {
+		if parsed, ok := val.(float64); ok {
+			return int(parsed)
+		}
+	}
+
+	return requested
+}
+
+// RankByCost orders steps by the estimated cost of the request. Steps whose context
+// window cannot fit the request are skipped, and steps that are down or have an error
+// rate above the threshold are only kept as a last resort. When premium is set, the
+// capable steps of the premium model, or the most expensive capable step if the route
+// has none, are tried first.
+func (t *Tracker) RankByCost(steps []*Step, costs map[string]CostEstimator, promptTks, completionTks int, cfg *StrategyConfig, down func(*Step) bool, premium bool) *Selection {
+	threshold := defaultErrorRateThreshold
+	if cfg != nil && cfg.ErrorRateThreshold != 0 {
+		threshold = cfg.ErrorRateThreshold
+	}
+
+	premiumModel := ""
+	if cfg != nil {
+		premiumModel = cfg.PremiumModel
+	}
+
+	capable := []*Candidate{}
+	unhealthy := []*Candidate{}
+	excluded := []*Candidate{}
+
+	for _, step := range steps {
+		avg, errRate, count := t.Snapshot(step.Provider, step.Model)
+		budget := completionBudget(step, completionTks)
+
+		c := &Candidate{
+			Provider:       step.Provider,
+			Model:          step.Model,
+			AvgLatencyInMs: avg,
+			ErrorRate:      errRate,
+			Samples:        count,
+			ContextWindow:  getContextWindow(step),
+			step:           step,
+		}
+
+		if ce, ok := costs[step.Provider]; ok && ce != nil {
+			if cost, err := ce.EstimateTotalCost(step.Model, promptTks, budget); err == nil {
+				c.EstimatedCostInUsd = &cost
+			}
+		}
+
+		if c.ContextWindow != 0 && promptTks+budget > c.ContextWindow {
+			c.Excluded = fmt.Sprintf("request needs about %d tokens which exceeds the context window", promptTks+budget)
+			c.skip = true
+			excluded = append(excluded, c)
+			continue
+		}
+
+		if down != nil && down(step) {
+			c.Excluded = "provider setting is down"
+			unhealthy = append(unhealthy, c)
+			continue
+		}
+
+		if count >= minErrorRateSamples && errRate > threshold {
+			c.Excluded = fmt.Sprintf("error rate %.2f is above threshold %.2f", errRate, threshold)
+			unhealthy = append(unhealthy, c)
+			continue
+		}
+
+		capable = append(capable, c)
+	}
+
+	byCost := func(cs []*Candidate) {
+		sort.SliceStable(cs, func(i, j int) bool {
+			if cs[i].EstimatedCostInUsd == nil {
+				return false
+			}
+
+			if cs[j].EstimatedCostInUsd == nil {
+				return true
+			}
+
+			return *cs[i].EstimatedCostInUsd < *cs[j].EstimatedCostInUsd
+		})
+	}
+
+	byCost(capable)
+	byCost(unhealthy)
+
+	premiumSelected := false
+	if premium && len(capable) != 0 {
+		premiumSelected = true
+		if len(premiumModel) != 0 {
+			matched, rest := []*Candidate{}, []*Candidate{}
+			for _, c := range capable {
+				if c.Model == premiumModel {
+					matched = append(matched, c)
+					continue
+				}
+
+				rest = append(rest, c)
+			}
+
+			premiumSelected = len(matched) != 0
+			capable = append(matched, rest...)
+		} else {
+			sort.SliceStable(capable, func(i, j int) bool {
+				if capable[j].EstimatedCostInUsd == nil {
+					return capable[i].EstimatedCostInUsd != nil
+				}
+
+				return capable[i].EstimatedCostInUsd != nil && *capable[i].EstimatedCostInUsd > *capable[j].EstimatedCostInUsd
+			})
+		}
+	}
+
+	selection := &Selection{
+		Strategy:   StrategyCheapestCapable,
+		Candidates: append(append(capable, unhealthy...), excluded...),
+	}
+
+	switch {
+	case premiumSelected:
+		selection.Selected = targetKey(capable[0].Provider, capable[0].Model)
+		selection.Reason = "premium target requested by the caller whose context window fits the request and whose error rate is below threshold"
+	case len(capable) != 0:
+		selection.Selected = targetKey(capable[0].Provider, capable[0].Model)
+		selection.Reason = "cheapest target whose context window fits the request and whose error rate is below threshold"
+	case len(unhealthy) != 0:
+		selection.Selected = targetKey(unhealthy[0].Provider, unhealthy[0].Model)
+		selection.Reason = "every capable target is down or above the error rate threshold, using the cheapest one"
+	default:
+		selection.Reason = "no target has a context window that fits the request"
+	}
+
+	return selection
+}
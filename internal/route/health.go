@@ -0,0 +1,53 @@
+package route
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/health"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+// HealthChecker reports the status of the upstream of a provider setting as
+// observed by background probes.
+type HealthChecker interface {
+	Status(settingId string) string
+}
+
+// isDown returns whether the provider setting of a step is down, or nil if
+// the request has no health checker.
+func isDown(req *Request) func(*Step) bool {
+	if req.Health == nil {
+		return nil
+	}
+
+	return func(step *Step) bool {
+		setting, ok := req.Settings[step.Provider]
+		return ok && setting != nil && req.Health.Status(setting.Id) == health.StatusDown
+	}
+}
+
+// demoteDown moves the steps whose provider setting is down behind the other
+// steps, keeping the relative order within both groups. Steps are only
+// reordered, never dropped, so a route whose upstreams all look down still
+// tries them.
+func demoteDown(steps []*Step, req *Request) []*Step {
+	if req.Health == nil || len(steps) < 2 {
+		return steps
+	}
+
+	up, down := []*Step{}, []*Step{}
+	for _, step := range steps {
+		setting, ok := req.Settings[step.Provider]
+		if ok && setting != nil && req.Health.Status(setting.Id) == health.StatusDown {
+			telemetry.Incr("bricksllm.route.demote_down.demoted", []string{"provider:" + step.Provider}, 1)
+			down = append(down, step)
+			continue
+		}
+
+		up = append(up, step)
+	}
+
+	if len(down) == 0 || len(up) == 0 {
+		return steps
+	}
+
+	return append(up, down...)
+}
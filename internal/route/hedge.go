@@ -0,0 +1,211 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+)
+
+// HedgeConfig races the first two steps of a route to cut tail latency.
+// The second step is only sent the request if the first one has not started
+// responding within DelayInMs, or has failed by then. Whichever responds
+// successfully first is returned and the other request is cancelled. Raced
+// steps are tried once, and the rest of the steps are tried as usual if both
+// of them fail.
+type HedgeConfig struct {
+	Enabled   bool `json:"enabled"`
+	DelayInMs int  `json:"delayInMs"`
+}
+
+// Validate returns the fields of the config that are invalid.
+func (hc *HedgeConfig) Validate() []string {
+	fields := []string{}
+	if hc.Enabled && hc.DelayInMs <= 0 {
+		fields = append(fields, "hedge.delayInMs")
+	}
+
+	return fields
+}
+
+type attempt struct {
+	step   *Step
+	evt    *event.Event
+	res    *http.Response
+	data   []byte
+	cancel context.CancelFunc
+	err    error
+}
+
+// hedge races steps, which must hold two steps, and returns the events of
+// the attempts along with the attempt that won. The event of the winner
+// comes last. The winner is nil if no step responded at all.
+func (r *Route) hedge(req *Request, steps []*Step, body []byte, newEvent func(*Step) *event.Event) ([]*event.Event, *attempt) {
+	results := make(chan *attempt, len(steps))
+
+	launched := []*attempt{}
+	launch := func(step *Step) {
+		if !req.Breakers.Allow(r.Id, step, r.CircuitBreaker) {
+			return
+		}
+
+		timeout, err := time.ParseDuration(step.Timeout)
+		if err != nil {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(req.context(), timeout)
+		a := &attempt{step: step, evt: newEvent(step), cancel: cancel}
+		launched = append(launched, a)
+
+		go r.try(ctx, req, a, body, results)
+	}
+
+	launch(steps[0])
+
+	timer := time.NewTimer(time.Duration(r.Hedge.DelayInMs) * time.Millisecond)
+	defer timer.Stop()
+
+	hedged := false
+	fire := func() {
+		if hedged {
+			return
+		}
+
+		hedged = true
+		telemetry.Incr("bricksllm.route.hedge.fired", nil, 1)
+		launch(steps[1])
+	}
+
+	if len(launched) == 0 {
+		fire()
+	}
+
+	var won *attempt
+	var failed *attempt
+	done := 0
+	for done < len(launched) && won == nil {
+		select {
+		case <-timer.C:
+			fire()
+		case a := <-results:
+			done++
+
+			if a.err == nil && a.res.StatusCode == http.StatusOK {
+				won = a
+				continue
+			}
+
+			if a.res != nil {
+				failed = a
+			}
+
+			// a failure before the delay has passed is not worth waiting for
+			fire()
+		}
+	}
+
+	// the loser is cancelled and waited on, so that its event is complete
+	// before it is recorded
+	for _, a := range launched {
+		if a != won {
+			a.cancel()
+		}
+	}
+
+	for ; done < len(launched); done++ {
+		if a := <-results; a.res != nil && a.res.StatusCode == http.StatusOK {
+			a.res.Body.Close()
+		}
+	}
+
+	if won != nil && len(launched) > 1 {
+		telemetry.Incr("bricksllm.route.hedge.won", []string{"provider:" + won.step.Provider}, 1)
+	}
+
+	if won == nil {
+		won = failed
+	}
+
+	events := []*event.Event{}
+	for _, a := range launched {
+		if a != won {
+			events = append(events, a.evt)
+		}
+	}
+
+	if won != nil {
+		events = append(events, won.evt)
+	}
+
+	return events, won
+}
+
+// try sends the request of a to its step once and delivers a to results.
+// Responses that are not okay are read into the data of a.
+func (r *Route) try(ctx context.Context, req *Request, a *attempt, body []byte, results chan<- *attempt) {
+	step := a.step
+	start := time.Now()
+
+	defer func() {
+		a.evt.LatencyInMs = int(time.Since(start).Milliseconds())
+
+		// cancelled losers say nothing about the health of their step
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			if req.Tracker != nil {
+				req.Tracker.Record(step.Provider, step.Model, time.Since(start), a.err != nil)
+			}
+
+			req.Breakers.Record(r.Id, step, r.CircuitBreaker, time.Since(start), IsBreakerFailure(a.evt.Status, a.err))
+		}
+
+		results <- a
+	}()
+
+	bs, err := step.DecorateRequest(step.Provider, body, r.ShouldRunEmbeddings())
+	if err != nil {
+		a.err = err
+		return
+	}
+
+	hreq, err := req.createHttpRequest(ctx, step.Provider, r.ShouldRunEmbeddings(), step.Params, bs)
+	if err != nil {
+		a.err = err
+		return
+	}
+
+	res, err := req.Client.Do(hreq)
+	if err != nil {
+		a.err = err
+		return
+	}
+
+	a.res = res
+	a.evt.Status = res.StatusCode
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+
+		data, err := io.ReadAll(res.Body)
+		if err != nil {
+			a.err = err
+			return
+		}
+
+		a.data, a.err = normalizeResponseBody(step.Provider, res.StatusCode, data)
+		if a.err == nil {
+			a.err = errors.New("response is not okay")
+		}
+
+		return
+	}
+
+	if err := normalizeResponse(step.Provider, res); err != nil {
+		a.res = nil
+		a.err = err
+	}
+}
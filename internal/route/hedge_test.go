@@ -0,0 +1,152 @@
+package route
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+)
+
+// hedgeUpstream answers chat completions by the model requested: "fast"
+// responds right away, "failing" responds with 500 and "slow" holds the
+// request until it is cancelled, which it reports on cancelled.
+type hedgeUpstream struct {
+	lock      sync.Mutex
+	models    []string
+	cancelled chan string
+}
+
+func (u *hedgeUpstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body := struct {
+		Model string `json:"model"`
+	}{}
+	json.NewDecoder(r.Body).Decode(&body)
+
+	u.lock.Lock()
+	u.models = append(u.models, body.Model)
+	u.lock.Unlock()
+
+	switch body.Model {
+	case "slow":
+		select {
+		case <-r.Context().Done():
+			u.cancelled <- body.Model
+		case <-time.After(5 * time.Second):
+		}
+	case "failing":
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error":{"message":"failed"}}`))
+	default:
+		w.Write([]byte(`{"id":"chatcmpl"}`))
+	}
+}
+
+func (u *hedgeUpstream) requested() []string {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	return append([]string{}, u.models...)
+}
+
+func hedgeAcross(t *testing.T, delay time.Duration, models ...string) (*hedgeUpstream, []*event.Event, *attempt) {
+	u := &hedgeUpstream{cancelled: make(chan string, len(models))}
+	srv := httptest.NewServer(u)
+	t.Cleanup(srv.Close)
+
+	steps := []*Step{}
+	for _, model := range models {
+		steps = append(steps, &Step{Provider: "self-hosted", Model: model, Timeout: "5s"})
+	}
+
+	r := &Route{Id: "route", Steps: steps, Hedge: &HedgeConfig{Enabled: true, DelayInMs: int(delay.Milliseconds())}}
+	req := &Request{
+		Settings: map[string]*provider.Setting{
+			"setting": {Provider: "self-hosted", Setting: map[string]string{"url": srv.URL}},
+		},
+		Forwarded: httptest.NewRequest(http.MethodPost, "/api/routes/route", nil),
+	}
+
+	events, won := r.hedge(req, steps, []byte(`{"model":"","messages":[]}`), func(*Step) *event.Event {
+		return &event.Event{}
+	})
+
+	if won != nil {
+		t.Cleanup(won.cancel)
+		if won.res != nil && won.res.StatusCode == http.StatusOK {
+			t.Cleanup(func() { won.res.Body.Close() })
+		}
+	}
+
+	return u, events, won
+}
+
+func TestHedgeCancelsTheLoser(t *testing.T) {
+	u, events, won := hedgeAcross(t, 10*time.Millisecond, "slow", "fast")
+
+	if won == nil || won.step.Model != "fast" || won.err != nil {
+		t.Fatalf("expected the fast step to win, got: %+v", won)
+	}
+
+	if len(events) != 2 || events[1] != won.evt {
+		t.Fatalf("expected the events of both attempts with the winner last, got: %d", len(events))
+	}
+
+	select {
+	case model := <-u.cancelled:
+		if model != "slow" {
+			t.Fatalf("expected the slow step to be cancelled, got: %s", model)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected the request of the losing step to be cancelled")
+	}
+}
+
+func TestHedgeFiresEarlyWhenTheFirstStepFails(t *testing.T) {
+	start := time.Now()
+	_, events, won := hedgeAcross(t, 10*time.Second, "failing", "fast")
+
+	if time.Since(start) > 2*time.Second {
+		t.Fatalf("expected the hedge to fire once the first step failed, took: %s", time.Since(start))
+	}
+
+	if won == nil || won.step.Model != "fast" {
+		t.Fatalf("expected the second step to win, got: %+v", won)
+	}
+
+	if len(events) != 2 || events[0].Status != http.StatusInternalServerError {
+		t.Fatalf("expected the failed attempt to be recorded, got: %d events", len(events))
+	}
+}
+
+func TestHedgeDoesNotFireBeforeTheDelay(t *testing.T) {
+	u, events, won := hedgeAcross(t, time.Second, "fast", "slow")
+
+	if won == nil || won.step.Model != "fast" {
+		t.Fatalf("expected the first step to win, got: %+v", won)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("expected only the first step to be attempted, got: %d events", len(events))
+	}
+
+	if requested := u.requested(); len(requested) != 1 || requested[0] != "fast" {
+		t.Fatalf("expected only the first step to be sent the request, got: %v", requested)
+	}
+}
+
+func TestHedgeReturnsTheFailureWhenBothStepsFail(t *testing.T) {
+	_, events, won := hedgeAcross(t, 10*time.Millisecond, "failing", "failing")
+
+	if won == nil || won.err == nil || won.res.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected a failed attempt to be returned, got: %+v", won)
+	}
+
+	if len(events) != 2 || events[1] != won.evt {
+		t.Fatalf("expected the events of both attempts with the returned one last, got: %d", len(events))
+	}
+}
@@ -0,0 +1,35 @@
+package route
+
+import "slices"
+
+// forLanguage prefers the steps serving the detected language of a request.
+// Steps without languages serve every language, and are used when no step
+// lists the language. Unlike regions, languages never fail a request: when
+// no step serves the language, every step is used.
+func forLanguage(steps []*Step, language string) []*Step {
+	if len(language) == 0 {
+		return steps
+	}
+
+	listed, general := []*Step{}, []*Step{}
+	for _, step := range steps {
+		if len(step.Languages) == 0 {
+			general = append(general, step)
+			continue
+		}
+
+		if slices.Contains(step.Languages, language) {
+			listed = append(listed, step)
+		}
+	}
+
+	if len(listed) != 0 {
+		return listed
+	}
+
+	if len(general) != 0 {
+		return general
+	}
+
+	return steps
+}
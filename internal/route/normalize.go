@@ -0,0 +1,118 @@
+package route
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	goopenai "github.com/sashabaranov/go-openai"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+)
+
+const (
+	anthropicMessagesUrl    = "https://api.anthropic.com/v1/messages"
+	anthropicDefaultVersion = "2023-06-01"
+)
+
+// isSelfHosted reports whether provider serves an OpenAI compatible API
+// from the url of its provider setting.
+func isSelfHosted(provider string) bool {
+	return provider == "vllm" || provider == "self-hosted"
+}
+
+// translatesFormat reports whether requests to provider have to be
+// translated from, and responses back into, the OpenAI format.
+func translatesFormat(provider string) bool {
+	return provider == "anthropic"
+}
+
+// toProviderFormat converts an OpenAI chat completion request into the
+// format of provider. Translated requests never stream since the reply has
+// to be normalized as a whole.
+func toProviderFormat(provider string, req *goopenai.ChatCompletionRequest) ([]byte, error) {
+	if provider != "anthropic" {
+		return json.Marshal(req)
+	}
+
+	mr, err := anthropic.FromChatCompletionRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	mr.Stream = false
+
+	return json.Marshal(mr)
+}
+
+// normalizeResponseBody converts a response body of provider into the
+// OpenAI shape. Bodies of other providers are returned as they are.
+func normalizeResponseBody(provider string, status int, data []byte) ([]byte, error) {
+	if !translatesFormat(provider) {
+		return data, nil
+	}
+
+	if status != http.StatusOK {
+		er := &anthropic.ErrorResponse{}
+		if err := json.Unmarshal(data, er); err != nil {
+			er = nil
+		}
+
+		return json.Marshal(anthropic.ToChatCompletionErrorResponse(er))
+	}
+
+	mr := &anthropic.MessagesResponse{}
+	if err := json.Unmarshal(data, mr); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(anthropic.ToChatCompletionResponse(mr))
+}
+
+// normalizeResponse replaces the body of a successful response with its
+// OpenAI shaped equivalent so that a route replies the same way regardless
+// of the step that served it.
+func normalizeResponse(provider string, res *http.Response) error {
+	if !translatesFormat(provider) || res.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	normalized, err := normalizeResponseBody(provider, res.StatusCode, data)
+	if err != nil {
+		return errors.New("cannot normalize " + provider + " response: " + err.Error())
+	}
+
+	res.Body = io.NopCloser(bytes.NewReader(normalized))
+	res.ContentLength = int64(len(normalized))
+	res.Header.Set("Content-Length", strconv.Itoa(len(normalized)))
+	res.Header.Set("Content-Type", "application/json")
+
+	for name := range res.Header {
+		if strings.HasPrefix(strings.ToLower(name), "anthropic-") {
+			res.Header.Del(name)
+		}
+	}
+
+	return nil
+}
+
+// selfHostedUrl builds the url of an OpenAI compatible endpoint under base.
+func selfHostedUrl(base string, runEmbeddings bool) string {
+	base = strings.TrimSuffix(base, "/")
+	if runEmbeddings {
+		return base + "/v1/embeddings"
+	}
+
+	return base + "/v1/chat/completions"
+}
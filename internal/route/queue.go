@@ -0,0 +1,44 @@
+package route
+
+import "time"
+
+// QueueConfig caps the requests to a route that are in flight at once
+// across gateways. Once the route is saturated, high priority requests still
+// proceed while normal and low priority ones wait in a queue of at most
+// MaxQueued requests, normal priority first. Requests that cannot be queued,
+// or that have waited MaxWaitInMs, are rejected with 429.
+type QueueConfig struct {
+	MaxConcurrency int `json:"maxConcurrency"`
+	MaxQueued      int `json:"maxQueued"`
+	MaxWaitInMs    int `json:"maxWaitInMs"`
+}
+
+// Outcomes of asking a route queue for a slot.
+const (
+	QueueWaiting  = 0
+	QueueAdmitted = 1
+	QueueFull     = -1
+)
+
+// Validate returns the fields of the config that are invalid.
+func (qc *QueueConfig) Validate() []string {
+	fields := []string{}
+	if qc.MaxConcurrency <= 0 {
+		fields = append(fields, "queue.maxConcurrency")
+	}
+
+	if qc.MaxQueued < 0 {
+		fields = append(fields, "queue.maxQueued")
+	}
+
+	if qc.MaxQueued > 0 && qc.MaxWaitInMs <= 0 {
+		fields = append(fields, "queue.maxWaitInMs")
+	}
+
+	return fields
+}
+
+// MaxWait is how long a queued request waits for a slot of the route.
+func (qc *QueueConfig) MaxWait() time.Duration {
+	return time.Duration(qc.MaxWaitInMs) * time.Millisecond
+}
@@ -0,0 +1,58 @@
+package route
+
+import (
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+)
+
+// RateLimitConfig caps the requests a route sends upstream per Unit across
+// every key using it, such as the quota of a deployment the route sits in
+// front of. With FairShare, the limit is split evenly between the keys that
+// sent requests to the route in the current or the previous window, so that
+// a single key cannot take the whole quota while others are waiting. Shares
+// left unused can be used by the other keys, and a key that is alone can use
+// the whole quota.
+type RateLimitConfig struct {
+	Requests  int          `json:"requests"`
+	Unit      key.TimeUnit `json:"unit"`
+	FairShare bool         `json:"fairShare"`
+}
+
+// Outcomes of taking a request from the rate limit of a route.
+const (
+	RateLimitAdmitted      = 1
+	RateLimitExceeded      = -1
+	RateLimitShareExceeded = -2
+)
+
+// Validate returns the fields of the config that are invalid.
+func (rc *RateLimitConfig) Validate() []string {
+	fields := []string{}
+	if rc.Requests <= 0 {
+		fields = append(fields, "rateLimit.requests")
+	}
+
+	if rc.Window() == 0 {
+		fields = append(fields, "rateLimit.unit")
+	}
+
+	return fields
+}
+
+// Window returns the length of the windows requests are counted in, or 0 if
+// the unit is not supported.
+func (rc *RateLimitConfig) Window() time.Duration {
+	switch rc.Unit {
+	case key.SecondTimeUnit:
+		return time.Second
+	case key.MinuteTimeUnit:
+		return time.Minute
+	case key.HourTimeUnit:
+		return time.Hour
+	case key.DayTimeUnit:
+		return 24 * time.Hour
+	}
+
+	return 0
+}
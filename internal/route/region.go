@@ -0,0 +1,106 @@
+package route
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"golang.org/x/net/http/httpguts"
+)
+
+// RegionHeader asks for the steps of a route in a region when the region
+// config of the route does not name another header. It is not forwarded
+// upstream.
+const RegionHeader = "X-BRICKS-REGION"
+
+// keys tagged with this prefix followed by a region are pinned to it
+const regionTagPrefix = "region:"
+
+// ErrNoRegionTarget is returned when no healthy step of a route is in the
+// region a request is pinned to.
+var ErrNoRegionTarget = errors.New("no healthy route step is in the requested region")
+
+// RegionConfig restricts the requests to a route to the steps tagged with
+// their region. Keys tagged with region:<name> are pinned to that region, and
+// other requests can ask for one with Header. Requests pinned to a region
+// fail rather than being sent to steps of other regions, or to steps whose
+// provider setting is down. Requests without a region use every step.
+type RegionConfig struct {
+	Enabled bool   `json:"enabled"`
+	Header  string `json:"header,omitempty"`
+}
+
+// Validate returns the fields of the config that are invalid.
+func (rc *RegionConfig) Validate() []string {
+	fields := []string{}
+	if len(rc.Header) != 0 && !httpguts.ValidHeaderFieldName(rc.Header) {
+		fields = append(fields, "region.header")
+	}
+
+	return fields
+}
+
+// regionOf returns the region a request is pinned to, or an empty string if
+// it can use steps of any region. The region of the key wins over the one
+// asked for by the request.
+func (rc *RegionConfig) regionOf(req *Request) string {
+	if req.Key != nil {
+		for _, tag := range req.Key.Tags {
+			if strings.HasPrefix(tag, regionTagPrefix) {
+				return strings.TrimPrefix(tag, regionTagPrefix)
+			}
+		}
+	}
+
+	if req.Forwarded == nil {
+		return ""
+	}
+
+	header := rc.Header
+	if len(header) == 0 {
+		header = RegionHeader
+	}
+
+	return strings.TrimSpace(req.Forwarded.Header.Get(header))
+}
+
+// region returns the region a request to the route is pinned to.
+func (r *Route) region(req *Request) string {
+	if r.Region == nil || !r.Region.Enabled {
+		return ""
+	}
+
+	return r.Region.regionOf(req)
+}
+
+// inRegion returns the steps tagged with region, keeping their order.
+func inRegion(steps []*Step, region string) []*Step {
+	matched := []*Step{}
+	for _, step := range steps {
+		if strings.EqualFold(step.Region, region) {
+			matched = append(matched, step)
+		}
+	}
+
+	return matched
+}
+
+// dropDown removes the steps whose provider setting is down.
+func dropDown(steps []*Step, req *Request) []*Step {
+	down := isDown(req)
+	if down == nil {
+		return steps
+	}
+
+	up := []*Step{}
+	for _, step := range steps {
+		if down(step) {
+			telemetry.Incr("bricksllm.route.drop_down.dropped", []string{"provider:" + step.Provider}, 1)
+			continue
+		}
+
+		up = append(up, step)
+	}
+
+	return up
+}
@@ -25,9 +25,51 @@ type recorder interface {
 	RecordEvent(e *event.Event) error
 }
 
+// CacheConfig caches the responses of a route in Redis for Ttl. Requests are
+// keyed on their normalized body and the values of the Vary headers.
 type CacheConfig struct {
-	Enabled bool   `json:"enabled"`
-	Ttl     string `json:"ttl"`
+	Enabled bool     `json:"enabled"`
+	Ttl     string   `json:"ttl"`
+	Vary    []string `json:"vary,omitempty"`
+}
+
+// SnippetConfig keeps only the first HeadChars and last TailChars characters of
+// responses to a route, for a SampleRate share of requests, so that responses can
+// be spot checked without retaining whole conversations. Keys logging full
+// responses are not affected.
+type SnippetConfig struct {
+	Enabled    bool    `json:"enabled"`
+	SampleRate float64 `json:"sampleRate"`
+	HeadChars  int     `json:"headChars"`
+	TailChars  int     `json:"tailChars"`
+}
+
+// Snippet is what is stored in place of a sampled response.
+type Snippet struct {
+	Head      string `json:"head"`
+	Tail      string `json:"tail,omitempty"`
+	Length    int    `json:"length"`
+	Truncated bool   `json:"truncated"`
+}
+
+// NewSnippet cuts the head and tail out of a response. Lengths are counted in
+// characters rather than bytes so that multi-byte characters are never split.
+func (sc *SnippetConfig) NewSnippet(response string) *Snippet {
+	runes := []rune(response)
+	s := &Snippet{
+		Length: len(runes),
+	}
+
+	if len(runes) <= sc.HeadChars+sc.TailChars {
+		s.Head = response
+		return s
+	}
+
+	s.Head = string(runes[:sc.HeadChars])
+	s.Tail = string(runes[len(runes)-sc.TailChars:])
+	s.Truncated = true
+
+	return s
 }
 
 type Step struct {
@@ -38,6 +80,9 @@ type Step struct {
 	Params        map[string]string `json:"params"`
 	Model         string            `json:"model"`
 	Timeout       string            `json:"timeout"`
+	ContextWindow int               `json:"contextWindow"`
+	Region        string            `json:"region,omitempty"`
+	Languages     []string          `json:"languages,omitempty"`
 }
 
 func ConvertToArrayOfStrings(input []any) []string {
@@ -100,7 +145,7 @@ func (s *Step) DecorateRequest(provider string, body []byte, isEmbedding bool) (
 
 		s.DecorateChatCompletionRequest(completionReq)
 
-		return json.Marshal(completionReq)
+		return toProviderFormat(provider, completionReq)
 	}
 
 	return body, nil
@@ -181,21 +226,69 @@ func (s *Step) DecorateChatCompletionRequest(req *goopenai.ChatCompletionRequest
 }
 
 type Route struct {
-	Id            string       `json:"id"`
-	RetryStrategy string       `json:"retryStrategy"`
-	RequestFormat string       `json:"requestFormat"`
-	CreatedAt     int64        `json:"createdAt"`
-	UpdatedAt     int64        `json:"updatedAt"`
-	Name          string       `json:"name"`
-	Path          string       `json:"path"`
-	KeyIds        []string     `json:"keyIds"`
-	Steps         []*Step      `json:"steps"`
-	CacheConfig   *CacheConfig `json:"cacheConfig"`
+	Id             string          `json:"id"`
+	RetryStrategy  string          `json:"retryStrategy"`
+	Strategy       string          `json:"strategy"`
+	StrategyConfig *StrategyConfig `json:"strategyConfig"`
+	RequestFormat  string          `json:"requestFormat"`
+	CreatedAt      int64           `json:"createdAt"`
+	UpdatedAt      int64           `json:"updatedAt"`
+	Name           string          `json:"name"`
+	Path           string          `json:"path"`
+	KeyIds         []string        `json:"keyIds"`
+	Steps          []*Step         `json:"steps"`
+	CacheConfig    *CacheConfig    `json:"cacheConfig"`
+	SnippetConfig  *SnippetConfig  `json:"snippetConfig"`
+	Namespace      string          `json:"namespace"`
+
+	CircuitBreaker *CircuitBreakerConfig `json:"circuitBreaker,omitempty"`
+	RetryPolicy    *key.RetryPolicy      `json:"retryPolicy,omitempty"`
+	Split          *SplitConfig          `json:"split,omitempty"`
+	Shadow         *ShadowConfig         `json:"shadow,omitempty"`
+
+	// Transport tunes the connections to the steps of the route, in place of
+	// the transport configs of their provider settings.
+	Transport *provider.TransportConfig `json:"transport,omitempty"`
+
+	Sticky *StickyConfig `json:"sticky,omitempty"`
+	Hedge  *HedgeConfig  `json:"hedge,omitempty"`
+	Queue  *QueueConfig  `json:"queue,omitempty"`
+
+	RateLimit *RateLimitConfig `json:"rateLimit,omitempty"`
+	Region    *RegionConfig    `json:"region,omitempty"`
+	Transform *TransformConfig `json:"transform,omitempty"`
+
+	// PolicyId attaches a policy to the route. It applies to requests of
+	// keys without a policy of their own, ahead of policies matched by tag.
+	PolicyId string `json:"policyId,omitempty"`
+
+	// PolicyVersions pins the policies applied to requests of the route to
+	// one of their versions, by policy id, ahead of any rollout.
+	PolicyVersions map[string]int `json:"policyVersions,omitempty"`
+
+	// Version is incremented on every update, so that concurrent updates of
+	// a route can be told apart.
+	Version int `json:"version"`
+}
+
+// AllSteps returns every step the route may send requests to: its own steps,
+// followed by the ones of its split and its shadow step, if any.
+func (r *Route) AllSteps() []*Step {
+	all := append([]*Step{}, r.Steps...)
+	if r.Split != nil {
+		all = append(all, r.Split.Steps...)
+	}
+
+	if r.Shadow != nil && r.Shadow.Step != nil {
+		all = append(all, r.Shadow.Step)
+	}
+
+	return all
 }
 
 func (r *Route) ValidateSettings(settings []*provider.Setting) bool {
 	target := map[string]bool{}
-	for _, s := range r.Steps {
+	for _, s := range r.AllSteps() {
 		target[s.Provider] = true
 	}
 
@@ -253,10 +346,82 @@ func (r *Route) RunStepsV2(req *Request, rec recorder, log *zap.Logger, kc *key.
 		return nil, err
 	}
 
+	if r.Transform != nil {
+		body, err = r.Transform.apply(body, r.ShouldRunEmbeddings())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	region := r.region(req)
+	if r.Shadow != nil && r.Shadow.sampled() && (len(region) == 0 || strings.EqualFold(r.Shadow.Step.Region, region)) {
+		r.mirror(req, rec, log, kc, body)
+	}
+
 	events := []*event.Event{}
 	response := &Response{}
 
-	for _, step := range r.Steps {
+	steps, rationale, err := r.plan(req, body, response, log)
+	if err != nil {
+		return nil, err
+	}
+
+	newEvent := func(step *Step) *event.Event {
+		evt := &event.Event{
+			SchemaVersion:    event.SchemaVersion,
+			Id:               util.NewUuid(),
+			CreatedAt:        time.Now().Unix(),
+			Tags:             kc.Tags,
+			KeyId:            kc.KeyId,
+			Provider:         step.Provider,
+			Method:           req.Forwarded.Method,
+			Path:             req.Forwarded.URL.Path,
+			Model:            step.Model,
+			Action:           req.Action,
+			Request:          []byte(`{}`),
+			Response:         []byte(`{}`),
+			CustomId:         req.Forwarded.Header.Get("X-CUSTOM-EVENT-ID"),
+			UserId:           req.UserId,
+			PolicyId:         req.PolicyId,
+			PolicyRules:      req.PolicyRules,
+			RiskScore:        req.RiskScore,
+			PolicyVersion:    req.PolicyVersion,
+			RequestHash:      req.RequestHash,
+			Language:         req.Language,
+			RouteId:          r.Id,
+			CorrelationId:    req.CorrelationId,
+			RoutingRationale: rationale,
+			Experiment:       response.Experiment,
+			Variant:          response.Variant,
+		}
+
+		if kc.ShouldLogRequest {
+			evt.Request = body
+		}
+
+		return evt
+	}
+
+	if r.Hedge != nil && r.Hedge.Enabled && len(steps) > 1 {
+		hedged, won := r.hedge(req, steps[:2], body, newEvent)
+		events = append(events, hedged...)
+
+		// steps that were raced are not tried again
+		steps = steps[2:]
+		if won != nil {
+			response.Provider = won.step.Provider
+			response.Model = won.step.Model
+			response.Response = won.res
+			response.Cancel = won.cancel
+			response.Data = won.data
+
+			if won.res.StatusCode == http.StatusOK {
+				steps = nil
+			}
+		}
+	}
+
+	for _, step := range steps {
 		dur := time.Second
 		if len(step.RetryInterval) != 0 {
 			parsed, err := time.ParseDuration(step.RetryInterval)
@@ -267,41 +432,32 @@ func (r *Route) RunStepsV2(req *Request, rec recorder, log *zap.Logger, kc *key.
 			dur = parsed
 		}
 
-		b := InitializeBackoff(r.RetryStrategy, dur)
-		withRetries := backoff.WithMaxRetries(b, uint64(step.Retries))
+		b := InitializeBackoff(r.RetryStrategy, req.Priority.ScaleRetryInterval(dur))
+		withRetries := backoff.WithMaxRetries(b, uint64(req.Priority.ScaleRetries(step.Retries)))
+
+		do := func() (err error) {
+			// a breaker that opened while retrying moves on to the next step
+			// right away
+			if !req.Breakers.Allow(r.Id, step, r.CircuitBreaker) {
+				return backoff.Permanent(ErrCircuitOpen)
+			}
 
-		do := func() error {
 			start := time.Now()
 
-			evt := &event.Event{
-				Id:            util.NewUuid(),
-				CreatedAt:     time.Now().Unix(),
-				Tags:          kc.Tags,
-				KeyId:         kc.KeyId,
-				Provider:      step.Provider,
-				Method:        req.Forwarded.Method,
-				Path:          req.Forwarded.URL.Path,
-				Model:         step.Model,
-				Action:        req.Action,
-				Request:       []byte(`{}`),
-				Response:      []byte(`{}`),
-				CustomId:      req.Forwarded.Header.Get("X-CUSTOM-EVENT-ID"),
-				UserId:        req.UserId,
-				PolicyId:      req.PolicyId,
-				RouteId:       r.Id,
-				CorrelationId: req.CorrelationId,
-			}
+			evt := newEvent(step)
 
 			defer func() {
 				evt.LatencyInMs = int(time.Since(start).Milliseconds())
+
+				if req.Tracker != nil {
+					req.Tracker.Record(step.Provider, step.Model, time.Since(start), err != nil)
+				}
+
+				req.Breakers.Record(r.Id, step, r.CircuitBreaker, time.Since(start), IsBreakerFailure(evt.Status, err))
 			}()
 
 			events = append(events, evt)
 
-			if kc.ShouldLogRequest {
-				evt.Request = body
-			}
-
 			parsed, err := time.ParseDuration(step.Timeout)
 			if err != nil {
 				return err
@@ -313,7 +469,7 @@ func (r *Route) RunStepsV2(req *Request, rec recorder, log *zap.Logger, kc *key.
 			}
 
 			shouldNotCancel := false
-			ctx, cancel := context.WithTimeout(context.Background(), parsed)
+			ctx, cancel := context.WithTimeout(req.context(), parsed)
 			defer func() {
 				if !shouldNotCancel {
 					cancel()
@@ -344,10 +500,19 @@ func (r *Route) RunStepsV2(req *Request, rec recorder, log *zap.Logger, kc *key.
 					return err
 				}
 
-				response.Data = bytes
+				normalized, err := normalizeResponseBody(step.Provider, res.StatusCode, bytes)
+				if err != nil {
+					return err
+				}
+
+				response.Data = normalized
 				return errors.New("response is not okay")
 			}
 
+			if err := normalizeResponse(step.Provider, res); err != nil {
+				return err
+			}
+
 			if kc.ShouldLogResponse {
 				evt.Response = body
 			}
@@ -389,6 +554,81 @@ func (r *Route) RunStepsV2(req *Request, rec recorder, log *zap.Logger, kc *key.
 	return nil, errors.New("no responses")
 }
 
+// plan returns the steps a request is sent to, in the order they are tried,
+// along with the rationale of the strategy that ranked them. The experiment,
+// variant and selection of the request are set on response.
+func (r *Route) plan(req *Request, body []byte, response *Response, log *zap.Logger) ([]*Step, []byte, error) {
+	steps := r.Steps
+	if r.Split != nil {
+		response.Experiment = r.Split.Experiment
+		response.Variant = r.Split.assign(req.UserId)
+
+		if response.Variant == VariantTreatment {
+			steps = r.Split.Steps
+		}
+	}
+
+	region := r.region(req)
+	if len(region) != 0 {
+		steps = inRegion(steps, region)
+		if len(steps) == 0 {
+			return nil, nil, ErrNoRegionTarget
+		}
+	}
+
+	steps = forLanguage(steps, req.Language)
+
+	var rationale []byte
+	if req.Tracker != nil && (r.Strategy == StrategyLatency || r.Strategy == StrategyCheapestCapable) {
+		var selection *Selection
+		if r.Strategy == StrategyLatency {
+			selection = req.Tracker.RankByLatency(steps, req.Costs)
+		}
+
+		if r.Strategy == StrategyCheapestCapable {
+			counter, _ := req.Costs["openai"].(promptTokenCounter)
+			promptTks, completionTks := estimateRequestTokens(body, r.ShouldRunEmbeddings(), counter)
+			selection = req.Tracker.RankByCost(steps, req.Costs, promptTks, completionTks, r.StrategyConfig, isDown(req), wantsPremium(req))
+		}
+
+		steps = selection.Steps()
+		if len(steps) == 0 {
+			return nil, nil, errors.New(selection.Reason)
+		}
+
+		response.Selection = selection
+
+		data, err := json.Marshal(selection)
+		if err != nil {
+			log.Debug("error when marshalling route selection", zap.Error(err))
+		}
+
+		if err == nil {
+			rationale = data
+		}
+	}
+
+	if r.Sticky != nil && r.Sticky.Enabled {
+		steps = r.Sticky.stick(steps, r.Sticky.keyOf(req, body))
+	}
+
+	steps = demoteDown(steps, req)
+
+	steps = skipOpen(steps, r, req)
+	if len(steps) == 0 {
+		return nil, nil, ErrCircuitOpen
+	}
+
+	if len(region) != 0 {
+		steps = dropDown(steps, req)
+		if len(steps) == 0 {
+			return nil, nil, ErrNoRegionTarget
+		}
+	}
+
+	return steps, rationale, nil
+}
+
 func (r *Route) RunSteps(req *Request, rec recorder, log *zap.Logger) (*Response, error) {
 	if len(r.Steps) == 0 {
 		return nil, errors.New("steps are empty")
@@ -459,6 +699,7 @@ func (r *Route) RunSteps(req *Request, rec recorder, log *zap.Logger) (*Response
 			currentRetry++
 
 			evt := &event.Event{
+				SchemaVersion: event.SchemaVersion,
 				Id:            util.NewUuid(),
 				CreatedAt:     time.Now().Unix(),
 				Provider:      step.Provider,
@@ -471,6 +712,11 @@ func (r *Route) RunSteps(req *Request, rec recorder, log *zap.Logger) (*Response
 				CustomId:      req.Forwarded.Header.Get("X-CUSTOM-EVENT-ID"),
 				UserId:        req.UserId,
 				PolicyId:      req.PolicyId,
+				PolicyRules:   req.PolicyRules,
+				RiskScore:     req.RiskScore,
+				PolicyVersion: req.PolicyVersion,
+				RequestHash:   req.RequestHash,
+				Language:      req.Language,
 				RouteId:       r.Id,
 				CorrelationId: req.CorrelationId,
 			}
@@ -621,8 +867,30 @@ type Request struct {
 	Response      []byte
 	UserId        string
 	PolicyId      string
+	PolicyRules   []string
+	RiskScore     float64
+	PolicyVersion int
+	RequestHash   string
+	Language      string
 	Action        string
 	CorrelationId string
+	Tracker       *Tracker
+	Health        HealthChecker
+	Breakers      *Breakers
+	Context       context.Context
+	Costs         map[string]CostEstimator
+	Priority      key.Priority
+}
+
+// context returns the context upstream requests of the route are created
+// with. It carries values of the proxy, such as the retry policy, and is not
+// canceled along with the client request.
+func (r *Request) context() context.Context {
+	if r.Context == nil {
+		return context.Background()
+	}
+
+	return r.Context
 }
 
 func (r *Request) GetSettingValue(provider string, param string) (string, error) {
@@ -640,12 +908,28 @@ func (r *Request) GetSettingValue(provider string, param string) (string, error)
 	return "", errors.New(fmt.Sprintf("%s setting is not found", provider))
 }
 
+// withSettingTransport carries the transport config of the provider setting
+// used for name in ctx, unless ctx already has the one of the route.
+func (r *Request) withSettingTransport(ctx context.Context, name string) context.Context {
+	for _, setting := range r.Settings {
+		if setting.Provider == name {
+			return provider.WithTransportConfig(ctx, setting.Transport)
+		}
+	}
+
+	return ctx
+}
+
 type Response struct {
-	Provider string
-	Model    string
-	Data     []byte
-	Cancel   context.CancelFunc
-	Response *http.Response
+	Provider  string
+	Model     string
+	Data      []byte
+	Cancel    context.CancelFunc
+	Response  *http.Response
+	Selection *Selection
+
+	Experiment string
+	Variant    string
 }
 
 func buildRequestUrl(provider string, runEmbeddings bool, resourceName string, params map[string]string) string {
@@ -668,6 +952,10 @@ func buildRequestUrl(provider string, runEmbeddings bool, resourceName string, p
 		return fmt.Sprintf("https://%s.openai.azure.com/openai/deployments/%s/chat/completions?api-version=%s", resourceName, deploymentId, apiVersion)
 	}
 
+	if provider == "anthropic" && !runEmbeddings {
+		return anthropicMessagesUrl
+	}
+
 	return ""
 }
 
@@ -691,21 +979,38 @@ func (r *Request) createHttpRequest(ctx context.Context, provider string, runEmb
 		resourceName = val
 	}
 
+	// self hosted providers do not require an api key
 	key, err := r.GetSettingValue(provider, "apikey")
-	if err != nil {
+	if err != nil && !isSelfHosted(provider) {
 		return nil, err
 	}
 
 	url := buildRequestUrl(provider, runEmbeddings, resourceName, params)
+	if isSelfHosted(provider) {
+		base, err := r.GetSettingValue(provider, "url")
+		if err != nil {
+			return nil, err
+		}
+
+		url = selfHostedUrl(base, runEmbeddings)
+	}
+
 	if len(url) == 0 {
 		return nil, errors.New("request url is empty")
 	}
 
+	ctx = r.withSettingTransport(ctx, provider)
+
 	hreq, err := http.NewRequestWithContext(ctx, r.Forwarded.Method, url, io.NopCloser(bytes.NewReader(data)))
+	if err != nil {
+		return nil, err
+	}
 
 	if provider == "azure" {
 		hreq.Header.Set("api-key", key)
-	} else {
+	} else if provider == "anthropic" {
+		hreq.Header.Set("x-api-key", key)
+	} else if len(key) != 0 {
 		hreq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", key))
 	}
 
@@ -714,7 +1019,7 @@ func (r *Request) createHttpRequest(ctx context.Context, provider string, runEmb
 			continue
 		}
 
-		if strings.HasPrefix(strings.ToLower(k), "api-key") {
+		if strings.HasPrefix(strings.ToLower(k), "api-key") || strings.ToLower(k) == "x-api-key" {
 			continue
 		}
 
@@ -722,8 +1027,16 @@ func (r *Request) createHttpRequest(ctx context.Context, provider string, runEmb
 			continue
 		}
 
+		if strings.EqualFold(k, PremiumHeader) || strings.EqualFold(k, CacheBypassHeader) || strings.EqualFold(k, RegionHeader) {
+			continue
+		}
+
 		hreq.Header.Set(k, r.Forwarded.Header.Get(k))
 	}
 
-	return hreq, err
+	if provider == "anthropic" && len(hreq.Header.Get("anthropic-version")) == 0 {
+		hreq.Header.Set("anthropic-version", anthropicDefaultVersion)
+	}
+
+	return hreq, nil
 }
@@ -0,0 +1,241 @@
+package route
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	StrategyFallback = "fallback"
+	StrategyLatency  = "latency"
+)
+
+// targets whose effective latency is within this ratio of the fastest one are
+// considered equally fast and the cheapest of them is selected.
+const latencyTolerance = 0.1
+
+type CostEstimator interface {
+	EstimateTotalCost(model string, promptTks, completionTks int) (float64, error)
+}
+
+type sample struct {
+	at          time.Time
+	latencyInMs int64
+	failed      bool
+}
+
+type targetStats struct {
+	samples []sample
+	next    int
+}
+
+// Tracker keeps rolling latency and error samples per provider and model.
+type Tracker struct {
+	lock   sync.RWMutex
+	window time.Duration
+	size   int
+	stats  map[string]*targetStats
+}
+
+func NewTracker(window time.Duration, size int) *Tracker {
+	return &Tracker{
+		window: window,
+		size:   size,
+		stats:  map[string]*targetStats{},
+	}
+}
+
+func targetKey(provider, model string) string {
+	return provider + "/" + model
+}
+
+func (t *Tracker) Record(provider, model string, latency time.Duration, failed bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	k := targetKey(provider, model)
+	ts, ok := t.stats[k]
+	if !ok {
+		ts = &targetStats{}
+		t.stats[k] = ts
+	}
+
+	s := sample{at: time.Now(), latencyInMs: latency.Milliseconds(), failed: failed}
+	if len(ts.samples) < t.size {
+		ts.samples = append(ts.samples, s)
+		return
+	}
+
+	ts.samples[ts.next] = s
+	ts.next = (ts.next + 1) % t.size
+}
+
+// Snapshot returns the average latency of successful requests, the error rate and
+// the number of samples collected within the window.
+func (t *Tracker) Snapshot(provider, model string) (float64, float64, int) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	ts, ok := t.stats[targetKey(provider, model)]
+	if !ok {
+		return 0, 0, 0
+	}
+
+	cutoff := time.Now().Add(-t.window)
+	count, failures, succeeded := 0, 0, 0
+	var total int64 = 0
+
+	for _, s := range ts.samples {
+		if s.at.Before(cutoff) {
+			continue
+		}
+
+		count++
+		if s.failed {
+			failures++
+			continue
+		}
+
+		succeeded++
+		total += s.latencyInMs
+	}
+
+	if count == 0 {
+		return 0, 0, 0
+	}
+
+	avg := 0.0
+	if succeeded != 0 {
+		avg = float64(total) / float64(succeeded)
+	}
+
+	return avg, float64(failures) / float64(count), count
+}
+
+type Candidate struct {
+	Provider              string   `json:"provider"`
+	Model                 string   `json:"model"`
+	AvgLatencyInMs        float64  `json:"avgLatencyInMs"`
+	ErrorRate             float64  `json:"errorRate"`
+	Samples               int      `json:"samples"`
+	Score                 float64  `json:"score"`
+	CostPerThousandTokens *float64 `json:"costPerThousandTokens,omitempty"`
+	EstimatedCostInUsd    *float64 `json:"estimatedCostInUsd,omitempty"`
+	ContextWindow         int      `json:"contextWindow,omitempty"`
+	Excluded              string   `json:"excluded,omitempty"`
+
+	step *Step
+	skip bool
+}
+
+// Selection records why steps were attempted in a given order.
+type Selection struct {
+	Strategy   string       `json:"strategy"`
+	Selected   string       `json:"selected"`
+	Reason     string       `json:"reason"`
+	Candidates []*Candidate `json:"candidates"`
+}
+
+func (s *Selection) Steps() []*Step {
+	steps := []*Step{}
+	for _, c := range s.Candidates {
+		if c.skip {
+			continue
+		}
+
+		steps = append(steps, c.step)
+	}
+
+	return steps
+}
+
+func cheaper(a, b *Candidate) bool {
+	if a.CostPerThousandTokens == nil {
+		return false
+	}
+
+	if b.CostPerThousandTokens == nil {
+		return true
+	}
+
+	return *a.CostPerThousandTokens < *b.CostPerThousandTokens
+}
+
+// RankByLatency orders steps by effective latency, which is the average latency
+// inflated by the error rate. Targets without samples are tried first so that
+// every target gets measured.
+func (t *Tracker) RankByLatency(steps []*Step, costs map[string]CostEstimator) *Selection {
+	candidates := []*Candidate{}
+	for _, step := range steps {
+		avg, errRate, count := t.Snapshot(step.Provider, step.Model)
+
+		c := &Candidate{
+			Provider:       step.Provider,
+			Model:          step.Model,
+			AvgLatencyInMs: avg,
+			ErrorRate:      errRate,
+			Samples:        count,
+			step:           step,
+		}
+
+		if count != 0 {
+			success := 1 - errRate
+			if success < 0.05 {
+				success = 0.05
+			}
+
+			if avg == 0 {
+				avg = 1
+			}
+
+			c.Score = avg / success
+		}
+
+		if ce, ok := costs[step.Provider]; ok && ce != nil {
+			if cost, err := ce.EstimateTotalCost(step.Model, 1000, 1000); err == nil {
+				c.CostPerThousandTokens = &cost
+			}
+		}
+
+		candidates = append(candidates, c)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score < candidates[j].Score
+	})
+
+	selection := &Selection{
+		Strategy:   StrategyLatency,
+		Candidates: candidates,
+	}
+
+	if len(candidates) == 0 {
+		return selection
+	}
+
+	best := candidates[0].Score
+	tier := 0
+	for tier < len(candidates) && candidates[tier].Score <= best*(1+latencyTolerance) {
+		tier++
+	}
+
+	sort.SliceStable(candidates[:tier], func(i, j int) bool {
+		return cheaper(candidates[i], candidates[j])
+	})
+
+	selected := candidates[0]
+	selection.Selected = targetKey(selected.Provider, selected.Model)
+
+	switch {
+	case selected.Samples == 0:
+		selection.Reason = "no latency samples collected for target yet"
+	case tier > 1:
+		selection.Reason = fmt.Sprintf("cheapest of %d targets within %.0f%% of the lowest effective latency", tier, latencyTolerance*100)
+	default:
+		selection.Reason = "lowest effective latency"
+	}
+
+	return selection
+}
@@ -0,0 +1,161 @@
+package route
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"go.uber.org/zap"
+)
+
+const VariantShadow = "shadow"
+
+// maxInflightShadows bounds the mirrored requests a gateway waits on at once,
+// so that a slow shadow target cannot pile up goroutines. Requests beyond it
+// are not mirrored.
+const maxInflightShadows = 64
+
+var shadowSlots = make(chan struct{}, maxInflightShadows)
+
+// ShadowConfig mirrors Percentage percent of the requests to a route to Step
+// in the background. Responses of the mirror are never returned to clients.
+// Only their status, latency, token counts and cost are recorded, as events of
+// the shadow variant of Experiment, so that a new provider can be evaluated on
+// production traffic safely.
+type ShadowConfig struct {
+	Experiment string  `json:"experiment"`
+	Percentage float64 `json:"percentage"`
+	Step       *Step   `json:"step"`
+}
+
+// Validate returns the fields of the config that are invalid. The step is
+// validated along with the ones of the route.
+func (sc *ShadowConfig) Validate() []string {
+	fields := []string{}
+	if sc.Percentage <= 0 || sc.Percentage > 100 {
+		fields = append(fields, "shadow.percentage")
+	}
+
+	if sc.Step == nil {
+		fields = append(fields, "shadow.step")
+	}
+
+	return fields
+}
+
+func (sc *ShadowConfig) sampled() bool {
+	return sc.Step != nil && rand.Float64()*100 < sc.Percentage
+}
+
+// mirror sends a copy of a request to the shadow step of the route without
+// waiting for it. The upstream request is built right away since the
+// forwarded request is not ours to read once the route has responded.
+func (r *Route) mirror(req *Request, rec recorder, log *zap.Logger, kc *key.ResponseKey, body []byte) {
+	select {
+	case shadowSlots <- struct{}{}:
+	default:
+		telemetry.Incr("bricksllm.route.mirror.dropped", nil, 1)
+		return
+	}
+
+	step := r.Shadow.Step
+	release := func() { <-shadowSlots }
+
+	timeout, err := time.ParseDuration(step.Timeout)
+	if err != nil {
+		release()
+		return
+	}
+
+	bs, err := step.DecorateRequest(step.Provider, body, r.ShouldRunEmbeddings())
+	if err != nil {
+		release()
+		telemetry.Incr("bricksllm.route.mirror.decorate_request_error", nil, 1)
+		return
+	}
+
+	// mirrors never stream so that their usage can be read off the response
+	bs, _ = sjson.DeleteBytes(bs, "stream")
+	bs, _ = sjson.DeleteBytes(bs, "stream_options")
+
+	ctx, cancel := context.WithTimeout(provider.WithTransportConfig(context.Background(), r.Transport), timeout)
+	hreq, err := req.createHttpRequest(ctx, step.Provider, r.ShouldRunEmbeddings(), step.Params, bs)
+	if err != nil {
+		cancel()
+		release()
+		telemetry.Incr("bricksllm.route.mirror.create_http_request_error", nil, 1)
+		return
+	}
+
+	evt := &event.Event{
+		SchemaVersion: event.SchemaVersion,
+		Id:            util.NewUuid(),
+		CreatedAt:     time.Now().Unix(),
+		Tags:          kc.Tags,
+		KeyId:         kc.KeyId,
+		Provider:      step.Provider,
+		Method:        req.Forwarded.Method,
+		Path:          req.Forwarded.URL.Path,
+		Model:         step.Model,
+		Request:       []byte(`{}`),
+		Response:      []byte(`{}`),
+		UserId:        req.UserId,
+		RouteId:       r.Id,
+		CorrelationId: req.CorrelationId,
+		Experiment:    r.Shadow.Experiment,
+		Variant:       VariantShadow,
+	}
+
+	estimator := req.Costs[step.Provider]
+
+	go func() {
+		defer release()
+		defer cancel()
+
+		start := time.Now()
+		res, err := req.Client.Do(hreq)
+		evt.LatencyInMs = int(time.Since(start).Milliseconds())
+
+		if err != nil {
+			telemetry.Incr("bricksllm.route.mirror.http_client_error", []string{"provider:" + step.Provider}, 1)
+			log.Debug("error when mirroring request to shadow step", zap.Error(err))
+		}
+
+		if err == nil {
+			defer res.Body.Close()
+
+			evt.Status = res.StatusCode
+			data, err := io.ReadAll(res.Body)
+			if err == nil && res.StatusCode == http.StatusOK {
+				data, err = normalizeResponseBody(step.Provider, res.StatusCode, data)
+			}
+
+			if err == nil && res.StatusCode == http.StatusOK {
+				evt.PromptTokenCount = int(gjson.GetBytes(data, "usage.prompt_tokens").Int())
+				evt.CompletionTokenCount = int(gjson.GetBytes(data, "usage.completion_tokens").Int())
+			}
+
+			if estimator != nil {
+				if cost, err := estimator.EstimateTotalCost(step.Model, evt.PromptTokenCount, evt.CompletionTokenCount); err == nil {
+					evt.CostInUsd = cost
+					evt.PricedInUsd()
+				}
+			}
+		}
+
+		telemetry.Incr("bricksllm.route.mirror.mirrored", []string{"provider:" + step.Provider}, 1)
+
+		if err := rec.RecordEvent(evt); err != nil {
+			log.Debug("error when recording shadow event", zap.Error(err))
+		}
+	}()
+}
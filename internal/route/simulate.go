@@ -0,0 +1,203 @@
+package route
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+const redactedHeaderValue = "[REDACTED]"
+
+// TestRequest is a synthetic request sent through a route without reaching
+// its upstreams. The policy and provider settings of KeyId are used when it
+// is set, and SettingIds otherwise. Nothing is charged to the key.
+type TestRequest struct {
+	Request    json.RawMessage   `json:"request"`
+	KeyId      string            `json:"keyId,omitempty"`
+	SettingIds []string          `json:"settingIds,omitempty"`
+	UserId     string            `json:"userId,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+}
+
+func (r *TestRequest) Validate() error {
+	if len(r.Request) == 0 || !json.Valid(r.Request) {
+		return internal_errors.NewInvalidFieldsError([]string{"request"})
+	}
+
+	return nil
+}
+
+type TestTarget struct {
+	Provider string            `json:"provider"`
+	Model    string            `json:"model"`
+	Params   map[string]string `json:"params,omitempty"`
+}
+
+func testTargetOf(s *Step) *TestTarget {
+	return &TestTarget{
+		Provider: s.Provider,
+		Model:    s.Model,
+		Params:   s.Params,
+	}
+}
+
+// TestUpstreamRequest is the request the chosen target would be sent, with
+// credentials redacted.
+type TestUpstreamRequest struct {
+	Method  string            `json:"method"`
+	Url     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    json.RawMessage   `json:"body"`
+}
+
+// TestPolicy is the outcome of the policy of the key. Only regular
+// expression rules are evaluated, since the other rules call detection
+// services that are billed.
+type TestPolicy struct {
+	PolicyId     string   `json:"policyId"`
+	Action       string   `json:"action"`
+	Detail       string   `json:"detail,omitempty"`
+	NotEvaluated []string `json:"notEvaluated,omitempty"`
+	Language     string   `json:"language,omitempty"`
+}
+
+type TestCache struct {
+	Enabled  bool   `json:"enabled"`
+	Ttl      string `json:"ttl,omitempty"`
+	Bypassed bool   `json:"bypassed"`
+}
+
+type TestTimings struct {
+	PolicyInMicros int64 `json:"policyInMicros"`
+	PlanInMicros   int64 `json:"planInMicros"`
+	BuildInMicros  int64 `json:"buildInMicros"`
+	TotalInMicros  int64 `json:"totalInMicros"`
+}
+
+type TestResult struct {
+	RouteId    string               `json:"routeId"`
+	Policy     *TestPolicy          `json:"policy,omitempty"`
+	Cache      *TestCache           `json:"cache"`
+	Experiment string               `json:"experiment,omitempty"`
+	Variant    string               `json:"variant,omitempty"`
+	Selection  *Selection           `json:"selection,omitempty"`
+	Target     *TestTarget          `json:"target,omitempty"`
+	Fallbacks  []*TestTarget        `json:"fallbacks"`
+	Upstream   *TestUpstreamRequest `json:"upstream,omitempty"`
+	Timings    *TestTimings         `json:"timings"`
+	Notes      []string             `json:"notes,omitempty"`
+	Error      string               `json:"error,omitempty"`
+}
+
+// Simulate plans a request the way RunStepsV2 does and builds the upstream
+// request of the chosen target without sending it. Failures to plan or build
+// are reported on the result.
+func (r *Route) Simulate(req *Request, body []byte, result *TestResult, log *zap.Logger) {
+	result.RouteId = r.Id
+	result.Fallbacks = []*TestTarget{}
+	if result.Timings == nil {
+		result.Timings = &TestTimings{}
+	}
+
+	result.Cache = &TestCache{Bypassed: true}
+	if r.CacheConfig != nil {
+		result.Cache.Enabled = r.CacheConfig.Enabled
+		result.Cache.Ttl = r.CacheConfig.Ttl
+	}
+
+	if r.Strategy == StrategyLatency || r.Strategy == StrategyCheapestCapable {
+		result.Notes = append(result.Notes, "steps are not ranked by the "+r.Strategy+" strategy since its samples are kept by the gateways serving the route")
+	}
+
+	if r.Hedge != nil && r.Hedge.Enabled {
+		result.Notes = append(result.Notes, "the first fallback is raced against the target when hedging")
+	}
+
+	if r.Transform != nil {
+		transformed, err := r.Transform.apply(body, r.ShouldRunEmbeddings())
+		if err != nil {
+			result.Error = err.Error()
+			return
+		}
+
+		body = transformed
+	}
+
+	start := time.Now()
+	response := &Response{}
+	steps, _, err := r.plan(req, body, response, log)
+	result.Timings.PlanInMicros = time.Since(start).Microseconds()
+
+	result.Experiment = response.Experiment
+	result.Variant = response.Variant
+	result.Selection = response.Selection
+
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+
+	result.Target = testTargetOf(steps[0])
+	for _, step := range steps[1:] {
+		result.Fallbacks = append(result.Fallbacks, testTargetOf(step))
+	}
+
+	start = time.Now()
+	defer func() {
+		result.Timings.BuildInMicros = time.Since(start).Microseconds()
+	}()
+
+	upstream, err := r.buildUpstream(req, steps[0], body)
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+
+	result.Upstream = upstream
+}
+
+func (r *Route) buildUpstream(req *Request, step *Step, body []byte) (*TestUpstreamRequest, error) {
+	bs, err := step.DecorateRequest(step.Provider, body, r.ShouldRunEmbeddings())
+	if err != nil {
+		return nil, err
+	}
+
+	hreq, err := req.createHttpRequest(context.Background(), step.Provider, r.ShouldRunEmbeddings(), step.Params, bs)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(hreq.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if !json.Valid(data) {
+		return nil, errors.New("upstream request body is not valid json")
+	}
+
+	headers := map[string]string{}
+	for name := range hreq.Header {
+		lowered := strings.ToLower(name)
+		if lowered == "authorization" || lowered == "api-key" || lowered == "x-api-key" {
+			headers[name] = redactedHeaderValue
+			continue
+		}
+
+		headers[name] = hreq.Header.Get(name)
+	}
+
+	return &TestUpstreamRequest{
+		Method:  hreq.Method,
+		Url:     hreq.URL.String(),
+		Headers: headers,
+		Body:    data,
+	}, nil
+}
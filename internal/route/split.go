@@ -0,0 +1,58 @@
+package route
+
+import (
+	"hash/fnv"
+	"math/rand"
+)
+
+const (
+	VariantControl   = "control"
+	VariantTreatment = "treatment"
+)
+
+// SplitConfig sends Percentage percent of the requests to a route to Steps
+// instead of the steps of the route, so that two model configurations can be
+// compared on the same production traffic. Events are tagged with Experiment
+// and the variant they were assigned to. Requests of a user stick to one
+// variant, so that feedback is not split across both.
+type SplitConfig struct {
+	Experiment string  `json:"experiment"`
+	Percentage float64 `json:"percentage"`
+	Steps      []*Step `json:"steps"`
+}
+
+// Validate returns the fields of the config that are invalid. Steps are
+// validated along with the ones of the route.
+func (sc *SplitConfig) Validate() []string {
+	fields := []string{}
+	if len(sc.Experiment) == 0 {
+		fields = append(fields, "split.experiment")
+	}
+
+	if sc.Percentage < 0 || sc.Percentage > 100 {
+		fields = append(fields, "split.percentage")
+	}
+
+	if len(sc.Steps) == 0 {
+		fields = append(fields, "split.steps")
+	}
+
+	return fields
+}
+
+// assign picks the variant of a request. Users are hashed into a bucket per
+// experiment, and anonymous requests are assigned at random.
+func (sc *SplitConfig) assign(userId string) string {
+	roll := rand.Float64() * 100
+	if len(userId) != 0 {
+		h := fnv.New32a()
+		h.Write([]byte(sc.Experiment + "/" + userId))
+		roll = float64(h.Sum32()%10000) / 100
+	}
+
+	if roll < sc.Percentage {
+		return VariantTreatment
+	}
+
+	return VariantControl
+}
@@ -0,0 +1,68 @@
+package route
+
+import (
+	"hash/fnv"
+
+	"github.com/tidwall/gjson"
+	"golang.org/x/net/http/httpguts"
+)
+
+// StickyConfig pins the requests of an end user to one step of a route, so
+// that repeated requests keep hitting the same upstream and benefit from its
+// prompt cache. Users are identified by Header when it is set on a request,
+// and by the user field of the request body otherwise. Steps are picked by
+// rendezvous hashing, so that adding or removing a step only moves the users
+// of that step. The other steps are kept as fallbacks in their usual order.
+type StickyConfig struct {
+	Enabled bool   `json:"enabled"`
+	Header  string `json:"header,omitempty"`
+}
+
+// Validate returns the fields of the config that are invalid.
+func (sc *StickyConfig) Validate() []string {
+	fields := []string{}
+	if len(sc.Header) != 0 && !httpguts.ValidHeaderFieldName(sc.Header) {
+		fields = append(fields, "sticky.header")
+	}
+
+	return fields
+}
+
+// keyOf returns what identifies the end user of a request, or an empty
+// string for anonymous requests.
+func (sc *StickyConfig) keyOf(req *Request, body []byte) string {
+	if len(sc.Header) != 0 && req.Forwarded != nil {
+		if val := req.Forwarded.Header.Get(sc.Header); len(val) != 0 {
+			return val
+		}
+	}
+
+	return gjson.GetBytes(body, "user").String()
+}
+
+// stick moves the step picked for key to the front of steps.
+func (sc *StickyConfig) stick(steps []*Step, key string) []*Step {
+	if len(key) == 0 || len(steps) < 2 {
+		return steps
+	}
+
+	picked := 0
+	var best uint64
+	for idx, step := range steps {
+		h := fnv.New64a()
+		h.Write([]byte(key + "/" + step.Provider + "/" + step.Model + "/" + step.Params["deploymentId"]))
+		if score := h.Sum64(); idx == 0 || score > best {
+			picked, best = idx, score
+		}
+	}
+
+	if picked == 0 {
+		return steps
+	}
+
+	stuck := make([]*Step, 0, len(steps))
+	stuck = append(stuck, steps[picked])
+	stuck = append(stuck, steps[:picked]...)
+
+	return append(stuck, steps[picked+1:]...)
+}
@@ -0,0 +1,160 @@
+package route
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Modes of the system message of a transform config.
+const (
+	SystemMessageInject   = "inject"
+	SystemMessageOverride = "override"
+)
+
+// TransformConfig rewrites the requests to a route before they are sent to
+// any of its steps. SystemMessage is added in front of the messages of chat
+// completion requests, and replaces their own system messages when
+// SystemMessageMode is override. PromptPrefix and PromptSuffix are added to
+// the last user message, such as compliance boilerplate. MaxTokensCeiling
+// caps the completion tokens a request can ask for, and StripParams removes
+// top level parameters that the upstreams of the route do not support.
+type TransformConfig struct {
+	SystemMessage     string   `json:"systemMessage,omitempty"`
+	SystemMessageMode string   `json:"systemMessageMode,omitempty"`
+	PromptPrefix      string   `json:"promptPrefix,omitempty"`
+	PromptSuffix      string   `json:"promptSuffix,omitempty"`
+	MaxTokensCeiling  int      `json:"maxTokensCeiling,omitempty"`
+	StripParams       []string `json:"stripParams,omitempty"`
+}
+
+// params requests to a route cannot do without
+var requiredParams = map[string]bool{
+	"model":    true,
+	"messages": true,
+	"input":    true,
+}
+
+// Validate returns the fields of the config that are invalid.
+func (tc *TransformConfig) Validate() []string {
+	fields := []string{}
+	if len(tc.SystemMessageMode) != 0 && tc.SystemMessageMode != SystemMessageInject && tc.SystemMessageMode != SystemMessageOverride {
+		fields = append(fields, "transform.systemMessageMode")
+	}
+
+	if tc.SystemMessageMode == SystemMessageOverride && len(tc.SystemMessage) == 0 {
+		fields = append(fields, "transform.systemMessage")
+	}
+
+	if tc.MaxTokensCeiling < 0 {
+		fields = append(fields, "transform.maxTokensCeiling")
+	}
+
+	for _, param := range tc.StripParams {
+		if len(param) == 0 || requiredParams[param] || strings.ContainsAny(param, ".*?|#@") {
+			fields = append(fields, "transform.stripParams")
+			break
+		}
+	}
+
+	return fields
+}
+
+// Set reports whether any transform is configured.
+func (tc *TransformConfig) Set() bool {
+	return len(tc.SystemMessage) != 0 || len(tc.PromptPrefix) != 0 || len(tc.PromptSuffix) != 0 || tc.MaxTokensCeiling != 0 || len(tc.StripParams) != 0
+}
+
+// apply returns body with the transforms of the config applied. Only the
+// parameters are stripped from embeddings requests.
+func (tc *TransformConfig) apply(body []byte, embeddings bool) ([]byte, error) {
+	var err error
+	for _, param := range tc.StripParams {
+		body, err = sjson.DeleteBytes(body, param)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if embeddings {
+		return body, nil
+	}
+
+	if tc.MaxTokensCeiling > 0 {
+		for _, param := range []string{"max_tokens", "max_completion_tokens"} {
+			requested := gjson.GetBytes(body, param)
+			if requested.Exists() && requested.Int() <= int64(tc.MaxTokensCeiling) {
+				continue
+			}
+
+			if !requested.Exists() && param == "max_completion_tokens" {
+				continue
+			}
+
+			body, err = sjson.SetBytes(body, param, tc.MaxTokensCeiling)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(tc.SystemMessage) == 0 && len(tc.PromptPrefix) == 0 && len(tc.PromptSuffix) == 0 {
+		return body, nil
+	}
+
+	messages := []map[string]any{}
+	if raw := gjson.GetBytes(body, "messages"); raw.Exists() {
+		if err := json.Unmarshal([]byte(raw.Raw), &messages); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(tc.PromptPrefix) != 0 || len(tc.PromptSuffix) != 0 {
+		for idx := len(messages) - 1; idx >= 0; idx-- {
+			if messages[idx]["role"] == "user" {
+				messages[idx]["content"] = tc.wrap(messages[idx]["content"])
+				break
+			}
+		}
+	}
+
+	if len(tc.SystemMessage) != 0 {
+		kept := []map[string]any{{"role": "system", "content": tc.SystemMessage}}
+		for _, m := range messages {
+			if tc.SystemMessageMode == SystemMessageOverride && m["role"] == "system" {
+				continue
+			}
+
+			kept = append(kept, m)
+		}
+
+		messages = kept
+	}
+
+	return sjson.SetBytes(body, "messages", messages)
+}
+
+// wrap adds the prompt prefix and suffix to the content of a message, which
+// is either a string or a list of content parts.
+func (tc *TransformConfig) wrap(content any) any {
+	switch parts := content.(type) {
+	case string:
+		return tc.PromptPrefix + parts + tc.PromptSuffix
+	case []any:
+		wrapped := []any{}
+		if len(tc.PromptPrefix) != 0 {
+			wrapped = append(wrapped, map[string]any{"type": "text", "text": tc.PromptPrefix})
+		}
+
+		wrapped = append(wrapped, parts...)
+		if len(tc.PromptSuffix) != 0 {
+			wrapped = append(wrapped, map[string]any{"type": "text", "text": tc.PromptSuffix})
+		}
+
+		return wrapped
+	}
+
+	return content
+}
@@ -0,0 +1,172 @@
+package route
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+)
+
+// UpdateRoute changes the fields of a route that are set. Version is the
+// version of the route the update was made against, and the update is
+// rejected if the route has changed since. Paths and namespaces cannot be
+// changed. A split without steps, a shadow without a step, an empty
+// transport or transform config, a disabled sticky, hedge or region config,
+// a queue without a concurrency limit or a rate limit without requests
+// removes it. An empty policy id detaches the policy of the route.
+type UpdateRoute struct {
+	Version        *int                  `json:"version"`
+	Name           *string               `json:"name"`
+	RetryStrategy  *string               `json:"retryStrategy"`
+	Strategy       *string               `json:"strategy"`
+	StrategyConfig *StrategyConfig       `json:"strategyConfig"`
+	RequestFormat  *string               `json:"requestFormat"`
+	KeyIds         *[]string             `json:"keyIds"`
+	Steps          *[]*Step              `json:"steps"`
+	CacheConfig    *CacheConfig          `json:"cacheConfig"`
+	SnippetConfig  *SnippetConfig        `json:"snippetConfig"`
+	CircuitBreaker *CircuitBreakerConfig `json:"circuitBreaker"`
+	RetryPolicy    *key.RetryPolicy      `json:"retryPolicy"`
+	Split          *SplitConfig          `json:"split"`
+	Shadow         *ShadowConfig         `json:"shadow"`
+
+	Transport *provider.TransportConfig `json:"transport"`
+	Sticky    *StickyConfig             `json:"sticky"`
+	Hedge     *HedgeConfig              `json:"hedge"`
+	Queue     *QueueConfig              `json:"queue"`
+	RateLimit *RateLimitConfig          `json:"rateLimit"`
+	Region    *RegionConfig             `json:"region"`
+	Transform *TransformConfig          `json:"transform"`
+
+	PolicyId       *string         `json:"policyId"`
+	PolicyVersions *map[string]int `json:"policyVersions"`
+}
+
+// Apply sets the fields of the update on r.
+func (ur *UpdateRoute) Apply(r *Route) {
+	if ur.Name != nil {
+		r.Name = *ur.Name
+	}
+
+	if ur.RetryStrategy != nil {
+		r.RetryStrategy = *ur.RetryStrategy
+	}
+
+	if ur.Strategy != nil {
+		r.Strategy = *ur.Strategy
+	}
+
+	if ur.StrategyConfig != nil {
+		r.StrategyConfig = ur.StrategyConfig
+	}
+
+	if ur.RequestFormat != nil {
+		r.RequestFormat = *ur.RequestFormat
+	}
+
+	if ur.KeyIds != nil {
+		r.KeyIds = *ur.KeyIds
+	}
+
+	if ur.Steps != nil {
+		r.Steps = *ur.Steps
+	}
+
+	if ur.CacheConfig != nil {
+		r.CacheConfig = ur.CacheConfig
+	}
+
+	if ur.SnippetConfig != nil {
+		r.SnippetConfig = ur.SnippetConfig
+	}
+
+	if ur.CircuitBreaker != nil {
+		r.CircuitBreaker = ur.CircuitBreaker
+	}
+
+	if ur.RetryPolicy != nil {
+		r.RetryPolicy = ur.RetryPolicy
+	}
+
+	if ur.Split != nil {
+		r.Split = ur.Split
+		if len(ur.Split.Steps) == 0 {
+			r.Split = nil
+		}
+	}
+
+	if ur.Transport != nil {
+		r.Transport = ur.Transport
+		if !ur.Transport.Set() {
+			r.Transport = nil
+		}
+	}
+
+	if ur.Sticky != nil {
+		r.Sticky = ur.Sticky
+		if !ur.Sticky.Enabled {
+			r.Sticky = nil
+		}
+	}
+
+	if ur.Hedge != nil {
+		r.Hedge = ur.Hedge
+		if !ur.Hedge.Enabled {
+			r.Hedge = nil
+		}
+	}
+
+	if ur.Queue != nil {
+		r.Queue = ur.Queue
+		if ur.Queue.MaxConcurrency == 0 {
+			r.Queue = nil
+		}
+	}
+
+	if ur.RateLimit != nil {
+		r.RateLimit = ur.RateLimit
+		if ur.RateLimit.Requests == 0 {
+			r.RateLimit = nil
+		}
+	}
+
+	if ur.Region != nil {
+		r.Region = ur.Region
+		if !ur.Region.Enabled {
+			r.Region = nil
+		}
+	}
+
+	if ur.Transform != nil {
+		r.Transform = ur.Transform
+		if !ur.Transform.Set() {
+			r.Transform = nil
+		}
+	}
+
+	if ur.PolicyId != nil {
+		r.PolicyId = *ur.PolicyId
+	}
+
+	if ur.PolicyVersions != nil {
+		r.PolicyVersions = *ur.PolicyVersions
+		if len(*ur.PolicyVersions) == 0 {
+			r.PolicyVersions = nil
+		}
+	}
+
+	if ur.Shadow != nil {
+		r.Shadow = ur.Shadow
+		if ur.Shadow.Step == nil {
+			r.Shadow = nil
+		}
+	}
+}
+
+// RouteVersion is a snapshot of a route at one of its versions. Rolling back
+// to a version makes a new version with the config of it, so that history is
+// never rewritten.
+type RouteVersion struct {
+	RouteId   string `json:"routeId"`
+	Version   int    `json:"version"`
+	Route     *Route `json:"route"`
+	CreatedAt int64  `json:"createdAt"`
+}
@@ -2,15 +2,25 @@ package admin
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/batch"
+	"github.com/bricks-cloud/bricksllm/internal/catalog"
+	"github.com/bricks-cloud/bricksllm/internal/change"
+	"github.com/bricks-cloud/bricksllm/internal/dryrun"
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
 	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/finetune"
 	"github.com/bricks-cloud/bricksllm/internal/key"
 	"github.com/bricks-cloud/bricksllm/internal/policy"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
@@ -26,14 +36,29 @@ type ProviderSettingsManager interface {
 	UpdateSetting(id string, setting *provider.UpdateSetting) (*provider.Setting, error)
 	GetSettingViaCache(id string) (*provider.Setting, error)
 	GetSettingsViaCache(ids []string) ([]*provider.Setting, error)
+	ListSettings(ids []string, f *provider.SettingFilter) ([]*provider.Setting, error)
+	DeleteSetting(id string, cascade bool) ([]*dryrun.Dependent, error)
+	PreviewDeleteSetting(id string, cascade bool) (*dryrun.Result, error)
+	GetDeployments(id string) ([]*provider.Deployment, error)
+	PutDeployment(id string, d *provider.Deployment) ([]*provider.Deployment, error)
+	DeleteDeployment(id, model string) ([]*provider.Deployment, error)
+	GetSettingHealth(ctx context.Context, id string) (*catalog.SettingHealth, error)
+	TestConnection(ctx context.Context, id string) (*catalog.ConnectionTest, error)
+	GetSettingVersions(id string) ([]*provider.SettingVersion, error)
+	RollbackSetting(id string, version int) (*provider.Setting, error)
 }
 
 type KeyManager interface {
 	GetKeys(tags, keyIds []string, provider string) ([]*key.ResponseKey, error)
-	GetKeysV2(tags, keyIds []string, revoked *bool, limit, offset int, name, order string, returnCount bool) (*key.GetKeysResponse, error)
+	GetKeysV2(tags, keyIds []string, revoked *bool, limit, offset int, name, order string, returnCount bool, namespace string) (*key.GetKeysResponse, error)
 	UpdateKey(id string, key *key.UpdateKey) (*key.ResponseKey, error)
 	CreateKey(key *key.RequestKey) (*key.ResponseKey, error)
 	DeleteKey(id string) error
+	PreviewDeleteKey(id string) (*dryrun.Result, error)
+	CreateClaimLink(id string, r *key.ClaimLinkRequest) (*key.ClaimLink, error)
+	ClaimKey(token string) (*key.ClaimedKey, error)
+	VerifyKeyFormat(secret string) (*key.FormatVerification, error)
+	BulkRevokeKeys(r *key.BulkRevokeRequest) (*key.BulkRevokeResult, error)
 }
 
 type KeyReportingManager interface {
@@ -45,12 +70,26 @@ type KeyReportingManager interface {
 	GetAggregatedEventByDayReporting(e *event.ReportingRequest) (*event.ReportingResponseV2, error)
 	GetCustomIds(keyId string) ([]string, error)
 	GetUserIds(keyId string) ([]string, error)
+	GetSummary(keyIds []string) (*event.Summary, error)
+	GetSigningReporting(r *event.SigningReportingRequest) (*event.SigningReportingResponse, error)
+	GetPolicyViolationReporting(r *event.PolicyViolationReportingRequest) (*event.PolicyViolationReportingResponse, error)
+	GetPolicyVersionReporting(r *event.PolicyVersionReportingRequest) (*event.PolicyVersionReportingResponse, error)
+	GetBatches(keyIds []string, status string) ([]*batch.Batch, error)
+	GetFineTuningJobs(keyIds []string, status string) ([]*finetune.Job, error)
 }
 
 type PoliciesManager interface {
 	CreatePolicy(p *policy.Policy) (*policy.Policy, error)
 	UpdatePolicy(id string, p *policy.UpdatePolicy) (*policy.Policy, error)
 	GetPoliciesByTags(tags []string) ([]*policy.Policy, error)
+	GetPolicy(id string) (*policy.Policy, error)
+	DeletePolicy(id string, cascade bool) ([]*dryrun.Dependent, error)
+	PreviewDeletePolicy(id string, cascade bool) (*dryrun.Result, error)
+	EvaluatePolicy(id string, er *policy.EvaluateRequest, log *zap.Logger) (*policy.Evaluation, error)
+	GetPolicyVersions(id string) ([]*policy.PolicyVersion, error)
+	RollbackPolicy(id string, version int) (*policy.Policy, error)
+	SetPolicyRollout(id string, ur *policy.UpdateRollout) (*policy.Policy, error)
+	GetEffectivePolicy(keyId, routeId string) (*policy.EffectivePolicy, error)
 }
 
 type ErrorResponse struct {
@@ -59,59 +98,148 @@ type ErrorResponse struct {
 	Status   int    `json:"status"`
 	Detail   string `json:"detail"`
 	Instance string `json:"instance"`
+
+	// Errors lists every invalid field when a request fails validation.
+	Errors []*internal_errors.FieldError `json:"errors,omitempty"`
 }
 
 type AdminServer struct {
-	server *http.Server
-	log    *zap.Logger
-	m      KeyManager
+	server   *http.Server
+	log      *zap.Logger
+	m        KeyManager
+	port     string
+	certFile string
+	keyFile  string
+}
+
+type TlsConfig struct {
+	CertFile     string
+	KeyFile      string
+	ClientCaFile string
 }
 
-func NewAdminServer(log *zap.Logger, mode string, m KeyManager, krm KeyReportingManager, psm ProviderSettingsManager, cpm CustomProvidersManager, rm RouteManager, pm PoliciesManager, um UserManager, adminPass string) (*AdminServer, error) {
+func NewAdminServer(log *zap.Logger, mode string, m KeyManager, krm KeyReportingManager, psm ProviderSettingsManager, cpm CustomProvidersManager, rm RouteManager, pm PoliciesManager, um UserManager, om OnboardManager, cm CompareManager, cfm ConfigManager, wm WebhookManager, tm ToolManager, ctm CatalogManager, mam ModelAliasManager, adminPass string, host string, port string, tlsCfg *TlsConfig, ic IdempotencyCache, ie IdempotencyEncryptor, idempotencyTtl time.Duration, gc *GuardConfig, cc *CorsConfig, prober Prober, d Drainer, changes *change.Hub, bl *BodyLimitConfig) (*AdminServer, error) {
 	router := gin.New()
 
 	prod := mode == "production"
-	router.Use(getAdminLoggerMiddleware(log, "admin", prod, adminPass))
+	router.Use(getCorsMiddleware(cc))
+	router.Use(getLocalizationMiddleware())
+	router.Use(getAdminLoggerMiddleware(log, "admin", prod, adminPass, newGuard(gc)))
+	router.Use(getBodyLimitMiddleware(bl))
+	router.Use(getNamespaceMiddleware())
+
+	router.Use(getChangesMiddleware(changes))
+
+	idempotent := getIdempotencyMiddleware(ic, nil, idempotencyTtl, prod)
+	idempotentSecret := getIdempotencyMiddleware(ic, ie, idempotencyTtl, prod)
 
-	router.GET("/api/health", getGetHealthCheckHandler())
+	router.GET(healthPath, getGetHealthCheckHandler(prober))
+	router.GET(livenessPath, getGetLivenessHandler())
+	router.GET("/api/summary", getGetSummaryHandler(krm, m, prod))
+	router.GET("/api/openapi.json", getGetOpenApiHandler(router))
+
+	router.POST(drainPath, getStartDrainHandler(d))
+	router.GET(drainPath, getGetDrainHandler(d))
+	router.DELETE(drainPath, getStopDrainHandler(d))
 
 	router.POST("/api/v2/key-management/keys", getGetKeysV2Handler(m, prod))
 	router.GET("/api/key-management/keys", getGetKeysHandler(m, prod))
-	router.PUT("/api/key-management/keys", getCreateKeyHandler(m, prod))
+	router.PUT("/api/key-management/keys", idempotentSecret, getCreateKeyHandler(m, prod))
 	router.PATCH("/api/key-management/keys/:id", getUpdateKeyHandler(m, prod))
 	router.DELETE("/api/key-management/keys/:id", getDeleteKeyHandler(m, prod))
-
-	router.GET("/api/reporting/keys/:id", getGetKeyReportingHandler(krm, prod))
-	router.POST("/api/reporting/events", getGetEventMetricsHandler(krm, prod))
-	router.POST("/api/reporting/events-by-day", getGetEventMetricsByDayHandler(krm, prod))
-	router.GET("/api/events", getGetEventsHandler(krm, prod))
-	router.POST("/api/v2/events", getGetEventsV2Handler(krm, prod))
-	router.GET("/api/reporting/user-ids", getGetUserIdsHandler(krm, prod))
-	router.POST("/api/reporting/top-keys", getGetTopKeysMetricsHandler(krm, prod))
-
-	router.GET("/api/reporting/custom-ids", getGetCustomIdsHandler(krm, prod))
-
-	router.PUT("/api/provider-settings", getCreateProviderSettingHandler(psm, prod))
+	router.POST("/api/key-management/keys/:id/claim-link", idempotentSecret, getCreateClaimLinkHandler(m, prod))
+	router.GET("/api/key-management/keys/:id/effective-policy", getGetEffectivePolicyHandler(m, rm, pm, prod))
+	router.GET(claimKeyPath, getClaimKeyPageHandler())
+	router.POST(claimKeyPath, idempotentSecret, getClaimKeyHandler(m, prod))
+	router.POST("/api/key-management/keys/verify-format", getVerifyKeyFormatHandler(m, prod))
+	router.POST("/api/key-management/keys/revoke", idempotent, getBulkRevokeKeysHandler(m, prod))
+
+	router.GET("/api/reporting/keys/:id", getGetKeyReportingHandler(krm, m, prod))
+	router.POST("/api/reporting/events", getGetEventMetricsHandler(krm, m, prod))
+	router.POST("/api/reporting/events-by-day", getGetEventMetricsByDayHandler(krm, m, prod))
+	router.GET("/api/events", getGetEventsHandler(krm, m, prod))
+	router.POST("/api/v2/events", getGetEventsV2Handler(krm, m, prod))
+	router.GET("/api/reporting/user-ids", getGetUserIdsHandler(krm, m, prod))
+	router.POST("/api/reporting/top-keys", getGetTopKeysMetricsHandler(krm, m, prod))
+	router.POST("/api/reporting/signing", getGetSigningReportingHandler(krm, m, prod))
+	router.POST("/api/reporting/policy-violations", getGetPolicyViolationReportingHandler(krm, m, prod))
+	router.POST("/api/reporting/policy-versions", getGetPolicyVersionReportingHandler(krm, pm, prod))
+
+	router.GET("/api/reporting/custom-ids", getGetCustomIdsHandler(krm, m, prod))
+	router.GET("/api/batches", getGetBatchesHandler(krm, m, prod))
+	router.GET("/api/fine-tuning-jobs", getGetFineTuningJobsHandler(krm, m, prod))
+
+	router.PUT("/api/provider-settings", idempotent, getCreateProviderSettingHandler(psm, prod))
 	router.GET("/api/provider-settings", getGetProviderSettingsHandler(psm, prod))
 	router.PATCH("/api/provider-settings/:id", getUpdateProviderSettingHandler(psm, prod))
-
-	router.POST("/api/custom/providers", getCreateCustomProviderHandler(cpm, prod))
+	router.DELETE("/api/provider-settings/:id", getDeleteProviderSettingHandler(psm, prod))
+	router.GET("/api/provider-settings/:id/deployments", getGetDeploymentsHandler(psm, prod))
+	router.PUT("/api/provider-settings/:id/deployments/:model", idempotent, getPutDeploymentHandler(psm, prod))
+	router.DELETE("/api/provider-settings/:id/deployments/:model", getDeleteDeploymentHandler(psm, prod))
+	router.GET("/api/provider-settings/:id/health", getCheckProviderSettingHealthHandler(psm, prod))
+	router.POST("/api/provider-settings/:id/test", getTestProviderSettingHandler(psm, prod))
+	router.GET("/api/provider-settings/:id/versions", getGetProviderSettingVersionsHandler(psm, prod))
+	router.POST("/api/provider-settings/:id/rollback/:version", idempotent, getRollbackProviderSettingHandler(psm, prod))
+
+	router.POST("/api/custom/providers", idempotent, getCreateCustomProviderHandler(cpm, prod))
 	router.GET("/api/custom/providers", getGetCustomProvidersHandler(cpm, prod))
 	router.PATCH("/api/custom/providers/:id", getUpdateCustomProvidersHandler(cpm, prod))
+	router.DELETE("/api/custom/providers/:id", getDeleteCustomProviderHandler(cpm, prod))
 
-	router.POST("/api/routes", getCreateRouteHandler(rm, prod))
+	router.POST("/api/routes", idempotent, getCreateRouteHandler(rm, prod))
 	router.GET("/api/routes/:id", getGetRouteHandler(rm, prod))
+	router.GET("/api/routes/:id/status", getGetRouteStatusHandler(rm, prod))
 	router.GET("/api/routes", getGetRoutesHandler(rm, prod))
 	router.DELETE("/api/routes/:id", getDeleteRouteHandler(rm, prod))
+	router.PATCH("/api/routes/:id", getUpdateRouteHandler(rm, prod))
+	router.GET("/api/routes/:id/versions", getGetRouteVersionsHandler(rm, prod))
+	router.POST("/api/routes/:id/rollback/:version", idempotent, getRollbackRouteHandler(rm, prod))
+	router.POST("/api/routes/:id/test", getTestRouteHandler(rm, prod))
 
-	router.POST("/api/policies", getCreatePolicyHandler(pm, prod))
+	router.POST("/api/policies", idempotent, getCreatePolicyHandler(pm, prod))
 	router.PATCH("/api/policies/:id", getUpdatePolicyHandler(pm, prod))
 	router.GET("/api/policies", getGetPoliciesByTagsHandler(pm, prod))
+	router.DELETE("/api/policies/:id", getDeletePolicyHandler(pm, prod))
+	router.POST("/api/policies/:id/evaluate", getEvaluatePolicyHandler(pm, prod))
+	router.GET("/api/policies/:id/versions", getGetPolicyVersionsHandler(pm, prod))
+	router.POST("/api/policies/:id/rollback/:version", idempotent, getRollbackPolicyHandler(pm, prod))
+	router.PUT("/api/policies/:id/rollout", idempotent, getUpdatePolicyRolloutHandler(pm, prod))
 
-	router.POST("/api/users", getCreateUserHandler(um, prod))
+	router.POST("/api/users", idempotent, getCreateUserHandler(um, prod))
 	router.PATCH("/api/users/:id", getUpdateUserHandler(um, prod))
 	router.PATCH("/api/users", getUpdateUserViaTagsAndUserIdHandler(um, prod))
 	router.GET("/api/users", getGetUsersHandler(um, prod))
+	router.DELETE("/api/users/:id", getDeleteUserHandler(um, prod))
+
+	router.POST("/api/onboard", idempotentSecret, getOnboardHandler(om, prod))
+
+	router.POST("/api/compare", getCompareHandler(cm, prod))
+
+	router.POST("/api/config/apply", idempotent, getApplyConfigHandler(cfm, prod))
+	router.GET("/api/config/export", getExportConfigHandler(cfm, prod))
+	router.POST("/api/config/import", idempotent, getImportConfigHandler(cfm, prod))
+
+	router.POST("/api/webhooks", idempotentSecret, getCreateWebhookHandler(wm, prod))
+	router.GET("/api/webhooks", getGetWebhooksHandler(wm, prod))
+	router.DELETE("/api/webhooks/:id", getDeleteWebhookHandler(wm, prod))
+
+	router.POST("/api/tools", idempotent, getCreateToolHandler(tm, prod))
+	router.GET("/api/tools", getGetToolsHandler(tm, prod))
+	router.DELETE("/api/tools/:id", getDeleteToolHandler(tm, prod))
+	router.GET("/api/tools/:id/executions", getGetToolExecutionsHandler(tm, prod))
+
+	router.POST("/api/model-aliases", idempotent, getCreateModelAliasHandler(mam, prod))
+	router.GET("/api/model-aliases", getGetModelAliasesHandler(mam, prod))
+	router.PATCH("/api/model-aliases/:id", getUpdateModelAliasHandler(mam, prod))
+	router.DELETE("/api/model-aliases/:id", getDeleteModelAliasHandler(mam, prod))
+
+	router.GET("/api/models", getGetModelsHandler(ctm, prod))
+	router.PATCH("/api/models/:id", getUpdateModelHandler(ctm, prod))
+	router.GET("/api/pricing", getGetPricingHandler(ctm, prod))
+	router.PUT("/api/pricing", idempotent, getPutPricingHandler(ctm, prod))
+
+	router.GET(changesPath, getGetChangesStreamHandler(changes, prod))
+	router.GET(configChangesPath, getGetConfigChangesHandler(changes, prod))
 
 	// Static file serving with caching for swagger documentation and admin interface
 	staticGroup := router.Group("/")
@@ -122,46 +250,139 @@ func NewAdminServer(log *zap.Logger, mode string, m KeyManager, krm KeyReporting
 	staticGroup.StaticFile("/admin.yaml", "/docs/admin.yaml")
 
 	srv := &http.Server{
-		Addr:    ":8001",
+		Addr:    net.JoinHostPort(host, port),
 		Handler: router,
 	}
 
-	return &AdminServer{
+	as := &AdminServer{
 		log:    log,
 		server: srv,
 		m:      m,
-	}, nil
+		port:   port,
+	}
+
+	if tlsCfg != nil && len(tlsCfg.CertFile) != 0 {
+		as.certFile = tlsCfg.CertFile
+		as.keyFile = tlsCfg.KeyFile
+
+		srv.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+
+		if len(tlsCfg.ClientCaFile) != 0 {
+			ca, err := os.ReadFile(tlsCfg.ClientCaFile)
+			if err != nil {
+				return nil, err
+			}
+
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(ca) {
+				return nil, errors.New("admin tls client ca file does not contain valid certificates")
+			}
+
+			srv.TLSConfig.ClientCAs = pool
+			srv.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	return as, nil
 }
 
 func (as *AdminServer) Run() {
 	go func() {
-		as.log.Info("admin server listening at 8001")
-		as.log.Info("PORT 8001 | GET    | /api/health is set up for health checking the admin server")
-		as.log.Info("PORT 8001 | GET    | /api/key-management/keys is set up for retrieving keys using a query param called tag")
-		as.log.Info("PORT 8001 | POST   | /api/v2/key-management/keys is set up for retrieving keys")
-		as.log.Info("PORT 8001 | PUT    | /api/key-management/keys is set up for creating a key")
-		as.log.Info("PORT 8001 | PATCH  | /api/key-management/keys/:id is set up for updating a key using an id")
-		as.log.Info("PORT 8001 | GET    | /api/provider-settings is set up for getting provider settings")
-		as.log.Info("PORT 8001 | PUT    | /api/provider-settings is set up for creating a provider setting")
-		as.log.Info("PORT 8001 | PATCH  | /api/provider-settings:id is set up for updating provider setting")
-		as.log.Info("PORT 8001 | POST   | /api/reporting/events is set up for retrieving api metrics")
-		as.log.Info("PORT 8001 | GET    | /api/events is set up for retrieving events")
-		as.log.Info("PORT 8001 | POST   | /api/v2/events is set up for retrieving events")
-		as.log.Info("PORT 8001 | POST   | /api/custom/providers is set up for creating a custom provider")
-		as.log.Info("PORT 8001 | GET    | /api/custom/providers is set up for retrieving all custom providers")
-		as.log.Info("PORT 8001 | PATCH  | /api/custom/providers/:id is set up for updating a custom provider")
-		as.log.Info("PORT 8001 | POST   | /api/routes is set up for creating a custom route")
-		as.log.Info("PORT 8001 | GET    | /api/routes/:id is set up for retrieving a route")
-		as.log.Info("PORT 8001 | GET    | /api/routes is set up for retrieving routes")
-		as.log.Info("PORT 8001 | DELETE | /api/routes/:id is set up for deleting a route")
-		as.log.Info("PORT 8001 | POST   | /api/policies is set up for creating a policy")
-		as.log.Info("PORT 8001 | PATCH  | /api/policies/:id is set up for retrieving a policy")
-		as.log.Info("PORT 8001 | GET    | /api/policies is set up for retrieving policies")
-		as.log.Info("PORT 8001 | POST   | /api/users is set up for creating a user")
-		as.log.Info("PORT 8001 | GET    | /api/users is set up for retrieving users")
-		as.log.Info("PORT 8001 | PATCH  | /api/users is set up for updating a user")
-
-		if err := as.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		as.log.Sugar().Infof("admin server listening at %s", as.server.Addr)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/health is set up for checking the readiness of the admin server and its dependencies", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/health/live is set up for checking the liveness of the admin server", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/lifecycle/drain is set up for draining the proxy", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/lifecycle/drain is set up for retrieving drain progress", as.port)
+		as.log.Sugar().Infof("PORT %s | DELETE | /api/lifecycle/drain is set up for resuming the proxy", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/summary is set up for retrieving a dashboard summary", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/openapi.json is set up for retrieving the OpenAPI document of the admin API", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/key-management/keys is set up for retrieving keys using a query param called tag", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/v2/key-management/keys is set up for retrieving keys", as.port)
+		as.log.Sugar().Infof("PORT %s | PUT    | /api/key-management/keys is set up for creating a key", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/key-management/keys/:id/claim-link is set up for creating a one-time key claim link", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/key-management/keys/:id/effective-policy is set up for retrieving the policy applied to requests of a key", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/key-management/claims/:token is set up for confirming the claim of a key secret via a one-time link", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/key-management/claims/:token is set up for claiming a key secret via a one-time link", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/key-management/keys/verify-format is set up for verifying the format of a key secret", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/key-management/keys/revoke is set up for previewing and confirming the revocation of keys matched by a filter", as.port)
+		as.log.Sugar().Infof("PORT %s | PATCH  | /api/key-management/keys/:id is set up for updating a key using an id", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/provider-settings is set up for getting provider settings", as.port)
+		as.log.Sugar().Infof("PORT %s | PUT    | /api/provider-settings is set up for creating a provider setting", as.port)
+		as.log.Sugar().Infof("PORT %s | PATCH  | /api/provider-settings:id is set up for updating provider setting", as.port)
+		as.log.Sugar().Infof("PORT %s | DELETE | /api/provider-settings/:id is set up for deleting a provider setting", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/provider-settings/:id/deployments is set up for retrieving the azure deployment mapping of a provider setting", as.port)
+		as.log.Sugar().Infof("PORT %s | PUT    | /api/provider-settings/:id/deployments/:model is set up for mapping a model to an azure deployment", as.port)
+		as.log.Sugar().Infof("PORT %s | DELETE | /api/provider-settings/:id/deployments/:model is set up for removing the azure deployment mapping of a model", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/provider-settings/:id/health is set up for getting the upstream health of a provider setting", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/provider-settings/:id/test is set up for testing the connection of a provider setting to its upstream", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/provider-settings/:id/versions is set up for getting the version history of a provider setting", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/provider-settings/:id/rollback/:version is set up for rolling back a provider setting to one of its versions", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/reporting/events is set up for retrieving api metrics", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/reporting/signing is set up for auditing upstream request signing", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/reporting/policy-violations is set up for reporting policy violations by rule", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/reporting/policy-versions is set up for comparing the versions of a policy", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/events is set up for retrieving events", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/v2/events is set up for retrieving events", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/batches is set up for retrieving batches and their reconciled usage", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/fine-tuning-jobs is set up for retrieving fine-tuning jobs and their training cost", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/custom/providers is set up for creating a custom provider", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/custom/providers is set up for retrieving all custom providers", as.port)
+		as.log.Sugar().Infof("PORT %s | PATCH  | /api/custom/providers/:id is set up for updating a custom provider", as.port)
+		as.log.Sugar().Infof("PORT %s | DELETE | /api/custom/providers/:id is set up for deleting a custom provider", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/routes is set up for creating a custom route", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/routes/:id is set up for retrieving a route", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/routes/:id/status is set up for retrieving the circuit breaker state of a route", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/routes is set up for retrieving routes", as.port)
+		as.log.Sugar().Infof("PORT %s | DELETE | /api/routes/:id is set up for deleting a route", as.port)
+		as.log.Sugar().Infof("PORT %s | PATCH  | /api/routes/:id is set up for updating a route", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/routes/:id/versions is set up for retrieving the versions of a route", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/routes/:id/rollback/:version is set up for rolling back a route to one of its versions", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/routes/:id/test is set up for testing a route without calling its upstreams", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/policies is set up for creating a policy", as.port)
+		as.log.Sugar().Infof("PORT %s | PATCH  | /api/policies/:id is set up for retrieving a policy", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/policies is set up for retrieving policies", as.port)
+		as.log.Sugar().Infof("PORT %s | DELETE | /api/policies/:id is set up for deleting a policy", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/policies/:id/evaluate is set up for evaluating a policy against a sample request", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/policies/:id/versions is set up for retrieving the version history of a policy", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/policies/:id/rollback/:version is set up for rolling a policy back to one of its versions", as.port)
+		as.log.Sugar().Infof("PORT %s | PUT    | /api/policies/:id/rollout is set up for rolling out the current version of a policy", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/users is set up for creating a user", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/users is set up for retrieving users", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/onboard is set up for creating a user and a key for an org in one transaction", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/compare is set up for comparing responses of models and routes", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/config/apply is set up for reconciling keys, provider settings, routes and policies toward a document", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/config/export is set up for exporting keys, provider settings, routes, policies and custom providers as an archive", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/config/import is set up for importing a config archive", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/webhooks is set up for registering an org usage webhook", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/webhooks is set up for retrieving org usage webhooks", as.port)
+		as.log.Sugar().Infof("PORT %s | DELETE | /api/webhooks/:id is set up for deleting an org usage webhook", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/tools is set up for registering a brokered tool", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/tools is set up for retrieving brokered tools", as.port)
+		as.log.Sugar().Infof("PORT %s | DELETE | /api/tools/:id is set up for deleting a brokered tool", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/tools/:id/executions is set up for retrieving the audit trail of a brokered tool", as.port)
+		as.log.Sugar().Infof("PORT %s | POST   | /api/model-aliases is set up for creating a model alias", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/model-aliases is set up for retrieving model aliases", as.port)
+		as.log.Sugar().Infof("PORT %s | PATCH  | /api/model-aliases/:id is set up for retargeting a model alias", as.port)
+		as.log.Sugar().Infof("PORT %s | DELETE | /api/model-aliases/:id is set up for deleting a model alias", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/models is set up for retrieving the model catalog with pricing and capabilities", as.port)
+		as.log.Sugar().Infof("PORT %s | PATCH  | /api/models/:id is set up for overriding the pricing and capabilities of a model", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/pricing is set up for retrieving the pricing table", as.port)
+		as.log.Sugar().Infof("PORT %s | PUT    | /api/pricing is set up for overriding the pricing of models", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/changes/stream is set up for streaming admin changes as server-sent events", as.port)
+		as.log.Sugar().Infof("PORT %s | GET    | /api/config/changes is set up for long polling admin changes", as.port)
+		as.log.Sugar().Infof("PORT %s | PATCH  | /api/users is set up for updating a user", as.port)
+		as.log.Sugar().Infof("PORT %s | DELETE | /api/users/:id is set up for deleting a user", as.port)
+
+		var err error
+		if len(as.certFile) != 0 {
+			err = as.server.ListenAndServeTLS(as.certFile, as.keyFile)
+		} else {
+			err = as.server.ListenAndServe()
+		}
+
+		if err != nil && err != http.ErrServerClosed {
 			as.log.Sugar().Fatalf("error admin server listening: %v", err)
 		}
 	}()
@@ -176,12 +397,6 @@ func (as *AdminServer) Shutdown(ctx context.Context) error {
 	return nil
 }
 
-func getGetHealthCheckHandler() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Status(http.StatusOK)
-	}
-}
-
 func getGetKeysHandler(m KeyManager, prod bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := util.GetLogFromCtx(c)
@@ -239,7 +454,7 @@ func getGetKeysHandler(m KeyManager, prod bool) gin.HandlerFunc {
 		}
 
 		telemetry.Incr("bricksllm.admin.get_get_keys_handler.success", nil, 1)
-		c.JSON(http.StatusOK, keys)
+		c.JSON(http.StatusOK, withinNamespace(c, keys, func(k *key.ResponseKey) string { return k.Namespace }))
 	}
 }
 
@@ -268,6 +483,10 @@ func getGetKeysV2Handler(m KeyManager, prod bool) gin.HandlerFunc {
 
 		data, err := io.ReadAll(c.Request.Body)
 		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
 			logError(log, "error when reading get keys request body", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/request-body-read",
@@ -280,20 +499,21 @@ func getGetKeysV2Handler(m KeyManager, prod bool) gin.HandlerFunc {
 		}
 
 		request := &key.KeyRequest{}
-		err = json.Unmarshal(data, request)
+		err = bindJSON(data, request)
 		if err != nil {
-			logError(log, "error when unmarshalling get key request body", prod, err)
-			c.JSON(http.StatusInternalServerError, &ErrorResponse{
-				Type:     "/errors/json-unmarshal",
-				Title:    "json unmarshaller error",
-				Status:   http.StatusInternalServerError,
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "request body validation failed",
+				Status:   http.StatusBadRequest,
 				Detail:   err.Error(),
 				Instance: path,
+				Errors:   fieldErrorsOf(err),
 			})
 			return
 		}
 
-		keys, err := m.GetKeysV2(request.Tags, request.KeyIds, request.Revoked, request.Limit, request.Offset, request.Name, request.Order, request.ReturnCount)
+		ns, _ := namespaceOf(c)
+		keys, err := m.GetKeysV2(request.Tags, request.KeyIds, request.Revoked, request.Limit, request.Offset, request.Name, request.Order, request.ReturnCount, ns)
 		if err != nil {
 			errType := "internal"
 
@@ -311,6 +531,7 @@ func getGetKeysV2Handler(m KeyManager, prod bool) gin.HandlerFunc {
 					Status:   http.StatusBadRequest,
 					Detail:   err.Error(),
 					Instance: path,
+					Errors:   fieldErrorsOf(err),
 				})
 				return
 			}
@@ -359,7 +580,33 @@ func getGetProviderSettingsHandler(m ProviderSettingsManager, prod bool) gin.Han
 			return
 		}
 
-		created, err := m.GetSettingsViaCache(c.QueryArray("ids"))
+		f := &provider.SettingFilter{
+			Name:        c.Query("name"),
+			Environment: c.Query("environment"),
+		}
+
+		for _, label := range c.QueryArray("labels") {
+			k, v, found := strings.Cut(label, "=")
+			if !found || len(k) == 0 {
+				telemetry.Incr("bricksllm.admin.get_get_provider_settings.invalid_label", nil, 1)
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "label filter is not valid",
+					Status:   http.StatusBadRequest,
+					Detail:   fmt.Sprintf("label %s must be in the form of key=value", label),
+					Instance: path,
+				})
+				return
+			}
+
+			if f.Labels == nil {
+				f.Labels = map[string]string{}
+			}
+
+			f.Labels[k] = v
+		}
+
+		created, err := m.ListSettings(c.QueryArray("ids"), f)
 		if err != nil {
 			errType := "internal"
 
@@ -382,7 +629,7 @@ func getGetProviderSettingsHandler(m ProviderSettingsManager, prod bool) gin.Han
 
 		telemetry.Incr("bricksllm.admin.get_get_provider_settings.success", nil, 1)
 
-		c.JSON(http.StatusOK, created)
+		c.JSON(http.StatusOK, withinNamespace(c, created, func(s *provider.Setting) string { return s.Namespace }))
 	}
 }
 
@@ -411,6 +658,10 @@ func getCreateProviderSettingHandler(m ProviderSettingsManager, prod bool) gin.H
 
 		data, err := io.ReadAll(c.Request.Body)
 		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
 			logError(log, "error when reading api key create request body", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/request-body-read",
@@ -423,19 +674,23 @@ func getCreateProviderSettingHandler(m ProviderSettingsManager, prod bool) gin.H
 		}
 
 		setting := &provider.Setting{}
-		err = json.Unmarshal(data, setting)
+		err = bindJSON(data, setting)
 		if err != nil {
-			logError(log, "error when unmarshalling provider setting update request body", prod, err)
-			c.JSON(http.StatusInternalServerError, &ErrorResponse{
-				Type:     "/errors/json-unmarshal",
-				Title:    "json unmarshaller error",
-				Status:   http.StatusInternalServerError,
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "request body validation failed",
+				Status:   http.StatusBadRequest,
 				Detail:   err.Error(),
 				Instance: path,
+				Errors:   fieldErrorsOf(err),
 			})
 			return
 		}
 
+		if ns, ok := namespaceOf(c); ok {
+			setting.Namespace = ns
+		}
+
 		created, err := m.CreateSetting(setting)
 		if err != nil {
 			errType := "internal"
@@ -455,6 +710,7 @@ func getCreateProviderSettingHandler(m ProviderSettingsManager, prod bool) gin.H
 					Status:   http.StatusBadRequest,
 					Detail:   err.Error(),
 					Instance: path,
+					Errors:   fieldErrorsOf(err),
 				})
 				return
 			}
@@ -470,6 +726,7 @@ func getCreateProviderSettingHandler(m ProviderSettingsManager, prod bool) gin.H
 			return
 		}
 
+		recordChange(c, change.KindProviderSetting, change.ActionCreate, created.Id, created.Namespace)
 		telemetry.Incr("bricksllm.admin.get_create_provider_setting_handler.success", nil, 1)
 
 		c.JSON(http.StatusOK, created)
@@ -501,6 +758,10 @@ func getCreateKeyHandler(m KeyManager, prod bool) gin.HandlerFunc {
 
 		data, err := io.ReadAll(c.Request.Body)
 		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
 			logError(log, "error when reading key creation request body", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/request-body-read",
@@ -513,19 +774,23 @@ func getCreateKeyHandler(m KeyManager, prod bool) gin.HandlerFunc {
 		}
 
 		rk := &key.RequestKey{}
-		err = json.Unmarshal(data, rk)
+		err = bindJSON(data, rk)
 		if err != nil {
-			logError(log, "error when unmarshalling key creation request body", prod, err)
-			c.JSON(http.StatusInternalServerError, &ErrorResponse{
-				Type:     "/errors/json-unmarshal",
-				Title:    "json unmarshaller error",
-				Status:   http.StatusInternalServerError,
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "request body validation failed",
+				Status:   http.StatusBadRequest,
 				Detail:   err.Error(),
 				Instance: path,
+				Errors:   fieldErrorsOf(err),
 			})
 			return
 		}
 
+		if ns, ok := namespaceOf(c); ok {
+			rk.Namespace = ns
+		}
+
 		resk, err := m.CreateKey(rk)
 		if err != nil {
 			errType := "internal"
@@ -545,6 +810,7 @@ func getCreateKeyHandler(m KeyManager, prod bool) gin.HandlerFunc {
 					Status:   http.StatusBadRequest,
 					Detail:   err.Error(),
 					Instance: path,
+					Errors:   fieldErrorsOf(err),
 				})
 				return
 			}
@@ -560,6 +826,7 @@ func getCreateKeyHandler(m KeyManager, prod bool) gin.HandlerFunc {
 			return
 		}
 
+		recordChange(c, change.KindKey, change.ActionCreate, resk.KeyId, resk.Namespace)
 		telemetry.Incr("bricksllm.admin.get_create_key_handler.success", nil, 1)
 
 		c.JSON(http.StatusOK, resk)
@@ -590,8 +857,16 @@ func getUpdateProviderSettingHandler(m ProviderSettingsManager, prod bool) gin.H
 		}
 
 		id := c.Param("id")
+		if !ensureInNamespace(c, log, prod, path, "provider setting", settingNamespace(m, id)) {
+			return
+		}
+
 		data, err := io.ReadAll(c.Request.Body)
 		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
 			logError(log, "error when reading api key update request body", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/request-body-read",
@@ -604,15 +879,15 @@ func getUpdateProviderSettingHandler(m ProviderSettingsManager, prod bool) gin.H
 		}
 
 		setting := &provider.UpdateSetting{}
-		err = json.Unmarshal(data, setting)
+		err = bindJSON(data, setting)
 		if err != nil {
-			logError(log, "error when unmarshalling provider setting update request body", prod, err)
-			c.JSON(http.StatusInternalServerError, &ErrorResponse{
-				Type:     "/errors/json-unmarshal",
-				Title:    "json unmarshaller error",
-				Status:   http.StatusInternalServerError,
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "request body validation failed",
+				Status:   http.StatusBadRequest,
 				Detail:   err.Error(),
 				Instance: path,
+				Errors:   fieldErrorsOf(err),
 			})
 			return
 		}
@@ -647,6 +922,7 @@ func getUpdateProviderSettingHandler(m ProviderSettingsManager, prod bool) gin.H
 					Status:   http.StatusBadRequest,
 					Detail:   err.Error(),
 					Instance: path,
+					Errors:   fieldErrorsOf(err),
 				})
 				return
 			}
@@ -662,6 +938,7 @@ func getUpdateProviderSettingHandler(m ProviderSettingsManager, prod bool) gin.H
 			return
 		}
 
+		recordChange(c, change.KindProviderSetting, change.ActionUpdate, updated.Id, updated.Namespace)
 		telemetry.Incr("bricksllm.admin.get_update_provider_setting_handler.success", nil, 1)
 
 		c.JSON(http.StatusOK, updated)
@@ -704,8 +981,16 @@ func getUpdateKeyHandler(m KeyManager, prod bool) gin.HandlerFunc {
 			return
 		}
 
+		if !ensureInNamespace(c, log, prod, path, "key", keyNamespace(m, id)) {
+			return
+		}
+
 		data, err := io.ReadAll(c.Request.Body)
 		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
 			logError(log, "error when reading api key update request body", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/request-body-read",
@@ -718,15 +1003,15 @@ func getUpdateKeyHandler(m KeyManager, prod bool) gin.HandlerFunc {
 		}
 
 		uk := &key.UpdateKey{}
-		err = json.Unmarshal(data, uk)
+		err = bindJSON(data, uk)
 		if err != nil {
-			logError(log, "error when unmarshalling api key update request body", prod, err)
-			c.JSON(http.StatusInternalServerError, &ErrorResponse{
-				Type:     "/errors/json-unmarshal",
-				Title:    "json unmarshaller error",
-				Status:   http.StatusInternalServerError,
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "request body validation failed",
+				Status:   http.StatusBadRequest,
 				Detail:   err.Error(),
 				Instance: path,
+				Errors:   fieldErrorsOf(err),
 			})
 			return
 		}
@@ -748,6 +1033,7 @@ func getUpdateKeyHandler(m KeyManager, prod bool) gin.HandlerFunc {
 					Status:   http.StatusBadRequest,
 					Detail:   err.Error(),
 					Instance: path,
+					Errors:   fieldErrorsOf(err),
 				})
 				return
 			}
@@ -775,6 +1061,7 @@ func getUpdateKeyHandler(m KeyManager, prod bool) gin.HandlerFunc {
 			return
 		}
 
+		recordChange(c, change.KindKey, change.ActionUpdate, resk.KeyId, resk.Namespace)
 		telemetry.Incr("bricksllm.admin.get_update_key_handler.success", nil, 1)
 
 		c.JSON(http.StatusOK, resk)
@@ -809,8 +1096,33 @@ func getDeleteKeyHandler(m KeyManager, prod bool) gin.HandlerFunc {
 			return
 		}
 
-		err := m.DeleteKey(id)
+		if !ensureInNamespace(c, log, prod, path, "key", keyNamespace(m, id)) {
+			return
+		}
+
+		dryRun := c.Query("dryRun") == "true"
+
+		var result *dryrun.Result
+		var err error
+		if dryRun {
+			result, err = m.PreviewDeleteKey(id)
+		} else {
+			recordChange(c, change.KindKey, change.ActionDelete, id, namespaceForChange(c, keyNamespace(m, id)))
+			err = m.DeleteKey(id)
+		}
+
 		if err != nil {
+			if _, ok := err.(notFoundError); ok {
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    "key is not found",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
 			logError(log, "error when deleting api key", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/key-manager",
@@ -822,6 +1134,11 @@ func getDeleteKeyHandler(m KeyManager, prod bool) gin.HandlerFunc {
 			return
 		}
 
+		if dryRun {
+			c.JSON(http.StatusOK, result)
+			return
+		}
+
 		c.Status(http.StatusOK)
 	}
 }
@@ -831,7 +1148,12 @@ type notFoundError interface {
 	NotFound()
 }
 
-func getGetKeyReportingHandler(m KeyReportingManager, prod bool) gin.HandlerFunc {
+type conflictError interface {
+	Error() string
+	Conflict()
+}
+
+func getGetKeyReportingHandler(m KeyReportingManager, km KeyManager, prod bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := util.GetLogFromCtx(c)
 		telemetry.Incr("bricksllm.admin.get_get_key_reporting_hanlder.requests", nil, 1)
@@ -867,6 +1189,10 @@ func getGetKeyReportingHandler(m KeyReportingManager, prod bool) gin.HandlerFunc
 			return
 		}
 
+		if !ensureInNamespace(c, log, prod, path, "key", keyNamespace(km, id)) {
+			return
+		}
+
 		kr, err := m.GetKeyReporting(id)
 		if err != nil {
 			errType := "internal"
@@ -914,6 +1240,8 @@ type CustomProvidersManager interface {
 	GetRouteConfigFromMem(name, path string) *custom.RouteConfig
 	GetCustomProviderFromMem(name string) *custom.Provider
 	UpdateCustomProvider(id string, setting *custom.UpdateProvider) (*custom.Provider, error)
+	DeleteCustomProvider(id string, cascade bool) ([]*dryrun.Dependent, error)
+	PreviewDeleteCustomProvider(id string, cascade bool) (*dryrun.Result, error)
 }
 
 func getCreateCustomProviderHandler(m CustomProvidersManager, prod bool) gin.HandlerFunc {
@@ -941,6 +1269,10 @@ func getCreateCustomProviderHandler(m CustomProvidersManager, prod bool) gin.Han
 
 		data, err := io.ReadAll(c.Request.Body)
 		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
 			logError(log, "error when reading create a custom provider request body", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/request-body-read",
@@ -953,15 +1285,15 @@ func getCreateCustomProviderHandler(m CustomProvidersManager, prod bool) gin.Han
 		}
 
 		setting := &custom.Provider{}
-		err = json.Unmarshal(data, setting)
+		err = bindJSON(data, setting)
 		if err != nil {
-			logError(log, "error when unmarshalling create a custom provider request body", prod, err)
-			c.JSON(http.StatusInternalServerError, &ErrorResponse{
-				Type:     "/errors/json-unmarshal",
-				Title:    "json unmarshaller error",
-				Status:   http.StatusInternalServerError,
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "request body validation failed",
+				Status:   http.StatusBadRequest,
 				Detail:   err.Error(),
 				Instance: path,
+				Errors:   fieldErrorsOf(err),
 			})
 			return
 		}
@@ -984,6 +1316,7 @@ func getCreateCustomProviderHandler(m CustomProvidersManager, prod bool) gin.Han
 					Status:   http.StatusBadRequest,
 					Detail:   err.Error(),
 					Instance: path,
+					Errors:   fieldErrorsOf(err),
 				})
 				return
 			}
@@ -1078,6 +1411,10 @@ func getUpdateCustomProvidersHandler(m CustomProvidersManager, prod bool) gin.Ha
 		id := c.Param("id")
 		data, err := io.ReadAll(c.Request.Body)
 		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
 			logError(log, "error when reading update a custom provider request body", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/request-body-read",
@@ -1090,15 +1427,15 @@ func getUpdateCustomProvidersHandler(m CustomProvidersManager, prod bool) gin.Ha
 		}
 
 		setting := &custom.UpdateProvider{}
-		err = json.Unmarshal(data, setting)
+		err = bindJSON(data, setting)
 		if err != nil {
-			logError(log, "error when unmarshalling update a custom provider request body", prod, err)
-			c.JSON(http.StatusInternalServerError, &ErrorResponse{
-				Type:     "/errors/json-unmarshal",
-				Title:    "json unmarshaller error",
-				Status:   http.StatusInternalServerError,
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "request body validation failed",
+				Status:   http.StatusBadRequest,
 				Detail:   err.Error(),
 				Instance: path,
+				Errors:   fieldErrorsOf(err),
 			})
 			return
 		}
@@ -1120,6 +1457,7 @@ func getUpdateCustomProvidersHandler(m CustomProvidersManager, prod bool) gin.Ha
 					Status:   http.StatusBadRequest,
 					Detail:   err.Error(),
 					Instance: path,
+					Errors:   fieldErrorsOf(err),
 				})
 				return
 			}
@@ -1152,6 +1490,219 @@ func getUpdateCustomProvidersHandler(m CustomProvidersManager, prod bool) gin.Ha
 	}
 }
 
+func getDeleteProviderSettingHandler(m ProviderSettingsManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_delete_provider_setting_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_delete_provider_setting_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/provider-settings/:id"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		id := c.Param("id")
+		if len(id) == 0 {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/missing-param-id",
+				Title:    "id is empty",
+				Status:   http.StatusBadRequest,
+				Detail:   "id url param is missing from the request url. it is required for deleting a provider setting.",
+				Instance: path,
+			})
+
+			return
+		}
+
+		if !ensureInNamespace(c, log, prod, path, "provider setting", settingNamespace(m, id)) {
+			return
+		}
+
+		dryRun := c.Query("dryRun") == "true"
+		cascade := c.Query("cascade") == "true"
+
+		var result *dryrun.Result
+		var err error
+		if dryRun {
+			result, err = m.PreviewDeleteSetting(id, cascade)
+		} else {
+			ns := namespaceForChange(c, settingNamespace(m, id))
+			recordChange(c, change.KindProviderSetting, change.ActionDelete, id, ns)
+
+			var dependents []*dryrun.Dependent
+			dependents, err = m.DeleteSetting(id, cascade)
+			recordCascade(c, dependents, ns)
+		}
+
+		if err != nil {
+			errType := "internal"
+			defer func() {
+				telemetry.Incr("bricksllm.admin.get_delete_provider_setting_handler.delete_err", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    "provider setting is not found",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(conflictError); ok {
+				errType = "conflict"
+				c.JSON(http.StatusConflict, &ErrorResponse{
+					Type:     "/errors/conflict",
+					Title:    "provider setting is still referenced",
+					Status:   http.StatusConflict,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when deleting a provider setting", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/provider-settings-manager",
+				Title:    "deleting a provider setting error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		if dryRun {
+			telemetry.Incr("bricksllm.admin.get_delete_provider_setting_handler.dry_run_success", nil, 1)
+			c.JSON(http.StatusOK, result)
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_delete_provider_setting_handler.success", nil, 1)
+		c.Status(http.StatusOK)
+	}
+}
+
+func getDeleteCustomProviderHandler(m CustomProvidersManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_delete_custom_provider_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_delete_custom_provider_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/custom/providers/:id"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		id := c.Param("id")
+		if len(id) == 0 {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/missing-param-id",
+				Title:    "id is empty",
+				Status:   http.StatusBadRequest,
+				Detail:   "id url param is missing from the request url. it is required for deleting a custom provider.",
+				Instance: path,
+			})
+
+			return
+		}
+
+		dryRun := c.Query("dryRun") == "true"
+		cascade := c.Query("cascade") == "true"
+
+		var result *dryrun.Result
+		var err error
+		if dryRun {
+			result, err = m.PreviewDeleteCustomProvider(id, cascade)
+		} else {
+			var dependents []*dryrun.Dependent
+			dependents, err = m.DeleteCustomProvider(id, cascade)
+			recordCascade(c, dependents, "")
+		}
+
+		if err != nil {
+			errType := "internal"
+			defer func() {
+				telemetry.Incr("bricksllm.admin.get_delete_custom_provider_handler.delete_err", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    "custom provider is not found",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(conflictError); ok {
+				errType = "conflict"
+				c.JSON(http.StatusConflict, &ErrorResponse{
+					Type:     "/errors/conflict",
+					Title:    "custom provider is still referenced",
+					Status:   http.StatusConflict,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when deleting a custom provider", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/custom-provider-manager",
+				Title:    "deleting a custom provider error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		if dryRun {
+			telemetry.Incr("bricksllm.admin.get_delete_custom_provider_handler.dry_run_success", nil, 1)
+			c.JSON(http.StatusOK, result)
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_delete_custom_provider_handler.success", nil, 1)
+		c.Status(http.StatusOK)
+	}
+}
+
 func logError(log *zap.Logger, msg string, prod bool, err error) {
 	if prod {
 		log.Debug(msg, zap.Error(err))
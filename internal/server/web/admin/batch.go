@@ -0,0 +1,60 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/batch"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+func getGetBatchesHandler(m KeyReportingManager, km KeyManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_get_batches_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_get_batches_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/batches"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		kids, ok := scopeKeyIds(c, log, prod, path, km, c.QueryArray("keyIds"), []*batch.Batch{})
+		if !ok {
+			return
+		}
+
+		batches, err := m.GetBatches(kids, c.Query("status"))
+		if err != nil {
+			telemetry.Incr("bricksllm.admin.get_get_batches_handler.get_batches_error", nil, 1)
+
+			logError(log, "error when getting batches", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/key-reporting-manager",
+				Title:    "getting batches error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_get_batches_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, batches)
+	}
+}
@@ -0,0 +1,258 @@
+package admin
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+type fieldsError interface {
+	Fields() []*internal_errors.FieldError
+}
+
+func fieldErrorsOf(err error) []*internal_errors.FieldError {
+	if fe, ok := err.(fieldsError); ok {
+		return fe.Fields()
+	}
+
+	return nil
+}
+
+// bindJSON checks the request body against the shape of v before decoding it so
+// that every mismatched field is reported instead of only the first one.
+func bindJSON(data []byte, v any) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	var raw any
+	if err := d.Decode(&raw); err != nil {
+		return internal_errors.NewFieldsValidationError([]*internal_errors.FieldError{{
+			Field:  "body",
+			Reason: "request body is not valid json: " + err.Error(),
+		}})
+	}
+
+	fields := checkJSONValue("", raw, reflect.TypeOf(v))
+	if len(fields) != 0 {
+		sort.Slice(fields, func(i, j int) bool {
+			return fields[i].Field < fields[j].Field
+		})
+
+		return internal_errors.NewFieldsValidationError(fields)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return internal_errors.NewFieldsValidationError([]*internal_errors.FieldError{{
+			Field:  "body",
+			Reason: err.Error(),
+		}})
+	}
+
+	return nil
+}
+
+func jsonKindOf(value any) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+
+	return "null"
+}
+
+func mismatch(path, expected string, value any) []*internal_errors.FieldError {
+	if len(path) == 0 {
+		path = "body"
+	}
+
+	fe := &internal_errors.FieldError{
+		Field:  path,
+		Reason: fmt.Sprintf("expected %s but got %s", expected, jsonKindOf(value)),
+	}
+
+	if kind := jsonKindOf(value); kind != "array" && kind != "object" {
+		fe.Value = value
+	}
+
+	return []*internal_errors.FieldError{fe}
+}
+
+func joinPath(path, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+
+	return path + "." + name
+}
+
+func checkJSONValue(path string, value any, t reflect.Type) []*internal_errors.FieldError {
+	if value == nil {
+		return nil
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return nil
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			return mismatch(path, "string", value)
+		}
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			return mismatch(path, "boolean", value)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := value.(json.Number)
+		if !ok {
+			return mismatch(path, "integer", value)
+		}
+
+		if _, err := n.Int64(); err != nil {
+			return mismatch(path, "integer", value)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := value.(json.Number)
+		if !ok || strings.HasPrefix(n.String(), "-") {
+			return mismatch(path, "non-negative integer", value)
+		}
+
+		if _, err := n.Int64(); err != nil {
+			return mismatch(path, "non-negative integer", value)
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := value.(json.Number); !ok {
+			return mismatch(path, "number", value)
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			if _, ok := value.(string); !ok {
+				return mismatch(path, "base64 encoded string", value)
+			}
+
+			return nil
+		}
+
+		items, ok := value.([]any)
+		if !ok {
+			return mismatch(path, "array", value)
+		}
+
+		fields := []*internal_errors.FieldError{}
+		for index, item := range items {
+			fields = append(fields, checkJSONValue(joinPath(path, fmt.Sprintf("[%d]", index)), item, t.Elem())...)
+		}
+
+		return fields
+	case reflect.Map:
+		entries, ok := value.(map[string]any)
+		if !ok {
+			return mismatch(path, "object", value)
+		}
+
+		fields := []*internal_errors.FieldError{}
+		for k, entry := range entries {
+			fields = append(fields, checkJSONValue(joinPath(path, k), entry, t.Elem())...)
+		}
+
+		return fields
+	case reflect.Struct:
+		entries, ok := value.(map[string]any)
+		if !ok {
+			return mismatch(path, "object", value)
+		}
+
+		fieldTypes := map[string]reflect.Type{}
+		collectJSONFields(t, fieldTypes)
+
+		fields := []*internal_errors.FieldError{}
+		for k, entry := range entries {
+			ft, ok := fieldTypes[k]
+			if !ok {
+				ft, ok = fieldTypes[strings.ToLower(k)]
+			}
+
+			// unknown fields are ignored the same way encoding/json ignores them
+			if !ok {
+				continue
+			}
+
+			fields = append(fields, checkJSONValue(joinPath(path, k), entry, ft)...)
+		}
+
+		return fields
+	}
+
+	return nil
+}
+
+// collectJSONFields maps json names of t, including promoted fields of embedded
+// structs, to their types. Lower cased names are added for case insensitive matching.
+func collectJSONFields(t reflect.Type, fieldTypes map[string]reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.Anonymous {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				if _, tagged := f.Tag.Lookup("json"); !tagged {
+					collectJSONFields(ft, fieldTypes)
+					continue
+				}
+			}
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+
+			if parsed := strings.Split(tag, ",")[0]; len(parsed) != 0 {
+				name = parsed
+			}
+		}
+
+		if _, ok := fieldTypes[name]; !ok {
+			fieldTypes[name] = f.Type
+		}
+
+		if _, ok := fieldTypes[strings.ToLower(name)]; !ok {
+			fieldTypes[strings.ToLower(name)] = f.Type
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimitConfig caps the size of admin request bodies. Routes are keyed by
+// method and route path, such as "POST /api/v2/events", and override MaxBytes.
+// A limit of zero or less disables the check.
+type BodyLimitConfig struct {
+	MaxBytes int64
+	Routes   map[string]int64
+}
+
+// NewBodyLimitConfig parses route limits given as "METHOD /path=bytes".
+func NewBodyLimitConfig(maxBytes int64, routes []string) (*BodyLimitConfig, error) {
+	cfg := &BodyLimitConfig{
+		MaxBytes: maxBytes,
+		Routes:   map[string]int64{},
+	}
+
+	for _, entry := range routes {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+
+		route, size, found := strings.Cut(entry, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		if !found || !hasPath || !strings.HasPrefix(strings.TrimSpace(path), "/") {
+			return nil, fmt.Errorf("body limit %s is not in the form of METHOD /path=bytes", entry)
+		}
+
+		parsed, err := strconv.ParseInt(strings.TrimSpace(size), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("body limit %s does not have a valid size: %w", entry, err)
+		}
+
+		cfg.Routes[strings.ToUpper(method)+" "+strings.TrimSpace(path)] = parsed
+	}
+
+	return cfg, nil
+}
+
+func (cfg *BodyLimitConfig) limitOf(method, path string) int64 {
+	if limit, ok := cfg.Routes[method+" "+path]; ok {
+		return limit
+	}
+
+	return cfg.MaxBytes
+}
+
+// getBodyLimitMiddleware rejects bodies declared larger than the limit of the
+// route up front and caps the rest while they are read, so that handlers never
+// buffer more than the limit.
+func getBodyLimitMiddleware(cfg *BodyLimitConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cfg == nil || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		path := c.FullPath()
+		limit := cfg.limitOf(c.Request.Method, path)
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > limit {
+			telemetry.Incr("bricksllm.admin.get_body_limit_middleware.rejected", []string{"path:" + path}, 1)
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, newBodyTooLargeResponse(limit, path))
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+func newBodyTooLargeResponse(limit int64, path string) *ErrorResponse {
+	return &ErrorResponse{
+		Type:     "/errors/request-too-large",
+		Title:    "request body is too large",
+		Status:   http.StatusRequestEntityTooLarge,
+		Detail:   fmt.Sprintf("request body exceeds the limit of %d bytes", limit),
+		Instance: path,
+	}
+}
+
+// bodyTooLarge responds with 413 when err was caused by reading past the body
+// limit. Bodies sent without a Content-Length are only caught here.
+func bodyTooLarge(c *gin.Context, path string, err error) bool {
+	mbe := &http.MaxBytesError{}
+	if !errors.As(err, &mbe) {
+		return false
+	}
+
+	telemetry.Incr("bricksllm.admin.get_body_limit_middleware.rejected", []string{"path:" + c.FullPath()}, 1)
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, newBodyTooLargeResponse(mbe.Limit, path))
+	return true
+}
@@ -0,0 +1,119 @@
+package admin
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/change"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+// getBulkRevokeKeysHandler revokes every active key matched by a filter, for
+// example all keys of a compromised provider setting. Requests without a
+// confirmation token only preview the revocation.
+func getBulkRevokeKeysHandler(m KeyManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_bulk_revoke_keys_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_bulk_revoke_keys_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/key-management/keys/revoke"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
+			logError(log, "error when reading bulk revoke keys request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		r := &key.BulkRevokeRequest{}
+		if err := bindJSON(data, r); err != nil {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "request body validation failed",
+				Status:   http.StatusBadRequest,
+				Detail:   err.Error(),
+				Instance: path,
+				Errors:   fieldErrorsOf(err),
+			})
+			return
+		}
+
+		// a namespaced admin can only revoke keys of its own namespace
+		if ns, ok := namespaceOf(c); ok {
+			r.Namespace = ns
+		}
+
+		result, err := m.BulkRevokeKeys(r)
+		if err != nil {
+			errType := "internal"
+			defer func() {
+				telemetry.Incr("bricksllm.admin.get_bulk_revoke_keys_handler.bulk_revoke_keys_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(validationError); ok {
+				errType = "validation"
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "bulk revoke validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+					Errors:   fieldErrorsOf(err),
+				})
+				return
+			}
+
+			logError(log, "error when bulk revoking keys", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/key-manager",
+				Title:    "bulk revoke keys error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		if result.Revoked {
+			for _, id := range result.KeyIds {
+				recordChange(c, change.KindKey, change.ActionUpdate, id, namespaceForChange(c, keyNamespace(m, id)))
+			}
+		}
+
+		telemetry.Incr("bricksllm.admin.get_bulk_revoke_keys_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,176 @@
+package admin
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/catalog"
+	"github.com/bricks-cloud/bricksllm/internal/change"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+type CatalogManager interface {
+	GetModels(provider string) ([]*catalog.Model, error)
+	UpdateModel(id string, u *catalog.UpdatePricing) (*catalog.Pricing, error)
+	GetPricing() ([]*catalog.Pricing, error)
+	PutPricing(ps []*catalog.Pricing) ([]*catalog.Pricing, error)
+}
+
+func getGetModelsHandler(m CatalogManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_get_models_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_get_models_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/models"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		models, err := m.GetModels(c.Query("provider"))
+		if err != nil {
+			telemetry.Incr("bricksllm.admin.get_get_models_handler.get_models_error", nil, 1)
+
+			logError(log, "error when getting models", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/catalog-manager",
+				Title:    "getting models error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		if c.Query("missing") == "true" {
+			filtered := []*catalog.Model{}
+			for _, model := range models {
+				if model.Missing {
+					filtered = append(filtered, model)
+				}
+			}
+
+			models = filtered
+		}
+
+		telemetry.Incr("bricksllm.admin.get_get_models_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, models)
+	}
+}
+
+// getUpdateModelHandler overrides the pricing and capabilities of a model in
+// the managed pricing table.
+func getUpdateModelHandler(m CatalogManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_update_model_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_update_model_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/models/:id"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
+			logError(log, "error when reading model update request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		u := &catalog.UpdatePricing{}
+		if err := bindJSON(data, u); err != nil {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "request body validation failed",
+				Status:   http.StatusBadRequest,
+				Detail:   err.Error(),
+				Instance: path,
+				Errors:   fieldErrorsOf(err),
+			})
+			return
+		}
+
+		updated, err := m.UpdateModel(c.Param("id"), u)
+		if err != nil {
+			telemetry.Incr("bricksllm.admin.get_update_model_handler.update_model_error", nil, 1)
+
+			if _, ok := err.(notFoundError); ok {
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    "model is not found",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(validationError); ok {
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "model validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+					Errors:   fieldErrorsOf(err),
+				})
+				return
+			}
+
+			logError(log, "error when updating a model", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/catalog-manager",
+				Title:    "updating model error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		recordChange(c, change.KindPricing, change.ActionUpdate, updated.Id, "")
+
+		telemetry.Incr("bricksllm.admin.get_update_model_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, updated)
+	}
+}
@@ -0,0 +1,318 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/change"
+	"github.com/bricks-cloud/bricksllm/internal/dryrun"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	changesPath              = "/api/changes/stream"
+	configChangesPath        = "/api/config/changes"
+	changesHeartbeatInterval = 15 * time.Second
+	defaultLongPollTimeout   = 30 * time.Second
+	maxLongPollTimeout       = 60 * time.Second
+	pendingChangesKey        = "pendingChanges"
+)
+
+type pendingChange struct {
+	kind      string
+	action    string
+	id        string
+	namespace string
+}
+
+// recordChange queues a change to be published once the handler has responded
+// successfully, so that failed writes never reach the stream.
+func recordChange(c *gin.Context, kind, action, id, namespace string) {
+	pending, _ := c.Get(pendingChangesKey)
+	changes, _ := pending.([]*pendingChange)
+
+	c.Set(pendingChangesKey, append(changes, &pendingChange{
+		kind:      kind,
+		action:    action,
+		id:        id,
+		namespace: namespace,
+	}))
+}
+
+// namespaceForChange returns the namespace a change is published under. Writes
+// made without the X-Namespace header look the namespace up beforehand since
+// the object may be gone once the handler is done.
+func namespaceForChange(c *gin.Context, lookup func() (string, error)) string {
+	if ns, ok := namespaceOf(c); ok {
+		return ns
+	}
+
+	ns, _ := lookup()
+	return ns
+}
+
+// recordCascade queues the deletion of the dependents removed together with a
+// resource. They are published under the namespace of that resource.
+func recordCascade(c *gin.Context, dependents []*dryrun.Dependent, namespace string) {
+	kinds := map[string]string{
+		"key":              change.KindKey,
+		"provider_setting": change.KindProviderSetting,
+	}
+
+	for _, d := range dependents {
+		kind, ok := kinds[d.Resource]
+		if !ok {
+			continue
+		}
+
+		for _, id := range d.Ids {
+			recordChange(c, kind, change.ActionDelete, id, namespace)
+		}
+	}
+}
+
+func getChangesMiddleware(h *change.Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusMultipleChoices {
+			return
+		}
+
+		pending, _ := c.Get(pendingChangesKey)
+		changes, _ := pending.([]*pendingChange)
+		for _, pc := range changes {
+			h.Publish(pc.kind, pc.action, pc.id, pc.namespace)
+		}
+	}
+}
+
+// changeFilter selects the changes of the kinds given by the kinds query
+// param that belong to the namespace the request is scoped to.
+func changeFilter(c *gin.Context) func(*change.Change) bool {
+	kinds := map[string]bool{}
+	for _, kind := range c.QueryArray("kinds") {
+		kinds[kind] = true
+	}
+
+	ns, scoped := namespaceOf(c)
+	return func(ch *change.Change) bool {
+		if scoped && ch.Namespace != ns {
+			return false
+		}
+
+		return len(kinds) == 0 || kinds[ch.Kind]
+	}
+}
+
+func getGetChangesStreamHandler(h *change.Hub, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_get_changes_stream_handler.requests", nil, 1)
+
+		path := changesPath
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		// browsers resend the id of the last event they received when reconnecting
+		raw := c.GetHeader("Last-Event-ID")
+		if len(raw) == 0 {
+			raw = c.Query("after")
+		}
+
+		var after int64
+		if len(raw) != 0 {
+			parsed, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || parsed < 0 {
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "change stream request validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   "last event id must be a non negative integer",
+					Instance: path,
+				})
+				return
+			}
+
+			after = parsed
+		}
+
+		selected := changeFilter(c)
+
+		backlog, ch := h.Subscribe(after)
+		defer h.Unsubscribe(ch)
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+
+		write := func(w io.Writer, ch *change.Change) bool {
+			if !selected(ch) {
+				return true
+			}
+
+			data, err := json.Marshal(ch)
+			if err != nil {
+				logError(log, "error when marshalling a change", prod, err)
+				return true
+			}
+
+			_, err = fmt.Fprintf(w, "id: %d\nevent: change\ndata: %s\n\n", ch.Seq, data)
+			return err == nil
+		}
+
+		for _, b := range backlog {
+			if !write(c.Writer, b) {
+				return
+			}
+		}
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(changesHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case next, ok := <-ch:
+				if !ok {
+					telemetry.Incr("bricksllm.admin.get_get_changes_stream_handler.subscriber_dropped", nil, 1)
+					return false
+				}
+
+				return write(w, next)
+			case <-heartbeat.C:
+				_, err := io.WriteString(w, ": keep-alive\n\n")
+				return err == nil
+			}
+		})
+	}
+}
+
+// getGetConfigChangesHandler answers as soon as a change after the since
+// version is published, or with an empty list once the timeout passes, so
+// that sync agents pick up admin writes without polling on a fixed interval.
+func getGetConfigChangesHandler(h *change.Hub, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		telemetry.Incr("bricksllm.admin.get_get_config_changes_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_get_config_changes_handler.latency", dur, nil, 1)
+		}()
+
+		path := configChangesPath
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		raw := c.Query("since")
+		if len(raw) == 0 {
+			// without a version there is nothing to catch up from
+			_, version, _ := h.Since(0)
+			c.JSON(http.StatusOK, &change.Batch{Version: version, Reset: true, Changes: []*change.Change{}})
+			return
+		}
+
+		since, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || since < 0 {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "config changes request validation failed",
+				Status:   http.StatusBadRequest,
+				Detail:   "since must be a non negative integer",
+				Instance: path,
+			})
+			return
+		}
+
+		timeout := defaultLongPollTimeout
+		if raw := c.Query("timeout"); len(raw) != 0 {
+			parsed, err := time.ParseDuration(raw)
+			if err != nil || parsed < 0 || parsed > maxLongPollTimeout {
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "config changes request validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   fmt.Sprintf("timeout must be a duration between 0s and %s", maxLongPollTimeout),
+					Instance: path,
+				})
+				return
+			}
+
+			timeout = parsed
+		}
+
+		selected := changeFilter(c)
+		poll := func() *change.Batch {
+			changes, version, ok := h.Since(since)
+
+			res := &change.Batch{Version: version, Reset: !ok, Changes: []*change.Change{}}
+			for _, ch := range changes {
+				if selected(ch) {
+					res.Changes = append(res.Changes, ch)
+				}
+			}
+
+			return res
+		}
+
+		// subscribe before looking at the backlog so that nothing published in
+		// between is missed
+		_, ch := h.Subscribe(0)
+		defer h.Unsubscribe(ch)
+
+		res := poll()
+		if res.Reset || len(res.Changes) != 0 || timeout == 0 {
+			telemetry.Incr("bricksllm.admin.get_get_config_changes_handler.success", nil, 1)
+			c.JSON(http.StatusOK, res)
+			return
+		}
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+	wait:
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case <-timer.C:
+				telemetry.Incr("bricksllm.admin.get_get_config_changes_handler.timeout", nil, 1)
+				break wait
+			case next, ok := <-ch:
+				if !ok || selected(next) {
+					break wait
+				}
+			}
+		}
+
+		telemetry.Incr("bricksllm.admin.get_get_config_changes_handler.success", nil, 1)
+		c.JSON(http.StatusOK, poll())
+	}
+}
@@ -0,0 +1,247 @@
+package admin
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+const claimKeyPath = "/api/key-management/claims/:token"
+
+func getCreateClaimLinkHandler(m KeyManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_create_claim_link_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_create_claim_link_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/key-management/keys/:id/claim-link"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		id := c.Param("id")
+		if len(id) == 0 {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/missing-param-id",
+				Title:    "id is empty",
+				Status:   http.StatusBadRequest,
+				Detail:   "id url param is missing from the request url. it is required for creating a claim link.",
+				Instance: path,
+			})
+
+			return
+		}
+
+		if !ensureInNamespace(c, log, prod, path, "key", keyNamespace(m, id)) {
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
+			logError(log, "error when reading claim link request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		r := &key.ClaimLinkRequest{}
+		if len(data) != 0 {
+			err = json.Unmarshal(data, r)
+			if err != nil {
+				logError(log, "error when unmarshalling claim link request body", prod, err)
+				c.JSON(http.StatusInternalServerError, &ErrorResponse{
+					Type:     "/errors/json-unmarshal",
+					Title:    "json unmarshaller error",
+					Status:   http.StatusInternalServerError,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+		}
+
+		cl, err := m.CreateClaimLink(id, r)
+		if err != nil {
+			errType := "internal"
+			defer func() {
+				telemetry.Incr("bricksllm.admin.get_create_claim_link_handler.create_claim_link_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(validationError); ok {
+				errType = "validation"
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "claim link validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    "create claim link failed",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when creating claim link", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/key-manager",
+				Title:    "create claim link error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		scheme := "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+
+		cl.Url = scheme + "://" + c.Request.Host + strings.Replace(claimKeyPath, ":token", cl.Token, 1)
+
+		telemetry.Incr("bricksllm.admin.get_create_claim_link_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, cl)
+	}
+}
+
+var claimPage = template.Must(template.New("claim").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="robots" content="noindex"><title>Claim key</title></head>
+<body>
+<p>Claiming reveals a new secret for the key once and replaces its current secret.</p>
+<form method="post" action="{{.}}"><button type="submit">Claim key</button></form>
+</body>
+</html>
+`))
+
+// getClaimKeyPageHandler serves the page a claim link opens to. Link
+// unfurlers and scanners fetch links on their own, so opening the link only
+// asks for a confirmation and the token is redeemed with a POST.
+func getClaimKeyPageHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		telemetry.Incr("bricksllm.admin.get_claim_key_page_handler.requests", nil, 1)
+
+		c.Header("Cache-Control", "no-store")
+		c.Header("X-Robots-Tag", "noindex")
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Status(http.StatusOK)
+		claimPage.Execute(c.Writer, strings.Replace(claimKeyPath, ":token", c.Param("token"), 1))
+	}
+}
+
+func getClaimKeyHandler(m KeyManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_claim_key_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_claim_key_handler.latency", dur, nil, 1)
+		}()
+
+		path := claimKeyPath
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		token := c.Param("token")
+		if len(token) == 0 {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/missing-param-token",
+				Title:    "token is empty",
+				Status:   http.StatusBadRequest,
+				Detail:   "token url param is missing from the request url. it is required for claiming a key.",
+				Instance: path,
+			})
+
+			return
+		}
+
+		ck, err := m.ClaimKey(token)
+		if err != nil {
+			errType := "internal"
+			defer func() {
+				telemetry.Incr("bricksllm.admin.get_claim_key_handler.claim_key_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    "claim key failed",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when claiming key", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/key-manager",
+				Title:    "claim key error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_claim_key_handler.success", nil, 1)
+
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, ck)
+	}
+}
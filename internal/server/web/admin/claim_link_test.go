@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type claimKeyManager struct {
+	KeyManager
+	claimed []string
+}
+
+func (m *claimKeyManager) ClaimKey(token string) (*key.ClaimedKey, error) {
+	m.claimed = append(m.claimed, token)
+	return &key.ClaimedKey{KeyId: "key-id", Key: "secret"}, nil
+}
+
+func newClaimRouter(m KeyManager) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		util.SetLogToCtx(c, zap.NewNop())
+	})
+	router.GET(claimKeyPath, getClaimKeyPageHandler())
+	router.POST(claimKeyPath, getClaimKeyHandler(m, false))
+
+	return router
+}
+
+func TestClaimKeyPageDoesNotConsumeToken(t *testing.T) {
+	m := &claimKeyManager{}
+	router := newClaimRouter(m)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/key-management/claims/token", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+	}
+
+	if len(m.claimed) != 0 {
+		t.Fatalf("expected GET not to claim the key, got %d claims", len(m.claimed))
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/key-management/claims/token", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	if len(m.claimed) != 1 || m.claimed[0] != "token" {
+		t.Fatalf("expected POST to claim token, got %v", m.claimed)
+	}
+}
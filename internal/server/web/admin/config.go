@@ -0,0 +1,274 @@
+package admin
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/gitops"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+type ConfigManager interface {
+	Apply(data []byte, dryRun, prune bool) (*gitops.ApplyResult, error)
+	Export() (*gitops.Archive, error)
+	Import(data []byte, strategy string, dryRun bool) (*gitops.ApplyResult, error)
+}
+
+// recordApplied queues the changes made by applying a document. Custom
+// providers are not part of the change feed.
+func recordApplied(c *gin.Context, result *gitops.ApplyResult) {
+	if result.DryRun {
+		return
+	}
+
+	ns, _ := namespaceOf(c)
+	for _, ch := range result.Changes {
+		if ch.Action == gitops.ActionUnchanged || ch.Action == gitops.ActionSkip || ch.Kind == gitops.KindCustomProvider {
+			continue
+		}
+
+		recordChange(c, ch.Kind, ch.Action, ch.Id, ns)
+	}
+}
+
+func getApplyConfigHandler(m ConfigManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_apply_config_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_apply_config_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/config/apply"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
+			logError(log, "error when reading apply config request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		dryRun := c.Query("dryRun") == "true"
+		prune := c.Query("prune") == "true"
+
+		result, err := m.Apply(data, dryRun, prune)
+		if err != nil {
+			errType := "internal"
+
+			defer func() {
+				telemetry.Incr("bricksllm.admin.get_apply_config_handler.apply_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(validationError); ok {
+				errType = "validation"
+
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "config validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    "config apply failed",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when applying config", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/config-manager",
+				Title:    "config apply error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		recordApplied(c, result)
+
+		telemetry.Incr("bricksllm.admin.get_apply_config_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+func getExportConfigHandler(m ConfigManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_export_config_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_export_config_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/config/export"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		archive, err := m.Export()
+		if err != nil {
+			telemetry.Incr("bricksllm.admin.get_export_config_handler.export_error", nil, 1)
+
+			logError(log, "error when exporting config", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/config-manager",
+				Title:    "config export error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_export_config_handler.success", nil, 1)
+
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"bricksllm-config-%d.json\"", archive.ExportedAt))
+		c.JSON(http.StatusOK, archive)
+	}
+}
+
+func getImportConfigHandler(m ConfigManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_import_config_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_import_config_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/config/import"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
+			logError(log, "error when reading import config request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		result, err := m.Import(data, c.Query("strategy"), c.Query("dryRun") == "true")
+		if err != nil {
+			errType := "internal"
+
+			defer func() {
+				telemetry.Incr("bricksllm.admin.get_import_config_handler.import_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(validationError); ok {
+				errType = "validation"
+
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "config validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(conflictError); ok {
+				errType = "conflict"
+
+				c.JSON(http.StatusConflict, &ErrorResponse{
+					Type:     "/errors/conflict",
+					Title:    "config import conflict",
+					Status:   http.StatusConflict,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when importing config", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/config-manager",
+				Title:    "config import error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		recordApplied(c, result)
+
+		telemetry.Incr("bricksllm.admin.get_import_config_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, result)
+	}
+}
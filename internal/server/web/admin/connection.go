@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// writeSettingCheckError maps errors of the endpoints that check a provider
+// setting against its upstream to responses.
+func writeSettingCheckError(c *gin.Context, log *zap.Logger, prod bool, path, metric, check string, err error) {
+	errType := "internal"
+	defer func() {
+		telemetry.Incr(metric, []string{
+			"error_type:" + errType,
+		}, 1)
+	}()
+
+	if _, ok := err.(notFoundError); ok {
+		errType = "not_found"
+		c.JSON(http.StatusNotFound, &ErrorResponse{
+			Type:     "/errors/not-found",
+			Title:    "provider setting is not found",
+			Status:   http.StatusNotFound,
+			Detail:   err.Error(),
+			Instance: path,
+		})
+		return
+	}
+
+	if _, ok := err.(validationError); ok {
+		errType = "validation"
+		c.JSON(http.StatusBadRequest, &ErrorResponse{
+			Type:     "/errors/validation",
+			Title:    "provider setting " + check + " validation failed",
+			Status:   http.StatusBadRequest,
+			Detail:   err.Error(),
+			Instance: path,
+			Errors:   fieldErrorsOf(err),
+		})
+		return
+	}
+
+	logError(log, "error when running provider setting "+check, prod, err)
+	c.JSON(http.StatusInternalServerError, &ErrorResponse{
+		Type:     "/errors/provider-settings-manager",
+		Title:    "provider setting " + check + " error",
+		Status:   http.StatusInternalServerError,
+		Detail:   err.Error(),
+		Instance: path,
+	})
+}
+
+// getTestProviderSettingHandler checks that the upstream of a provider setting
+// is reachable and accepts its credentials. Failed checks are reported in the
+// body with a 200, since the test itself ran.
+func getTestProviderSettingHandler(m ProviderSettingsManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_test_provider_setting_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_test_provider_setting_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/provider-settings/:id/test"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		id := c.Param("id")
+		if !ensureInNamespace(c, log, prod, path, "provider setting", settingNamespace(m, id)) {
+			return
+		}
+
+		t, err := m.TestConnection(c.Request.Context(), id)
+		if err != nil {
+			writeSettingCheckError(c, log, prod, path, "bricksllm.admin.get_test_provider_setting_handler.test_connection_error", "connection test", err)
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_test_provider_setting_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, t)
+	}
+}
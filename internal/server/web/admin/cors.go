@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CorsConfig struct {
+	AllowedOrigins []string
+	AllowedHeaders []string
+	AllowedMethods []string
+}
+
+func getCorsMiddleware(cfg *CorsConfig) gin.HandlerFunc {
+	allowAll := false
+	origins := map[string]bool{}
+	headers, methods := "", ""
+
+	if cfg != nil {
+		for _, o := range cfg.AllowedOrigins {
+			o = strings.TrimSuffix(strings.TrimSpace(o), "/")
+			if o == "*" {
+				allowAll = true
+			}
+
+			if len(o) != 0 {
+				origins[o] = true
+			}
+		}
+
+		headers = strings.Join(cfg.AllowedHeaders, ", ")
+		methods = strings.Join(cfg.AllowedMethods, ", ")
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if len(origins) == 0 || len(origin) == 0 {
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Origin")
+
+		allowed := allowAll || origins[origin]
+		if allowed {
+			c.Header("Access-Control-Allow-Origin", origin)
+			c.Header("Access-Control-Expose-Headers", "Idempotent-Replayed, Retry-After, ETag")
+		}
+
+		// preflight requests carry no admin credentials so they are answered before authentication
+		if c.Request.Method == http.MethodOptions && len(c.GetHeader("Access-Control-Request-Method")) != 0 {
+			if allowed {
+				c.Header("Access-Control-Allow-Methods", methods)
+				c.Header("Access-Control-Allow-Headers", headers)
+				c.Header("Access-Control-Max-Age", "3600")
+			}
+
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
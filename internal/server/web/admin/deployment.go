@@ -0,0 +1,213 @@
+package admin
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/change"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// writeDeploymentError maps errors of the deployment endpoints to responses.
+func writeDeploymentError(c *gin.Context, log *zap.Logger, prod bool, path, metric string, err error) {
+	errType := "internal"
+	defer func() {
+		telemetry.Incr(metric, []string{
+			"error_type:" + errType,
+		}, 1)
+	}()
+
+	if _, ok := err.(notFoundError); ok {
+		errType = "not_found"
+		c.JSON(http.StatusNotFound, &ErrorResponse{
+			Type:     "/errors/not-found",
+			Title:    "deployment mapping is not found",
+			Status:   http.StatusNotFound,
+			Detail:   err.Error(),
+			Instance: path,
+		})
+		return
+	}
+
+	if _, ok := err.(validationError); ok {
+		errType = "validation"
+		c.JSON(http.StatusBadRequest, &ErrorResponse{
+			Type:     "/errors/validation",
+			Title:    "deployment mapping validation failed",
+			Status:   http.StatusBadRequest,
+			Detail:   err.Error(),
+			Instance: path,
+			Errors:   fieldErrorsOf(err),
+		})
+		return
+	}
+
+	logError(log, "error when managing azure deployment mappings", prod, err)
+	c.JSON(http.StatusInternalServerError, &ErrorResponse{
+		Type:     "/errors/provider-settings-manager",
+		Title:    "deployment mapping error",
+		Status:   http.StatusInternalServerError,
+		Detail:   err.Error(),
+		Instance: path,
+	})
+}
+
+func getGetDeploymentsHandler(m ProviderSettingsManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_get_deployments_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_get_deployments_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/provider-settings/:id/deployments"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		id := c.Param("id")
+		if !ensureInNamespace(c, log, prod, path, "provider setting", settingNamespace(m, id)) {
+			return
+		}
+
+		deployments, err := m.GetDeployments(id)
+		if err != nil {
+			writeDeploymentError(c, log, prod, path, "bricksllm.admin.get_get_deployments_handler.get_deployments_error", err)
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_get_deployments_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, deployments)
+	}
+}
+
+func getPutDeploymentHandler(m ProviderSettingsManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_put_deployment_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_put_deployment_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/provider-settings/:id/deployments/:model"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		id := c.Param("id")
+		if !ensureInNamespace(c, log, prod, path, "provider setting", settingNamespace(m, id)) {
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
+			logError(log, "error when reading put deployment request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		d := &provider.Deployment{}
+		if err := bindJSON(data, d); err != nil {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "request body validation failed",
+				Status:   http.StatusBadRequest,
+				Detail:   err.Error(),
+				Instance: path,
+				Errors:   fieldErrorsOf(err),
+			})
+			return
+		}
+
+		// the model of the url is the one being mapped
+		d.Model = c.Param("model")
+
+		deployments, err := m.PutDeployment(id, d)
+		if err != nil {
+			writeDeploymentError(c, log, prod, path, "bricksllm.admin.get_put_deployment_handler.put_deployment_error", err)
+			return
+		}
+
+		recordChange(c, change.KindProviderSetting, change.ActionUpdate, id, namespaceForChange(c, settingNamespace(m, id)))
+		telemetry.Incr("bricksllm.admin.get_put_deployment_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, deployments)
+	}
+}
+
+func getDeleteDeploymentHandler(m ProviderSettingsManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_delete_deployment_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_delete_deployment_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/provider-settings/:id/deployments/:model"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		id := c.Param("id")
+		if !ensureInNamespace(c, log, prod, path, "provider setting", settingNamespace(m, id)) {
+			return
+		}
+
+		deployments, err := m.DeleteDeployment(id, c.Param("model"))
+		if err != nil {
+			writeDeploymentError(c, log, prod, path, "bricksllm.admin.get_delete_deployment_handler.delete_deployment_error", err)
+			return
+		}
+
+		recordChange(c, change.KindProviderSetting, change.ActionUpdate, id, namespaceForChange(c, settingNamespace(m, id)))
+		telemetry.Incr("bricksllm.admin.get_delete_deployment_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, deployments)
+	}
+}
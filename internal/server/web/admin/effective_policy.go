@@ -0,0 +1,96 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+// getGetEffectivePolicyHandler reports the policy applied to requests of a
+// key, and whether it is attached to the key, to the route given by the
+// routeId query param or matched by tag.
+func getGetEffectivePolicyHandler(m KeyManager, rm RouteManager, pm PoliciesManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_get_effective_policy_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_get_effective_policy_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/key-management/keys/:id/effective-policy"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		id := c.Param("id")
+		if !ensureInNamespace(c, log, prod, path, "key", keyNamespace(m, id)) {
+			return
+		}
+
+		routeId := c.Query("routeId")
+		if len(routeId) != 0 && !ensureInNamespace(c, log, prod, path, "route", routeNamespace(rm, routeId)) {
+			return
+		}
+
+		ep, err := pm.GetEffectivePolicy(id, routeId)
+		if err != nil {
+			errType := "internal"
+			defer func() {
+				telemetry.Incr("bricksllm.admin.get_get_effective_policy_handler.get_effective_policy_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(validationError); ok {
+				errType = "validation"
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "effective policy request validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    "key or route is not found",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when getting the effective policy of a key", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/policy-manager",
+				Title:    "get effective policy error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_get_effective_policy_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, ep)
+	}
+}
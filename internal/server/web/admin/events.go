@@ -2,7 +2,6 @@ package admin
 
 import (
 	"encoding/json"
-	"io"
 	"net/http"
 	"strconv"
 	"time"
@@ -13,7 +12,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func getGetUserIdsHandler(m KeyReportingManager, prod bool) gin.HandlerFunc {
+func getGetUserIdsHandler(m KeyReportingManager, km KeyManager, prod bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := util.GetLogFromCtx(c)
 		telemetry.Incr("bricksllm.admin.get_get_user_ids_handler.requests", nil, 1)
@@ -48,6 +47,10 @@ func getGetUserIdsHandler(m KeyReportingManager, prod bool) gin.HandlerFunc {
 			return
 		}
 
+		if !ensureInNamespace(c, log, prod, path, "key", keyNamespace(km, kid)) {
+			return
+		}
+
 		cids, err := m.GetUserIds(kid)
 		if err != nil {
 			telemetry.Incr("bricksllm.admin.get_get_user_ids_handler.get_user_ids_err", nil, 1)
@@ -68,7 +71,7 @@ func getGetUserIdsHandler(m KeyReportingManager, prod bool) gin.HandlerFunc {
 	}
 }
 
-func getGetCustomIdsHandler(m KeyReportingManager, prod bool) gin.HandlerFunc {
+func getGetCustomIdsHandler(m KeyReportingManager, km KeyManager, prod bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := util.GetLogFromCtx(c)
 		telemetry.Incr("bricksllm.admin.get_get_custom_ids_handler.requests", nil, 1)
@@ -104,6 +107,10 @@ func getGetCustomIdsHandler(m KeyReportingManager, prod bool) gin.HandlerFunc {
 			return
 		}
 
+		if !ensureInNamespace(c, log, prod, path, "key", keyNamespace(km, kid)) {
+			return
+		}
+
 		cids, err := m.GetCustomIds(kid)
 		if err != nil {
 			telemetry.Incr("bricksllm.admin.get_get_user_ids_handler.get_custom_ids_err", nil, 1)
@@ -124,7 +131,7 @@ func getGetCustomIdsHandler(m KeyReportingManager, prod bool) gin.HandlerFunc {
 	}
 }
 
-func getGetEventsHandler(m KeyReportingManager, prod bool) gin.HandlerFunc {
+func getGetEventsHandler(m KeyReportingManager, km KeyManager, prod bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := util.GetLogFromCtx(c)
 		telemetry.Incr("bricksllm.admin.get_get_events_handler.requests", nil, 1)
@@ -224,6 +231,20 @@ func getGetEventsHandler(m KeyReportingManager, prod bool) gin.HandlerFunc {
 			qend = parsedEnd
 		}
 
+		requested := []string{}
+		if kiok {
+			requested = keyIds
+		}
+
+		kids, ok := scopeKeyIds(c, log, prod, path, km, requested, []*event.Event{})
+		if !ok {
+			return
+		}
+
+		if kiok {
+			keyIds = kids
+		}
+
 		evs, err := m.GetEvents(userId, customId, keyIds, qstart, qend)
 		if err != nil {
 			telemetry.Incr("bricksllm.admin.get_get_events_handler.get_events_error", nil, 1)
@@ -239,13 +260,17 @@ func getGetEventsHandler(m KeyReportingManager, prod bool) gin.HandlerFunc {
 			return
 		}
 
+		if _, ok := namespaceOf(c); ok {
+			evs = withinKeys(evs, kids)
+		}
+
 		telemetry.Incr("bricksllm.admin.get_get_events_handler.success", nil, 1)
 
 		c.JSON(http.StatusOK, evs)
 	}
 }
 
-func getGetEventsV2Handler(m KeyReportingManager, prod bool) gin.HandlerFunc {
+func getGetEventsV2Handler(m KeyReportingManager, km KeyManager, prod bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := util.GetLogFromCtx(c)
 		telemetry.Incr("bricksllm.admin.get_get_events_v2_handler.requests", nil, 1)
@@ -268,22 +293,12 @@ func getGetEventsV2Handler(m KeyReportingManager, prod bool) gin.HandlerFunc {
 			return
 		}
 
-		data, err := io.ReadAll(c.Request.Body)
-		if err != nil {
-			logError(log, "error when reading get events request body", prod, err)
-			c.JSON(http.StatusInternalServerError, &ErrorResponse{
-				Type:     "/errors/request-body-read",
-				Title:    "get events request body reader error",
-				Status:   http.StatusInternalServerError,
-				Detail:   err.Error(),
-				Instance: path,
-			})
-			return
-		}
-
 		request := &event.EventRequest{}
-		err = json.Unmarshal(data, request)
-		if err != nil {
+		if err := json.NewDecoder(c.Request.Body).Decode(request); err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
 			logError(log, "error when unmarshalling get events request body", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/json-unmarshal",
@@ -295,6 +310,12 @@ func getGetEventsV2Handler(m KeyReportingManager, prod bool) gin.HandlerFunc {
 			return
 		}
 
+		kids, ok := scopeKeyIds(c, log, prod, path, km, request.KeyIds, &event.EventResponse{})
+		if !ok {
+			return
+		}
+		request.KeyIds = kids
+
 		keys, err := m.GetEventsV2(request)
 		if err != nil {
 			errType := "internal"
@@ -332,3 +353,21 @@ func getGetEventsV2Handler(m KeyReportingManager, prod bool) gin.HandlerFunc {
 		c.JSON(http.StatusOK, keys)
 	}
 }
+
+// withinKeys drops the events of keys other than kids, which scopes events
+// looked up by user or custom id to the namespace of the request.
+func withinKeys(evs []*event.Event, kids []string) []*event.Event {
+	allowed := map[string]bool{}
+	for _, kid := range kids {
+		allowed[kid] = true
+	}
+
+	selected := []*event.Event{}
+	for _, ev := range evs {
+		if allowed[ev.KeyId] {
+			selected = append(selected, ev)
+		}
+	}
+
+	return selected
+}
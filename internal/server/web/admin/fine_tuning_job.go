@@ -0,0 +1,60 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/finetune"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+func getGetFineTuningJobsHandler(m KeyReportingManager, km KeyManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_get_fine_tuning_jobs_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_get_fine_tuning_jobs_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/fine-tuning-jobs"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		kids, ok := scopeKeyIds(c, log, prod, path, km, c.QueryArray("keyIds"), []*finetune.Job{})
+		if !ok {
+			return
+		}
+
+		jobs, err := m.GetFineTuningJobs(kids, c.Query("status"))
+		if err != nil {
+			telemetry.Incr("bricksllm.admin.get_get_fine_tuning_jobs_handler.get_fine_tuning_jobs_error", nil, 1)
+
+			logError(log, "error when getting fine-tuning jobs", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/key-reporting-manager",
+				Title:    "getting fine-tuning jobs error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_get_fine_tuning_jobs_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, jobs)
+	}
+}
@@ -0,0 +1,133 @@
+package admin
+
+import (
+	"sync"
+	"time"
+)
+
+const maxLockoutDuration = 24 * time.Hour
+
+type GuardConfig struct {
+	// RateLimit is the number of requests allowed per IP per minute. Zero disables rate limiting.
+	RateLimit int
+	// LockoutThreshold is the number of failed admin password attempts before an IP is locked out. Zero disables lockouts.
+	LockoutThreshold int
+	// LockoutDuration is the first lockout duration. It doubles with every consecutive lockout.
+	LockoutDuration time.Duration
+}
+
+type ipState struct {
+	windowStart time.Time
+	requests    int
+	failures    int
+	lockouts    int
+	lockedUntil time.Time
+	lastSeen    time.Time
+}
+
+type guard struct {
+	cfg       GuardConfig
+	lock      sync.Mutex
+	states    map[string]*ipState
+	lastPrune time.Time
+}
+
+func newGuard(cfg *GuardConfig) *guard {
+	g := &guard{
+		states: map[string]*ipState{},
+	}
+
+	if cfg != nil {
+		g.cfg = *cfg
+	}
+
+	return g
+}
+
+func (g *guard) get(ip string, now time.Time) *ipState {
+	if now.Sub(g.lastPrune) > time.Minute {
+		for k, s := range g.states {
+			if now.Sub(s.lastSeen) > time.Minute && now.After(s.lockedUntil) && s.lockouts == 0 {
+				delete(g.states, k)
+			}
+		}
+
+		g.lastPrune = now
+	}
+
+	s, ok := g.states[ip]
+	if !ok {
+		s = &ipState{windowStart: now}
+		g.states[ip] = s
+	}
+
+	s.lastSeen = now
+
+	return s
+}
+
+// check returns how long the IP has to wait before sending another request and
+// whether the wait is caused by a lockout.
+func (g *guard) check(ip string, now time.Time) (time.Duration, bool) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	s := g.get(ip, now)
+	if now.Before(s.lockedUntil) {
+		return s.lockedUntil.Sub(now), true
+	}
+
+	if g.cfg.RateLimit <= 0 {
+		return 0, false
+	}
+
+	if now.Sub(s.windowStart) >= time.Minute {
+		s.windowStart = now
+		s.requests = 0
+	}
+
+	s.requests++
+	if s.requests > g.cfg.RateLimit {
+		return s.windowStart.Add(time.Minute).Sub(now), false
+	}
+
+	return 0, false
+}
+
+// fail records a failed admin password attempt and returns the lockout duration
+// if the attempt triggered one.
+func (g *guard) fail(ip string, now time.Time) time.Duration {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.cfg.LockoutThreshold <= 0 {
+		return 0
+	}
+
+	s := g.get(ip, now)
+	s.failures++
+	if s.failures < g.cfg.LockoutThreshold {
+		return 0
+	}
+
+	dur := g.cfg.LockoutDuration << s.lockouts
+	if dur <= 0 || dur > maxLockoutDuration {
+		dur = maxLockoutDuration
+	}
+
+	s.failures = 0
+	s.lockouts++
+	s.lockedUntil = now.Add(dur)
+
+	return dur
+}
+
+func (g *guard) succeed(ip string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if s, ok := g.states[ip]; ok {
+		s.failures = 0
+		s.lockouts = 0
+	}
+}
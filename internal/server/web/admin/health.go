@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/health"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	healthPath   = "/api/health"
+	livenessPath = "/api/health/live"
+)
+
+type Prober interface {
+	Probe(ctx context.Context) *health.Report
+}
+
+// getGetHealthCheckHandler serves the readiness probe. It responds with 503 only
+// when a critical dependency is down so that degraded instances keep serving.
+func getGetHealthCheckHandler(p Prober) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if p == nil {
+			c.JSON(http.StatusOK, &health.Report{Status: health.StatusOk, Dependencies: []*health.Dependency{}})
+			return
+		}
+
+		report := p.Probe(c.Request.Context())
+		telemetry.Incr("bricksllm.admin.get_health_check_handler.requests", []string{
+			"status:" + report.Status,
+		}, 1)
+
+		if report.Status == health.StatusDown {
+			c.JSON(http.StatusServiceUnavailable, report)
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+func getGetLivenessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": health.StatusOk})
+	}
+}
@@ -0,0 +1,287 @@
+package admin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+type IdempotencyCache interface {
+	SetNx(key string, value any, ttl time.Duration) (bool, error)
+	Set(key string, value any, ttl time.Duration) error
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+}
+
+// IdempotencyEncryptor encrypts responses that carry secrets, such as key
+// secrets and claim tokens, before they are kept for replay.
+type IdempotencyEncryptor interface {
+	Encrypt(input string, headers map[string]string) (string, error)
+	Decrypt(input string, headers map[string]string) (string, error)
+	Enabled() bool
+}
+
+type idempotencyRecord struct {
+	RequestHash string `json:"requestHash"`
+	Completed   bool   `json:"completed"`
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+	Encrypted   bool   `json:"encrypted"`
+	// Withheld is set when the response carried secrets that could not be
+	// encrypted, in which case only the fact that the request completed is
+	// kept.
+	Withheld  bool  `json:"withheld"`
+	CreatedAt int64 `json:"createdAt"`
+}
+
+func (r *idempotencyRecord) headers() map[string]string {
+	return map[string]string{"X-UPDATED-AT": strconv.FormatInt(r.CreatedAt, 10)}
+}
+
+type idempotencyResponseWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w idempotencyResponseWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w idempotencyResponseWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// getIdempotencyMiddleware replays the original response for retried requests carrying the same
+// Idempotency-Key header. Reusing a key with a different request is rejected. Endpoints whose
+// responses carry secrets pass enc, and their responses are only kept encrypted with it. Without
+// encryption a retry is refused instead of replayed, so that the request is still not repeated.
+func getIdempotencyMiddleware(ic IdempotencyCache, enc IdempotencyEncryptor, ttl time.Duration, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ik := c.GetHeader(idempotencyKeyHeader)
+		if ic == nil || len(ik) == 0 {
+			c.Next()
+			return
+		}
+
+		log := util.GetLogFromCtx(c)
+		path := c.FullPath()
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
+			logError(log, "error when reading idempotent request body", prod, err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(data))
+
+		h := sha256.New()
+		h.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		h.Write(data)
+		hash := hex.EncodeToString(h.Sum(nil))
+
+		cacheKey := "idempotency:" + ik
+
+		pending, err := json.Marshal(&idempotencyRecord{
+			RequestHash: hash,
+		})
+		if err != nil {
+			logError(log, "error when marshalling idempotency record", prod, err)
+			c.Next()
+			return
+		}
+
+		acquired, err := ic.SetNx(cacheKey, pending, ttl)
+		if err != nil {
+			telemetry.Incr("bricksllm.admin.idempotency_middleware.set_nx_error", nil, 1)
+			logError(log, "error when reserving idempotency key", prod, err)
+			c.Next()
+			return
+		}
+
+		if !acquired {
+			bs, err := ic.Get(cacheKey)
+			if err != nil || len(bs) == 0 {
+				telemetry.Incr("bricksllm.admin.idempotency_middleware.get_error", nil, 1)
+				c.AbortWithStatusJSON(http.StatusConflict, &ErrorResponse{
+					Type:     "/errors/idempotency-key-in-use",
+					Title:    "idempotency key is in use",
+					Status:   http.StatusConflict,
+					Detail:   "a request with the same idempotency key is being processed. retry later.",
+					Instance: path,
+				})
+				return
+			}
+
+			record := &idempotencyRecord{}
+			if err := json.Unmarshal(bs, record); err != nil {
+				logError(log, "error when unmarshalling idempotency record", prod, err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, &ErrorResponse{
+					Type:     "/errors/json-unmarshal",
+					Title:    "json unmarshaller error",
+					Status:   http.StatusInternalServerError,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if record.RequestHash != hash {
+				telemetry.Incr("bricksllm.admin.idempotency_middleware.mismatch", nil, 1)
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, &ErrorResponse{
+					Type:     "/errors/idempotency-key-mismatch",
+					Title:    "idempotency key reused",
+					Status:   http.StatusUnprocessableEntity,
+					Detail:   "idempotency key has already been used for a different request",
+					Instance: path,
+				})
+				return
+			}
+
+			if !record.Completed {
+				c.AbortWithStatusJSON(http.StatusConflict, &ErrorResponse{
+					Type:     "/errors/idempotency-key-in-use",
+					Title:    "idempotency key is in use",
+					Status:   http.StatusConflict,
+					Detail:   "a request with the same idempotency key is being processed. retry later.",
+					Instance: path,
+				})
+				return
+			}
+
+			if record.Withheld {
+				telemetry.Incr("bricksllm.admin.idempotency_middleware.withheld", nil, 1)
+				c.AbortWithStatusJSON(http.StatusConflict, &ErrorResponse{
+					Type:     "/errors/idempotency-response-withheld",
+					Title:    "idempotent response is withheld",
+					Status:   http.StatusConflict,
+					Detail:   "a request with the same idempotency key has completed. its response carries secrets and can not be replayed without secret encryption.",
+					Instance: path,
+				})
+				return
+			}
+
+			body := record.Body
+			if record.Encrypted {
+				if enc == nil {
+					logError(log, "error when decrypting idempotency record", prod, errors.New("no encryptor is configured"))
+					c.AbortWithStatusJSON(http.StatusInternalServerError, &ErrorResponse{
+						Type:     "/errors/idempotency-decryption",
+						Title:    "idempotent response decryption error",
+						Status:   http.StatusInternalServerError,
+						Detail:   "idempotent response can not be decrypted",
+						Instance: path,
+					})
+					return
+				}
+
+				decrypted, err := enc.Decrypt(string(record.Body), record.headers())
+				if err != nil {
+					telemetry.Incr("bricksllm.admin.idempotency_middleware.decrypt_error", nil, 1)
+					logError(log, "error when decrypting idempotency record", prod, err)
+					c.AbortWithStatusJSON(http.StatusInternalServerError, &ErrorResponse{
+						Type:     "/errors/idempotency-decryption",
+						Title:    "idempotent response decryption error",
+						Status:   http.StatusInternalServerError,
+						Detail:   err.Error(),
+						Instance: path,
+					})
+					return
+				}
+
+				body = []byte(decrypted)
+			}
+
+			telemetry.Incr("bricksllm.admin.idempotency_middleware.replayed", nil, 1)
+
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(record.Status, record.ContentType, body)
+			c.Abort()
+			return
+		}
+
+		w := idempotencyResponseWriter{
+			ResponseWriter: c.Writer,
+			body:           &bytes.Buffer{},
+		}
+		c.Writer = w
+
+		c.Next()
+
+		// server errors are not cached so that the request can be retried
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			if err := ic.Delete(cacheKey); err != nil {
+				telemetry.Incr("bricksllm.admin.idempotency_middleware.delete_error", nil, 1)
+			}
+
+			return
+		}
+
+		record := &idempotencyRecord{
+			RequestHash: hash,
+			Completed:   true,
+			Status:      c.Writer.Status(),
+			ContentType: c.Writer.Header().Get("Content-Type"),
+			Body:        w.body.Bytes(),
+			CreatedAt:   time.Now().Unix(),
+		}
+
+		if enc != nil {
+			sealIdempotencyRecord(record, enc)
+		}
+
+		completed, err := json.Marshal(record)
+		if err != nil {
+			logError(log, "error when marshalling idempotency record", prod, err)
+			return
+		}
+
+		if err := ic.Set(cacheKey, completed, ttl); err != nil {
+			telemetry.Incr("bricksllm.admin.idempotency_middleware.set_error", nil, 1)
+			logError(log, "error when storing idempotency record", prod, err)
+		}
+	}
+}
+
+// sealIdempotencyRecord encrypts the body of record, or drops it when it can
+// not be encrypted, so that secrets are never kept in plaintext.
+func sealIdempotencyRecord(record *idempotencyRecord, enc IdempotencyEncryptor) {
+	if enc.Enabled() {
+		encrypted, err := enc.Encrypt(string(record.Body), record.headers())
+		if err == nil {
+			record.Body = []byte(encrypted)
+			record.Encrypted = true
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.idempotency_middleware.encrypt_error", nil, 1)
+	}
+
+	record.Body = nil
+	record.Withheld = true
+}
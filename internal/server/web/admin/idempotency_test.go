@@ -0,0 +1,147 @@
+package admin
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type memoryIdempotencyCache map[string][]byte
+
+func (c memoryIdempotencyCache) SetNx(key string, value any, ttl time.Duration) (bool, error) {
+	if _, ok := c[key]; ok {
+		return false, nil
+	}
+
+	return true, c.Set(key, value, ttl)
+}
+
+func (c memoryIdempotencyCache) Set(key string, value any, ttl time.Duration) error {
+	bs, ok := value.([]byte)
+	if !ok {
+		return errors.New("value is not bytes")
+	}
+
+	c[key] = bs
+	return nil
+}
+
+func (c memoryIdempotencyCache) Get(key string) ([]byte, error) {
+	return c[key], nil
+}
+
+func (c memoryIdempotencyCache) Delete(key string) error {
+	delete(c, key)
+	return nil
+}
+
+// reversingEncryptor "encrypts" by reversing the input.
+type reversingEncryptor struct {
+	enabled bool
+}
+
+func reverse(input string) string {
+	rs := []rune(input)
+	for i, j := 0, len(rs)-1; i < j; i, j = i+1, j-1 {
+		rs[i], rs[j] = rs[j], rs[i]
+	}
+
+	return string(rs)
+}
+
+func (e reversingEncryptor) Encrypt(input string, headers map[string]string) (string, error) {
+	return reverse(input), nil
+}
+
+func (e reversingEncryptor) Decrypt(input string, headers map[string]string) (string, error) {
+	return reverse(input), nil
+}
+
+func (e reversingEncryptor) Enabled() bool {
+	return e.enabled
+}
+
+func newIdempotentRouter(ic IdempotencyCache, enc IdempotencyEncryptor, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		util.SetLogToCtx(c, zap.NewNop())
+	})
+	router.POST("/api/keys", getIdempotencyMiddleware(ic, enc, time.Hour, false), func(c *gin.Context) {
+		*calls++
+		c.JSON(http.StatusOK, gin.H{"key": "sk-plaintext-secret"})
+	})
+
+	return router
+}
+
+func sendIdempotent(router *gin.Engine) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/keys", bytes.NewBufferString(`{"name":"a"}`))
+	req.Header.Set(idempotencyKeyHeader, "retry-1")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w
+}
+
+func cachedBodies(ic memoryIdempotencyCache) string {
+	all := ""
+	for _, bs := range ic {
+		all += string(bs)
+	}
+
+	return all
+}
+
+func TestIdempotencyReplaysEncryptedSecretResponse(t *testing.T) {
+	ic := memoryIdempotencyCache{}
+	calls := 0
+	router := newIdempotentRouter(ic, reversingEncryptor{enabled: true}, &calls)
+
+	first := sendIdempotent(router)
+	if strings.Contains(cachedBodies(ic), "c2stcGxhaW50ZXh0LXNlY3JldA") || strings.Contains(cachedBodies(ic), "sk-plaintext-secret") {
+		t.Fatalf("expected the secret not to be kept in plaintext")
+	}
+
+	second := sendIdempotent(router)
+	if calls != 1 {
+		t.Fatalf("expected the retry not to reach the handler, got %d calls", calls)
+	}
+
+	if second.Header().Get("Idempotent-Replayed") != "true" || second.Body.String() != first.Body.String() {
+		t.Fatalf("expected the original response to be replayed, got %d: %s", second.Code, second.Body.String())
+	}
+}
+
+func TestIdempotencyWithholdsSecretResponseWithoutEncryption(t *testing.T) {
+	ic := memoryIdempotencyCache{}
+	calls := 0
+	router := newIdempotentRouter(ic, reversingEncryptor{}, &calls)
+
+	if w := sendIdempotent(router); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	if strings.Contains(cachedBodies(ic), "c2stcGxhaW50ZXh0LXNlY3JldA") {
+		t.Fatalf("expected the secret not to be kept without encryption")
+	}
+
+	w := sendIdempotent(router)
+	if calls != 1 {
+		t.Fatalf("expected the retry not to reach the handler, got %d calls", calls)
+	}
+
+	if w.Code != http.StatusConflict || strings.Contains(w.Body.String(), "sk-plaintext-secret") {
+		t.Fatalf("expected the retry to be refused without the secret, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,78 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/drain"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+const drainPath = "/api/lifecycle/drain"
+
+type Drainer interface {
+	Start(retryAfter time.Duration) *drain.Status
+	Stop() *drain.Status
+	Status() *drain.Status
+}
+
+// getStartDrainHandler puts the proxy into drain mode. The optional retryAfter
+// query param overrides the Retry-After sent to rejected clients.
+func getStartDrainHandler(d Drainer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		telemetry.Incr("bricksllm.admin.get_start_drain_handler.requests", nil, 1)
+
+		path := drainPath
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		var retryAfter time.Duration
+		if raw := c.Query("retryAfter"); len(raw) != 0 {
+			parsed, err := time.ParseDuration(raw)
+			if err != nil || parsed < time.Second {
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "drain request validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   "retryAfter must be a duration of at least 1s",
+					Instance: path,
+				})
+				return
+			}
+
+			retryAfter = parsed
+		}
+
+		status := d.Start(retryAfter)
+
+		telemetry.Incr("bricksllm.admin.get_start_drain_handler.success", nil, 1)
+		c.JSON(http.StatusAccepted, status)
+	}
+}
+
+func getGetDrainHandler(d Drainer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		telemetry.Incr("bricksllm.admin.get_get_drain_handler.requests", nil, 1)
+
+		c.JSON(http.StatusOK, d.Status())
+	}
+}
+
+// getStopDrainHandler takes the proxy out of drain mode, for instance when a
+// deploy is rolled back.
+func getStopDrainHandler(d Drainer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		telemetry.Incr("bricksllm.admin.get_stop_drain_handler.requests", nil, 1)
+
+		c.JSON(http.StatusOK, d.Stop())
+	}
+}
@@ -0,0 +1,81 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/bricks-cloud/bricksllm/internal/i18n"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+// localizedResponseWriter holds back error responses so that they can be
+// translated once the handler is done. Successful responses, including event
+// streams, are written through untouched.
+type localizedResponseWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w localizedResponseWriter) Write(b []byte) (int, error) {
+	if w.Status() >= 400 {
+		return w.body.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w localizedResponseWriter) WriteString(s string) (int, error) {
+	if w.Status() >= 400 {
+		return w.body.WriteString(s)
+	}
+
+	return w.ResponseWriter.WriteString(s)
+}
+
+// getLocalizationMiddleware translates the title, detail and field errors of
+// error responses into the language negotiated from the Accept-Language
+// header. English requests are served as is.
+func getLocalizationMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Language")
+
+		lang := i18n.Negotiate(c.GetHeader("Accept-Language"))
+		if lang == i18n.English {
+			c.Next()
+			return
+		}
+
+		w := localizedResponseWriter{
+			ResponseWriter: c.Writer,
+			body:           &bytes.Buffer{},
+		}
+		c.Writer = w
+
+		c.Next()
+
+		c.Writer = w.ResponseWriter
+		if w.body.Len() == 0 {
+			return
+		}
+
+		body := w.body.Bytes()
+
+		er := &ErrorResponse{}
+		if err := json.Unmarshal(body, er); err == nil && len(er.Title) != 0 {
+			er.Title = i18n.Translate(lang, er.Title)
+			er.Detail = i18n.Translate(lang, er.Detail)
+			for _, fe := range er.Errors {
+				fe.Reason = i18n.Translate(lang, fe.Reason)
+			}
+
+			if translated, err := json.Marshal(er); err == nil {
+				telemetry.Incr("bricksllm.admin.get_localization_middleware.translated", []string{"lang:" + lang}, 1)
+				c.Header("Content-Language", lang)
+				body = translated
+			}
+		}
+
+		c.Writer.Write(body)
+	}
+}
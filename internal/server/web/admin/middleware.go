@@ -1,21 +1,66 @@
 package admin
 
 import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
 	"github.com/bricks-cloud/bricksllm/internal/util"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-func getAdminLoggerMiddleware(log *zap.Logger, prefix string, prod bool, adminPass string) gin.HandlerFunc {
+func abortWithTooManyRequests(c *gin.Context, wait time.Duration, detail string) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, &ErrorResponse{
+		Type:     "/errors/too-many-requests",
+		Title:    "too many requests",
+		Status:   http.StatusTooManyRequests,
+		Detail:   detail,
+		Instance: c.FullPath(),
+	})
+}
+
+func getAdminLoggerMiddleware(log *zap.Logger, prefix string, prod bool, adminPass string, g *guard) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if len(adminPass) != 0 && c.Request.Header.Get("X-API-KEY") != adminPass {
-			c.Status(200)
-			c.Abort()
+		// forwarded headers can be spoofed so limits are applied to the peer address
+		ip := c.RemoteIP()
+		now := time.Now()
+
+		if wait, locked := g.check(ip, now); wait > 0 {
+			if locked {
+				telemetry.Incr("bricksllm.admin.get_admin_logger_middleware.locked_out", nil, 1)
+				abortWithTooManyRequests(c, wait, fmt.Sprintf("too many failed authentication attempts. retry in %s", wait.Round(time.Second)))
+				return
+			}
+
+			telemetry.Incr("bricksllm.admin.get_admin_logger_middleware.rate_limited", nil, 1)
+			abortWithTooManyRequests(c, wait, fmt.Sprintf("rate limit exceeded. retry in %s", wait.Round(time.Second)))
 			return
 		}
 
+		// claim links are opened by developers who do not hold the admin password and
+		// probes are sent by orchestrators
+		if len(adminPass) != 0 && c.FullPath() != claimKeyPath && c.FullPath() != healthPath && c.FullPath() != livenessPath {
+			if c.Request.Header.Get("X-API-KEY") != adminPass {
+				telemetry.Incr("bricksllm.admin.get_admin_logger_middleware.auth_failure", nil, 1)
+
+				if dur := g.fail(ip, now); dur > 0 {
+					telemetry.Incr("bricksllm.admin.get_admin_logger_middleware.lockout", nil, 1)
+					log.Warn("locked out ip after repeated admin authentication failures", zap.String("ip", ip), zap.Duration("duration", dur))
+				}
+
+				c.Status(200)
+				c.Abort()
+				return
+			}
+
+			g.succeed(ip)
+		}
+
 		cid := util.NewUuid()
 		c.Set(util.STRING_CORRELATION_ID, cid)
 		logWithCid := log.With(zap.String(util.STRING_CORRELATION_ID, cid))
@@ -0,0 +1,377 @@
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/alias"
+	"github.com/bricks-cloud/bricksllm/internal/change"
+	"github.com/bricks-cloud/bricksllm/internal/dryrun"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+type ModelAliasManager interface {
+	CreateModelAlias(a *alias.Alias) (*alias.Alias, error)
+	GetModelAliases() ([]*alias.Alias, error)
+	UpdateModelAlias(id string, ua *alias.UpdateAlias) (*alias.Alias, error)
+	DeleteModelAlias(id string) error
+	PreviewDeleteModelAlias(id string) (*dryrun.Result, error)
+}
+
+func getCreateModelAliasHandler(m ModelAliasManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_create_model_alias_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_create_model_alias_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/model-aliases"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
+			logError(log, "error when reading create model alias request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		a := &alias.Alias{}
+		err = json.Unmarshal(data, a)
+		if err != nil {
+			logError(log, "error when unmarshalling create model alias request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/json-unmarshal",
+				Title:    "json unmarshaller error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		created, err := m.CreateModelAlias(a)
+		if err != nil {
+			errType := "internal"
+
+			defer func() {
+				telemetry.Incr("bricksllm.admin.get_create_model_alias_handler.create_model_alias_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(validationError); ok {
+				errType = "validation"
+
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "model alias validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(conflictError); ok {
+				errType = "conflict"
+
+				c.JSON(http.StatusConflict, &ErrorResponse{
+					Type:     "/errors/conflict",
+					Title:    "model alias overlaps with an existing alias",
+					Status:   http.StatusConflict,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when creating a model alias", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/model-alias-manager",
+				Title:    "model alias creation error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		recordChange(c, change.KindModelAlias, change.ActionCreate, created.Id, "")
+
+		telemetry.Incr("bricksllm.admin.get_create_model_alias_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, created)
+	}
+}
+
+func getGetModelAliasesHandler(m ModelAliasManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_get_model_aliases_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_get_model_aliases_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/model-aliases"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		aliases, err := m.GetModelAliases()
+		if err != nil {
+			telemetry.Incr("bricksllm.admin.get_get_model_aliases_handler.get_model_aliases_error", nil, 1)
+
+			logError(log, "error when getting model aliases", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/model-alias-manager",
+				Title:    "getting model aliases error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_get_model_aliases_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, aliases)
+	}
+}
+
+func getUpdateModelAliasHandler(m ModelAliasManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_update_model_alias_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_update_model_alias_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/model-aliases/:id"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
+			logError(log, "error when reading update model alias request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		ua := &alias.UpdateAlias{}
+		err = json.Unmarshal(data, ua)
+		if err != nil {
+			logError(log, "error when unmarshalling update model alias request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/json-unmarshal",
+				Title:    "json unmarshaller error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		updated, err := m.UpdateModelAlias(c.Param("id"), ua)
+		if err != nil {
+			errType := "internal"
+
+			defer func() {
+				telemetry.Incr("bricksllm.admin.get_update_model_alias_handler.update_model_alias_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/model-alias-not-found",
+					Title:    "model alias not found error",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(validationError); ok {
+				errType = "validation"
+
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "model alias validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(conflictError); ok {
+				errType = "conflict"
+
+				c.JSON(http.StatusConflict, &ErrorResponse{
+					Type:     "/errors/conflict",
+					Title:    "model alias overlaps with an existing alias",
+					Status:   http.StatusConflict,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when updating a model alias", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/model-alias-manager",
+				Title:    "model alias update error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		recordChange(c, change.KindModelAlias, change.ActionUpdate, updated.Id, "")
+
+		telemetry.Incr("bricksllm.admin.get_update_model_alias_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+func getDeleteModelAliasHandler(m ModelAliasManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_delete_model_alias_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_delete_model_alias_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/model-aliases/:id"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		dryRun := c.Query("dryRun") == "true"
+
+		var result *dryrun.Result
+		var err error
+		if dryRun {
+			result, err = m.PreviewDeleteModelAlias(c.Param("id"))
+		} else {
+			err = m.DeleteModelAlias(c.Param("id"))
+		}
+
+		if err != nil {
+			errType := "internal"
+			defer func() {
+				telemetry.Incr("bricksllm.admin.get_delete_model_alias_handler.delete_model_alias_err", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+
+				logError(log, "model alias not found", prod, err)
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/model-alias-not-found",
+					Title:    "model alias not found error",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when deleting a model alias", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/model-alias-manager",
+				Title:    "deleting a model alias error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		if dryRun {
+			telemetry.Incr("bricksllm.admin.get_delete_model_alias_handler.dry_run_success", nil, 1)
+			c.JSON(http.StatusOK, result)
+			return
+		}
+
+		recordChange(c, change.KindModelAlias, change.ActionDelete, c.Param("id"), "")
+
+		telemetry.Incr("bricksllm.admin.get_delete_model_alias_handler.success", nil, 1)
+		c.Status(http.StatusOK)
+	}
+}
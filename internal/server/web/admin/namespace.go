@@ -0,0 +1,184 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	namespaceHeader    = "X-Namespace"
+	maxNamespaceLength = 255
+)
+
+// namespaceOf returns the namespace an admin request is scoped to. Requests
+// without the X-Namespace header are unscoped and operate across namespaces.
+func namespaceOf(c *gin.Context) (string, bool) {
+	ns := c.GetHeader(namespaceHeader)
+	return ns, len(ns) != 0
+}
+
+func getNamespaceMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ns, ok := namespaceOf(c); ok && len(ns) > maxNamespaceLength {
+			telemetry.Incr("bricksllm.admin.get_namespace_middleware.invalid_namespace", nil, 1)
+			c.AbortWithStatusJSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "namespace header validation failed",
+				Status:   http.StatusBadRequest,
+				Detail:   fmt.Sprintf("%s header can not be longer than %d characters", namespaceHeader, maxNamespaceLength),
+				Instance: c.FullPath(),
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// withinNamespace drops the items that belong to another namespace than the
+// one the request is scoped to.
+func withinNamespace[T any](c *gin.Context, items []T, namespace func(T) string) []T {
+	scoped, ok := namespaceOf(c)
+	if !ok {
+		return items
+	}
+
+	selected := []T{}
+	for _, item := range items {
+		if namespace(item) == scoped {
+			selected = append(selected, item)
+		}
+	}
+
+	return selected
+}
+
+// ensureInNamespace responds with 404 and returns false when the object
+// resolved by lookup lives in another namespace. Objects that do not exist
+// are left to the handler so that it reports them the way it always has.
+func ensureInNamespace(c *gin.Context, log *zap.Logger, prod bool, path, kind string, lookup func() (string, error)) bool {
+	scoped, ok := namespaceOf(c)
+	if !ok {
+		return true
+	}
+
+	ns, err := lookup()
+	if err != nil {
+		if _, ok := err.(notFoundError); ok {
+			return true
+		}
+
+		logError(log, "error when resolving namespace of "+kind, prod, err)
+		c.JSON(http.StatusInternalServerError, &ErrorResponse{
+			Type:     "/errors/namespace",
+			Title:    "resolving namespace failed",
+			Status:   http.StatusInternalServerError,
+			Detail:   err.Error(),
+			Instance: path,
+		})
+		return false
+	}
+
+	if ns != scoped {
+		telemetry.Incr("bricksllm.admin.ensure_in_namespace.outside_namespace", []string{"kind:" + kind}, 1)
+		c.JSON(http.StatusNotFound, &ErrorResponse{
+			Type:     "/errors/not-found",
+			Title:    kind + " is not found",
+			Status:   http.StatusNotFound,
+			Detail:   fmt.Sprintf("%s is not found in namespace: %s", kind, scoped),
+			Instance: path,
+		})
+		return false
+	}
+
+	return true
+}
+
+func keyNamespace(m KeyManager, id string) func() (string, error) {
+	return func() (string, error) {
+		keys, err := m.GetKeys(nil, []string{id}, "")
+		if err != nil {
+			return "", err
+		}
+
+		if len(keys) == 0 {
+			return "", internal_errors.NewNotFoundError("key is not found for id: " + id)
+		}
+
+		return keys[0].Namespace, nil
+	}
+}
+
+func settingNamespace(m ProviderSettingsManager, id string) func() (string, error) {
+	return func() (string, error) {
+		setting, err := m.GetSettingViaCache(id)
+		if err != nil {
+			return "", err
+		}
+
+		return setting.Namespace, nil
+	}
+}
+
+func routeNamespace(m RouteManager, id string) func() (string, error) {
+	return func() (string, error) {
+		r, err := m.GetRoute(id)
+		if err != nil {
+			return "", err
+		}
+
+		return r.Namespace, nil
+	}
+}
+
+func policyNamespace(m PoliciesManager, id string) func() (string, error) {
+	return func() (string, error) {
+		p, err := m.GetPolicy(id)
+		if err != nil {
+			return "", err
+		}
+
+		return p.Namespace, nil
+	}
+}
+
+// scopeKeyIds narrows the key ids a reporting request asks for to the keys of
+// the namespace the request is scoped to, or to all of them when it asks for
+// none. When no key is left it responds with empty and returns false, since
+// querying without key ids would report across every namespace.
+func scopeKeyIds(c *gin.Context, log *zap.Logger, prod bool, path string, m KeyManager, requested []string, empty any) ([]string, bool) {
+	scoped, ok := namespaceOf(c)
+	if !ok {
+		return requested, true
+	}
+
+	res, err := m.GetKeysV2(nil, requested, nil, 0, 0, "", "", false, scoped)
+	if err != nil {
+		logError(log, "error when getting keys of namespace", prod, err)
+		c.JSON(http.StatusInternalServerError, &ErrorResponse{
+			Type:     "/errors/namespace",
+			Title:    "resolving namespace failed",
+			Status:   http.StatusInternalServerError,
+			Detail:   err.Error(),
+			Instance: path,
+		})
+		return nil, false
+	}
+
+	kids := []string{}
+	for _, k := range res.Keys {
+		kids = append(kids, k.KeyId)
+	}
+
+	if len(kids) == 0 {
+		c.JSON(http.StatusOK, empty)
+		return nil, false
+	}
+
+	return kids, true
+}
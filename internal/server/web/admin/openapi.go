@@ -0,0 +1,381 @@
+package admin
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/alias"
+	"github.com/bricks-cloud/bricksllm/internal/batch"
+	"github.com/bricks-cloud/bricksllm/internal/catalog"
+	"github.com/bricks-cloud/bricksllm/internal/change"
+	"github.com/bricks-cloud/bricksllm/internal/drain"
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/finetune"
+	"github.com/bricks-cloud/bricksllm/internal/gitops"
+	"github.com/bricks-cloud/bricksllm/internal/health"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/tool"
+	"github.com/bricks-cloud/bricksllm/internal/user"
+	"github.com/bricks-cloud/bricksllm/internal/webhook"
+	"github.com/gin-gonic/gin"
+)
+
+type queryParam struct {
+	name  string
+	array bool
+}
+
+type routeDoc struct {
+	tag      string
+	summary  string
+	query    []queryParam
+	request  any
+	response any
+}
+
+var routeDocs = map[string]routeDoc{
+	"GET /api/health":                                      {tag: "Health Check", summary: "Readiness check with dependency status", response: &health.Report{}},
+	"GET /api/health/live":                                 {tag: "Health Check", summary: "Liveness check"},
+	"GET /api/summary":                                     {tag: "Reporting", summary: "Get dashboard summary", response: &event.Summary{}},
+	"GET /api/openapi.json":                                {tag: "Health Check", summary: "Get the OpenAPI document of the admin API"},
+	"POST /api/lifecycle/drain":                            {tag: "Lifecycle", summary: "Drain the proxy", query: []queryParam{{name: "retryAfter"}}, response: &drain.Status{}},
+	"GET /api/lifecycle/drain":                             {tag: "Lifecycle", summary: "Get drain progress", response: &drain.Status{}},
+	"DELETE /api/lifecycle/drain":                          {tag: "Lifecycle", summary: "Resume the proxy", response: &drain.Status{}},
+	"POST /api/v2/key-management/keys":                     {tag: "Keys", summary: "List keys", request: &key.KeyRequest{}, response: &key.GetKeysResponse{}},
+	"GET /api/key-management/keys":                         {tag: "Keys", summary: "List keys using query params", query: []queryParam{{name: "tag"}, {name: "tags", array: true}, {name: "keyIds", array: true}, {name: "provider"}}, response: []*key.ResponseKey{}},
+	"PUT /api/key-management/keys":                         {tag: "Keys", summary: "Create a key", request: &key.RequestKey{}, response: &key.ResponseKey{}},
+	"PATCH /api/key-management/keys/:id":                   {tag: "Keys", summary: "Update a key", request: &key.UpdateKey{}, response: &key.ResponseKey{}},
+	"DELETE /api/key-management/keys/:id":                  {tag: "Keys", summary: "Delete a key", query: []queryParam{{name: "dryRun"}}},
+	"POST /api/key-management/keys/:id/claim-link":         {tag: "Keys", summary: "Create a key claim link", request: &key.ClaimLinkRequest{}, response: &key.ClaimLink{}},
+	"GET /api/key-management/keys/:id/effective-policy":    {tag: "Keys", summary: "Get the policy applied to requests of a key", query: []queryParam{{name: "routeId"}}, response: &policy.EffectivePolicy{}},
+	"GET /api/key-management/claims/:token":                {tag: "Keys", summary: "Confirm the claim of a key secret"},
+	"POST /api/key-management/claims/:token":               {tag: "Keys", summary: "Claim a key secret", response: &key.ClaimedKey{}},
+	"POST /api/key-management/keys/verify-format":          {tag: "Keys", summary: "Verify the format of a key secret", request: &key.VerifyFormatRequest{}, response: &key.FormatVerification{}},
+	"POST /api/key-management/keys/revoke":                 {tag: "Keys", summary: "Preview or confirm the revocation of keys matched by a filter", request: &key.BulkRevokeRequest{}, response: &key.BulkRevokeResult{}},
+	"GET /api/reporting/keys/:id":                          {tag: "Reporting", summary: "Get key reporting", response: &key.KeyReporting{}},
+	"POST /api/reporting/events":                           {tag: "Reporting", summary: "Get event metrics", request: &event.ReportingRequest{}, response: &event.ReportingResponse{}},
+	"POST /api/reporting/events-by-day":                    {tag: "Reporting", summary: "Get event metrics aggregated by day", request: &event.ReportingRequest{}, response: &event.ReportingResponseV2{}},
+	"GET /api/events":                                      {tag: "Events", summary: "List events", query: []queryParam{{name: "customId"}, {name: "userId"}, {name: "keyIds", array: true}, {name: "start"}, {name: "end"}}, response: []*event.Event{}},
+	"GET /api/batches":                                     {tag: "Events", summary: "List batches with their reconciled usage", query: []queryParam{{name: "keyIds", array: true}, {name: "status"}}, response: []*batch.Batch{}},
+	"GET /api/fine-tuning-jobs":                            {tag: "Events", summary: "List fine-tuning jobs with their status and training cost", query: []queryParam{{name: "keyIds", array: true}, {name: "status"}}, response: []*finetune.Job{}},
+	"POST /api/v2/events":                                  {tag: "Events", summary: "List events with filters", request: &event.EventRequest{}, response: &event.EventResponse{}},
+	"GET /api/reporting/user-ids":                          {tag: "Reporting", summary: "List user ids", query: []queryParam{{name: "keyId"}}, response: []string{}},
+	"POST /api/reporting/top-keys":                         {tag: "Reporting", summary: "Get top keys by spend", request: &event.KeyReportingRequest{}, response: &event.KeyReportingResponse{}},
+	"POST /api/reporting/signing":                          {tag: "Reporting", summary: "Get upstream signing identities and verification failures", request: &event.SigningReportingRequest{}, response: &event.SigningReportingResponse{}},
+	"POST /api/reporting/policy-violations":                {tag: "Reporting", summary: "Get policy violations by policy, rule, key and time bucket", request: &event.PolicyViolationReportingRequest{}, response: &event.PolicyViolationReportingResponse{}},
+	"POST /api/reporting/policy-versions":                  {tag: "Reporting", summary: "Compare the requests each version of a policy applied to", request: &event.PolicyVersionReportingRequest{}, response: &event.PolicyVersionReportingResponse{}},
+	"GET /api/reporting/custom-ids":                        {tag: "Reporting", summary: "List custom ids", query: []queryParam{{name: "keyId"}}, response: []string{}},
+	"PUT /api/provider-settings":                           {tag: "Provider Settings", summary: "Create a provider setting", request: &provider.Setting{}, response: &provider.Setting{}},
+	"GET /api/provider-settings":                           {tag: "Provider Settings", summary: "List provider settings", query: []queryParam{{name: "ids", array: true}, {name: "name"}, {name: "environment"}, {name: "labels", array: true}}, response: []*provider.Setting{}},
+	"PATCH /api/provider-settings/:id":                     {tag: "Provider Settings", summary: "Update a provider setting", request: &provider.UpdateSetting{}, response: &provider.Setting{}},
+	"DELETE /api/provider-settings/:id":                    {tag: "Provider Settings", summary: "Delete a provider setting", query: []queryParam{{name: "dryRun"}, {name: "cascade"}}},
+	"GET /api/provider-settings/:id/deployments":           {tag: "Provider Settings", summary: "List the azure deployment mapping of a provider setting", response: []*provider.Deployment{}},
+	"PUT /api/provider-settings/:id/deployments/:model":    {tag: "Provider Settings", summary: "Map a model to an azure deployment", request: &provider.Deployment{}, response: []*provider.Deployment{}},
+	"DELETE /api/provider-settings/:id/deployments/:model": {tag: "Provider Settings", summary: "Remove the azure deployment mapping of a model", response: []*provider.Deployment{}},
+	"GET /api/provider-settings/:id/health":                {tag: "Provider Settings", summary: "Get the upstream health of a provider setting", response: &catalog.SettingHealth{}},
+	"POST /api/provider-settings/:id/test":                 {tag: "Provider Settings", summary: "Test the connection of a provider setting", response: &catalog.ConnectionTest{}},
+	"GET /api/provider-settings/:id/versions":              {tag: "Provider Settings", summary: "Get the version history of a provider setting", response: []*provider.SettingVersion{}},
+	"POST /api/provider-settings/:id/rollback/:version":    {tag: "Provider Settings", summary: "Roll back a provider setting to one of its versions", response: &provider.Setting{}},
+	"POST /api/custom/providers":                           {tag: "Custom Providers", summary: "Create a custom provider", request: &custom.Provider{}, response: &custom.Provider{}},
+	"GET /api/custom/providers":                            {tag: "Custom Providers", summary: "List custom providers", response: []*custom.Provider{}},
+	"PATCH /api/custom/providers/:id":                      {tag: "Custom Providers", summary: "Update a custom provider", request: &custom.UpdateProvider{}, response: &custom.Provider{}},
+	"DELETE /api/custom/providers/:id":                     {tag: "Custom Providers", summary: "Delete a custom provider", query: []queryParam{{name: "dryRun"}, {name: "cascade"}}},
+	"POST /api/routes":                                     {tag: "Routes", summary: "Create a route", request: &route.Route{}, response: &route.Route{}},
+	"GET /api/routes/:id":                                  {tag: "Routes", summary: "Get a route", response: &route.Route{}},
+	"GET /api/routes/:id/status":                           {tag: "Routes", summary: "Get the circuit breaker state of the steps of a route", response: &route.RouteStatus{}},
+	"GET /api/routes":                                      {tag: "Routes", summary: "List routes", response: []*route.Route{}},
+	"DELETE /api/routes/:id":                               {tag: "Routes", summary: "Delete a route", query: []queryParam{{name: "dryRun"}}},
+	"PATCH /api/routes/:id":                                {tag: "Routes", summary: "Update a route", request: &route.UpdateRoute{}, response: &route.Route{}},
+	"GET /api/routes/:id/versions":                         {tag: "Routes", summary: "Get the version history of a route", response: []*route.RouteVersion{}},
+	"POST /api/routes/:id/rollback/:version":               {tag: "Routes", summary: "Roll back a route to one of its versions", response: &route.Route{}},
+	"POST /api/routes/:id/test":                            {tag: "Routes", summary: "Test a route without calling its upstreams", request: &route.TestRequest{}, response: &route.TestResult{}},
+	"POST /api/policies":                                   {tag: "Policies", summary: "Create a policy", request: &policy.Policy{}, response: &policy.Policy{}},
+	"PATCH /api/policies/:id":                              {tag: "Policies", summary: "Update a policy", request: &policy.UpdatePolicy{}, response: &policy.Policy{}},
+	"GET /api/policies":                                    {tag: "Policies", summary: "List policies by tags", query: []queryParam{{name: "tags", array: true}}, response: []*policy.Policy{}},
+	"DELETE /api/policies/:id":                             {tag: "Policies", summary: "Delete a policy", query: []queryParam{{name: "dryRun"}, {name: "cascade"}}},
+	"POST /api/policies/:id/evaluate":                      {tag: "Policies", summary: "Evaluate a policy against a sample request", request: &policy.EvaluateRequest{}, response: &policy.Evaluation{}},
+	"GET /api/policies/:id/versions":                       {tag: "Policies", summary: "Get the version history of a policy", response: []*policy.PolicyVersion{}},
+	"POST /api/policies/:id/rollback/:version":             {tag: "Policies", summary: "Roll back a policy to one of its versions", response: &policy.Policy{}},
+	"PUT /api/policies/:id/rollout":                        {tag: "Policies", summary: "Roll out the current version of a policy to a percentage of requests", request: &policy.UpdateRollout{}, response: &policy.Policy{}},
+	"POST /api/users":                                      {tag: "Users", summary: "Create a user", request: &user.User{}, response: &user.User{}},
+	"PATCH /api/users/:id":                                 {tag: "Users", summary: "Update a user", request: &user.UpdateUser{}, response: &user.User{}},
+	"PATCH /api/users":                                     {tag: "Users", summary: "Update a user via tags and user id", query: []queryParam{{name: "tags", array: true}, {name: "userId"}, {name: "dryRun"}}, request: &user.UpdateUser{}, response: &user.User{}},
+	"DELETE /api/users/:id":                                {tag: "Users", summary: "Delete a user", query: []queryParam{{name: "dryRun"}, {name: "cascade"}}},
+	"GET /api/users":                                       {tag: "Users", summary: "List users", query: []queryParam{{name: "tags", array: true}, {name: "keyIds", array: true}, {name: "userIds", array: true}, {name: "offset"}, {name: "limit"}}, response: []*user.User{}},
+	"POST /api/onboard":                                    {tag: "Users", summary: "Onboard an org user", request: &user.OnboardRequest{}, response: &user.OnboardResponse{}},
+	"POST /api/compare":                                    {tag: "Routes", summary: "Compare responses of models and routes", request: &route.CompareRequest{}, response: &route.CompareResponse{}},
+	"POST /api/config/apply":                               {tag: "Config", summary: "Reconcile keys, provider settings, routes and policies toward a document", query: []queryParam{{name: "dryRun"}, {name: "prune"}}, request: &gitops.Document{}, response: &gitops.ApplyResult{}},
+	"GET /api/config/export":                               {tag: "Config", summary: "Export keys, provider settings, routes, policies and custom providers as an archive", response: &gitops.Archive{}},
+	"POST /api/config/import":                              {tag: "Config", summary: "Import a config archive", query: []queryParam{{name: "strategy"}, {name: "dryRun"}}, request: &gitops.Archive{}, response: &gitops.ApplyResult{}},
+	"POST /api/webhooks":                                   {tag: "Webhooks", summary: "Register an org usage webhook", request: &webhook.Webhook{}, response: &webhook.Webhook{}},
+	"GET /api/webhooks":                                    {tag: "Webhooks", summary: "List org usage webhooks", query: []queryParam{{name: "org"}}, response: []*webhook.Webhook{}},
+	"DELETE /api/webhooks/:id":                             {tag: "Webhooks", summary: "Delete an org usage webhook", query: []queryParam{{name: "dryRun"}}},
+	"POST /api/tools":                                      {tag: "Tools", summary: "Register a brokered tool", request: &tool.Tool{}, response: &tool.Tool{}},
+	"GET /api/tools":                                       {tag: "Tools", summary: "List brokered tools", response: []*tool.Tool{}},
+	"DELETE /api/tools/:id":                                {tag: "Tools", summary: "Delete a brokered tool", query: []queryParam{{name: "dryRun"}}},
+	"POST /api/model-aliases":                              {tag: "Model aliases", summary: "Create a model alias", request: &alias.Alias{}, response: &alias.Alias{}},
+	"GET /api/model-aliases":                               {tag: "Model aliases", summary: "List model aliases", response: []*alias.Alias{}},
+	"PATCH /api/model-aliases/:id":                         {tag: "Model aliases", summary: "Retarget a model alias or change the keys it applies to", request: &alias.UpdateAlias{}, response: &alias.Alias{}},
+	"DELETE /api/model-aliases/:id":                        {tag: "Model aliases", summary: "Delete a model alias", query: []queryParam{{name: "dryRun"}}},
+	"GET /api/tools/:id/executions":                        {tag: "Tools", summary: "List the most recent executions of a brokered tool", query: []queryParam{{name: "limit"}}, response: []*tool.Execution{}},
+	"GET /api/models":                                      {tag: "Models", summary: "List models with their pricing and capabilities", query: []queryParam{{name: "provider"}, {name: "missing"}}, response: []*catalog.Model{}},
+	"PATCH /api/models/:id":                                {tag: "Models", summary: "Override the pricing and capabilities of a model", request: &catalog.UpdatePricing{}, response: &catalog.Pricing{}},
+	"GET /api/pricing":                                     {tag: "Pricing", summary: "List the pricing table", query: []queryParam{{name: "provider"}}, response: []*catalog.Pricing{}},
+	"PUT /api/pricing":                                     {tag: "Pricing", summary: "Override the pricing of models", request: []*catalog.Pricing{}, response: []*catalog.Pricing{}},
+	"GET /api/changes/stream":                              {tag: "Changes", summary: "Stream admin changes as server-sent events", query: []queryParam{{name: "kinds", array: true}, {name: "after"}}},
+	"GET /api/config/changes":                              {tag: "Changes", summary: "Long poll for admin changes", query: []queryParam{{name: "since"}, {name: "timeout"}, {name: "kinds", array: true}}, response: &change.Batch{}},
+}
+
+type schemaRegistry struct {
+	schemas map[string]map[string]any
+	names   map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		schemas: map[string]map[string]any{},
+		names:   map[reflect.Type]string{},
+	}
+}
+
+var bytesType = reflect.TypeOf([]byte{})
+
+func (sr *schemaRegistry) schemaOf(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if t == reflect.TypeOf(time.Duration(0)) {
+			return map[string]any{"type": "integer", "description": "duration in nanoseconds"}
+		}
+
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t == bytesType {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+
+		return map[string]any{"type": "array", "items": sr.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": sr.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			return map[string]any{"type": "string", "format": "date-time"}
+		}
+
+		if len(t.Name()) == 0 {
+			properties := map[string]any{}
+			sr.addProperties(t, properties)
+
+			return map[string]any{"type": "object", "properties": properties}
+		}
+
+		return map[string]any{"$ref": "#/components/schemas/" + sr.register(t)}
+	}
+
+	return map[string]any{}
+}
+
+func (sr *schemaRegistry) register(t reflect.Type) string {
+	if name, ok := sr.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, taken := sr.schemas[name]; taken {
+		parts := strings.Split(t.PkgPath(), "/")
+		name = strings.ToUpper(parts[len(parts)-1][:1]) + parts[len(parts)-1][1:] + name
+	}
+
+	sr.names[t] = name
+	sr.schemas[name] = map[string]any{}
+
+	properties := map[string]any{}
+	sr.addProperties(t, properties)
+
+	sr.schemas[name] = map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+
+	return name
+}
+
+func (sr *schemaRegistry) addProperties(t reflect.Type, properties map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.Anonymous {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				sr.addProperties(ft, properties)
+				continue
+			}
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+
+			if parsed := strings.Split(tag, ",")[0]; len(parsed) != 0 {
+				name = parsed
+			}
+		}
+
+		properties[name] = sr.schemaOf(f.Type)
+	}
+}
+
+func toOpenApiPath(path string) (string, []string) {
+	params := []string{}
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			params = append(params, part[1:])
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+
+	return strings.Join(parts, "/"), params
+}
+
+func buildOpenApiDocument(routes gin.RoutesInfo) map[string]any {
+	sr := newSchemaRegistry()
+	paths := map[string]map[string]any{}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path == routes[j].Path {
+			return routes[i].Method < routes[j].Method
+		}
+
+		return routes[i].Path < routes[j].Path
+	})
+
+	for _, r := range routes {
+		if !strings.HasPrefix(r.Path, "/api/") {
+			continue
+		}
+
+		doc := routeDocs[r.Method+" "+r.Path]
+		path, pathParams := toOpenApiPath(r.Path)
+
+		parameters := []map[string]any{}
+		for _, p := range pathParams {
+			parameters = append(parameters, map[string]any{
+				"in":       "path",
+				"name":     p,
+				"required": true,
+				"schema":   map[string]any{"type": "string"},
+			})
+		}
+
+		for _, q := range doc.query {
+			schema := map[string]any{"type": "string"}
+			if q.array {
+				schema = map[string]any{"type": "array", "items": schema}
+			}
+
+			parameters = append(parameters, map[string]any{
+				"in":     "query",
+				"name":   q.name,
+				"schema": schema,
+			})
+		}
+
+		operation := map[string]any{
+			"operationId": strings.ToLower(r.Method) + strings.NewReplacer("/", "_", ":", "", "-", "_", ".", "_").Replace(r.Path),
+			"responses": map[string]any{
+				"200": map[string]any{
+					"description": "Successful response.",
+				},
+			},
+		}
+
+		if len(doc.summary) != 0 {
+			operation["summary"] = doc.summary
+		}
+
+		if len(doc.tag) != 0 {
+			operation["tags"] = []string{doc.tag}
+		}
+
+		if len(parameters) != 0 {
+			operation["parameters"] = parameters
+		}
+
+		if doc.request != nil {
+			operation["requestBody"] = map[string]any{
+				"content": map[string]any{
+					"application/json": map[string]any{
+						"schema": sr.schemaOf(reflect.TypeOf(doc.request)),
+					},
+				},
+			}
+		}
+
+		if doc.response != nil {
+			operation["responses"] = map[string]any{
+				"200": map[string]any{
+					"description": "Successful response.",
+					"content": map[string]any{
+						"application/json": map[string]any{
+							"schema": sr.schemaOf(reflect.TypeOf(doc.response)),
+						},
+					},
+				},
+			}
+		}
+
+		if _, ok := paths[path]; !ok {
+			paths[path] = map[string]any{}
+		}
+
+		paths[path][strings.ToLower(r.Method)] = operation
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "BricksLLM Admin API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": sr.schemas,
+		},
+	}
+}
+
+func getGetOpenApiHandler(router *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		telemetry.Incr("bricksllm.admin.get_get_openapi_handler.requests", nil, 1)
+
+		c.JSON(http.StatusOK, buildOpenApiDocument(router.Routes()))
+	}
+}
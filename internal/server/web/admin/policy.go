@@ -1,11 +1,12 @@
 package admin
 
 import (
-	"encoding/json"
 	"io"
 	"net/http"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/change"
+	"github.com/bricks-cloud/bricksllm/internal/dryrun"
 	"github.com/bricks-cloud/bricksllm/internal/policy"
 	"github.com/bricks-cloud/bricksllm/internal/telemetry"
 	"github.com/bricks-cloud/bricksllm/internal/util"
@@ -37,6 +38,10 @@ func getCreatePolicyHandler(pm PoliciesManager, prod bool) gin.HandlerFunc {
 
 		data, err := io.ReadAll(c.Request.Body)
 		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
 			logError(log, "error when reading policy creation request body", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/request-body-read",
@@ -49,23 +54,39 @@ func getCreatePolicyHandler(pm PoliciesManager, prod bool) gin.HandlerFunc {
 		}
 
 		p := &policy.Policy{}
-		err = json.Unmarshal(data, p)
+		err = bindJSON(data, p)
 		if err != nil {
-			logError(log, "error when unmarshalling policy creation request body", prod, err)
-			c.JSON(http.StatusInternalServerError, &ErrorResponse{
-				Type:     "/errors/json-unmarshal",
-				Title:    "json unmarshaller error",
-				Status:   http.StatusInternalServerError,
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "request body validation failed",
+				Status:   http.StatusBadRequest,
 				Detail:   err.Error(),
 				Instance: path,
+				Errors:   fieldErrorsOf(err),
 			})
 			return
 		}
 
+		if ns, ok := namespaceOf(c); ok {
+			p.Namespace = ns
+		}
+
 		created, err := pm.CreatePolicy(p)
 		if err != nil {
 			telemetry.Incr("bricksllm.admin.get_get_create_policy_handler.creat_policy_error", nil, 1)
 
+			if _, ok := err.(validationError); ok {
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "policy validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+					Errors:   fieldErrorsOf(err),
+				})
+				return
+			}
+
 			logError(log, "error when creating a policy", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/policies/creation",
@@ -77,6 +98,7 @@ func getCreatePolicyHandler(pm PoliciesManager, prod bool) gin.HandlerFunc {
 			return
 		}
 
+		recordChange(c, change.KindPolicy, change.ActionCreate, created.Id, created.Namespace)
 		telemetry.Incr("bricksllm.admin.get_get_create_policy_handler.success", nil, 1)
 
 		c.JSON(http.StatusOK, created)
@@ -119,8 +141,16 @@ func getUpdatePolicyHandler(pm PoliciesManager, prod bool) gin.HandlerFunc {
 			return
 		}
 
+		if !ensureInNamespace(c, log, prod, path, "policy", policyNamespace(pm, id)) {
+			return
+		}
+
 		data, err := io.ReadAll(c.Request.Body)
 		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
 			logError(log, "error when reading policy creation request body", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/request-body-read",
@@ -133,15 +163,15 @@ func getUpdatePolicyHandler(pm PoliciesManager, prod bool) gin.HandlerFunc {
 		}
 
 		p := &policy.UpdatePolicy{}
-		err = json.Unmarshal(data, p)
+		err = bindJSON(data, p)
 		if err != nil {
-			logError(log, "error when unmarshalling policy creation request body", prod, err)
-			c.JSON(http.StatusInternalServerError, &ErrorResponse{
-				Type:     "/errors/json-unmarshal",
-				Title:    "json unmarshaller error",
-				Status:   http.StatusInternalServerError,
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "request body validation failed",
+				Status:   http.StatusBadRequest,
 				Detail:   err.Error(),
 				Instance: path,
+				Errors:   fieldErrorsOf(err),
 			})
 			return
 		}
@@ -150,6 +180,18 @@ func getUpdatePolicyHandler(pm PoliciesManager, prod bool) gin.HandlerFunc {
 		if err != nil {
 			telemetry.Incr("bricksllm.admin.get_update_policy_handler.update_policy_error", nil, 1)
 
+			if _, ok := err.(validationError); ok {
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "policy validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+					Errors:   fieldErrorsOf(err),
+				})
+				return
+			}
+
 			logError(log, "error when updating a policy by id", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/policie/updates",
@@ -161,6 +203,7 @@ func getUpdatePolicyHandler(pm PoliciesManager, prod bool) gin.HandlerFunc {
 			return
 		}
 
+		recordChange(c, change.KindPolicy, change.ActionUpdate, updated.Id, updated.Namespace)
 		telemetry.Incr("bricksllm.admin.get_update_policy_handler.success", nil, 1)
 
 		c.JSON(http.StatusOK, updated)
@@ -226,6 +269,116 @@ func getGetPoliciesByTagsHandler(pm PoliciesManager, prod bool) gin.HandlerFunc
 
 		telemetry.Incr("bricksllm.admin.get_get_policies_by_tags_handler.success", nil, 1)
 
-		c.JSON(http.StatusOK, policies)
+		c.JSON(http.StatusOK, withinNamespace(c, policies, func(p *policy.Policy) string { return p.Namespace }))
+	}
+}
+
+func getDeletePolicyHandler(m PoliciesManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_delete_policy_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_delete_policy_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/policies/:id"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		id := c.Param("id")
+		if len(id) == 0 {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/missing-param-id",
+				Title:    "id is empty",
+				Status:   http.StatusBadRequest,
+				Detail:   "id url param is missing from the request url. it is required for deleting a policy.",
+				Instance: path,
+			})
+
+			return
+		}
+
+		if !ensureInNamespace(c, log, prod, path, "policy", policyNamespace(m, id)) {
+			return
+		}
+
+		dryRun := c.Query("dryRun") == "true"
+		cascade := c.Query("cascade") == "true"
+
+		var result *dryrun.Result
+		var err error
+		if dryRun {
+			result, err = m.PreviewDeletePolicy(id, cascade)
+		} else {
+			ns := namespaceForChange(c, policyNamespace(m, id))
+			recordChange(c, change.KindPolicy, change.ActionDelete, id, ns)
+
+			var dependents []*dryrun.Dependent
+			dependents, err = m.DeletePolicy(id, cascade)
+			recordCascade(c, dependents, ns)
+		}
+
+		if err != nil {
+			errType := "internal"
+			defer func() {
+				telemetry.Incr("bricksllm.admin.get_delete_policy_handler.delete_err", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    "policy is not found",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(conflictError); ok {
+				errType = "conflict"
+				c.JSON(http.StatusConflict, &ErrorResponse{
+					Type:     "/errors/conflict",
+					Title:    "policy is still referenced",
+					Status:   http.StatusConflict,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when deleting a policy", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/policies/deletion",
+				Title:    "deleting a policy error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		if dryRun {
+			telemetry.Incr("bricksllm.admin.get_delete_policy_handler.dry_run_success", nil, 1)
+			c.JSON(http.StatusOK, result)
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_delete_policy_handler.success", nil, 1)
+		c.Status(http.StatusOK)
 	}
 }
@@ -0,0 +1,226 @@
+package admin
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/change"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func writePolicyVersionError(c *gin.Context, log *zap.Logger, prod bool, path, metric string, err error) {
+	errType := "internal"
+	defer func() {
+		telemetry.Incr(metric, []string{
+			"error_type:" + errType,
+		}, 1)
+	}()
+
+	if _, ok := err.(notFoundError); ok {
+		errType = "not_found"
+		c.JSON(http.StatusNotFound, &ErrorResponse{
+			Type:     "/errors/not-found",
+			Title:    "policy is not found",
+			Status:   http.StatusNotFound,
+			Detail:   err.Error(),
+			Instance: path,
+		})
+		return
+	}
+
+	if _, ok := err.(validationError); ok {
+		errType = "validation"
+		c.JSON(http.StatusBadRequest, &ErrorResponse{
+			Type:     "/errors/validation",
+			Title:    "policy version validation failed",
+			Status:   http.StatusBadRequest,
+			Detail:   err.Error(),
+			Instance: path,
+			Errors:   fieldErrorsOf(err),
+		})
+		return
+	}
+
+	logError(log, "error when changing the version of a policy", prod, err)
+	c.JSON(http.StatusInternalServerError, &ErrorResponse{
+		Type:     "/errors/policy-manager",
+		Title:    "policy version error",
+		Status:   http.StatusInternalServerError,
+		Detail:   err.Error(),
+		Instance: path,
+	})
+}
+
+func getGetPolicyVersionsHandler(pm PoliciesManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_get_policy_versions_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_get_policy_versions_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/policies/:id/versions"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		id := c.Param("id")
+		if !ensureInNamespace(c, log, prod, path, "policy", policyNamespace(pm, id)) {
+			return
+		}
+
+		versions, err := pm.GetPolicyVersions(id)
+		if err != nil {
+			writePolicyVersionError(c, log, prod, path, "bricksllm.admin.get_get_policy_versions_handler.get_policy_versions_error", err)
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_get_policy_versions_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, versions)
+	}
+}
+
+// getRollbackPolicyHandler restores the config a policy had at one of its
+// versions.
+func getRollbackPolicyHandler(pm PoliciesManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_rollback_policy_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_rollback_policy_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/policies/:id/rollback/:version"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		version, err := strconv.Atoi(c.Param("version"))
+		if err != nil || version < 1 {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "policy rollback request validation failed",
+				Status:   http.StatusBadRequest,
+				Detail:   "version must be a positive integer",
+				Instance: path,
+			})
+			return
+		}
+
+		id := c.Param("id")
+		if !ensureInNamespace(c, log, prod, path, "policy", policyNamespace(pm, id)) {
+			return
+		}
+
+		p, err := pm.RollbackPolicy(id, version)
+		if err != nil {
+			writePolicyVersionError(c, log, prod, path, "bricksllm.admin.get_rollback_policy_handler.rollback_policy_error", err)
+			return
+		}
+
+		recordChange(c, change.KindPolicy, change.ActionUpdate, p.Id, p.Namespace)
+		telemetry.Incr("bricksllm.admin.get_rollback_policy_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, p)
+	}
+}
+
+// getUpdatePolicyRolloutHandler starts, changes or ends the rollout of the
+// current version of a policy.
+func getUpdatePolicyRolloutHandler(pm PoliciesManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_update_policy_rollout_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_update_policy_rollout_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/policies/:id/rollout"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
+			logError(log, "error when reading update policy rollout request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		ur := &policy.UpdateRollout{}
+		if err := bindJSON(data, ur); err != nil {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "request body validation failed",
+				Status:   http.StatusBadRequest,
+				Detail:   err.Error(),
+				Instance: path,
+				Errors:   fieldErrorsOf(err),
+			})
+			return
+		}
+
+		id := c.Param("id")
+		if !ensureInNamespace(c, log, prod, path, "policy", policyNamespace(pm, id)) {
+			return
+		}
+
+		p, err := pm.SetPolicyRollout(id, ur)
+		if err != nil {
+			writePolicyVersionError(c, log, prod, path, "bricksllm.admin.get_update_policy_rollout_handler.set_policy_rollout_error", err)
+			return
+		}
+
+		recordChange(c, change.KindPolicy, change.ActionUpdate, p.Id, p.Namespace)
+		telemetry.Incr("bricksllm.admin.get_update_policy_rollout_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, p)
+	}
+}
@@ -0,0 +1,160 @@
+package admin
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/catalog"
+	"github.com/bricks-cloud/bricksllm/internal/change"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+func getGetPricingHandler(m CatalogManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_get_pricing_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_get_pricing_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/pricing"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		pricing, err := m.GetPricing()
+		if err != nil {
+			telemetry.Incr("bricksllm.admin.get_get_pricing_handler.get_pricing_error", nil, 1)
+
+			logError(log, "error when getting pricing", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/catalog-manager",
+				Title:    "getting pricing error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		if provider := c.Query("provider"); len(provider) != 0 {
+			filtered := []*catalog.Pricing{}
+			for _, p := range pricing {
+				if p.Provider == provider {
+					filtered = append(filtered, p)
+				}
+			}
+
+			pricing = filtered
+		}
+
+		telemetry.Incr("bricksllm.admin.get_get_pricing_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, pricing)
+	}
+}
+
+// getPutPricingHandler overrides the pricing of models, so that price changes
+// and new models are accounted for without waiting for a release.
+func getPutPricingHandler(m CatalogManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_put_pricing_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_put_pricing_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/pricing"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
+			logError(log, "error when reading pricing request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		ps := []*catalog.Pricing{}
+		if err := bindJSON(data, &ps); err != nil {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "request body validation failed",
+				Status:   http.StatusBadRequest,
+				Detail:   err.Error(),
+				Instance: path,
+				Errors:   fieldErrorsOf(err),
+			})
+			return
+		}
+
+		stored, err := m.PutPricing(ps)
+		if err != nil {
+			telemetry.Incr("bricksllm.admin.get_put_pricing_handler.put_pricing_error", nil, 1)
+
+			if _, ok := err.(validationError); ok {
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "pricing validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+					Errors:   fieldErrorsOf(err),
+				})
+				return
+			}
+
+			logError(log, "error when putting pricing", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/catalog-manager",
+				Title:    "putting pricing error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		for _, p := range stored {
+			recordChange(c, change.KindPricing, change.ActionUpdate, p.Id, "")
+		}
+
+		telemetry.Incr("bricksllm.admin.get_put_pricing_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, stored)
+	}
+}
@@ -49,7 +49,7 @@ func validateTopKeyReportingRequest(r *event.KeyReportingRequest) bool {
 	return true
 }
 
-func getGetEventMetricsHandler(m KeyReportingManager, prod bool) gin.HandlerFunc {
+func getGetEventMetricsHandler(m KeyReportingManager, km KeyManager, prod bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := util.GetLogFromCtx(c)
 		telemetry.Incr("bricksllm.admin.get_get_event_metrics.requests", nil, 1)
@@ -73,22 +73,12 @@ func getGetEventMetricsHandler(m KeyReportingManager, prod bool) gin.HandlerFunc
 			return
 		}
 
-		data, err := io.ReadAll(c.Request.Body)
-		if err != nil {
-			logError(log, "error when reading event reporting request body", prod, err)
-			c.JSON(http.StatusInternalServerError, &ErrorResponse{
-				Type:     "/errors/request-body-read",
-				Title:    "request body reader error",
-				Status:   http.StatusInternalServerError,
-				Detail:   err.Error(),
-				Instance: path,
-			})
-			return
-		}
-
 		request := &event.ReportingRequest{}
-		err = json.Unmarshal(data, request)
-		if err != nil {
+		if err := json.NewDecoder(c.Request.Body).Decode(request); err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
 			logError(log, "error when unmarshalling event reporting request body", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/json-unmarshal",
@@ -103,7 +93,7 @@ func getGetEventMetricsHandler(m KeyReportingManager, prod bool) gin.HandlerFunc
 		if !validateEventReportingRequest(request) {
 			telemetry.Incr("bricksllm.admin.get_get_event_metrics.request_not_valid", nil, 1)
 
-			err = fmt.Errorf("event reporting request %+v is not valid", request)
+			err := fmt.Errorf("event reporting request %+v is not valid", request)
 			logError(log, "invalid reporting request", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/invalid-reporting-request",
@@ -115,10 +105,27 @@ func getGetEventMetricsHandler(m KeyReportingManager, prod bool) gin.HandlerFunc
 			return
 		}
 
+		kids, ok := scopeKeyIds(c, log, prod, path, km, request.KeyIds, &event.ReportingResponse{})
+		if !ok {
+			return
+		}
+		request.KeyIds = kids
+
 		reportingResponse, err := m.GetEventReporting(request)
 		if err != nil {
 			telemetry.Incr("bricksllm.admin.get_get_event_metrics.get_event_reporting_error", nil, 1)
 
+			if _, ok := err.(validationError); ok {
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "event reporting request validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
 			logError(log, "error when getting event reporting", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/event-reporting-manager",
@@ -136,7 +143,7 @@ func getGetEventMetricsHandler(m KeyReportingManager, prod bool) gin.HandlerFunc
 	}
 }
 
-func getGetEventMetricsByDayHandler(m KeyReportingManager, prod bool) gin.HandlerFunc {
+func getGetEventMetricsByDayHandler(m KeyReportingManager, km KeyManager, prod bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := util.GetLogFromCtx(c)
 		telemetry.Incr("bricksllm.admin.get_get_event_metrics_by_day.requests", nil, 1)
@@ -160,22 +167,12 @@ func getGetEventMetricsByDayHandler(m KeyReportingManager, prod bool) gin.Handle
 			return
 		}
 
-		data, err := io.ReadAll(c.Request.Body)
-		if err != nil {
-			logError(log, "error when reading event by day reporting request body", prod, err)
-			c.JSON(http.StatusInternalServerError, &ErrorResponse{
-				Type:     "/errors/request-body-read",
-				Title:    "request body reader error",
-				Status:   http.StatusInternalServerError,
-				Detail:   err.Error(),
-				Instance: path,
-			})
-			return
-		}
-
 		request := &event.ReportingRequest{}
-		err = json.Unmarshal(data, request)
-		if err != nil {
+		if err := json.NewDecoder(c.Request.Body).Decode(request); err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
 			logError(log, "error when unmarshalling event by day reporting request body", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/json-unmarshal",
@@ -190,7 +187,7 @@ func getGetEventMetricsByDayHandler(m KeyReportingManager, prod bool) gin.Handle
 		if !validateEventReportingByDayRequest(request) {
 			telemetry.Incr("bricksllm.admin.get_get_event_metrics_by_day.request_not_valid", nil, 1)
 
-			err = fmt.Errorf("event reporting request %+v is not valid", request)
+			err := fmt.Errorf("event reporting request %+v is not valid", request)
 			logError(log, "invalid reporting request", prod, err)
 			c.JSON(http.StatusBadRequest, &ErrorResponse{
 				Type:     "/errors/invalid-reporting-request",
@@ -202,10 +199,27 @@ func getGetEventMetricsByDayHandler(m KeyReportingManager, prod bool) gin.Handle
 			return
 		}
 
+		kids, ok := scopeKeyIds(c, log, prod, path, km, request.KeyIds, &event.ReportingResponseV2{})
+		if !ok {
+			return
+		}
+		request.KeyIds = kids
+
 		reportingResponse, err := m.GetAggregatedEventByDayReporting(request)
 		if err != nil {
 			telemetry.Incr("bricksllm.admin.get_get_event_metrics_by_day.get_aggregated_event_by_day_reporting", nil, 1)
 
+			if _, ok := err.(validationError); ok {
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "event reporting request validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
 			logError(log, "error when getting event by day reporting", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/event-reporting-manager",
@@ -223,7 +237,7 @@ func getGetEventMetricsByDayHandler(m KeyReportingManager, prod bool) gin.Handle
 	}
 }
 
-func getGetTopKeysMetricsHandler(m KeyReportingManager, prod bool) gin.HandlerFunc {
+func getGetTopKeysMetricsHandler(m KeyReportingManager, km KeyManager, prod bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := util.GetLogFromCtx(c)
 		telemetry.Incr("bricksllm.admin.get_get_top_keys_metrics_handler.requests", nil, 1)
@@ -247,22 +261,12 @@ func getGetTopKeysMetricsHandler(m KeyReportingManager, prod bool) gin.HandlerFu
 			return
 		}
 
-		data, err := io.ReadAll(c.Request.Body)
-		if err != nil {
-			logError(log, "error when reading top key reporting request body", prod, err)
-			c.JSON(http.StatusInternalServerError, &ErrorResponse{
-				Type:     "/errors/request-body-read",
-				Title:    "request body reader error",
-				Status:   http.StatusInternalServerError,
-				Detail:   err.Error(),
-				Instance: path,
-			})
-			return
-		}
-
 		request := &event.KeyReportingRequest{}
-		err = json.Unmarshal(data, request)
-		if err != nil {
+		if err := json.NewDecoder(c.Request.Body).Decode(request); err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
 			logError(log, "error when unmarshalling top key reporting request body", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/json-unmarshal",
@@ -276,7 +280,7 @@ func getGetTopKeysMetricsHandler(m KeyReportingManager, prod bool) gin.HandlerFu
 
 		if !validateTopKeyReportingRequest(request) {
 			telemetry.Incr("bricksllm.admin.get_get_top_keys_metrics_handler.request_not_valid", nil, 1)
-			err = fmt.Errorf("top key reporting request %+v is not valid", request)
+			err := fmt.Errorf("top key reporting request %+v is not valid", request)
 			logError(log, "invalid reporting request", prod, err)
 			c.JSON(http.StatusBadRequest, &ErrorResponse{
 				Type:     "/errors/invalid-reporting-request",
@@ -288,10 +292,27 @@ func getGetTopKeysMetricsHandler(m KeyReportingManager, prod bool) gin.HandlerFu
 			return
 		}
 
+		kids, ok := scopeKeyIds(c, log, prod, path, km, request.KeyIds, &event.KeyReportingResponse{})
+		if !ok {
+			return
+		}
+		request.KeyIds = kids
+
 		reportingResponse, err := m.GetTopKeyReporting(request)
 		if err != nil {
 			telemetry.Incr("bricksllm.admin.get_get_top_keys_metrics_handler.get_top_key_reporting", nil, 1)
 
+			if _, ok := err.(validationError); ok {
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "top key reporting request validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
 			logError(log, "error when getting top key reporting", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/event-reporting-manager",
@@ -308,3 +329,336 @@ func getGetTopKeysMetricsHandler(m KeyReportingManager, prod bool) gin.HandlerFu
 		c.JSON(http.StatusOK, reportingResponse)
 	}
 }
+
+func getGetSummaryHandler(m KeyReportingManager, km KeyManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_get_summary_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_get_summary_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/summary"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		kids, ok := scopeKeyIds(c, log, prod, path, km, nil, &event.Summary{
+			Keys:         &event.KeyCounts{},
+			TopKeys:      []*event.KeyDataPoint{},
+			Providers:    []*event.ProviderHealth{},
+			RecentErrors: []*event.Event{},
+		})
+		if !ok {
+			return
+		}
+
+		summary, err := m.GetSummary(kids)
+		if err != nil {
+			telemetry.Incr("bricksllm.admin.get_get_summary_handler.get_summary_error", nil, 1)
+
+			logError(log, "error when getting summary", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/key-reporting-manager",
+				Title:    "getting summary error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_get_summary_handler.success", nil, 1)
+		c.JSON(http.StatusOK, summary)
+	}
+}
+
+func getGetSigningReportingHandler(m KeyReportingManager, km KeyManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_get_signing_reporting_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_get_signing_reporting_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/reporting/signing"
+
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
+			logError(log, "error when reading signing reporting request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		request := &event.SigningReportingRequest{}
+		if err := bindJSON(data, request); err != nil {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "signing reporting request validation failed",
+				Status:   http.StatusBadRequest,
+				Detail:   err.Error(),
+				Instance: path,
+				Errors:   fieldErrorsOf(err),
+			})
+			return
+		}
+
+		kids, ok := scopeKeyIds(c, log, prod, path, km, request.KeyIds, &event.SigningReportingResponse{})
+		if !ok {
+			return
+		}
+		request.KeyIds = kids
+
+		reportingResponse, err := m.GetSigningReporting(request)
+		if err != nil {
+			if _, ok := err.(validationError); ok {
+				telemetry.Incr("bricksllm.admin.get_get_signing_reporting_handler.request_not_valid", nil, 1)
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "signing reporting request validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+					Errors:   fieldErrorsOf(err),
+				})
+				return
+			}
+
+			telemetry.Incr("bricksllm.admin.get_get_signing_reporting_handler.get_signing_reporting_error", nil, 1)
+
+			logError(log, "error when getting signing reporting", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/event-reporting-manager",
+				Title:    "signing reporting error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_get_signing_reporting_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, reportingResponse)
+	}
+}
+
+func getGetPolicyViolationReportingHandler(m KeyReportingManager, km KeyManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_get_policy_violation_reporting_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_get_policy_violation_reporting_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/reporting/policy-violations"
+
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
+			logError(log, "error when reading policy violation reporting request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		request := &event.PolicyViolationReportingRequest{}
+		if err := bindJSON(data, request); err != nil {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "policy violation reporting request validation failed",
+				Status:   http.StatusBadRequest,
+				Detail:   err.Error(),
+				Instance: path,
+				Errors:   fieldErrorsOf(err),
+			})
+			return
+		}
+
+		kids, ok := scopeKeyIds(c, log, prod, path, km, request.KeyIds, &event.PolicyViolationReportingResponse{})
+		if !ok {
+			return
+		}
+		request.KeyIds = kids
+
+		reportingResponse, err := m.GetPolicyViolationReporting(request)
+		if err != nil {
+			if _, ok := err.(validationError); ok {
+				telemetry.Incr("bricksllm.admin.get_get_policy_violation_reporting_handler.request_not_valid", nil, 1)
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "policy violation reporting request validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+					Errors:   fieldErrorsOf(err),
+				})
+				return
+			}
+
+			telemetry.Incr("bricksllm.admin.get_get_policy_violation_reporting_handler.get_policy_violation_reporting_error", nil, 1)
+
+			logError(log, "error when getting policy violation reporting", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/event-reporting-manager",
+				Title:    "policy violation reporting error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_get_policy_violation_reporting_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, reportingResponse)
+	}
+}
+
+func getGetPolicyVersionReportingHandler(m KeyReportingManager, pm PoliciesManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_get_policy_version_reporting_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_get_policy_version_reporting_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/reporting/policy-versions"
+
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
+			logError(log, "error when reading policy version reporting request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		request := &event.PolicyVersionReportingRequest{}
+		if err := bindJSON(data, request); err != nil {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "policy version reporting request validation failed",
+				Status:   http.StatusBadRequest,
+				Detail:   err.Error(),
+				Instance: path,
+				Errors:   fieldErrorsOf(err),
+			})
+			return
+		}
+
+		if !ensureInNamespace(c, log, prod, path, "policy", policyNamespace(pm, request.PolicyId)) {
+			return
+		}
+
+		reportingResponse, err := m.GetPolicyVersionReporting(request)
+		if err != nil {
+			if _, ok := err.(validationError); ok {
+				telemetry.Incr("bricksllm.admin.get_get_policy_version_reporting_handler.request_not_valid", nil, 1)
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "policy version reporting request validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+					Errors:   fieldErrorsOf(err),
+				})
+				return
+			}
+
+			telemetry.Incr("bricksllm.admin.get_get_policy_version_reporting_handler.get_policy_version_reporting_error", nil, 1)
+
+			logError(log, "error when getting policy version reporting", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/event-reporting-manager",
+				Title:    "policy version reporting error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_get_policy_version_reporting_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, reportingResponse)
+	}
+}
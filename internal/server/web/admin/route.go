@@ -1,22 +1,30 @@
 package admin
 
 import (
-	"encoding/json"
 	"io"
 	"net/http"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/change"
+	"github.com/bricks-cloud/bricksllm/internal/dryrun"
 	"github.com/bricks-cloud/bricksllm/internal/route"
 	"github.com/bricks-cloud/bricksllm/internal/telemetry"
 	"github.com/bricks-cloud/bricksllm/internal/util"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 type RouteManager interface {
 	DeleteRoute(id string) error
+	PreviewDeleteRoute(id string) (*dryrun.Result, error)
 	GetRoute(id string) (*route.Route, error)
 	GetRoutes() ([]*route.Route, error)
 	CreateRoute(r *route.Route) (*route.Route, error)
+	GetRouteStatus(id string) (*route.RouteStatus, error)
+	UpdateRoute(id string, ur *route.UpdateRoute) (*route.Route, error)
+	GetRouteVersions(id string) ([]*route.RouteVersion, error)
+	RollbackRoute(id string, version int) (*route.Route, error)
+	TestRoute(id string, tr *route.TestRequest, log *zap.Logger) (*route.TestResult, error)
 }
 
 func getCreateRouteHandler(m RouteManager, prod bool) gin.HandlerFunc {
@@ -44,6 +52,10 @@ func getCreateRouteHandler(m RouteManager, prod bool) gin.HandlerFunc {
 
 		data, err := io.ReadAll(c.Request.Body)
 		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
 			logError(log, "error when reading create a route request body", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/request-body-read",
@@ -56,19 +68,23 @@ func getCreateRouteHandler(m RouteManager, prod bool) gin.HandlerFunc {
 		}
 
 		r := &route.Route{}
-		err = json.Unmarshal(data, r)
+		err = bindJSON(data, r)
 		if err != nil {
-			logError(log, "error when unmarshalling create a route request body", prod, err)
-			c.JSON(http.StatusInternalServerError, &ErrorResponse{
-				Type:     "/errors/json-unmarshal",
-				Title:    "json unmarshaller error",
-				Status:   http.StatusInternalServerError,
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "request body validation failed",
+				Status:   http.StatusBadRequest,
 				Detail:   err.Error(),
 				Instance: path,
+				Errors:   fieldErrorsOf(err),
 			})
 			return
 		}
 
+		if ns, ok := namespaceOf(c); ok {
+			r.Namespace = ns
+		}
+
 		created, err := m.CreateRoute(r)
 		if err != nil {
 			errType := "internal"
@@ -87,6 +103,7 @@ func getCreateRouteHandler(m RouteManager, prod bool) gin.HandlerFunc {
 					Status:   http.StatusBadRequest,
 					Detail:   err.Error(),
 					Instance: path,
+					Errors:   fieldErrorsOf(err),
 				})
 				return
 			}
@@ -102,6 +119,7 @@ func getCreateRouteHandler(m RouteManager, prod bool) gin.HandlerFunc {
 			return
 		}
 
+		recordChange(c, change.KindRoute, change.ActionCreate, created.Id, created.Namespace)
 		telemetry.Incr("bricksllm.admin.get_create_route_handler.success", nil, 1)
 		c.JSON(http.StatusOK, created)
 	}
@@ -164,11 +182,84 @@ func getGetRouteHandler(m RouteManager, prod bool) gin.HandlerFunc {
 			return
 		}
 
+		if !ensureInNamespace(c, log, prod, path, "route", func() (string, error) { return r.Namespace, nil }) {
+			return
+		}
+
 		telemetry.Incr("bricksllm.admin.get_get_route_handler.success", nil, 1)
+		setRouteETag(c, r)
 		c.JSON(http.StatusOK, r)
 	}
 }
 
+// getGetRouteStatusHandler returns the circuit breaker state of every step of
+// a route as seen by the gateway serving the request.
+func getGetRouteStatusHandler(m RouteManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_get_route_status_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_get_route_status_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/routes/:id/status"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		if !ensureInNamespace(c, log, prod, path, "route", routeNamespace(m, c.Param("id"))) {
+			return
+		}
+
+		rs, err := m.GetRouteStatus(c.Param("id"))
+		if err != nil {
+			errType := "internal"
+			defer func() {
+				telemetry.Incr("bricksllm.admin.get_get_route_status_handler.get_route_status_err", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+
+				logError(log, "route not found", prod, err)
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/route-not-found",
+					Title:    "route not found error",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when getting the status of a route", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/route-manager",
+				Title:    "getting route status error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_get_route_status_handler.success", nil, 1)
+		c.JSON(http.StatusOK, rs)
+	}
+}
+
 func getDeleteRouteHandler(m RouteManager, prod bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := util.GetLogFromCtx(c)
@@ -192,7 +283,21 @@ func getDeleteRouteHandler(m RouteManager, prod bool) gin.HandlerFunc {
 			return
 		}
 
-		err := m.DeleteRoute(c.Param("id"))
+		if !ensureInNamespace(c, log, prod, path, "route", routeNamespace(m, c.Param("id"))) {
+			return
+		}
+
+		dryRun := c.Query("dryRun") == "true"
+
+		var result *dryrun.Result
+		var err error
+		if dryRun {
+			result, err = m.PreviewDeleteRoute(c.Param("id"))
+		} else {
+			recordChange(c, change.KindRoute, change.ActionDelete, c.Param("id"), namespaceForChange(c, routeNamespace(m, c.Param("id"))))
+			err = m.DeleteRoute(c.Param("id"))
+		}
+
 		if err != nil {
 			errType := "internal"
 			defer func() {
@@ -226,6 +331,12 @@ func getDeleteRouteHandler(m RouteManager, prod bool) gin.HandlerFunc {
 			return
 		}
 
+		if dryRun {
+			telemetry.Incr("bricksllm.admin.get_delete_route_handler.dry_run_success", nil, 1)
+			c.JSON(http.StatusOK, result)
+			return
+		}
+
 		telemetry.Incr("bricksllm.admin.get_delete_route_handler.success", nil, 1)
 		c.Status(http.StatusOK)
 	}
@@ -275,6 +386,6 @@ func getGetRoutesHandler(m RouteManager, prod bool) gin.HandlerFunc {
 		}
 
 		telemetry.Incr("bricksllm.admin.get_get_routes_handler.success", nil, 1)
-		c.JSON(http.StatusOK, rs)
+		c.JSON(http.StatusOK, withinNamespace(c, rs, func(r *route.Route) string { return r.Namespace }))
 	}
 }
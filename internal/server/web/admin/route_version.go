@@ -0,0 +1,284 @@
+package admin
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/change"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// setRouteETag sets the version of a route as the ETag of the response, so
+// that clients can send it back in If-Match when updating the route.
+func setRouteETag(c *gin.Context, r *route.Route) {
+	c.Header("ETag", fmt.Sprintf(`"%d"`, r.Version))
+}
+
+// ifMatchVersion parses the route version sent in the If-Match header. It
+// returns nil if the header is absent or is a wildcard.
+func ifMatchVersion(c *gin.Context) (*int, error) {
+	match := strings.TrimSpace(c.GetHeader("If-Match"))
+	if len(match) == 0 || match == "*" {
+		return nil, nil
+	}
+
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(match, "W/"), `"`))
+	if err != nil {
+		return nil, fmt.Errorf("header If-Match must be the ETag of a route: %s", match)
+	}
+
+	return &version, nil
+}
+
+// writeRouteVersionError maps errors of route updates and of the version
+// endpoints of routes to responses.
+func writeRouteVersionError(c *gin.Context, log *zap.Logger, prod bool, path, metric string, err error) {
+	errType := "internal"
+	defer func() {
+		telemetry.Incr(metric, []string{
+			"error_type:" + errType,
+		}, 1)
+	}()
+
+	if _, ok := err.(notFoundError); ok {
+		errType = "not_found"
+		c.JSON(http.StatusNotFound, &ErrorResponse{
+			Type:     "/errors/route-not-found",
+			Title:    "route not found error",
+			Status:   http.StatusNotFound,
+			Detail:   err.Error(),
+			Instance: path,
+		})
+		return
+	}
+
+	if _, ok := err.(validationError); ok {
+		errType = "validation"
+		c.JSON(http.StatusBadRequest, &ErrorResponse{
+			Type:     "/errors/validation",
+			Title:    "route validation failed",
+			Status:   http.StatusBadRequest,
+			Detail:   err.Error(),
+			Instance: path,
+			Errors:   fieldErrorsOf(err),
+		})
+		return
+	}
+
+	if _, ok := err.(conflictError); ok {
+		errType = "conflict"
+		c.JSON(http.StatusConflict, &ErrorResponse{
+			Type:     "/errors/conflict",
+			Title:    "route has been changed by another update",
+			Status:   http.StatusConflict,
+			Detail:   err.Error(),
+			Instance: path,
+		})
+		return
+	}
+
+	logError(log, "error when updating a route", prod, err)
+	c.JSON(http.StatusInternalServerError, &ErrorResponse{
+		Type:     "/errors/route-manager",
+		Title:    "updating a route error",
+		Status:   http.StatusInternalServerError,
+		Detail:   err.Error(),
+		Instance: path,
+	})
+}
+
+// getUpdateRouteHandler changes a route in place. The version the update was
+// made against is taken from the If-Match header, or else from the body, and
+// the update is rejected if the route has moved on from it.
+func getUpdateRouteHandler(m RouteManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_update_route_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_update_route_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/routes/:id"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
+			logError(log, "error when reading update a route request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		ur := &route.UpdateRoute{}
+		err = bindJSON(data, ur)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "request body validation failed",
+				Status:   http.StatusBadRequest,
+				Detail:   err.Error(),
+				Instance: path,
+				Errors:   fieldErrorsOf(err),
+			})
+			return
+		}
+
+		version, err := ifMatchVersion(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "request header validation failed",
+				Status:   http.StatusBadRequest,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		if version != nil {
+			ur.Version = version
+		}
+
+		id := c.Param("id")
+		if !ensureInNamespace(c, log, prod, path, "route", routeNamespace(m, id)) {
+			return
+		}
+
+		updated, err := m.UpdateRoute(id, ur)
+		if err != nil {
+			writeRouteVersionError(c, log, prod, path, "bricksllm.admin.get_update_route_handler.update_route_error", err)
+			return
+		}
+
+		recordChange(c, change.KindRoute, change.ActionUpdate, updated.Id, updated.Namespace)
+		telemetry.Incr("bricksllm.admin.get_update_route_handler.success", nil, 1)
+
+		setRouteETag(c, updated)
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+func getGetRouteVersionsHandler(m RouteManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_get_route_versions_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_get_route_versions_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/routes/:id/versions"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		id := c.Param("id")
+		if !ensureInNamespace(c, log, prod, path, "route", routeNamespace(m, id)) {
+			return
+		}
+
+		versions, err := m.GetRouteVersions(id)
+		if err != nil {
+			writeRouteVersionError(c, log, prod, path, "bricksllm.admin.get_get_route_versions_handler.get_route_versions_error", err)
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_get_route_versions_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, versions)
+	}
+}
+
+// getRollbackRouteHandler restores the config a route had at one of its
+// versions.
+func getRollbackRouteHandler(m RouteManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_rollback_route_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_rollback_route_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/routes/:id/rollback/:version"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		version, err := strconv.Atoi(c.Param("version"))
+		if err != nil || version < 1 {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "route rollback request validation failed",
+				Status:   http.StatusBadRequest,
+				Detail:   "version must be a positive integer",
+				Instance: path,
+			})
+			return
+		}
+
+		id := c.Param("id")
+		if !ensureInNamespace(c, log, prod, path, "route", routeNamespace(m, id)) {
+			return
+		}
+
+		r, err := m.RollbackRoute(id, version)
+		if err != nil {
+			writeRouteVersionError(c, log, prod, path, "bricksllm.admin.get_rollback_route_handler.rollback_route_error", err)
+			return
+		}
+
+		recordChange(c, change.KindRoute, change.ActionUpdate, r.Id, r.Namespace)
+		telemetry.Incr("bricksllm.admin.get_rollback_route_handler.success", nil, 1)
+
+		setRouteETag(c, r)
+		c.JSON(http.StatusOK, r)
+	}
+}
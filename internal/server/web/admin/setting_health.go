@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+// getCheckProviderSettingHealthHandler returns the upstream health of a
+// provider setting as tracked by the health monitor. An upstream that is down
+// still gets a 200 with the status in the body, since the lookup succeeded.
+func getCheckProviderSettingHealthHandler(m ProviderSettingsManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_check_provider_setting_health_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_check_provider_setting_health_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/provider-settings/:id/health"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		id := c.Param("id")
+		if !ensureInNamespace(c, log, prod, path, "provider setting", settingNamespace(m, id)) {
+			return
+		}
+
+		h, err := m.GetSettingHealth(c.Request.Context(), id)
+		if err != nil {
+			writeSettingCheckError(c, log, prod, path, "bricksllm.admin.get_check_provider_setting_health_handler.check_health_error", "health check", err)
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_check_provider_setting_health_handler.success", []string{
+			"status:" + h.Status,
+		}, 1)
+
+		c.JSON(http.StatusOK, h)
+	}
+}
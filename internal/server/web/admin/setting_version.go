@@ -0,0 +1,141 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/change"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// writeSettingVersionError maps errors of the version endpoints of provider
+// settings to responses.
+func writeSettingVersionError(c *gin.Context, log *zap.Logger, prod bool, path, metric string, err error) {
+	errType := "internal"
+	defer func() {
+		telemetry.Incr(metric, []string{
+			"error_type:" + errType,
+		}, 1)
+	}()
+
+	if _, ok := err.(notFoundError); ok {
+		errType = "not_found"
+		c.JSON(http.StatusNotFound, &ErrorResponse{
+			Type:     "/errors/not-found",
+			Title:    "provider setting version is not found",
+			Status:   http.StatusNotFound,
+			Detail:   err.Error(),
+			Instance: path,
+		})
+		return
+	}
+
+	logError(log, "error when managing provider setting versions", prod, err)
+	c.JSON(http.StatusInternalServerError, &ErrorResponse{
+		Type:     "/errors/provider-settings-manager",
+		Title:    "provider setting version error",
+		Status:   http.StatusInternalServerError,
+		Detail:   err.Error(),
+		Instance: path,
+	})
+}
+
+func getGetProviderSettingVersionsHandler(m ProviderSettingsManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_get_provider_setting_versions_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_get_provider_setting_versions_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/provider-settings/:id/versions"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		id := c.Param("id")
+		if !ensureInNamespace(c, log, prod, path, "provider setting", settingNamespace(m, id)) {
+			return
+		}
+
+		versions, err := m.GetSettingVersions(id)
+		if err != nil {
+			writeSettingVersionError(c, log, prod, path, "bricksllm.admin.get_get_provider_setting_versions_handler.get_setting_versions_error", err)
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_get_provider_setting_versions_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, versions)
+	}
+}
+
+// getRollbackProviderSettingHandler restores a provider setting to one of its
+// versions, which brings back the secrets it had without admins re-entering
+// them.
+func getRollbackProviderSettingHandler(m ProviderSettingsManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_rollback_provider_setting_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_rollback_provider_setting_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/provider-settings/:id/rollback/:version"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		version, err := strconv.Atoi(c.Param("version"))
+		if err != nil || version < 1 {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/validation",
+				Title:    "provider setting rollback request validation failed",
+				Status:   http.StatusBadRequest,
+				Detail:   "version must be a positive integer",
+				Instance: path,
+			})
+			return
+		}
+
+		id := c.Param("id")
+		if !ensureInNamespace(c, log, prod, path, "provider setting", settingNamespace(m, id)) {
+			return
+		}
+
+		setting, err := m.RollbackSetting(id, version)
+		if err != nil {
+			writeSettingVersionError(c, log, prod, path, "bricksllm.admin.get_rollback_provider_setting_handler.rollback_setting_error", err)
+			return
+		}
+
+		recordChange(c, change.KindProviderSetting, change.ActionUpdate, id, namespaceForChange(c, settingNamespace(m, id)))
+		telemetry.Incr("bricksllm.admin.get_rollback_provider_setting_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, setting)
+	}
+}
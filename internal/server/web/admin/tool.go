@@ -0,0 +1,344 @@
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/dryrun"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/tool"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+type ToolManager interface {
+	CreateTool(t *tool.Tool) (*tool.Tool, error)
+	GetTools() ([]*tool.Tool, error)
+	DeleteTool(id string) error
+	PreviewDeleteTool(id string) (*dryrun.Result, error)
+	GetToolExecutions(id string, limit int) ([]*tool.Execution, error)
+}
+
+func getCreateToolHandler(m ToolManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_create_tool_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_create_tool_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/tools"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
+			logError(log, "error when reading create tool request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/request-body-read",
+				Title:    "request body reader error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		t := &tool.Tool{}
+		err = json.Unmarshal(data, t)
+		if err != nil {
+			logError(log, "error when unmarshalling create tool request body", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/json-unmarshal",
+				Title:    "json unmarshaller error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		created, err := m.CreateTool(t)
+		if err != nil {
+			errType := "internal"
+
+			defer func() {
+				telemetry.Incr("bricksllm.admin.get_create_tool_handler.create_tool_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(validationError); ok {
+				errType = "validation"
+
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "tool validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(conflictError); ok {
+				errType = "conflict"
+
+				c.JSON(http.StatusConflict, &ErrorResponse{
+					Type:     "/errors/conflict",
+					Title:    "tool name is already registered",
+					Status:   http.StatusConflict,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when creating a tool", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/tool-manager",
+				Title:    "tool creation error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_create_tool_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, created)
+	}
+}
+
+func getGetToolsHandler(m ToolManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_get_tools_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_get_tools_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/tools"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		tools, err := m.GetTools()
+		if err != nil {
+			telemetry.Incr("bricksllm.admin.get_get_tools_handler.get_tools_error", nil, 1)
+
+			logError(log, "error when getting tools", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/tool-manager",
+				Title:    "getting tools error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_get_tools_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, tools)
+	}
+}
+
+func getDeleteToolHandler(m ToolManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_delete_tool_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_delete_tool_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/tools/:id"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		dryRun := c.Query("dryRun") == "true"
+
+		var result *dryrun.Result
+		var err error
+		if dryRun {
+			result, err = m.PreviewDeleteTool(c.Param("id"))
+		} else {
+			err = m.DeleteTool(c.Param("id"))
+		}
+
+		if err != nil {
+			errType := "internal"
+			defer func() {
+				telemetry.Incr("bricksllm.admin.get_delete_tool_handler.delete_tool_err", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+
+				logError(log, "tool not found", prod, err)
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/tool-not-found",
+					Title:    "tool not found error",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when deleting a tool", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/tool-manager",
+				Title:    "deleting a tool error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		if dryRun {
+			telemetry.Incr("bricksllm.admin.get_delete_tool_handler.dry_run_success", nil, 1)
+			c.JSON(http.StatusOK, result)
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_delete_tool_handler.success", nil, 1)
+		c.Status(http.StatusOK)
+	}
+}
+
+func getGetToolExecutionsHandler(m ToolManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_get_tool_executions_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_get_tool_executions_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/tools/:id/executions"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		limit := 0
+		limitStr, ok := c.GetQuery("limit")
+		if ok {
+			parsed, err := strconv.Atoi(limitStr)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/bad-filters",
+					Title:    "bad limit query param",
+					Status:   http.StatusBadRequest,
+					Detail:   "limit query param cannot be converted to integer",
+					Instance: path,
+				})
+				return
+			}
+
+			limit = parsed
+		}
+
+		executions, err := m.GetToolExecutions(c.Param("id"), limit)
+		if err != nil {
+			errType := "internal"
+			defer func() {
+				telemetry.Incr("bricksllm.admin.get_get_tool_executions_handler.get_tool_executions_error", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(validationError); ok {
+				errType = "validation"
+
+				c.JSON(http.StatusBadRequest, &ErrorResponse{
+					Type:     "/errors/validation",
+					Title:    "tool executions request validation failed",
+					Status:   http.StatusBadRequest,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/tool-not-found",
+					Title:    "tool not found error",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when getting tool executions", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/tool-manager",
+				Title:    "getting tool executions error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_get_tool_executions_handler.success", nil, 1)
+
+		c.JSON(http.StatusOK, executions)
+	}
+}
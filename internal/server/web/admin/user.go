@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/dryrun"
 	"github.com/bricks-cloud/bricksllm/internal/telemetry"
 	"github.com/bricks-cloud/bricksllm/internal/user"
 	"github.com/bricks-cloud/bricksllm/internal/util"
@@ -18,6 +19,9 @@ type UserManager interface {
 	CreateUser(u *user.User) (*user.User, error)
 	UpdateUser(id string, uu *user.UpdateUser) (*user.User, error)
 	UpdateUserViaTagsAndUserId(tags []string, uid string, uu *user.UpdateUser) (*user.User, error)
+	PreviewUpdateUserViaTagsAndUserId(tags []string, uid string, uu *user.UpdateUser) (*dryrun.Result, error)
+	DeleteUser(id string, cascade bool) ([]*dryrun.Dependent, error)
+	PreviewDeleteUser(id string, cascade bool) (*dryrun.Result, error)
 }
 
 func getGetUsersHandler(m UserManager, prod bool) gin.HandlerFunc {
@@ -130,6 +134,10 @@ func getCreateUserHandler(m UserManager, prod bool) gin.HandlerFunc {
 
 		data, err := io.ReadAll(c.Request.Body)
 		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
 			logError(log, "error when reading user creation request body", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/request-body-read",
@@ -232,6 +240,10 @@ func getUpdateUserHandler(m UserManager, prod bool) gin.HandlerFunc {
 
 		data, err := io.ReadAll(c.Request.Body)
 		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
 			logError(log, "error when reading update user request body", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/request-body-read",
@@ -334,6 +346,10 @@ func getUpdateUserViaTagsAndUserIdHandler(m UserManager, prod bool) gin.HandlerF
 
 		data, err := io.ReadAll(c.Request.Body)
 		if err != nil {
+			if bodyTooLarge(c, path, err) {
+				return
+			}
+
 			logError(log, "error when reading update user via tags and user id request body", prod, err)
 			c.JSON(http.StatusInternalServerError, &ErrorResponse{
 				Type:     "/errors/request-body-read",
@@ -359,7 +375,16 @@ func getUpdateUserViaTagsAndUserIdHandler(m UserManager, prod bool) gin.HandlerF
 			return
 		}
 
-		resk, err := m.UpdateUserViaTagsAndUserId(c.QueryArray("tags"), uid, uu)
+		dryRun := c.Query("dryRun") == "true"
+
+		var resk *user.User
+		var result *dryrun.Result
+		if dryRun {
+			result, err = m.PreviewUpdateUserViaTagsAndUserId(c.QueryArray("tags"), uid, uu)
+		} else {
+			resk, err = m.UpdateUserViaTagsAndUserId(c.QueryArray("tags"), uid, uu)
+		}
+
 		if err != nil {
 			errType := "internal"
 
@@ -393,8 +418,117 @@ func getUpdateUserViaTagsAndUserIdHandler(m UserManager, prod bool) gin.HandlerF
 			return
 		}
 
+		if dryRun {
+			telemetry.Incr("bricksllm.admin.get_update_user_via_tags_and_user_id_handler.dry_run_success", nil, 1)
+			c.JSON(http.StatusOK, result)
+			return
+		}
+
 		telemetry.Incr("bricksllm.admin.get_update_user_via_tags_and_user_id_handler.success", nil, 1)
 
 		c.JSON(http.StatusOK, resk)
 	}
 }
+
+func getDeleteUserHandler(m UserManager, prod bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.admin.get_delete_user_handler.requests", nil, 1)
+
+		start := time.Now()
+		defer func() {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.admin.get_delete_user_handler.latency", dur, nil, 1)
+		}()
+
+		path := "/api/users/:id"
+		if c == nil || c.Request == nil {
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/empty-context",
+				Title:    "context is empty error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "gin context is empty",
+				Instance: path,
+			})
+			return
+		}
+
+		id := c.Param("id")
+		if len(id) == 0 {
+			c.JSON(http.StatusBadRequest, &ErrorResponse{
+				Type:     "/errors/missing-param-id",
+				Title:    "id is empty",
+				Status:   http.StatusBadRequest,
+				Detail:   "id url param is missing from the request url. it is required for deleting a user.",
+				Instance: path,
+			})
+
+			return
+		}
+
+		dryRun := c.Query("dryRun") == "true"
+		cascade := c.Query("cascade") == "true"
+
+		var result *dryrun.Result
+		var err error
+		if dryRun {
+			result, err = m.PreviewDeleteUser(id, cascade)
+		} else {
+			var dependents []*dryrun.Dependent
+			dependents, err = m.DeleteUser(id, cascade)
+			recordCascade(c, dependents, "")
+		}
+
+		if err != nil {
+			errType := "internal"
+			defer func() {
+				telemetry.Incr("bricksllm.admin.get_delete_user_handler.delete_err", []string{
+					"error_type:" + errType,
+				}, 1)
+			}()
+
+			if _, ok := err.(notFoundError); ok {
+				errType = "not_found"
+				c.JSON(http.StatusNotFound, &ErrorResponse{
+					Type:     "/errors/not-found",
+					Title:    "user is not found",
+					Status:   http.StatusNotFound,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			if _, ok := err.(conflictError); ok {
+				errType = "conflict"
+				c.JSON(http.StatusConflict, &ErrorResponse{
+					Type:     "/errors/conflict",
+					Title:    "user still owns keys",
+					Status:   http.StatusConflict,
+					Detail:   err.Error(),
+					Instance: path,
+				})
+				return
+			}
+
+			logError(log, "error when deleting a user", prod, err)
+			c.JSON(http.StatusInternalServerError, &ErrorResponse{
+				Type:     "/errors/user-manager",
+				Title:    "deleting a user error",
+				Status:   http.StatusInternalServerError,
+				Detail:   err.Error(),
+				Instance: path,
+			})
+			return
+		}
+
+		if dryRun {
+			telemetry.Incr("bricksllm.admin.get_delete_user_handler.dry_run_success", nil, 1)
+			c.JSON(http.StatusOK, result)
+			return
+		}
+
+		telemetry.Incr("bricksllm.admin.get_delete_user_handler.success", nil, 1)
+		c.Status(http.StatusOK)
+	}
+}
@@ -1,7 +1,6 @@
 package proxy
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -30,7 +29,7 @@ type anthropicEstimator interface {
 
 func copyHttpHeaders(source *http.Request, dest *http.Request, removeUseAgent bool) {
 	for k := range source.Header {
-		if strings.ToLower(k) != "x-custom-event-id" {
+		if strings.ToLower(k) != "x-custom-event-id" && !isChainHeader(k) {
 			dest.Header.Set(k, source.Header.Get(k))
 		}
 	}
@@ -55,7 +54,7 @@ func getCompletionHandler(prod, private bool, client http.Client) gin.HandlerFun
 		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.anthropic.com/v1/complete", c.Request.Body)
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), http.MethodPost, "https://api.anthropic.com/v1/complete", c.Request.Body)
 		if err != nil {
 			logError(log, "error when creating anthropic http request", prod, err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create anthropic http request")
@@ -167,7 +166,7 @@ func getCompletionHandler(prod, private bool, client http.Client) gin.HandlerFun
 			return
 		}
 
-		buffer := bufio.NewReader(res.Body)
+		buffer := newSseReader(res.Body, c.GetInt("sseMaxLineSize"), "anthropic_completion")
 		// var totalCost float64 = 0
 
 		content := ""
@@ -197,7 +196,7 @@ func getCompletionHandler(prod, private bool, client http.Client) gin.HandlerFun
 
 		eventName := ""
 		c.Stream(func(w io.Writer) bool {
-			raw, err := buffer.ReadBytes('\n')
+			raw, err := buffer.ReadLine()
 
 			if err != nil {
 				if err == io.EOF {
@@ -310,7 +309,7 @@ func getMessagesHandler(prod, private bool, client http.Client, e anthropicEstim
 		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.anthropic.com/v1/messages", c.Request.Body)
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), http.MethodPost, "https://api.anthropic.com/v1/messages", c.Request.Body)
 		if err != nil {
 			logError(log, "error when creating anthropic http request", prod, err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create anthropic http request")
@@ -413,7 +412,7 @@ func getMessagesHandler(prod, private bool, client http.Client, e anthropicEstim
 			return
 		}
 
-		buffer := bufio.NewReader(res.Body)
+		buffer := newSseReader(res.Body, c.GetInt("sseMaxLineSize"), "anthropic_messages")
 		var totalCost float64 = 0
 
 		streamingResponse := [][]byte{}
@@ -448,7 +447,7 @@ func getMessagesHandler(prod, private bool, client http.Client, e anthropicEstim
 
 		eventName := ""
 		c.Stream(func(w io.Writer) bool {
-			raw, err := buffer.ReadBytes('\n')
+			raw, err := buffer.ReadLine()
 			if err != nil {
 				if err == io.EOF {
 					return false
@@ -0,0 +1,295 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+const anthropicDefaultVersion = "2023-06-01"
+
+var eventPrefix = []byte("event:")
+
+// getAnthropicChatCompletionHandler serves Claude models behind the OpenAI chat
+// completion shape. The middleware has already translated the request body
+// into an Anthropic messages request, so only responses are translated here.
+func getAnthropicChatCompletionHandler(prod, private bool, client http.Client, e anthropicEstimator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.proxy.get_anthropic_chat_completion_handler.requests", nil, 1)
+
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), http.MethodPost, "https://api.anthropic.com/v1/messages", c.Request.Body)
+		if err != nil {
+			logError(log, "error when creating anthropic http request", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create anthropic http request")
+			return
+		}
+
+		raw, exists := c.Get("key")
+		_, ok := raw.(*key.ResponseKey)
+		if !exists || !ok {
+			telemetry.Incr("bricksllm.proxy.get_anthropic_chat_completion_handler.api_key_not_registered", nil, 1)
+			JSON(c, http.StatusUnauthorized, "[BricksLLM] api key is not registered")
+			return
+		}
+
+		copyHttpHeaders(c.Request, req, c.GetBool("removeUserAgent"))
+
+		// openai clients authenticate with a bearer token that must not reach anthropic
+		req.Header.Del("Authorization")
+		req.Header.Del("Content-Length")
+		req.Header.Set("Content-Type", "application/json")
+		if len(req.Header.Get("anthropic-version")) == 0 {
+			req.Header.Set("anthropic-version", anthropicDefaultVersion)
+		}
+
+		isStreaming := c.GetBool("stream")
+		if isStreaming {
+			req.Header.Set("Accept", "text/event-stream")
+			req.Header.Set("Cache-Control", "no-cache")
+			req.Header.Set("Connection", "keep-alive")
+		}
+
+		start := time.Now()
+		res, err := client.Do(req)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_anthropic_chat_completion_handler.http_client_error", nil, 1)
+
+			logError(log, "error when sending http request to anthropic", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send http request to anthropic")
+			return
+		}
+
+		defer res.Body.Close()
+
+		model := c.GetString("model")
+
+		if res.StatusCode != http.StatusOK {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.proxy.get_anthropic_chat_completion_handler.error_latency", dur, nil, 1)
+			telemetry.Incr("bricksllm.proxy.get_anthropic_chat_completion_handler.error_response", nil, 1)
+
+			data, err := io.ReadAll(res.Body)
+			if err != nil {
+				logError(log, "error when reading anthropic http messages response body", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read anthropic response body")
+				return
+			}
+
+			logAnthropicErrorResponse(log, data, prod)
+
+			er := &anthropic.ErrorResponse{}
+			if err := json.Unmarshal(data, er); err != nil {
+				er = nil
+			}
+
+			c.JSON(res.StatusCode, anthropic.ToChatCompletionErrorResponse(er))
+			return
+		}
+
+		if !isStreaming {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.proxy.get_anthropic_chat_completion_handler.latency", dur, nil, 1)
+
+			data, err := io.ReadAll(res.Body)
+			if err != nil {
+				logError(log, "error when reading anthropic http messages response body", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read anthropic response body")
+				return
+			}
+
+			mr := &anthropic.MessagesResponse{}
+			if err := json.Unmarshal(data, mr); err != nil {
+				telemetry.Incr("bricksllm.proxy.get_anthropic_chat_completion_handler.unmarshal_error", nil, 1)
+				logError(log, "error when unmarshalling anthropic http messages response body", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to parse anthropic response body")
+				return
+			}
+
+			logCompletionResponse(log, data, prod, private)
+
+			cost, err := e.EstimateTotalCost(model, mr.Usage.InputTokens, mr.Usage.OutputTokens)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_anthropic_chat_completion_handler.estimate_total_cost_error", nil, 1)
+				logError(log, "error when estimating anthropic cost", prod, err)
+			}
+
+			c.Set("costInUsd", cost)
+			c.Set("promptTokenCount", mr.Usage.InputTokens)
+			c.Set("completionTokenCount", mr.Usage.OutputTokens)
+
+			telemetry.Incr("bricksllm.proxy.get_anthropic_chat_completion_handler.success", nil, 1)
+			telemetry.Timing("bricksllm.proxy.get_anthropic_chat_completion_handler.success_latency", dur, nil, 1)
+
+			c.JSON(res.StatusCode, anthropic.ToChatCompletionResponse(mr))
+			return
+		}
+
+		buffer := newSseReader(res.Body, c.GetInt("sseMaxLineSize"), "anthropic_chat_completion")
+		content := ""
+		streamingResponse := [][]byte{}
+		usage := &goopenai.Usage{}
+		defer func() {
+			cost, err := e.EstimateTotalCost(model, usage.PromptTokens, usage.CompletionTokens)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_anthropic_chat_completion_handler.estimate_total_cost_error", nil, 1)
+				logError(log, "error when estimating anthropic chat completion stream cost", prod, err)
+			}
+
+			c.Set("costInUsd", cost)
+			c.Set("promptTokenCount", usage.PromptTokens)
+			c.Set("completionTokenCount", usage.CompletionTokens)
+			c.Set("content", content)
+			c.Set("streaming_response", bytes.Join(streamingResponse, []byte{'\n'}))
+		}()
+
+		telemetry.Incr("bricksllm.proxy.get_anthropic_chat_completion_handler.streaming_requests", nil, 1)
+
+		chunk := &goopenai.ChatCompletionStreamResponse{
+			Object:  "chat.completion.chunk",
+			Created: time.Now().Unix(),
+			Model:   model,
+		}
+
+		send := func(delta goopenai.ChatCompletionStreamChoiceDelta, finishReason goopenai.FinishReason) {
+			chunk.Choices = []goopenai.ChatCompletionStreamChoice{{
+				Delta:        delta,
+				FinishReason: finishReason,
+			}}
+
+			data, err := json.Marshal(chunk)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_anthropic_chat_completion_handler.json_marshal_error", nil, 1)
+				logError(log, "error when marshalling anthropic chat completion chunk", prod, err)
+				return
+			}
+
+			c.SSEvent("", " "+string(data))
+		}
+
+		eventName := ""
+		c.Stream(func(w io.Writer) bool {
+			raw, err := buffer.ReadLine()
+			if err != nil {
+				if err == io.EOF {
+					return false
+				}
+
+				telemetry.Incr("bricksllm.proxy.get_anthropic_chat_completion_handler.read_bytes_error", nil, 1)
+				logError(log, "error when reading bytes from anthropic streaming response", prod, err)
+
+				data, err := json.Marshal(&goopenai.ErrorResponse{
+					Error: &goopenai.APIError{
+						Type:    "bricksllm_error",
+						Message: err.Error(),
+					},
+				})
+				if err == nil {
+					c.SSEvent("", " "+string(data))
+				}
+
+				c.SSEvent("", " [DONE]")
+				return false
+			}
+
+			streamingResponse = append(streamingResponse, raw)
+
+			noSpaceLine := bytes.TrimSpace(raw)
+			if bytes.HasPrefix(noSpaceLine, eventPrefix) {
+				eventName = string(bytes.TrimSpace(bytes.TrimPrefix(noSpaceLine, eventPrefix)))
+				return true
+			}
+
+			if !bytes.HasPrefix(noSpaceLine, headerData) {
+				return true
+			}
+
+			noPrefixLine := bytes.TrimPrefix(noSpaceLine, headerData)
+
+			switch eventName {
+			case "message_start":
+				messageStart := &anthropic.MessagesStreamMessageStart{}
+				if err := json.Unmarshal(noPrefixLine, messageStart); err != nil {
+					telemetry.Incr("bricksllm.proxy.get_anthropic_chat_completion_handler.message_start_response_unmarshall_error", nil, 1)
+					logError(log, "error when unmarshalling anthropic message stream response message_start", prod, err)
+					return true
+				}
+
+				chunk.ID = messageStart.Message.Id
+				if len(messageStart.Message.Model) != 0 {
+					chunk.Model = messageStart.Message.Model
+				}
+
+				usage.PromptTokens = messageStart.Message.Usage.InputTokens
+				send(goopenai.ChatCompletionStreamChoiceDelta{Role: goopenai.ChatMessageRoleAssistant}, "")
+
+			case "content_block_delta":
+				blockDelta := &anthropic.MessagesStreamBlockDelta{}
+				if err := json.Unmarshal(noPrefixLine, blockDelta); err != nil {
+					telemetry.Incr("bricksllm.proxy.get_anthropic_chat_completion_handler.content_block_delta_response_unmarshall_error", nil, 1)
+					logError(log, "error when unmarshalling anthropic message stream response content_block_delta", prod, err)
+					return true
+				}
+
+				if len(blockDelta.Delta.Text) != 0 {
+					content += blockDelta.Delta.Text
+					send(goopenai.ChatCompletionStreamChoiceDelta{Content: blockDelta.Delta.Text}, "")
+				}
+
+			case "message_delta":
+				messageDelta := &anthropic.MessagesStreamMessageDelta{}
+				if err := json.Unmarshal(noPrefixLine, messageDelta); err != nil {
+					telemetry.Incr("bricksllm.proxy.get_anthropic_chat_completion_handler.message_delta_response_unmarshall_error", nil, 1)
+					logError(log, "error when unmarshalling anthropic message stream response message_delta", prod, err)
+					return true
+				}
+
+				usage.CompletionTokens = messageDelta.Usage.OutputTokens
+				if len(messageDelta.Delta.StopReason) != 0 {
+					send(goopenai.ChatCompletionStreamChoiceDelta{}, anthropic.FinishReasonOf(messageDelta.Delta.StopReason))
+				}
+
+			case "message_stop":
+				c.SSEvent("", " [DONE]")
+				return false
+
+			case "error":
+				er := &anthropic.ErrorResponse{}
+				if err := json.Unmarshal(noPrefixLine, er); err != nil {
+					er = nil
+				}
+
+				data, err := json.Marshal(anthropic.ToChatCompletionErrorResponse(er))
+				if err == nil {
+					c.SSEvent("", " "+string(data))
+				}
+
+				c.SSEvent("", " [DONE]")
+				return false
+			}
+
+			return true
+		})
+
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+		telemetry.Timing("bricksllm.proxy.get_anthropic_chat_completion_handler.streaming_latency", time.Since(start), nil, 1)
+	}
+}
@@ -0,0 +1,207 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type usageDeduper interface {
+	SetNx(key string, value any, ttl time.Duration) (bool, error)
+}
+
+// runs and run steps can be retrieved long after they finished, so they are
+// remembered for as long as threads are kept around
+const assistantUsageTtl = 60 * 24 * time.Hour
+
+var terminalRunStatuses = map[string]bool{
+	"completed":  true,
+	"failed":     true,
+	"cancelled":  true,
+	"expired":    true,
+	"incomplete": true,
+}
+
+var terminalRunStepStatuses = map[string]bool{
+	"completed": true,
+	"failed":    true,
+	"cancelled": true,
+	"expired":   true,
+}
+
+type assistantRunUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+type assistantToolCall struct {
+	Type string `json:"type"`
+}
+
+// assistantObject holds the fields of runs, run steps and lists of either that
+// usage is accounted from.
+type assistantObject struct {
+	Id          string             `json:"id"`
+	Object      string             `json:"object"`
+	Status      string             `json:"status"`
+	Model       string             `json:"model"`
+	Usage       *assistantRunUsage `json:"usage"`
+	StepDetails struct {
+		ToolCalls []assistantToolCall `json:"tool_calls"`
+	} `json:"step_details"`
+	Data []*assistantObject `json:"data"`
+}
+
+// assistantObjectsOf returns the runs and run steps of a response, which is
+// either a single object, a list or a stream of run events.
+func assistantObjectsOf(body []byte) []*assistantObject {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil
+	}
+
+	if trimmed[0] == '{' {
+		o := &assistantObject{}
+		if err := json.Unmarshal(trimmed, o); err != nil {
+			return nil
+		}
+
+		if o.Object == "list" {
+			return o.Data
+		}
+
+		return []*assistantObject{o}
+	}
+
+	objects := []*assistantObject{}
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(trimmed))
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if !bytes.HasPrefix(line, headerData) {
+			continue
+		}
+
+		data := bytes.TrimSpace(bytes.TrimPrefix(line, headerData))
+		if len(data) == 0 || data[0] != '{' {
+			continue
+		}
+
+		o := &assistantObject{}
+		if err := json.Unmarshal(data, o); err == nil {
+			objects = append(objects, o)
+		}
+	}
+
+	return objects
+}
+
+// runAccountant attributes the usage of assistant runs to the key that
+// retrieved them. Runs only report their usage once they are finished, and
+// clients usually poll them, so every run and run step is accounted for once
+// no matter how many times it is retrieved.
+type runAccountant struct {
+	e estimator
+	d usageDeduper
+}
+
+func newRunAccountant(e estimator, d usageDeduper) *runAccountant {
+	return &runAccountant{
+		e: e,
+		d: d,
+	}
+}
+
+// first reports whether an object is seen for the first time.
+func (ra *runAccountant) first(kind, id string) bool {
+	if ra.d == nil || len(id) == 0 {
+		return false
+	}
+
+	ok, err := ra.d.SetNx("assistant-usage:"+kind+":"+id, 1, assistantUsageTtl)
+	if err != nil {
+		// runs are polled, skipping one is better than counting it on every
+		// poll while redis is unavailable
+		telemetry.Incr("bricksllm.proxy.run_accountant.set_nx_error", nil, 1)
+		return false
+	}
+
+	return ok
+}
+
+// account records the token usage of finished runs and the tool calls of
+// finished run steps in a response for the event of the request.
+func (ra *runAccountant) account(c *gin.Context, log *zap.Logger, prod bool, body []byte) {
+	if ra == nil {
+		return
+	}
+
+	model := ""
+	accounted := false
+	cost := 0.0
+	promptTks, completionTks := 0, 0
+	toolCalls := map[string]int{}
+	for _, o := range assistantObjectsOf(body) {
+		switch o.Object {
+		case "thread.run":
+			if o.Usage == nil || !terminalRunStatuses[o.Status] || !ra.first("run", o.Id) {
+				continue
+			}
+
+			accounted = true
+			model = o.Model
+			promptTks += o.Usage.PromptTokens
+			completionTks += o.Usage.CompletionTokens
+
+			runCost, err := ra.e.EstimateTotalCost(o.Model, o.Usage.PromptTokens, o.Usage.CompletionTokens)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.run_accountant.estimate_total_cost_error", nil, 1)
+				logError(log, "error when estimating openai run cost", prod, err)
+			}
+
+			cost += runCost
+		case "thread.run.step":
+			if !terminalRunStepStatuses[o.Status] || !ra.first("step", o.Id) {
+				continue
+			}
+
+			accounted = true
+			for _, tc := range o.StepDetails.ToolCalls {
+				toolCalls[tc.Type]++
+			}
+		}
+	}
+
+	if !accounted {
+		return
+	}
+
+	telemetry.Incr("bricksllm.proxy.run_accountant.accounted", nil, 1)
+
+	if len(model) != 0 && len(c.GetString("model")) == 0 {
+		c.Set("model", model)
+	}
+
+	c.Set("costInUsd", cost)
+	c.Set("promptTokenCount", promptTks)
+	c.Set("completionTokenCount", completionTks)
+
+	if len(toolCalls) != 0 {
+		c.Set("toolCalls", toolCalls)
+	}
+}
+
+func toolCallsOf(c *gin.Context) map[string]int {
+	if v, ok := c.Get("toolCalls"); ok {
+		if converted, ok := v.(map[string]int); ok {
+			return converted
+		}
+	}
+
+	return nil
+}
@@ -33,7 +33,7 @@ func getSpeechHandler(prod bool, client http.Client) gin.HandlerFunc {
 		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, c.Request.Method, "https://api.openai.com/v1/audio/speech", c.Request.Body)
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), c.Request.Method, "https://api.openai.com/v1/audio/speech", c.Request.Body)
 		if err != nil {
 			logError(log, "error when creating openai http request", prod, err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create openai http request")
@@ -180,7 +180,7 @@ func getTranscriptionsHandler(prod bool, client http.Client, e estimator) gin.Ha
 		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, c.Request.Method, "https://api.openai.com/v1/audio/transcriptions", c.Request.Body)
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), c.Request.Method, "https://api.openai.com/v1/audio/transcriptions", c.Request.Body)
 		if err != nil {
 			logError(log, "error when creating transcriptions openai http request", prod, err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create openai transcriptions http request")
@@ -298,6 +298,11 @@ func getTranscriptionsHandler(prod bool, client http.Client, e estimator) gin.Ha
 				}
 
 				c.Set("costInUsd", cost)
+
+				if filterTranscript(c, log, prod, ar) {
+					JSON(c, http.StatusForbidden, "[BricksLLM] response blocked")
+					return
+				}
 			}
 
 			data, err := convertVerboseJson(ar, format)
@@ -344,7 +349,7 @@ func getTranslationsHandler(prod bool, client http.Client, e estimator) gin.Hand
 		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, c.Request.Method, "https://api.openai.com/v1/audio/translations", c.Request.Body)
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), c.Request.Method, "https://api.openai.com/v1/audio/translations", c.Request.Body)
 		if err != nil {
 			logError(log, "error when creating translations openai http request", prod, err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create openai translations http request")
@@ -458,6 +463,11 @@ func getTranslationsHandler(prod bool, client http.Client, e estimator) gin.Hand
 				}
 
 				c.Set("costInUsd", cost)
+
+				if filterTranscript(c, log, prod, ar) {
+					JSON(c, http.StatusForbidden, "[BricksLLM] response blocked")
+					return
+				}
 			}
 
 			data, err := convertVerboseJson(ar, format)
@@ -1,7 +1,6 @@
 package proxy
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -9,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/bricks-cloud/bricksllm/internal/provider"
@@ -19,11 +19,11 @@ import (
 )
 
 func buildAzureUrl(path, deploymentId, apiVersion, resourceName string) string {
-	if path == "/api/providers/azure/openai/deployments/:deployment_id/chat/completions" {
+	if strings.HasSuffix(path, "/chat/completions") {
 		return fmt.Sprintf("https://%s.openai.azure.com/openai/deployments/%s/chat/completions?api-version=%s", resourceName, deploymentId, apiVersion)
 	}
 
-	if path == "/api/providers/azure/openai/deployments/:deployment_id/completions" {
+	if strings.HasSuffix(path, "/completions") {
 		return fmt.Sprintf("https://%s.openai.azure.com/openai/deployments/%s/completions?api-version=%s", resourceName, deploymentId, apiVersion)
 	}
 
@@ -43,7 +43,7 @@ func getAzureChatCompletionHandler(prod, private bool, client http.Client, aoe a
 		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, buildAzureUrl(c.FullPath(), c.Param("deployment_id"), c.Query("api-version"), c.GetString("resourceName")), c.Request.Body)
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), http.MethodPost, buildAzureUrl(c.FullPath(), c.GetString("azureDeployment"), c.GetString("azureApiVersion"), c.GetString("resourceName")), c.Request.Body)
 		if err != nil {
 			logError(log, "error when creating azure openai http request", prod, err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create azure openai http request")
@@ -127,6 +127,9 @@ func getAzureChatCompletionHandler(prod, private bool, client http.Client, aoe a
 			c.Set("costInUsd", cost)
 			c.Set("promptTokenCount", chatRes.Usage.PromptTokens)
 			c.Set("completionTokenCount", chatRes.Usage.CompletionTokens)
+			if chatRes.Usage.CompletionTokensDetails != nil {
+				c.Set("reasoningTokenCount", chatRes.Usage.CompletionTokensDetails.ReasoningTokens)
+			}
 
 			c.Data(res.StatusCode, "application/json", bytes)
 			return
@@ -149,7 +152,7 @@ func getAzureChatCompletionHandler(prod, private bool, client http.Client, aoe a
 			return
 		}
 
-		buffer := bufio.NewReader(res.Body)
+		buffer := newSseReader(res.Body, c.GetInt("sseMaxLineSize"), "azure_chat_completion")
 		// var totalCost float64 = 0
 		// var totalTokens int = 0
 		content := ""
@@ -185,7 +188,7 @@ func getAzureChatCompletionHandler(prod, private bool, client http.Client, aoe a
 		telemetry.Incr("bricksllm.proxy.get_azure_chat_completion_handler.streaming_requests", nil, 1)
 
 		c.Stream(func(w io.Writer) bool {
-			raw, err := buffer.ReadBytes('\n')
+			raw, err := buffer.ReadLine()
 			if err != nil {
 				if err == io.EOF {
 					return false
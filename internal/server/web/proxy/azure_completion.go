@@ -1,7 +1,6 @@
 package proxy
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -79,7 +78,7 @@ func getAzureCompletionsHandler(prod, private bool, client http.Client, aoe azur
 		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, buildAzureUrl(c.FullPath(), c.Param("deployment_id"), c.Query("api-version"), c.GetString("resourceName")), c.Request.Body)
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), http.MethodPost, buildAzureUrl(c.FullPath(), c.GetString("azureDeployment"), c.GetString("azureApiVersion"), c.GetString("resourceName")), c.Request.Body)
 		if err != nil {
 			logError(log, "error when creating azure openai completions http request", prod, err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create azure openai completions http request")
@@ -185,7 +184,7 @@ func getAzureCompletionsHandler(prod, private bool, client http.Client, aoe azur
 			return
 		}
 
-		buffer := bufio.NewReader(res.Body)
+		buffer := newSseReader(res.Body, c.GetInt("sseMaxLineSize"), "azure_completion")
 		// var totalCost float64 = 0
 		// var totalTokens int = 0
 		content := ""
@@ -202,7 +201,7 @@ func getAzureCompletionsHandler(prod, private bool, client http.Client, aoe azur
 		telemetry.Incr("bricksllm.proxy.get_azure_completions_handler.streaming_requests", nil, 1)
 
 		c.Stream(func(w io.Writer) bool {
-			raw, err := buffer.ReadBytes('\n')
+			raw, err := buffer.ReadLine()
 			if err != nil {
 				if err == io.EOF {
 					return false
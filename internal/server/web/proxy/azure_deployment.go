@@ -0,0 +1,81 @@
+package proxy
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultAzureApiVersion is used when neither the client, the deployment
+// mapping nor the provider setting specify an api-version.
+const defaultAzureApiVersion = "2024-06-01"
+
+// resolveAzureDeployment decides which deployment and api-version an azure
+// openai request is sent to. Clients either call a deployment route or send a
+// standard model name to a v1 route. A deployment mapped to the model, or to
+// the deployment id of the path, wins over the path, so that callers never
+// need to know deployment names. The api-version of the client wins over the
+// one of the mapping, which wins over the one of the provider setting.
+func resolveAzureDeployment(c *gin.Context, model string) {
+	deployment := c.Param("deployment_id")
+	apiVersion := ""
+
+	var selected *provider.Setting
+	if raw, exists := c.Get("settings"); exists {
+		if settings, ok := raw.([]*provider.Setting); ok && len(settings) != 0 {
+			selected = settings[0]
+		}
+	}
+
+	if selected != nil {
+		mapped := selected.DeploymentOf(model)
+		if mapped == nil && len(deployment) != 0 {
+			mapped = selected.DeploymentOf(deployment)
+		}
+
+		if mapped != nil {
+			telemetry.Incr("bricksllm.proxy.resolve_azure_deployment.mapped", nil, 1)
+			deployment = mapped.Deployment
+			apiVersion = mapped.ApiVersion
+		}
+
+		if len(apiVersion) == 0 {
+			apiVersion = selected.GetParam("apiVersion")
+		}
+	}
+
+	if len(deployment) == 0 {
+		// without a mapping the model name is taken as the deployment name,
+		// which is how deployments are commonly named
+		deployment = model
+	}
+
+	if requested := c.Query("api-version"); len(requested) != 0 {
+		apiVersion = requested
+	}
+
+	if len(apiVersion) == 0 {
+		apiVersion = defaultAzureApiVersion
+	}
+
+	c.Set("azureDeployment", deployment)
+	c.Set("azureApiVersion", apiVersion)
+}
+
+// azureDeploymentModel returns the model behind the deployment a request was
+// resolved to, for requests that name a deployment but no model. Deployments
+// that are not mapped are taken to be named after their model.
+func azureDeploymentModel(c *gin.Context) string {
+	deployment := c.GetString("azureDeployment")
+	if raw, exists := c.Get("settings"); exists {
+		if settings, ok := raw.([]*provider.Setting); ok && len(settings) != 0 {
+			for _, d := range settings[0].Deployments {
+				if d.Deployment == deployment {
+					return d.Model
+				}
+			}
+		}
+	}
+
+	return deployment
+}
@@ -34,7 +34,7 @@ func getAzureEmbeddingsHandler(prod, private bool, client http.Client, aoe azure
 		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, c.Request.Method, buildAzureUrl(c.FullPath(), c.Param("deployment_id"), c.Query("api-version"), c.GetString("resourceName")), c.Request.Body)
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), c.Request.Method, buildAzureUrl(c.FullPath(), c.GetString("azureDeployment"), c.GetString("azureApiVersion"), c.GetString("resourceName")), c.Request.Body)
 		if err != nil {
 			logError(log, "error when creating openai http request", prod, err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create openai http request")
@@ -0,0 +1,146 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/batch"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+)
+
+type batchStorage interface {
+	InsertBatch(b *batch.Batch) error
+}
+
+type createdBatch struct {
+	Id          string `json:"id"`
+	Endpoint    string `json:"endpoint"`
+	InputFileId string `json:"input_file_id"`
+	Status      string `json:"status"`
+}
+
+// getCreateBatchHandler creates OpenAI batches and remembers the key that
+// created them, so that their usage can be billed to it once they finish.
+func getCreateBatchHandler(prod bool, client http.Client, bs batchStorage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.proxy.get_create_batch_handler.requests", nil, 1)
+
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), http.MethodPost, "https://api.openai.com/v1/batches", c.Request.Body)
+		if err != nil {
+			logError(log, "error when creating openai http request", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create openai http request")
+			return
+		}
+
+		copyHttpHeaders(c.Request, req, c.GetBool("removeUserAgent"))
+
+		start := time.Now()
+		res, err := client.Do(req)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_create_batch_handler.http_client_error", nil, 1)
+
+			logError(log, "error when sending http request to openai", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send http request to openai")
+			return
+		}
+
+		defer res.Body.Close()
+
+		for name, values := range res.Header {
+			for _, value := range values {
+				c.Header(name, value)
+			}
+		}
+
+		dur := time.Since(start)
+		bytes, err := io.ReadAll(res.Body)
+		if err != nil {
+			logError(log, "error when reading openai create batch response body", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read openai response body")
+			return
+		}
+
+		if res.StatusCode == http.StatusOK {
+			telemetry.Incr("bricksllm.proxy.get_create_batch_handler.success", nil, 1)
+			telemetry.Timing("bricksllm.proxy.get_create_batch_handler.success_latency", dur, nil, 1)
+
+			trackBatch(c, log, prod, bs, bytes)
+
+			c.Data(res.StatusCode, "application/json", bytes)
+			return
+		}
+
+		telemetry.Timing("bricksllm.proxy.get_create_batch_handler.error_latency", dur, nil, 1)
+		telemetry.Incr("bricksllm.proxy.get_create_batch_handler.error_response", nil, 1)
+
+		errorRes := &goopenai.ErrorResponse{}
+		err = json.Unmarshal(bytes, errorRes)
+		if err != nil {
+			logError(log, "error when unmarshalling openai create batch error response body", prod, err)
+		}
+
+		logOpenAiError(log, prod, errorRes)
+
+		c.Data(res.StatusCode, "application/json", bytes)
+	}
+}
+
+// trackBatch stores a created batch. Failing to do so does not fail the
+// request, since the batch already exists upstream, but its usage will not be
+// billed.
+func trackBatch(c *gin.Context, log *zap.Logger, prod bool, bs batchStorage, body []byte) {
+	if bs == nil {
+		return
+	}
+
+	created := &createdBatch{}
+	if err := json.Unmarshal(body, created); err != nil {
+		telemetry.Incr("bricksllm.proxy.track_batch.unmarshal_error", nil, 1)
+		logError(log, "error when unmarshalling openai create batch response body", prod, err)
+		return
+	}
+
+	if len(created.Id) == 0 {
+		return
+	}
+
+	keyId := ""
+	if raw, exists := c.Get("key"); exists {
+		if kc, ok := raw.(*key.ResponseKey); ok {
+			keyId = kc.KeyId
+		}
+	}
+
+	now := time.Now().Unix()
+	err := bs.InsertBatch(&batch.Batch{
+		Id:                created.Id,
+		KeyId:             keyId,
+		ProviderSettingId: c.GetString("providerSettingId"),
+		Endpoint:          created.Endpoint,
+		InputFileId:       created.InputFileId,
+		Status:            created.Status,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	})
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.track_batch.insert_batch_error", nil, 1)
+		logError(log, "error when storing openai batch", prod, err)
+	}
+}
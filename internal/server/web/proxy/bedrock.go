@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
 	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
 	"github.com/bricks-cloud/bricksllm/internal/telemetry"
 	"github.com/bricks-cloud/bricksllm/internal/util"
@@ -21,6 +23,38 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
+var errBedrockCredentialsMissing = errors.New("aws credentials are missing")
+
+// loadBedrockConfig builds the aws config of the selected bedrock provider
+// setting. Settings using the default credential source are signed with the
+// credential chain of the gateway, which picks up IRSA web identity tokens on
+// EKS as well as task and instance roles.
+func loadBedrockConfig(ctx context.Context, c *gin.Context) (aws.Config, error) {
+	region := c.GetString("awsRegion")
+	if len(region) == 0 {
+		return aws.Config{}, errBedrockCredentialsMissing
+	}
+
+	if c.GetString("awsCredentialSource") == provider.AwsCredentialSourceDefault {
+		return config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	}
+
+	keyId := c.GetString("awsAccessKeyId")
+	secretKey := c.GetString("awsSecretAccessKey")
+	if len(keyId) == 0 || len(secretKey) == 0 {
+		return aws.Config{}, errBedrockCredentialsMissing
+	}
+
+	return config.LoadDefaultConfig(ctx,
+		config.WithCredentialsProvider(credentials.StaticCredentialsProvider{
+			Value: aws.Credentials{
+				AccessKeyID: keyId, SecretAccessKey: secretKey,
+				Source: "BricksLLM Credentials",
+			},
+		}),
+		config.WithRegion(region))
+}
+
 func setAnthropicVersionIfExists(version string, req *anthropic.BedrockMessageRequest) {
 	if req != nil && len(version) > 0 {
 		req.AnthropicVersion = version
@@ -71,28 +105,16 @@ func getBedrockCompletionHandler(prod bool, e anthropicEstimator) gin.HandlerFun
 			return
 		}
 
-		keyId := c.GetString("awsAccessKeyId")
-		secretKey := c.GetString("awsSecretAccessKey")
-		region := c.GetString("awsRegion")
-
-		if len(keyId) == 0 || len(secretKey) == 0 || len(region) == 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+		defer cancel()
+		cfg, err := loadBedrockConfig(ctx, c)
+		if err == errBedrockCredentialsMissing {
 			telemetry.Incr("bricksllm.proxy.get_bedrock_completion_handler.auth_error", nil, 1)
 			log.Error("key id, secret key or region is missing", []zapcore.Field{zap.Error(err)}...)
 			JSON(c, http.StatusUnauthorized, "[BricksLLM] auth credentials are missing")
 			return
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
-		defer cancel()
-		cfg, err := config.LoadDefaultConfig(ctx,
-			config.WithCredentialsProvider(credentials.StaticCredentialsProvider{
-				Value: aws.Credentials{
-					AccessKeyID: keyId, SecretAccessKey: secretKey,
-					Source: "BricksLLM Credentials",
-				},
-			}),
-			config.WithRegion(region))
-
 		if err != nil {
 			telemetry.Incr("bricksllm.proxy.get_bedrock_completion_handler.aws_config_creation_error", nil, 1)
 			log.Error("error when creating aws config", []zapcore.Field{zap.Error(err)}...)
@@ -100,7 +122,8 @@ func getBedrockCompletionHandler(prod bool, e anthropicEstimator) gin.HandlerFun
 			return
 		}
 
-		client := bedrockruntime.NewFromConfig(cfg)
+		recorder := &signingRecorder{}
+		client := bedrockruntime.NewFromConfig(cfg, recorder.withBedrockOptions)
 		stream := c.GetBool("stream")
 
 		ctx, cancel = context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
@@ -109,11 +132,12 @@ func getBedrockCompletionHandler(prod bool, e anthropicEstimator) gin.HandlerFun
 		start := time.Now()
 
 		if !stream {
-			output, err := client.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
+			output, err := client.InvokeModel(traceUpstream(ctx, c), &bedrockruntime.InvokeModelInput{
 				ModelId:     &anthropicReq.Model,
 				ContentType: aws.String("application/json"),
 				Body:        bs,
 			})
+			recorder.set(c, err)
 
 			if err != nil {
 				telemetry.Incr("bricksllm.proxy.get_bedrock_completion_handler.error_response", nil, 1)
@@ -142,11 +166,12 @@ func getBedrockCompletionHandler(prod bool, e anthropicEstimator) gin.HandlerFun
 
 		telemetry.Incr("bricksllm.proxy.get_bedrock_completion_handler.streaming_requests", nil, 1)
 
-		streamOutput, err := client.InvokeModelWithResponseStream(ctx, &bedrockruntime.InvokeModelWithResponseStreamInput{
+		streamOutput, err := client.InvokeModelWithResponseStream(traceUpstream(ctx, c), &bedrockruntime.InvokeModelWithResponseStreamInput{
 			ModelId:     &anthropicReq.Model,
 			ContentType: aws.String("application/json"),
 			Body:        bs,
 		})
+		recorder.set(c, err)
 
 		if err != nil {
 			telemetry.Incr("bricksllm.proxy.get_bedrock_completion_handler.invoking_model_with_streaming_response_error", nil, 1)
@@ -324,28 +349,16 @@ func getBedrockMessagesHandler(prod bool, e anthropicEstimator) gin.HandlerFunc
 			return
 		}
 
-		keyId := c.GetString("awsAccessKeyId")
-		secretKey := c.GetString("awsSecretAccessKey")
-		region := c.GetString("awsRegion")
-
-		if len(keyId) == 0 || len(secretKey) == 0 || len(region) == 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+		defer cancel()
+		cfg, err := loadBedrockConfig(ctx, c)
+		if err == errBedrockCredentialsMissing {
 			telemetry.Incr("bricksllm.proxy.get_bedrock_messages_handler.auth_error", nil, 1)
 			log.Error("key id, secret key or region is missing", []zapcore.Field{zap.Error(err)}...)
 			JSON(c, http.StatusUnauthorized, "[BricksLLM] auth credentials are missing")
 			return
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
-		defer cancel()
-		cfg, err := config.LoadDefaultConfig(ctx,
-			config.WithCredentialsProvider(credentials.StaticCredentialsProvider{
-				Value: aws.Credentials{
-					AccessKeyID: keyId, SecretAccessKey: secretKey,
-					Source: "BricksLLM Credentials",
-				},
-			}),
-			config.WithRegion(region))
-
 		if err != nil {
 			telemetry.Incr("bricksllm.proxy.get_bedrock_messages_handler.aws_config_creation_error", nil, 1)
 			log.Error("error when creating aws config", []zapcore.Field{zap.Error(err)}...)
@@ -353,7 +366,8 @@ func getBedrockMessagesHandler(prod bool, e anthropicEstimator) gin.HandlerFunc
 			return
 		}
 
-		client := bedrockruntime.NewFromConfig(cfg)
+		recorder := &signingRecorder{}
+		client := bedrockruntime.NewFromConfig(cfg, recorder.withBedrockOptions)
 		stream := c.GetBool("stream")
 
 		ctx, cancel = context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
@@ -362,11 +376,12 @@ func getBedrockMessagesHandler(prod bool, e anthropicEstimator) gin.HandlerFunc
 		start := time.Now()
 
 		if !stream {
-			output, err := client.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
+			output, err := client.InvokeModel(traceUpstream(ctx, c), &bedrockruntime.InvokeModelInput{
 				ModelId:     &anthropicReq.Model,
 				ContentType: aws.String("application/json"),
 				Body:        bs,
 			})
+			recorder.set(c, err)
 
 			if err != nil {
 				telemetry.Incr("bricksllm.proxy.get_bedrock_messages_handler.error_response", nil, 1)
@@ -415,12 +430,13 @@ func getBedrockMessagesHandler(prod bool, e anthropicEstimator) gin.HandlerFunc
 
 		telemetry.Incr("bricksllm.proxy.get_bedrock_messages_handler.streaming_requests", nil, 1)
 
-		streamOutput, err := client.InvokeModelWithResponseStream(ctx, &bedrockruntime.InvokeModelWithResponseStreamInput{
+		streamOutput, err := client.InvokeModelWithResponseStream(traceUpstream(ctx, c), &bedrockruntime.InvokeModelWithResponseStreamInput{
 			ModelId:     &anthropicReq.Model,
 			ContentType: aws.String("application/json"),
 			Accept:      aws.String("application/json"),
 			Body:        bs,
 		})
+		recorder.set(c, err)
 
 		if err != nil {
 			telemetry.Incr("bricksllm.proxy.get_bedrock_messages_handler.invoking_model_with_streaming_response_error", nil, 1)
@@ -0,0 +1,220 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/bricks-cloud/bricksllm/internal/provider/bedrock"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/tidwall/sjson"
+)
+
+type bedrockEstimator interface {
+	EstimateTotalCost(model string, promptTks, completionTks int) (float64, error)
+}
+
+// getBedrockChatCompletionHandler serves bedrock models behind the OpenAI chat
+// completion shape using the Converse APIs, which work the same way across
+// Claude, Titan and the other bedrock model families.
+func getBedrockChatCompletionHandler(prod, private bool, e bedrockEstimator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.proxy.get_bedrock_chat_completion_handler.requests", nil, 1)
+
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_bedrock_chat_completion_handler.read_all_error", nil, 1)
+			logError(log, "error when reading bedrock chat completion request body", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read request body")
+			return
+		}
+
+		ccr := &goopenai.ChatCompletionRequest{}
+		cleaned, err := sjson.DeleteBytes(body, "response_format.json_schema")
+		if err != nil {
+			cleaned = body
+		}
+
+		if err := json.Unmarshal(cleaned, ccr); err != nil {
+			telemetry.Incr("bricksllm.proxy.get_bedrock_chat_completion_handler.unmarshal_chat_completion_request_error", nil, 1)
+			logError(log, "error when unmarshalling bedrock chat completion request", prod, err)
+			JSON(c, http.StatusBadRequest, "[BricksLLM] failed to unmarshal chat completion request")
+			return
+		}
+
+		input, err := bedrock.FromChatCompletionRequest(ccr)
+		if err != nil {
+			JSON(c, http.StatusBadRequest, "[BricksLLM] "+err.Error())
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+		defer cancel()
+
+		cfg, err := loadBedrockConfig(ctx, c)
+		if err == errBedrockCredentialsMissing {
+			telemetry.Incr("bricksllm.proxy.get_bedrock_chat_completion_handler.auth_error", nil, 1)
+			JSON(c, http.StatusUnauthorized, "[BricksLLM] auth credentials are missing")
+			return
+		}
+
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_bedrock_chat_completion_handler.aws_config_creation_error", nil, 1)
+			logError(log, "error when creating aws config", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create aws config")
+			return
+		}
+
+		recorder := &signingRecorder{}
+		client := bedrockruntime.NewFromConfig(cfg, recorder.withBedrockOptions)
+
+		id := "chatcmpl-" + util.NewUuid()
+		model := ccr.Model
+		start := time.Now()
+
+		if !ccr.Stream {
+			output, err := client.Converse(traceUpstream(ctx, c), input)
+			recorder.set(c, err)
+
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_bedrock_chat_completion_handler.error_response", nil, 1)
+				telemetry.Timing("bricksllm.proxy.get_bedrock_chat_completion_handler.error_latency", time.Since(start), nil, 1)
+
+				logError(log, "error when calling bedrock converse", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to invoke bedrock model")
+				return
+			}
+
+			telemetry.Incr("bricksllm.proxy.get_bedrock_chat_completion_handler.success", nil, 1)
+			telemetry.Timing("bricksllm.proxy.get_bedrock_chat_completion_handler.success_latency", time.Since(start), nil, 1)
+
+			res := bedrock.ToChatCompletionResponse(id, model, output)
+			logChatCompletionResponse(log, prod, private, res)
+
+			cost, err := e.EstimateTotalCost(model, res.Usage.PromptTokens, res.Usage.CompletionTokens)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_bedrock_chat_completion_handler.estimate_total_cost_error", nil, 1)
+				logError(log, "error when estimating bedrock cost", prod, err)
+			}
+
+			c.Set("costInUsd", cost)
+			c.Set("promptTokenCount", res.Usage.PromptTokens)
+			c.Set("completionTokenCount", res.Usage.CompletionTokens)
+			if len(res.Choices) != 0 {
+				c.Set("content", res.Choices[0].Message.Content)
+			}
+
+			c.JSON(http.StatusOK, res)
+			return
+		}
+
+		telemetry.Incr("bricksllm.proxy.get_bedrock_chat_completion_handler.streaming_requests", nil, 1)
+
+		streamOutput, err := client.ConverseStream(traceUpstream(ctx, c), bedrock.ToStreamInput(input))
+		recorder.set(c, err)
+
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_bedrock_chat_completion_handler.converse_stream_error", nil, 1)
+
+			logError(log, "error when calling bedrock converse stream", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to invoke model request with stream response")
+			return
+		}
+
+		stream := streamOutput.GetStream()
+		defer stream.Close()
+
+		content := ""
+		streamingResponse := [][]byte{}
+		usage := goopenai.Usage{}
+		defer func() {
+			cost, err := e.EstimateTotalCost(model, usage.PromptTokens, usage.CompletionTokens)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_bedrock_chat_completion_handler.estimate_total_cost_error", nil, 1)
+				logError(log, "error when estimating bedrock streaming cost", prod, err)
+			}
+
+			c.Set("costInUsd", cost)
+			c.Set("promptTokenCount", usage.PromptTokens)
+			c.Set("completionTokenCount", usage.CompletionTokens)
+			c.Set("content", content)
+			c.Set("streaming_response", bytes.Join(streamingResponse, []byte{'\n'}))
+		}()
+
+		chunk := &goopenai.ChatCompletionStreamResponse{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: time.Now().Unix(),
+			Model:   model,
+		}
+
+		send := func(delta goopenai.ChatCompletionStreamChoiceDelta, finishReason goopenai.FinishReason) {
+			chunk.Choices = []goopenai.ChatCompletionStreamChoice{{
+				Delta:        delta,
+				FinishReason: finishReason,
+			}}
+
+			data, err := json.Marshal(chunk)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_bedrock_chat_completion_handler.json_marshal_error", nil, 1)
+				logError(log, "error when marshalling bedrock chat completion chunk", prod, err)
+				return
+			}
+
+			streamingResponse = append(streamingResponse, data)
+			c.SSEvent("", " "+string(data))
+		}
+
+		var finishReason goopenai.FinishReason
+		c.Stream(func(w io.Writer) bool {
+			ev, ok := <-stream.Events()
+			if !ok {
+				if err := stream.Err(); err != nil {
+					telemetry.Incr("bricksllm.proxy.get_bedrock_chat_completion_handler.stream_error", nil, 1)
+					logError(log, "error when reading bedrock converse stream", prod, err)
+				}
+
+				if len(finishReason) != 0 {
+					send(goopenai.ChatCompletionStreamChoiceDelta{}, finishReason)
+				}
+
+				c.SSEvent("", " [DONE]")
+				return false
+			}
+
+			switch v := ev.(type) {
+			case *types.ConverseStreamOutputMemberMessageStart:
+				send(goopenai.ChatCompletionStreamChoiceDelta{Role: goopenai.ChatMessageRoleAssistant}, "")
+			case *types.ConverseStreamOutputMemberContentBlockDelta:
+				if text, ok := v.Value.Delta.(*types.ContentBlockDeltaMemberText); ok && len(text.Value) != 0 {
+					content += text.Value
+					send(goopenai.ChatCompletionStreamChoiceDelta{Content: text.Value}, "")
+				}
+			case *types.ConverseStreamOutputMemberMessageStop:
+				// usage arrives in the metadata event after the stop, so the
+				// finish reason is held back until the stream ends
+				finishReason = bedrock.FinishReasonOf(v.Value.StopReason)
+			case *types.ConverseStreamOutputMemberMetadata:
+				usage = bedrock.Usage(v.Value.Usage)
+			}
+
+			return true
+		})
+
+		telemetry.Timing("bricksllm.proxy.get_bedrock_chat_completion_handler.streaming_latency", time.Since(start), nil, 1)
+	}
+}
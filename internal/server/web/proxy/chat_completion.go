@@ -1,7 +1,6 @@
 package proxy
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -17,7 +16,7 @@ import (
 	goopenai "github.com/sashabaranov/go-openai"
 )
 
-func getChatCompletionHandler(prod, private bool, client http.Client, e estimator) gin.HandlerFunc {
+func getChatCompletionHandler(prod, private bool, client http.Client, e estimator, tb ToolBroker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := util.GetLogFromCtx(c)
 		telemetry.Incr("bricksllm.proxy.get_chat_completion_handler.requests", nil, 1)
@@ -27,10 +26,15 @@ func getChatCompletionHandler(prod, private bool, client http.Client, e estimato
 			return
 		}
 
+		if names := c.GetHeader(toolsHeader); len(names) != 0 {
+			brokerChatCompletion(c, prod, private, client, e, tb, names)
+			return
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.openai.com/v1/chat/completions", c.Request.Body)
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), http.MethodPost, "https://api.openai.com/v1/chat/completions", c.Request.Body)
 		if err != nil {
 			logError(log, "error when creating openai http request", prod, err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create azure openai http request")
@@ -115,6 +119,9 @@ func getChatCompletionHandler(prod, private bool, client http.Client, e estimato
 			c.Set("costInUsd", cost)
 			c.Set("promptTokenCount", chatRes.Usage.PromptTokens)
 			c.Set("completionTokenCount", chatRes.Usage.CompletionTokens)
+			if chatRes.Usage.CompletionTokensDetails != nil {
+				c.Set("reasoningTokenCount", chatRes.Usage.CompletionTokensDetails.ReasoningTokens)
+			}
 
 			c.Data(res.StatusCode, "application/json", bytes)
 			return
@@ -144,7 +151,7 @@ func getChatCompletionHandler(prod, private bool, client http.Client, e estimato
 			return
 		}
 
-		buffer := bufio.NewReader(res.Body)
+		buffer := newSseReader(res.Body, c.GetInt("sseMaxLineSize"), "openai_chat_completion")
 		content := ""
 		streamingResponse := [][]byte{}
 		defer func() {
@@ -156,7 +163,7 @@ func getChatCompletionHandler(prod, private bool, client http.Client, e estimato
 		telemetry.Incr("bricksllm.proxy.get_chat_completion_handler.streaming_requests", nil, 1)
 
 		c.Stream(func(w io.Writer) bool {
-			raw, err := buffer.ReadBytes('\n')
+			raw, err := buffer.ReadLine()
 			if err != nil {
 				if err == io.EOF {
 					return false
@@ -0,0 +1,267 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+type cohereEstimator interface {
+	EstimateTotalCost(model string, promptTks, completionTks int) (float64, error)
+	EstimateEmbeddingsCost(model string, tks int) (float64, error)
+	EstimateRerankCost(model string, searches int) (float64, error)
+}
+
+// sendCohereRequest forwards the request of c to a cohere v2 endpoint and
+// copies the response headers back.
+func sendCohereRequest(ctx context.Context, c *gin.Context, client http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(traceUpstream(ctx, c), http.MethodPost, url, c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	copyHttpHeaders(c.Request, req, c.GetBool("removeUserAgent"))
+
+	if c.GetBool("stream") {
+		req.Header.Set("Accept", "text/event-stream")
+		req.Header.Set("Cache-Control", "no-cache")
+		req.Header.Set("Connection", "keep-alive")
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range res.Header {
+		for _, value := range values {
+			c.Header(name, value)
+		}
+	}
+
+	return res, nil
+}
+
+// cohereBilledTokens reads the billed tokens of a cohere usage object. Billed
+// units are what cohere charges for, so they are preferred over token counts.
+func cohereBilledTokens(usage gjson.Result) (int, int) {
+	billed := usage.Get("billed_units")
+	if !billed.Exists() {
+		billed = usage.Get("tokens")
+	}
+
+	return int(billed.Get("input_tokens").Int()), int(billed.Get("output_tokens").Int())
+}
+
+func getCohereChatHandler(prod bool, client http.Client, e cohereEstimator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.proxy.get_cohere_chat_handler.requests", nil, 1)
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+		defer cancel()
+
+		start := time.Now()
+		res, err := sendCohereRequest(ctx, c, client, "https://api.cohere.com/v2/chat")
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_cohere_chat_handler.http_client_error", nil, 1)
+
+			logError(log, "error when sending http request to cohere", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send http request to cohere")
+			return
+		}
+
+		defer res.Body.Close()
+
+		model := c.GetString("model")
+
+		if res.StatusCode != http.StatusOK || !c.GetBool("stream") {
+			dur := time.Since(start)
+			telemetry.Timing("bricksllm.proxy.get_cohere_chat_handler.latency", dur, nil, 1)
+
+			bytes, err := io.ReadAll(res.Body)
+			if err != nil {
+				logError(log, "error when reading cohere chat response body", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read cohere response body")
+				return
+			}
+
+			if res.StatusCode != http.StatusOK {
+				telemetry.Incr("bricksllm.proxy.get_cohere_chat_handler.error_response", nil, 1)
+				telemetry.Timing("bricksllm.proxy.get_cohere_chat_handler.error_latency", dur, nil, 1)
+				logError(log, "cohere chat responded with an error", prod, errors.New(gjson.GetBytes(bytes, "message").String()))
+
+				c.Data(res.StatusCode, "application/json", bytes)
+				return
+			}
+
+			telemetry.Incr("bricksllm.proxy.get_cohere_chat_handler.success", nil, 1)
+			telemetry.Timing("bricksllm.proxy.get_cohere_chat_handler.success_latency", dur, nil, 1)
+
+			promptTks, completionTks := cohereBilledTokens(gjson.GetBytes(bytes, "usage"))
+			cost, err := estimateTokenCost(c, e, model, promptTks, completionTks)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_cohere_chat_handler.estimate_total_cost_error", nil, 1)
+				logError(log, "error when estimating cohere chat cost", prod, err)
+			}
+
+			c.Set("costInUsd", cost)
+			c.Set("promptTokenCount", promptTks)
+			c.Set("completionTokenCount", completionTks)
+			c.Set("content", gjson.GetBytes(bytes, "message.content.0.text").String())
+
+			c.Data(res.StatusCode, "application/json", bytes)
+			return
+		}
+
+		buffer := newSseReader(res.Body, c.GetInt("sseMaxLineSize"), "cohere_chat")
+		content := ""
+		streamingResponse := [][]byte{}
+		promptTks, completionTks := 0, 0
+		defer func() {
+			cost, err := estimateTokenCost(c, e, model, promptTks, completionTks)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_cohere_chat_handler.estimate_total_cost_error", nil, 1)
+				logError(log, "error when estimating cohere streaming chat cost", prod, err)
+			}
+
+			c.Set("costInUsd", cost)
+			c.Set("promptTokenCount", promptTks)
+			c.Set("completionTokenCount", completionTks)
+			c.Set("content", content)
+			c.Set("streaming_response", bytes.Join(streamingResponse, []byte{'\n'}))
+		}()
+
+		telemetry.Incr("bricksllm.proxy.get_cohere_chat_handler.streaming_requests", nil, 1)
+
+		// cohere names its events, so lines are relayed as they are instead of
+		// being re-encoded as data only events
+		c.Stream(func(w io.Writer) bool {
+			raw, err := buffer.ReadLine()
+			if len(raw) != 0 {
+				if _, werr := w.Write(raw); werr != nil {
+					return false
+				}
+			}
+
+			if err != nil {
+				if err != io.EOF {
+					telemetry.Incr("bricksllm.proxy.get_cohere_chat_handler.read_bytes_error", nil, 1)
+					logError(log, "error when reading bytes from cohere chat response", prod, err)
+				}
+
+				return false
+			}
+
+			streamingResponse = append(streamingResponse, raw)
+
+			noSpaceLine := bytes.TrimSpace(raw)
+			if !bytes.HasPrefix(noSpaceLine, headerData) {
+				return true
+			}
+
+			data := gjson.ParseBytes(bytes.TrimPrefix(noSpaceLine, headerData))
+			switch data.Get("type").String() {
+			case "content-delta":
+				content += data.Get("delta.message.content.text").String()
+			case "message-end":
+				promptTks, completionTks = cohereBilledTokens(data.Get("delta.usage"))
+			}
+
+			return true
+		})
+
+		telemetry.Timing("bricksllm.proxy.get_cohere_chat_handler.streaming_latency", time.Since(start), nil, 1)
+	}
+}
+
+// getCohereBilledHandler proxies cohere endpoints billed from the meta of the
+// response, which are embed and rerank.
+func getCohereBilledHandler(name, url string, prod bool, client http.Client, estimate func(c *gin.Context, model string, meta gjson.Result) (float64, int, error)) gin.HandlerFunc {
+	metric := "bricksllm.proxy.get_cohere_" + name + "_handler."
+
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr(metric+"requests", nil, 1)
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+		defer cancel()
+
+		start := time.Now()
+		res, err := sendCohereRequest(ctx, c, client, url)
+		if err != nil {
+			telemetry.Incr(metric+"http_client_error", nil, 1)
+
+			logError(log, "error when sending http request to cohere", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send http request to cohere")
+			return
+		}
+
+		defer res.Body.Close()
+
+		dur := time.Since(start)
+		telemetry.Timing(metric+"latency", dur, nil, 1)
+
+		bytes, err := io.ReadAll(res.Body)
+		if err != nil {
+			logError(log, "error when reading cohere "+name+" response body", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read cohere response body")
+			return
+		}
+
+		if res.StatusCode != http.StatusOK {
+			telemetry.Incr(metric+"error_response", nil, 1)
+			logError(log, "cohere "+name+" responded with an error", prod, errors.New(gjson.GetBytes(bytes, "message").String()))
+
+			c.Data(res.StatusCode, "application/json", bytes)
+			return
+		}
+
+		telemetry.Incr(metric+"success", nil, 1)
+		telemetry.Timing(metric+"success_latency", dur, nil, 1)
+
+		cost, promptTks, err := estimate(c, c.GetString("model"), gjson.GetBytes(bytes, "meta"))
+		if err != nil {
+			telemetry.Incr(metric+"estimate_cost_error", nil, 1)
+			logError(log, "error when estimating cohere "+name+" cost", prod, err)
+		}
+
+		c.Set("costInUsd", cost)
+		c.Set("promptTokenCount", promptTks)
+
+		c.Data(res.StatusCode, "application/json", bytes)
+	}
+}
+
+func getCohereEmbedHandler(prod bool, client http.Client, e cohereEstimator) gin.HandlerFunc {
+	return getCohereBilledHandler("embed", "https://api.cohere.com/v2/embed", prod, client, func(c *gin.Context, model string, meta gjson.Result) (float64, int, error) {
+		tks := int(meta.Get("billed_units.input_tokens").Int())
+		cost, err := estimateEmbeddingsCost(c, e, model, tks)
+
+		return cost, tks, err
+	})
+}
+
+func getCohereRerankHandler(prod bool, client http.Client, e cohereEstimator) gin.HandlerFunc {
+	return getCohereBilledHandler("rerank", "https://api.cohere.com/v2/rerank", prod, client, func(c *gin.Context, model string, meta gjson.Result) (float64, int, error) {
+		cost, err := e.EstimateRerankCost(model, int(meta.Get("billed_units.search_units").Int()))
+
+		return cost, 0, err
+	})
+}
@@ -1,7 +1,6 @@
 package proxy
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -16,6 +15,7 @@ import (
 	"github.com/bricks-cloud/bricksllm/internal/util"
 	"github.com/gin-gonic/gin"
 	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
 )
 
 func getContentFromJson(bytes []byte, contentLoc string) string {
@@ -77,7 +77,18 @@ func getCustomProviderHandler(prod bool, client http.Client) gin.HandlerFunc {
 			return
 		}
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, rc.TargetUrl, io.NopCloser(bytes.NewReader(body)))
+		original := body
+		if len(rc.RequestTemplate) != 0 {
+			body, err = custom.Transform(rc.RequestTemplate, body)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_custom_provider_handler.transform_request_error", tags, 1)
+				logError(logWithCid, "error when transforming custom provider request", prod, err)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] request cannot be mapped by the request template of the route config")
+				return
+			}
+		}
+
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), http.MethodPost, rc.TargetUrl, io.NopCloser(bytes.NewReader(body)))
 		if err != nil {
 			logError(logWithCid, "error when creating custom provider http request", prod, err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create custom provider http request")
@@ -116,6 +127,7 @@ func getCustomProviderHandler(prod bool, client http.Client) gin.HandlerFunc {
 			}
 
 			c.Set("response", bytes)
+			setReportedTokenCounts(c, rc, bytes)
 
 			// tks, err := countTokensFromJson(bytes, rc.ResponseCompletionLocation)
 			// if err != nil {
@@ -123,6 +135,18 @@ func getCustomProviderHandler(prod bool, client http.Client) gin.HandlerFunc {
 			// }
 
 			// c.Set("completionTokenCount", tks)
+			if len(rc.ResponseTemplate) != 0 {
+				transformed, err := custom.Transform(rc.ResponseTemplate, bytes)
+				if err != nil {
+					telemetry.Incr("bricksllm.proxy.get_custom_provider_handler.transform_response_error", tags, 1)
+					logError(logWithCid, "error when transforming custom provider response", prod, err)
+					JSON(c, http.StatusBadGateway, "[BricksLLM] response cannot be mapped by the response template of the route config")
+					return
+				}
+
+				bytes = transformed
+			}
+
 			c.Data(res.StatusCode, "application/json", bytes)
 			return
 		}
@@ -143,26 +167,41 @@ func getCustomProviderHandler(prod bool, client http.Client) gin.HandlerFunc {
 			return
 		}
 
-		buffer := bufio.NewReader(res.Body)
+		buffer := newSseReader(res.Body, c.GetInt("sseMaxLineSize"), "custom_provider")
 		aggregated := ""
 		streamingResponse := [][]byte{}
 		defer func() {
 			c.Set("content", aggregated)
 			c.Set("streaming_response", bytes.Join(streamingResponse, []byte{'\n'}))
 
-			// tks, err := custom.Count(aggregated)
-			// if err != nil {
-			// 	telemetry.Incr("bricksllm.proxy.get_custom_provider_handler.count_error", nil, 1)
-			// 	logError(log, "error when counting tokens for custom provider streaming response", prod, err)
-			// }
+			// upstreams that do not report usage in their streams are billed
+			// for the estimated tokens of the prompt and of the deltas
+			if _, ok := c.Get("promptTokenCount"); !ok {
+				estimateTokenCount(c, logWithCid, prod, "promptTokenCount", getContentFromJson(original, rc.RequestPromptLocation))
+			}
 
-			// c.Set("completionTokenCount", tks)
+			if _, ok := c.Get("completionTokenCount"); !ok {
+				estimateTokenCount(c, logWithCid, prod, "completionTokenCount", aggregated)
+			}
 		}()
 
+		delimiter := []byte(rc.StreamDelimiter)
+		if len(delimiter) == 0 {
+			delimiter = []byte("data:")
+		}
+
+		contentType := res.Header.Get("Content-Type")
+		if len(contentType) == 0 {
+			contentType = "text/event-stream"
+		}
+
+		c.Header("Content-Type", contentType)
+		c.Header("Cache-Control", "no-cache")
+
 		telemetry.Incr("bricksllm.proxy.get_custom_provider_handler.streaming_requests", nil, 1)
 
 		c.Stream(func(w io.Writer) bool {
-			raw, err := buffer.ReadBytes('\n')
+			raw, err := buffer.ReadLine()
 			if err != nil {
 				if err == io.EOF {
 					return false
@@ -198,24 +237,61 @@ func getCustomProviderHandler(prod bool, client http.Client) gin.HandlerFunc {
 
 			streamingResponse = append(streamingResponse, raw)
 
+			// lines are passed through as they are, so that clients get the
+			// stream in the shape the upstream sends it
+			if _, err := w.Write(raw); err != nil {
+				telemetry.Incr("bricksllm.proxy.get_custom_provider_handler.write_error", nil, 1)
+				return false
+			}
+
 			noSpaceLine := bytes.TrimSpace(raw)
-			if !bytes.HasPrefix(noSpaceLine, headerData) {
+			if !bytes.HasPrefix(noSpaceLine, delimiter) {
 				return true
 			}
 
-			noPrefixLine := bytes.TrimPrefix(noSpaceLine, headerData)
-			c.SSEvent("", " "+string(noPrefixLine))
-
-			if string(noPrefixLine) == rc.StreamEndWord {
+			data := bytes.TrimSpace(bytes.TrimPrefix(noSpaceLine, delimiter))
+			if string(data) == rc.StreamEndWord {
 				return false
 			}
 
-			content := getContentFromJson(noPrefixLine, rc.StreamResponseCompletionLocation)
+			content := getContentFromJson(data, rc.StreamResponseCompletionLocation)
 			aggregated += content
 
+			setReportedTokenCounts(c, rc, data)
+
 			return true
 		})
 
 		telemetry.Timing("bricksllm.proxy.get_custom_provider_handler.streaming_latency", time.Since(start), nil, 1)
 	}
 }
+
+// estimateTokenCount sets the token count of text under name, unless there is
+// no text to count.
+func estimateTokenCount(c *gin.Context, log *zap.Logger, prod bool, name, text string) {
+	if len(text) == 0 {
+		return
+	}
+
+	tks, err := custom.Count(text)
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.get_custom_provider_handler.count_error", nil, 1)
+		logError(log, "error when counting tokens for custom provider streaming response", prod, err)
+		return
+	}
+
+	c.Set(name, tks)
+}
+
+// setReportedTokenCounts uses the token counts that an upstream reports in a
+// response or in a chunk of a stream, which usually carries them in its last
+// chunk.
+func setReportedTokenCounts(c *gin.Context, rc *custom.RouteConfig, body []byte) {
+	if tks, ok := custom.TokenCount(body, rc.PromptTokensLocation); ok {
+		c.Set("promptTokenCount", tks)
+	}
+
+	if tks, ok := custom.TokenCount(body, rc.CompletionTokensLocation); ok {
+		c.Set("completionTokenCount", tks)
+	}
+}
@@ -1,7 +1,6 @@
 package proxy
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -29,7 +28,7 @@ func getDeepinfraCompletionsHandler(prod, private bool, client http.Client) gin.
 		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.deepinfra.com/v1/openai/completions", c.Request.Body)
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), http.MethodPost, "https://api.deepinfra.com/v1/openai/completions", c.Request.Body)
 		if err != nil {
 			logError(log, "error when creating deepinfra http request", prod, err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create deepinfra http request")
@@ -140,7 +139,7 @@ func getDeepinfraCompletionsHandler(prod, private bool, client http.Client) gin.
 			return
 		}
 
-		buffer := bufio.NewReader(res.Body)
+		buffer := newSseReader(res.Body, c.GetInt("sseMaxLineSize"), "deepinfra_completion")
 		content := ""
 		streamingResponse := [][]byte{}
 		defer func() {
@@ -151,7 +150,7 @@ func getDeepinfraCompletionsHandler(prod, private bool, client http.Client) gin.
 		telemetry.Incr("bricksllm.proxy.get_deepinfra_completions_handler.streaming_requests", nil, 1)
 
 		c.Stream(func(w io.Writer) bool {
-			raw, err := buffer.ReadBytes('\n')
+			raw, err := buffer.ReadLine()
 			if err != nil {
 				if err == io.EOF {
 					return false
@@ -233,7 +232,7 @@ func getDeepinfraChatCompletionsHandler(prod, private bool, client http.Client)
 		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.deepinfra.com/v1/openai/chat/completions", c.Request.Body)
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), http.MethodPost, "https://api.deepinfra.com/v1/openai/chat/completions", c.Request.Body)
 		if err != nil {
 			logError(log, "error when creating deepinfra chat completions http request", prod, err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create deepinfra http request")
@@ -336,7 +335,7 @@ func getDeepinfraChatCompletionsHandler(prod, private bool, client http.Client)
 			return
 		}
 
-		buffer := bufio.NewReader(res.Body)
+		buffer := newSseReader(res.Body, c.GetInt("sseMaxLineSize"), "deepinfra_chat_completion")
 		content := ""
 		streamingResponse := [][]byte{}
 		defer func() {
@@ -347,7 +346,7 @@ func getDeepinfraChatCompletionsHandler(prod, private bool, client http.Client)
 		telemetry.Incr("bricksllm.proxy.get_deepinfra_chat_completions_handler.streaming_requests", nil, 1)
 
 		c.Stream(func(w io.Writer) bool {
-			raw, err := buffer.ReadBytes('\n')
+			raw, err := buffer.ReadLine()
 			if err != nil {
 				if err == io.EOF {
 					return false
@@ -428,7 +427,7 @@ func getDeepinfraEmbeddingsHandler(prod, private bool, client http.Client, e dee
 		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.deepinfra.com/v1/openai/embeddings", c.Request.Body)
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), http.MethodPost, "https://api.deepinfra.com/v1/openai/embeddings", c.Request.Body)
 		if err != nil {
 			logError(log, "error when creating deepinfra embeddings http request", prod, err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create deepinfra http request")
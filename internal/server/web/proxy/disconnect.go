@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"sync"
+	"time"
+)
+
+// StatusClientClosedRequest is recorded for requests whose client went away
+// before a response was written.
+const StatusClientClosedRequest = 499
+
+type DisconnectConfig struct {
+	// Threshold is the number of client disconnects within Window that trips a key. Zero disables detection.
+	Threshold int
+	// Ratio is the minimum share of requests within Window that have to be disconnects for a key to trip.
+	Ratio float64
+	// Window is the period over which requests and disconnects are counted.
+	Window time.Duration
+	// Cooldown is how long upstream calls of a tripped key are short circuited.
+	Cooldown time.Duration
+}
+
+type keyDisconnects struct {
+	windowStart  time.Time
+	requests     int
+	disconnects  int
+	trippedUntil time.Time
+	lastSeen     time.Time
+}
+
+// disconnectGuard detects keys whose clients abandon most of their requests,
+// which usually means an app is crash looping, and stops paying upstream for them.
+type disconnectGuard struct {
+	cfg       DisconnectConfig
+	lock      sync.Mutex
+	states    map[string]*keyDisconnects
+	lastPrune time.Time
+}
+
+func newDisconnectGuard(cfg *DisconnectConfig) *disconnectGuard {
+	g := &disconnectGuard{
+		states: map[string]*keyDisconnects{},
+	}
+
+	if cfg != nil {
+		g.cfg = *cfg
+	}
+
+	return g
+}
+
+func (g *disconnectGuard) get(keyId string, now time.Time) *keyDisconnects {
+	if now.Sub(g.lastPrune) > g.cfg.Window {
+		for k, s := range g.states {
+			if now.Sub(s.lastSeen) > g.cfg.Window && now.After(s.trippedUntil) {
+				delete(g.states, k)
+			}
+		}
+
+		g.lastPrune = now
+	}
+
+	s, ok := g.states[keyId]
+	if !ok {
+		s = &keyDisconnects{windowStart: now}
+		g.states[keyId] = s
+	}
+
+	s.lastSeen = now
+
+	return s
+}
+
+// tripped returns how long upstream calls of the key remain short circuited.
+func (g *disconnectGuard) tripped(keyId string, now time.Time) time.Duration {
+	if g.cfg.Threshold <= 0 || len(keyId) == 0 {
+		return 0
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	s, ok := g.states[keyId]
+	if !ok || !now.Before(s.trippedUntil) {
+		return 0
+	}
+
+	return s.trippedUntil.Sub(now)
+}
+
+// record counts a finished request of the key. When the request trips the key it
+// returns the cooldown along with the request and disconnect counts that caused it.
+func (g *disconnectGuard) record(keyId string, disconnected bool, now time.Time) (time.Duration, int, int) {
+	if g.cfg.Threshold <= 0 || len(keyId) == 0 {
+		return 0, 0, 0
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	s := g.get(keyId, now)
+	if now.Sub(s.windowStart) >= g.cfg.Window {
+		s.windowStart = now
+		s.requests = 0
+		s.disconnects = 0
+	}
+
+	s.requests++
+	if disconnected {
+		s.disconnects++
+	}
+
+	if s.disconnects < g.cfg.Threshold || float64(s.disconnects) < g.cfg.Ratio*float64(s.requests) {
+		return 0, 0, 0
+	}
+
+	requests, disconnects := s.requests, s.disconnects
+
+	s.trippedUntil = now.Add(g.cfg.Cooldown)
+	s.windowStart = now
+	s.requests = 0
+	s.disconnects = 0
+
+	return g.cfg.Cooldown, requests, disconnects
+}
@@ -0,0 +1,40 @@
+package proxy
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+type Drainer interface {
+	Admit() bool
+	Done()
+	RetryAfter() time.Duration
+}
+
+// getDrainMiddleware rejects new requests with 503 while the proxy is
+// draining. The readiness probe is rejected as well so that load balancers
+// stop routing to the instance, but the liveness probe keeps passing so the
+// instance is not restarted while its streams finish.
+func getDrainMiddleware(d Drainer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d == nil || c.FullPath() == livenessPath {
+			c.Next()
+			return
+		}
+
+		if !d.Admit() {
+			telemetry.Incr("bricksllm.proxy.get_drain_middleware.rejected", nil, 1)
+			c.Header("Retry-After", strconv.Itoa(int(d.RetryAfter().Seconds())))
+			JSON(c, http.StatusServiceUnavailable, "[BricksLLM] proxy is draining. retry on another instance")
+			c.Abort()
+			return
+		}
+		defer d.Done()
+
+		c.Next()
+	}
+}
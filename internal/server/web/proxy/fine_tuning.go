@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/finetune"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+)
+
+type fineTuningJobStorage interface {
+	InsertFineTuningJob(j *finetune.Job) error
+}
+
+type createdFineTuningJob struct {
+	Id     string `json:"id"`
+	Model  string `json:"model"`
+	Status string `json:"status"`
+}
+
+// getCreateFineTuningJobHandler creates OpenAI fine-tuning jobs and remembers
+// the key that created them, so that their training can be billed to it once
+// they stop.
+func getCreateFineTuningJobHandler(prod bool, client http.Client, fs fineTuningJobStorage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.proxy.get_create_fine_tuning_job_handler.requests", nil, 1)
+
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), http.MethodPost, "https://api.openai.com/v1/fine_tuning/jobs", c.Request.Body)
+		if err != nil {
+			logError(log, "error when creating openai http request", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create openai http request")
+			return
+		}
+
+		copyHttpHeaders(c.Request, req, c.GetBool("removeUserAgent"))
+
+		start := time.Now()
+		res, err := client.Do(req)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_create_fine_tuning_job_handler.http_client_error", nil, 1)
+
+			logError(log, "error when sending http request to openai", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send http request to openai")
+			return
+		}
+
+		defer res.Body.Close()
+
+		for name, values := range res.Header {
+			for _, value := range values {
+				c.Header(name, value)
+			}
+		}
+
+		dur := time.Since(start)
+		bytes, err := io.ReadAll(res.Body)
+		if err != nil {
+			logError(log, "error when reading openai create fine-tuning job response body", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read openai response body")
+			return
+		}
+
+		if res.StatusCode == http.StatusOK {
+			telemetry.Incr("bricksllm.proxy.get_create_fine_tuning_job_handler.success", nil, 1)
+			telemetry.Timing("bricksllm.proxy.get_create_fine_tuning_job_handler.success_latency", dur, nil, 1)
+
+			trackFineTuningJob(c, log, prod, fs, bytes)
+
+			c.Data(res.StatusCode, "application/json", bytes)
+			return
+		}
+
+		telemetry.Timing("bricksllm.proxy.get_create_fine_tuning_job_handler.error_latency", dur, nil, 1)
+		telemetry.Incr("bricksllm.proxy.get_create_fine_tuning_job_handler.error_response", nil, 1)
+
+		errorRes := &goopenai.ErrorResponse{}
+		err = json.Unmarshal(bytes, errorRes)
+		if err != nil {
+			logError(log, "error when unmarshalling openai create fine-tuning job error response body", prod, err)
+		}
+
+		logOpenAiError(log, prod, errorRes)
+
+		c.Data(res.StatusCode, "application/json", bytes)
+	}
+}
+
+// trackFineTuningJob stores a created job. Like batches, a job that fails to
+// be stored still trains, but its training is not billed.
+func trackFineTuningJob(c *gin.Context, log *zap.Logger, prod bool, fs fineTuningJobStorage, body []byte) {
+	if fs == nil {
+		return
+	}
+
+	created := &createdFineTuningJob{}
+	if err := json.Unmarshal(body, created); err != nil {
+		telemetry.Incr("bricksllm.proxy.track_fine_tuning_job.unmarshal_error", nil, 1)
+		logError(log, "error when unmarshalling openai create fine-tuning job response body", prod, err)
+		return
+	}
+
+	if len(created.Id) == 0 {
+		return
+	}
+
+	keyId := ""
+	if raw, exists := c.Get("key"); exists {
+		if kc, ok := raw.(*key.ResponseKey); ok {
+			keyId = kc.KeyId
+		}
+	}
+
+	now := time.Now().Unix()
+	err := fs.InsertFineTuningJob(&finetune.Job{
+		Id:                created.Id,
+		KeyId:             keyId,
+		ProviderSettingId: c.GetString("providerSettingId"),
+		Model:             created.Model,
+		Status:            created.Status,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	})
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.track_fine_tuning_job.insert_fine_tuning_job_error", nil, 1)
+		logError(log, "error when storing openai fine-tuning job", prod, err)
+	}
+}
@@ -0,0 +1,252 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+// Headers exchanged between chained gateways. A gateway that forwards to
+// another BricksLLM instance through a bricksllm provider setting attributes
+// the request to the gateway and key it entered the chain through, and the
+// upstream gateway answers with what it accounted for the request in trailers,
+// so that both gateways record the same cost.
+const (
+	headerOriginGateway = "X-BRICKSLLM-ORIGIN-GATEWAY"
+	headerOriginKeyId   = "X-BRICKSLLM-ORIGIN-KEY-ID"
+	headerGatewayHops   = "X-BRICKSLLM-GATEWAY-HOPS"
+
+	trailerCostInUsd            = "X-Bricksllm-Cost-In-Usd"
+	trailerPromptTokenCount     = "X-Bricksllm-Prompt-Token-Count"
+	trailerCompletionTokenCount = "X-Bricksllm-Completion-Token-Count"
+
+	chainHeaderPrefix = "x-bricksllm-"
+
+	// requests that went through more gateways than this are assumed to be
+	// caught in a loop of provider settings
+	maxGatewayHops = 8
+)
+
+// getGatewayMiddleware handles requests forwarded by a downstream gateway. It
+// rejects requests that loop back to this gateway, keeps the attribution of
+// the request for its event and reports the cost and token counts of the
+// request to the downstream gateway in trailers once the handler is done.
+func getGatewayMiddleware(gatewayId string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("gatewayId", gatewayId)
+
+		origin := c.GetHeader(headerOriginGateway)
+		if len(origin) == 0 {
+			return
+		}
+
+		hops, _ := strconv.Atoi(c.GetHeader(headerGatewayHops))
+		if hops > maxGatewayHops || (len(gatewayId) != 0 && origin == gatewayId) {
+			telemetry.Incr("bricksllm.proxy.get_gateway_middleware.loop_detected", nil, 1)
+			JSON(c, http.StatusLoopDetected, "[BricksLLM] request loops through chained gateways")
+			c.Abort()
+			return
+		}
+
+		telemetry.Incr("bricksllm.proxy.get_gateway_middleware.chained_requests", []string{
+			"origin:" + origin,
+		}, 1)
+
+		c.Set("originGateway", origin)
+		c.Set("originKeyId", c.GetHeader(headerOriginKeyId))
+		c.Header("Trailer", strings.Join([]string{trailerCostInUsd, trailerPromptTokenCount, trailerCompletionTokenCount}, ", "))
+
+		c.Next()
+
+		h := c.Writer.Header()
+		h.Set(trailerCostInUsd, strconv.FormatFloat(c.GetFloat64("costInUsd"), 'f', -1, 64))
+		h.Set(trailerPromptTokenCount, strconv.Itoa(c.GetInt("promptTokenCount")))
+		h.Set(trailerCompletionTokenCount, strconv.Itoa(c.GetInt("completionTokenCount")))
+	}
+}
+
+// isChainHeader reports whether a request header is only meant for the next
+// gateway of a chain, and must not be passed on to providers as is.
+func isChainHeader(name string) bool {
+	return strings.HasPrefix(strings.ToLower(name), chainHeaderPrefix)
+}
+
+// setChainHeaders attributes a request to the gateway and key it entered the
+// chain through. Requests that were already forwarded by a gateway keep the
+// attribution of the first one.
+func setChainHeaders(c *gin.Context, req *http.Request) {
+	origin := c.GetHeader(headerOriginGateway)
+	originKeyId := c.GetHeader(headerOriginKeyId)
+	if len(origin) == 0 {
+		origin = c.GetString("gatewayId")
+		originKeyId = ""
+		if kc, ok := c.Get("key"); ok {
+			if converted, ok := kc.(*key.ResponseKey); ok {
+				originKeyId = converted.KeyId
+			}
+		}
+	}
+
+	hops, _ := strconv.Atoi(c.GetHeader(headerGatewayHops))
+
+	req.Header.Set(headerOriginGateway, origin)
+	req.Header.Set(headerOriginKeyId, originKeyId)
+	req.Header.Set(headerGatewayHops, strconv.Itoa(hops+1))
+
+	if customId := c.GetHeader("X-CUSTOM-EVENT-ID"); len(customId) != 0 {
+		req.Header.Set("X-CUSTOM-EVENT-ID", customId)
+	}
+}
+
+// useUpstreamAccounting records the cost and token counts that the upstream
+// gateway reported in its trailers, which are only available once the body
+// has been read to the end.
+func useUpstreamAccounting(c *gin.Context, res *http.Response) {
+	cost, err := strconv.ParseFloat(res.Trailer.Get(trailerCostInUsd), 64)
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.get_bricksllm_handler.upstream_cost_missing", nil, 1)
+		return
+	}
+
+	c.Set("costInUsd", cost)
+
+	if tks, err := strconv.Atoi(res.Trailer.Get(trailerPromptTokenCount)); err == nil {
+		c.Set("promptTokenCount", tks)
+	}
+
+	if tks, err := strconv.Atoi(res.Trailer.Get(trailerCompletionTokenCount)); err == nil {
+		c.Set("completionTokenCount", tks)
+	}
+}
+
+// bricksLlmEndpoint is the url of the provider api of the upstream gateway
+// that the request is meant for.
+func bricksLlmEndpoint(c *gin.Context) string {
+	endpoint := strings.TrimSuffix(c.GetString("bricksllmUrl"), "/") + "/api/providers" + c.Param("wildcard")
+	if len(c.Request.URL.RawQuery) != 0 {
+		endpoint += "?" + c.Request.URL.RawQuery
+	}
+
+	return endpoint
+}
+
+// getBricksLlmHandler forwards requests to another BricksLLM instance. The
+// path after /api/providers/bricksllm is the provider path on the upstream
+// gateway, and responses are passed through as they are.
+func getBricksLlmHandler(prod bool, client http.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr("bricksllm.proxy.get_bricksllm_handler.requests", nil, 1)
+
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), c.Request.Method, bricksLlmEndpoint(c), c.Request.Body)
+		if err != nil {
+			logError(log, "error when creating bricksllm http request", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create bricksllm http request")
+			return
+		}
+
+		copyHttpHeaders(c.Request, req, c.GetBool("removeUserAgent"))
+		setChainHeaders(c, req)
+
+		isStreaming := c.GetBool("stream")
+		if isStreaming {
+			req.Header.Set("Accept", "text/event-stream")
+			req.Header.Set("Cache-Control", "no-cache")
+			req.Header.Set("Connection", "keep-alive")
+		}
+
+		start := time.Now()
+		res, err := client.Do(req)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_bricksllm_handler.http_client_error", nil, 1)
+
+			logError(log, "error when sending bricksllm request", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send bricksllm request")
+			return
+		}
+		defer res.Body.Close()
+
+		if !isStreaming || res.StatusCode != http.StatusOK {
+			bytes, err := io.ReadAll(res.Body)
+			if err != nil {
+				logError(log, "error when reading bricksllm response body", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read bricksllm response body")
+				return
+			}
+
+			useUpstreamAccounting(c, res)
+
+			if res.StatusCode != http.StatusOK {
+				telemetry.Timing("bricksllm.proxy.get_bricksllm_handler.error_latency", time.Since(start), nil, 1)
+				telemetry.Incr("bricksllm.proxy.get_bricksllm_handler.error_response", nil, 1)
+				logError(log, "error response from the upstream gateway", prod, errors.New(string(bytes)))
+			} else {
+				telemetry.Timing("bricksllm.proxy.get_bricksllm_handler.latency", time.Since(start), nil, 1)
+				c.Set("response", bytes)
+			}
+
+			c.Data(res.StatusCode, res.Header.Get("Content-Type"), bytes)
+			return
+		}
+
+		reader := newSseReader(res.Body, c.GetInt("sseMaxLineSize"), "bricksllm")
+		streamingResponse := [][]byte{}
+		defer func() {
+			c.Set("streaming_response", bytes.Join(streamingResponse, []byte{}))
+		}()
+
+		telemetry.Incr("bricksllm.proxy.get_bricksllm_handler.streaming_requests", nil, 1)
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+
+		completed := false
+		c.Stream(func(w io.Writer) bool {
+			raw, err := reader.ReadLine()
+			if err != nil {
+				if err == io.EOF {
+					completed = true
+					return false
+				}
+
+				telemetry.Incr("bricksllm.proxy.get_bricksllm_handler.read_bytes_error", nil, 1)
+				logError(log, "error when reading bytes from bricksllm response", prod, err)
+				return false
+			}
+
+			streamingResponse = append(streamingResponse, raw)
+
+			// lines are passed on verbatim, so that event lines and the
+			// blank lines ending events of every provider format survive
+			if _, err := w.Write(raw); err != nil {
+				return false
+			}
+
+			return true
+		})
+
+		if completed {
+			useUpstreamAccounting(c, res)
+		}
+
+		telemetry.Timing("bricksllm.proxy.get_bricksllm_handler.streaming_latency", time.Since(start), nil, 1)
+	}
+}
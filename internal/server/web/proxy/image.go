@@ -3,6 +3,8 @@ package proxy
 import (
 	"encoding/json"
 
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
 	goopenai "github.com/sashabaranov/go-openai"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -108,3 +110,21 @@ func logImageResponse(log *zap.Logger, data []byte, prod, private bool) {
 		log.Info("openai image response", fields...)
 	}
 }
+
+// setImageCost prices the images of a response by the model, quality and
+// size they were requested with.
+func setImageCost(c *gin.Context, log *zap.Logger, prod bool, e estimator, data []byte) {
+	ir := &goopenai.ImageResponse{}
+	if err := json.Unmarshal(data, ir); err != nil || len(ir.Data) == 0 {
+		return
+	}
+
+	cost, err := e.EstimateImageCost(c.GetString("model"), c.GetString("imageQuality"), c.GetString("imageSize"), len(ir.Data))
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.set_image_cost.estimate_image_cost_error", nil, 1)
+		logError(log, "error when estimating openai image cost", prod, err)
+		return
+	}
+
+	c.Set("costInUsd", cost)
+}
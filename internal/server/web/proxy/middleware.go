@@ -2,9 +2,14 @@ package proxy
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
@@ -13,11 +18,12 @@ import (
 	"github.com/bricks-cloud/bricksllm/internal/event"
 	"github.com/bricks-cloud/bricksllm/internal/key"
 	"github.com/bricks-cloud/bricksllm/internal/message"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
 	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/bricks-cloud/bricksllm/internal/provider/bedrock"
 	"github.com/bricks-cloud/bricksllm/internal/provider/openai"
 	"github.com/bricks-cloud/bricksllm/internal/provider/vllm"
-	"github.com/bricks-cloud/bricksllm/internal/route"
 	"github.com/bricks-cloud/bricksllm/internal/telemetry"
 	"github.com/bricks-cloud/bricksllm/internal/user"
 	"github.com/bricks-cloud/bricksllm/internal/util"
@@ -51,6 +57,7 @@ type estimator interface {
 	EstimateTotalCost(model string, promptTks, completionTks int) (float64, error)
 	EstimateEmbeddingsInputCost(model string, tks int) (float64, error)
 	EstimateChatCompletionPromptTokenCounts(model string, r *goopenai.ChatCompletionRequest) (int, error)
+	EstimateImageCost(model, quality, size string, n int) (float64, error)
 }
 
 type azureEstimator interface {
@@ -68,10 +75,13 @@ type deepinfraEstimator interface {
 
 type authenticator interface {
 	AuthenticateHttpRequest(req *http.Request) (*key.ResponseKey, []*provider.Setting, error)
+	AuthenticateKey(req *http.Request) (*key.ResponseKey, []*provider.Setting, error)
+	ReportUpstreamStatus(req *http.Request, status int, retryAfter string)
 }
 
 type validator interface {
 	Validate(k *key.ResponseKey, promptCost float64) error
+	usageReader
 }
 
 type rateLimitManager interface {
@@ -118,6 +128,11 @@ type redactedError interface {
 	Redacted()
 }
 
+// ruledError is implemented by policy errors that know which rules fired.
+type ruledError interface {
+	Rules() []string
+}
+
 type publisher interface {
 	Publish(message.Message)
 }
@@ -158,18 +173,63 @@ func getProvider(c *gin.Context) string {
 type responseWriter struct {
 	gin.ResponseWriter
 	body *bytes.Buffer
+	rw   responseRewriter
 }
 
 func (w responseWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
-	return w.ResponseWriter.Write(b)
+	if w.rw == nil {
+		w.body.Write(b)
+		return w.ResponseWriter.Write(b)
+	}
+
+	if err := w.send(w.rw.rewrite(b)); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+// WriteString keeps server sent events, which are written as strings, going
+// through the rewriter.
+func (w responseWriter) WriteString(s string) (int, error) {
+	if w.rw == nil {
+		return w.ResponseWriter.WriteString(s)
+	}
+
+	return w.Write([]byte(s))
+}
+
+func (w responseWriter) send(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	w.body.Write(data)
+	_, err := w.ResponseWriter.Write(data)
+	return err
+}
+
+// finish sends what the rewriter held back once the handler is done.
+func (w *responseWriter) finish() {
+	if w.rw == nil {
+		return
+	}
+
+	w.send(w.rw.flush())
+	w.rw = nil
 }
 
 type CustomPolicyDetector interface {
 	Detect(input []string, requirements []string) (bool, error)
 }
 
-func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManager, a authenticator, prod, private bool, log *zap.Logger, pub publisher, prefix string, ac accessCache, uac userAccessCache, client http.Client, scanner Scanner, cd CustomPolicyDetector, um userManager, removeUserAgent bool) gin.HandlerFunc {
+type Moderator interface {
+	Moderate(cfg *policy.ModerationConfig, input []string) (map[string]float64, error)
+	Embed(model string, input []string, cache bool) ([][]float64, error)
+	Guard(cfg *policy.GuardrailConfig, req *policy.GuardrailRequest) (*policy.GuardrailVerdict, error)
+}
+
+func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManager, a authenticator, prod, private bool, log *zap.Logger, pub publisher, prefix string, ac accessCache, uac userAccessCache, client http.Client, scanner Scanner, cd CustomPolicyDetector, mod Moderator, um userManager, removeUserAgent bool, dg *disconnectGuard, rce *requestCostEstimator, ks *keyScheduler, qw *quotaWarner, pt pricingTable, ssr settingSpendReader, mar ModelAliasResolver, tb tokenBucket) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c == nil || c.Request == nil {
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] request is empty")
@@ -177,11 +237,17 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			return
 		}
 
-		if c.FullPath() == "/api/health" {
+		if c.FullPath() == healthPath || c.FullPath() == livenessPath {
 			c.Abort()
 			return
 		}
 
+		// models are listed by the gateway itself, which authenticates the
+		// key in the handler
+		if c.FullPath() == modelsPath {
+			return
+		}
+
 		if removeUserAgent {
 			c.Set("removeUserAgent", removeUserAgent)
 		}
@@ -197,12 +263,15 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 		start := time.Now()
 		c.Set("startTime", start)
 
+		timings := newRequestTimings(c)
+
 		enrichedEvent := &event.EventWithRequestAndContent{}
 		requestBytes := []byte(`{}`)
 		responseBytes := []byte(`{}`)
 		userId := ""
 
 		var policyInput any = nil
+		policyTargets := []policy.Target{}
 
 		customId := c.Request.Header.Get("X-CUSTOM-EVENT-ID")
 
@@ -239,6 +308,7 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			telemetry.Timing("bricksllm.proxy.get_middleware.proxy_latency_in_ms", dur, nil, 1)
 
 			selectedProvider := getProvider(c)
+			useOverriddenPricing(c, pt, selectedProvider)
 
 			if prod {
 				logWithCid.Info("response to proxy",
@@ -251,11 +321,39 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 				)
 			}
 
+			status := c.Writer.Status()
+			disconnected := errors.Is(c.Request.Context().Err(), context.Canceled)
+			if disconnected {
+				telemetry.Incr("bricksllm.proxy.get_middleware.client_disconnected", nil, 1)
+
+				if !c.Writer.Written() {
+					status = StatusClientClosedRequest
+				}
+			}
+
+			if !c.GetBool("disconnectStormShortCircuited") {
+				if cooldown, requests, disconnects := dg.record(keyId, disconnected, time.Now()); cooldown > 0 {
+					telemetry.Incr("bricksllm.proxy.get_middleware.disconnect_storm_detected", nil, 1)
+					logWithCid.Warn("client disconnect storm detected, short circuiting upstream calls of key",
+						zap.String("keyId", keyId),
+						zap.Int("requests", requests),
+						zap.Int("disconnects", disconnects),
+						zap.Duration("cooldown", cooldown),
+					)
+				}
+			}
+
 			telemetry.Incr("bricksllm.proxy.get_middleware.responses", []string{
-				"status:" + strconv.Itoa(c.Writer.Status()),
+				"status:" + strconv.Itoa(status),
 			}, 1)
 
+			cacheStatus := c.GetString("cacheStatus")
+			if len(cacheStatus) == 0 {
+				cacheStatus = event.CacheStatusBypass
+			}
+
 			evt := &event.Event{
+				SchemaVersion:        event.SchemaVersion,
 				Id:                   util.NewUuid(),
 				CreatedAt:            time.Now().Unix(),
 				Tags:                 tags,
@@ -263,7 +361,7 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 				CostInUsd:            c.GetFloat64("costInUsd"),
 				Provider:             selectedProvider,
 				Model:                c.GetString("model"),
-				Status:               c.Writer.Status(),
+				Status:               status,
 				PromptTokenCount:     c.GetInt("promptTokenCount"),
 				CompletionTokenCount: c.GetInt("completionTokenCount"),
 				LatencyInMs:          latency,
@@ -274,10 +372,36 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 				Response:             responseBytes,
 				UserId:               userId,
 				PolicyId:             c.GetString("policyId"),
+				PolicyRules:          c.GetStringSlice("policyRules"),
+				RiskScore:            c.GetFloat64("riskScore"),
+				PolicyVersion:        c.GetInt("policyVersion"),
+				RequestHash:          c.GetString("requestHash"),
+				Language:             c.GetString("language"),
 				Action:               c.GetString("action"),
 				RouteId:              c.GetString("routeId"),
 				CorrelationId:        cid,
 				Metadata:             metadataBytes,
+				ReasoningTokenCount:  c.GetInt("reasoningTokenCount"),
+				CacheStatus:          cacheStatus,
+				Timings:              timings.report(),
+				ProviderSettingId:    c.GetString("providerSettingId"),
+				OriginGateway:        c.GetString("originGateway"),
+				OriginKeyId:          c.GetString("originKeyId"),
+				ToolCalls:            toolCallsOf(c),
+				Experiment:           c.GetString("experiment"),
+				Variant:              c.GetString("variant"),
+			}
+
+			if val, ok := c.Get("routingRationale"); ok {
+				if data, ok := val.([]byte); ok {
+					evt.RoutingRationale = data
+				}
+			}
+
+			if val, ok := c.Get("signing"); ok {
+				if data, ok := val.([]byte); ok {
+					evt.Signing = data
+				}
 			}
 
 			enrichedEvent.Event = evt
@@ -304,7 +428,9 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			return
 		}
 
+		authStart := time.Now()
 		kc, settings, err := a.AuthenticateHttpRequest(c.Request)
+		timings.observe(segmentAuth, authStart)
 		enrichedEvent.Key = kc
 		_, ok := err.(notAuthorizedError)
 		if ok {
@@ -332,12 +458,35 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			return
 		}
 
+		if wait := dg.tripped(kc.KeyId, time.Now()); wait > 0 {
+			telemetry.Incr("bricksllm.proxy.get_middleware.disconnect_storm_short_circuited", nil, 1)
+			c.Set("disconnectStormShortCircuited", true)
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			JSON(c, http.StatusTooManyRequests, "[BricksLLM] too many abandoned requests from this key, upstream calls are paused")
+			c.Abort()
+			return
+		}
+
 		c.Set("key", kc)
 		c.Set("settings", settings)
 
 		if len(settings) >= 1 {
 			selected := settings[0]
 
+			if !strings.HasPrefix(c.FullPath(), "/api/routes") {
+				c.Set("providerSettingId", selected.Id)
+				setUpstreamDefaults(c, selected)
+				c.Set("transportConfig", selected.Transport)
+
+				enrichedEvent.SpendLimit = selected.SpendLimit
+				if spendLimitReached(ssr, logWithCid, prod, selected) {
+					telemetry.Incr("bricksllm.proxy.get_middleware.spend_limit_reached", nil, 1)
+					JSON(c, http.StatusTooManyRequests, "[BricksLLM] monthly spend limit of provider setting reached")
+					c.Abort()
+					return
+				}
+			}
+
 			if selected.CostMap != nil {
 				enrichedEvent.CostMap = selected.CostMap
 				c.Set("cost_map", selected.CostMap)
@@ -349,7 +498,11 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 				}
 			}
 
-			if strings.HasPrefix(c.FullPath(), "/api/providers/bedrock/anthropic") {
+			if strings.HasPrefix(c.FullPath(), "/api/providers/bedrock") {
+				if selected != nil && len(selected.Setting["awsCredentialSource"]) != 0 {
+					c.Set("awsCredentialSource", selected.Setting["awsCredentialSource"])
+				}
+
 				if selected != nil && len(selected.Setting["awsAccessKeyId"]) != 0 {
 					c.Set("awsAccessKeyId", selected.Setting["awsAccessKeyId"])
 				}
@@ -368,11 +521,19 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 					c.Set("vllmUrl", selected.Setting["url"])
 				}
 			}
-		}
 
-		p := pm.GetPolicyByIdFromMemdb(kc.PolicyId)
+			if strings.HasPrefix(c.FullPath(), "/api/providers/self-hosted") {
+				if selected != nil && len(selected.Setting["url"]) != 0 {
+					c.Set("selfHostedUrl", selected.Setting["url"])
+				}
+			}
 
-		c.Set("policyId", kc.PolicyId)
+			if strings.HasPrefix(c.FullPath(), "/api/providers/bricksllm") {
+				if selected != nil && len(selected.Setting["url"]) != 0 {
+					c.Set("bricksllmUrl", selected.Setting["url"])
+				}
+			}
+		}
 
 		body, err := io.ReadAll(c.Request.Body)
 		if err != nil {
@@ -380,6 +541,11 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			return
 		}
 
+		if resolved, ok := resolveModelAlias(c, mar, kc.KeyId, body); ok {
+			body = resolved
+			c.Request.ContentLength = int64(len(body))
+		}
+
 		if kc.ShouldLogRequest {
 			if len(body) != 0 {
 				requestBytes = body
@@ -488,6 +654,95 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			policyInput = mr
 		}
 
+		if c.FullPath() == "/api/providers/bedrock/v1/chat/completions" {
+			ccr := &goopenai.ChatCompletionRequest{}
+			cleaned, err := sjson.Delete(string(body), "response_format.json_schema")
+			if err != nil {
+				logWithCid.Warn("removing response_format.json_schema", zap.Error(err))
+			}
+			err = json.Unmarshal([]byte(cleaned), ccr)
+			if err != nil {
+				logError(logWithCid, "error when unmarshalling bedrock chat completion request", prod, err)
+				return
+			}
+
+			if _, err := bedrock.FromChatCompletionRequest(ccr); err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.bedrock_chat_completion_conversion_error", nil, 1)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] "+err.Error())
+				c.Abort()
+				return
+			}
+
+			userId = ccr.User
+
+			enrichedEvent.Request = ccr
+
+			c.Set("model", ccr.Model)
+
+			logRequest(logWithCid, prod, private, ccr)
+
+			if ccr.Stream {
+				c.Set("stream", true)
+			}
+
+			policyInput = ccr
+		}
+
+		if c.FullPath() == "/api/providers/anthropic/v1/chat/completions" {
+			ccr := &goopenai.ChatCompletionRequest{}
+			cleaned, err := sjson.Delete(string(body), "response_format.json_schema")
+			if err != nil {
+				logWithCid.Warn("removing response_format.json_schema", zap.Error(err))
+			}
+			err = json.Unmarshal([]byte(cleaned), ccr)
+			if err != nil {
+				logError(logWithCid, "error when unmarshalling anthropic chat completion request", prod, err)
+				return
+			}
+
+			mr, err := anthropic.FromChatCompletionRequest(ccr)
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.anthropic_chat_completion_conversion_error", nil, 1)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] "+err.Error())
+				c.Abort()
+				return
+			}
+
+			// the handler forwards the translated request to anthropic as is
+			data, err := json.Marshal(mr)
+			if err != nil {
+				logError(logWithCid, "error when marshalling anthropic messages request", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to translate chat completion request")
+				c.Abort()
+				return
+			}
+
+			c.Request.Body = io.NopCloser(bytes.NewReader(data))
+
+			userId = ccr.User
+
+			enrichedEvent.Request = ccr
+
+			c.Set("model", mr.Model)
+
+			logRequest(logWithCid, prod, private, ccr)
+
+			if mr.Stream {
+				c.Set("stream", true)
+			}
+
+			policyInput = mr
+		}
+
+		// the upstream gateway prices the request, so only what is needed
+		// for the event and for streaming is read from the body
+		if strings.HasPrefix(c.FullPath(), "/api/providers/bricksllm") && len(body) != 0 {
+			c.Set("model", gjson.GetBytes(body, "model").String())
+			if gjson.GetBytes(body, "stream").Bool() {
+				c.Set("stream", true)
+			}
+		}
+
 		if strings.HasPrefix(c.FullPath(), "/api/custom/providers/:provider") {
 			providerName := c.Param("provider")
 
@@ -552,6 +807,15 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			c.Set("route_config", rc)
 			c.Set("routeId", rc.Id)
 
+			for _, step := range rc.AllSteps() {
+				if step != nil {
+					policyTargets = append(policyTargets, policy.Target{
+						Provider: step.Provider,
+						Model:    step.Model,
+					})
+				}
+			}
+
 			if rc.ShouldRunEmbeddings() {
 				er := &goopenai.EmbeddingRequest{}
 				err = json.Unmarshal(body, er)
@@ -563,10 +827,6 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 
 				c.Set("model", string(er.Model))
 
-				if rc.CacheConfig != nil && rc.CacheConfig.Enabled {
-					c.Set("cache_key", route.ComputeCacheKeyForEmbeddingsRequest(r, er))
-				}
-
 				c.Set("encoding_format", string(er.EncodingFormat))
 
 				logEmbeddingRequest(logWithCid, prod, private, er)
@@ -595,11 +855,19 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 					return
 				}
 
-				if rc.CacheConfig != nil && rc.CacheConfig.Enabled {
-					c.Set("cache_key", route.ComputeCacheKeyForChatCompletionRequest(r, ccr))
+				policyInput = ccr
+			}
+
+			if rc.CacheConfig != nil && rc.CacheConfig.Enabled {
+				cacheKey, skipped := rc.CacheConfig.Key(r, body, c.Request.Header)
+				if len(skipped) != 0 {
+					telemetry.Incr("bricksllm.proxy.get_middleware.route_cache_skipped", []string{"route:" + rc.Id, "reason:" + skipped}, 1)
+					c.Set("cacheStatus", event.CacheStatusBypass)
 				}
 
-				policyInput = ccr
+				if len(cacheKey) != 0 {
+					c.Set("cache_key", cacheKey)
+				}
 			}
 		}
 
@@ -702,7 +970,61 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			policyInput = er
 		}
 
-		if c.FullPath() == "/api/providers/azure/openai/deployments/:deployment_id/chat/completions" {
+		if c.FullPath() == "/api/providers/mistral/v1/chat/completions" || c.FullPath() == "/api/providers/groq/v1/chat/completions" || c.FullPath() == "/api/providers/self-hosted/v1/chat/completions" {
+			ccr := &goopenai.ChatCompletionRequest{}
+			cleaned, err := sjson.Delete(string(body), "response_format.json_schema")
+			if err != nil {
+				logWithCid.Warn("removing response_format.json_schema", zap.Error(err))
+			}
+			err = json.Unmarshal([]byte(cleaned), ccr)
+			if err != nil {
+				logError(logWithCid, "error when unmarshalling "+getProvider(c)+" chat completions request", prod, err)
+				return
+			}
+
+			userId = ccr.User
+			enrichedEvent.Request = ccr
+
+			c.Set("model", ccr.Model)
+
+			logRequest(logWithCid, prod, private, ccr)
+
+			if ccr.Stream {
+				c.Set("stream", true)
+			}
+
+			policyInput = ccr
+		}
+
+		if c.FullPath() == "/api/providers/mistral/v1/embeddings" || c.FullPath() == "/api/providers/self-hosted/v1/embeddings" {
+			er := &goopenai.EmbeddingRequest{}
+			err = json.Unmarshal(body, er)
+			if err != nil {
+				logError(logWithCid, "error when unmarshalling "+getProvider(c)+" embeddings request", prod, err)
+				return
+			}
+
+			enrichedEvent.Request = er
+
+			c.Set("model", string(er.Model))
+
+			logEmbeddingRequest(logWithCid, prod, private, er)
+			policyInput = er
+		}
+
+		if strings.HasPrefix(c.FullPath(), "/api/providers/cohere/") {
+			// cohere requests do not have an openai shape, so policies are not
+			// applied to them
+			enrichedEvent.Request = body
+
+			c.Set("model", gjson.GetBytes(body, "model").String())
+
+			if gjson.GetBytes(body, "stream").Bool() {
+				c.Set("stream", true)
+			}
+		}
+
+		if c.FullPath() == "/api/providers/azure/openai/deployments/:deployment_id/chat/completions" || c.FullPath() == "/api/providers/azure/openai/v1/chat/completions" {
 			ccr := &goopenai.ChatCompletionRequest{}
 			err = json.Unmarshal(body, ccr)
 			if err != nil {
@@ -713,6 +1035,7 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			userId = ccr.User
 			enrichedEvent.Request = ccr
 			c.Set("model", ccr.Model)
+			resolveAzureDeployment(c, ccr.Model)
 
 			logRequest(logWithCid, prod, private, ccr)
 
@@ -723,7 +1046,7 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			policyInput = ccr
 		}
 
-		if c.FullPath() == "/api/providers/azure/openai/deployments/:deployment_id/completions" {
+		if c.FullPath() == "/api/providers/azure/openai/deployments/:deployment_id/completions" || c.FullPath() == "/api/providers/azure/openai/v1/completions" {
 			cr := &goopenai.CompletionRequest{}
 			err = json.Unmarshal(body, cr)
 			if err != nil {
@@ -734,6 +1057,7 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			userId = cr.User
 			enrichedEvent.Request = cr
 			c.Set("model", cr.Model)
+			resolveAzureDeployment(c, cr.Model)
 
 			logAzureCompletionsRequest(logWithCid, prod, private, cr)
 
@@ -744,7 +1068,7 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			policyInput = cr
 		}
 
-		if c.FullPath() == "/api/providers/azure/openai/deployments/:deployment_id/embeddings" {
+		if c.FullPath() == "/api/providers/azure/openai/deployments/:deployment_id/embeddings" || c.FullPath() == "/api/providers/azure/openai/v1/embeddings" {
 			er := &goopenai.EmbeddingRequest{}
 			err = json.Unmarshal(body, er)
 			if err != nil {
@@ -753,6 +1077,7 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			}
 
 			userId = er.User
+			resolveAzureDeployment(c, string(er.Model))
 
 			c.Set("model", "ada")
 			c.Set("encoding_format", string(er.EncodingFormat))
@@ -823,7 +1148,8 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 				c.Set("model", "dall-e-2")
 			}
 
-			c.Set("model", ir.Model)
+			c.Set("imageQuality", ir.Quality)
+			c.Set("imageSize", ir.Size)
 			logCreateImageRequest(logWithCid, ir, prod, private)
 		}
 
@@ -844,6 +1170,8 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 				c.Set("model", "dall-e-2")
 			}
 
+			c.Set("imageQuality", c.PostForm("quality"))
+			c.Set("imageSize", size)
 			logEditImageRequest(logWithCid, prompt, model, n, size, responseFormat, user, prod, private)
 		}
 
@@ -863,6 +1191,7 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 				c.Set("model", "dall-e-2")
 			}
 
+			c.Set("imageSize", size)
 			logImageVariationsRequest(logWithCid, model, n, size, responseFormat, user, prod)
 		}
 
@@ -877,6 +1206,7 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			enrichedEvent.Request = sr
 
 			c.Set("model", string(sr.Model))
+			policyInput = sr
 
 			logCreateSpeechRequest(logWithCid, sr, prod, private)
 		}
@@ -1154,6 +1484,38 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			return
 		}
 
+		if err := qw.warn(c, kc); err != nil {
+			telemetry.Incr("bricksllm.proxy.get_middleware.quota_warning_error", nil, 1)
+			logError(logWithCid, "error when checking quota usage of key", prod, err)
+		}
+
+		if kc.MaxCostPerRequest > 0 && policyInput != nil && rce != nil {
+			cost, err := rce.EstimateWorstCase(policyInput, costTargets(c, policyTargets))
+			if err == errRequestUncapped {
+				telemetry.Incr("bricksllm.proxy.get_middleware.max_cost_per_request_uncapped", nil, 1)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] max cost per request requires max_tokens or max_completion_tokens to be set")
+				c.Abort()
+				return
+			}
+
+			// requests that can not be priced are rejected rather than let
+			// through with an unknown cost
+			if err != nil && err != errRequestCostUnknown {
+				telemetry.Incr("bricksllm.proxy.get_middleware.estimate_worst_case_cost_error", nil, 1)
+				logError(logWithCid, "error when estimating worst-case request cost", prod, err)
+				JSON(c, http.StatusBadRequest, "[BricksLLM] worst-case request cost can not be estimated for max cost per request")
+				c.Abort()
+				return
+			}
+
+			if err == nil && cost > kc.MaxCostPerRequest {
+				telemetry.Incr("bricksllm.proxy.get_middleware.max_cost_per_request_exceeded", nil, 1)
+				JSON(c, http.StatusBadRequest, fmt.Sprintf("[BricksLLM] worst-case request cost: %f exceeds max cost per request: %f", cost, kc.MaxCostPerRequest))
+				c.Abort()
+				return
+			}
+		}
+
 		if len(userId) != 0 {
 			c.Set("userId", userId)
 			us, err := um.GetUsers(kc.Tags, nil, []string{userId}, 0, 0)
@@ -1198,16 +1560,67 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			}
 		}
 
+		p := effectivePolicy(c, pm, kc)
 		if p != nil {
 			c.Set("policyId", p.Id)
+
+			var version int
+			p, version = selectPolicyVersion(c, pm, p, kc, userId)
+			c.Set("policyVersion", version)
+		}
+
+		if p != nil && isTranscriptPath(c.FullPath()) {
+			setTranscriptFilter(c, p, policyTargets, client, scanner, cd, mod, logWithCid)
 		}
 
 		if p != nil && policyInput != nil {
-			err := p.Filter(client, policyInput, scanner, cd, logWithCid)
+			if len(policyTargets) == 0 {
+				policyTargets = append(policyTargets, policy.Target{
+					Provider: getProvider(c),
+					Model:    c.GetString("model"),
+				})
+			}
+
+			p = p.Resolve(policyTargets)
+
+			// the hash of the request as received lets violations be audited
+			// without logging requests
+			sum := sha256.Sum256(body)
+			c.Set("requestHash", hex.EncodeToString(sum[:]))
+
+			if !checkPolicyLimits(c, p, rce, policyInput) {
+				telemetry.Incr("bricksllm.proxy.get_middleware.policy_limit_exceeded", nil, 1)
+				return
+			}
+
+			policyStart := time.Now()
+			signals, err := p.FilterWithSignals(client, policyInput, scanner, cd, mod, logWithCid)
+			timings.observe(segmentPolicy, policyStart)
+
+			var dt *detokenizer
+			if p.Tokenizes() {
+				dt = newDetokenizer(pm, p.Id, signals.Tokens, logWithCid)
+				if len(signals.Tokens) != 0 {
+					go storeTokens(pm, p.Id, signals.Tokens, logWithCid)
+				}
+			}
+
+			setResponseInspection(c, blw, p, scanner, mod, logWithCid, dt)
+			setOutputSchema(c, p)
+			if signals.RiskScore != 0 {
+				c.Set("riskScore", signals.RiskScore)
+			}
+			if len(signals.Language) != 0 {
+				c.Set("language", signals.Language)
+			}
 			if err == nil {
 				c.Set("action", "allowed")
 			}
 
+			if re, ok := err.(ruledError); ok && len(re.Rules()) != 0 {
+				c.Set("policyRules", re.Rules())
+			}
+
 			if err != nil {
 				_, ok := err.(blockedError)
 				if ok {
@@ -1241,7 +1654,60 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 			}
 		}
 
+		priority, ok := requestPriority(c, kc)
+		if !ok {
+			telemetry.Incr("bricksllm.proxy.get_middleware.invalid_priority", nil, 1)
+			JSON(c, http.StatusBadRequest, "[BricksLLM] priority must be one of low, normal or high")
+			c.Abort()
+			return
+		}
+
+		c.Set("priority", string(priority))
+		c.Header(priorityHeader, string(priority))
+
+		if kc.MaxConcurrency > 0 && ks != nil {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), c.GetDuration("requestTimeout"))
+			queueStart := time.Now()
+			release, err := ks.Acquire(ctx, kc.KeyId, kc.MaxConcurrency, priority)
+			cancel()
+			timings.observe(segmentQueueWait, queueStart)
+
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.max_concurrency_wait_exceeded", []string{"priority:" + string(priority)}, 1)
+				JSON(c, http.StatusTooManyRequests, "[BricksLLM] too many concurrent requests")
+				c.Abort()
+				return
+			}
+			defer release()
+		}
+
+		if tb != nil && len(settings) != 0 && settings[0].TokenBudget.Set() && !strings.HasPrefix(c.FullPath(), "/api/routes") {
+			paceStart := time.Now()
+			retryAfter, err := paceTokenBudget(c.Request.Context(), tb, logWithCid, settings[0], body)
+			timings.observe(segmentQueueWait, paceStart)
+
+			if err != nil && !errors.Is(err, errTokenBudgetExceeded) {
+				telemetry.Incr("bricksllm.proxy.get_middleware.token_budget_wait_cancelled", nil, 1)
+				c.Abort()
+				return
+			}
+
+			if err != nil {
+				telemetry.Incr("bricksllm.proxy.get_middleware.token_budget_exceeded", nil, 1)
+				c.Header("Retry-After", strconv.Itoa(retryAfter))
+				JSON(c, http.StatusTooManyRequests, "[BricksLLM] tokens per minute budget of provider setting exceeded")
+				c.Abort()
+				return
+			}
+		}
+
 		c.Next()
+		inspected := blw.rw != nil
+		blw.finish()
+		recordOutputSchema(c)
+		timings.finishUpstream()
+
+		a.ReportUpstreamStatus(c.Request, c.Writer.Status(), c.Writer.Header().Get("Retry-After"))
 
 		if kc.ShouldLogResponse {
 			if c.GetBool("stream") {
@@ -1249,6 +1715,10 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 
 				if ok {
 					bs, _ := streamingResponse.([]byte)
+					if inspected {
+						bs = blw.body.Bytes()
+					}
+
 					if len(bs) != 0 {
 						streamingData := &StreamingData{
 							Data: bs,
@@ -1273,6 +1743,12 @@ func getMiddleware(cpm CustomProvidersManager, rm routeManager, pm PoliciesManag
 				}
 			}
 		}
+
+		if !kc.ShouldLogResponse {
+			if snippet := responseSnippet(c, blw.body.Bytes()); snippet != nil {
+				responseBytes = snippet
+			}
+		}
 	}
 }
 
@@ -0,0 +1,47 @@
+package proxy
+
+import (
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/alias"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+type ModelAliasResolver interface {
+	Resolve(provider, name, keyId string) *alias.Alias
+}
+
+// resolveModelAlias rewrites the model named in the JSON body of a provider
+// request to the target of the alias it stands for. Routes pick their own
+// models, so their requests are left alone, and so are bodies that do not name
+// an alias.
+func resolveModelAlias(c *gin.Context, mar ModelAliasResolver, keyId string, body []byte) ([]byte, bool) {
+	if mar == nil || len(body) == 0 || strings.HasPrefix(c.FullPath(), "/api/routes") {
+		return body, false
+	}
+
+	name := gjson.GetBytes(body, "model")
+	if name.Type != gjson.String {
+		return body, false
+	}
+
+	provider := getProvider(c)
+	a := mar.Resolve(provider, name.String(), keyId)
+	if a == nil {
+		return body, false
+	}
+
+	rewritten, err := sjson.SetBytes(body, "model", a.Model)
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.resolve_model_alias.set_model_error", nil, 1)
+		return body, false
+	}
+
+	telemetry.Incr("bricksllm.proxy.resolve_model_alias.resolved", []string{"provider:" + provider}, 1)
+	c.Set("modelAlias", a.Name)
+
+	return rewritten, true
+}
@@ -0,0 +1,134 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/bricks-cloud/bricksllm/internal/catalog"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const modelsPath = "/v1/models"
+
+type listedModel struct {
+	Id      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+type modelList struct {
+	Object string         `json:"object"`
+	Data   []*listedModel `json:"data"`
+}
+
+// knownModels groups the models the gateway can price by provider. They are
+// listed for provider settings that do not restrict models.
+func knownModels() map[string][]string {
+	known := map[string][]string{}
+	for _, p := range catalog.DefaultPricing() {
+		known[p.Provider] = append(known[p.Provider], p.Model)
+	}
+
+	return known
+}
+
+// getListModelsHandler serves the models endpoint of OpenAI, so that SDKs can
+// discover what the calling key can use. Models of the provider settings of the
+// key are listed along with the routes it can access, which are listed by their
+// path.
+func getListModelsHandler(prod bool, log *zap.Logger, a authenticator, rm routeManager) gin.HandlerFunc {
+	known := knownModels()
+
+	return func(c *gin.Context) {
+		telemetry.Incr("bricksllm.proxy.get_list_models_handler.requests", nil, 1)
+
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		kc, settings, err := a.AuthenticateKey(c.Request)
+		if _, ok := err.(notAuthorizedError); ok {
+			telemetry.Incr("bricksllm.proxy.get_list_models_handler.authentication_error", nil, 1)
+			logError(log, "error when authenticating list models request", prod, err)
+			JSON(c, http.StatusUnauthorized, fmt.Sprintf("[BricksLLM] %v", err))
+			return
+		}
+
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.get_list_models_handler.authenticate_key_error", nil, 1)
+			logError(log, "error when authenticating list models request", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] internal authentication error")
+			return
+		}
+
+		listed := map[string]*listedModel{}
+		byProvider := map[string][]*provider.Setting{}
+		for _, setting := range settings {
+			byProvider[setting.Provider] = append(byProvider[setting.Provider], setting)
+
+			models := setting.AllowedModels
+			if len(models) == 0 {
+				models = known[setting.Provider]
+			}
+
+			for _, model := range models {
+				if _, ok := listed[model]; ok {
+					continue
+				}
+
+				listed[model] = &listedModel{
+					Id:      model,
+					Object:  "model",
+					Created: setting.CreatedAt,
+					OwnedBy: setting.Provider,
+				}
+			}
+		}
+
+		for _, r := range rm.GetRoutesFromMemDb() {
+			if !contains(r.KeyIds, kc.KeyId) || len(r.Steps) == 0 {
+				continue
+			}
+
+			// a route is only usable when every step can be served by a
+			// setting of the key that allows its model
+			usable := true
+			for _, step := range r.AllSteps() {
+				ps, ok := byProvider[step.Provider]
+				if !ok || !isModelAllowed(step.Model, ps) {
+					usable = false
+					break
+				}
+			}
+
+			if usable {
+				listed[r.Path] = &listedModel{
+					Id:      r.Path,
+					Object:  "model",
+					Created: r.CreatedAt,
+					OwnedBy: "bricksllm",
+				}
+			}
+		}
+
+		data := []*listedModel{}
+		for _, m := range listed {
+			data = append(data, m)
+		}
+
+		sort.Slice(data, func(i, j int) bool {
+			return data[i].Id < data[j].Id
+		})
+
+		c.JSON(http.StatusOK, &modelList{
+			Object: "list",
+			Data:   data,
+		})
+	}
+}
@@ -0,0 +1,397 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/selfhosted"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/tidwall/gjson"
+)
+
+type tokenCostEstimator interface {
+	EstimateTotalCost(model string, promptTks, completionTks int) (float64, error)
+}
+
+type embeddingsCostEstimator interface {
+	EstimateEmbeddingsCost(model string, tks int) (float64, error)
+}
+
+type openAiCompatibleEstimator interface {
+	tokenCostEstimator
+	embeddingsCostEstimator
+}
+
+// fixedEndpoint is the endpoint of a provider that is always at url.
+func fixedEndpoint(url string) func(c *gin.Context) string {
+	return func(c *gin.Context) string {
+		return url
+	}
+}
+
+// selfHostedEndpoint is the endpoint at path of the self hosted server set
+// on the context by the proxy middleware.
+func selfHostedEndpoint(path string) func(c *gin.Context) string {
+	return func(c *gin.Context) string {
+		return selfhosted.BaseUrl(c.GetString("selfHostedUrl")) + path
+	}
+}
+
+// estimateTokenCost prices a request with the cost map of the provider setting
+// when it prices the model, and with the built in pricing otherwise.
+func estimateTokenCost(c *gin.Context, e tokenCostEstimator, model string, promptTks, completionTks int) (float64, error) {
+	if m, exists := c.Get("cost_map"); exists {
+		if converted, ok := m.(*provider.CostMap); ok && converted.Prices(model) {
+			return provider.EstimateTotalCostWithCostMaps(model, promptTks, completionTks, 1000, converted.PromptCostPerModel, converted.CompletionCostPerModel)
+		}
+	}
+
+	return e.EstimateTotalCost(model, promptTks, completionTks)
+}
+
+func estimateEmbeddingsCost(c *gin.Context, e embeddingsCostEstimator, model string, tks int) (float64, error) {
+	if m, exists := c.Get("cost_map"); exists {
+		if converted, ok := m.(*provider.CostMap); ok && converted.Prices(model) {
+			return provider.EstimateCostWithCostMap(model, tks, 1000, converted.EmbeddingsCostPerModel)
+		}
+	}
+
+	return e.EstimateEmbeddingsCost(model, tks)
+}
+
+// streamUsageOf reads the usage of a chat completion chunk. Providers report
+// it on the last chunk, groq under x_groq unless usage is requested with
+// stream_options.
+func streamUsageOf(chunk []byte) *goopenai.Usage {
+	for _, path := range []string{"usage", "x_groq.usage"} {
+		result := gjson.GetBytes(chunk, path)
+		if !result.IsObject() {
+			continue
+		}
+
+		return &goopenai.Usage{
+			PromptTokens:     int(result.Get("prompt_tokens").Int()),
+			CompletionTokens: int(result.Get("completion_tokens").Int()),
+			TotalTokens:      int(result.Get("total_tokens").Int()),
+		}
+	}
+
+	return nil
+}
+
+// getOpenAiCompatibleChatCompletionsHandler proxies chat completions to
+// providers that implement the openai chat completions api, such as mistral,
+// groq and self hosted servers, and prices them with the pricing of the
+// provider.
+func getOpenAiCompatibleChatCompletionsHandler(providerName string, endpoint func(c *gin.Context) string, prod, private bool, client http.Client, e tokenCostEstimator) gin.HandlerFunc {
+	metric := "bricksllm.proxy.get_" + providerName + "_chat_completions_handler."
+
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr(metric+"requests", nil, 1)
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), http.MethodPost, endpoint(c), c.Request.Body)
+		if err != nil {
+			logError(log, "error when creating "+providerName+" chat completions http request", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create "+providerName+" http request")
+			return
+		}
+
+		copyHttpHeaders(c.Request, req, c.GetBool("removeUserAgent"))
+
+		isStreaming := c.GetBool("stream")
+		if isStreaming {
+			req.Header.Set("Accept", "text/event-stream")
+			req.Header.Set("Cache-Control", "no-cache")
+			req.Header.Set("Connection", "keep-alive")
+		}
+
+		start := time.Now()
+		res, err := client.Do(req)
+		if err != nil {
+			telemetry.Incr(metric+"http_client_error", nil, 1)
+
+			logError(log, "error when sending http request to "+providerName, prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send http request to "+providerName)
+			return
+		}
+
+		defer res.Body.Close()
+
+		for name, values := range res.Header {
+			for _, value := range values {
+				c.Header(name, value)
+			}
+		}
+
+		model := c.GetString("model")
+
+		if res.StatusCode == http.StatusOK && !isStreaming {
+			dur := time.Since(start)
+			telemetry.Timing(metric+"latency", dur, nil, 1)
+
+			bytes, err := io.ReadAll(res.Body)
+			if err != nil {
+				logError(log, "error when reading "+providerName+" chat completions response body", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read "+providerName+" response body")
+				return
+			}
+
+			chatRes := &goopenai.ChatCompletionResponse{}
+			telemetry.Incr(metric+"success", nil, 1)
+			telemetry.Timing(metric+"success_latency", dur, nil, 1)
+
+			err = json.Unmarshal(bytes, chatRes)
+			if err != nil {
+				logError(log, "error when unmarshalling "+providerName+" chat completions response body", prod, err)
+			}
+
+			if err == nil {
+				logChatCompletionResponse(log, prod, private, chatRes)
+			}
+
+			cost, err := estimateTokenCost(c, e, model, chatRes.Usage.PromptTokens, chatRes.Usage.CompletionTokens)
+			if err != nil {
+				telemetry.Incr(metric+"estimate_total_cost_error", nil, 1)
+				logError(log, "error when estimating "+providerName+" chat completions total cost", prod, err)
+			}
+
+			c.Set("costInUsd", cost)
+			c.Set("promptTokenCount", chatRes.Usage.PromptTokens)
+			c.Set("completionTokenCount", chatRes.Usage.CompletionTokens)
+
+			c.Data(res.StatusCode, "application/json", bytes)
+			return
+		}
+
+		if res.StatusCode != http.StatusOK {
+			dur := time.Since(start)
+			telemetry.Timing(metric+"error_latency", dur, nil, 1)
+			telemetry.Incr(metric+"error_response", nil, 1)
+
+			bytes, err := io.ReadAll(res.Body)
+			if err != nil {
+				logError(log, "error when reading "+providerName+" chat completions response body", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read "+providerName+" response body")
+				return
+			}
+
+			errorRes := &goopenai.ErrorResponse{}
+			err = json.Unmarshal(bytes, errorRes)
+			if err != nil {
+				logError(log, "error when unmarshalling "+providerName+" chat completions error response body", prod, err)
+			}
+
+			logOpenAiError(log, prod, errorRes)
+
+			c.Data(res.StatusCode, "application/json", bytes)
+			return
+		}
+
+		buffer := newSseReader(res.Body, c.GetInt("sseMaxLineSize"), providerName+"_chat_completion")
+		content := ""
+		streamingResponse := [][]byte{}
+		usage := goopenai.Usage{}
+		defer func() {
+			cost, err := estimateTokenCost(c, e, model, usage.PromptTokens, usage.CompletionTokens)
+			if err != nil {
+				telemetry.Incr(metric+"estimate_total_cost_error", nil, 1)
+				logError(log, "error when estimating "+providerName+" streaming chat completions total cost", prod, err)
+			}
+
+			c.Set("costInUsd", cost)
+			c.Set("promptTokenCount", usage.PromptTokens)
+			c.Set("completionTokenCount", usage.CompletionTokens)
+			c.Set("content", content)
+			c.Set("streaming_response", bytes.Join(streamingResponse, []byte{'\n'}))
+		}()
+
+		telemetry.Incr(metric+"streaming_requests", nil, 1)
+
+		c.Stream(func(w io.Writer) bool {
+			raw, err := buffer.ReadLine()
+			if err != nil {
+				if err == io.EOF {
+					return false
+				}
+
+				if errors.Is(err, context.DeadlineExceeded) {
+					telemetry.Incr(metric+"context_deadline_exceeded_error", nil, 1)
+					logError(log, "context deadline exceeded when reading bytes from "+providerName+" chat completions response", prod, err)
+
+					return false
+				}
+
+				telemetry.Incr(metric+"read_bytes_error", nil, 1)
+				logError(log, "error when reading bytes from "+providerName+" chat completions response", prod, err)
+
+				apiErr := &goopenai.ErrorResponse{
+					Error: &goopenai.APIError{
+						Type:    "bricksllm_error",
+						Message: err.Error(),
+					},
+				}
+
+				bytes, err := json.Marshal(apiErr)
+				if err != nil {
+					telemetry.Incr(metric+"json_marshal_error", nil, 1)
+					logError(log, "error when marshalling bytes for streaming "+providerName+" chat completions error response", prod, err)
+					return false
+				}
+
+				c.SSEvent("", string(bytes))
+				c.SSEvent("", " [DONE]")
+				return false
+			}
+
+			streamingResponse = append(streamingResponse, raw)
+
+			noSpaceLine := bytes.TrimSpace(raw)
+			if !bytes.HasPrefix(noSpaceLine, headerData) {
+				return true
+			}
+
+			noPrefixLine := bytes.TrimPrefix(noSpaceLine, headerData)
+			c.SSEvent("", " "+string(noPrefixLine))
+
+			if string(noPrefixLine) == "[DONE]" {
+				return false
+			}
+
+			if u := streamUsageOf(noPrefixLine); u != nil {
+				usage = *u
+			}
+
+			chatCompletionStreamResp := &goopenai.ChatCompletionStreamResponse{}
+			err = json.Unmarshal(noPrefixLine, chatCompletionStreamResp)
+			if err != nil {
+				telemetry.Incr(metric+"completion_response_unmarshall_error", nil, 1)
+				logError(log, "error when unmarshalling "+providerName+" chat completions stream response", prod, err)
+			}
+
+			if err == nil {
+				if len(chatCompletionStreamResp.Choices) > 0 && len(chatCompletionStreamResp.Choices[0].Delta.Content) != 0 {
+					content += chatCompletionStreamResp.Choices[0].Delta.Content
+				}
+			}
+
+			return true
+		})
+
+		telemetry.Timing(metric+"streaming_latency", time.Since(start), nil, 1)
+	}
+}
+
+// getOpenAiCompatibleEmbeddingsHandler proxies embeddings to providers that
+// implement the openai embeddings api.
+func getOpenAiCompatibleEmbeddingsHandler(providerName string, endpoint func(c *gin.Context) string, prod, private bool, client http.Client, e embeddingsCostEstimator) gin.HandlerFunc {
+	metric := "bricksllm.proxy.get_" + providerName + "_embeddings_handler."
+
+	return func(c *gin.Context) {
+		log := util.GetLogFromCtx(c)
+		telemetry.Incr(metric+"requests", nil, 1)
+		if c == nil || c.Request == nil {
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] context is empty")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), http.MethodPost, endpoint(c), c.Request.Body)
+		if err != nil {
+			logError(log, "error when creating "+providerName+" embeddings http request", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create "+providerName+" http request")
+			return
+		}
+
+		copyHttpHeaders(c.Request, req, c.GetBool("removeUserAgent"))
+
+		start := time.Now()
+
+		res, err := client.Do(req)
+		if err != nil {
+			telemetry.Incr(metric+"http_client_error", nil, 1)
+
+			logError(log, "error when sending http request to "+providerName, prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send http request to "+providerName)
+			return
+		}
+
+		defer res.Body.Close()
+
+		dur := time.Since(start)
+		telemetry.Timing(metric+"latency", dur, nil, 1)
+
+		bytes, err := io.ReadAll(res.Body)
+		if err != nil {
+			logError(log, "error when reading "+providerName+" embeddings response body", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read "+providerName+" embeddings response body")
+			return
+		}
+
+		var cost float64 = 0
+		promptTokenCounts := 0
+
+		if res.StatusCode == http.StatusOK {
+			telemetry.Incr(metric+"success", nil, 1)
+			telemetry.Timing(metric+"success_latency", dur, nil, 1)
+
+			embeddingsRes := &EmbeddingResponse{}
+			err = json.Unmarshal(bytes, embeddingsRes)
+			if err != nil {
+				logError(log, "error when unmarshalling "+providerName+" embeddings response body", prod, err)
+			}
+
+			if err == nil {
+				logEmbeddingResponse(log, prod, private, embeddingsRes)
+				promptTokenCounts = embeddingsRes.Usage.PromptTokens
+
+				cost, err = estimateEmbeddingsCost(c, e, c.GetString("model"), embeddingsRes.Usage.TotalTokens)
+				if err != nil {
+					telemetry.Incr(metric+"estimate_total_cost_error", nil, 1)
+					logError(log, "error when estimating "+providerName+" embeddings cost", prod, err)
+				}
+			}
+		}
+
+		c.Set("costInUsd", cost)
+		c.Set("promptTokenCount", promptTokenCounts)
+
+		if res.StatusCode != http.StatusOK {
+			telemetry.Incr(metric+"error_response", nil, 1)
+
+			errorRes := &goopenai.ErrorResponse{}
+			err = json.Unmarshal(bytes, errorRes)
+			if err != nil {
+				logError(log, "error when unmarshalling "+providerName+" embeddings error response body", prod, err)
+			}
+
+			logOpenAiError(log, prod, errorRes)
+		}
+
+		for name, values := range res.Header {
+			for _, value := range values {
+				c.Header(name, value)
+			}
+		}
+
+		c.Data(res.StatusCode, "application/json", bytes)
+	}
+}
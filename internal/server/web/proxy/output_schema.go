@@ -0,0 +1,263 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+type outputSchemaKey struct{}
+
+// outputSchema is the schema the responses of a request are checked against,
+// along with what became of the check, which is recorded on the event of the
+// request once the handler is done.
+type outputSchema struct {
+	config *policy.SchemaConfig
+
+	lock     sync.Mutex
+	violated bool
+	action   policy.Action
+}
+
+func (out *outputSchema) fail(action policy.Action) {
+	out.lock.Lock()
+	defer out.lock.Unlock()
+
+	out.violated = true
+	out.action = action
+}
+
+// setOutputSchema checks the responses of the request of c against the output
+// schema of p. Streamed responses are not checked, since they reach the client
+// before they are complete.
+func setOutputSchema(c *gin.Context, p *policy.Policy) {
+	if !p.SchemaConfig.Enabled() || c.GetBool("stream") {
+		return
+	}
+
+	c.Set("outputSchema", &outputSchema{config: p.SchemaConfig})
+}
+
+// withOutputSchema carries the output schema of the request of c in ctx, where
+// the transport of the proxy client picks it up.
+func withOutputSchema(ctx context.Context, c *gin.Context) context.Context {
+	raw, exists := c.Get("outputSchema")
+	if !exists {
+		return ctx
+	}
+
+	out, ok := raw.(*outputSchema)
+	if !ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, outputSchemaKey{}, out)
+}
+
+// recordOutputSchema adds responses that did not match the output schema to
+// the policy action and rules reported on the event of the request.
+func recordOutputSchema(c *gin.Context) {
+	raw, _ := c.Get("outputSchema")
+	out, ok := raw.(*outputSchema)
+	if !ok {
+		return
+	}
+
+	out.lock.Lock()
+	defer out.lock.Unlock()
+
+	if !out.violated {
+		return
+	}
+
+	recordResponseRules(c, []string{policy.SchemaViolation}, out.action)
+}
+
+// schemaErrorResponse is the body of a response that does not match the output
+// schema of its policy. It keeps the shape of OpenAI errors, with how the
+// response failed to match added so that clients can tell what went wrong.
+type schemaErrorResponse struct {
+	Error *schemaError `json:"error"`
+}
+
+type schemaError struct {
+	Message    string   `json:"message"`
+	Type       string   `json:"type"`
+	Code       string   `json:"code"`
+	Violations []string `json:"violations"`
+}
+
+func schemaErrorBody(violations []string) []byte {
+	data, _ := json.Marshal(&schemaErrorResponse{
+		Error: &schemaError{
+			Message:    "[BricksLLM] response does not match the output schema of the policy",
+			Type:       policy.SchemaViolation,
+			Code:       strconv.Itoa(http.StatusUnprocessableEntity),
+			Violations: violations,
+		},
+	})
+
+	return data
+}
+
+// checkCompletion returns how the first choice of a chat completion that does
+// not match the schema fails to match, along with its text. Choices without
+// text, such as tool calls, are not checked.
+func checkCompletion(sc *policy.SchemaConfig, body []byte) ([]string, string) {
+	for _, choice := range gjson.GetBytes(body, "choices").Array() {
+		content := choice.Get("message.content")
+		if content.Type != gjson.String {
+			continue
+		}
+
+		if violations := sc.Check(content.String()); len(violations) != 0 {
+			return violations, content.String()
+		}
+	}
+
+	return nil, ""
+}
+
+// correctRequest returns the body of req with the response that did not match
+// the schema and a corrective system message appended to its messages.
+func correctRequest(sc *policy.SchemaConfig, body []byte, content string, violations []string) ([]byte, error) {
+	updated, err := sjson.SetBytes(body, "messages.-1", map[string]string{
+		"role":    "assistant",
+		"content": content,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sjson.SetBytes(updated, "messages.-1", map[string]string{
+		"role":    "system",
+		"content": sc.CorrectiveMessage(violations),
+	})
+}
+
+var usagePaths = []string{
+	"usage.prompt_tokens",
+	"usage.completion_tokens",
+	"usage.total_tokens",
+}
+
+// addUsage adds the usage of the attempts that were discarded to body, so that
+// the tokens spent on them are accounted for.
+func addUsage(body []byte, discarded map[string]int64) []byte {
+	for path, tokens := range discarded {
+		if tokens == 0 {
+			continue
+		}
+
+		updated, err := sjson.SetBytes(body, path, gjson.GetBytes(body, path).Int()+tokens)
+		if err != nil {
+			continue
+		}
+
+		body = updated
+	}
+
+	return body
+}
+
+func withBody(res *http.Response, body []byte) *http.Response {
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	res.ContentLength = int64(len(body))
+	res.Header.Del("Content-Length")
+
+	return res
+}
+
+// schemaTransport checks chat completions that carry an output schema against
+// it, and sends requests whose responses do not match back with a corrective
+// message until the retries of the schema run out. Responses of the attempts
+// that are discarded are not seen by handlers.
+type schemaTransport struct {
+	base http.RoundTripper
+}
+
+func (t *schemaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	out, ok := req.Context().Value(outputSchemaKey{}).(*outputSchema)
+	if !ok || req.Body == nil || req.Body == http.NoBody {
+		return t.base.RoundTrip(req)
+	}
+
+	if err := rewindable(req); err != nil {
+		return nil, err
+	}
+
+	discarded := map[string]int64{}
+	for attempt := 0; ; attempt++ {
+		res, err := t.base.RoundTrip(req)
+		if err != nil || res.StatusCode != http.StatusOK || len(res.Header.Get("Content-Encoding")) != 0 {
+			return res, err
+		}
+
+		data, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		violations, content := checkCompletion(out.config, data)
+		if len(violations) == 0 {
+			return withBody(res, addUsage(data, discarded)), nil
+		}
+
+		var body []byte
+		if attempt < out.config.MaxRetries && req.GetBody != nil {
+			body, err = readBody(req)
+			if err == nil {
+				body, err = correctRequest(out.config, body, content, violations)
+			}
+		}
+
+		if len(body) == 0 || err != nil {
+			action := out.config.FailureAction()
+			telemetry.Incr("bricksllm.proxy.schema_transport.violations", []string{"action:" + string(action)}, 1)
+			out.fail(action)
+
+			if action != policy.Block {
+				return withBody(res, addUsage(data, discarded)), nil
+			}
+
+			res.StatusCode = http.StatusUnprocessableEntity
+			res.Status = strconv.Itoa(http.StatusUnprocessableEntity) + " " + http.StatusText(http.StatusUnprocessableEntity)
+			res.Header.Set("Content-Type", "application/json")
+
+			return withBody(res, schemaErrorBody(violations)), nil
+		}
+
+		telemetry.Incr("bricksllm.proxy.schema_transport.retries", nil, 1)
+		for _, path := range usagePaths {
+			discarded[path] += gjson.GetBytes(data, path).Int()
+		}
+
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return io.ReadAll(body)
+}
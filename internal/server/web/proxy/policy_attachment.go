@@ -0,0 +1,26 @@
+package proxy
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+// effectivePolicy returns the policy that applies to the request, attached to
+// the key, to the route the request is sent through or matched by the tags of
+// the key, in that order.
+func effectivePolicy(c *gin.Context, pm PoliciesManager, kc *key.ResponseKey) *policy.Policy {
+	raw, _ := c.Get("route_config")
+	rc, _ := raw.(*route.Route)
+
+	ep := pm.GetEffectivePolicyFromMemdb(kc, rc)
+	if ep.Policy == nil {
+		return nil
+	}
+
+	telemetry.Incr("bricksllm.proxy.effective_policy.attached", []string{"attached_to:" + string(ep.AttachedTo)}, 1)
+
+	return ep.Policy
+}
@@ -0,0 +1,60 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/gin-gonic/gin"
+)
+
+// limitErrorResponse is the body of a request rejected for exceeding a limit
+// of a policy. It keeps the shape of OpenAI errors, with the limit that was
+// exceeded added so that clients can tell how to shrink the request.
+type limitErrorResponse struct {
+	Error *limitError `json:"error"`
+}
+
+type limitError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code"`
+	Limit   string `json:"limit"`
+	Max     int64  `json:"max"`
+	Actual  int64  `json:"actual"`
+}
+
+func limitJSON(c *gin.Context, le *policy.LimitError) {
+	c.JSON(http.StatusBadRequest, &limitErrorResponse{
+		Error: &limitError{
+			Message: fmt.Sprintf("[BricksLLM] %s", le.Error()),
+			Type:    "policy_limit_exceeded",
+			Code:    strconv.Itoa(http.StatusBadRequest),
+			Limit:   le.Limit,
+			Max:     le.Max,
+			Actual:  le.Actual,
+		},
+	})
+}
+
+// checkPolicyLimits rejects requests over a limit of p. Prompt tokens are
+// only counted when the policy limits them.
+func checkPolicyLimits(c *gin.Context, p *policy.Policy, rce *requestCostEstimator, input any) bool {
+	promptTokens := 0
+	if p.LimitConfig != nil && p.LimitConfig.MaxPromptTokens > 0 {
+		promptTokens = rce.PromptTokens(input)
+	}
+
+	le := p.CheckLimits(input, promptTokens)
+	if le == nil {
+		return true
+	}
+
+	c.Set("action", "blocked")
+	c.Set("policyRules", []string{le.Rule()})
+	limitJSON(c, le)
+	c.Abort()
+
+	return false
+}
@@ -0,0 +1,42 @@
+package proxy
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+// selectPolicyVersion returns the version of p that applies to the request,
+// along with its number. A version pinned by the route takes precedence over
+// one pinned by the key, and both over the rollout of the policy. If the
+// selected version is not kept, the current one applies.
+func selectPolicyVersion(c *gin.Context, pm PoliciesManager, p *policy.Policy, kc *key.ResponseKey, userId string) (*policy.Policy, int) {
+	version := 0
+
+	raw, _ := c.Get("route_config")
+	if rc, ok := raw.(*route.Route); ok {
+		version = rc.PolicyVersions[p.Id]
+	}
+
+	if version == 0 && kc != nil {
+		version = kc.PolicyVersion
+	}
+
+	if version == 0 {
+		version = p.Select(userId)
+	}
+
+	if version == p.Version {
+		return p, version
+	}
+
+	selected := pm.GetPolicyVersionFromCache(p.Id, version)
+	if selected == nil {
+		telemetry.Incr("bricksllm.proxy.select_policy_version.policy_version_not_found", nil, 1)
+		return p, p.Version
+	}
+
+	return selected, version
+}
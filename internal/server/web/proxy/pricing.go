@@ -0,0 +1,45 @@
+package proxy
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/catalog"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+type pricingTable interface {
+	GetPricing(provider, model string) *catalog.Pricing
+}
+
+// useOverriddenPricing prices the request with the pricing that admins set
+// for its model in place of the built in pricing, which keeps spend accurate
+// for models priced after the gateway was released. Cost maps of provider
+// settings still take precedence.
+func useOverriddenPricing(c *gin.Context, pt pricingTable, selectedProvider string) {
+	if pt == nil {
+		return
+	}
+
+	model := c.GetString("model")
+	p := pt.GetPricing(selectedProvider, model)
+	if p == nil {
+		return
+	}
+
+	promptTks, completionTks := c.GetInt("promptTokenCount"), c.GetInt("completionTokenCount")
+	if promptTks == 0 && completionTks == 0 {
+		return
+	}
+
+	if m, exists := c.Get("cost_map"); exists {
+		if converted, ok := m.(*provider.CostMap); ok && converted.Prices(model) {
+			return
+		}
+	}
+
+	telemetry.Incr("bricksllm.proxy.use_overridden_pricing.priced", []string{
+		"provider:" + selectedProvider,
+	}, 1)
+
+	c.Set("costInUsd", p.Cost(promptTks, completionTks))
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/bricks-cloud/bricksllm/internal/pii"
 	"github.com/bricks-cloud/bricksllm/internal/policy"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
 	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
 	"github.com/bricks-cloud/bricksllm/internal/telemetry"
 	"github.com/bricks-cloud/bricksllm/internal/util"
@@ -34,7 +35,10 @@ type ProviderSettingsManager interface {
 }
 
 type PoliciesManager interface {
-	GetPolicyByIdFromMemdb(id string) *policy.Policy
+	GetEffectivePolicyFromMemdb(k *key.ResponseKey, r *route.Route) *policy.EffectivePolicy
+	GetPolicyVersionFromCache(id string, version int) *policy.Policy
+	StoreTokens(policyId string, tokens map[string]string) error
+	GetTokenValues(policyId string, tokens []string) (map[string]string, error)
 }
 
 type ProxyServer struct {
@@ -81,22 +85,43 @@ func CorsMiddleware() gin.HandlerFunc {
 	}
 }
 
-func NewProxyServer(log *zap.Logger, mode, privacyMode string, c cache, m KeyManager, rm routeManager, a authenticator, psm ProviderSettingsManager, cpm CustomProvidersManager, ks keyStorage, e estimator, ae anthropicEstimator, aoe azureEstimator, v validator, r recorder, pub publisher, rlm rateLimitManager, timeout time.Duration, ac accessCache, uac userAccessCache, pm PoliciesManager, scanner Scanner, cd CustomPolicyDetector, die deepinfraEstimator, um userManager, removeAgentHeaders bool) (*ProxyServer, error) {
+func NewProxyServer(log *zap.Logger, mode, privacyMode string, c cache, m KeyManager, rm routeManager, a authenticator, psm ProviderSettingsManager, cpm CustomProvidersManager, ks keyStorage, e estimator, ae anthropicEstimator, aoe azureEstimator, v validator, r recorder, pub publisher, rlm rateLimitManager, timeout time.Duration, ac accessCache, uac userAccessCache, pm PoliciesManager, scanner Scanner, cd CustomPolicyDetector, mod Moderator, die deepinfraEstimator, um userManager, removeAgentHeaders bool, dc *DisconnectConfig, prober Prober, d Drainer, tb ToolBroker, be bedrockEstimator, me openAiCompatibleEstimator, ge tokenCostEstimator, coe cohereEstimator, she openAiCompatibleEstimator, quotaWarningThresholds []float64, sseMaxLineSize int, hc route.HealthChecker, gatewayId string, pt pricingTable, ud usageDeduper, bs batchStorage, ssr settingSpendReader, fs fineTuningJobStorage, breakers *route.Breakers, mar ModelAliasResolver, rq routeQueue, rrl routeRateLimiter, tbk tokenBucket) (*ProxyServer, error) {
 	router := gin.New()
 	prod := mode == "production"
 	private := privacyMode == "strict"
 
+	rce := newRequestCostEstimator(e, ae, pt, map[string]tokenCostEstimator{
+		"openai":      e,
+		"azure":       aoe,
+		"anthropic":   ae,
+		"bedrock":     be,
+		"cohere":      coe,
+		"mistral":     me,
+		"groq":        ge,
+		"self-hosted": she,
+		// vllm serves models run by the operator, like self hosted servers
+		"vllm": she,
+	})
+
 	router.Use(CorsMiddleware())
+	router.Use(getDrainMiddleware(d))
 	router.Use(getTimeoutMiddleware(timeout))
-	router.Use(getMiddleware(cpm, rm, pm, a, prod, private, log, pub, "proxy", ac, uac, http.Client{}, scanner, cd, um, removeAgentHeaders))
+	router.Use(getSseMiddleware(sseMaxLineSize))
+	router.Use(getGatewayMiddleware(gatewayId))
+	router.Use(getMiddleware(cpm, rm, pm, a, prod, private, log, pub, "proxy", ac, uac, http.Client{}, scanner, cd, mod, um, removeAgentHeaders, newDisconnectGuard(dc), rce, newKeyScheduler(), newQuotaWarner(v, quotaWarningThresholds), pt, ssr, mar, tbk))
 
-	client := http.Client{}
+	client := newUpstreamClient()
+	ra := newRunAccountant(e, ud)
 
 	// health check
-	router.POST("/api/health", getGetHealthCheckHandler())
+	router.POST(healthPath, getGetHealthCheckHandler(prober))
 
 	// health check
-	router.GET("/api/health", getGetHealthCheckHandler())
+	router.GET(healthPath, getGetHealthCheckHandler(prober))
+	router.GET(livenessPath, getGetLivenessHandler())
+
+	// models
+	router.GET(modelsPath, getListModelsHandler(prod, log, a, rm))
 
 	// audios
 	router.POST("/api/providers/openai/v1/audio/speech", getSpeechHandler(prod, client))
@@ -104,90 +129,105 @@ func NewProxyServer(log *zap.Logger, mode, privacyMode string, c cache, m KeyMan
 	router.POST("/api/providers/openai/v1/audio/translations", getTranslationsHandler(prod, client, e))
 
 	// completions
-	router.POST("/api/providers/openai/v1/chat/completions", getChatCompletionHandler(prod, private, client, e))
+	router.POST("/api/providers/openai/v1/chat/completions", getChatCompletionHandler(prod, private, client, e, tb))
 
 	// embeddings
 	router.POST("/api/providers/openai/v1/embeddings", getEmbeddingHandler(prod, private, client, e))
 
 	// moderations
-	router.POST("/api/providers/openai/v1/moderations", getPassThroughHandler(prod, private, client))
+	router.POST("/api/providers/openai/v1/moderations", getPassThroughHandler(prod, private, client, e, ra))
 
 	// models
-	router.GET("/api/providers/openai/v1/models", getPassThroughHandler(prod, private, client))
-	router.GET("/api/providers/openai/v1/models/:model", getPassThroughHandler(prod, private, client))
-	router.DELETE("/api/providers/openai/v1/models/:model", getPassThroughHandler(prod, private, client))
+	router.GET("/api/providers/openai/v1/models", getPassThroughHandler(prod, private, client, e, ra))
+	router.GET("/api/providers/openai/v1/models/:model", getPassThroughHandler(prod, private, client, e, ra))
+	router.DELETE("/api/providers/openai/v1/models/:model", getPassThroughHandler(prod, private, client, e, ra))
 
 	// assistants
-	router.POST("/api/providers/openai/v1/assistants", getPassThroughHandler(prod, private, client))
-	router.GET("/api/providers/openai/v1/assistants/:assistant_id", getPassThroughHandler(prod, private, client))
-	router.POST("/api/providers/openai/v1/assistants/:assistant_id", getPassThroughHandler(prod, private, client))
-	router.DELETE("/api/providers/openai/v1/assistants/:assistant_id", getPassThroughHandler(prod, private, client))
-	router.GET("/api/providers/openai/v1/assistants", getPassThroughHandler(prod, private, client))
+	router.POST("/api/providers/openai/v1/assistants", getPassThroughHandler(prod, private, client, e, ra))
+	router.GET("/api/providers/openai/v1/assistants/:assistant_id", getPassThroughHandler(prod, private, client, e, ra))
+	router.POST("/api/providers/openai/v1/assistants/:assistant_id", getPassThroughHandler(prod, private, client, e, ra))
+	router.DELETE("/api/providers/openai/v1/assistants/:assistant_id", getPassThroughHandler(prod, private, client, e, ra))
+	router.GET("/api/providers/openai/v1/assistants", getPassThroughHandler(prod, private, client, e, ra))
 
 	// assistant files
-	router.POST("/api/providers/openai/v1/assistants/:assistant_id/files", getPassThroughHandler(prod, private, client))
-	router.GET("/api/providers/openai/v1/assistants/:assistant_id/files/:file_id", getPassThroughHandler(prod, private, client))
-	router.DELETE("/api/providers/openai/v1/assistants/:assistant_id/files/:file_id", getPassThroughHandler(prod, private, client))
-	router.GET("/api/providers/openai/v1/assistants/:assistant_id/files", getPassThroughHandler(prod, private, client))
+	router.POST("/api/providers/openai/v1/assistants/:assistant_id/files", getPassThroughHandler(prod, private, client, e, ra))
+	router.GET("/api/providers/openai/v1/assistants/:assistant_id/files/:file_id", getPassThroughHandler(prod, private, client, e, ra))
+	router.DELETE("/api/providers/openai/v1/assistants/:assistant_id/files/:file_id", getPassThroughHandler(prod, private, client, e, ra))
+	router.GET("/api/providers/openai/v1/assistants/:assistant_id/files", getPassThroughHandler(prod, private, client, e, ra))
 
 	// threads
-	router.POST("/api/providers/openai/v1/threads", getPassThroughHandler(prod, private, client))
-	router.GET("/api/providers/openai/v1/threads/:thread_id", getPassThroughHandler(prod, private, client))
-	router.POST("/api/providers/openai/v1/threads/:thread_id", getPassThroughHandler(prod, private, client))
-	router.DELETE("/api/providers/openai/v1/threads/:thread_id", getPassThroughHandler(prod, private, client))
+	router.POST("/api/providers/openai/v1/threads", getPassThroughHandler(prod, private, client, e, ra))
+	router.GET("/api/providers/openai/v1/threads/:thread_id", getPassThroughHandler(prod, private, client, e, ra))
+	router.POST("/api/providers/openai/v1/threads/:thread_id", getPassThroughHandler(prod, private, client, e, ra))
+	router.DELETE("/api/providers/openai/v1/threads/:thread_id", getPassThroughHandler(prod, private, client, e, ra))
 
 	// messages
-	router.POST("/api/providers/openai/v1/threads/:thread_id/messages", getPassThroughHandler(prod, private, client))
-	router.GET("/api/providers/openai/v1/threads/:thread_id/messages/:message_id", getPassThroughHandler(prod, private, client))
-	router.POST("/api/providers/openai/v1/threads/:thread_id/messages/:message_id", getPassThroughHandler(prod, private, client))
-	router.GET("/api/providers/openai/v1/threads/:thread_id/messages", getPassThroughHandler(prod, private, client))
+	router.POST("/api/providers/openai/v1/threads/:thread_id/messages", getPassThroughHandler(prod, private, client, e, ra))
+	router.GET("/api/providers/openai/v1/threads/:thread_id/messages/:message_id", getPassThroughHandler(prod, private, client, e, ra))
+	router.POST("/api/providers/openai/v1/threads/:thread_id/messages/:message_id", getPassThroughHandler(prod, private, client, e, ra))
+	router.GET("/api/providers/openai/v1/threads/:thread_id/messages", getPassThroughHandler(prod, private, client, e, ra))
 
 	// message files
-	router.GET("/api/providers/openai/v1/threads/:thread_id/messages/:message_id/files/:file_id", getPassThroughHandler(prod, private, client))
-	router.GET("/api/providers/openai/v1/threads/:thread_id/messages/:message_id/files", getPassThroughHandler(prod, private, client))
+	router.GET("/api/providers/openai/v1/threads/:thread_id/messages/:message_id/files/:file_id", getPassThroughHandler(prod, private, client, e, ra))
+	router.GET("/api/providers/openai/v1/threads/:thread_id/messages/:message_id/files", getPassThroughHandler(prod, private, client, e, ra))
 
 	// runs
-	router.POST("/api/providers/openai/v1/threads/:thread_id/runs", getPassThroughHandler(prod, private, client))
-	router.GET("/api/providers/openai/v1/threads/:thread_id/runs/:run_id", getPassThroughHandler(prod, private, client))
-	router.POST("/api/providers/openai/v1/threads/:thread_id/runs/:run_id", getPassThroughHandler(prod, private, client))
-	router.GET("/api/providers/openai/v1/threads/:thread_id/runs", getPassThroughHandler(prod, private, client))
-	router.POST("/api/providers/openai/v1/threads/:thread_id/runs/:run_id/submit_tool_outputs", getPassThroughHandler(prod, private, client))
-	router.POST("/api/providers/openai/v1/threads/:thread_id/runs/:run_id/cancel", getPassThroughHandler(prod, private, client))
-	router.POST("/api/providers/openai/v1/threads/runs", getPassThroughHandler(prod, private, client))
-	router.GET("/api/providers/openai/v1/threads/:thread_id/runs/:run_id/steps/:step_id", getPassThroughHandler(prod, private, client))
-	router.GET("/api/providers/openai/v1/threads/:thread_id/runs/:run_id/steps", getPassThroughHandler(prod, private, client))
+	router.POST("/api/providers/openai/v1/threads/:thread_id/runs", getPassThroughHandler(prod, private, client, e, ra))
+	router.GET("/api/providers/openai/v1/threads/:thread_id/runs/:run_id", getPassThroughHandler(prod, private, client, e, ra))
+	router.POST("/api/providers/openai/v1/threads/:thread_id/runs/:run_id", getPassThroughHandler(prod, private, client, e, ra))
+	router.GET("/api/providers/openai/v1/threads/:thread_id/runs", getPassThroughHandler(prod, private, client, e, ra))
+	router.POST("/api/providers/openai/v1/threads/:thread_id/runs/:run_id/submit_tool_outputs", getPassThroughHandler(prod, private, client, e, ra))
+	router.POST("/api/providers/openai/v1/threads/:thread_id/runs/:run_id/cancel", getPassThroughHandler(prod, private, client, e, ra))
+	router.POST("/api/providers/openai/v1/threads/runs", getPassThroughHandler(prod, private, client, e, ra))
+	router.GET("/api/providers/openai/v1/threads/:thread_id/runs/:run_id/steps/:step_id", getPassThroughHandler(prod, private, client, e, ra))
+	router.GET("/api/providers/openai/v1/threads/:thread_id/runs/:run_id/steps", getPassThroughHandler(prod, private, client, e, ra))
 
 	// files
-	router.GET("/api/providers/openai/v1/files", getPassThroughHandler(prod, private, client))
-	router.POST("/api/providers/openai/v1/files", getPassThroughHandler(prod, private, client))
-	router.DELETE("/api/providers/openai/v1/files/:file_id", getPassThroughHandler(prod, private, client))
-	router.GET("/api/providers/openai/v1/files/:file_id", getPassThroughHandler(prod, private, client))
-	router.GET("/api/providers/openai/v1/files/:file_id/content", getPassThroughHandler(prod, private, client))
+	router.GET("/api/providers/openai/v1/files", getPassThroughHandler(prod, private, client, e, ra))
+	router.POST("/api/providers/openai/v1/files", getPassThroughHandler(prod, private, client, e, ra))
+	router.DELETE("/api/providers/openai/v1/files/:file_id", getPassThroughHandler(prod, private, client, e, ra))
+	router.GET("/api/providers/openai/v1/files/:file_id", getPassThroughHandler(prod, private, client, e, ra))
+	router.GET("/api/providers/openai/v1/files/:file_id/content", getPassThroughHandler(prod, private, client, e, ra))
 
 	// batch
-	router.POST("/api/providers/openai/v1/batches", getPassThroughHandler(prod, private, client))
-	router.GET("/api/providers/openai/v1/batches/:batch_id", getPassThroughHandler(prod, private, client))
-	router.POST("/api/providers/openai/v1/batches/:batch_id/cancel", getPassThroughHandler(prod, private, client))
-	router.GET("/api/providers/openai/v1/batches", getPassThroughHandler(prod, private, client))
+	router.POST("/api/providers/openai/v1/batches", getCreateBatchHandler(prod, client, bs))
+	router.GET("/api/providers/openai/v1/batches/:batch_id", getPassThroughHandler(prod, private, client, e, ra))
+	router.POST("/api/providers/openai/v1/batches/:batch_id/cancel", getPassThroughHandler(prod, private, client, e, ra))
+	router.GET("/api/providers/openai/v1/batches", getPassThroughHandler(prod, private, client, e, ra))
+
+	// fine-tuning
+	router.POST("/api/providers/openai/v1/fine_tuning/jobs", getCreateFineTuningJobHandler(prod, client, fs))
+	router.GET("/api/providers/openai/v1/fine_tuning/jobs", getPassThroughHandler(prod, private, client, e, ra))
+	router.GET("/api/providers/openai/v1/fine_tuning/jobs/:fine_tuning_job_id", getPassThroughHandler(prod, private, client, e, ra))
+	router.GET("/api/providers/openai/v1/fine_tuning/jobs/:fine_tuning_job_id/events", getPassThroughHandler(prod, private, client, e, ra))
+	router.GET("/api/providers/openai/v1/fine_tuning/jobs/:fine_tuning_job_id/checkpoints", getPassThroughHandler(prod, private, client, e, ra))
+	router.POST("/api/providers/openai/v1/fine_tuning/jobs/:fine_tuning_job_id/cancel", getPassThroughHandler(prod, private, client, e, ra))
 
 	// images
-	router.POST("/api/providers/openai/v1/images/generations", getPassThroughHandler(prod, private, client))
-	router.POST("/api/providers/openai/v1/images/edits", getPassThroughHandler(prod, private, client))
-	router.POST("/api/providers/openai/v1/images/variations", getPassThroughHandler(prod, private, client))
+	router.POST("/api/providers/openai/v1/images/generations", getPassThroughHandler(prod, private, client, e, ra))
+	router.POST("/api/providers/openai/v1/images/edits", getPassThroughHandler(prod, private, client, e, ra))
+	router.POST("/api/providers/openai/v1/images/variations", getPassThroughHandler(prod, private, client, e, ra))
 
 	// azure
 	router.POST("/api/providers/azure/openai/deployments/:deployment_id/chat/completions", getAzureChatCompletionHandler(prod, private, client, aoe))
 	router.POST("/api/providers/azure/openai/deployments/:deployment_id/embeddings", getAzureEmbeddingsHandler(prod, private, client, aoe))
 	router.POST("/api/providers/azure/openai/deployments/:deployment_id/completions", getAzureCompletionsHandler(prod, private, client, aoe))
+	router.POST("/api/providers/azure/openai/v1/chat/completions", getAzureChatCompletionHandler(prod, private, client, aoe))
+	router.POST("/api/providers/azure/openai/v1/embeddings", getAzureEmbeddingsHandler(prod, private, client, aoe))
+	router.POST("/api/providers/azure/openai/v1/completions", getAzureCompletionsHandler(prod, private, client, aoe))
 
 	// anthropic
 	router.POST("/api/providers/anthropic/v1/complete", getCompletionHandler(prod, private, client))
 	router.POST("/api/providers/anthropic/v1/messages", getMessagesHandler(prod, private, client, ae))
+	router.POST("/api/providers/anthropic/v1/chat/completions", getAnthropicChatCompletionHandler(prod, private, client, ae))
 
 	// bedrock anthropic
 	router.POST("/api/providers/bedrock/anthropic/v1/complete", getBedrockCompletionHandler(prod, ae))
 	router.POST("/api/providers/bedrock/anthropic/v1/messages", getBedrockMessagesHandler(prod, ae))
 
+	// bedrock
+	router.POST("/api/providers/bedrock/v1/chat/completions", getBedrockChatCompletionHandler(prod, private, be))
+
 	// vllm
 	router.POST("/api/providers/vllm/v1/chat/completions", getVllmChatCompletionsHandler(prod, private, client))
 	router.POST("/api/providers/vllm/v1/completions", getVllmCompletionsHandler(prod, private, client))
@@ -198,11 +238,31 @@ func NewProxyServer(log *zap.Logger, mode, privacyMode string, c cache, m KeyMan
 	router.POST("/api/providers/deepinfra/v1/completions", getDeepinfraCompletionsHandler(prod, private, client))
 	router.POST("/api/providers/deepinfra/v1/embeddings", getDeepinfraEmbeddingsHandler(prod, private, client, die))
 
+	// mistral
+	router.POST("/api/providers/mistral/v1/chat/completions", getOpenAiCompatibleChatCompletionsHandler("mistral", fixedEndpoint("https://api.mistral.ai/v1/chat/completions"), prod, private, client, me))
+	router.POST("/api/providers/mistral/v1/embeddings", getOpenAiCompatibleEmbeddingsHandler("mistral", fixedEndpoint("https://api.mistral.ai/v1/embeddings"), prod, private, client, me))
+
+	// cohere
+	router.POST("/api/providers/cohere/v2/chat", getCohereChatHandler(prod, client, coe))
+	router.POST("/api/providers/cohere/v2/embed", getCohereEmbedHandler(prod, client, coe))
+	router.POST("/api/providers/cohere/v2/rerank", getCohereRerankHandler(prod, client, coe))
+
+	// groq
+	router.POST("/api/providers/groq/v1/chat/completions", getOpenAiCompatibleChatCompletionsHandler("groq", fixedEndpoint("https://api.groq.com/openai/v1/chat/completions"), prod, private, client, ge))
+
+	// self-hosted
+	router.POST("/api/providers/self-hosted/v1/chat/completions", getOpenAiCompatibleChatCompletionsHandler("self_hosted", selfHostedEndpoint("/v1/chat/completions"), prod, private, client, she))
+	router.POST("/api/providers/self-hosted/v1/embeddings", getOpenAiCompatibleEmbeddingsHandler("self_hosted", selfHostedEndpoint("/v1/embeddings"), prod, private, client, she))
+
+	// bricksllm
+	router.POST("/api/providers/bricksllm/*wildcard", getBricksLlmHandler(prod, client))
+	router.GET("/api/providers/bricksllm/*wildcard", getBricksLlmHandler(prod, client))
+
 	// custom provider
 	router.POST("/api/custom/providers/:provider/*wildcard", getCustomProviderHandler(prod, client))
 
 	// custom route
-	router.POST("/api/routes/*route", getRouteHandler(prod, c, aoe, e, client, r))
+	router.POST("/api/routes/*route", getRouteHandler(prod, c, aoe, e, ae, client, r, route.NewTracker(5*time.Minute, 200), hc, breakers, rq, rrl))
 
 	// vector store
 	router.POST("/api/providers/openai/v1/vector_stores", getCreateVectorStoreHandler(prod, client))
@@ -242,12 +302,6 @@ func NewProxyServer(log *zap.Logger, mode, privacyMode string, c cache, m KeyMan
 	}, nil
 }
 
-func getGetHealthCheckHandler() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Status(http.StatusOK)
-	}
-}
-
 type Form struct {
 	File *multipart.FileHeader `form:"file" binding:"required"`
 }
@@ -290,7 +344,7 @@ func writeFieldToBuffer(fields []string, c *gin.Context, writer *multipart.Write
 	return nil
 }
 
-func getPassThroughHandler(prod, private bool, client http.Client) gin.HandlerFunc {
+func getPassThroughHandler(prod, private bool, client http.Client, e estimator, ra *runAccountant) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := util.GetLogFromCtx(c)
 
@@ -316,7 +370,7 @@ func getPassThroughHandler(prod, private bool, client http.Client) gin.HandlerFu
 			return
 		}
 
-		req, err := http.NewRequestWithContext(ctx, c.Request.Method, targetUrl, c.Request.Body)
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), c.Request.Method, targetUrl, c.Request.Body)
 		if err != nil {
 			logError(log, "error when creating openai http request", prod, err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create openai http request")
@@ -648,6 +702,10 @@ func getPassThroughHandler(prod, private bool, client http.Client) gin.HandlerFu
 				logListRunStepsResponse(log, bytes, prod)
 			}
 
+			if strings.Contains(c.FullPath(), "/runs") {
+				ra.account(c, log, prod, bytes)
+			}
+
 			if c.FullPath() == "/api/providers/openai/v1/moderations" && c.Request.Method == http.MethodPost {
 				logCreateModerationResponse(log, bytes, prod)
 			}
@@ -686,14 +744,17 @@ func getPassThroughHandler(prod, private bool, client http.Client) gin.HandlerFu
 
 			if c.FullPath() == "/api/providers/openai/v1/images/generations" && c.Request.Method == http.MethodPost {
 				logImageResponse(log, bytes, prod, private)
+				setImageCost(c, log, prod, e, bytes)
 			}
 
 			if c.FullPath() == "/api/providers/openai/v1/images/edits" && c.Request.Method == http.MethodPost {
 				logImageResponse(log, bytes, prod, private)
+				setImageCost(c, log, prod, e, bytes)
 			}
 
 			if c.FullPath() == "/api/providers/openai/v1/images/variations" && c.Request.Method == http.MethodPost {
 				logImageResponse(log, bytes, prod, private)
+				setImageCost(c, log, prod, e, bytes)
 			}
 		}
 
@@ -874,10 +935,6 @@ func buildProxyUrl(c *gin.Context) (string, error) {
 		return "https://api.openai.com/v1/files/" + c.Param("file_id") + "/content", nil
 	}
 
-	if c.FullPath() == "/api/providers/openai/v1/batches" && c.Request.Method == http.MethodPost {
-		return "https://api.openai.com/v1/batches", nil
-	}
-
 	if c.FullPath() == "/api/providers/openai/v1/batches/:batch_id" && c.Request.Method == http.MethodGet {
 		return "https://api.openai.com/v1/batches/" + c.Param("batch_id"), nil
 	}
@@ -890,6 +947,26 @@ func buildProxyUrl(c *gin.Context) (string, error) {
 		return "https://api.openai.com/v1/batches", nil
 	}
 
+	if c.FullPath() == "/api/providers/openai/v1/fine_tuning/jobs" && c.Request.Method == http.MethodGet {
+		return "https://api.openai.com/v1/fine_tuning/jobs", nil
+	}
+
+	if c.FullPath() == "/api/providers/openai/v1/fine_tuning/jobs/:fine_tuning_job_id" && c.Request.Method == http.MethodGet {
+		return "https://api.openai.com/v1/fine_tuning/jobs/" + c.Param("fine_tuning_job_id"), nil
+	}
+
+	if c.FullPath() == "/api/providers/openai/v1/fine_tuning/jobs/:fine_tuning_job_id/events" && c.Request.Method == http.MethodGet {
+		return "https://api.openai.com/v1/fine_tuning/jobs/" + c.Param("fine_tuning_job_id") + "/events", nil
+	}
+
+	if c.FullPath() == "/api/providers/openai/v1/fine_tuning/jobs/:fine_tuning_job_id/checkpoints" && c.Request.Method == http.MethodGet {
+		return "https://api.openai.com/v1/fine_tuning/jobs/" + c.Param("fine_tuning_job_id") + "/checkpoints", nil
+	}
+
+	if c.FullPath() == "/api/providers/openai/v1/fine_tuning/jobs/:fine_tuning_job_id/cancel" && c.Request.Method == http.MethodPost {
+		return "https://api.openai.com/v1/fine_tuning/jobs/" + c.Param("fine_tuning_job_id") + "/cancel", nil
+	}
+
 	if c.FullPath() == "/api/providers/openai/v1/images/generations" && c.Request.Method == http.MethodPost {
 		return "https://api.openai.com/v1/images/generations", nil
 	}
@@ -930,6 +1007,10 @@ func (ps *ProxyServer) Run() {
 
 		// health check
 		ps.log.Info("PORT 8002 | GET    | /api/health is ready")
+		ps.log.Info("PORT 8002 | GET    | /api/health/live is ready")
+
+		// models
+		ps.log.Info("PORT 8002 | GET    | /v1/models is ready")
 
 		// audio
 		ps.log.Info("PORT 8002 | POST   | /api/providers/openai/v1/audio/speech is ready for creating openai speeches")
@@ -954,6 +1035,12 @@ func (ps *ProxyServer) Run() {
 		ps.log.Info("PORT 8002 | POST   | /api/providers/openai/v1/files is ready for uploading files to openai")
 		ps.log.Info("PORT 8002 | GET    | /api/providers/openai/v1/files/:file_id is ready for retrieving a file metadata from openai")
 		ps.log.Info("PORT 8002 | GET    | /api/providers/openai/v1/files/:file_id/content is ready for retrieving a file's content from openai")
+		ps.log.Info("PORT 8002 | POST   | /api/providers/openai/v1/fine_tuning/jobs is ready for creating an openai fine-tuning job")
+		ps.log.Info("PORT 8002 | GET    | /api/providers/openai/v1/fine_tuning/jobs is ready for listing openai fine-tuning jobs")
+		ps.log.Info("PORT 8002 | GET    | /api/providers/openai/v1/fine_tuning/jobs/:fine_tuning_job_id is ready for retrieving an openai fine-tuning job")
+		ps.log.Info("PORT 8002 | GET    | /api/providers/openai/v1/fine_tuning/jobs/:fine_tuning_job_id/events is ready for listing openai fine-tuning job events")
+		ps.log.Info("PORT 8002 | GET    | /api/providers/openai/v1/fine_tuning/jobs/:fine_tuning_job_id/checkpoints is ready for listing openai fine-tuning job checkpoints")
+		ps.log.Info("PORT 8002 | POST   | /api/providers/openai/v1/fine_tuning/jobs/:fine_tuning_job_id/cancel is ready for cancelling an openai fine-tuning job")
 
 		// assistants
 		ps.log.Info("PORT 8002 | POST   | /api/providers/openai/v1/assistants is ready for creating openai assistants")
@@ -1003,15 +1090,21 @@ func (ps *ProxyServer) Run() {
 		// azure
 		ps.log.Info("PORT 8002 | POST   | /api/providers/azure/openai/deployments/:deployment_id/chat/completions is ready for forwarding completion requests to azure openai")
 		ps.log.Info("PORT 8002 | POST   | /api/providers/azure/openai/deployments/:deployment_id/embeddings is ready for forwarding embeddings requests to azure openai")
+		ps.log.Info("PORT 8002 | POST   | /api/providers/azure/openai/v1/chat/completions is ready for forwarding completion requests to the azure openai deployment mapped to the model")
+		ps.log.Info("PORT 8002 | POST   | /api/providers/azure/openai/v1/embeddings is ready for forwarding embeddings requests to the azure openai deployment mapped to the model")
 
 		// anthropic
 		ps.log.Info("PORT 8002 | POST   | /api/providers/anthropic/v1/complete is ready for forwarding completion requests to anthropic")
 		ps.log.Info("PORT 8002 | POST   | /api/providers/anthropic/v1/messages is ready for forwarding message requests to anthropic")
+		ps.log.Info("PORT 8002 | POST   | /api/providers/anthropic/v1/chat/completions is ready for forwarding openai compatible chat completion requests to anthropic")
 
 		// bedrock anthropic
 		ps.log.Info("PORT 8002 | POST   | /api/providers/bedrock/anthropic/v1/complete is ready for forwarding completion requests to bedrock anthropic")
 		ps.log.Info("PORT 8002 | POST   | /api/providers/bedrock/anthropic/v1/messages is ready for forwarding message requests to bedrock anthropic")
 
+		// bedrock
+		ps.log.Info("PORT 8002 | POST   | /api/providers/bedrock/v1/chat/completions is ready for forwarding openai compatible chat completion requests to bedrock")
+
 		// vllm
 		ps.log.Info("PORT 8002 | POST   | /api/providers/vllm/v1/chat/completions is ready for forwarding vllm chat completions requests")
 		ps.log.Info("PORT 8002 | POST   | /api/providers/vllm/v1/completions is ready for forwarding vllm completions requests")
@@ -1022,6 +1115,26 @@ func (ps *ProxyServer) Run() {
 		ps.log.Info("PORT 8002 | POST   | /api/providers/deepinfra/v1/completions is ready for forwarding deepinfra completions requests")
 		ps.log.Info("PORT 8002 | POST   | /api/providers/deepinfra/v1/embeddings is ready for forwarding deepinfra embeddings requests")
 
+		// mistral
+		ps.log.Info("PORT 8002 | POST   | /api/providers/mistral/v1/chat/completions is ready for forwarding mistral chat completions requests")
+		ps.log.Info("PORT 8002 | POST   | /api/providers/mistral/v1/embeddings is ready for forwarding mistral embeddings requests")
+
+		// cohere
+		ps.log.Info("PORT 8002 | POST   | /api/providers/cohere/v2/chat is ready for forwarding cohere chat requests")
+		ps.log.Info("PORT 8002 | POST   | /api/providers/cohere/v2/embed is ready for forwarding cohere embed requests")
+		ps.log.Info("PORT 8002 | POST   | /api/providers/cohere/v2/rerank is ready for forwarding cohere rerank requests")
+
+		// groq
+		ps.log.Info("PORT 8002 | POST   | /api/providers/groq/v1/chat/completions is ready for forwarding groq chat completions requests")
+
+		// self-hosted
+		ps.log.Info("PORT 8002 | POST   | /api/providers/self-hosted/v1/chat/completions is ready for forwarding self-hosted chat completions requests")
+		ps.log.Info("PORT 8002 | POST   | /api/providers/self-hosted/v1/embeddings is ready for forwarding self-hosted embeddings requests")
+
+		// bricksllm
+		ps.log.Info("PORT 8002 | POST   | /api/providers/bricksllm/*wildcard is ready for forwarding requests to an upstream bricksllm gateway")
+		ps.log.Info("PORT 8002 | GET    | /api/providers/bricksllm/*wildcard is ready for forwarding requests to an upstream bricksllm gateway")
+
 		// custom provider
 		ps.log.Info("PORT 8002 | POST   | /api/custom/providers/:provider/*wildcard is ready for forwarding requests to custom providers")
 
@@ -0,0 +1,106 @@
+package proxy
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+const quotaWarningHeader = "X-BricksLLM-Quota-Warning"
+
+type usageReader interface {
+	Usage(k *key.ResponseKey) ([]*key.LimitUsage, error)
+}
+
+// quotaWarning is sent in a quota warning header for every limit of a key
+// that crossed a threshold. Threshold is the highest threshold crossed.
+type quotaWarning struct {
+	*key.LimitUsage
+	Ratio     float64 `json:"ratio"`
+	Threshold float64 `json:"threshold"`
+}
+
+// quotaWarner tells clients that their key is approaching a limit, so that
+// they can warn users before requests start being rejected.
+type quotaWarner struct {
+	ur         usageReader
+	thresholds []float64
+}
+
+func newQuotaWarner(ur usageReader, thresholds []float64) *quotaWarner {
+	sorted := []float64{}
+	for _, t := range thresholds {
+		if t > 0 {
+			sorted = append(sorted, t)
+		}
+	}
+
+	sort.Float64s(sorted)
+
+	return &quotaWarner{
+		ur:         ur,
+		thresholds: sorted,
+	}
+}
+
+// crossed returns the highest threshold that ratio reached.
+func (qw *quotaWarner) crossed(ratio float64) (float64, bool) {
+	for i := len(qw.thresholds) - 1; i >= 0; i-- {
+		if ratio >= qw.thresholds[i] {
+			return qw.thresholds[i], true
+		}
+	}
+
+	return 0, false
+}
+
+func (qw *quotaWarner) warnings(kc *key.ResponseKey) ([]*quotaWarning, error) {
+	if qw == nil || qw.ur == nil || len(qw.thresholds) == 0 {
+		return nil, nil
+	}
+
+	usages, err := qw.ur.Usage(kc)
+	if err != nil {
+		return nil, err
+	}
+
+	warnings := []*quotaWarning{}
+	for _, u := range usages {
+		if threshold, ok := qw.crossed(u.Ratio()); ok {
+			warnings = append(warnings, &quotaWarning{
+				LimitUsage: u,
+				Ratio:      u.Ratio(),
+				Threshold:  threshold,
+			})
+		}
+	}
+
+	return warnings, nil
+}
+
+// warn adds a quota warning header to the response for every limit of kc
+// that crossed a threshold. Warnings never fail the request.
+func (qw *quotaWarner) warn(c *gin.Context, kc *key.ResponseKey) error {
+	warnings, err := qw.warnings(kc)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range warnings {
+		data, err := json.Marshal(w)
+		if err != nil {
+			return err
+		}
+
+		telemetry.Incr("bricksllm.proxy.quota_warner.warn.warnings", []string{
+			"limit:" + w.Limit,
+		}, 1)
+
+		c.Writer.Header().Add(quotaWarningHeader, string(data))
+	}
+
+	return nil
+}
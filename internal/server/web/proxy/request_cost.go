@@ -0,0 +1,218 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/provider/anthropic"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+var errRequestCostUnknown = errors.New("worst-case request cost can not be estimated")
+
+// errRequestUncapped is returned for chat requests that do not cap the number
+// of completion tokens, whose worst-case cost has no bound.
+var errRequestUncapped = errors.New("request does not cap completion tokens with max_tokens or max_completion_tokens")
+
+// requestCostEstimator prices a request as if the model used every prompt
+// token it was sent and every completion token it was allowed to generate.
+// Chat requests are priced the way their provider bills them, with the cost
+// map of the provider setting, then the pricing table, then the estimator of
+// the provider in chats.
+type requestCostEstimator struct {
+	e     estimator
+	ae    anthropicEstimator
+	pt    pricingTable
+	chats map[string]tokenCostEstimator
+}
+
+func newRequestCostEstimator(e estimator, ae anthropicEstimator, pt pricingTable, chats map[string]tokenCostEstimator) *requestCostEstimator {
+	return &requestCostEstimator{
+		e:     e,
+		ae:    ae,
+		pt:    pt,
+		chats: chats,
+	}
+}
+
+// costTarget is a provider and model a request may be sent to, along with the
+// cost map of the provider setting it is sent with. The model of the request
+// is used when model is empty.
+type costTarget struct {
+	provider string
+	model    string
+	costMap  *provider.CostMap
+}
+
+// approximateTokenizerModel counts the prompt tokens of models that have no
+// tokenizer of their own, such as those of mistral or groq.
+const approximateTokenizerModel = "gpt-4"
+
+// EstimateWorstCase returns the worst-case cost of a parsed request body. Chat
+// requests are priced at every target and the most expensive one is returned,
+// since a route may send them to any of its steps. errRequestUncapped is
+// returned for chat requests that do not cap the number of completion tokens,
+// and an error for chat requests whose model is not priced by a target, since
+// their cost has no known bound. errRequestCostUnknown is returned for other
+// requests, which are not chat requests.
+func (rce *requestCostEstimator) EstimateWorstCase(input any, targets []costTarget) (float64, error) {
+	switch r := input.(type) {
+	case *goopenai.ChatCompletionRequest:
+		if len(targets) == 0 {
+			return 0, errRequestCostUnknown
+		}
+
+		completionTks := r.MaxCompletionTokens
+		if completionTks == 0 {
+			completionTks = r.MaxTokens
+		}
+
+		if completionTks <= 0 {
+			return 0, errRequestUncapped
+		}
+
+		if r.N > 1 {
+			completionTks *= r.N
+		}
+
+		worst := 0.0
+		for _, t := range targets {
+			model := t.model
+			if len(model) == 0 {
+				model = r.Model
+			}
+
+			promptTks, err := rce.chatPromptTokens(model, r)
+			if err != nil {
+				return 0, err
+			}
+
+			cost, err := rce.priceChat(t.provider, model, t.costMap, promptTks, completionTks)
+			if err != nil {
+				return 0, err
+			}
+
+			worst = math.Max(worst, cost)
+		}
+
+		return worst, nil
+	case *anthropic.MessagesRequest:
+		if rce.ae == nil {
+			return 0, errRequestCostUnknown
+		}
+
+		if r.MaxTokens <= 0 {
+			return 0, errRequestUncapped
+		}
+
+		return rce.ae.EstimateTotalCost(r.Model, rce.ae.CountMessagesTokens(r.Messages), r.MaxTokens)
+	case *anthropic.CompletionRequest:
+		if rce.ae == nil {
+			return 0, errRequestCostUnknown
+		}
+
+		if r.MaxTokensToSample <= 0 {
+			return 0, errRequestUncapped
+		}
+
+		return rce.ae.EstimateTotalCost(r.Model, rce.ae.Count(r.Prompt), r.MaxTokensToSample)
+	}
+
+	return 0, errRequestCostUnknown
+}
+
+func (rce *requestCostEstimator) chatPromptTokens(model string, r *goopenai.ChatCompletionRequest) (int, error) {
+	if rce.e == nil {
+		return 0, errors.New("prompt tokens of chat completion request can not be counted")
+	}
+
+	tks, err := rce.e.EstimateChatCompletionPromptTokenCounts(model, r)
+	if err == nil {
+		return tks, nil
+	}
+
+	return rce.e.EstimateChatCompletionPromptTokenCounts(approximateTokenizerModel, r)
+}
+
+func (rce *requestCostEstimator) priceChat(providerName, model string, cm *provider.CostMap, promptTks, completionTks int) (float64, error) {
+	if cm.Prices(model) {
+		return provider.EstimateTotalCostWithCostMaps(model, promptTks, completionTks, 1000, cm.PromptCostPerModel, cm.CompletionCostPerModel)
+	}
+
+	if rce.pt != nil {
+		if p := rce.pt.GetPricing(providerName, model); p != nil {
+			return p.Cost(promptTks, completionTks), nil
+		}
+	}
+
+	e := rce.chats[providerName]
+	if e == nil {
+		return 0, fmt.Errorf("%s models are not priced", providerName)
+	}
+
+	return e.EstimateTotalCost(model, promptTks, completionTks)
+}
+
+// PromptTokens counts the prompt tokens of a parsed request body with the
+// tokenizer of its provider. It returns 0 when they can not be counted.
+func (rce *requestCostEstimator) PromptTokens(input any) int {
+	if rce == nil {
+		return 0
+	}
+
+	switch r := input.(type) {
+	case *goopenai.ChatCompletionRequest:
+		if rce.e == nil {
+			return 0
+		}
+
+		tks, err := rce.e.EstimateChatCompletionPromptTokenCounts(r.Model, r)
+		if err != nil {
+			return 0
+		}
+
+		return tks
+	case *anthropic.MessagesRequest:
+		if rce.ae == nil {
+			return 0
+		}
+
+		return rce.ae.CountMessagesTokens(r.Messages)
+	case *anthropic.CompletionRequest:
+		if rce.ae == nil {
+			return 0
+		}
+
+		return rce.ae.Count(r.Prompt)
+	}
+
+	return 0
+}
+
+// costTargets returns where the request of c may be sent: every step of its
+// route, or the provider of its path with the cost map of its setting.
+func costTargets(c *gin.Context, routeTargets []policy.Target) []costTarget {
+	targets := []costTarget{}
+	for _, t := range routeTargets {
+		targets = append(targets, costTarget{provider: t.Provider, model: t.Model})
+	}
+
+	if len(targets) != 0 {
+		return targets
+	}
+
+	selected := getProvider(c)
+	model := c.GetString("model")
+	if len(model) == 0 && selected == "azure" {
+		model = azureDeploymentModel(c)
+	}
+
+	cm, _ := c.Get("cost_map")
+	converted, _ := cm.(*provider.CostMap)
+
+	return append(targets, costTarget{provider: selected, model: model, costMap: converted})
+}
@@ -0,0 +1,135 @@
+package proxy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bricks-cloud/bricksllm/internal/catalog"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// countingEstimator counts 10 prompt tokens for models it has a tokenizer of.
+type countingEstimator struct {
+	estimator
+	models map[string]bool
+}
+
+func (e *countingEstimator) EstimateChatCompletionPromptTokenCounts(model string, r *goopenai.ChatCompletionRequest) (int, error) {
+	if !e.models[model] {
+		return 0, errors.New("no tokenizer for model: " + model)
+	}
+
+	return 10, nil
+}
+
+// perTokenEstimator prices the models it knows at a flat rate per token.
+type perTokenEstimator map[string]float64
+
+func (e perTokenEstimator) EstimateTotalCost(model string, promptTks, completionTks int) (float64, error) {
+	rate, ok := e[model]
+	if !ok {
+		return 0, errors.New("model is not priced: " + model)
+	}
+
+	return rate * float64(promptTks+completionTks), nil
+}
+
+type fixedPricing map[string]*catalog.Pricing
+
+func (p fixedPricing) GetPricing(provider, model string) *catalog.Pricing {
+	return p[provider+"/"+model]
+}
+
+func newTestRequestCostEstimator(pt pricingTable) *requestCostEstimator {
+	e := &countingEstimator{models: map[string]bool{approximateTokenizerModel: true, "gpt-4o": true}}
+
+	return newRequestCostEstimator(e, nil, pt, map[string]tokenCostEstimator{
+		"openai":  perTokenEstimator{"gpt-4o": 1},
+		"mistral": perTokenEstimator{"mistral-large-latest": 2},
+	})
+}
+
+func TestEstimateWorstCasePricesChatWithItsProvider(t *testing.T) {
+	rce := newTestRequestCostEstimator(nil)
+	r := &goopenai.ChatCompletionRequest{Model: "mistral-large-latest", MaxTokens: 90}
+
+	cost, err := rce.EstimateWorstCase(r, []costTarget{{provider: "mistral"}})
+	if err != nil {
+		t.Fatalf("expected a mistral chat request to be priced, got: %v", err)
+	}
+
+	if cost != 200 {
+		t.Fatalf("expected the worst-case cost to be 200 at the mistral rate, got: %f", cost)
+	}
+}
+
+func TestEstimateWorstCaseRejectsChatOfUnpricedProvider(t *testing.T) {
+	rce := newTestRequestCostEstimator(nil)
+	r := &goopenai.ChatCompletionRequest{Model: "llama-3.1-8b-instant", MaxTokens: 90}
+
+	_, err := rce.EstimateWorstCase(r, []costTarget{{provider: "groq"}})
+	if err == nil || err == errRequestCostUnknown || err == errRequestUncapped {
+		t.Fatalf("expected a groq chat request without a price to fail estimation, got: %v", err)
+	}
+}
+
+func TestEstimateWorstCasePrefersCostMapAndPricingTable(t *testing.T) {
+	rce := newTestRequestCostEstimator(fixedPricing{
+		"groq/llama-3.1-8b-instant": {InputCostPerMillionTokens: 1000000, OutputCostPerMillionTokens: 1000000},
+	})
+
+	r := &goopenai.ChatCompletionRequest{Model: "llama-3.1-8b-instant", MaxTokens: 90}
+	cost, err := rce.EstimateWorstCase(r, []costTarget{{provider: "groq"}})
+	if err != nil || cost != 100 {
+		t.Fatalf("expected the pricing table to price the groq request at 100, got: %f, %v", cost, err)
+	}
+
+	cm := &provider.CostMap{
+		PromptCostPerModel:     map[string]float64{"llama-3.1-8b-instant": 3000},
+		CompletionCostPerModel: map[string]float64{"llama-3.1-8b-instant": 3000},
+	}
+
+	cost, err = rce.EstimateWorstCase(r, []costTarget{{provider: "groq", costMap: cm}})
+	if err != nil || cost != 300 {
+		t.Fatalf("expected the cost map to price the groq request at 300, got: %f, %v", cost, err)
+	}
+}
+
+func TestEstimateWorstCaseTakesMostExpensiveRouteStep(t *testing.T) {
+	rce := newTestRequestCostEstimator(nil)
+	r := &goopenai.ChatCompletionRequest{MaxCompletionTokens: 90}
+
+	cost, err := rce.EstimateWorstCase(r, []costTarget{
+		{provider: "openai", model: "gpt-4o"},
+		{provider: "mistral", model: "mistral-large-latest"},
+	})
+	if err != nil || cost != 200 {
+		t.Fatalf("expected the route to be priced at its mistral step for 200, got: %f, %v", cost, err)
+	}
+}
+
+func TestEstimateWorstCaseRejectsUncappedChat(t *testing.T) {
+	rce := newTestRequestCostEstimator(nil)
+	r := &goopenai.ChatCompletionRequest{Model: "mistral-large-latest"}
+
+	if _, err := rce.EstimateWorstCase(r, []costTarget{{provider: "mistral"}}); err != errRequestUncapped {
+		t.Fatalf("expected an uncapped chat request to be rejected, got: %v", err)
+	}
+}
+
+func TestCostTargetsPriceAzureDeploymentsByTheirModel(t *testing.T) {
+	c, _ := gin.CreateTestContext(nil)
+	c.Set("provider", "azure")
+	c.Set("azureDeployment", "prod-chat")
+	c.Set("settings", []*provider.Setting{{
+		Provider:    "azure",
+		Deployments: []*provider.Deployment{{Model: "gpt-4o", Deployment: "prod-chat"}},
+	}})
+
+	targets := costTargets(c, nil)
+	if len(targets) != 1 || targets[0].provider != "azure" || targets[0].model != "gpt-4o" {
+		t.Fatalf("expected the azure deployment to be priced as gpt-4o, got: %+v", targets)
+	}
+}
@@ -0,0 +1,311 @@
+package proxy
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"go.uber.org/zap"
+)
+
+// responseRewriter rewrites a response as the handler writes it. rewrite
+// returns what to send on for what the handler wrote, and flush what is left
+// once the handler is done.
+type responseRewriter interface {
+	rewrite(b []byte) []byte
+	flush() []byte
+}
+
+var blockedResponseBody = []byte(`{"error":{"message":"[BricksLLM] response blocked","code":"403"}}`)
+
+// setResponseInspection makes w inspect the response with the response rules
+// of p before it reaches the client, and put back the values of tokens when dt
+// is not nil.
+func setResponseInspection(c *gin.Context, w *responseWriter, p *policy.Policy, scanner Scanner, mod Moderator, log *zap.Logger, dt *detokenizer) {
+	if !p.InspectsResponses() && dt == nil {
+		return
+	}
+
+	if c.GetBool("stream") {
+		mode, size := p.StreamFlush()
+		if !p.InspectsResponses() {
+			// text is only held back while a token may be cut off
+			mode, size = policy.FlushOnSize, 1
+		}
+
+		w.rw = &streamInspector{c: c, p: p, scanner: scanner, mod: mod, log: log, mode: mode, size: size, dt: dt}
+		return
+	}
+
+	w.rw = &bodyInspector{c: c, w: w, p: p, scanner: scanner, mod: mod, log: log, dt: dt}
+}
+
+var responseActions = map[policy.Action]string{
+	policy.Allow:          "allowed",
+	policy.AllowButRedact: "redacted",
+	policy.AllowButWarn:   "warned",
+	policy.Block:          "blocked",
+}
+
+var actionSeverity = map[string]int{
+	"":         0,
+	"allowed":  0,
+	"redacted": 1,
+	"warned":   2,
+	"blocked":  3,
+}
+
+// recordResponseScan adds what was found in a response to the policy action
+// and rules reported on the event of the request.
+func recordResponseScan(c *gin.Context, sr *policy.ScanResult) {
+	recordResponseRules(c, sr.Rules(), sr.Action)
+}
+
+// recordResponseRules adds rules a response matched, and the action taken on
+// it, to what is reported on the event of the request.
+func recordResponseRules(c *gin.Context, matched []string, taken policy.Action) {
+	if len(matched) == 0 {
+		return
+	}
+
+	c.Set("policyRules", append(c.GetStringSlice("policyRules"), matched...))
+
+	action := responseActions[taken]
+	if actionSeverity[action] > actionSeverity[c.GetString("action")] {
+		c.Set("action", action)
+	}
+
+	telemetry.Incr("bricksllm.proxy.record_response_scan.response_"+action, nil, 1)
+}
+
+// bodyInspector holds back a response until the handler is done, so that it
+// is inspected as a whole.
+type bodyInspector struct {
+	c       *gin.Context
+	w       *responseWriter
+	p       *policy.Policy
+	scanner Scanner
+	mod     Moderator
+	log     *zap.Logger
+	body    bytes.Buffer
+	dt      *detokenizer
+}
+
+func (bi *bodyInspector) rewrite(b []byte) []byte {
+	bi.body.Write(b)
+	return nil
+}
+
+func (bi *bodyInspector) flush() []byte {
+	data := bi.body.Bytes()
+	if bi.w.Status() >= http.StatusMultipleChoices || len(data) == 0 {
+		return data
+	}
+
+	updated, sr, err := bi.p.InspectResponseBody(data, bi.scanner, bi.mod, bi.log)
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.body_inspector.flush.inspect_error", nil, 1)
+		bi.log.Debug("error when inspecting a response", zap.Error(err))
+		return data
+	}
+
+	recordResponseScan(bi.c, sr)
+
+	if sr.Action == policy.Block {
+		bi.w.ResponseWriter.WriteHeader(http.StatusForbidden)
+		bi.w.Header().Set("Content-Type", "application/json")
+		updated = blockedResponseBody
+	} else if bi.dt != nil {
+		updated = bi.dt.detokenizeBody(updated)
+	}
+
+	if len(bi.w.Header().Get("Content-Length")) != 0 {
+		bi.w.Header().Set("Content-Length", strconv.Itoa(len(updated)))
+	}
+
+	return updated
+}
+
+// streamTextPaths are where the text of an event is found in the streaming
+// formats of OpenAI and Anthropic. Events of other formats are sent on as is.
+var streamTextPaths = []string{
+	"choices.0.delta.content",
+	"choices.0.text",
+	"delta.text",
+	"completion",
+}
+
+type heldEvent struct {
+	lines []string
+	data  string
+	path  string
+	text  string
+}
+
+// streamInspector holds back the text events of a streamed response and
+// inspects them together whenever it flushes. Held back events are sent on as
+// a single event carrying all of their text, and the stream ends with an
+// error event once anything is blocked.
+type streamInspector struct {
+	c       *gin.Context
+	p       *policy.Policy
+	scanner Scanner
+	mod     Moderator
+	log     *zap.Logger
+	mode    policy.StreamFlush
+	size    int
+	partial []byte
+	held    []*heldEvent
+	heldLen int
+	blocked bool
+	dt      *detokenizer
+}
+
+func (si *streamInspector) rewrite(b []byte) []byte {
+	si.partial = append(si.partial, b...)
+
+	out := []byte{}
+	for {
+		idx := bytes.Index(si.partial, []byte("\n\n"))
+		if idx < 0 {
+			break
+		}
+
+		raw := si.partial[:idx+2]
+		si.partial = si.partial[idx+2:]
+		out = append(out, si.event(raw)...)
+	}
+
+	return out
+}
+
+func (si *streamInspector) flush() []byte {
+	out := si.release()
+	if si.blocked {
+		return out
+	}
+
+	out = append(out, si.partial...)
+	si.partial = nil
+
+	return out
+}
+
+func (si *streamInspector) event(raw []byte) []byte {
+	if si.blocked {
+		return nil
+	}
+
+	held := &heldEvent{}
+	for _, line := range strings.Split(strings.TrimRight(string(raw), "\n"), "\n") {
+		if data, ok := strings.CutPrefix(line, "data:"); ok {
+			held.data = strings.TrimPrefix(data, " ")
+			continue
+		}
+
+		held.lines = append(held.lines, line)
+	}
+
+	for _, path := range streamTextPaths {
+		if r := gjson.Get(held.data, path); r.Type == gjson.String {
+			held.path = path
+			held.text = r.String()
+			break
+		}
+	}
+
+	if len(held.path) == 0 {
+		return append(si.release(), raw...)
+	}
+
+	si.held = append(si.held, held)
+	si.heldLen += len(held.text)
+
+	if si.dt != nil && si.pendingToken() {
+		return nil
+	}
+
+	if si.shouldFlush(held.text) {
+		return si.release()
+	}
+
+	return nil
+}
+
+func (si *streamInspector) shouldFlush(last string) bool {
+	switch si.mode {
+	case policy.FlushOnSentence:
+		trimmed := strings.TrimRight(last, " \t")
+		return strings.HasSuffix(last, "\n") || strings.HasSuffix(trimmed, ".") || strings.HasSuffix(trimmed, "!") || strings.HasSuffix(trimmed, "?")
+	case policy.FlushOnSize:
+		return si.heldLen >= si.size
+	}
+
+	return false
+}
+
+// pendingToken reports whether the held back text ends in the middle of what
+// could be a token, which is held back until the token is complete.
+func (si *streamInspector) pendingToken() bool {
+	texts := make([]string, len(si.held))
+	for i, h := range si.held {
+		texts[i] = h.text
+	}
+
+	return policy.PendingToken(strings.Join(texts, ""))
+}
+
+// release inspects the held back events and returns what to send on for them.
+func (si *streamInspector) release() []byte {
+	if len(si.held) == 0 {
+		return nil
+	}
+
+	held := si.held
+	si.held = nil
+	si.heldLen = 0
+
+	texts := make([]string, len(held))
+	for i, h := range held {
+		texts[i] = h.text
+	}
+
+	joined := strings.Join(texts, "")
+	sr, err := si.p.InspectResponse([]string{joined}, si.scanner, si.mod, si.log)
+	if err != nil || len(sr.Updated) != 1 {
+		telemetry.Incr("bricksllm.proxy.stream_inspector.release.inspect_error", nil, 1)
+		si.log.Debug("error when inspecting a streamed response", zap.Error(err))
+		sr = &policy.ScanResult{Action: policy.Allow, Updated: []string{joined}}
+	}
+
+	recordResponseScan(si.c, sr)
+
+	if sr.Action == policy.Block {
+		si.blocked = true
+		return []byte("data: " + string(blockedResponseBody) + "\n\n")
+	}
+
+	text := sr.Updated[0]
+	if si.dt != nil {
+		text = si.dt.detokenize(text)
+	}
+
+	first := held[0]
+	data, err := sjson.Set(first.data, first.path, text)
+	if err != nil {
+		data = first.data
+	}
+
+	out := strings.Join(first.lines, "\n")
+	if len(out) != 0 {
+		out += "\n"
+	}
+
+	return []byte(out + "data: " + data + "\n\n")
+}
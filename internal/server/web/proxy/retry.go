@@ -0,0 +1,224 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/gin-gonic/gin"
+)
+
+type retryPolicyKey struct{}
+
+// retryContext is the retry policy of a request along with the key or route
+// whose budget its retries are taken from.
+type retryContext struct {
+	policy *key.RetryPolicy
+	scope  string
+}
+
+// retryPolicyOf returns the retry policy of the request of c. The policy of
+// the key overrides the one of the route the request is made to.
+func retryPolicyOf(c *gin.Context) (*key.RetryPolicy, string) {
+	raw, _ := c.Get("key")
+	if kc, ok := raw.(*key.ResponseKey); ok && kc.RetryPolicy.Set() {
+		return kc.RetryPolicy, "key:" + kc.KeyId
+	}
+
+	raw, _ = c.Get("route_config")
+	if rc, ok := raw.(*route.Route); ok && rc.RetryPolicy.Set() {
+		return rc.RetryPolicy, "route:" + rc.Id
+	}
+
+	return nil, ""
+}
+
+// withRetryPolicy carries the retry policy of the request of c in ctx, where
+// the transport of the proxy client picks it up. Streaming requests are left
+// out unless their policy allows it or the client made them idempotent.
+func withRetryPolicy(ctx context.Context, c *gin.Context) context.Context {
+	policy, scope := retryPolicyOf(c)
+	if !policy.Enabled() {
+		return ctx
+	}
+
+	if c.GetBool("stream") && !policy.RetryStreaming && len(c.GetHeader("Idempotency-Key")) == 0 {
+		return ctx
+	}
+
+	return context.WithValue(ctx, retryPolicyKey{}, &retryContext{
+		policy: policy,
+		scope:  scope,
+	})
+}
+
+type budgetWindow struct {
+	start time.Time
+	used  int
+}
+
+// retryBudget counts the retries of every key and route within the current
+// minute.
+type retryBudget struct {
+	lock    sync.Mutex
+	windows map[string]*budgetWindow
+}
+
+func newRetryBudget() *retryBudget {
+	return &retryBudget{
+		windows: map[string]*budgetWindow{},
+	}
+}
+
+func (rb *retryBudget) take(scope string, perMinute int) bool {
+	if perMinute <= 0 {
+		return true
+	}
+
+	rb.lock.Lock()
+	defer rb.lock.Unlock()
+
+	now := time.Now()
+	w, ok := rb.windows[scope]
+	if !ok || now.Sub(w.start) >= time.Minute {
+		w = &budgetWindow{start: now}
+		rb.windows[scope] = w
+	}
+
+	if w.used >= perMinute {
+		return false
+	}
+
+	w.used++
+	return true
+}
+
+// retryTransport sends requests that carry a retry policy again when they
+// fail with a retryable status or get no response. Responses of the attempts
+// that are retried are discarded, so handlers only see the last one.
+type retryTransport struct {
+	base   http.RoundTripper
+	budget *retryBudget
+}
+
+// rewindable makes the body of req readable once per attempt.
+func rewindable(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return nil
+	}
+
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	return nil
+}
+
+func shouldRetry(req *http.Request, policy *key.RetryPolicy, res *http.Response, err error) bool {
+	if err != nil {
+		// requests canceled by clients or timing out are not worth another try
+		return req.Context().Err() == nil
+	}
+
+	return policy.IsRetryable(res.StatusCode)
+}
+
+// retryAfter returns the wait asked for by the Retry-After header of res in
+// seconds, if any.
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	if res == nil {
+		return 0, false
+	}
+
+	seconds, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+
+	return time.Duration(seconds) * time.Second, true
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rc, ok := req.Context().Value(retryPolicyKey{}).(*retryContext)
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+
+	if err := rewindable(req); err != nil {
+		return nil, err
+	}
+
+	initial, max := rc.policy.Intervals()
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = initial
+	b.MaxInterval = max
+	b.RandomizationFactor = rc.policy.Jitter
+	b.MaxElapsedTime = 0
+	b.Reset()
+
+	for attempt := 1; ; attempt++ {
+		res, err := t.base.RoundTrip(req)
+		if attempt >= rc.policy.MaxAttempts || !shouldRetry(req, rc.policy, res, err) {
+			return res, err
+		}
+
+		wait := b.NextBackOff()
+
+		// upstreams asking for a wait longer than the max interval are not
+		// retried, since the client is better placed to wait
+		if after, ok := retryAfter(res); ok {
+			if after > max {
+				return res, err
+			}
+
+			wait = after
+		}
+
+		if !t.budget.take(rc.scope, rc.policy.BudgetPerMinute) {
+			telemetry.Incr("bricksllm.proxy.retry_transport.budget_exhausted", nil, 1)
+			return res, err
+		}
+
+		status := "none"
+		if res != nil {
+			status = strconv.Itoa(res.StatusCode)
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		telemetry.Incr("bricksllm.proxy.retry_transport.retries", []string{"status:" + status}, 1)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		req = req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			req.Body = body
+		}
+	}
+}
@@ -1,12 +1,16 @@
 package proxy
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/bricks-cloud/bricksllm/internal/event"
 	"github.com/bricks-cloud/bricksllm/internal/key"
 	"github.com/bricks-cloud/bricksllm/internal/provider"
 	"github.com/bricks-cloud/bricksllm/internal/route"
@@ -18,6 +22,7 @@ import (
 
 type routeManager interface {
 	GetRouteFromMemDb(path string) *route.Route
+	GetRoutesFromMemDb() []*route.Route
 }
 
 type cache interface {
@@ -25,7 +30,7 @@ type cache interface {
 	GetBytes(key string) ([]byte, error)
 }
 
-func getRouteHandler(prod bool, ca cache, aoe azureEstimator, e estimator, client http.Client, rec recorder) gin.HandlerFunc {
+func getRouteHandler(prod bool, ca cache, aoe azureEstimator, e estimator, ae anthropicEstimator, client http.Client, rec recorder, tracker *route.Tracker, hc route.HealthChecker, breakers *route.Breakers, rq routeQueue, rrl routeRateLimiter) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := util.GetLogFromCtx(c)
 		trueStart := time.Now()
@@ -60,16 +65,23 @@ func getRouteHandler(prod bool, ca cache, aoe azureEstimator, e estimator, clien
 		shouldCache := len(cacheKey) != 0
 
 		if shouldCache {
+			lookupStart := time.Now()
 			bytes, err := ca.GetBytes(cacheKey)
+			timingsOf(c).observe(segmentCacheLookup, lookupStart)
 
 			if err == nil && len(bytes) != 0 {
+				telemetry.Incr("bricksllm.proxy.get_route_handeler.cache_hit", []string{"route:" + rc.Id}, 1)
 				telemetry.Incr("bricksllm.proxy.get_route_handeler.success", nil, 1)
 				telemetry.Timing("bricksllm.proxy.get_route_handeler.success_latency", time.Since(trueStart), nil, 1)
 
 				c.Set("provider", "cached")
+				c.Set("cacheStatus", event.CacheStatusHit)
 				c.Data(http.StatusOK, "application/json", bytes)
 				return
 			}
+
+			telemetry.Incr("bricksllm.proxy.get_route_handeler.cache_miss", []string{"route:" + rc.Id}, 1)
+			c.Set("cacheStatus", event.CacheStatusMiss)
 		}
 
 		raw, exists = c.Get("settings")
@@ -85,6 +97,32 @@ func getRouteHandler(prod bool, ca cache, aoe azureEstimator, e estimator, clien
 			settingsMap[setting.Id] = setting
 		}
 
+		if rc.RateLimit != nil && rrl != nil {
+			retryAfter, err := takeRouteRateLimit(rrl, log, rc, kc.KeyId)
+			if err != nil {
+				c.Header("Retry-After", strconv.Itoa(retryAfter))
+				JSON(c, http.StatusTooManyRequests, "[BricksLLM] "+err.Error())
+				return
+			}
+		}
+
+		if rc.Queue != nil && rq != nil {
+			queueStart := time.Now()
+			release, err := waitForRoute(c.Request.Context(), rq, log, rc, key.Priority(c.GetString("priority")), c.GetDuration("requestTimeout"))
+			timingsOf(c).observe(segmentQueueWait, queueStart)
+
+			if err != nil && !errors.Is(err, errRouteQueueFull) && !errors.Is(err, errRouteQueueTimeout) {
+				return
+			}
+
+			if err != nil {
+				c.Header("Retry-After", strconv.Itoa(routeQueueRetryAfter(rc.Queue)))
+				JSON(c, http.StatusTooManyRequests, "[BricksLLM] route is at its concurrency limit")
+				return
+			}
+			defer release()
+		}
+
 		start := time.Now()
 
 		cid := c.GetString(util.STRING_CORRELATION_ID)
@@ -97,8 +135,23 @@ func getRouteHandler(prod bool, ca cache, aoe azureEstimator, e estimator, clien
 			Start:         c.GetTime("startTime"),
 			UserId:        c.GetString("userId"),
 			PolicyId:      c.GetString("policyId"),
+			PolicyRules:   c.GetStringSlice("policyRules"),
+			RiskScore:     c.GetFloat64("riskScore"),
+			PolicyVersion: c.GetInt("policyVersion"),
+			RequestHash:   c.GetString("requestHash"),
+			Language:      c.GetString("language"),
 			Action:        c.GetString("action"),
 			CorrelationId: cid,
+			Tracker:       tracker,
+			Health:        hc,
+			Breakers:      breakers,
+			Context:       withOutputSchema(withTransportConfig(withRetryPolicy(context.Background(), c), c), c),
+			Priority:      key.Priority(c.GetString("priority")),
+			Costs: map[string]route.CostEstimator{
+				"openai":    e,
+				"azure":     aoe,
+				"anthropic": ae,
+			},
 		}
 
 		val, exists := c.Get("requestBytes")
@@ -107,7 +160,20 @@ func getRouteHandler(prod bool, ca cache, aoe azureEstimator, e estimator, clien
 			rreq.Request = bs
 		}
 
+		timingsOf(c).startUpstream()
 		runRes, err := rc.RunStepsV2(rreq, rec, log, kc)
+		if errors.Is(err, route.ErrCircuitOpen) {
+			telemetry.Incr("bricksllm.proxy.get_route_handeler.circuit_open", tags, 1)
+			JSON(c, http.StatusServiceUnavailable, "[BricksLLM] all route steps are unavailable")
+			return
+		}
+
+		if errors.Is(err, route.ErrNoRegionTarget) {
+			telemetry.Incr("bricksllm.proxy.get_route_handeler.no_region_target", tags, 1)
+			JSON(c, http.StatusServiceUnavailable, "[BricksLLM] no healthy route step is in the requested region")
+			return
+		}
+
 		if err != nil {
 			telemetry.Incr("bricksllm.proxy.get_route_handeler.run_steps_error", tags, 1)
 			logError(log, "error when running steps", prod, err)
@@ -120,6 +186,23 @@ func getRouteHandler(prod bool, ca cache, aoe azureEstimator, e estimator, clien
 		c.Set("model", runRes.Model)
 		c.Set("provider", runRes.Provider)
 
+		if len(runRes.Variant) != 0 {
+			telemetry.Incr("bricksllm.proxy.get_route_handeler.variant_assigned", []string{"path:" + c.FullPath(), "variant:" + runRes.Variant}, 1)
+			c.Set("experiment", runRes.Experiment)
+			c.Set("variant", runRes.Variant)
+		}
+
+		if runRes.Selection != nil {
+			data, err := json.Marshal(runRes.Selection)
+			if err != nil {
+				logError(log, "error when marshalling route selection", prod, err)
+			}
+
+			if err == nil {
+				c.Set("routingRationale", data)
+			}
+		}
+
 		res := runRes.Response
 
 		defer res.Body.Close()
@@ -155,7 +238,7 @@ func getRouteHandler(prod bool, ca cache, aoe azureEstimator, e estimator, clien
 
 			}
 
-			err = parseResult(c, rc.ShouldRunEmbeddings(), bytes, e, aoe, runRes.Model, runRes.Provider)
+			err = parseResult(c, rc.ShouldRunEmbeddings(), bytes, e, aoe, ae, runRes.Model, runRes.Provider)
 			if err != nil {
 				logError(log, "error when parsing run steps result", prod, err)
 			}
@@ -184,7 +267,7 @@ func getRouteHandler(prod bool, ca cache, aoe azureEstimator, e estimator, clien
 	}
 }
 
-func parseResult(c *gin.Context, runEmbeddings bool, bytes []byte, e estimator, aoe azureEstimator, model, provider string) error {
+func parseResult(c *gin.Context, runEmbeddings bool, bytes []byte, e estimator, aoe azureEstimator, ae anthropicEstimator, model, provider string) error {
 	base64ChatRes := &EmbeddingResponseBase64{}
 	chatRes := &EmbeddingResponse{}
 
@@ -271,6 +354,11 @@ func parseResult(c *gin.Context, runEmbeddings bool, bytes []byte, e estimator,
 			if err != nil {
 				return err
 			}
+		} else if provider == "anthropic" {
+			cost, err = ae.EstimateTotalCost(chatRes.Model, chatRes.Usage.PromptTokens, chatRes.Usage.CompletionTokens)
+			if err != nil {
+				return err
+			}
 		}
 
 		// micros := int64(cost * 1000000)
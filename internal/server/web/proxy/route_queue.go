@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"go.uber.org/zap"
+)
+
+type routeQueue interface {
+	Admit(routeId, ticket string, rank, limit, maxQueued int, lease, stale time.Duration, skip bool) (int, error)
+	Leave(routeId, ticket string) error
+	Release(routeId, ticket string) error
+	Depth(routeId string) (int64, error)
+}
+
+const (
+	routeQueuePollInterval = 50 * time.Millisecond
+
+	// waiting tickets that missed a few polls are taken off the queue
+	routeQueueStaleAfter = 10 * routeQueuePollInterval
+
+	// slots outlive the requests holding them by this much at most
+	defaultRouteQueueLease = 10 * time.Minute
+)
+
+var (
+	errRouteQueueFull    = errors.New("route queue is full")
+	errRouteQueueTimeout = errors.New("timed out waiting in route queue")
+)
+
+// routeQueueRetryAfter is the Retry-After, in seconds, sent with requests
+// that could not get a slot of the route.
+func routeQueueRetryAfter(qc *route.QueueConfig) int {
+	return int(math.Max(1, math.Ceil(qc.MaxWait().Seconds())))
+}
+
+// waitForRoute blocks until the request gets a slot of the route, and
+// returns the release of the slot. Queue errors let requests through so that
+// an unavailable queue does not take routes down. When the client goes away
+// while queued, the ticket is taken off the queue and the error of ctx is
+// returned.
+func waitForRoute(ctx context.Context, rq routeQueue, log *zap.Logger, rc *route.Route, p key.Priority, lease time.Duration) (func(), error) {
+	qc := rc.Queue
+	if lease <= 0 {
+		lease = defaultRouteQueueLease
+	}
+
+	tags := []string{"route:" + rc.Id, "priority:" + string(p)}
+	ticket := util.NewUuid()
+	skip := p == key.PriorityHigh
+
+	release := func() {
+		if err := rq.Release(rc.Id, ticket); err != nil {
+			telemetry.Incr("bricksllm.proxy.wait_for_route.release_error", tags, 1)
+			log.Debug("error when releasing route queue slot", zap.Error(err))
+		}
+	}
+
+	start := time.Now()
+	defer func() {
+		telemetry.Timing("bricksllm.proxy.wait_for_route.wait_latency", time.Since(start), tags, 1)
+	}()
+
+	deadline := time.NewTimer(qc.MaxWait())
+	defer deadline.Stop()
+
+	poll := time.NewTicker(routeQueuePollInterval)
+	defer poll.Stop()
+
+	queued := false
+	for {
+		admitted, err := rq.Admit(rc.Id, ticket, p.Rank(), qc.MaxConcurrency, qc.MaxQueued, lease, routeQueueStaleAfter, skip)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.wait_for_route.admit_error", tags, 1)
+			log.Debug("error when admitting request to route queue", zap.Error(err))
+			return release, nil
+		}
+
+		if admitted == route.QueueAdmitted {
+			if queued {
+				telemetry.Incr("bricksllm.proxy.wait_for_route.dequeued", tags, 1)
+			}
+
+			return release, nil
+		}
+
+		if admitted == route.QueueFull {
+			telemetry.Incr("bricksllm.proxy.wait_for_route.queue_full", tags, 1)
+			return nil, errRouteQueueFull
+		}
+
+		if !queued {
+			queued = true
+			telemetry.Incr("bricksllm.proxy.wait_for_route.queued", tags, 1)
+
+			if depth, err := rq.Depth(rc.Id); err == nil {
+				telemetry.Gauge("bricksllm.proxy.wait_for_route.queue_depth", float64(depth), []string{"route:" + rc.Id}, 1)
+			}
+		}
+
+		select {
+		case <-poll.C:
+			continue
+		case <-deadline.C:
+			telemetry.Incr("bricksllm.proxy.wait_for_route.timeout", tags, 1)
+			err = errRouteQueueTimeout
+		case <-ctx.Done():
+			telemetry.Incr("bricksllm.proxy.wait_for_route.cancelled", tags, 1)
+			err = ctx.Err()
+		}
+
+		if lerr := rq.Leave(rc.Id, ticket); lerr != nil {
+			log.Debug("error when leaving route queue", zap.Error(lerr))
+		}
+
+		return nil, err
+	}
+}
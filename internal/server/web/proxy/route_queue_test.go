@@ -0,0 +1,151 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"go.uber.org/zap"
+)
+
+// scriptedQueue answers admissions with outcomes in order, repeating the last
+// one, and counts how tickets left the queue.
+type scriptedQueue struct {
+	lock     sync.Mutex
+	outcomes []int
+	err      error
+	admits   int
+	skipped  []bool
+	left     int
+	released int
+}
+
+func (q *scriptedQueue) Admit(routeId, ticket string, rank, limit, maxQueued int, lease, stale time.Duration, skip bool) (int, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.skipped = append(q.skipped, skip)
+	if q.err != nil {
+		return 0, q.err
+	}
+
+	outcome := q.outcomes[min(q.admits, len(q.outcomes)-1)]
+	q.admits++
+
+	return outcome, nil
+}
+
+func (q *scriptedQueue) Leave(routeId, ticket string) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.left++
+	return nil
+}
+
+func (q *scriptedQueue) Release(routeId, ticket string) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.released++
+	return nil
+}
+
+func (q *scriptedQueue) Depth(routeId string) (int64, error) {
+	return 1, nil
+}
+
+func newQueuedRoute(maxWait time.Duration) *route.Route {
+	return &route.Route{Id: "route", Queue: &route.QueueConfig{MaxConcurrency: 1, MaxQueued: 1, MaxWaitInMs: int(maxWait.Milliseconds())}}
+}
+
+func TestWaitForRouteReleasesAdmittedSlot(t *testing.T) {
+	q := &scriptedQueue{outcomes: []int{route.QueueWaiting, route.QueueAdmitted}}
+
+	release, err := waitForRoute(context.Background(), q, zap.NewNop(), newQueuedRoute(time.Second), key.PriorityNormal, 0)
+	if err != nil || release == nil {
+		t.Fatalf("expected the request to be admitted once a slot frees up, got: %v", err)
+	}
+
+	release()
+	if q.released != 1 || q.left != 0 {
+		t.Fatalf("expected the slot to be released and the queue not to be left, got %d releases and %d leaves", q.released, q.left)
+	}
+}
+
+func TestWaitForRouteRejectsOverflowWithoutLeaving(t *testing.T) {
+	q := &scriptedQueue{outcomes: []int{route.QueueFull}}
+
+	release, err := waitForRoute(context.Background(), q, zap.NewNop(), newQueuedRoute(time.Second), key.PriorityNormal, 0)
+	if !errors.Is(err, errRouteQueueFull) || release != nil {
+		t.Fatalf("expected a full queue to reject the request, got: %v", err)
+	}
+
+	if q.left != 0 || q.released != 0 {
+		t.Fatalf("expected a rejected request not to touch the queue, got %d leaves and %d releases", q.left, q.released)
+	}
+}
+
+func TestWaitForRouteLeavesWhenClientGoesAway(t *testing.T) {
+	q := &scriptedQueue{outcomes: []int{route.QueueWaiting}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(2*routeQueuePollInterval, cancel)
+
+	_, err := waitForRoute(ctx, q, zap.NewNop(), newQueuedRoute(10*time.Second), key.PriorityNormal, 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the error of the client context, got: %v", err)
+	}
+
+	// callers only report the full and timeout errors as overflow
+	if errors.Is(err, errRouteQueueFull) || errors.Is(err, errRouteQueueTimeout) {
+		t.Fatalf("expected a client leaving not to be reported as overflow, got: %v", err)
+	}
+
+	if q.left != 1 || q.released != 0 {
+		t.Fatalf("expected the ticket to leave the queue once, got %d leaves and %d releases", q.left, q.released)
+	}
+}
+
+func TestWaitForRouteTimesOutAndLeaves(t *testing.T) {
+	q := &scriptedQueue{outcomes: []int{route.QueueWaiting}}
+
+	start := time.Now()
+	_, err := waitForRoute(context.Background(), q, zap.NewNop(), newQueuedRoute(3*routeQueuePollInterval), key.PriorityNormal, 0)
+	if !errors.Is(err, errRouteQueueTimeout) {
+		t.Fatalf("expected the request to time out in the queue, got: %v", err)
+	}
+
+	if waited := time.Since(start); waited < 3*routeQueuePollInterval {
+		t.Fatalf("expected the request to wait for the max wait, waited: %s", waited)
+	}
+
+	if q.left != 1 {
+		t.Fatalf("expected the timed out ticket to leave the queue, got %d leaves", q.left)
+	}
+}
+
+func TestWaitForRouteLetsRequestsThroughOnQueueErrors(t *testing.T) {
+	q := &scriptedQueue{err: errors.New("redis is down")}
+
+	release, err := waitForRoute(context.Background(), q, zap.NewNop(), newQueuedRoute(time.Second), key.PriorityNormal, 0)
+	if err != nil || release == nil {
+		t.Fatalf("expected an unavailable queue to let the request through, got: %v", err)
+	}
+}
+
+func TestWaitForRouteSkipsQueueForHighPriority(t *testing.T) {
+	q := &scriptedQueue{outcomes: []int{route.QueueAdmitted}}
+
+	if _, err := waitForRoute(context.Background(), q, zap.NewNop(), newQueuedRoute(time.Second), key.PriorityHigh, 0); err != nil {
+		t.Fatalf("expected a high priority request to be admitted, got: %v", err)
+	}
+
+	if len(q.skipped) != 1 || !q.skipped[0] {
+		t.Fatalf("expected a high priority request to skip the queue, got: %v", q.skipped)
+	}
+}
@@ -0,0 +1,49 @@
+package proxy
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+type routeRateLimiter interface {
+	Take(routeId, keyId string, limit int, window time.Duration, fairShare bool) (int, time.Duration, error)
+}
+
+var (
+	errRouteRateLimited  = errors.New("route exceeded rate limit")
+	errRouteShareLimited = errors.New("key exceeded its share of route rate limit")
+)
+
+// takeRouteRateLimit counts a request of keyId against the rate limit of the
+// route. Once the limit or the share of the key is used up, it returns the
+// seconds until the window resets. Limiter errors let requests through so
+// that an unavailable limiter does not take routes down.
+func takeRouteRateLimit(rrl routeRateLimiter, log *zap.Logger, rc *route.Route, keyId string) (int, error) {
+	rl := rc.RateLimit
+	tags := []string{"route:" + rc.Id}
+
+	outcome, left, err := rrl.Take(rc.Id, keyId, rl.Requests, rl.Window(), rl.FairShare)
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.take_route_rate_limit.take_error", tags, 1)
+		log.Debug("error when taking route rate limit", zap.Error(err))
+		return 0, nil
+	}
+
+	retryAfter := int(math.Max(1, math.Ceil(left.Seconds())))
+
+	switch outcome {
+	case route.RateLimitExceeded:
+		telemetry.Incr("bricksllm.proxy.take_route_rate_limit.exceeded", tags, 1)
+		return retryAfter, errRouteRateLimited
+	case route.RateLimitShareExceeded:
+		telemetry.Incr("bricksllm.proxy.take_route_rate_limit.share_exceeded", append(tags, "key:"+keyId), 1)
+		return retryAfter, errRouteShareLimited
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,137 @@
+package proxy
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/gin-gonic/gin"
+)
+
+const priorityHeader = "X-BricksLLM-Priority"
+
+// requestPriority reads the priority header and caps it at the max priority of
+// the key. Requests without the header are normal priority.
+func requestPriority(c *gin.Context, kc *key.ResponseKey) (key.Priority, bool) {
+	raw := strings.ToLower(strings.TrimSpace(c.GetHeader(priorityHeader)))
+	if len(raw) == 0 {
+		return key.PriorityNormal.Clamp(kc.MaxPriority), true
+	}
+
+	p := key.Priority(raw)
+	if !p.Valid() {
+		return "", false
+	}
+
+	return p.Clamp(kc.MaxPriority), true
+}
+
+type waiter struct {
+	ready chan struct{}
+}
+
+type keyQueue struct {
+	inFlight int
+	waiting  [3][]*waiter
+}
+
+func (q *keyQueue) empty() bool {
+	for _, w := range q.waiting {
+		if len(w) != 0 {
+			return false
+		}
+	}
+
+	return q.inFlight == 0
+}
+
+// keyScheduler caps the requests of a key that are in flight at once. Waiting
+// requests are admitted highest priority first, and in arrival order within a
+// priority, so that background jobs of a tenant yield to its interactive
+// traffic.
+type keyScheduler struct {
+	lock sync.Mutex
+	keys map[string]*keyQueue
+}
+
+func newKeyScheduler() *keyScheduler {
+	return &keyScheduler{
+		keys: map[string]*keyQueue{},
+	}
+}
+
+// Acquire blocks until the request is admitted or ctx is done. The returned
+// release must be called once the request completes.
+func (s *keyScheduler) Acquire(ctx context.Context, keyId string, limit int, p key.Priority) (func(), error) {
+	release := func() {
+		s.release(keyId, limit)
+	}
+
+	s.lock.Lock()
+	q, ok := s.keys[keyId]
+	if !ok {
+		q = &keyQueue{}
+		s.keys[keyId] = q
+	}
+
+	if q.inFlight < limit {
+		q.inFlight++
+		s.lock.Unlock()
+		return release, nil
+	}
+
+	w := &waiter{ready: make(chan struct{})}
+	rank := p.Rank()
+	q.waiting[rank] = append(q.waiting[rank], w)
+	s.lock.Unlock()
+
+	select {
+	case <-w.ready:
+		return release, nil
+	case <-ctx.Done():
+	}
+
+	s.lock.Lock()
+	for i, queued := range q.waiting[rank] {
+		if queued == w {
+			q.waiting[rank] = append(q.waiting[rank][:i], q.waiting[rank][i+1:]...)
+			if q.empty() {
+				delete(s.keys, keyId)
+			}
+
+			s.lock.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+	s.lock.Unlock()
+
+	// the request was admitted while giving up, hand its slot to the next one
+	release()
+	return nil, ctx.Err()
+}
+
+func (s *keyScheduler) release(keyId string, limit int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	q, ok := s.keys[keyId]
+	if !ok {
+		return
+	}
+
+	q.inFlight--
+
+	for rank := len(q.waiting) - 1; rank >= 0 && q.inFlight < limit; rank-- {
+		for len(q.waiting[rank]) != 0 && q.inFlight < limit {
+			w := q.waiting[rank][0]
+			q.waiting[rank] = q.waiting[rank][1:]
+			q.inFlight++
+			close(w.ready)
+		}
+	}
+
+	if q.empty() {
+		delete(s.keys, keyId)
+	}
+}
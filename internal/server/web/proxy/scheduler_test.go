@@ -0,0 +1,180 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/gin-gonic/gin"
+)
+
+// waitQueued blocks until n requests of keyId are waiting in s.
+func waitQueued(t *testing.T, s *keyScheduler, keyId string, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		s.lock.Lock()
+		queued := 0
+		if q, ok := s.keys[keyId]; ok {
+			for _, w := range q.waiting {
+				queued += len(w)
+			}
+		}
+		s.lock.Unlock()
+
+		if queued == n {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("expected %d requests to be waiting", n)
+}
+
+func TestKeySchedulerAdmitsHighestPriorityFirst(t *testing.T) {
+	s := newKeyScheduler()
+
+	release, err := s.Acquire(context.Background(), "key", 1, key.PriorityNormal)
+	if err != nil {
+		t.Fatalf("expected a request under the limit to be admitted, got: %v", err)
+	}
+
+	admitted := make(chan string, 4)
+	queue := func(name string, p key.Priority) {
+		go func() {
+			done, err := s.Acquire(context.Background(), "key", 1, p)
+			if err != nil {
+				admitted <- "error: " + err.Error()
+				return
+			}
+
+			admitted <- name
+			done()
+		}()
+	}
+
+	queue("low", key.PriorityLow)
+	waitQueued(t, s, "key", 1)
+	queue("normal-1", key.PriorityNormal)
+	waitQueued(t, s, "key", 2)
+	queue("normal-2", key.PriorityNormal)
+	waitQueued(t, s, "key", 3)
+	queue("high", key.PriorityHigh)
+	waitQueued(t, s, "key", 4)
+
+	release()
+
+	order := []string{}
+	for i := 0; i < 4; i++ {
+		order = append(order, <-admitted)
+	}
+
+	expected := []string{"high", "normal-1", "normal-2", "low"}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("expected requests to be admitted in order %v, got: %v", expected, order)
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.keys) != 0 {
+		t.Fatalf("expected the key to be forgotten once it is idle")
+	}
+}
+
+func TestKeySchedulerGivesUpAtTheDeadline(t *testing.T) {
+	s := newKeyScheduler()
+
+	release, _ := s.Acquire(context.Background(), "key", 1, key.PriorityNormal)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := s.Acquire(ctx, "key", 1, key.PriorityHigh); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a waiting request to give up at its deadline, got: %v", err)
+	}
+
+	if waited := time.Since(start); waited < 20*time.Millisecond {
+		t.Fatalf("expected the request to wait until its deadline, waited: %s", waited)
+	}
+
+	waitQueued(t, s, "key", 0)
+
+	// the request that gave up must not hold on to the slot freed for it
+	release()
+
+	next, err := s.Acquire(context.Background(), "key", 1, key.PriorityLow)
+	if err != nil {
+		t.Fatalf("expected the freed slot to be available, got: %v", err)
+	}
+	next()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.keys) != 0 {
+		t.Fatalf("expected the key to be forgotten once it is idle")
+	}
+}
+
+func TestKeySchedulerAdmitsUpToTheLimitAtOnce(t *testing.T) {
+	s := newKeyScheduler()
+
+	releases := []func(){}
+	for i := 0; i < 3; i++ {
+		release, err := s.Acquire(context.Background(), "key", 3, key.PriorityLow)
+		if err != nil {
+			t.Fatalf("expected request %d to be admitted under the limit, got: %v", i, err)
+		}
+
+		releases = append(releases, release)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := s.Acquire(ctx, "key", 3, key.PriorityHigh); err == nil {
+		t.Fatalf("expected a request over the limit to wait")
+	}
+
+	if _, err := s.Acquire(context.Background(), "other", 3, key.PriorityLow); err != nil {
+		t.Fatalf("expected other keys not to be limited, got: %v", err)
+	}
+
+	for _, release := range releases {
+		release()
+	}
+}
+
+func TestRequestPriorityIsCappedByTheKey(t *testing.T) {
+	cases := []struct {
+		header   string
+		max      key.Priority
+		expected key.Priority
+		ok       bool
+	}{
+		{"", "", key.PriorityNormal, true},
+		{"HIGH", "", key.PriorityHigh, true},
+		{"high", key.PriorityNormal, key.PriorityNormal, true},
+		{"", key.PriorityLow, key.PriorityLow, true},
+		{"urgent", "", "", false},
+	}
+
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/providers/openai/v1/chat/completions", nil)
+		c.Request.Header.Set(priorityHeader, tc.header)
+
+		p, ok := requestPriority(c, &key.ResponseKey{MaxPriority: tc.max})
+		if p != tc.expected || ok != tc.ok {
+			t.Fatalf("expected header %q under max %q to be %q, %v, got: %q, %v", tc.header, tc.max, tc.expected, tc.ok, p, ok)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/gin-gonic/gin"
+)
+
+const sigV4Algorithm = "AWS4-HMAC-SHA256"
+
+// signingFailureCodes are upstream error codes that mean the signature or the
+// credential behind it was rejected.
+var signingFailureCodes = map[string]bool{
+	"InvalidSignatureException":   true,
+	"SignatureDoesNotMatch":       true,
+	"UnrecognizedClientException": true,
+	"InvalidClientTokenId":        true,
+	"IncompleteSignature":         true,
+	"ExpiredTokenException":       true,
+}
+
+// signingRecorder captures the signature metadata of the last attempt of an
+// aws sdk call so that it can be recorded on the event.
+type signingRecorder struct {
+	lock    sync.Mutex
+	signing *event.Signing
+}
+
+func (r *signingRecorder) withBedrockOptions(o *bedrockruntime.Options) {
+	o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+		return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("BricksSigningRecorder", r.handleFinalize), middleware.After)
+	})
+}
+
+func (r *signingRecorder) handleFinalize(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+	if req, ok := in.Request.(*smithyhttp.Request); ok {
+		if s := parseSigV4(req.Header.Get("Authorization"), req.Header.Get("X-Amz-Date")); s != nil {
+			r.lock.Lock()
+			r.signing = s
+			r.lock.Unlock()
+		}
+	}
+
+	return next.HandleFinalize(ctx, in)
+}
+
+// result returns the recorded signing marked as verified unless err shows that
+// the upstream rejected the signature. Nil is returned when nothing was signed.
+func (r *signingRecorder) result(err error) *event.Signing {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.signing == nil {
+		return nil
+	}
+
+	s := *r.signing
+	if err == nil {
+		s.Verified = true
+		return &s
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		if signingFailureCodes[apiErr.ErrorCode()] {
+			s.Failure = apiErr.ErrorCode()
+			return &s
+		}
+
+		// any other api error means the upstream accepted the signature
+		s.Verified = true
+	}
+
+	return &s
+}
+
+func (r *signingRecorder) set(c *gin.Context, err error) {
+	s := r.result(err)
+	if s == nil {
+		return
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return
+	}
+
+	c.Set("signing", data)
+}
+
+// parseSigV4 reads the credential scope out of an AWS signature version 4
+// authorization header. The signature itself is not kept.
+func parseSigV4(authorization, amzDate string) *event.Signing {
+	if !strings.HasPrefix(authorization, sigV4Algorithm+" ") {
+		return nil
+	}
+
+	s := &event.Signing{
+		Scheme: sigV4Algorithm,
+	}
+
+	for _, part := range strings.Split(strings.TrimPrefix(authorization, sigV4Algorithm+" "), ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+
+		switch name {
+		case "Credential":
+			// access key id / date / region / service / aws4_request
+			scope := strings.Split(value, "/")
+			s.Identity = scope[0]
+			if len(scope) >= 4 {
+				s.Region = scope[2]
+				s.Service = scope[3]
+			}
+		case "SignedHeaders":
+			s.SignedHeaders = strings.Split(value, ";")
+		}
+	}
+
+	if t, err := time.Parse("20060102T150405Z", amzDate); err == nil {
+		s.SignedAt = t.Unix()
+	}
+
+	return s
+}
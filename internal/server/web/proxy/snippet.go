@@ -0,0 +1,47 @@
+package proxy
+
+import (
+	"encoding/json"
+	"math/rand"
+
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+// responseSnippet returns the snippet to store in place of the response when
+// the route samples response snippets and this request was picked. It returns
+// nil otherwise.
+func responseSnippet(c *gin.Context, body []byte) []byte {
+	raw, ok := c.Get("route_config")
+	if !ok {
+		return nil
+	}
+
+	rc, ok := raw.(*route.Route)
+	if !ok || rc.SnippetConfig == nil || !rc.SnippetConfig.Enabled {
+		return nil
+	}
+
+	if rand.Float64() >= rc.SnippetConfig.SampleRate {
+		return nil
+	}
+
+	if c.GetBool("stream") {
+		streamed, _ := c.Get("streaming_response")
+		body, _ = streamed.([]byte)
+	}
+
+	if len(body) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(rc.SnippetConfig.NewSnippet(string(body)))
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.response_snippet.json_marshal_error", nil, 1)
+		return nil
+	}
+
+	telemetry.Incr("bricksllm.proxy.response_snippet.sampled", nil, 1)
+	return data
+}
@@ -0,0 +1,29 @@
+package proxy
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+type settingSpendReader interface {
+	GetSpend(settingId string) (int64, error)
+}
+
+// spendLimitReached reports whether setting used up its monthly spend limit.
+// Requests are let through when the spend cannot be read, so that an outage of
+// the cache does not stop the gateway.
+func spendLimitReached(ssr settingSpendReader, log *zap.Logger, prod bool, setting *provider.Setting) bool {
+	if ssr == nil || setting.SpendLimit == nil {
+		return false
+	}
+
+	spend, err := ssr.GetSpend(setting.Id)
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.spend_limit_reached.get_spend_error", nil, 1)
+		logError(log, "error when getting spend of provider setting", prod, err)
+		return false
+	}
+
+	return setting.SpendLimit.Reached(spend)
+}
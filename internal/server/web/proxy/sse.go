@@ -0,0 +1,197 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSseMaxLineSize = 8 << 20
+	sseReadBufferSize     = 64 << 10
+)
+
+var errSseLineTooLong = errors.New("server sent event line exceeds the max line size")
+
+func getSseMiddleware(maxLineSize int) gin.HandlerFunc {
+	if maxLineSize <= 0 {
+		maxLineSize = defaultSseMaxLineSize
+	}
+
+	return func(c *gin.Context) {
+		c.Set("sseMaxLineSize", maxLineSize)
+	}
+}
+
+// sseReader reads the lines of a server sent event stream. Lines are
+// reassembled from as many reads as they take, up to the max line size, and a
+// last line without a line ending is returned rather than dropped.
+//
+// The data of an event may be spread over several data lines. When a data
+// line does not hold a complete JSON frame by itself, the data lines that
+// follow it are merged into it until the frame parses, so that handlers keep
+// seeing one frame per line.
+type sseReader struct {
+	r       *bufio.Reader
+	max     int
+	source  string
+	pending []byte
+	err     error
+}
+
+func newSseReader(r io.Reader, maxLineSize int, source string) *sseReader {
+	if maxLineSize <= 0 {
+		maxLineSize = defaultSseMaxLineSize
+	}
+
+	return &sseReader{
+		r:      bufio.NewReaderSize(r, sseReadBufferSize),
+		max:    maxLineSize,
+		source: source,
+	}
+}
+
+func (s *sseReader) corrupted(reason string) {
+	telemetry.Incr("bricksllm.proxy.sse_reader.corrupted_frames", []string{
+		"reason:" + reason,
+		"source:" + s.source,
+	}, 1)
+}
+
+// readRawLine reads a line including its line ending. An oversized line is
+// drained up to its end, so that the stream stays aligned on lines.
+func (s *sseReader) readRawLine() ([]byte, error) {
+	if s.pending != nil {
+		line := s.pending
+		s.pending = nil
+		return line, nil
+	}
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	var line []byte
+	tooLong := false
+	for {
+		frag, err := s.r.ReadSlice('\n')
+		if !tooLong {
+			if len(line)+len(frag) > s.max {
+				tooLong = true
+				line = nil
+			} else {
+				line = append(line, frag...)
+			}
+		}
+
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+
+		if err != nil {
+			s.err = err
+			if tooLong {
+				s.corrupted("too_long")
+				return nil, fmt.Errorf("%w of %d bytes", errSseLineTooLong, s.max)
+			}
+
+			if len(line) != 0 {
+				if err == io.EOF {
+					s.corrupted("unterminated")
+				}
+
+				return line, nil
+			}
+
+			return nil, err
+		}
+
+		if tooLong {
+			s.corrupted("too_long")
+			return nil, fmt.Errorf("%w of %d bytes", errSseLineTooLong, s.max)
+		}
+
+		return line, nil
+	}
+}
+
+// dataOf returns the data of a data line. Only the line ending is trimmed,
+// since a frame split mid token can end or start with meaningful spaces.
+func dataOf(line []byte) ([]byte, bool) {
+	trimmed := bytes.TrimRight(line, "\r\n")
+	if !bytes.HasPrefix(trimmed, headerData) {
+		return nil, false
+	}
+
+	return bytes.TrimPrefix(trimmed, headerData), true
+}
+
+func isFrame(data []byte) bool {
+	return len(data) == 0 || string(data) == "[DONE]" || json.Valid(data)
+}
+
+// ReadLine returns the next line of the stream, or the data lines of a frame
+// merged into one line.
+func (s *sseReader) ReadLine() ([]byte, error) {
+	line, err := s.readRawLine()
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := dataOf(line)
+	if !ok || isFrame(data) {
+		return line, nil
+	}
+
+	parts := [][]byte{data}
+	size := len(data)
+	for {
+		next, err := s.readRawLine()
+		if err != nil {
+			if errors.Is(err, errSseLineTooLong) {
+				return nil, err
+			}
+
+			break
+		}
+
+		more, ok := dataOf(next)
+		if !ok {
+			s.pending = next
+			break
+		}
+
+		size += len(more)
+		if size > s.max {
+			s.corrupted("too_long")
+			return nil, fmt.Errorf("%w of %d bytes", errSseLineTooLong, s.max)
+		}
+
+		parts = append(parts, more)
+
+		// the line feed that joins data lines is only valid json between
+		// tokens, where leaving it out means the same, so the parts are
+		// concatenated. That also mends frames split in the middle of a
+		// string.
+		if joined := bytes.Join(parts, nil); json.Valid(joined) {
+			telemetry.Incr("bricksllm.proxy.sse_reader.reassembled_frames", []string{"source:" + s.source}, 1)
+			return append(append([]byte("data: "), joined...), '\n'), nil
+		}
+	}
+
+	// the frame never parsed, so its lines are passed on as they were read
+	s.corrupted("unparseable")
+
+	lines := [][]byte{}
+	for _, part := range parts {
+		lines = append(lines, append(append([]byte("data: "), part...), '\n'))
+	}
+
+	return bytes.Join(lines, nil), nil
+}
@@ -0,0 +1,169 @@
+package proxy
+
+import (
+	"context"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+// segment is a part of a request spent in the gateway.
+type segment int
+
+const (
+	segmentAuth segment = iota
+	segmentPolicy
+	segmentCacheLookup
+	segmentQueueWait
+	segmentCount
+)
+
+// requestTimings collects how long a request spends in each segment of the
+// proxy. Upstream segments are observed through an http trace, whose hooks
+// run on the goroutines of the transport, hence the lock.
+type requestTimings struct {
+	lock          sync.Mutex
+	segments      [segmentCount]time.Duration
+	upstreamStart time.Time
+	upstreamTtfb  time.Duration
+	upstream      time.Duration
+}
+
+func newRequestTimings(c *gin.Context) *requestTimings {
+	t := &requestTimings{}
+	c.Set("timings", t)
+
+	return t
+}
+
+// timingsOf returns the timings of the request, or nil when the request did not
+// go through the middleware. Every method is safe to call on nil.
+func timingsOf(c *gin.Context) *requestTimings {
+	if raw, exists := c.Get("timings"); exists {
+		if t, ok := raw.(*requestTimings); ok {
+			return t
+		}
+	}
+
+	return nil
+}
+
+// observe adds the time since start to a segment.
+func (t *requestTimings) observe(s segment, start time.Time) {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.segments[s] += time.Since(start)
+}
+
+// startUpstream marks the first upstream call. Retries and follow-up calls
+// count towards the same segment.
+func (t *requestTimings) startUpstream() {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.upstreamStart.IsZero() {
+		t.upstreamStart = time.Now()
+	}
+}
+
+func (t *requestTimings) firstUpstreamByte() {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.upstreamStart.IsZero() && t.upstreamTtfb == 0 {
+		t.upstreamTtfb = time.Since(t.upstreamStart)
+	}
+}
+
+// finishUpstream closes the upstream segment once the handler returns, so that
+// streamed responses are measured until their last chunk.
+func (t *requestTimings) finishUpstream() {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.upstreamStart.IsZero() {
+		t.upstream = time.Since(t.upstreamStart)
+	}
+}
+
+// traceUpstream returns ctx with an http trace recording the upstream segments
+// of the request of c. It also carries the upstream defaults of the provider
+// setting, the retry policy, the transport config and the output schema,
+// since every upstream request is created with it.
+func traceUpstream(ctx context.Context, c *gin.Context) context.Context {
+	ctx = withUpstreamDefaults(ctx, c)
+	ctx = withRetryPolicy(ctx, c)
+	ctx = withTransportConfig(ctx, c)
+	ctx = withOutputSchema(ctx, c)
+
+	t := timingsOf(c)
+	if t == nil {
+		return ctx
+	}
+
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn: func(string) {
+			t.startUpstream()
+		},
+		GotFirstResponseByte: t.firstUpstreamByte,
+	})
+}
+
+// report emits a histogram per segment the request went through and returns
+// the segments for the event.
+func (t *requestTimings) report() *event.Timings {
+	if t == nil {
+		return nil
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	reported := []struct {
+		name string
+		dur  time.Duration
+	}{
+		{"auth", t.segments[segmentAuth]},
+		{"policy", t.segments[segmentPolicy]},
+		{"cache_lookup", t.segments[segmentCacheLookup]},
+		{"queue_wait", t.segments[segmentQueueWait]},
+		{"upstream_ttfb", t.upstreamTtfb},
+		{"upstream", t.upstream},
+	}
+
+	for _, s := range reported {
+		if s.dur > 0 {
+			telemetry.Timing("bricksllm.proxy.timings."+s.name, s.dur, nil, 1)
+		}
+	}
+
+	return &event.Timings{
+		AuthInMs:         t.segments[segmentAuth].Milliseconds(),
+		PolicyInMs:       t.segments[segmentPolicy].Milliseconds(),
+		CacheLookupInMs:  t.segments[segmentCacheLookup].Milliseconds(),
+		QueueWaitInMs:    t.segments[segmentQueueWait].Milliseconds(),
+		UpstreamTtfbInMs: t.upstreamTtfb.Milliseconds(),
+		UpstreamInMs:     t.upstream.Milliseconds(),
+	}
+}
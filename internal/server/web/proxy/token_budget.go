@@ -0,0 +1,66 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+type tokenBucket interface {
+	Reserve(settingId string, tokens, tokensPerMinute int, maxWait time.Duration) (time.Duration, bool, error)
+	Refund(settingId string, tokens, tokensPerMinute int) error
+}
+
+var errTokenBudgetExceeded = errors.New("tokens per minute budget of provider setting exceeded")
+
+// paceTokenBudget delays a request until the token budget of its provider
+// setting can cover it. It returns the seconds after which to retry when the
+// wait would exceed the max delay of the budget. Bucket errors let requests
+// through so that an unavailable bucket does not take the setting down. When
+// the client goes away while waiting, the reserved tokens are given back and
+// the error of ctx is returned.
+func paceTokenBudget(ctx context.Context, tb tokenBucket, log *zap.Logger, setting *provider.Setting, body []byte) (int, error) {
+	budget := setting.TokenBudget
+	tags := []string{"provider:" + setting.Provider}
+
+	tokens := provider.EstimateBudgetTokens(body)
+	wait, reserved, err := tb.Reserve(setting.Id, tokens, budget.TokensPerMinute, budget.MaxDelay())
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.pace_token_budget.reserve_error", tags, 1)
+		log.Debug("error when reserving token budget", zap.Error(err))
+		return 0, nil
+	}
+
+	if !reserved {
+		telemetry.Incr("bricksllm.proxy.pace_token_budget.exceeded", tags, 1)
+		return int(math.Max(1, math.Ceil(wait.Seconds()))), errTokenBudgetExceeded
+	}
+
+	if wait <= 0 {
+		return 0, nil
+	}
+
+	telemetry.Incr("bricksllm.proxy.pace_token_budget.delayed", tags, 1)
+	telemetry.Timing("bricksllm.proxy.pace_token_budget.delay", wait, tags, 1)
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return 0, nil
+	case <-ctx.Done():
+		telemetry.Incr("bricksllm.proxy.pace_token_budget.cancelled", tags, 1)
+		if err := tb.Refund(setting.Id, tokens, budget.TokensPerMinute); err != nil {
+			telemetry.Incr("bricksllm.proxy.pace_token_budget.refund_error", tags, 1)
+			log.Debug("error when refunding token budget", zap.Error(err))
+		}
+
+		return 0, ctx.Err()
+	}
+}
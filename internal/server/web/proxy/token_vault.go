@@ -0,0 +1,91 @@
+package proxy
+
+import (
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+// storeTokens keeps the tokens a request was tokenized with in the token
+// vault. It runs off the request path, since the values of the request are
+// already known to its detokenizer.
+func storeTokens(pm PoliciesManager, policyId string, tokens map[string]string, log *zap.Logger) {
+	if err := pm.StoreTokens(policyId, tokens); err != nil {
+		telemetry.Incr("bricksllm.proxy.store_tokens.store_error", nil, 1)
+		log.Debug("error when storing tokens", zap.Error(err))
+	}
+}
+
+// detokenizer puts the values of tokens in a response back before it reaches
+// the client. Tokens of the request are put back from memory, and tokens the
+// model repeats from earlier requests are looked up in the token vault.
+type detokenizer struct {
+	pm       PoliciesManager
+	policyId string
+	values   map[string]string
+	missing  map[string]bool
+	log      *zap.Logger
+}
+
+func newDetokenizer(pm PoliciesManager, policyId string, tokens map[string]string, log *zap.Logger) *detokenizer {
+	values := map[string]string{}
+	for token, value := range tokens {
+		values[token] = value
+	}
+
+	return &detokenizer{
+		pm:       pm,
+		policyId: policyId,
+		values:   values,
+		missing:  map[string]bool{},
+		log:      log,
+	}
+}
+
+// lookup loads the values of the tokens in text that are not known yet.
+func (d *detokenizer) lookup(text string) {
+	unknown := []string{}
+	for _, token := range policy.FindTokens(text) {
+		if _, ok := d.values[token]; !ok && !d.missing[token] {
+			unknown = append(unknown, token)
+		}
+	}
+
+	if len(unknown) == 0 {
+		return
+	}
+
+	found, err := d.pm.GetTokenValues(d.policyId, unknown)
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.detokenizer.lookup.get_token_values_error", nil, 1)
+		d.log.Debug("error when getting token values", zap.Error(err))
+		return
+	}
+
+	for _, token := range unknown {
+		value, ok := found[token]
+		if !ok {
+			d.missing[token] = true
+			continue
+		}
+
+		d.values[token] = value
+	}
+}
+
+func (d *detokenizer) detokenize(text string) string {
+	d.lookup(text)
+	return policy.Detokenize(text, d.values)
+}
+
+func (d *detokenizer) detokenizeBody(body []byte) []byte {
+	d.lookup(string(body))
+
+	updated, err := policy.DetokenizeResponseBody(body, d.values)
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.detokenizer.detokenize_body.marshal_error", nil, 1)
+		return body
+	}
+
+	return updated
+}
@@ -0,0 +1,268 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/key"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/tool"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	toolsHeader      = "X-BRICKS-TOOLS"
+	toolRoundsHeader = "X-BRICKS-TOOL-ROUNDS"
+)
+
+type ToolBroker interface {
+	GetTool(name string) *tool.Tool
+	MaxRounds() int
+	Execute(ctx context.Context, keyId string, round int, calls []*tool.Call) []*tool.Result
+}
+
+// brokerChatCompletion attaches the registered tools named in the
+// X-BRICKS-TOOLS header to a chat completion request and answers the tool
+// calls of the model until it stops calling brokered tools. Responses calling
+// tools defined by the client are returned as is so that the client can
+// handle them.
+func brokerChatCompletion(c *gin.Context, prod, private bool, client http.Client, e estimator, tb ToolBroker, names string) {
+	log := util.GetLogFromCtx(c)
+	telemetry.Incr("bricksllm.proxy.broker_chat_completion.requests", nil, 1)
+
+	if c.GetBool("stream") {
+		JSON(c, http.StatusBadRequest, "[BricksLLM] brokered tools are not supported for streaming requests")
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		logError(log, "error when reading brokered chat completion request body", prod, err)
+		JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read request body")
+		return
+	}
+
+	defined := map[string]bool{}
+	for _, name := range gjson.GetBytes(body, "tools.#.function.name").Array() {
+		defined[name.String()] = true
+	}
+
+	brokered := map[string]bool{}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 || brokered[name] {
+			continue
+		}
+
+		t := tb.GetTool(name)
+		if t == nil {
+			telemetry.Incr("bricksllm.proxy.broker_chat_completion.tool_not_found", nil, 1)
+			JSON(c, http.StatusBadRequest, "[BricksLLM] tool is not registered: "+name)
+			return
+		}
+
+		if defined[name] {
+			JSON(c, http.StatusBadRequest, "[BricksLLM] tool is already defined in the request: "+name)
+			return
+		}
+
+		definition := goopenai.Tool{
+			Type: goopenai.ToolTypeFunction,
+			Function: &goopenai.FunctionDefinition{
+				Name:        t.Name,
+				Description: t.Description,
+			},
+		}
+
+		if len(t.Parameters) != 0 {
+			definition.Function.Parameters = t.Parameters
+		}
+
+		data, err := json.Marshal(definition)
+		if err != nil {
+			logError(log, "error when marshalling brokered tool definition", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to attach brokered tools")
+			return
+		}
+
+		body, err = sjson.SetRawBytes(body, "tools.-1", data)
+		if err != nil {
+			logError(log, "error when attaching brokered tool definition", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to attach brokered tools")
+			return
+		}
+
+		brokered[name] = true
+	}
+
+	keyId := ""
+	if raw, exists := c.Get("key"); exists {
+		if kc, ok := raw.(*key.ResponseKey); ok {
+			keyId = kc.KeyId
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
+	defer cancel()
+
+	model := c.GetString("model")
+	usage := goopenai.Usage{}
+	var cost float64 = 0
+	defer func() {
+		c.Set("costInUsd", cost)
+		c.Set("promptTokenCount", usage.PromptTokens)
+		c.Set("completionTokenCount", usage.CompletionTokens)
+	}()
+
+	start := time.Now()
+	for round := 0; ; round++ {
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), http.MethodPost, "https://api.openai.com/v1/chat/completions", bytes.NewReader(body))
+		if err != nil {
+			logError(log, "error when creating openai http request", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create openai http request")
+			return
+		}
+
+		copyHttpHeaders(c.Request, req, c.GetBool("removeUserAgent"))
+		req.Header.Del(toolsHeader)
+		req.Header.Del("Content-Length")
+
+		res, err := client.Do(req)
+		if err != nil {
+			telemetry.Incr("bricksllm.proxy.broker_chat_completion.http_client_error", nil, 1)
+
+			logError(log, "error when sending http request to openai", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to send http request to openai")
+			return
+		}
+
+		data, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			logError(log, "error when reading openai http chat completion response body", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to read openai response body")
+			return
+		}
+
+		c.Header(toolRoundsHeader, strconv.Itoa(round))
+
+		if res.StatusCode != http.StatusOK {
+			telemetry.Incr("bricksllm.proxy.broker_chat_completion.error_response", nil, 1)
+
+			errorRes := &goopenai.ErrorResponse{}
+			if err := json.Unmarshal(data, errorRes); err == nil {
+				logOpenAiError(log, prod, errorRes)
+			}
+
+			c.Data(res.StatusCode, "application/json", data)
+			return
+		}
+
+		chatRes := &goopenai.ChatCompletionResponse{}
+		if err := json.Unmarshal(data, chatRes); err != nil {
+			logError(log, "error when unmarshalling openai http chat completion response body", prod, err)
+			c.Data(res.StatusCode, "application/json", data)
+			return
+		}
+
+		logChatCompletionResponse(log, prod, private, chatRes)
+
+		usage.PromptTokens += chatRes.Usage.PromptTokens
+		usage.CompletionTokens += chatRes.Usage.CompletionTokens
+		cost += estimateBrokeredRoundCost(c, prod, e, model, chatRes.Usage)
+
+		calls := brokeredCalls(chatRes, brokered)
+		if len(calls) == 0 || round >= tb.MaxRounds() {
+			telemetry.Incr("bricksllm.proxy.broker_chat_completion.success", nil, 1)
+			telemetry.Timing("bricksllm.proxy.broker_chat_completion.latency", time.Since(start), nil, 1)
+
+			c.Data(res.StatusCode, "application/json", data)
+			return
+		}
+
+		body, err = sjson.SetRawBytes(body, "messages.-1", []byte(gjson.GetBytes(data, "choices.0.message").Raw))
+		if err != nil {
+			logError(log, "error when appending assistant message to brokered chat completion request", prod, err)
+			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to continue brokered chat completion")
+			return
+		}
+
+		for _, r := range tb.Execute(ctx, keyId, round, calls) {
+			msg, err := json.Marshal(goopenai.ChatCompletionMessage{
+				Role:       goopenai.ChatMessageRoleTool,
+				Content:    r.Content,
+				ToolCallID: r.Call.Id,
+			})
+			if err == nil {
+				body, err = sjson.SetRawBytes(body, "messages.-1", msg)
+			}
+
+			if err != nil {
+				logError(log, "error when appending tool result to brokered chat completion request", prod, err)
+				JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to continue brokered chat completion")
+				return
+			}
+		}
+
+		telemetry.Incr("bricksllm.proxy.broker_chat_completion.round", nil, 1)
+	}
+}
+
+// brokeredCalls returns the tool calls of the first choice, or nothing when
+// any of them has to be handled by the client.
+func brokeredCalls(res *goopenai.ChatCompletionResponse, brokered map[string]bool) []*tool.Call {
+	if len(res.Choices) == 0 || res.Choices[0].FinishReason != goopenai.FinishReasonToolCalls {
+		return nil
+	}
+
+	calls := []*tool.Call{}
+	for _, tc := range res.Choices[0].Message.ToolCalls {
+		if !brokered[tc.Function.Name] {
+			return nil
+		}
+
+		calls = append(calls, &tool.Call{
+			Id:        tc.ID,
+			Name:      tc.Function.Name,
+			Arguments: tc.Function.Arguments,
+		})
+	}
+
+	return calls
+}
+
+func estimateBrokeredRoundCost(c *gin.Context, prod bool, e estimator, model string, usage goopenai.Usage) float64 {
+	log := util.GetLogFromCtx(c)
+
+	cost, err := e.EstimateTotalCost(model, usage.PromptTokens, usage.CompletionTokens)
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.broker_chat_completion.estimate_total_cost_error", nil, 1)
+		logError(log, "error when estimating openai cost", prod, err)
+	}
+
+	if m, exists := c.Get("cost_map"); exists {
+		if converted, ok := m.(*provider.CostMap); ok {
+			newCost, err := provider.EstimateTotalCostWithCostMaps(model, usage.PromptTokens, usage.CompletionTokens, 1000, converted.PromptCostPerModel, converted.CompletionCostPerModel)
+			if err != nil {
+				logError(log, "error when estimating openai chat completions total cost with cost maps", prod, err)
+			}
+
+			if newCost != 0 {
+				cost = newCost
+			}
+		}
+	}
+
+	return cost
+}
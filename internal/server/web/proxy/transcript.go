@@ -0,0 +1,89 @@
+package proxy
+
+import (
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+	goopenai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+)
+
+type transcriptFilter func(t *policy.Transcript) error
+
+func isTranscriptPath(path string) bool {
+	return path == "/api/providers/openai/v1/audio/transcriptions" || path == "/api/providers/openai/v1/audio/translations"
+}
+
+// setTranscriptFilter hands the policy of the key to the audio handlers, which
+// apply it to the transcribed text once the provider has responded.
+func setTranscriptFilter(c *gin.Context, p *policy.Policy, targets []policy.Target, client http.Client, scanner Scanner, cd CustomPolicyDetector, mod Moderator, log *zap.Logger) {
+	if len(targets) == 0 {
+		targets = []policy.Target{{
+			Provider: getProvider(c),
+			Model:    c.GetString("model"),
+		}}
+	}
+
+	resolved := p.Resolve(targets)
+	c.Set("transcriptFilter", transcriptFilter(func(t *policy.Transcript) error {
+		return resolved.Filter(client, t, scanner, cd, mod, log)
+	}))
+}
+
+// filterTranscript applies the policy of the key to a transcription, redacting
+// the text and segments of ar in place. It reports whether the transcription
+// is blocked.
+func filterTranscript(c *gin.Context, log *zap.Logger, prod bool, ar *goopenai.AudioResponse) bool {
+	v, ok := c.Get("transcriptFilter")
+	if !ok {
+		return false
+	}
+
+	filter, ok := v.(transcriptFilter)
+	if !ok {
+		return false
+	}
+
+	t := &policy.Transcript{Text: ar.Text}
+	for _, seg := range ar.Segments {
+		t.Segments = append(t.Segments, seg.Text)
+	}
+
+	err := filter(t)
+	if err == nil {
+		c.Set("action", "allowed")
+	}
+
+	if re, ok := err.(ruledError); ok && len(re.Rules()) != 0 {
+		c.Set("policyRules", re.Rules())
+	}
+
+	if err != nil {
+		if _, ok := err.(blockedError); ok {
+			c.Set("action", "blocked")
+			telemetry.Incr("bricksllm.proxy.filter_transcript.transcript_blocked", nil, 1)
+			return true
+		}
+
+		if _, ok := err.(warnedError); ok {
+			c.Set("action", "warned")
+		}
+
+		if _, ok := err.(redactedError); ok {
+			c.Set("action", "redacted")
+		}
+
+		logError(log, "error when filtering a transcript", prod, err)
+	}
+
+	ar.Text = t.Text
+	for i := range ar.Segments {
+		if i < len(t.Segments) {
+			ar.Segments[i].Text = t.Segments[i]
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,163 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/http2"
+)
+
+// maxTunedTransports bounds the transports kept for distinct transport
+// configs. Configs change rarely, so going over it means old configs are
+// piling up and every transport is dropped.
+const maxTunedTransports = 64
+
+// withTransportConfig carries the transport config of the request of c in
+// ctx. The config of the route the request is made to wins over the one of
+// its provider setting.
+func withTransportConfig(ctx context.Context, c *gin.Context) context.Context {
+	raw, _ := c.Get("route_config")
+	if rc, ok := raw.(*route.Route); ok {
+		ctx = provider.WithTransportConfig(ctx, rc.Transport)
+	}
+
+	raw, _ = c.Get("transportConfig")
+	if tc, ok := raw.(*provider.TransportConfig); ok {
+		ctx = provider.WithTransportConfig(ctx, tc)
+	}
+
+	return ctx
+}
+
+// tunedTransport sends requests that carry a transport config over a
+// transport built from it, so that the connections to one upstream can be
+// tuned without touching the others. Requests without one use base.
+type tunedTransport struct {
+	base       http.RoundTripper
+	lock       sync.Mutex
+	transports map[string]*http.Transport
+}
+
+func newTunedTransport(base http.RoundTripper) *tunedTransport {
+	return &tunedTransport{
+		base:       base,
+		transports: map[string]*http.Transport{},
+	}
+}
+
+func (t *tunedTransport) transportFor(tc *provider.TransportConfig) (*http.Transport, error) {
+	data, err := json.Marshal(tc)
+	if err != nil {
+		return nil, err
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if existing, ok := t.transports[string(data)]; ok {
+		return existing, nil
+	}
+
+	if len(t.transports) >= maxTunedTransports {
+		telemetry.Incr("bricksllm.proxy.tuned_transport.transports_dropped", nil, 1)
+		for _, existing := range t.transports {
+			existing.CloseIdleConnections()
+		}
+
+		t.transports = map[string]*http.Transport{}
+	}
+
+	built, err := buildTransport(tc)
+	if err != nil {
+		return nil, err
+	}
+
+	t.transports[string(data)] = built
+
+	return built, nil
+}
+
+func (t *tunedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tc := provider.TransportConfigFrom(req.Context())
+	if !tc.Set() {
+		return t.base.RoundTrip(req)
+	}
+
+	tuned, err := t.transportFor(tc)
+	if err != nil {
+		telemetry.Incr("bricksllm.proxy.tuned_transport.build_transport_error", nil, 1)
+		return t.base.RoundTrip(req)
+	}
+
+	return tuned.RoundTrip(req)
+}
+
+// buildTransport returns a transport with the settings of
+// http.DefaultTransport, overridden by the ones of tc.
+func buildTransport(tc *provider.TransportConfig) (*http.Transport, error) {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	if d := tc.Duration("dialTimeout"); d > 0 {
+		dialer.Timeout = d
+	}
+
+	if d := tc.Duration("keepAlive"); d > 0 {
+		dialer.KeepAlive = d
+	}
+
+	t := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     !tc.DisableHTTP2,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		ResponseHeaderTimeout: tc.Duration("timeout"),
+		MaxIdleConnsPerHost:   tc.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       tc.MaxConnsPerHost,
+	}
+
+	if d := tc.Duration("tlsHandshakeTimeout"); d > 0 {
+		t.TLSHandshakeTimeout = d
+	}
+
+	if d := tc.Duration("idleConnTimeout"); d > 0 {
+		t.IdleConnTimeout = d
+	}
+
+	if tc.MaxIdleConns > 0 {
+		t.MaxIdleConns = tc.MaxIdleConns
+	}
+
+	if tc.DisableHTTP2 {
+		// a non-nil empty map turns HTTP/2 off
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		return t, nil
+	}
+
+	readIdle, ping := tc.Duration("http2ReadIdleTimeout"), tc.Duration("http2PingTimeout")
+	if readIdle > 0 || ping > 0 {
+		h2, err := http2.ConfigureTransports(t)
+		if err != nil {
+			return nil, err
+		}
+
+		h2.ReadIdleTimeout = readIdle
+		h2.PingTimeout = ping
+	}
+
+	return t, nil
+}
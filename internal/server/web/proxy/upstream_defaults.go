@@ -0,0 +1,86 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/gin-gonic/gin"
+)
+
+type upstreamDefaultsKey struct{}
+
+// upstreamDefaults are the headers and query params of the provider setting
+// selected for a request.
+type upstreamDefaults struct {
+	headers     map[string]string
+	queryParams map[string]string
+}
+
+func setUpstreamDefaults(c *gin.Context, setting *provider.Setting) {
+	if len(setting.Headers) == 0 && len(setting.QueryParams) == 0 {
+		return
+	}
+
+	c.Set("upstreamDefaults", &upstreamDefaults{
+		headers:     setting.Headers,
+		queryParams: setting.QueryParams,
+	})
+}
+
+// withUpstreamDefaults carries the defaults of the request of c in ctx, where
+// the transport of the proxy client picks them up.
+func withUpstreamDefaults(ctx context.Context, c *gin.Context) context.Context {
+	raw, exists := c.Get("upstreamDefaults")
+	if !exists {
+		return ctx
+	}
+
+	d, ok := raw.(*upstreamDefaults)
+	if !ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, upstreamDefaultsKey{}, d)
+}
+
+// defaultsTransport adds the defaults found in the context of a request right
+// before it is sent, so that they replace whatever handlers copied over from
+// the client request.
+type defaultsTransport struct {
+	base http.RoundTripper
+}
+
+func (t *defaultsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	d, ok := req.Context().Value(upstreamDefaultsKey{}).(*upstreamDefaults)
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	for name, value := range d.headers {
+		req.Header.Set(name, value)
+	}
+
+	if len(d.queryParams) != 0 {
+		query := req.URL.Query()
+		for name, value := range d.queryParams {
+			query.Set(name, value)
+		}
+
+		req.URL.RawQuery = query.Encode()
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+func newUpstreamClient() http.Client {
+	return http.Client{
+		Transport: &schemaTransport{
+			base: &retryTransport{
+				base:   &defaultsTransport{base: newTunedTransport(http.DefaultTransport)},
+				budget: newRetryBudget(),
+			},
+		},
+	}
+}
@@ -1,7 +1,6 @@
 package proxy
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -39,7 +38,7 @@ func getVllmCompletionsHandler(prod, private bool, client http.Client) gin.Handl
 		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url+"/v1/completions", c.Request.Body)
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), http.MethodPost, url+"/v1/completions", c.Request.Body)
 		if err != nil {
 			logError(log, "error when creating vllm http request", prod, err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create vllm http request")
@@ -149,7 +148,7 @@ func getVllmCompletionsHandler(prod, private bool, client http.Client) gin.Handl
 			return
 		}
 
-		buffer := bufio.NewReader(res.Body)
+		buffer := newSseReader(res.Body, c.GetInt("sseMaxLineSize"), "vllm_completion")
 		content := ""
 		streamingResponse := [][]byte{}
 		defer func() {
@@ -160,7 +159,7 @@ func getVllmCompletionsHandler(prod, private bool, client http.Client) gin.Handl
 		telemetry.Incr("bricksllm.proxy.get_vllm_completions_handler.streaming_requests", nil, 1)
 
 		c.Stream(func(w io.Writer) bool {
-			raw, err := buffer.ReadBytes('\n')
+			raw, err := buffer.ReadLine()
 			if err != nil {
 				if err == io.EOF {
 					return false
@@ -387,7 +386,7 @@ func getVllmChatCompletionsHandler(prod, private bool, client http.Client) gin.H
 		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url+"/v1/chat/completions", c.Request.Body)
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), http.MethodPost, url+"/v1/chat/completions", c.Request.Body)
 		if err != nil {
 			logError(log, "error when creating vllm chat completions http request", prod, err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create vllm http request")
@@ -496,7 +495,7 @@ func getVllmChatCompletionsHandler(prod, private bool, client http.Client) gin.H
 			return
 		}
 
-		buffer := bufio.NewReader(res.Body)
+		buffer := newSseReader(res.Body, c.GetInt("sseMaxLineSize"), "vllm_chat_completion")
 		content := ""
 		streamingResponse := [][]byte{}
 		defer func() {
@@ -507,7 +506,7 @@ func getVllmChatCompletionsHandler(prod, private bool, client http.Client) gin.H
 		telemetry.Incr("bricksllm.proxy.get_vllm_chat_completions_handler.streaming_requests", nil, 1)
 
 		c.Stream(func(w io.Writer) bool {
-			raw, err := buffer.ReadBytes('\n')
+			raw, err := buffer.ReadLine()
 			if err != nil {
 				if err == io.EOF {
 					return false
@@ -596,7 +595,7 @@ func getVllmModelsHandler(prod, private bool, client http.Client) gin.HandlerFun
 		ctx, cancel := context.WithTimeout(context.Background(), c.GetDuration("requestTimeout"))
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/v1/models", nil)
+		req, err := http.NewRequestWithContext(traceUpstream(ctx, c), http.MethodGet, url+"/v1/models", nil)
 		if err != nil {
 			logError(log, "error when creating vllm models http request", prod, err)
 			JSON(c, http.StatusInternalServerError, "[BricksLLM] failed to create vllm models http request")
@@ -2,6 +2,7 @@ package memdb
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bricks-cloud/bricksllm/internal/provider/custom"
@@ -22,6 +23,7 @@ type CustomProvidersMemDb struct {
 	done            chan bool
 	interval        time.Duration
 	log             *zap.Logger
+	lastSynced      atomic.Int64
 }
 
 func NewCustomProvidersMemDb(ex CustomProvidersStorage, log *zap.Logger, interval time.Duration) (*CustomProvidersMemDb, error) {
@@ -46,14 +48,23 @@ func NewCustomProvidersMemDb(ex CustomProvidersStorage, log *zap.Logger, interva
 		log.Sugar().Infof("custom provider settings memdb updated at %d with %d providers", latetest, numberOfProviders)
 	}
 
-	return &CustomProvidersMemDb{
+	mdb := &CustomProvidersMemDb{
 		external:        ex,
 		nameToProviders: nameToProviders,
 		log:             log,
 		lastUpdated:     latetest,
 		interval:        interval,
 		done:            make(chan bool),
-	}, nil
+	}
+
+	mdb.lastSynced.Store(time.Now().UnixNano())
+
+	return mdb, nil
+}
+
+// LastSynced returns when custom providers were last fetched successfully.
+func (mdb *CustomProvidersMemDb) LastSynced() time.Time {
+	return time.Unix(0, mdb.lastSynced.Load())
 }
 
 func (mdb *CustomProvidersMemDb) GetProvider(name string) *custom.Provider {
@@ -85,6 +96,13 @@ func (mdb *CustomProvidersMemDb) SetProvider(provider *custom.Provider) {
 	mdb.nameToProviders[provider.Provider] = provider
 }
 
+func (mdb *CustomProvidersMemDb) DeleteProvider(name string) {
+	mdb.lock.Lock()
+	defer mdb.lock.Unlock()
+
+	delete(mdb.nameToProviders, name)
+}
+
 func (mdb *CustomProvidersMemDb) Listen() {
 	ticker := time.NewTicker(mdb.interval)
 	mdb.log.Info("custom providers memdb started listening for provider updates")
@@ -105,6 +123,8 @@ func (mdb *CustomProvidersMemDb) Listen() {
 					continue
 				}
 
+				mdb.lastSynced.Store(time.Now().UnixNano())
+
 				if len(providers) == 0 {
 					continue
 				}
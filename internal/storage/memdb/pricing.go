@@ -0,0 +1,150 @@
+package memdb
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/catalog"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+type PricingStorage interface {
+	GetModelPricing(overriddenOnly bool) ([]*catalog.Pricing, error)
+	GetUpdatedModelPricing(updatedAt int64) ([]*catalog.Pricing, error)
+}
+
+// PricingMemDb caches the entries of the pricing table that admins have
+// overridden, which take precedence over the built in pricing when the proxy
+// computes the cost of requests.
+type PricingMemDb struct {
+	external     PricingStorage
+	lastUpdated  int64
+	modelToPrice map[string]*catalog.Pricing
+	lock         sync.RWMutex
+	done         chan bool
+	refresh      chan struct{}
+	interval     time.Duration
+	log          *zap.Logger
+	lastSynced   atomic.Int64
+}
+
+func pricingKey(provider, model string) string {
+	return provider + "/" + model
+}
+
+func NewPricingMemDb(ex PricingStorage, log *zap.Logger, interval time.Duration) (*PricingMemDb, error) {
+	modelToPrice := map[string]*catalog.Pricing{}
+
+	pricing, err := ex.GetModelPricing(true)
+	if err != nil {
+		return nil, err
+	}
+
+	var latetest int64 = -1
+	for _, p := range pricing {
+		modelToPrice[pricingKey(p.Provider, p.Model)] = p
+		if p.UpdatedAt > latetest {
+			latetest = p.UpdatedAt
+		}
+	}
+
+	if len(pricing) != 0 {
+		log.Sugar().Infof("pricing memdb updated at %d with %d models", latetest, len(pricing))
+	}
+
+	mdb := &PricingMemDb{
+		external:     ex,
+		modelToPrice: modelToPrice,
+		log:          log,
+		lastUpdated:  latetest,
+		interval:     interval,
+		done:         make(chan bool),
+		refresh:      make(chan struct{}, 1),
+	}
+
+	mdb.lastSynced.Store(time.Now().UnixNano())
+
+	return mdb, nil
+}
+
+// LastSynced returns when pricing was last fetched successfully.
+func (mdb *PricingMemDb) LastSynced() time.Time {
+	return time.Unix(0, mdb.lastSynced.Load())
+}
+
+// GetPricing returns the overridden pricing of a model, or nil when admins
+// have not overridden it.
+func (mdb *PricingMemDb) GetPricing(provider, model string) *catalog.Pricing {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
+	return mdb.modelToPrice[pricingKey(provider, model)]
+}
+
+func (mdb *PricingMemDb) setPricing(p *catalog.Pricing) {
+	mdb.lock.Lock()
+	defer mdb.lock.Unlock()
+
+	mdb.modelToPrice[pricingKey(p.Provider, p.Model)] = p
+}
+
+func (mdb *PricingMemDb) Listen() {
+	ticker := time.NewTicker(mdb.interval)
+	mdb.log.Info("pricing memdb started listening for pricing updates")
+
+	go func() {
+		lastUpdated := mdb.lastUpdated
+
+		for {
+			select {
+			case <-mdb.done:
+				ticker.Stop()
+				mdb.log.Info("pricing memdb stopped")
+				return
+			case <-ticker.C:
+			case <-mdb.refresh:
+				telemetry.Incr("bricksllm.memdb.pricing_memdb.listen.refresh", nil, 1)
+			}
+
+			pricing, err := mdb.external.GetUpdatedModelPricing(lastUpdated)
+			if err != nil {
+				telemetry.Incr("bricksllm.memdb.pricing_memdb.listen.get_updated_model_pricing_error", nil, 1)
+
+				mdb.log.Sugar().Debugf("memdb failed to get pricing: %v", err)
+				continue
+			}
+
+			for _, p := range pricing {
+				if p.UpdatedAt > lastUpdated {
+					lastUpdated = p.UpdatedAt
+				}
+
+				mdb.log.Sugar().Infof("pricing memdb updated a model: %s", pricingKey(p.Provider, p.Model))
+				mdb.setPricing(p)
+			}
+
+			if len(pricing) != 0 {
+				mdb.log.Sugar().Infof("pricing memdb updated at %d with %d models", lastUpdated, len(pricing))
+			}
+
+			mdb.lastSynced.Store(time.Now().UnixNano())
+		}
+	}()
+}
+
+// Refresh makes the listener fetch updated pricing right away instead of
+// waiting for the next tick.
+func (mdb *PricingMemDb) Refresh() {
+	select {
+	case mdb.refresh <- struct{}{}:
+	default:
+	}
+}
+
+func (mdb *PricingMemDb) Stop() {
+	mdb.log.Info("shutting down pricing memdb...")
+
+	mdb.done <- true
+}
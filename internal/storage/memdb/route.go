@@ -2,6 +2,7 @@ package memdb
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bricks-cloud/bricksllm/internal/policy"
@@ -29,8 +30,10 @@ type RoutesMemDb struct {
 	pathToRoute         map[string]*route.Route
 	lock                sync.RWMutex
 	done                chan bool
+	refresh             chan struct{}
 	interval            time.Duration
 	log                 *zap.Logger
+	lastSynced          atomic.Int64
 }
 
 func NewRoutesMemDb(ex RoutesStorage, ps PoliciesStorage, log *zap.Logger, interval time.Duration) (*RoutesMemDb, error) {
@@ -75,7 +78,7 @@ func NewRoutesMemDb(ex RoutesStorage, ps PoliciesStorage, log *zap.Logger, inter
 		log.Sugar().Infof("policies memdb updated at %d with %d policies", platetest, numberOfPolicies)
 	}
 
-	return &RoutesMemDb{
+	mdb := &RoutesMemDb{
 		external:            ex,
 		ps:                  ps,
 		idToPolicy:          idToPolicy,
@@ -85,7 +88,17 @@ func NewRoutesMemDb(ex RoutesStorage, ps PoliciesStorage, log *zap.Logger, inter
 		lastUpdatedPolicies: platetest,
 		interval:            interval,
 		done:                make(chan bool),
-	}, nil
+		refresh:             make(chan struct{}, 1),
+	}
+
+	mdb.lastSynced.Store(time.Now().UnixNano())
+
+	return mdb, nil
+}
+
+// LastSynced returns when routes and policies were last fetched successfully.
+func (mdb *RoutesMemDb) LastSynced() time.Time {
+	return time.Unix(0, mdb.lastSynced.Load())
 }
 
 func (mdb *RoutesMemDb) GetRoute(path string) *route.Route {
@@ -97,6 +110,19 @@ func (mdb *RoutesMemDb) GetRoute(path string) *route.Route {
 	return nil
 }
 
+// GetRoutes returns every route in the memdb.
+func (mdb *RoutesMemDb) GetRoutes() []*route.Route {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
+	routes := []*route.Route{}
+	for _, r := range mdb.pathToRoute {
+		routes = append(routes, r)
+	}
+
+	return routes
+}
+
 func (mdb *RoutesMemDb) GetPolicy(id string) *policy.Policy {
 	p, ok := mdb.idToPolicy[id]
 	if ok {
@@ -106,6 +132,21 @@ func (mdb *RoutesMemDb) GetPolicy(id string) *policy.Policy {
 	return nil
 }
 
+// GetPoliciesByTags returns the policies in namespace that have one of tags.
+func (mdb *RoutesMemDb) GetPoliciesByTags(namespace string, tags []string) []*policy.Policy {
+	mdb.lock.RLock()
+	defer mdb.lock.RUnlock()
+
+	ps := []*policy.Policy{}
+	for _, p := range mdb.idToPolicy {
+		if p.SharesTag(namespace, tags) {
+			ps = append(ps, p)
+		}
+	}
+
+	return ps
+}
+
 func (mdb *RoutesMemDb) SetRoute(r *route.Route) {
 	mdb.lock.RLock()
 	defer mdb.lock.RUnlock()
@@ -120,6 +161,22 @@ func (mdb *RoutesMemDb) SetPolicy(p *policy.Policy) {
 	mdb.idToPolicy[p.Id] = p
 }
 
+func (mdb *RoutesMemDb) DeletePolicy(id string) {
+	mdb.lock.Lock()
+	defer mdb.lock.Unlock()
+
+	delete(mdb.idToPolicy, id)
+}
+
+// DeleteRoute removes the route of path so that it stops being served before
+// the memdb is rebuilt.
+func (mdb *RoutesMemDb) DeleteRoute(path string) {
+	mdb.lock.Lock()
+	defer mdb.lock.Unlock()
+
+	delete(mdb.pathToRoute, path)
+}
+
 func (mdb *RoutesMemDb) Listen() {
 	ticker := time.NewTicker(mdb.interval)
 	mdb.log.Info("routes memdb started listening for route updates")
@@ -134,66 +191,81 @@ func (mdb *RoutesMemDb) Listen() {
 				mdb.log.Info("routes memdb stopped")
 				return
 			case <-ticker.C:
-				routes, err := mdb.external.GetUpdatedRoutes(lastUpdated)
-				if err != nil {
-					telemetry.Incr("bricksllm.memdb.routes_memdb.listen.get_updated_routes_error", nil, 1)
+			case <-mdb.refresh:
+				telemetry.Incr("bricksllm.memdb.routes_memdb.listen.refresh", nil, 1)
+			}
 
-					mdb.log.Sugar().Debugf("memdb failed to get routes: %v", err)
-					continue
-				}
+			routes, err := mdb.external.GetUpdatedRoutes(lastUpdated)
+			if err != nil {
+				telemetry.Incr("bricksllm.memdb.routes_memdb.listen.get_updated_routes_error", nil, 1)
 
-				any := false
-				numberOfUpdated := 0
-				for _, r := range routes {
-					if r.UpdatedAt > lastUpdated {
-						lastUpdated = r.UpdatedAt
-					}
-
-					existing := mdb.GetRoute(r.Path)
-					if existing == nil || r.UpdatedAt > existing.UpdatedAt {
-						mdb.log.Sugar().Infof("routes memdb updated a route: %s", r.Path)
-						numberOfUpdated += 1
-						any = true
-						mdb.SetRoute(r)
-					}
+				mdb.log.Sugar().Debugf("memdb failed to get routes: %v", err)
+				continue
+			}
+
+			any := false
+			numberOfUpdated := 0
+			for _, r := range routes {
+				if r.UpdatedAt > lastUpdated {
+					lastUpdated = r.UpdatedAt
 				}
 
-				if any {
-					mdb.log.Sugar().Infof("routes memdb updated at %d with %d routes", lastUpdated, numberOfUpdated)
+				existing := mdb.GetRoute(r.Path)
+				if existing == nil || r.UpdatedAt > existing.UpdatedAt {
+					mdb.log.Sugar().Infof("routes memdb updated a route: %s", r.Path)
+					numberOfUpdated += 1
+					any = true
+					mdb.SetRoute(r)
 				}
+			}
 
-				policies, err := mdb.ps.GetUpdatedPolicies(plastUpdated)
-				if err != nil {
-					telemetry.Incr("bricksllm.memdb.routes_memdb.listen.get_updated_policies_error", nil, 1)
+			if any {
+				mdb.log.Sugar().Infof("routes memdb updated at %d with %d routes", lastUpdated, numberOfUpdated)
+			}
 
-					mdb.log.Sugar().Debugf("memdb failed to get policies: %v", err)
-					continue
-				}
+			policies, err := mdb.ps.GetUpdatedPolicies(plastUpdated)
+			if err != nil {
+				telemetry.Incr("bricksllm.memdb.routes_memdb.listen.get_updated_policies_error", nil, 1)
 
-				pany := false
-				pnumberOfUpdated := 0
-				for _, p := range policies {
-					if p.UpdatedAt > plastUpdated {
-						plastUpdated = p.UpdatedAt
-					}
-
-					existing := mdb.GetPolicy(p.Id)
-					if existing == nil || p.UpdatedAt > existing.UpdatedAt {
-						mdb.log.Sugar().Infof("routes memdb updated a policy: %s", p.Id)
-						pnumberOfUpdated += 1
-						pany = true
-						mdb.SetPolicy(p)
-					}
+				mdb.log.Sugar().Debugf("memdb failed to get policies: %v", err)
+				continue
+			}
+
+			pany := false
+			pnumberOfUpdated := 0
+			for _, p := range policies {
+				if p.UpdatedAt > plastUpdated {
+					plastUpdated = p.UpdatedAt
 				}
 
-				if pany {
-					mdb.log.Sugar().Infof("routes memdb updated at %d with %d policies", plastUpdated, pnumberOfUpdated)
+				existing := mdb.GetPolicy(p.Id)
+				if existing == nil || p.UpdatedAt > existing.UpdatedAt {
+					mdb.log.Sugar().Infof("routes memdb updated a policy: %s", p.Id)
+					pnumberOfUpdated += 1
+					pany = true
+					mdb.SetPolicy(p)
 				}
 			}
+
+			if pany {
+				mdb.log.Sugar().Infof("routes memdb updated at %d with %d policies", plastUpdated, pnumberOfUpdated)
+			}
+
+			mdb.lastSynced.Store(time.Now().UnixNano())
 		}
 	}()
 }
 
+// Refresh makes the listener fetch updated routes and policies right away
+// instead of waiting for the next tick. It never blocks, refreshes requested
+// while one is pending are coalesced.
+func (mdb *RoutesMemDb) Refresh() {
+	select {
+	case mdb.refresh <- struct{}{}:
+	default:
+	}
+}
+
 func (mdb *RoutesMemDb) Stop() {
 	mdb.log.Info("shutting down routes memdb...")
 
@@ -0,0 +1,170 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/batch"
+	"github.com/lib/pq"
+)
+
+func (s *Store) CreateBatchesTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS batches (
+		id VARCHAR(255) PRIMARY KEY,
+		key_id VARCHAR(255) NOT NULL,
+		provider_setting_id VARCHAR(255) NOT NULL,
+		endpoint VARCHAR(255) NOT NULL,
+		input_file_id VARCHAR(255) NOT NULL,
+		output_file_id VARCHAR(255) NOT NULL DEFAULT '',
+		status VARCHAR(255) NOT NULL,
+		request_count INT NOT NULL DEFAULT 0,
+		prompt_token_count INT NOT NULL DEFAULT 0,
+		completion_token_count INT NOT NULL DEFAULT 0,
+		cost_in_usd FLOAT8 NOT NULL DEFAULT 0,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		reconciled_at BIGINT NOT NULL DEFAULT 0
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) CreateKeyIdIndexForBatches() error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, "CREATE INDEX IF NOT EXISTS batches_key_id_idx ON batches(key_id)")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanBatch(scan func(dest ...any) error) (*batch.Batch, error) {
+	b := &batch.Batch{}
+	if err := scan(
+		&b.Id,
+		&b.KeyId,
+		&b.ProviderSettingId,
+		&b.Endpoint,
+		&b.InputFileId,
+		&b.OutputFileId,
+		&b.Status,
+		&b.RequestCount,
+		&b.PromptTokenCount,
+		&b.CompletionTokenCount,
+		&b.CostInUsd,
+		&b.CreatedAt,
+		&b.UpdatedAt,
+		&b.ReconciledAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (s *Store) InsertBatch(b *batch.Batch) error {
+	query := `
+		INSERT INTO batches (id, key_id, provider_setting_id, endpoint, input_file_id, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctxTimeout, query, b.Id, b.KeyId, b.ProviderSettingId, b.Endpoint, b.InputFileId, b.Status, b.CreatedAt, b.UpdatedAt)
+	return err
+}
+
+func (s *Store) getBatches(query string, args ...any) ([]*batch.Batch, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := []*batch.Batch{}
+	for rows.Next() {
+		b, err := scanBatch(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		batches = append(batches, b)
+	}
+
+	return batches, rows.Err()
+}
+
+// GetBatches returns the batches created by any of keyIds, newest first. All
+// batches are returned when no key ids are given.
+func (s *Store) GetBatches(keyIds []string, status string) ([]*batch.Batch, error) {
+	conditions := []string{}
+	args := []any{}
+	if len(keyIds) != 0 {
+		args = append(args, pq.Array(keyIds))
+		conditions = append(conditions, fmt.Sprintf("key_id = ANY($%d)", len(args)))
+	}
+
+	if len(status) != 0 {
+		args = append(args, status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	query := "SELECT * FROM batches"
+	if len(conditions) != 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	return s.getBatches(query+" ORDER BY created_at DESC", args...)
+}
+
+func (s *Store) GetUnreconciledBatches() ([]*batch.Batch, error) {
+	return s.getBatches("SELECT * FROM batches WHERE reconciled_at = 0 ORDER BY created_at")
+}
+
+func (s *Store) UpdateBatchStatus(id, status, outputFileId string, updatedAt int64) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctxTimeout, "UPDATE batches SET status = $2, output_file_id = $3, updated_at = $4 WHERE id = $1 AND reconciled_at = 0", id, status, outputFileId, updatedAt)
+	return err
+}
+
+// ReconcileBatch stores the usage of a finished batch. It reports false when
+// the batch has already been reconciled, so that only one gateway bills it.
+func (s *Store) ReconcileBatch(b *batch.Batch) (bool, error) {
+	query := `
+		UPDATE batches SET status = $2, output_file_id = $3, request_count = $4, prompt_token_count = $5, completion_token_count = $6, cost_in_usd = $7, updated_at = $8, reconciled_at = $9
+		WHERE id = $1 AND reconciled_at = 0
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, query, b.Id, b.Status, b.OutputFileId, b.RequestCount, b.PromptTokenCount, b.CompletionTokenCount, b.CostInUsd, b.UpdatedAt, b.ReconciledAt)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
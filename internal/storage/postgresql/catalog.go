@@ -0,0 +1,128 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/bricks-cloud/bricksllm/internal/catalog"
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+)
+
+func (s *Store) CreateModelsTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS models (
+		id VARCHAR(255) PRIMARY KEY,
+		provider VARCHAR(255) NOT NULL,
+		model VARCHAR(255) NOT NULL,
+		owned_by VARCHAR(255) NOT NULL DEFAULT '',
+		available BOOLEAN NOT NULL DEFAULT TRUE,
+		first_seen_at BIGINT NOT NULL,
+		last_seen_at BIGINT NOT NULL,
+		UNIQUE (provider, model)
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpsertCatalogModels records the models a provider lists as of syncedAt and
+// marks every other model of the provider as unavailable.
+func (s *Store) UpsertCatalogModels(provider string, models []*catalog.Model, syncedAt int64) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctxTimeout, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO models (id, provider, model, owned_by, available, first_seen_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, TRUE, $5, $5)
+		ON CONFLICT (provider, model) DO UPDATE SET owned_by = EXCLUDED.owned_by, available = TRUE, last_seen_at = EXCLUDED.last_seen_at
+	`
+
+	for _, m := range models {
+		if _, err := tx.ExecContext(ctxTimeout, query, util.NewUuid(), provider, m.Model, m.OwnedBy, syncedAt); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctxTimeout, "UPDATE models SET available = FALSE WHERE provider = $1 AND last_seen_at < $2", provider, syncedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetCatalogModels returns catalog models of a provider, or every catalog model
+// when provider is empty.
+func (s *Store) GetCatalogModels(provider string) ([]*catalog.Model, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	query := "SELECT * FROM models ORDER BY provider, model"
+	args := []any{}
+	if len(provider) != 0 {
+		query = "SELECT * FROM models WHERE provider = $1 ORDER BY model"
+		args = append(args, provider)
+	}
+
+	rows, err := s.db.QueryContext(ctxTimeout, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	models := []*catalog.Model{}
+	for rows.Next() {
+		m := &catalog.Model{}
+		if err := rows.Scan(
+			&m.Id,
+			&m.Provider,
+			&m.Model,
+			&m.OwnedBy,
+			&m.Available,
+			&m.FirstSeenAt,
+			&m.LastSeenAt,
+		); err != nil {
+			return nil, err
+		}
+
+		models = append(models, m)
+	}
+
+	return models, nil
+}
+
+func (s *Store) GetCatalogModel(id string) (*catalog.Model, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	m := &catalog.Model{}
+	if err := s.db.QueryRowContext(ctxTimeout, "SELECT * FROM models WHERE id = $1", id).Scan(
+		&m.Id,
+		&m.Provider,
+		&m.Model,
+		&m.OwnedBy,
+		&m.Available,
+		&m.FirstSeenAt,
+		&m.LastSeenAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("model is not found for: " + id)
+		}
+
+		return nil, err
+	}
+
+	return m, nil
+}
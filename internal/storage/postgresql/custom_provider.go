@@ -350,6 +350,38 @@ func mergeRouterConfigs(existingConfigs []*custom.RouteConfig, targetConfigs []*
 			merged.TargetUrl = existing.TargetUrl
 		}
 
+		if len(target.RequestTemplate) != 0 {
+			merged.RequestTemplate = target.RequestTemplate
+		}
+
+		if len(target.RequestTemplate) == 0 {
+			merged.RequestTemplate = existing.RequestTemplate
+		}
+
+		if len(target.ResponseTemplate) != 0 {
+			merged.ResponseTemplate = target.ResponseTemplate
+		}
+
+		if len(target.ResponseTemplate) == 0 {
+			merged.ResponseTemplate = existing.ResponseTemplate
+		}
+
+		if len(target.PromptTokensLocation) != 0 {
+			merged.PromptTokensLocation = target.PromptTokensLocation
+		}
+
+		if len(target.PromptTokensLocation) == 0 {
+			merged.PromptTokensLocation = existing.PromptTokensLocation
+		}
+
+		if len(target.CompletionTokensLocation) != 0 {
+			merged.CompletionTokensLocation = target.CompletionTokensLocation
+		}
+
+		if len(target.CompletionTokensLocation) == 0 {
+			merged.CompletionTokensLocation = existing.CompletionTokensLocation
+		}
+
 		pathToRouteMap[merged.Path] = merged
 	}
 
@@ -359,3 +391,24 @@ func mergeRouterConfigs(existingConfigs []*custom.RouteConfig, targetConfigs []*
 
 	return result
 }
+
+func (s *Store) DeleteCustomProvider(id string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctxTimeout, "DELETE FROM custom_providers WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return internal_errors.NewNotFoundError("custom provider is not found for: " + id)
+	}
+
+	return nil
+}
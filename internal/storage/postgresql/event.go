@@ -3,6 +3,7 @@ package postgresql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -37,7 +38,7 @@ func (s *Store) CreateEventsByDayTable() error {
 
 func (s *Store) AlterEventsTable() error {
 	alterTableQuery := `
-		ALTER TABLE events ADD COLUMN IF NOT EXISTS path VARCHAR(255), ADD COLUMN IF NOT EXISTS method VARCHAR(255), ADD COLUMN IF NOT EXISTS custom_id VARCHAR(255), ADD COLUMN IF NOT EXISTS request JSONB, ADD COLUMN IF NOT EXISTS response JSONB, ADD COLUMN IF NOT EXISTS user_id VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS action VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS policy_id VARCHAR(255) NOT NULL DEFAULT '',  ADD COLUMN IF NOT EXISTS route_id VARCHAR(255) NOT NULL DEFAULT '',  ADD COLUMN IF NOT EXISTS correlation_id VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS metadata JSONB;
+		ALTER TABLE events ADD COLUMN IF NOT EXISTS path VARCHAR(255), ADD COLUMN IF NOT EXISTS method VARCHAR(255), ADD COLUMN IF NOT EXISTS custom_id VARCHAR(255), ADD COLUMN IF NOT EXISTS request JSONB, ADD COLUMN IF NOT EXISTS response JSONB, ADD COLUMN IF NOT EXISTS user_id VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS action VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS policy_id VARCHAR(255) NOT NULL DEFAULT '',  ADD COLUMN IF NOT EXISTS route_id VARCHAR(255) NOT NULL DEFAULT '',  ADD COLUMN IF NOT EXISTS correlation_id VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS metadata JSONB, ADD COLUMN IF NOT EXISTS routing_rationale JSONB, ADD COLUMN IF NOT EXISTS signing JSONB, ADD COLUMN IF NOT EXISTS schema_version INT NOT NULL DEFAULT 1, ADD COLUMN IF NOT EXISTS reasoning_token_count INT NOT NULL DEFAULT 0, ADD COLUMN IF NOT EXISTS cache_status VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS cost_in_currency FLOAT8 NOT NULL DEFAULT 0, ADD COLUMN IF NOT EXISTS fx_rate FLOAT8 NOT NULL DEFAULT 0, ADD COLUMN IF NOT EXISTS timings JSONB, ADD COLUMN IF NOT EXISTS policy_rules TEXT[] NOT NULL DEFAULT '{}', ADD COLUMN IF NOT EXISTS provider_setting_id VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS origin_gateway VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS origin_key_id VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS tool_calls JSONB, ADD COLUMN IF NOT EXISTS experiment VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS variant VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS risk_score FLOAT8 NOT NULL DEFAULT 0, ADD COLUMN IF NOT EXISTS policy_version INT NOT NULL DEFAULT 0, ADD COLUMN IF NOT EXISTS request_hash VARCHAR(64) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS language VARCHAR(16) NOT NULL DEFAULT '';
 	`
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
@@ -169,6 +170,8 @@ func (s *Store) GetEvents(userId string, customId string, keyIds []string, start
 		var path sql.NullString
 		var method sql.NullString
 		var customId sql.NullString
+		var timings []byte
+		var toolCalls []byte
 
 		if err := rows.Scan(
 			&e.Id,
@@ -193,6 +196,26 @@ func (s *Store) GetEvents(userId string, customId string, keyIds []string, start
 			&e.RouteId,
 			&e.CorrelationId,
 			&e.Metadata,
+			&e.RoutingRationale,
+			&e.Signing,
+			&e.SchemaVersion,
+			&e.ReasoningTokenCount,
+			&e.CacheStatus,
+			&e.Currency,
+			&e.CostInCurrency,
+			&e.FxRate,
+			&timings,
+			pq.Array(&e.PolicyRules),
+			&e.ProviderSettingId,
+			&e.OriginGateway,
+			&e.OriginKeyId,
+			&toolCalls,
+			&e.Experiment,
+			&e.Variant,
+			&e.RiskScore,
+			&e.PolicyVersion,
+			&e.RequestHash,
+			&e.Language,
 		); err != nil {
 			return nil, err
 		}
@@ -202,6 +225,19 @@ func (s *Store) GetEvents(userId string, customId string, keyIds []string, start
 		pe.Method = method.String
 		pe.CustomId = customId.String
 
+		if len(timings) != 0 {
+			pe.Timings = &event.Timings{}
+			if err := json.Unmarshal(timings, pe.Timings); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(toolCalls) != 0 {
+			if err := json.Unmarshal(toolCalls, &pe.ToolCalls); err != nil {
+				return nil, err
+			}
+		}
+
 		events = append(events, pe)
 	}
 
@@ -551,6 +587,26 @@ func (s *Store) GetEventDataPoints(start, end, increment int64, tags, keyIds, cu
 				groupByQuery += ",events_table.user_id"
 				selectQuery += ",events_table.user_id as userId"
 			}
+
+			if filter == "providerSettingId" {
+				groupByQuery += ",events_table.provider_setting_id"
+				selectQuery += ",events_table.provider_setting_id as providerSettingId"
+			}
+
+			if filter == "originGateway" {
+				groupByQuery += ",events_table.origin_gateway"
+				selectQuery += ",events_table.origin_gateway as originGateway"
+			}
+
+			if filter == "experiment" {
+				groupByQuery += ",events_table.experiment"
+				selectQuery += ",events_table.experiment as experiment"
+			}
+
+			if filter == "variant" {
+				groupByQuery += ",events_table.variant"
+				selectQuery += ",events_table.variant as variant"
+			}
 		}
 	}
 
@@ -615,6 +671,10 @@ func (s *Store) GetEventDataPoints(start, end, increment int64, tags, keyIds, cu
 		var keyId sql.NullString
 		var customId sql.NullString
 		var userId sql.NullString
+		var providerSettingId sql.NullString
+		var originGateway sql.NullString
+		var experiment sql.NullString
+		var variant sql.NullString
 
 		additional := []any{
 			&e.TimeStamp,
@@ -643,6 +703,22 @@ func (s *Store) GetEventDataPoints(start, end, increment int64, tags, keyIds, cu
 				if filter == "userId" {
 					additional = append(additional, &userId)
 				}
+
+				if filter == "providerSettingId" {
+					additional = append(additional, &providerSettingId)
+				}
+
+				if filter == "originGateway" {
+					additional = append(additional, &originGateway)
+				}
+
+				if filter == "experiment" {
+					additional = append(additional, &experiment)
+				}
+
+				if filter == "variant" {
+					additional = append(additional, &variant)
+				}
 			}
 		}
 
@@ -657,6 +733,10 @@ func (s *Store) GetEventDataPoints(start, end, increment int64, tags, keyIds, cu
 		pe.KeyId = keyId.String
 		pe.CustomId = customId.String
 		pe.UserId = userId.String
+		pe.ProviderSettingId = providerSettingId.String
+		pe.OriginGateway = originGateway.String
+		pe.Experiment = experiment.String
+		pe.Variant = variant.String
 
 		data = append(data, pe)
 	}
@@ -708,6 +788,26 @@ func (s *Store) GetEventsV2(req *event.EventRequest) (*event.EventResponse, erro
 		cquery += fmt.Sprintf(" AND action = ANY('%s')", sliceToSqlStringArray(req.Actions))
 	}
 
+	if len(req.SigningIdentities) != 0 {
+		query += fmt.Sprintf(" AND signing->>'identity' = ANY('%s')", sliceToSqlStringArray(req.SigningIdentities))
+		cquery += fmt.Sprintf(" AND signing->>'identity' = ANY('%s')", sliceToSqlStringArray(req.SigningIdentities))
+	}
+
+	if req.SigningFailed {
+		query += " AND COALESCE(signing->>'failure', '') <> ''"
+		cquery += " AND COALESCE(signing->>'failure', '') <> ''"
+	}
+
+	if req.MinRiskScore != 0 {
+		query += fmt.Sprintf(" AND risk_score >= %f", req.MinRiskScore)
+		cquery += fmt.Sprintf(" AND risk_score >= %f", req.MinRiskScore)
+	}
+
+	if len(req.RequestHashes) != 0 {
+		query += fmt.Sprintf(" AND request_hash = ANY('%s')", sliceToSqlStringArray(req.RequestHashes))
+		cquery += fmt.Sprintf(" AND request_hash = ANY('%s')", sliceToSqlStringArray(req.RequestHashes))
+	}
+
 	if len(req.CostOrder) != 0 {
 		query += fmt.Sprintf(" ORDER BY cost_in_usd %s", strings.ToUpper(req.CostOrder))
 	}
@@ -753,6 +853,8 @@ func (s *Store) GetEventsV2(req *event.EventRequest) (*event.EventResponse, erro
 		var path sql.NullString
 		var method sql.NullString
 		var customId sql.NullString
+		var timings []byte
+		var toolCalls []byte
 
 		if err := rows.Scan(
 			&e.Id,
@@ -777,6 +879,26 @@ func (s *Store) GetEventsV2(req *event.EventRequest) (*event.EventResponse, erro
 			&e.RouteId,
 			&e.CorrelationId,
 			&e.Metadata,
+			&e.RoutingRationale,
+			&e.Signing,
+			&e.SchemaVersion,
+			&e.ReasoningTokenCount,
+			&e.CacheStatus,
+			&e.Currency,
+			&e.CostInCurrency,
+			&e.FxRate,
+			&timings,
+			pq.Array(&e.PolicyRules),
+			&e.ProviderSettingId,
+			&e.OriginGateway,
+			&e.OriginKeyId,
+			&toolCalls,
+			&e.Experiment,
+			&e.Variant,
+			&e.RiskScore,
+			&e.PolicyVersion,
+			&e.RequestHash,
+			&e.Language,
 		); err != nil {
 			return nil, err
 		}
@@ -786,6 +908,19 @@ func (s *Store) GetEventsV2(req *event.EventRequest) (*event.EventResponse, erro
 		pe.Method = method.String
 		pe.CustomId = customId.String
 
+		if len(timings) != 0 {
+			pe.Timings = &event.Timings{}
+			if err := json.Unmarshal(timings, pe.Timings); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(toolCalls) != 0 {
+			if err := json.Unmarshal(toolCalls, &pe.ToolCalls); err != nil {
+				return nil, err
+			}
+		}
+
 		events = append(events, pe)
 	}
 
@@ -796,10 +931,36 @@ func (s *Store) GetEventsV2(req *event.EventRequest) (*event.EventResponse, erro
 
 func (s *Store) InsertEvent(e *event.Event) error {
 	query := `
-		INSERT INTO events (event_id, created_at, tags, key_id, cost_in_usd, provider, model, status_code, prompt_token_count, completion_token_count, latency_in_ms, path, method, custom_id, request, response, user_id, action, policy_id, route_id, correlation_id, metadata)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+		INSERT INTO events (event_id, created_at, tags, key_id, cost_in_usd, provider, model, status_code, prompt_token_count, completion_token_count, latency_in_ms, path, method, custom_id, request, response, user_id, action, policy_id, route_id, correlation_id, metadata, routing_rationale, signing, schema_version, reasoning_token_count, cache_status, currency, cost_in_currency, fx_rate, timings, policy_rules, provider_setting_id, origin_gateway, origin_key_id, tool_calls, experiment, variant, risk_score, policy_version, request_hash, language)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35, $36, $37, $38, $39, $40, $41, $42)
 	`
 
+	var timings []byte
+	if e.Timings != nil {
+		data, err := json.Marshal(e.Timings)
+		if err != nil {
+			return err
+		}
+
+		timings = data
+	}
+
+	var toolCalls []byte
+	if len(e.ToolCalls) != 0 {
+		data, err := json.Marshal(e.ToolCalls)
+		if err != nil {
+			return err
+		}
+
+		toolCalls = data
+	}
+
+	// rules can hold regex definitions, so they are not joined by hand
+	policyRules := e.PolicyRules
+	if policyRules == nil {
+		policyRules = []string{}
+	}
+
 	values := []any{
 		e.Id,
 		e.CreatedAt,
@@ -823,6 +984,26 @@ func (s *Store) InsertEvent(e *event.Event) error {
 		e.RouteId,
 		e.CorrelationId,
 		e.Metadata,
+		e.RoutingRationale,
+		e.Signing,
+		e.SchemaVersion,
+		e.ReasoningTokenCount,
+		e.CacheStatus,
+		e.Currency,
+		e.CostInCurrency,
+		e.FxRate,
+		timings,
+		pq.Array(policyRules),
+		e.ProviderSettingId,
+		e.OriginGateway,
+		e.OriginKeyId,
+		toolCalls,
+		e.Experiment,
+		e.Variant,
+		e.RiskScore,
+		e.PolicyVersion,
+		e.RequestHash,
+		e.Language,
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), s.wt)
@@ -833,3 +1014,305 @@ func (s *Store) InsertEvent(e *event.Event) error {
 
 	return nil
 }
+
+func (s *Store) GetTotalCost(start, end int64, keyIds []string) (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	query := "SELECT COALESCE(SUM(cost_in_usd), 0) FROM events WHERE created_at >= $1 AND created_at < $2"
+	args := []any{start, end}
+	if len(keyIds) != 0 {
+		query += " AND key_id = ANY($3)"
+		args = append(args, pq.Array(keyIds))
+	}
+
+	var cost float64
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(&cost); err != nil {
+		return 0, err
+	}
+
+	return cost, nil
+}
+
+func (s *Store) GetProviderHealthDataPoints(start, end int64, keyIds []string) ([]*event.ProviderHealth, error) {
+	args := []any{start, end}
+	keyFilter := ""
+	if len(keyIds) != 0 {
+		keyFilter = " AND key_id = ANY($3)"
+		args = append(args, pq.Array(keyIds))
+	}
+
+	query := fmt.Sprintf(`
+	SELECT provider, COUNT(*) AS num_of_requests, COALESCE(SUM(CASE WHEN status_code >= 400 THEN 1 END), 0) AS error_count, COALESCE(AVG(latency_in_ms), 0) AS latency_in_ms_avg
+	FROM events
+	WHERE created_at >= $1 AND created_at < $2 AND (provider = '') IS FALSE%s
+	GROUP BY provider
+	ORDER BY provider;
+	`, keyFilter)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	data := []*event.ProviderHealth{}
+	for rows.Next() {
+		ph := &event.ProviderHealth{}
+		if err := rows.Scan(
+			&ph.Provider,
+			&ph.NumberOfRequests,
+			&ph.ErrorCount,
+			&ph.LatencyInMsAvg,
+		); err != nil {
+			return nil, err
+		}
+
+		data = append(data, ph)
+	}
+
+	return data, nil
+}
+
+func (s *Store) GetRecentErrorEvents(limit int, keyIds []string) ([]*event.Event, error) {
+	args := []any{limit}
+	keyFilter := ""
+	if len(keyIds) != 0 {
+		keyFilter = " AND key_id = ANY($2)"
+		args = append(args, pq.Array(keyIds))
+	}
+
+	query := fmt.Sprintf(`
+	SELECT event_id, created_at, tags, key_id, cost_in_usd, provider, model, status_code, prompt_token_count, completion_token_count, latency_in_ms, path, method, custom_id, user_id, action, policy_id, route_id, correlation_id
+	FROM events
+	WHERE status_code >= 400%s
+	ORDER BY created_at DESC
+	LIMIT $1;
+	`, keyFilter)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*event.Event{}
+	for rows.Next() {
+		var e event.Event
+		var path sql.NullString
+		var method sql.NullString
+		var customId sql.NullString
+
+		if err := rows.Scan(
+			&e.Id,
+			&e.CreatedAt,
+			pq.Array(&e.Tags),
+			&e.KeyId,
+			&e.CostInUsd,
+			&e.Provider,
+			&e.Model,
+			&e.Status,
+			&e.PromptTokenCount,
+			&e.CompletionTokenCount,
+			&e.LatencyInMs,
+			&path,
+			&method,
+			&customId,
+			&e.UserId,
+			&e.Action,
+			&e.PolicyId,
+			&e.RouteId,
+			&e.CorrelationId,
+		); err != nil {
+			return nil, err
+		}
+
+		pe := &e
+		pe.Path = path.String
+		pe.Method = method.String
+		pe.CustomId = customId.String
+
+		events = append(events, pe)
+	}
+
+	return events, nil
+}
+
+func (s *Store) GetSigningDataPoints(start, end int64, keyIds, identities []string) ([]*event.SigningDataPoint, error) {
+	query := fmt.Sprintf(`
+	SELECT signing->>'identity' AS identity, COALESCE(signing->>'scheme', '') AS scheme, provider, COUNT(*) AS num_of_requests, COALESCE(SUM(CASE WHEN COALESCE(signing->>'failure', '') <> '' THEN 1 END), 0) AS verification_failures, MAX(created_at) AS last_signed_at
+	FROM events
+	WHERE created_at >= %d AND created_at < %d AND signing IS NOT NULL
+	`, start, end)
+
+	if len(keyIds) != 0 {
+		query += fmt.Sprintf(" AND key_id = ANY('%s')", sliceToSqlStringArray(keyIds))
+	}
+
+	if len(identities) != 0 {
+		query += fmt.Sprintf(" AND signing->>'identity' = ANY('%s')", sliceToSqlStringArray(identities))
+	}
+
+	query += " GROUP BY identity, scheme, provider ORDER BY verification_failures DESC, num_of_requests DESC;"
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	data := []*event.SigningDataPoint{}
+	for rows.Next() {
+		var identity sql.NullString
+		dp := &event.SigningDataPoint{}
+		if err := rows.Scan(
+			&identity,
+			&dp.Scheme,
+			&dp.Provider,
+			&dp.NumberOfRequests,
+			&dp.VerificationFailures,
+			&dp.LastSignedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		dp.Identity = identity.String
+		data = append(data, dp)
+	}
+
+	return data, nil
+}
+
+// GetPolicyViolationDataPoints counts events that policies acted on once per
+// rule that fired, so that an event tripping two rules counts for both.
+func (s *Store) GetPolicyViolationDataPoints(req *event.PolicyViolationReportingRequest) ([]*event.PolicyViolationDataPoint, error) {
+	bucket := "$1"
+	if req.Increment != 0 {
+		bucket = fmt.Sprintf("$1 + (created_at - $1) / %d * %d", req.Increment, req.Increment)
+	}
+
+	query := fmt.Sprintf(`
+	SELECT %s AS bucket, policy_id, rule, key_id, COUNT(*) AS violations,
+		COUNT(*) FILTER (WHERE action = 'blocked') AS blocked_count,
+		COUNT(*) FILTER (WHERE action = 'warned') AS warned_count,
+		COUNT(*) FILTER (WHERE action = 'redacted') AS redacted_count,
+		COALESCE(MAX(risk_score), 0) AS max_risk_score,
+		to_jsonb((array_agg(jsonb_build_object('eventId', event_id, 'createdAt', created_at, 'action', action, 'path', COALESCE(path, ''), 'model', model, 'userId', user_id, 'customId', COALESCE(custom_id, ''), 'riskScore', risk_score, 'rules', policy_rules, 'requestHash', request_hash) ORDER BY created_at DESC))[1:%d]) AS samples
+	FROM (
+		SELECT event_id, created_at, action, path, model, user_id, custom_id, policy_id, key_id, risk_score, policy_rules, request_hash, unnest(CASE WHEN cardinality(policy_rules) = 0 THEN ARRAY['']::TEXT[] ELSE policy_rules END) AS rule
+		FROM events
+		WHERE created_at >= $1 AND created_at < $2 AND action = ANY($3) AND policy_id <> ''
+	) AS violations
+	WHERE TRUE
+	`, bucket, req.SampleSize)
+
+	values := []any{req.Start, req.End, pq.Array(req.Actions)}
+
+	if len(req.PolicyIds) != 0 {
+		values = append(values, pq.Array(req.PolicyIds))
+		query += fmt.Sprintf(" AND policy_id = ANY($%d)", len(values))
+	}
+
+	if len(req.KeyIds) != 0 {
+		values = append(values, pq.Array(req.KeyIds))
+		query += fmt.Sprintf(" AND key_id = ANY($%d)", len(values))
+	}
+
+	if len(req.Rules) != 0 {
+		values = append(values, pq.Array(req.Rules))
+		query += fmt.Sprintf(" AND rule = ANY($%d)", len(values))
+	}
+
+	query += " GROUP BY bucket, policy_id, rule, key_id ORDER BY bucket, violations DESC;"
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	data := []*event.PolicyViolationDataPoint{}
+	for rows.Next() {
+		dp := &event.PolicyViolationDataPoint{}
+		var samples []byte
+		if err := rows.Scan(
+			&dp.TimeStamp,
+			&dp.PolicyId,
+			&dp.Rule,
+			&dp.KeyId,
+			&dp.Violations,
+			&dp.BlockedCount,
+			&dp.WarnedCount,
+			&dp.RedactedCount,
+			&dp.MaxRiskScore,
+			&samples,
+		); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(samples, &dp.Samples); err != nil {
+			return nil, err
+		}
+
+		data = append(data, dp)
+	}
+
+	return data, nil
+}
+
+// GetPolicyVersionDataPoints summarizes the events of a policy by the version
+// of the policy that applied to them.
+func (s *Store) GetPolicyVersionDataPoints(req *event.PolicyVersionReportingRequest) ([]*event.PolicyVersionDataPoint, error) {
+	query := `
+	SELECT policy_version, COUNT(*) AS number_of_requests,
+		COUNT(*) FILTER (WHERE action = 'blocked') AS blocked_count,
+		COUNT(*) FILTER (WHERE action = 'warned') AS warned_count,
+		COALESCE(AVG((timings->>'policy_in_ms')::FLOAT8), 0) AS average_policy_latency_in_ms
+	FROM events
+	WHERE policy_id = $1 AND created_at >= $2 AND created_at < $3
+	GROUP BY policy_version ORDER BY policy_version DESC;
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, req.PolicyId, req.Start, req.End)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	data := []*event.PolicyVersionDataPoint{}
+	for rows.Next() {
+		dp := &event.PolicyVersionDataPoint{}
+		if err := rows.Scan(
+			&dp.Version,
+			&dp.NumberOfRequests,
+			&dp.BlockedCount,
+			&dp.WarnedCount,
+			&dp.AveragePolicyLatencyInMs,
+		); err != nil {
+			return nil, err
+		}
+
+		if dp.NumberOfRequests != 0 {
+			dp.BlockRate = float64(dp.BlockedCount) / float64(dp.NumberOfRequests)
+		}
+
+		data = append(data, dp)
+	}
+
+	return data, nil
+}
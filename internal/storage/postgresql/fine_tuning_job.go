@@ -0,0 +1,164 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/finetune"
+	"github.com/lib/pq"
+)
+
+func (s *Store) CreateFineTuningJobsTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS fine_tuning_jobs (
+		id VARCHAR(255) PRIMARY KEY,
+		key_id VARCHAR(255) NOT NULL,
+		provider_setting_id VARCHAR(255) NOT NULL,
+		model VARCHAR(255) NOT NULL,
+		fine_tuned_model VARCHAR(255) NOT NULL DEFAULT '',
+		status VARCHAR(255) NOT NULL,
+		trained_tokens INT NOT NULL DEFAULT 0,
+		cost_in_usd FLOAT8 NOT NULL DEFAULT 0,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		reconciled_at BIGINT NOT NULL DEFAULT 0
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) CreateKeyIdIndexForFineTuningJobs() error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, "CREATE INDEX IF NOT EXISTS fine_tuning_jobs_key_id_idx ON fine_tuning_jobs(key_id)")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanFineTuningJob(scan func(dest ...any) error) (*finetune.Job, error) {
+	j := &finetune.Job{}
+	if err := scan(
+		&j.Id,
+		&j.KeyId,
+		&j.ProviderSettingId,
+		&j.Model,
+		&j.FineTunedModel,
+		&j.Status,
+		&j.TrainedTokens,
+		&j.CostInUsd,
+		&j.CreatedAt,
+		&j.UpdatedAt,
+		&j.ReconciledAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+func (s *Store) InsertFineTuningJob(j *finetune.Job) error {
+	query := `
+		INSERT INTO fine_tuning_jobs (id, key_id, provider_setting_id, model, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctxTimeout, query, j.Id, j.KeyId, j.ProviderSettingId, j.Model, j.Status, j.CreatedAt, j.UpdatedAt)
+	return err
+}
+
+func (s *Store) getFineTuningJobs(query string, args ...any) ([]*finetune.Job, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []*finetune.Job{}
+	for rows.Next() {
+		j, err := scanFineTuningJob(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, j)
+	}
+
+	return jobs, rows.Err()
+}
+
+// GetFineTuningJobs returns the fine-tuning jobs created by any of keyIds,
+// newest first. All jobs are returned when no key ids are given.
+func (s *Store) GetFineTuningJobs(keyIds []string, status string) ([]*finetune.Job, error) {
+	conditions := []string{}
+	args := []any{}
+	if len(keyIds) != 0 {
+		args = append(args, pq.Array(keyIds))
+		conditions = append(conditions, fmt.Sprintf("key_id = ANY($%d)", len(args)))
+	}
+
+	if len(status) != 0 {
+		args = append(args, status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	query := "SELECT * FROM fine_tuning_jobs"
+	if len(conditions) != 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	return s.getFineTuningJobs(query+" ORDER BY created_at DESC", args...)
+}
+
+func (s *Store) GetUnreconciledFineTuningJobs() ([]*finetune.Job, error) {
+	return s.getFineTuningJobs("SELECT * FROM fine_tuning_jobs WHERE reconciled_at = 0 ORDER BY created_at")
+}
+
+func (s *Store) UpdateFineTuningJobStatus(id, status, fineTunedModel string, updatedAt int64) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctxTimeout, "UPDATE fine_tuning_jobs SET status = $2, fine_tuned_model = $3, updated_at = $4 WHERE id = $1 AND reconciled_at = 0", id, status, fineTunedModel, updatedAt)
+	return err
+}
+
+// ReconcileFineTuningJob stores the training cost of a stopped job. It reports
+// false when another gateway has already reconciled the job.
+func (s *Store) ReconcileFineTuningJob(j *finetune.Job) (bool, error) {
+	query := `
+		UPDATE fine_tuning_jobs SET status = $2, fine_tuned_model = $3, trained_tokens = $4, cost_in_usd = $5, updated_at = $6, reconciled_at = $7
+		WHERE id = $1 AND reconciled_at = 0
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, query, j.Id, j.Status, j.FineTunedModel, j.TrainedTokens, j.CostInUsd, j.UpdatedAt, j.ReconciledAt)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
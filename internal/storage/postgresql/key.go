@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/event"
 	"github.com/bricks-cloud/bricksllm/internal/key"
 	"github.com/lib/pq"
 )
@@ -55,7 +56,7 @@ func (s *Store) AlterKeysTable() error {
 			END IF;
 		END
 		$$;
-		ALTER TABLE keys ADD COLUMN IF NOT EXISTS setting_id VARCHAR(255), ADD COLUMN IF NOT EXISTS allowed_paths JSONB, ADD COLUMN IF NOT EXISTS setting_ids VARCHAR(255)[] NOT NULL DEFAULT ARRAY[]::VARCHAR(255)[], ADD COLUMN IF NOT EXISTS should_log_request BOOLEAN NOT NULL DEFAULT FALSE, ADD COLUMN IF NOT EXISTS should_log_response BOOLEAN NOT NULL DEFAULT FALSE, ADD COLUMN IF NOT EXISTS rotation_enabled BOOLEAN NOT NULL DEFAULT FALSE, ADD COLUMN IF NOT EXISTS policy_id VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS is_key_not_hashed BOOLEAN NOT NULL DEFAULT FALSE;
+		ALTER TABLE keys ADD COLUMN IF NOT EXISTS setting_id VARCHAR(255), ADD COLUMN IF NOT EXISTS allowed_paths JSONB, ADD COLUMN IF NOT EXISTS setting_ids VARCHAR(255)[] NOT NULL DEFAULT ARRAY[]::VARCHAR(255)[], ADD COLUMN IF NOT EXISTS should_log_request BOOLEAN NOT NULL DEFAULT FALSE, ADD COLUMN IF NOT EXISTS should_log_response BOOLEAN NOT NULL DEFAULT FALSE, ADD COLUMN IF NOT EXISTS rotation_enabled BOOLEAN NOT NULL DEFAULT FALSE, ADD COLUMN IF NOT EXISTS policy_id VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS is_key_not_hashed BOOLEAN NOT NULL DEFAULT FALSE, ADD COLUMN IF NOT EXISTS max_cost_per_request FLOAT8 NOT NULL DEFAULT 0, ADD COLUMN IF NOT EXISTS namespace VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS max_priority VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS max_concurrency INT NOT NULL DEFAULT 0, ADD COLUMN IF NOT EXISTS retry_policy JSONB, ADD COLUMN IF NOT EXISTS policy_version INT NOT NULL DEFAULT 0;
 	`
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
@@ -164,6 +165,7 @@ func (s *Store) GetKeys(tags, keyIds []string, provider string) ([]*key.Response
 		var k key.ResponseKey
 		var settingId sql.NullString
 		var data []byte
+		var rpdata []byte
 		if err := rows.Scan(
 			&k.Name,
 			&k.CreatedAt,
@@ -187,6 +189,12 @@ func (s *Store) GetKeys(tags, keyIds []string, provider string) ([]*key.Response
 			&k.RotationEnabled,
 			&k.PolicyId,
 			&k.IsKeyNotHashed,
+			&k.MaxCostPerRequest,
+			&k.Namespace,
+			&k.MaxPriority,
+			&k.MaxConcurrency,
+			&rpdata,
+			&k.PolicyVersion,
 		); err != nil {
 			return nil, err
 		}
@@ -203,13 +211,19 @@ func (s *Store) GetKeys(tags, keyIds []string, provider string) ([]*key.Response
 			pk.AllowedPaths = pathConfigs
 		}
 
+		if len(rpdata) != 0 {
+			if err := json.Unmarshal(rpdata, &pk.RetryPolicy); err != nil {
+				return nil, err
+			}
+		}
+
 		keys = append(keys, pk)
 	}
 
 	return keys, nil
 }
 
-func (s *Store) GetKeysV2(tags, keyIds []string, revoked *bool, limit, offset int, name, order string, returnCount bool) (*key.GetKeysResponse, error) {
+func (s *Store) GetKeysV2(tags, keyIds []string, revoked *bool, limit, offset int, name, order string, returnCount bool, namespace string) (*key.GetKeysResponse, error) {
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
 	defer cancel()
 
@@ -221,7 +235,7 @@ func (s *Store) GetKeysV2(tags, keyIds []string, revoked *bool, limit, offset in
 
 	index := 1
 
-	if len(tags) != 0 || len(keyIds) != 0 || revoked != nil || len(name) != 0 {
+	if len(tags) != 0 || len(keyIds) != 0 || len(namespace) != 0 || revoked != nil || len(name) != 0 {
 		query += " WHERE "
 		countQuery += " WHERE "
 	}
@@ -245,6 +259,18 @@ func (s *Store) GetKeysV2(tags, keyIds []string, revoked *bool, limit, offset in
 		index += 1
 	}
 
+	if len(namespace) != 0 {
+		if index > 1 {
+			query += " AND "
+			countQuery += " AND "
+		}
+
+		args = append(args, namespace)
+		query += fmt.Sprintf("namespace = $%d", index)
+		countQuery += fmt.Sprintf("namespace = $%d", index)
+		index += 1
+	}
+
 	if revoked != nil {
 		if index > 1 {
 			query += " AND "
@@ -288,6 +314,7 @@ func (s *Store) GetKeysV2(tags, keyIds []string, revoked *bool, limit, offset in
 		var k key.ResponseKey
 		var settingId sql.NullString
 		var data []byte
+		var rpdata []byte
 		if err := rows.Scan(
 			&k.Name,
 			&k.CreatedAt,
@@ -311,6 +338,12 @@ func (s *Store) GetKeysV2(tags, keyIds []string, revoked *bool, limit, offset in
 			&k.RotationEnabled,
 			&k.PolicyId,
 			&k.IsKeyNotHashed,
+			&k.MaxCostPerRequest,
+			&k.Namespace,
+			&k.MaxPriority,
+			&k.MaxConcurrency,
+			&rpdata,
+			&k.PolicyVersion,
 		); err != nil {
 			return nil, err
 		}
@@ -327,6 +360,12 @@ func (s *Store) GetKeysV2(tags, keyIds []string, revoked *bool, limit, offset in
 			pk.AllowedPaths = pathConfigs
 		}
 
+		if len(rpdata) != 0 {
+			if err := json.Unmarshal(rpdata, &pk.RetryPolicy); err != nil {
+				return nil, err
+			}
+		}
+
 		keys = append(keys, pk)
 	}
 
@@ -365,6 +404,7 @@ func (s *Store) GetKeyByHash(hash string) (*key.ResponseKey, error) {
 	var k key.ResponseKey
 	var settingId sql.NullString
 	var data []byte
+	var rpdata []byte
 
 	err := s.db.QueryRowContext(ctxTimeout, "SELECT * FROM keys WHERE key = $1", hash).Scan(
 		&k.Name,
@@ -389,6 +429,12 @@ func (s *Store) GetKeyByHash(hash string) (*key.ResponseKey, error) {
 		&k.RotationEnabled,
 		&k.PolicyId,
 		&k.IsKeyNotHashed,
+		&k.MaxCostPerRequest,
+		&k.Namespace,
+		&k.MaxPriority,
+		&k.MaxConcurrency,
+		&rpdata,
+		&k.PolicyVersion,
 	)
 
 	if err != nil {
@@ -410,6 +456,12 @@ func (s *Store) GetKeyByHash(hash string) (*key.ResponseKey, error) {
 		k.AllowedPaths = pathConfigs
 	}
 
+	if len(rpdata) != 0 {
+		if err := json.Unmarshal(rpdata, &k.RetryPolicy); err != nil {
+			return nil, err
+		}
+	}
+
 	return &k, nil
 }
 
@@ -428,6 +480,7 @@ func (s *Store) GetKey(keyId string) (*key.ResponseKey, error) {
 		var k key.ResponseKey
 		var settingId sql.NullString
 		var data []byte
+		var rpdata []byte
 
 		if err := rows.Scan(
 			&k.Name,
@@ -452,6 +505,12 @@ func (s *Store) GetKey(keyId string) (*key.ResponseKey, error) {
 			&k.RotationEnabled,
 			&k.PolicyId,
 			&k.IsKeyNotHashed,
+			&k.MaxCostPerRequest,
+			&k.Namespace,
+			&k.MaxPriority,
+			&k.MaxConcurrency,
+			&rpdata,
+			&k.PolicyVersion,
 		); err != nil {
 			return nil, err
 		}
@@ -468,6 +527,12 @@ func (s *Store) GetKey(keyId string) (*key.ResponseKey, error) {
 			pk.AllowedPaths = pathConfigs
 		}
 
+		if len(rpdata) != 0 {
+			if err := json.Unmarshal(rpdata, &pk.RetryPolicy); err != nil {
+				return nil, err
+			}
+		}
+
 		keys = append(keys, pk)
 	}
 
@@ -493,6 +558,7 @@ func (s *Store) GetAllKeys() ([]*key.ResponseKey, error) {
 		var k key.ResponseKey
 		var settingId sql.NullString
 		var data []byte
+		var rpdata []byte
 		if err := rows.Scan(
 			&k.Name,
 			&k.CreatedAt,
@@ -516,6 +582,12 @@ func (s *Store) GetAllKeys() ([]*key.ResponseKey, error) {
 			&k.RotationEnabled,
 			&k.PolicyId,
 			&k.IsKeyNotHashed,
+			&k.MaxCostPerRequest,
+			&k.Namespace,
+			&k.MaxPriority,
+			&k.MaxConcurrency,
+			&rpdata,
+			&k.PolicyVersion,
 		); err != nil {
 			return nil, err
 		}
@@ -531,6 +603,12 @@ func (s *Store) GetAllKeys() ([]*key.ResponseKey, error) {
 			pk.AllowedPaths = pathConfigs
 		}
 
+		if len(rpdata) != 0 {
+			if err := json.Unmarshal(rpdata, &pk.RetryPolicy); err != nil {
+				return nil, err
+			}
+		}
+
 		keys = append(keys, pk)
 	}
 
@@ -552,6 +630,7 @@ func (s *Store) GetUpdatedKeys(updatedAt int64) ([]*key.ResponseKey, error) {
 		var k key.ResponseKey
 		var settingId sql.NullString
 		var data []byte
+		var rpdata []byte
 		if err := rows.Scan(
 			&k.Name,
 			&k.CreatedAt,
@@ -575,6 +654,12 @@ func (s *Store) GetUpdatedKeys(updatedAt int64) ([]*key.ResponseKey, error) {
 			&k.RotationEnabled,
 			&k.PolicyId,
 			&k.IsKeyNotHashed,
+			&k.MaxCostPerRequest,
+			&k.Namespace,
+			&k.MaxPriority,
+			&k.MaxConcurrency,
+			&rpdata,
+			&k.PolicyVersion,
 		); err != nil {
 			return nil, err
 		}
@@ -590,6 +675,12 @@ func (s *Store) GetUpdatedKeys(updatedAt int64) ([]*key.ResponseKey, error) {
 			pk.AllowedPaths = pathConfigs
 		}
 
+		if len(rpdata) != 0 {
+			if err := json.Unmarshal(rpdata, &pk.RetryPolicy); err != nil {
+				return nil, err
+			}
+		}
+
 		keys = append(keys, pk)
 	}
 
@@ -699,6 +790,35 @@ func (s *Store) UpdateKey(id string, uk *key.UpdateKey) (*key.ResponseKey, error
 		counter++
 	}
 
+	if uk.MaxCostPerRequest != nil {
+		values = append(values, *uk.MaxCostPerRequest)
+		fields = append(fields, fmt.Sprintf("max_cost_per_request = $%d", counter))
+		counter++
+	}
+
+	if uk.MaxPriority != nil {
+		values = append(values, *uk.MaxPriority)
+		fields = append(fields, fmt.Sprintf("max_priority = $%d", counter))
+		counter++
+	}
+
+	if uk.MaxConcurrency != nil {
+		values = append(values, *uk.MaxConcurrency)
+		fields = append(fields, fmt.Sprintf("max_concurrency = $%d", counter))
+		counter++
+	}
+
+	if uk.RetryPolicy != nil {
+		data, err := json.Marshal(uk.RetryPolicy)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("retry_policy = $%d", counter))
+		counter++
+	}
+
 	if uk.AllowedPaths != nil {
 		data, err := json.Marshal(uk.AllowedPaths)
 		if err != nil {
@@ -715,6 +835,12 @@ func (s *Store) UpdateKey(id string, uk *key.UpdateKey) (*key.ResponseKey, error
 		counter++
 	}
 
+	if uk.PolicyVersion != nil {
+		values = append(values, *uk.PolicyVersion)
+		fields = append(fields, fmt.Sprintf("policy_version = $%d", counter))
+		counter++
+	}
+
 	if len(uk.Key) != 0 {
 		values = append(values, uk.Key)
 		fields = append(fields, fmt.Sprintf("key = $%d", counter))
@@ -729,6 +855,7 @@ func (s *Store) UpdateKey(id string, uk *key.UpdateKey) (*key.ResponseKey, error
 	var k key.ResponseKey
 	var settingId sql.NullString
 	var data []byte
+	var rpdata []byte
 	if err := s.db.QueryRowContext(ctxTimeout, query, values...).Scan(
 		&k.Name,
 		&k.CreatedAt,
@@ -752,6 +879,12 @@ func (s *Store) UpdateKey(id string, uk *key.UpdateKey) (*key.ResponseKey, error
 		&k.RotationEnabled,
 		&k.PolicyId,
 		&k.IsKeyNotHashed,
+		&k.MaxCostPerRequest,
+		&k.Namespace,
+		&k.MaxPriority,
+		&k.MaxConcurrency,
+		&rpdata,
+		&k.PolicyVersion,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, internal_errors.NewNotFoundError(fmt.Sprintf("key not found for id: %s", id))
@@ -771,13 +904,26 @@ func (s *Store) UpdateKey(id string, uk *key.UpdateKey) (*key.ResponseKey, error
 		pk.AllowedPaths = pathConfigs
 	}
 
+	if len(rpdata) != 0 {
+		if err := json.Unmarshal(rpdata, &pk.RetryPolicy); err != nil {
+			return nil, err
+		}
+	}
+
 	return pk, nil
 }
 
 func (s *Store) CreateKey(rk *key.RequestKey) (*key.ResponseKey, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	return insertKey(ctxTimeout, s.db, rk)
+}
+
+func insertKey(ctx context.Context, q rowQuerier, rk *key.RequestKey) (*key.ResponseKey, error) {
 	query := `
-		INSERT INTO keys (name, created_at, updated_at, tags, revoked, key_id, key, revoked_reason, cost_limit_in_usd, cost_limit_in_usd_over_time, cost_limit_in_usd_unit, rate_limit_over_time, rate_limit_unit, ttl, setting_id, allowed_paths, setting_ids, should_log_request, should_log_response, rotation_enabled, policy_id, is_key_not_hashed)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+		INSERT INTO keys (name, created_at, updated_at, tags, revoked, key_id, key, revoked_reason, cost_limit_in_usd, cost_limit_in_usd_over_time, cost_limit_in_usd_unit, rate_limit_over_time, rate_limit_unit, ttl, setting_id, allowed_paths, setting_ids, should_log_request, should_log_response, rotation_enabled, policy_id, is_key_not_hashed, max_cost_per_request, namespace, max_priority, max_concurrency, retry_policy, policy_version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28)
 		RETURNING *;
 	`
 
@@ -786,6 +932,14 @@ func (s *Store) CreateKey(rk *key.RequestKey) (*key.ResponseKey, error) {
 		return nil, err
 	}
 
+	var pdata []byte
+	if rk.RetryPolicy != nil {
+		pdata, err = json.Marshal(rk.RetryPolicy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	values := []any{
 		rk.Name,
 		rk.CreatedAt,
@@ -809,16 +963,20 @@ func (s *Store) CreateKey(rk *key.RequestKey) (*key.ResponseKey, error) {
 		rk.RotationEnabled,
 		rk.PolicyId,
 		rk.IsKeyNotHashed,
+		rk.MaxCostPerRequest,
+		rk.Namespace,
+		rk.MaxPriority,
+		rk.MaxConcurrency,
+		pdata,
+		rk.PolicyVersion,
 	}
 
-	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
-	defer cancel()
-
 	var k key.ResponseKey
 
 	var settingId sql.NullString
 	var data []byte
-	if err := s.db.QueryRowContext(ctxTimeout, query, values...).Scan(
+	var rpdata []byte
+	if err := q.QueryRowContext(ctx, query, values...).Scan(
 		&k.Name,
 		&k.CreatedAt,
 		&k.UpdatedAt,
@@ -841,6 +999,12 @@ func (s *Store) CreateKey(rk *key.RequestKey) (*key.ResponseKey, error) {
 		&k.RotationEnabled,
 		&k.PolicyId,
 		&k.IsKeyNotHashed,
+		&k.MaxCostPerRequest,
+		&k.Namespace,
+		&k.MaxPriority,
+		&k.MaxConcurrency,
+		&rpdata,
+		&k.PolicyVersion,
 	); err != nil {
 		return nil, err
 	}
@@ -857,6 +1021,12 @@ func (s *Store) CreateKey(rk *key.RequestKey) (*key.ResponseKey, error) {
 		pk.AllowedPaths = pathConfigs
 	}
 
+	if len(rpdata) != 0 {
+		if err := json.Unmarshal(rpdata, &pk.RetryPolicy); err != nil {
+			return nil, err
+		}
+	}
+
 	return pk, nil
 }
 
@@ -868,6 +1038,85 @@ func (s *Store) DeleteKey(id string) error {
 	return err
 }
 
+// deleteKeysTx deletes the keys of ids within tx and returns the hashes they
+// were stored under.
+func deleteKeysTx(ctx context.Context, tx *sql.Tx, ids []string) ([]string, error) {
+	hashes := []string{}
+	if len(ids) == 0 {
+		return hashes, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, "DELETE FROM keys WHERE key_id = ANY($1) RETURNING key", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, rows.Err()
+}
+
 func sliceToSqlStringArray(slice []string) string {
 	return "{" + strings.Join(slice, ",") + "}"
 }
+
+func (s *Store) GetKeyCounts(keyIds []string) (*event.KeyCounts, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	query := "SELECT COUNT(*) FILTER (WHERE revoked = False), COUNT(*) FILTER (WHERE revoked = True) FROM keys"
+	args := []any{}
+	if len(keyIds) != 0 {
+		query += " WHERE key_id = ANY($1)"
+		args = append(args, pq.Array(keyIds))
+	}
+
+	counts := &event.KeyCounts{}
+	if err := s.db.QueryRowContext(ctxTimeout, query, args...).Scan(
+		&counts.Active,
+		&counts.Revoked,
+	); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+func (s *Store) GetKeyIdsBySettingId(settingId string) ([]string, error) {
+	return s.getKeyIds("SELECT key_id FROM keys WHERE setting_id = $1 OR $1 = ANY(setting_ids)", settingId)
+}
+
+func (s *Store) GetKeyIdsByPolicyId(policyId string) ([]string, error) {
+	return s.getKeyIds("SELECT key_id FROM keys WHERE policy_id = $1", policyId)
+}
+
+func (s *Store) getKeyIds(query string, args ...any) ([]string, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,174 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/bricks-cloud/bricksllm/internal/alias"
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/lib/pq"
+)
+
+func (s *Store) CreateModelAliasesTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS model_aliases (
+		id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		provider VARCHAR(255) NOT NULL,
+		model VARCHAR(255) NOT NULL,
+		key_ids VARCHAR(255)[]
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanModelAlias(scan func(dest ...any) error) (*alias.Alias, error) {
+	a := &alias.Alias{}
+	if err := scan(
+		&a.Id,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+		&a.Name,
+		&a.Provider,
+		&a.Model,
+		pq.Array(&a.KeyIds),
+	); err != nil {
+		return nil, err
+	}
+
+	if a.KeyIds == nil {
+		a.KeyIds = []string{}
+	}
+
+	return a, nil
+}
+
+func (s *Store) CreateModelAlias(a *alias.Alias) (*alias.Alias, error) {
+	query := `
+		INSERT INTO model_aliases (id, created_at, updated_at, name, provider, model, key_ids)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING *
+	`
+
+	values := []any{
+		a.Id,
+		a.CreatedAt,
+		a.UpdatedAt,
+		a.Name,
+		a.Provider,
+		a.Model,
+		pq.Array(a.KeyIds),
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	return scanModelAlias(s.db.QueryRowContext(ctxTimeout, query, values...).Scan)
+}
+
+func (s *Store) GetModelAlias(id string) (*alias.Alias, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	retrieved, err := scanModelAlias(s.db.QueryRowContext(ctxTimeout, "SELECT * FROM model_aliases WHERE $1 = id", id).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("model alias is not found")
+		}
+		return nil, err
+	}
+
+	return retrieved, nil
+}
+
+func (s *Store) GetModelAliases() ([]*alias.Alias, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, "SELECT * FROM model_aliases ORDER BY created_at")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := []*alias.Alias{}
+	for rows.Next() {
+		a, err := scanModelAlias(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		aliases = append(aliases, a)
+	}
+
+	return aliases, nil
+}
+
+func (s *Store) UpdateModelAlias(id string, a *alias.UpdateAlias) (*alias.Alias, error) {
+	values := []any{
+		id,
+		a.UpdatedAt,
+	}
+
+	fields := []string{"updated_at = $2"}
+
+	d := 3
+
+	if len(a.Model) != 0 {
+		values = append(values, a.Model)
+		fields = append(fields, fmt.Sprintf("model = $%d", d))
+		d++
+	}
+
+	if a.KeyIds != nil {
+		values = append(values, pq.Array(*a.KeyIds))
+		fields = append(fields, fmt.Sprintf("key_ids = $%d", d))
+	}
+
+	query := fmt.Sprintf("UPDATE model_aliases SET %s WHERE id = $1 RETURNING *", strings.Join(fields, ","))
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	updated, err := scanModelAlias(s.db.QueryRowContext(ctxTimeout, query, values...).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError(fmt.Sprintf("model alias not found for id: %s", id))
+		}
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (s *Store) DeleteModelAlias(id string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctxTimeout, "DELETE FROM model_aliases WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return internal_errors.NewNotFoundError("model alias is not found for id: " + id)
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/bricks-cloud/bricksllm/internal/catalog"
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+)
+
+func (s *Store) CreateModelPricingTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS model_pricing (
+		id VARCHAR(255) PRIMARY KEY,
+		provider VARCHAR(255) NOT NULL,
+		model VARCHAR(255) NOT NULL,
+		context_window INT NOT NULL DEFAULT 0,
+		input_cost_per_million_tokens FLOAT8 NOT NULL DEFAULT 0,
+		output_cost_per_million_tokens FLOAT8 NOT NULL DEFAULT 0,
+		supports_tools BOOLEAN NOT NULL DEFAULT FALSE,
+		supports_vision BOOLEAN NOT NULL DEFAULT FALSE,
+		supports_streaming BOOLEAN NOT NULL DEFAULT FALSE,
+		overridden BOOLEAN NOT NULL DEFAULT FALSE,
+		updated_at BIGINT NOT NULL,
+		UNIQUE (provider, model)
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SeedModelPricing writes the built in pricing into the pricing table. Entries
+// that admins have overridden are left as they are.
+func (s *Store) SeedModelPricing(defaults []*catalog.Pricing, updatedAt int64) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctxTimeout, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO model_pricing (id, provider, model, context_window, input_cost_per_million_tokens, output_cost_per_million_tokens, supports_tools, supports_vision, supports_streaming, overridden, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, FALSE, $10)
+		ON CONFLICT (provider, model) DO UPDATE SET context_window = EXCLUDED.context_window, input_cost_per_million_tokens = EXCLUDED.input_cost_per_million_tokens, output_cost_per_million_tokens = EXCLUDED.output_cost_per_million_tokens, supports_tools = EXCLUDED.supports_tools, supports_vision = EXCLUDED.supports_vision, supports_streaming = EXCLUDED.supports_streaming, updated_at = EXCLUDED.updated_at
+		WHERE NOT model_pricing.overridden
+	`
+
+	for _, p := range defaults {
+		if _, err := tx.ExecContext(ctxTimeout, query, util.NewUuid(), p.Provider, p.Model, p.ContextWindow, p.InputCostPerMillionTokens, p.OutputCostPerMillionTokens, p.SupportsTools, p.SupportsVision, p.SupportsStreaming, updatedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+type pricingScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPricing(row pricingScanner) (*catalog.Pricing, error) {
+	p := &catalog.Pricing{}
+	if err := row.Scan(
+		&p.Id,
+		&p.Provider,
+		&p.Model,
+		&p.ContextWindow,
+		&p.InputCostPerMillionTokens,
+		&p.OutputCostPerMillionTokens,
+		&p.SupportsTools,
+		&p.SupportsVision,
+		&p.SupportsStreaming,
+		&p.Overridden,
+		&p.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// GetModelPricing returns the entries of the pricing table. Only entries that
+// admins have overridden are returned when overriddenOnly is set.
+func (s *Store) GetModelPricing(overriddenOnly bool) ([]*catalog.Pricing, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	query := "SELECT * FROM model_pricing ORDER BY provider, model"
+	if overriddenOnly {
+		query = "SELECT * FROM model_pricing WHERE overridden ORDER BY provider, model"
+	}
+
+	rows, err := s.db.QueryContext(ctxTimeout, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pricing := []*catalog.Pricing{}
+	for rows.Next() {
+		p, err := scanPricing(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		pricing = append(pricing, p)
+	}
+
+	return pricing, rows.Err()
+}
+
+// GetUpdatedModelPricing returns the overridden entries of the pricing table
+// that were updated after updatedAt.
+func (s *Store) GetUpdatedModelPricing(updatedAt int64) ([]*catalog.Pricing, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, "SELECT * FROM model_pricing WHERE overridden AND updated_at > $1", updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pricing := []*catalog.Pricing{}
+	for rows.Next() {
+		p, err := scanPricing(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		pricing = append(pricing, p)
+	}
+
+	return pricing, rows.Err()
+}
+
+func (s *Store) GetModelPricingById(id string) (*catalog.Pricing, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	p, err := scanPricing(s.db.QueryRowContext(ctxTimeout, "SELECT * FROM model_pricing WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("model pricing is not found for: " + id)
+		}
+
+		return nil, err
+	}
+
+	return p, nil
+}
+
+const upsertModelPricingQuery = `
+	INSERT INTO model_pricing (id, provider, model, context_window, input_cost_per_million_tokens, output_cost_per_million_tokens, supports_tools, supports_vision, supports_streaming, overridden, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, TRUE, $10)
+	ON CONFLICT (provider, model) DO UPDATE SET context_window = EXCLUDED.context_window, input_cost_per_million_tokens = EXCLUDED.input_cost_per_million_tokens, output_cost_per_million_tokens = EXCLUDED.output_cost_per_million_tokens, supports_tools = EXCLUDED.supports_tools, supports_vision = EXCLUDED.supports_vision, supports_streaming = EXCLUDED.supports_streaming, overridden = TRUE, updated_at = EXCLUDED.updated_at
+	RETURNING *
+`
+
+type pricingQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func upsertModelPricing(ctx context.Context, q pricingQuerier, p *catalog.Pricing) (*catalog.Pricing, error) {
+	id := p.Id
+	if len(id) == 0 {
+		id = util.NewUuid()
+	}
+
+	return scanPricing(q.QueryRowContext(ctx, upsertModelPricingQuery, id, p.Provider, p.Model, p.ContextWindow, p.InputCostPerMillionTokens, p.OutputCostPerMillionTokens, p.SupportsTools, p.SupportsVision, p.SupportsStreaming, p.UpdatedAt))
+}
+
+// UpsertModelPricing stores an override of the pricing of a model.
+func (s *Store) UpsertModelPricing(p *catalog.Pricing) (*catalog.Pricing, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	return upsertModelPricing(ctxTimeout, s.db, p)
+}
+
+// UpsertModelPricings stores overrides of the pricing of several models in
+// one transaction, so that either all of them or none are stored.
+func (s *Store) UpsertModelPricings(ps []*catalog.Pricing) ([]*catalog.Pricing, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctxTimeout, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stored := []*catalog.Pricing{}
+	for _, p := range ps {
+		upserted, err := upsertModelPricing(ctxTimeout, tx, p)
+		if err != nil {
+			return nil, err
+		}
+
+		stored = append(stored, upserted)
+	}
+
+	return stored, tx.Commit()
+}
@@ -0,0 +1,77 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+	"github.com/lib/pq"
+)
+
+func (s *Store) CreatePiiTokensTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS pii_tokens (
+		policy_id VARCHAR(255) NOT NULL,
+		token VARCHAR(255) NOT NULL,
+		value TEXT NOT NULL,
+		created_at BIGINT NOT NULL,
+		PRIMARY KEY (policy_id, token)
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// InsertPiiToken stores the encrypted value of a token of a policy. Tokens are
+// deterministic, so storing one again is a no-op.
+func (s *Store) InsertPiiToken(policyId, token, encrypted string, createdAt int64) error {
+	query := `
+		INSERT INTO pii_tokens (policy_id, token, value, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (policy_id, token) DO NOTHING
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctxTimeout, query, policyId, token, encrypted, createdAt)
+	return err
+}
+
+// GetPiiTokens returns the stored tokens of a policy among tokens.
+func (s *Store) GetPiiTokens(policyId string, tokens []string) ([]*policy.VaultToken, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, "SELECT token, value, created_at FROM pii_tokens WHERE policy_id = $1 AND token = ANY($2)", policyId, pq.Array(tokens))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := []*policy.VaultToken{}
+	for rows.Next() {
+		t := &policy.VaultToken{}
+		if err := rows.Scan(&t.Token, &t.Value, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		found = append(found, t)
+	}
+
+	return found, rows.Err()
+}
+
+// DeletePiiTokens removes the tokens of a deleted policy.
+func (s *Store) DeletePiiTokens(policyId string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctxTimeout, "DELETE FROM pii_tokens WHERE policy_id = $1", policyId)
+	return err
+}
@@ -35,6 +35,21 @@ func (s *Store) CreatePolicyTable() error {
 	return nil
 }
 
+func (s *Store) AlterPolicyTable() error {
+	alterTableQuery := `
+		ALTER TABLE policies ADD COLUMN IF NOT EXISTS conditions JSONB NOT NULL DEFAULT '[]'::JSONB, ADD COLUMN IF NOT EXISTS namespace VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS response_config JSONB NOT NULL DEFAULT 'null'::JSONB, ADD COLUMN IF NOT EXISTS moderation_config JSONB NOT NULL DEFAULT 'null'::JSONB, ADD COLUMN IF NOT EXISTS injection_config JSONB NOT NULL DEFAULT 'null'::JSONB, ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1, ADD COLUMN IF NOT EXISTS rollout JSONB NOT NULL DEFAULT 'null'::JSONB, ADD COLUMN IF NOT EXISTS limit_config JSONB NOT NULL DEFAULT 'null'::JSONB, ADD COLUMN IF NOT EXISTS topic_config JSONB NOT NULL DEFAULT 'null'::JSONB, ADD COLUMN IF NOT EXISTS language_config JSONB NOT NULL DEFAULT 'null'::JSONB, ADD COLUMN IF NOT EXISTS latency_budget JSONB NOT NULL DEFAULT 'null'::JSONB, ADD COLUMN IF NOT EXISTS guardrail_config JSONB NOT NULL DEFAULT 'null'::JSONB, ADD COLUMN IF NOT EXISTS schema_config JSONB NOT NULL DEFAULT 'null'::JSONB;
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, alterTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *Store) CreatePolicy(p *policy.Policy) (*policy.Policy, error) {
 	fields := []string{
 		"id",
@@ -42,6 +57,7 @@ func (s *Store) CreatePolicy(p *policy.Policy) (*policy.Policy, error) {
 		"updated_at",
 		"tags",
 		"name",
+		"namespace",
 	}
 
 	values := []any{
@@ -50,12 +66,13 @@ func (s *Store) CreatePolicy(p *policy.Policy) (*policy.Policy, error) {
 		p.UpdatedAt,
 		pq.Array(p.Tags),
 		p.Name,
+		p.Namespace,
 	}
 
 	vidxs := []string{
-		"$1", "$2", "$3", "$4", "$5",
+		"$1", "$2", "$3", "$4", "$5", "$6",
 	}
-	idx := 6
+	idx := 7
 
 	if p.Config != nil {
 		cd, err := json.Marshal(p.Config)
@@ -90,6 +107,126 @@ func (s *Store) CreatePolicy(p *policy.Policy) (*policy.Policy, error) {
 		fields = append(fields, "custom_config")
 		values = append(values, cd)
 		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.Conditions != nil {
+		cd, err := json.Marshal(p.Conditions)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "conditions")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.ResponseConfig != nil {
+		cd, err := json.Marshal(p.ResponseConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "response_config")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.ModerationConfig != nil {
+		cd, err := json.Marshal(p.ModerationConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "moderation_config")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.InjectionConfig != nil {
+		cd, err := json.Marshal(p.InjectionConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "injection_config")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.LimitConfig != nil {
+		cd, err := json.Marshal(p.LimitConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "limit_config")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.TopicConfig != nil {
+		cd, err := json.Marshal(p.TopicConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "topic_config")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.LanguageConfig != nil {
+		cd, err := json.Marshal(p.LanguageConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "language_config")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.LatencyBudget != nil {
+		cd, err := json.Marshal(p.LatencyBudget)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "latency_budget")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.GuardrailConfig != nil {
+		cd, err := json.Marshal(p.GuardrailConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "guardrail_config")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
+		idx++
+	}
+
+	if p.SchemaConfig != nil {
+		cd, err := json.Marshal(p.SchemaConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, "schema_config")
+		values = append(values, cd)
+		vidxs = append(vidxs, fmt.Sprintf("$%d", idx))
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), s.wt)
@@ -106,6 +243,17 @@ func (s *Store) CreatePolicy(p *policy.Policy) (*policy.Policy, error) {
 	var createdcd []byte
 	var createdcusd []byte
 	var createdregexd []byte
+	var createdcondd []byte
+	var createdrespd []byte
+	var createdmodd []byte
+	var createdinjd []byte
+	var createdrold []byte
+	var createdlimd []byte
+	var createdtopd []byte
+	var createdlangd []byte
+	var createdlatd []byte
+	var createdguardd []byte
+	var createdschd []byte
 	row := s.db.QueryRowContext(ctx, query, values...)
 	if err := row.Scan(
 		&created.Id,
@@ -116,6 +264,19 @@ func (s *Store) CreatePolicy(p *policy.Policy) (*policy.Policy, error) {
 		&createdcd,
 		&createdregexd,
 		&createdcusd,
+		&createdcondd,
+		&created.Namespace,
+		&createdrespd,
+		&createdmodd,
+		&createdinjd,
+		&created.Version,
+		&createdrold,
+		&createdlimd,
+		&createdtopd,
+		&createdlangd,
+		&createdlatd,
+		&createdguardd,
+		&createdschd,
 	); err != nil {
 
 		return nil, err
@@ -139,6 +300,72 @@ func (s *Store) CreatePolicy(p *policy.Policy) (*policy.Policy, error) {
 		}
 	}
 
+	if len(createdcondd) != 0 {
+		if err := json.Unmarshal(createdcondd, &created.Conditions); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdrespd) != 0 {
+		if err := json.Unmarshal(createdrespd, &created.ResponseConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdmodd) != 0 {
+		if err := json.Unmarshal(createdmodd, &created.ModerationConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdinjd) != 0 {
+		if err := json.Unmarshal(createdinjd, &created.InjectionConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdrold) != 0 {
+		if err := json.Unmarshal(createdrold, &created.Rollout); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdlimd) != 0 {
+		if err := json.Unmarshal(createdlimd, &created.LimitConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdtopd) != 0 {
+		if err := json.Unmarshal(createdtopd, &created.TopicConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdlangd) != 0 {
+		if err := json.Unmarshal(createdlangd, &created.LanguageConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdlatd) != 0 {
+		if err := json.Unmarshal(createdlatd, &created.LatencyBudget); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdguardd) != 0 {
+		if err := json.Unmarshal(createdguardd, &created.GuardrailConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(createdschd) != 0 {
+		if err := json.Unmarshal(createdschd, &created.SchemaConfig); err != nil {
+			return nil, err
+		}
+	}
+
 	return created, nil
 }
 
@@ -148,7 +375,7 @@ func (s *Store) UpdatePolicy(id string, p *policy.UpdatePolicy) (*policy.Policy,
 		p.UpdatedAt,
 	}
 
-	fields := []string{"updated_at = $2"}
+	fields := []string{"updated_at = $2", "version = version + 1"}
 
 	d := 3
 
@@ -194,6 +421,116 @@ func (s *Store) UpdatePolicy(id string, p *policy.UpdatePolicy) (*policy.Policy,
 
 		values = append(values, data)
 		fields = append(fields, fmt.Sprintf("custom_config = $%d", d))
+		d++
+	}
+
+	if p.Conditions != nil {
+		data, err := json.Marshal(p.Conditions)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("conditions = $%d", d))
+		d++
+	}
+
+	if p.ResponseConfig != nil {
+		data, err := json.Marshal(p.ResponseConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("response_config = $%d", d))
+		d++
+	}
+
+	if p.ModerationConfig != nil {
+		data, err := json.Marshal(p.ModerationConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("moderation_config = $%d", d))
+		d++
+	}
+
+	if p.InjectionConfig != nil {
+		data, err := json.Marshal(p.InjectionConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("injection_config = $%d", d))
+		d++
+	}
+
+	if p.LimitConfig != nil {
+		data, err := json.Marshal(p.LimitConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("limit_config = $%d", d))
+		d++
+	}
+
+	if p.TopicConfig != nil {
+		data, err := json.Marshal(p.TopicConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("topic_config = $%d", d))
+		d++
+	}
+
+	if p.LanguageConfig != nil {
+		data, err := json.Marshal(p.LanguageConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("language_config = $%d", d))
+		d++
+	}
+
+	if p.LatencyBudget != nil {
+		data, err := json.Marshal(p.LatencyBudget)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("latency_budget = $%d", d))
+		d++
+	}
+
+	if p.GuardrailConfig != nil {
+		data, err := json.Marshal(p.GuardrailConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("guardrail_config = $%d", d))
+		d++
+	}
+
+	if p.SchemaConfig != nil {
+		data, err := json.Marshal(p.SchemaConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("schema_config = $%d", d))
 	}
 
 	query := fmt.Sprintf("UPDATE policies SET %s WHERE id = $1 RETURNING *", strings.Join(fields, ","))
@@ -204,6 +541,17 @@ func (s *Store) UpdatePolicy(id string, p *policy.UpdatePolicy) (*policy.Policy,
 	var cd []byte
 	var cusd []byte
 	var regexd []byte
+	var condd []byte
+	var respd []byte
+	var modd []byte
+	var injd []byte
+	var rold []byte
+	var limd []byte
+	var topd []byte
+	var langd []byte
+	var latd []byte
+	var guardd []byte
+	var schd []byte
 	row := s.db.QueryRowContext(ctxTimeout, query, values...)
 	if err := row.Scan(
 		&updated.Id,
@@ -214,6 +562,19 @@ func (s *Store) UpdatePolicy(id string, p *policy.UpdatePolicy) (*policy.Policy,
 		&cd,
 		&regexd,
 		&cusd,
+		&condd,
+		&updated.Namespace,
+		&respd,
+		&modd,
+		&injd,
+		&updated.Version,
+		&rold,
+		&limd,
+		&topd,
+		&langd,
+		&latd,
+		&guardd,
+		&schd,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, internal_errors.NewNotFoundError("policy is not found for id: " + id)
@@ -240,6 +601,72 @@ func (s *Store) UpdatePolicy(id string, p *policy.UpdatePolicy) (*policy.Policy,
 		}
 	}
 
+	if len(condd) != 0 {
+		if err := json.Unmarshal(condd, &updated.Conditions); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(respd) != 0 {
+		if err := json.Unmarshal(respd, &updated.ResponseConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(modd) != 0 {
+		if err := json.Unmarshal(modd, &updated.ModerationConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(injd) != 0 {
+		if err := json.Unmarshal(injd, &updated.InjectionConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rold) != 0 {
+		if err := json.Unmarshal(rold, &updated.Rollout); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(limd) != 0 {
+		if err := json.Unmarshal(limd, &updated.LimitConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(topd) != 0 {
+		if err := json.Unmarshal(topd, &updated.TopicConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(langd) != 0 {
+		if err := json.Unmarshal(langd, &updated.LanguageConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(latd) != 0 {
+		if err := json.Unmarshal(latd, &updated.LatencyBudget); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(guardd) != 0 {
+		if err := json.Unmarshal(guardd, &updated.GuardrailConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(schd) != 0 {
+		if err := json.Unmarshal(schd, &updated.SchemaConfig); err != nil {
+			return nil, err
+		}
+	}
+
 	return updated, nil
 }
 
@@ -258,6 +685,17 @@ func (s *Store) GetAllPolicies() ([]*policy.Policy, error) {
 		var cd []byte
 		var cusd []byte
 		var regexd []byte
+		var condd []byte
+		var respd []byte
+		var modd []byte
+		var injd []byte
+		var rold []byte
+		var limd []byte
+		var topd []byte
+		var langd []byte
+		var latd []byte
+		var guardd []byte
+		var schd []byte
 
 		p := &policy.Policy{}
 		if err := rows.Scan(
@@ -269,6 +707,19 @@ func (s *Store) GetAllPolicies() ([]*policy.Policy, error) {
 			&cd,
 			&regexd,
 			&cusd,
+			&condd,
+			&p.Namespace,
+			&respd,
+			&modd,
+			&injd,
+			&p.Version,
+			&rold,
+			&limd,
+			&topd,
+			&langd,
+			&latd,
+			&guardd,
+			&schd,
 		); err != nil {
 			return nil, err
 		}
@@ -291,6 +742,72 @@ func (s *Store) GetAllPolicies() ([]*policy.Policy, error) {
 			}
 		}
 
+		if len(condd) != 0 {
+			if err := json.Unmarshal(condd, &p.Conditions); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(respd) != 0 {
+			if err := json.Unmarshal(respd, &p.ResponseConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(modd) != 0 {
+			if err := json.Unmarshal(modd, &p.ModerationConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(injd) != 0 {
+			if err := json.Unmarshal(injd, &p.InjectionConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(rold) != 0 {
+			if err := json.Unmarshal(rold, &p.Rollout); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(limd) != 0 {
+			if err := json.Unmarshal(limd, &p.LimitConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(topd) != 0 {
+			if err := json.Unmarshal(topd, &p.TopicConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(langd) != 0 {
+			if err := json.Unmarshal(langd, &p.LanguageConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(latd) != 0 {
+			if err := json.Unmarshal(latd, &p.LatencyBudget); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(guardd) != 0 {
+			if err := json.Unmarshal(guardd, &p.GuardrailConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(schd) != 0 {
+			if err := json.Unmarshal(schd, &p.SchemaConfig); err != nil {
+				return nil, err
+			}
+		}
+
 		ps = append(ps, p)
 	}
 
@@ -307,6 +824,17 @@ func (s *Store) GetPolicyById(id string) (*policy.Policy, error) {
 	var cd []byte
 	var cusd []byte
 	var regexd []byte
+	var condd []byte
+	var respd []byte
+	var modd []byte
+	var injd []byte
+	var rold []byte
+	var limd []byte
+	var topd []byte
+	var langd []byte
+	var latd []byte
+	var guardd []byte
+	var schd []byte
 
 	if err := row.Scan(
 		&p.Id,
@@ -317,6 +845,19 @@ func (s *Store) GetPolicyById(id string) (*policy.Policy, error) {
 		&cd,
 		&regexd,
 		&cusd,
+		&condd,
+		&p.Namespace,
+		&respd,
+		&modd,
+		&injd,
+		&p.Version,
+		&rold,
+		&limd,
+		&topd,
+		&langd,
+		&latd,
+		&guardd,
+		&schd,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, internal_errors.NewNotFoundError("policy is not found for id: " + id)
@@ -343,6 +884,72 @@ func (s *Store) GetPolicyById(id string) (*policy.Policy, error) {
 		}
 	}
 
+	if len(condd) != 0 {
+		if err := json.Unmarshal(condd, &p.Conditions); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(respd) != 0 {
+		if err := json.Unmarshal(respd, &p.ResponseConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(modd) != 0 {
+		if err := json.Unmarshal(modd, &p.ModerationConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(injd) != 0 {
+		if err := json.Unmarshal(injd, &p.InjectionConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rold) != 0 {
+		if err := json.Unmarshal(rold, &p.Rollout); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(limd) != 0 {
+		if err := json.Unmarshal(limd, &p.LimitConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(topd) != 0 {
+		if err := json.Unmarshal(topd, &p.TopicConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(langd) != 0 {
+		if err := json.Unmarshal(langd, &p.LanguageConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(latd) != 0 {
+		if err := json.Unmarshal(latd, &p.LatencyBudget); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(guardd) != 0 {
+		if err := json.Unmarshal(guardd, &p.GuardrailConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(schd) != 0 {
+		if err := json.Unmarshal(schd, &p.SchemaConfig); err != nil {
+			return nil, err
+		}
+	}
+
 	return p, nil
 }
 
@@ -362,6 +969,17 @@ func (s *Store) GetPoliciesByTags(tags []string) ([]*policy.Policy, error) {
 		var cd []byte
 		var cusd []byte
 		var regexd []byte
+		var condd []byte
+		var respd []byte
+		var modd []byte
+		var injd []byte
+		var rold []byte
+		var limd []byte
+		var topd []byte
+		var langd []byte
+		var latd []byte
+		var guardd []byte
+		var schd []byte
 
 		p := &policy.Policy{}
 
@@ -374,6 +992,19 @@ func (s *Store) GetPoliciesByTags(tags []string) ([]*policy.Policy, error) {
 			&cd,
 			&regexd,
 			&cusd,
+			&condd,
+			&p.Namespace,
+			&respd,
+			&modd,
+			&injd,
+			&p.Version,
+			&rold,
+			&limd,
+			&topd,
+			&langd,
+			&latd,
+			&guardd,
+			&schd,
 		); err != nil {
 			return nil, err
 		}
@@ -396,6 +1027,72 @@ func (s *Store) GetPoliciesByTags(tags []string) ([]*policy.Policy, error) {
 			}
 		}
 
+		if len(condd) != 0 {
+			if err := json.Unmarshal(condd, &p.Conditions); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(respd) != 0 {
+			if err := json.Unmarshal(respd, &p.ResponseConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(modd) != 0 {
+			if err := json.Unmarshal(modd, &p.ModerationConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(injd) != 0 {
+			if err := json.Unmarshal(injd, &p.InjectionConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(rold) != 0 {
+			if err := json.Unmarshal(rold, &p.Rollout); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(limd) != 0 {
+			if err := json.Unmarshal(limd, &p.LimitConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(topd) != 0 {
+			if err := json.Unmarshal(topd, &p.TopicConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(langd) != 0 {
+			if err := json.Unmarshal(langd, &p.LanguageConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(latd) != 0 {
+			if err := json.Unmarshal(latd, &p.LatencyBudget); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(guardd) != 0 {
+			if err := json.Unmarshal(guardd, &p.GuardrailConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(schd) != 0 {
+			if err := json.Unmarshal(schd, &p.SchemaConfig); err != nil {
+				return nil, err
+			}
+		}
+
 		ps = append(ps, p)
 
 	}
@@ -418,6 +1115,17 @@ func (s *Store) GetUpdatedPolicies(updatedAt int64) ([]*policy.Policy, error) {
 		var cd []byte
 		var cusd []byte
 		var regexd []byte
+		var condd []byte
+		var respd []byte
+		var modd []byte
+		var injd []byte
+		var rold []byte
+		var limd []byte
+		var topd []byte
+		var langd []byte
+		var latd []byte
+		var guardd []byte
+		var schd []byte
 
 		p := &policy.Policy{}
 		if err := rows.Scan(
@@ -429,6 +1137,19 @@ func (s *Store) GetUpdatedPolicies(updatedAt int64) ([]*policy.Policy, error) {
 			&cd,
 			&regexd,
 			&cusd,
+			&condd,
+			&p.Namespace,
+			&respd,
+			&modd,
+			&injd,
+			&p.Version,
+			&rold,
+			&limd,
+			&topd,
+			&langd,
+			&latd,
+			&guardd,
+			&schd,
 		); err != nil {
 			return nil, err
 		}
@@ -451,8 +1172,148 @@ func (s *Store) GetUpdatedPolicies(updatedAt int64) ([]*policy.Policy, error) {
 			}
 		}
 
+		if len(condd) != 0 {
+			if err := json.Unmarshal(condd, &p.Conditions); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(respd) != 0 {
+			if err := json.Unmarshal(respd, &p.ResponseConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(modd) != 0 {
+			if err := json.Unmarshal(modd, &p.ModerationConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(injd) != 0 {
+			if err := json.Unmarshal(injd, &p.InjectionConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(rold) != 0 {
+			if err := json.Unmarshal(rold, &p.Rollout); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(limd) != 0 {
+			if err := json.Unmarshal(limd, &p.LimitConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(topd) != 0 {
+			if err := json.Unmarshal(topd, &p.TopicConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(langd) != 0 {
+			if err := json.Unmarshal(langd, &p.LanguageConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(latd) != 0 {
+			if err := json.Unmarshal(latd, &p.LatencyBudget); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(guardd) != 0 {
+			if err := json.Unmarshal(guardd, &p.GuardrailConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(schd) != 0 {
+			if err := json.Unmarshal(schd, &p.SchemaConfig); err != nil {
+				return nil, err
+			}
+		}
+
 		ps = append(ps, p)
 	}
 
 	return ps, nil
 }
+
+func (s *Store) DeletePolicy(id string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctxTimeout, "DELETE FROM policies WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return internal_errors.NewNotFoundError("policy is not found for id: " + id)
+	}
+
+	return nil
+}
+
+// DeletePolicyWithDependents deletes a policy with its versions and vault
+// tokens, together with the keys and routes of keyIds and routeIds, in one
+// transaction. It returns the hashes of the deleted keys and the paths of the
+// deleted routes so that their caches can be invalidated.
+func (s *Store) DeletePolicyWithDependents(id string, keyIds, routeIds []string) ([]string, []string, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctxTimeout, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	hashes, err := deleteKeysTx(ctxTimeout, tx, keyIds)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	paths, err := deleteRoutesTx(ctxTimeout, tx, routeIds)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := tx.ExecContext(ctxTimeout, "DELETE FROM policies WHERE id = $1", id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if affected == 0 {
+		return nil, nil, internal_errors.NewNotFoundError("policy is not found for id: " + id)
+	}
+
+	if _, err := tx.ExecContext(ctxTimeout, "DELETE FROM policy_versions WHERE policy_id = $1", id); err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := tx.ExecContext(ctxTimeout, "DELETE FROM pii_tokens WHERE policy_id = $1", id); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return hashes, paths, nil
+}
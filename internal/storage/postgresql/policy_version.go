@@ -0,0 +1,140 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/policy"
+)
+
+func (s *Store) CreatePolicyVersionsTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS policy_versions (
+		policy_id VARCHAR(255) NOT NULL,
+		version INT NOT NULL,
+		policy JSONB NOT NULL,
+		created_at BIGINT NOT NULL,
+		PRIMARY KEY (policy_id, version)
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// InsertPolicyVersion stores p under its current version. A version is only
+// ever stored once, so inserting it again is a no-op.
+func (s *Store) InsertPolicyVersion(p *policy.Policy, createdAt int64) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO policy_versions (policy_id, version, policy, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (policy_id, version) DO NOTHING
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err = s.db.ExecContext(ctxTimeout, query, p.Id, p.Version, data, createdAt)
+	return err
+}
+
+func scanPolicyVersion(scan func(dest ...any) error) (*policy.PolicyVersion, error) {
+	v := &policy.PolicyVersion{}
+	var data []byte
+	if err := scan(&v.PolicyId, &v.Version, &data, &v.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	v.Policy = &policy.Policy{}
+	if err := json.Unmarshal(data, v.Policy); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// GetPolicyVersions returns the versions of a policy, newest first.
+func (s *Store) GetPolicyVersions(policyId string) ([]*policy.PolicyVersion, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, "SELECT policy_id, version, policy, created_at FROM policy_versions WHERE policy_id = $1 ORDER BY version DESC", policyId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []*policy.PolicyVersion{}
+	for rows.Next() {
+		v, err := scanPolicyVersion(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, v)
+	}
+
+	return versions, rows.Err()
+}
+
+func (s *Store) GetPolicyVersion(policyId string, version int) (*policy.PolicyVersion, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	row := s.db.QueryRowContext(ctxTimeout, "SELECT policy_id, version, policy, created_at FROM policy_versions WHERE policy_id = $1 AND version = $2", policyId, version)
+	v, err := scanPolicyVersion(row.Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError(fmt.Sprintf("version %d of policy %s is not found", version, policyId))
+		}
+
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// DeletePolicyVersions removes the history of a deleted policy.
+func (s *Store) DeletePolicyVersions(policyId string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctxTimeout, "DELETE FROM policy_versions WHERE policy_id = $1", policyId)
+	return err
+}
+
+// SetPolicyRollout sets the rollout of a policy without making a new version
+// of it. A nil rollout ends the one in progress.
+func (s *Store) SetPolicyRollout(id string, r *policy.Rollout, updatedAt int64) (*policy.Policy, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "UPDATE policies SET rollout = $2, updated_at = $3 WHERE id = $1", id, data, updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return nil, internal_errors.NewNotFoundError("policy is not found for id: " + id)
+	}
+
+	return s.GetPolicyById(id)
+}
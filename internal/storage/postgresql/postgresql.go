@@ -1,6 +1,7 @@
 package postgresql
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"errors"
@@ -15,6 +16,11 @@ type Store struct {
 	rt time.Duration
 }
 
+// rowQuerier is implemented by both *sql.DB and *sql.Tx so that inserts can be reused inside transactions.
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 func NewStore(connStr string, wt time.Duration, rt time.Duration) (*Store, error) {
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
@@ -28,6 +34,10 @@ func NewStore(connStr string, wt time.Duration, rt time.Duration) (*Store, error
 	}, nil
 }
 
+func (s *Store) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
 type NullArray struct {
 	Array []string
 	Valid bool
@@ -0,0 +1,128 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+)
+
+func (s *Store) CreateProviderSettingVersionsTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS provider_setting_versions (
+		setting_id VARCHAR(255) NOT NULL,
+		version INT NOT NULL,
+		setting JSONB NOT NULL,
+		created_at BIGINT NOT NULL,
+		PRIMARY KEY (setting_id, version)
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// InsertProviderSettingVersion stores setting, secrets included, as the next
+// version of it.
+func (s *Store) InsertProviderSettingVersion(setting *provider.Setting, createdAt int64) (*provider.SettingVersion, error) {
+	data, err := json.Marshal(setting)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO provider_setting_versions (setting_id, version, setting, created_at)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3 FROM provider_setting_versions WHERE setting_id = $1
+		RETURNING version
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	v := &provider.SettingVersion{
+		SettingId: setting.Id,
+		Setting:   setting,
+		CreatedAt: createdAt,
+	}
+
+	if err := s.db.QueryRowContext(ctxTimeout, query, setting.Id, data, createdAt).Scan(&v.Version); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func scanProviderSettingVersion(scan func(dest ...any) error) (*provider.SettingVersion, error) {
+	v := &provider.SettingVersion{}
+	var data []byte
+	if err := scan(&v.SettingId, &v.Version, &data, &v.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	v.Setting = &provider.Setting{}
+	if err := json.Unmarshal(data, v.Setting); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// GetProviderSettingVersions returns the versions of a provider setting, newest
+// first.
+func (s *Store) GetProviderSettingVersions(settingId string) ([]*provider.SettingVersion, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, "SELECT setting_id, version, setting, created_at FROM provider_setting_versions WHERE setting_id = $1 ORDER BY version DESC", settingId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []*provider.SettingVersion{}
+	for rows.Next() {
+		v, err := scanProviderSettingVersion(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, v)
+	}
+
+	return versions, rows.Err()
+}
+
+func (s *Store) GetProviderSettingVersion(settingId string, version int) (*provider.SettingVersion, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	row := s.db.QueryRowContext(ctxTimeout, "SELECT setting_id, version, setting, created_at FROM provider_setting_versions WHERE setting_id = $1 AND version = $2", settingId, version)
+	v, err := scanProviderSettingVersion(row.Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError(fmt.Sprintf("version %d of provider setting %s is not found", version, settingId))
+		}
+
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// DeleteProviderSettingVersions removes the history of a deleted provider
+// setting, so that none of its secrets are kept around.
+func (s *Store) DeleteProviderSettingVersions(settingId string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctxTimeout, "DELETE FROM provider_setting_versions WHERE setting_id = $1", settingId)
+	return err
+}
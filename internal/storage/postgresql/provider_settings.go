@@ -36,7 +36,7 @@ func (s *Store) CreateProviderSettingsTable() error {
 
 func (s *Store) AlterProviderSettingsTable() error {
 	alterTableQuery := `
-		ALTER TABLE provider_settings ADD COLUMN IF NOT EXISTS name VARCHAR(255), ADD COLUMN IF NOT EXISTS allowed_models VARCHAR(255)[], ADD COLUMN IF NOT EXISTS cost_map JSONB NOT NULL DEFAULT '{}'::JSONB
+		ALTER TABLE provider_settings ADD COLUMN IF NOT EXISTS name VARCHAR(255), ADD COLUMN IF NOT EXISTS allowed_models VARCHAR(255)[], ADD COLUMN IF NOT EXISTS cost_map JSONB NOT NULL DEFAULT '{}'::JSONB, ADD COLUMN IF NOT EXISTS namespace VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS deployments JSONB NOT NULL DEFAULT '[]'::JSONB, ADD COLUMN IF NOT EXISTS key_pool JSONB, ADD COLUMN IF NOT EXISTS labels JSONB NOT NULL DEFAULT '{}'::JSONB, ADD COLUMN IF NOT EXISTS environment VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS headers JSONB NOT NULL DEFAULT '{}'::JSONB, ADD COLUMN IF NOT EXISTS query_params JSONB NOT NULL DEFAULT '{}'::JSONB, ADD COLUMN IF NOT EXISTS spend_limit JSONB, ADD COLUMN IF NOT EXISTS disabled BOOLEAN NOT NULL DEFAULT FALSE, ADD COLUMN IF NOT EXISTS disabled_reason VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS transport_config JSONB, ADD COLUMN IF NOT EXISTS token_budget JSONB
 	`
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
@@ -56,6 +56,14 @@ func (s *Store) GetProviderSetting(id string, withSecret bool) (*provider.Settin
 	setting := &provider.Setting{}
 	var data []byte
 	var cmdata []byte
+	var dpdata []byte
+	var kpdata []byte
+	var lbdata []byte
+	var hddata []byte
+	var qpdata []byte
+	var sldata []byte
+	var tcdata []byte
+	var tbdata []byte
 	var name sql.NullString
 	err := s.db.QueryRowContext(ctxTimeout, "SELECT * FROM provider_settings WHERE $1 = id", id).Scan(
 		&setting.Id,
@@ -66,6 +74,18 @@ func (s *Store) GetProviderSetting(id string, withSecret bool) (*provider.Settin
 		&name,
 		pq.Array(&setting.AllowedModels),
 		&cmdata,
+		&setting.Namespace,
+		&dpdata,
+		&kpdata,
+		&lbdata,
+		&setting.Environment,
+		&hddata,
+		&qpdata,
+		&sldata,
+		&setting.Disabled,
+		&setting.DisabledReason,
+		&tcdata,
+		&tbdata,
 	)
 
 	if err != nil {
@@ -86,10 +106,54 @@ func (s *Store) GetProviderSetting(id string, withSecret bool) (*provider.Settin
 		return nil, err
 	}
 
+	if err := json.Unmarshal(dpdata, &setting.Deployments); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(lbdata, &setting.Labels); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(hddata, &setting.Headers); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(qpdata, &setting.QueryParams); err != nil {
+		return nil, err
+	}
+
+	sl, err := unmarshalSpendLimit(sldata)
+	if err != nil {
+		return nil, err
+	}
+
+	setting.SpendLimit = sl
+
+	tc, err := unmarshalTransportConfig(tcdata)
+	if err != nil {
+		return nil, err
+	}
+
+	setting.Transport = tc
+
+	tb, err := unmarshalTokenBudget(tbdata)
+	if err != nil {
+		return nil, err
+	}
+
+	setting.TokenBudget = tb
+
+	kp, err := unmarshalKeyPool(kpdata, withSecret)
+	if err != nil {
+		return nil, err
+	}
+
 	if !withSecret {
 		delete(m, "apikey")
 	}
 
+	setting.KeyPool = kp
+
 	setting.Setting = m
 	setting.CostMap = cm
 
@@ -113,6 +177,14 @@ func (s *Store) GetUpdatedProviderSettings(updatedAt int64) ([]*provider.Setting
 		setting := &provider.Setting{}
 		var data []byte
 		var cmdata []byte
+		var dpdata []byte
+		var kpdata []byte
+		var lbdata []byte
+		var hddata []byte
+		var qpdata []byte
+		var sldata []byte
+		var tcdata []byte
+		var tbdata []byte
 		var name sql.NullString
 		if err := rows.Scan(
 			&setting.Id,
@@ -123,6 +195,18 @@ func (s *Store) GetUpdatedProviderSettings(updatedAt int64) ([]*provider.Setting
 			&name,
 			pq.Array(&setting.AllowedModels),
 			&cmdata,
+			&setting.Namespace,
+			&dpdata,
+			&kpdata,
+			&lbdata,
+			&setting.Environment,
+			&hddata,
+			&qpdata,
+			&sldata,
+			&setting.Disabled,
+			&setting.DisabledReason,
+			&tcdata,
+			&tbdata,
 		); err != nil {
 			return nil, err
 		}
@@ -137,6 +221,49 @@ func (s *Store) GetUpdatedProviderSettings(updatedAt int64) ([]*provider.Setting
 			return nil, err
 		}
 
+		if err := json.Unmarshal(dpdata, &setting.Deployments); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(lbdata, &setting.Labels); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(hddata, &setting.Headers); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(qpdata, &setting.QueryParams); err != nil {
+			return nil, err
+		}
+
+		sl, err := unmarshalSpendLimit(sldata)
+		if err != nil {
+			return nil, err
+		}
+
+		setting.SpendLimit = sl
+
+		tc, err := unmarshalTransportConfig(tcdata)
+		if err != nil {
+			return nil, err
+		}
+
+		setting.Transport = tc
+
+		tb, err := unmarshalTokenBudget(tbdata)
+		if err != nil {
+			return nil, err
+		}
+
+		setting.TokenBudget = tb
+
+		kp, err := unmarshalKeyPool(kpdata, true)
+		if err != nil {
+			return nil, err
+		}
+
+		setting.KeyPool = kp
 		setting.Setting = m
 		setting.CostMap = cm
 		setting.Name = name.String
@@ -186,15 +313,129 @@ func (s *Store) UpdateProviderSetting(id string, setting *provider.UpdateSetting
 
 		values = append(values, data)
 		fields = append(fields, fmt.Sprintf("cost_map = $%d", d))
+		d++
+	}
+
+	if setting.Deployments != nil {
+		data, err := json.Marshal(*setting.Deployments)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("deployments = $%d", d))
+		d++
+	}
+
+	if setting.KeyPool != nil {
+		data, err := json.Marshal(setting.KeyPool)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("key_pool = $%d", d))
+		d++
+	}
+
+	if setting.Labels != nil {
+		data, err := json.Marshal(*setting.Labels)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("labels = $%d", d))
+		d++
+	}
+
+	if setting.Environment != nil {
+		values = append(values, *setting.Environment)
+		fields = append(fields, fmt.Sprintf("environment = $%d", d))
+		d++
+	}
+
+	if setting.Headers != nil {
+		data, err := json.Marshal(*setting.Headers)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("headers = $%d", d))
+		d++
+	}
+
+	if setting.QueryParams != nil {
+		data, err := json.Marshal(*setting.QueryParams)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("query_params = $%d", d))
+		d++
+	}
+
+	if setting.SpendLimit != nil {
+		data, err := marshalSpendLimit(setting.SpendLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("spend_limit = $%d", d))
+		d++
+	}
+
+	if setting.Transport != nil {
+		data, err := marshalTransportConfig(setting.Transport)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("transport_config = $%d", d))
+		d++
+	}
+
+	if setting.TokenBudget != nil {
+		data, err := marshalTokenBudget(setting.TokenBudget)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, data)
+		fields = append(fields, fmt.Sprintf("token_budget = $%d", d))
+		d++
 	}
 
-	query := fmt.Sprintf("UPDATE provider_settings SET %s WHERE id = $1 RETURNING id, created_at, updated_at, provider, name, allowed_models, setting, cost_map;", strings.Join(fields, ","))
+	if setting.Disabled != nil {
+		values = append(values, *setting.Disabled)
+		fields = append(fields, fmt.Sprintf("disabled = $%d", d))
+		d++
+	}
+
+	if setting.DisabledReason != nil {
+		values = append(values, *setting.DisabledReason)
+		fields = append(fields, fmt.Sprintf("disabled_reason = $%d", d))
+	}
+
+	query := fmt.Sprintf("UPDATE provider_settings SET %s WHERE id = $1 RETURNING id, created_at, updated_at, provider, name, allowed_models, setting, cost_map, namespace, deployments, key_pool, labels, environment, headers, query_params, spend_limit, disabled, disabled_reason, transport_config, token_budget;", strings.Join(fields, ","))
 	updated := &provider.Setting{}
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
 	defer cancel()
 
 	var rawd []byte
 	var cmdata []byte
+	var dpdata []byte
+	var kpdata []byte
+	var lbdata []byte
+	var hddata []byte
+	var qpdata []byte
+	var sldata []byte
+	var tcdata []byte
+	var tbdata []byte
 
 	row := s.db.QueryRowContext(ctxTimeout, query, values...)
 	if err := row.Scan(
@@ -206,6 +447,18 @@ func (s *Store) UpdateProviderSetting(id string, setting *provider.UpdateSetting
 		pq.Array(&updated.AllowedModels),
 		&rawd,
 		&cmdata,
+		&updated.Namespace,
+		&dpdata,
+		&kpdata,
+		&lbdata,
+		&updated.Environment,
+		&hddata,
+		&qpdata,
+		&sldata,
+		&updated.Disabled,
+		&updated.DisabledReason,
+		&tcdata,
+		&tbdata,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, internal_errors.NewNotFoundError("provider setting is not found for: " + id)
@@ -224,6 +477,50 @@ func (s *Store) UpdateProviderSetting(id string, setting *provider.UpdateSetting
 		return nil, err
 	}
 
+	if err := json.Unmarshal(dpdata, &updated.Deployments); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(lbdata, &updated.Labels); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(hddata, &updated.Headers); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(qpdata, &updated.QueryParams); err != nil {
+		return nil, err
+	}
+
+	sl, err := unmarshalSpendLimit(sldata)
+	if err != nil {
+		return nil, err
+	}
+
+	updated.SpendLimit = sl
+
+	tc, err := unmarshalTransportConfig(tcdata)
+	if err != nil {
+		return nil, err
+	}
+
+	updated.Transport = tc
+
+	tb, err := unmarshalTokenBudget(tbdata)
+	if err != nil {
+		return nil, err
+	}
+
+	updated.TokenBudget = tb
+
+	kp, err := unmarshalKeyPool(kpdata, false)
+	if err != nil {
+		return nil, err
+	}
+
+	updated.KeyPool = kp
+
 	delete(m, "apikey")
 
 	updated.Setting = m
@@ -255,9 +552,9 @@ func (s *Store) CreateProviderSetting(setting *provider.Setting) (*provider.Sett
 	}
 
 	query := `
-		INSERT INTO provider_settings (id, created_at, updated_at, provider, setting, name, allowed_models, cost_map)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id, created_at, updated_at, provider, name, allowed_models, setting, cost_map
+		INSERT INTO provider_settings (id, created_at, updated_at, provider, setting, name, allowed_models, cost_map, namespace, deployments, key_pool, labels, environment, headers, query_params, spend_limit, transport_config, token_budget)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		RETURNING id, created_at, updated_at, provider, name, allowed_models, setting, cost_map, namespace, deployments, key_pool, labels, environment, headers, query_params, spend_limit, transport_config, token_budget
 	`
 
 	data, err := json.Marshal(setting.Setting)
@@ -270,6 +567,69 @@ func (s *Store) CreateProviderSetting(setting *provider.Setting) (*provider.Sett
 		return nil, err
 	}
 
+	deployments := setting.Deployments
+	if deployments == nil {
+		deployments = []*provider.Deployment{}
+	}
+
+	dpd, err := json.Marshal(deployments)
+	if err != nil {
+		return nil, err
+	}
+
+	var kpd []byte
+	if setting.KeyPool != nil {
+		kpd, err = json.Marshal(setting.KeyPool)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	labels := setting.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	lbd, err := json.Marshal(labels)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := setting.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+
+	hdd, err := json.Marshal(headers)
+	if err != nil {
+		return nil, err
+	}
+
+	queryParams := setting.QueryParams
+	if queryParams == nil {
+		queryParams = map[string]string{}
+	}
+
+	qpd, err := json.Marshal(queryParams)
+	if err != nil {
+		return nil, err
+	}
+
+	sld, err := marshalSpendLimit(setting.SpendLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	tcd, err := marshalTransportConfig(setting.Transport)
+	if err != nil {
+		return nil, err
+	}
+
+	tbd, err := marshalTokenBudget(setting.TokenBudget)
+	if err != nil {
+		return nil, err
+	}
+
 	values := []any{
 		setting.Id,
 		setting.CreatedAt,
@@ -279,10 +639,28 @@ func (s *Store) CreateProviderSetting(setting *provider.Setting) (*provider.Sett
 		setting.Name,
 		sliceToSqlStringArray(setting.AllowedModels),
 		cmd,
+		setting.Namespace,
+		dpd,
+		kpd,
+		lbd,
+		setting.Environment,
+		hdd,
+		qpd,
+		sld,
+		tcd,
+		tbd,
 	}
 
 	var rawd []byte
 	var rawcmd []byte
+	var rawdpd []byte
+	var rawkpd []byte
+	var rawlbd []byte
+	var rawhdd []byte
+	var rawqpd []byte
+	var rawsld []byte
+	var rawtcd []byte
+	var rawtbd []byte
 
 	created := &provider.Setting{}
 	var name sql.NullString
@@ -295,6 +673,16 @@ func (s *Store) CreateProviderSetting(setting *provider.Setting) (*provider.Sett
 		pq.Array(&created.AllowedModels),
 		&rawd,
 		&rawcmd,
+		&created.Namespace,
+		&rawdpd,
+		&rawkpd,
+		&rawlbd,
+		&created.Environment,
+		&rawhdd,
+		&rawqpd,
+		&rawsld,
+		&rawtcd,
+		&rawtbd,
 	); err != nil {
 		return nil, err
 	}
@@ -309,6 +697,50 @@ func (s *Store) CreateProviderSetting(setting *provider.Setting) (*provider.Sett
 		return nil, err
 	}
 
+	if err := json.Unmarshal(rawdpd, &created.Deployments); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(rawlbd, &created.Labels); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(rawhdd, &created.Headers); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(rawqpd, &created.QueryParams); err != nil {
+		return nil, err
+	}
+
+	sl, err := unmarshalSpendLimit(rawsld)
+	if err != nil {
+		return nil, err
+	}
+
+	created.SpendLimit = sl
+
+	tc, err := unmarshalTransportConfig(rawtcd)
+	if err != nil {
+		return nil, err
+	}
+
+	created.Transport = tc
+
+	tb, err := unmarshalTokenBudget(rawtbd)
+	if err != nil {
+		return nil, err
+	}
+
+	created.TokenBudget = tb
+
+	kp, err := unmarshalKeyPool(rawkpd, false)
+	if err != nil {
+		return nil, err
+	}
+
+	created.KeyPool = kp
+
 	delete(m, "apikey")
 
 	created.Setting = m
@@ -342,6 +774,14 @@ func (s *Store) GetProviderSettings(withSecret bool, ids []string) ([]*provider.
 		setting := &provider.Setting{}
 		var data []byte
 		var cmdata []byte
+		var dpdata []byte
+		var kpdata []byte
+		var lbdata []byte
+		var hddata []byte
+		var qpdata []byte
+		var sldata []byte
+		var tcdata []byte
+		var tbdata []byte
 
 		var name sql.NullString
 		if err := rows.Scan(
@@ -353,6 +793,18 @@ func (s *Store) GetProviderSettings(withSecret bool, ids []string) ([]*provider.
 			&name,
 			pq.Array(&setting.AllowedModels),
 			&cmdata,
+			&setting.Namespace,
+			&dpdata,
+			&kpdata,
+			&lbdata,
+			&setting.Environment,
+			&hddata,
+			&qpdata,
+			&sldata,
+			&setting.Disabled,
+			&setting.DisabledReason,
+			&tcdata,
+			&tbdata,
 		); err != nil {
 			return nil, err
 		}
@@ -367,10 +819,54 @@ func (s *Store) GetProviderSettings(withSecret bool, ids []string) ([]*provider.
 			return nil, err
 		}
 
+		if err := json.Unmarshal(dpdata, &setting.Deployments); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(lbdata, &setting.Labels); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(hddata, &setting.Headers); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(qpdata, &setting.QueryParams); err != nil {
+			return nil, err
+		}
+
+		sl, err := unmarshalSpendLimit(sldata)
+		if err != nil {
+			return nil, err
+		}
+
+		setting.SpendLimit = sl
+
+		tc, err := unmarshalTransportConfig(tcdata)
+		if err != nil {
+			return nil, err
+		}
+
+		setting.Transport = tc
+
+		tb, err := unmarshalTokenBudget(tbdata)
+		if err != nil {
+			return nil, err
+		}
+
+		setting.TokenBudget = tb
+
+		kp, err := unmarshalKeyPool(kpdata, withSecret)
+		if err != nil {
+			return nil, err
+		}
+
 		if !withSecret {
 			delete(m, "apikey")
 		}
 
+		setting.KeyPool = kp
+
 		setting.Setting = m
 		setting.CostMap = cm
 
@@ -384,3 +880,213 @@ func (s *Store) GetProviderSettings(withSecret bool, ids []string) ([]*provider.
 
 	return settings, nil
 }
+
+// unmarshalKeyPool reads the key pool column, which is null for settings
+// without a pool.
+func unmarshalKeyPool(data []byte, withSecret bool) (*provider.KeyPool, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	kp := &provider.KeyPool{}
+	if err := json.Unmarshal(data, kp); err != nil {
+		return nil, err
+	}
+
+	if !withSecret {
+		kp.RemoveSecrets()
+	}
+
+	return kp, nil
+}
+
+// marshalSpendLimit returns nil for a spend limit without a limit, which
+// stores null so that the setting is no longer limited.
+func marshalSpendLimit(sl *provider.SpendLimit) (any, error) {
+	if sl == nil || sl.MonthlyCostLimitInUsd <= 0 {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(sl)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func unmarshalSpendLimit(data []byte) (*provider.SpendLimit, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	sl := &provider.SpendLimit{}
+	if err := json.Unmarshal(data, sl); err != nil {
+		return nil, err
+	}
+
+	return sl, nil
+}
+
+// marshalTransportConfig returns nil for a transport config that is not set,
+// which stores null so that the setting goes back to the default transport.
+func marshalTransportConfig(tc *provider.TransportConfig) (any, error) {
+	if !tc.Set() {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(tc)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func unmarshalTransportConfig(data []byte) (*provider.TransportConfig, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	tc := &provider.TransportConfig{}
+	if err := json.Unmarshal(data, tc); err != nil {
+		return nil, err
+	}
+
+	return tc, nil
+}
+
+// marshalTokenBudget returns nil for a token budget that is not set, which
+// stores null so that requests with the setting are no longer paced.
+func marshalTokenBudget(tb *provider.TokenBudget) (any, error) {
+	if !tb.Set() {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(tb)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func unmarshalTokenBudget(data []byte) (*provider.TokenBudget, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	tb := &provider.TokenBudget{}
+	if err := json.Unmarshal(data, tb); err != nil {
+		return nil, err
+	}
+
+	return tb, nil
+}
+
+// DisableProviderSetting disables a provider setting that is still enabled,
+// and reports whether it did so that gateways sharing a database act on the
+// disabling once. The update time is kept since secrets are encrypted under it.
+func (s *Store) DisableProviderSetting(id, reason string) (bool, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, "UPDATE provider_settings SET disabled = TRUE, disabled_reason = $2 WHERE id = $1 AND disabled = FALSE", id, reason)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (s *Store) DeleteProviderSetting(id string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctxTimeout, "DELETE FROM provider_settings WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return internal_errors.NewNotFoundError("provider setting is not found for: " + id)
+	}
+
+	return nil
+}
+
+// DeleteProviderSettingWithKeys deletes a provider setting with its versions,
+// together with the keys of keyIds, in one transaction. It returns the hashes
+// of the deleted keys so that their cache entries can be invalidated.
+func (s *Store) DeleteProviderSettingWithKeys(id string, keyIds []string) ([]string, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctxTimeout, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	hashes, err := deleteKeysTx(ctxTimeout, tx, keyIds)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := tx.ExecContext(ctxTimeout, "DELETE FROM provider_settings WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if affected == 0 {
+		return nil, internal_errors.NewNotFoundError("provider setting is not found for: " + id)
+	}
+
+	if _, err := tx.ExecContext(ctxTimeout, "DELETE FROM provider_setting_versions WHERE setting_id = $1", id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+func (s *Store) GetProviderSettingIdsByProvider(name string) ([]string, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, "SELECT id FROM provider_settings WHERE provider = $1", name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
 	"github.com/bricks-cloud/bricksllm/internal/route"
@@ -35,7 +36,7 @@ func (s *Store) CreateRoutesTable() error {
 
 func (s *Store) AlterRoutesTable() error {
 	alterTableQuery := `
-		ALTER TABLE routes ADD COLUMN IF NOT EXISTS request_format VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS retry_strategy VARCHAR(255) NOT NULL DEFAULT '';
+		ALTER TABLE routes ADD COLUMN IF NOT EXISTS request_format VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS retry_strategy VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS strategy VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS strategy_config JSONB, ADD COLUMN IF NOT EXISTS namespace VARCHAR(255) NOT NULL DEFAULT '', ADD COLUMN IF NOT EXISTS snippet_config JSONB, ADD COLUMN IF NOT EXISTS circuit_breaker_config JSONB, ADD COLUMN IF NOT EXISTS retry_policy JSONB, ADD COLUMN IF NOT EXISTS split_config JSONB, ADD COLUMN IF NOT EXISTS shadow_config JSONB, ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1, ADD COLUMN IF NOT EXISTS transport_config JSONB, ADD COLUMN IF NOT EXISTS sticky_config JSONB, ADD COLUMN IF NOT EXISTS hedge_config JSONB, ADD COLUMN IF NOT EXISTS queue_config JSONB, ADD COLUMN IF NOT EXISTS rate_limit_config JSONB, ADD COLUMN IF NOT EXISTS region_config JSONB, ADD COLUMN IF NOT EXISTS transform_config JSONB, ADD COLUMN IF NOT EXISTS policy_versions JSONB, ADD COLUMN IF NOT EXISTS policy_id VARCHAR(255) NOT NULL DEFAULT '';
 	`
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
@@ -62,6 +63,40 @@ func (s *Store) DeleteRoute(id string) error {
 	return nil
 }
 
+// deleteRoutesTx deletes the routes of ids and their versions within tx and
+// returns the paths of the deleted routes.
+func deleteRoutesTx(ctx context.Context, tx *sql.Tx, ids []string) ([]string, error) {
+	paths := []string{}
+	if len(ids) == 0 {
+		return paths, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, "DELETE FROM routes WHERE id = ANY($1) RETURNING path", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+
+		paths = append(paths, path)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM route_versions WHERE route_id = ANY($1)", pq.Array(ids)); err != nil {
+		return nil, err
+	}
+
+	return paths, nil
+}
+
 func (s *Store) CreateRoute(r *route.Route) (*route.Route, error) {
 	sbytes, err := json.Marshal(r.Steps)
 	if err != nil {
@@ -73,6 +108,76 @@ func (s *Store) CreateRoute(r *route.Route) (*route.Route, error) {
 		return nil, err
 	}
 
+	scbytes, err := json.Marshal(r.StrategyConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	snbytes, err := json.Marshal(r.SnippetConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	cbbytes, err := json.Marshal(r.CircuitBreaker)
+	if err != nil {
+		return nil, err
+	}
+
+	rpbytes, err := json.Marshal(r.RetryPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	spbytes, err := json.Marshal(r.Split)
+	if err != nil {
+		return nil, err
+	}
+
+	shbytes, err := json.Marshal(r.Shadow)
+	if err != nil {
+		return nil, err
+	}
+
+	tcbytes, err := json.Marshal(r.Transport)
+	if err != nil {
+		return nil, err
+	}
+
+	stbytes, err := json.Marshal(r.Sticky)
+	if err != nil {
+		return nil, err
+	}
+
+	hdbytes, err := json.Marshal(r.Hedge)
+	if err != nil {
+		return nil, err
+	}
+
+	qcbytes, err := json.Marshal(r.Queue)
+	if err != nil {
+		return nil, err
+	}
+
+	rlbytes, err := json.Marshal(r.RateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	rgbytes, err := json.Marshal(r.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	tfbytes, err := json.Marshal(r.Transform)
+	if err != nil {
+		return nil, err
+	}
+
+	pvbytes, err := json.Marshal(r.PolicyVersions)
+	if err != nil {
+		return nil, err
+	}
+
 	values := []any{
 		r.Id,
 		r.CreatedAt,
@@ -84,12 +189,30 @@ func (s *Store) CreateRoute(r *route.Route) (*route.Route, error) {
 		cbytes,
 		r.RequestFormat,
 		r.RetryStrategy,
+		r.Strategy,
+		scbytes,
+		r.Namespace,
+		snbytes,
+		cbbytes,
+		rpbytes,
+		spbytes,
+		shbytes,
+		r.Version,
+		tcbytes,
+		stbytes,
+		hdbytes,
+		qcbytes,
+		rlbytes,
+		rgbytes,
+		tfbytes,
+		pvbytes,
+		r.PolicyId,
 	}
 
 	query := `
-	INSERT INTO routes (id, created_at, updated_at, name, path, key_ids, steps, cache_config, request_format, retry_strategy)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-	RETURNING id, created_at, updated_at, name, path, key_ids, steps, cache_config, request_format, retry_strategy
+	INSERT INTO routes (id, created_at, updated_at, name, path, key_ids, steps, cache_config, request_format, retry_strategy, strategy, strategy_config, namespace, snippet_config, circuit_breaker_config, retry_policy, split_config, shadow_config, version, transport_config, sticky_config, hedge_config, queue_config, rate_limit_config, region_config, transform_config, policy_versions, policy_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28)
+	RETURNING id, created_at, updated_at, name, path, key_ids, steps, cache_config, request_format, retry_strategy, strategy, strategy_config, namespace, snippet_config, circuit_breaker_config, retry_policy, split_config, shadow_config, version, transport_config, sticky_config, hedge_config, queue_config, rate_limit_config, region_config, transform_config, policy_versions, policy_id
 `
 
 	created := &route.Route{}
@@ -99,6 +222,20 @@ func (s *Store) CreateRoute(r *route.Route) (*route.Route, error) {
 
 	var cdata []byte
 	var sdata []byte
+	var scdata []byte
+	var sndata []byte
+	var cbdata []byte
+	var rpdata []byte
+	var spdata []byte
+	var shdata []byte
+	var tcdata []byte
+	var stdata []byte
+	var hddata []byte
+	var qcdata []byte
+	var rldata []byte
+	var rgdata []byte
+	var tfdata []byte
+	var pvdata []byte
 
 	if err := s.db.QueryRowContext(ctxTimeout, query, values...).Scan(
 		&created.Id,
@@ -111,6 +248,24 @@ func (s *Store) CreateRoute(r *route.Route) (*route.Route, error) {
 		&cdata,
 		&created.RequestFormat,
 		&created.RetryStrategy,
+		&created.Strategy,
+		&scdata,
+		&created.Namespace,
+		&sndata,
+		&cbdata,
+		&rpdata,
+		&spdata,
+		&shdata,
+		&created.Version,
+		&tcdata,
+		&stdata,
+		&hddata,
+		&qcdata,
+		&rldata,
+		&rgdata,
+		&tfdata,
+		&pvdata,
+		&created.PolicyId,
 	); err != nil {
 		return nil, err
 	}
@@ -123,15 +278,117 @@ func (s *Store) CreateRoute(r *route.Route) (*route.Route, error) {
 		return nil, err
 	}
 
+	if len(scdata) != 0 {
+		if err := json.Unmarshal(scdata, &created.StrategyConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(sndata) != 0 {
+		if err := json.Unmarshal(sndata, &created.SnippetConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cbdata) != 0 {
+		if err := json.Unmarshal(cbdata, &created.CircuitBreaker); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rpdata) != 0 {
+		if err := json.Unmarshal(rpdata, &created.RetryPolicy); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(spdata) != 0 {
+		if err := json.Unmarshal(spdata, &created.Split); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(shdata) != 0 {
+		if err := json.Unmarshal(shdata, &created.Shadow); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(tcdata) != 0 {
+		if err := json.Unmarshal(tcdata, &created.Transport); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(stdata) != 0 {
+		if err := json.Unmarshal(stdata, &created.Sticky); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(hddata) != 0 {
+		if err := json.Unmarshal(hddata, &created.Hedge); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(qcdata) != 0 {
+		if err := json.Unmarshal(qcdata, &created.Queue); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rldata) != 0 {
+		if err := json.Unmarshal(rldata, &created.RateLimit); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rgdata) != 0 {
+		if err := json.Unmarshal(rgdata, &created.Region); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(tfdata) != 0 {
+		if err := json.Unmarshal(tfdata, &created.Transform); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(pvdata) != 0 {
+		if err := json.Unmarshal(pvdata, &created.PolicyVersions); err != nil {
+			return nil, err
+		}
+	}
+
 	return created, nil
 }
 
+func (s *Store) GetRouteIdsByPolicyId(policyId string) ([]string, error) {
+	return s.getKeyIds("SELECT id FROM routes WHERE policy_id = $1", policyId)
+}
+
 func (s *Store) GetRoute(id string) (*route.Route, error) {
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
 	defer cancel()
 
 	var cdata []byte
 	var sdata []byte
+	var scdata []byte
+	var sndata []byte
+	var cbdata []byte
+	var rpdata []byte
+	var spdata []byte
+	var shdata []byte
+	var tcdata []byte
+	var stdata []byte
+	var hddata []byte
+	var qcdata []byte
+	var rldata []byte
+	var rgdata []byte
+	var tfdata []byte
+	var pvdata []byte
 
 	created := &route.Route{}
 	if err := s.db.QueryRowContext(ctxTimeout, "SELECT * FROM routes WHERE $1 = id", id).Scan(
@@ -145,6 +402,24 @@ func (s *Store) GetRoute(id string) (*route.Route, error) {
 		&cdata,
 		&created.RequestFormat,
 		&created.RetryStrategy,
+		&created.Strategy,
+		&scdata,
+		&created.Namespace,
+		&sndata,
+		&cbdata,
+		&rpdata,
+		&spdata,
+		&shdata,
+		&created.Version,
+		&tcdata,
+		&stdata,
+		&hddata,
+		&qcdata,
+		&rldata,
+		&rgdata,
+		&tfdata,
+		&pvdata,
+		&created.PolicyId,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, internal_errors.NewNotFoundError("custom provider is not found")
@@ -161,6 +436,90 @@ func (s *Store) GetRoute(id string) (*route.Route, error) {
 		return nil, err
 	}
 
+	if len(scdata) != 0 {
+		if err := json.Unmarshal(scdata, &created.StrategyConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(sndata) != 0 {
+		if err := json.Unmarshal(sndata, &created.SnippetConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cbdata) != 0 {
+		if err := json.Unmarshal(cbdata, &created.CircuitBreaker); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rpdata) != 0 {
+		if err := json.Unmarshal(rpdata, &created.RetryPolicy); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(spdata) != 0 {
+		if err := json.Unmarshal(spdata, &created.Split); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(shdata) != 0 {
+		if err := json.Unmarshal(shdata, &created.Shadow); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(tcdata) != 0 {
+		if err := json.Unmarshal(tcdata, &created.Transport); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(stdata) != 0 {
+		if err := json.Unmarshal(stdata, &created.Sticky); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(hddata) != 0 {
+		if err := json.Unmarshal(hddata, &created.Hedge); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(qcdata) != 0 {
+		if err := json.Unmarshal(qcdata, &created.Queue); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rldata) != 0 {
+		if err := json.Unmarshal(rldata, &created.RateLimit); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rgdata) != 0 {
+		if err := json.Unmarshal(rgdata, &created.Region); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(tfdata) != 0 {
+		if err := json.Unmarshal(tfdata, &created.Transform); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(pvdata) != 0 {
+		if err := json.Unmarshal(pvdata, &created.PolicyVersions); err != nil {
+			return nil, err
+		}
+	}
+
 	return created, nil
 }
 
@@ -170,6 +529,20 @@ func (s *Store) GetRouteByPath(path string) (*route.Route, error) {
 
 	var cdata []byte
 	var sdata []byte
+	var scdata []byte
+	var sndata []byte
+	var cbdata []byte
+	var rpdata []byte
+	var spdata []byte
+	var shdata []byte
+	var tcdata []byte
+	var stdata []byte
+	var hddata []byte
+	var qcdata []byte
+	var rldata []byte
+	var rgdata []byte
+	var tfdata []byte
+	var pvdata []byte
 
 	created := &route.Route{}
 	if err := s.db.QueryRowContext(ctxTimeout, "SELECT * FROM routes WHERE $1 = path", path).Scan(
@@ -183,6 +556,24 @@ func (s *Store) GetRouteByPath(path string) (*route.Route, error) {
 		&cdata,
 		&created.RequestFormat,
 		&created.RetryStrategy,
+		&created.Strategy,
+		&scdata,
+		&created.Namespace,
+		&sndata,
+		&cbdata,
+		&rpdata,
+		&spdata,
+		&shdata,
+		&created.Version,
+		&tcdata,
+		&stdata,
+		&hddata,
+		&qcdata,
+		&rldata,
+		&rgdata,
+		&tfdata,
+		&pvdata,
+		&created.PolicyId,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, internal_errors.NewNotFoundError("route is not found")
@@ -199,6 +590,90 @@ func (s *Store) GetRouteByPath(path string) (*route.Route, error) {
 		return nil, err
 	}
 
+	if len(scdata) != 0 {
+		if err := json.Unmarshal(scdata, &created.StrategyConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(sndata) != 0 {
+		if err := json.Unmarshal(sndata, &created.SnippetConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cbdata) != 0 {
+		if err := json.Unmarshal(cbdata, &created.CircuitBreaker); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rpdata) != 0 {
+		if err := json.Unmarshal(rpdata, &created.RetryPolicy); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(spdata) != 0 {
+		if err := json.Unmarshal(spdata, &created.Split); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(shdata) != 0 {
+		if err := json.Unmarshal(shdata, &created.Shadow); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(tcdata) != 0 {
+		if err := json.Unmarshal(tcdata, &created.Transport); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(stdata) != 0 {
+		if err := json.Unmarshal(stdata, &created.Sticky); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(hddata) != 0 {
+		if err := json.Unmarshal(hddata, &created.Hedge); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(qcdata) != 0 {
+		if err := json.Unmarshal(qcdata, &created.Queue); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rldata) != 0 {
+		if err := json.Unmarshal(rldata, &created.RateLimit); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rgdata) != 0 {
+		if err := json.Unmarshal(rgdata, &created.Region); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(tfdata) != 0 {
+		if err := json.Unmarshal(tfdata, &created.Transform); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(pvdata) != 0 {
+		if err := json.Unmarshal(pvdata, &created.PolicyVersions); err != nil {
+			return nil, err
+		}
+	}
+
 	return created, nil
 }
 
@@ -217,6 +692,20 @@ func (s *Store) GetUpdatedRoutes(updatedAt int64) ([]*route.Route, error) {
 		r := &route.Route{}
 		var cdata []byte
 		var sdata []byte
+		var scdata []byte
+		var sndata []byte
+		var cbdata []byte
+		var rpdata []byte
+		var spdata []byte
+		var shdata []byte
+		var tcdata []byte
+		var stdata []byte
+		var hddata []byte
+		var qcdata []byte
+		var rldata []byte
+		var rgdata []byte
+		var tfdata []byte
+		var pvdata []byte
 
 		if err := rows.Scan(
 			&r.Id,
@@ -229,6 +718,24 @@ func (s *Store) GetUpdatedRoutes(updatedAt int64) ([]*route.Route, error) {
 			&cdata,
 			&r.RequestFormat,
 			&r.RetryStrategy,
+			&r.Strategy,
+			&scdata,
+			&r.Namespace,
+			&sndata,
+			&cbdata,
+			&rpdata,
+			&spdata,
+			&shdata,
+			&r.Version,
+			&tcdata,
+			&stdata,
+			&hddata,
+			&qcdata,
+			&rldata,
+			&rgdata,
+			&tfdata,
+			&pvdata,
+			&r.PolicyId,
 		); err != nil {
 			return nil, err
 		}
@@ -241,6 +748,90 @@ func (s *Store) GetUpdatedRoutes(updatedAt int64) ([]*route.Route, error) {
 			return nil, err
 		}
 
+		if len(scdata) != 0 {
+			if err := json.Unmarshal(scdata, &r.StrategyConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(sndata) != 0 {
+			if err := json.Unmarshal(sndata, &r.SnippetConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(cbdata) != 0 {
+			if err := json.Unmarshal(cbdata, &r.CircuitBreaker); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(rpdata) != 0 {
+			if err := json.Unmarshal(rpdata, &r.RetryPolicy); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(spdata) != 0 {
+			if err := json.Unmarshal(spdata, &r.Split); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(shdata) != 0 {
+			if err := json.Unmarshal(shdata, &r.Shadow); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(tcdata) != 0 {
+			if err := json.Unmarshal(tcdata, &r.Transport); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(stdata) != 0 {
+			if err := json.Unmarshal(stdata, &r.Sticky); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(hddata) != 0 {
+			if err := json.Unmarshal(hddata, &r.Hedge); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(qcdata) != 0 {
+			if err := json.Unmarshal(qcdata, &r.Queue); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(rldata) != 0 {
+			if err := json.Unmarshal(rldata, &r.RateLimit); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(rgdata) != 0 {
+			if err := json.Unmarshal(rgdata, &r.Region); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(tfdata) != 0 {
+			if err := json.Unmarshal(tfdata, &r.Transform); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(pvdata) != 0 {
+			if err := json.Unmarshal(pvdata, &r.PolicyVersions); err != nil {
+				return nil, err
+			}
+		}
+
 		routes = append(routes, r)
 	}
 
@@ -262,6 +853,20 @@ func (s *Store) GetRoutes() ([]*route.Route, error) {
 		r := &route.Route{}
 		var cdata []byte
 		var sdata []byte
+		var scdata []byte
+		var sndata []byte
+		var cbdata []byte
+		var rpdata []byte
+		var spdata []byte
+		var shdata []byte
+		var tcdata []byte
+		var stdata []byte
+		var hddata []byte
+		var qcdata []byte
+		var rldata []byte
+		var rgdata []byte
+		var tfdata []byte
+		var pvdata []byte
 
 		if err := rows.Scan(
 			&r.Id,
@@ -274,6 +879,24 @@ func (s *Store) GetRoutes() ([]*route.Route, error) {
 			&cdata,
 			&r.RequestFormat,
 			&r.RetryStrategy,
+			&r.Strategy,
+			&scdata,
+			&r.Namespace,
+			&sndata,
+			&cbdata,
+			&rpdata,
+			&spdata,
+			&shdata,
+			&r.Version,
+			&tcdata,
+			&stdata,
+			&hddata,
+			&qcdata,
+			&rldata,
+			&rgdata,
+			&tfdata,
+			&pvdata,
+			&r.PolicyId,
 		); err != nil {
 			return nil, err
 		}
@@ -286,8 +909,233 @@ func (s *Store) GetRoutes() ([]*route.Route, error) {
 			return nil, err
 		}
 
+		if len(scdata) != 0 {
+			if err := json.Unmarshal(scdata, &r.StrategyConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(sndata) != 0 {
+			if err := json.Unmarshal(sndata, &r.SnippetConfig); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(cbdata) != 0 {
+			if err := json.Unmarshal(cbdata, &r.CircuitBreaker); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(rpdata) != 0 {
+			if err := json.Unmarshal(rpdata, &r.RetryPolicy); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(spdata) != 0 {
+			if err := json.Unmarshal(spdata, &r.Split); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(shdata) != 0 {
+			if err := json.Unmarshal(shdata, &r.Shadow); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(tcdata) != 0 {
+			if err := json.Unmarshal(tcdata, &r.Transport); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(stdata) != 0 {
+			if err := json.Unmarshal(stdata, &r.Sticky); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(hddata) != 0 {
+			if err := json.Unmarshal(hddata, &r.Hedge); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(qcdata) != 0 {
+			if err := json.Unmarshal(qcdata, &r.Queue); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(rldata) != 0 {
+			if err := json.Unmarshal(rldata, &r.RateLimit); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(rgdata) != 0 {
+			if err := json.Unmarshal(rgdata, &r.Region); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(tfdata) != 0 {
+			if err := json.Unmarshal(tfdata, &r.Transform); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(pvdata) != 0 {
+			if err := json.Unmarshal(pvdata, &r.PolicyVersions); err != nil {
+				return nil, err
+			}
+		}
+
 		routes = append(routes, r)
 	}
 
 	return routes, nil
 }
+
+// UpdateRoute writes the mutable fields of r and increments its version,
+// provided that it is still at version.
+func (s *Store) UpdateRoute(r *route.Route, version int) (*route.Route, error) {
+	sbytes, err := json.Marshal(r.Steps)
+	if err != nil {
+		return nil, err
+	}
+
+	cbytes, err := json.Marshal(r.CacheConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	scbytes, err := json.Marshal(r.StrategyConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	snbytes, err := json.Marshal(r.SnippetConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	cbbytes, err := json.Marshal(r.CircuitBreaker)
+	if err != nil {
+		return nil, err
+	}
+
+	rpbytes, err := json.Marshal(r.RetryPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	spbytes, err := json.Marshal(r.Split)
+	if err != nil {
+		return nil, err
+	}
+
+	shbytes, err := json.Marshal(r.Shadow)
+	if err != nil {
+		return nil, err
+	}
+
+	tcbytes, err := json.Marshal(r.Transport)
+	if err != nil {
+		return nil, err
+	}
+
+	stbytes, err := json.Marshal(r.Sticky)
+	if err != nil {
+		return nil, err
+	}
+
+	hdbytes, err := json.Marshal(r.Hedge)
+	if err != nil {
+		return nil, err
+	}
+
+	qcbytes, err := json.Marshal(r.Queue)
+	if err != nil {
+		return nil, err
+	}
+
+	rlbytes, err := json.Marshal(r.RateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	rgbytes, err := json.Marshal(r.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	tfbytes, err := json.Marshal(r.Transform)
+	if err != nil {
+		return nil, err
+	}
+
+	pvbytes, err := json.Marshal(r.PolicyVersions)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []any{
+		r.Id,
+		r.UpdatedAt,
+		r.Name,
+		sliceToSqlStringArray(r.KeyIds),
+		sbytes,
+		cbytes,
+		r.RequestFormat,
+		r.RetryStrategy,
+		r.Strategy,
+		scbytes,
+		snbytes,
+		cbbytes,
+		rpbytes,
+		spbytes,
+		shbytes,
+		tcbytes,
+		stbytes,
+		hdbytes,
+		qcbytes,
+		rlbytes,
+		rgbytes,
+		tfbytes,
+		pvbytes,
+		r.PolicyId,
+		version,
+	}
+
+	query := `
+	UPDATE routes SET updated_at = $2, name = $3, key_ids = $4, steps = $5, cache_config = $6, request_format = $7, retry_strategy = $8, strategy = $9, strategy_config = $10, snippet_config = $11, circuit_breaker_config = $12, retry_policy = $13, split_config = $14, shadow_config = $15, transport_config = $16, sticky_config = $17, hedge_config = $18, queue_config = $19, rate_limit_config = $20, region_config = $21, transform_config = $22, policy_versions = $23, policy_id = $24, version = version + 1
+	WHERE id = $1 AND version = $25
+`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	res, err := s.db.ExecContext(ctxTimeout, query, values...)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if affected == 0 {
+		existing, err := s.GetRoute(r.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, internal_errors.NewConflictError(fmt.Sprintf("route %s is at version %d, not %d", r.Id, existing.Version, version))
+	}
+
+	return s.GetRoute(r.Id)
+}
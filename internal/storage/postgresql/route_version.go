@@ -0,0 +1,117 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/route"
+)
+
+func (s *Store) CreateRouteVersionsTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS route_versions (
+		route_id VARCHAR(255) NOT NULL,
+		version INT NOT NULL,
+		route JSONB NOT NULL,
+		created_at BIGINT NOT NULL,
+		PRIMARY KEY (route_id, version)
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// InsertRouteVersion stores r under its current version. A version is only
+// ever stored once, so inserting it again is a no-op.
+func (s *Store) InsertRouteVersion(r *route.Route, createdAt int64) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO route_versions (route_id, version, route, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (route_id, version) DO NOTHING
+	`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err = s.db.ExecContext(ctxTimeout, query, r.Id, r.Version, data, createdAt)
+	return err
+}
+
+func scanRouteVersion(scan func(dest ...any) error) (*route.RouteVersion, error) {
+	v := &route.RouteVersion{}
+	var data []byte
+	if err := scan(&v.RouteId, &v.Version, &data, &v.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	v.Route = &route.Route{}
+	if err := json.Unmarshal(data, v.Route); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// GetRouteVersions returns the versions of a route, newest first.
+func (s *Store) GetRouteVersions(routeId string) ([]*route.RouteVersion, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, "SELECT route_id, version, route, created_at FROM route_versions WHERE route_id = $1 ORDER BY version DESC", routeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []*route.RouteVersion{}
+	for rows.Next() {
+		v, err := scanRouteVersion(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, v)
+	}
+
+	return versions, rows.Err()
+}
+
+func (s *Store) GetRouteVersion(routeId string, version int) (*route.RouteVersion, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	row := s.db.QueryRowContext(ctxTimeout, "SELECT route_id, version, route, created_at FROM route_versions WHERE route_id = $1 AND version = $2", routeId, version)
+	v, err := scanRouteVersion(row.Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError(fmt.Sprintf("version %d of route %s is not found", version, routeId))
+		}
+
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// DeleteRouteVersions removes the history of a deleted route.
+func (s *Store) DeleteRouteVersions(routeId string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctxTimeout, "DELETE FROM route_versions WHERE route_id = $1", routeId)
+	return err
+}
@@ -0,0 +1,250 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/tool"
+)
+
+func (s *Store) CreateToolsTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS tools (
+		id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		name VARCHAR(64) NOT NULL UNIQUE,
+		description TEXT NOT NULL,
+		parameters JSONB,
+		url VARCHAR(2048) NOT NULL,
+		auth_header VARCHAR(255) NOT NULL,
+		auth_value TEXT NOT NULL,
+		timeout_in_ms INT NOT NULL
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) CreateToolExecutionsTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS tool_executions (
+		id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		tool_id VARCHAR(255) NOT NULL,
+		tool_name VARCHAR(64) NOT NULL,
+		tool_call_id VARCHAR(255) NOT NULL,
+		key_id VARCHAR(255) NOT NULL,
+		round INT NOT NULL,
+		status INT NOT NULL,
+		latency_in_ms INT NOT NULL,
+		error TEXT NOT NULL
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) CreateToolIdIndexForToolExecutions() error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, "CREATE INDEX IF NOT EXISTS tool_id_idx ON tool_executions(tool_id)")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanTool(scan func(dest ...any) error) (*tool.Tool, error) {
+	t := &tool.Tool{}
+	var parameters []byte
+	if err := scan(
+		&t.Id,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+		&t.Name,
+		&t.Description,
+		&parameters,
+		&t.Url,
+		&t.AuthHeader,
+		&t.AuthValue,
+		&t.TimeoutInMs,
+	); err != nil {
+		return nil, err
+	}
+
+	if len(parameters) != 0 {
+		t.Parameters = parameters
+	}
+
+	return t, nil
+}
+
+func (s *Store) CreateTool(t *tool.Tool) (*tool.Tool, error) {
+	query := `
+		INSERT INTO tools (id, created_at, updated_at, name, description, parameters, url, auth_header, auth_value, timeout_in_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING *
+	`
+
+	var parameters any
+	if len(t.Parameters) != 0 {
+		parameters = []byte(t.Parameters)
+	}
+
+	values := []any{
+		t.Id,
+		t.CreatedAt,
+		t.UpdatedAt,
+		t.Name,
+		t.Description,
+		parameters,
+		t.Url,
+		t.AuthHeader,
+		t.AuthValue,
+		t.TimeoutInMs,
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	return scanTool(s.db.QueryRowContext(ctxTimeout, query, values...).Scan)
+}
+
+func (s *Store) GetTool(id string) (*tool.Tool, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	retrieved, err := scanTool(s.db.QueryRowContext(ctxTimeout, "SELECT * FROM tools WHERE $1 = id", id).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("tool is not found")
+		}
+		return nil, err
+	}
+
+	return retrieved, nil
+}
+
+func (s *Store) GetTools(withAuth bool) ([]*tool.Tool, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, "SELECT * FROM tools ORDER BY created_at")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tools := []*tool.Tool{}
+	for rows.Next() {
+		t, err := scanTool(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		if !withAuth {
+			t.AuthValue = ""
+		}
+
+		tools = append(tools, t)
+	}
+
+	return tools, nil
+}
+
+func (s *Store) DeleteTool(id string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctxTimeout, "DELETE FROM tools WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return internal_errors.NewNotFoundError("tool is not found for id: " + id)
+	}
+
+	return nil
+}
+
+func (s *Store) CreateToolExecution(e *tool.Execution) error {
+	query := `
+		INSERT INTO tool_executions (id, created_at, tool_id, tool_name, tool_call_id, key_id, round, status, latency_in_ms, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	values := []any{
+		e.Id,
+		e.CreatedAt,
+		e.ToolId,
+		e.ToolName,
+		e.ToolCallId,
+		e.KeyId,
+		e.Round,
+		e.Status,
+		e.LatencyInMs,
+		e.Error,
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctxTimeout, query, values...)
+	return err
+}
+
+// GetToolExecutions returns the most recent executions of a tool first.
+func (s *Store) GetToolExecutions(toolId string, limit int) ([]*tool.Execution, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctxTimeout, "SELECT * FROM tool_executions WHERE tool_id = $1 ORDER BY created_at DESC LIMIT $2", toolId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	executions := []*tool.Execution{}
+	for rows.Next() {
+		e := &tool.Execution{}
+		if err := rows.Scan(
+			&e.Id,
+			&e.CreatedAt,
+			&e.ToolId,
+			&e.ToolName,
+			&e.ToolCallId,
+			&e.KeyId,
+			&e.Round,
+			&e.Status,
+			&e.LatencyInMs,
+			&e.Error,
+		); err != nil {
+			return nil, err
+		}
+
+		executions = append(executions, e)
+	}
+
+	return executions, nil
+}
@@ -172,7 +172,79 @@ func (s *Store) GetUsers(tags, keyIds, userIds []string, offset, limit int) ([]*
 	return users, nil
 }
 
+func (s *Store) GetUser(id string) (*user.User, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	var u user.User
+	var data []byte
+	if err := s.db.QueryRowContext(ctxTimeout, "SELECT * FROM users WHERE id = $1", id).Scan(
+		&u.Id,
+		&u.Name,
+		&u.CreatedAt,
+		&u.UpdatedAt,
+		pq.Array(&u.Tags),
+		&u.Revoked,
+		&u.RevokedReason,
+		&u.CostLimitInUsd,
+		&u.CostLimitInUsdOverTime,
+		&u.CostLimitInUsdUnit,
+		&u.RateLimitOverTime,
+		&u.RateLimitUnit,
+		&u.Ttl,
+		pq.Array(&u.KeyIds),
+		&data,
+		pq.Array(&u.AllowedModels),
+		&u.UserId,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("user is not found for id: " + id)
+		}
+
+		return nil, err
+	}
+
+	if len(data) != 0 {
+		pathConfigs := []key.PathConfig{}
+		if err := json.Unmarshal(data, &pathConfigs); err != nil {
+			return nil, err
+		}
+
+		u.AllowedPaths = pathConfigs
+	}
+
+	return &u, nil
+}
+
+func (s *Store) DeleteUser(id string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctxTimeout, "DELETE FROM users WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return internal_errors.NewNotFoundError("user is not found for id: " + id)
+	}
+
+	return nil
+}
+
 func (s *Store) CreateUser(u *user.User) (*user.User, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	return insertUser(ctxTimeout, s.db, u)
+}
+
+func insertUser(ctx context.Context, q rowQuerier, u *user.User) (*user.User, error) {
 	query := `
 		INSERT INTO users (id, name, created_at, updated_at, tags, revoked, revoked_reason, cost_limit_in_usd, cost_limit_in_usd_over_time, cost_limit_in_usd_unit, rate_limit_over_time, rate_limit_unit, ttl, key_ids, allowed_paths, allowed_models, user_id)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
@@ -204,13 +276,10 @@ func (s *Store) CreateUser(u *user.User) (*user.User, error) {
 		u.UserId,
 	}
 
-	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
-	defer cancel()
-
 	var created user.User
 
 	var data []byte
-	if err := s.db.QueryRowContext(ctxTimeout, query, values...).Scan(
+	if err := q.QueryRowContext(ctx, query, values...).Scan(
 		&created.Id,
 		&created.Name,
 		&created.CreatedAt,
@@ -533,3 +602,31 @@ func (s *Store) UpdateUserViaTagsAndUserId(tags []string, uid string, uu *user.U
 
 	return pu, nil
 }
+
+// CreateUserWithKey creates a key and a user owning it in a single transaction.
+func (s *Store) CreateUserWithKey(u *user.User, rk *key.RequestKey) (*user.User, *key.ResponseKey, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctxTimeout, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	createdKey, err := insertKey(ctxTimeout, tx, rk)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	createdUser, err := insertUser(ctxTimeout, tx, u)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return createdUser, createdKey, nil
+}
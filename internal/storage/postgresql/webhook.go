@@ -0,0 +1,149 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/webhook"
+)
+
+func (s *Store) CreateWebhooksTable() error {
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS webhooks (
+		id VARCHAR(255) PRIMARY KEY,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		org VARCHAR(255) NOT NULL,
+		url VARCHAR(2048) NOT NULL,
+		secret VARCHAR(255) NOT NULL
+	)`
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+	_, err := s.db.ExecContext(ctxTimeout, createTableQuery)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) CreateWebhook(w *webhook.Webhook) (*webhook.Webhook, error) {
+	query := `
+		INSERT INTO webhooks (id, created_at, updated_at, org, url, secret)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING *
+	`
+
+	values := []any{
+		w.Id,
+		w.CreatedAt,
+		w.UpdatedAt,
+		w.Org,
+		w.Url,
+		w.Secret,
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	created := &webhook.Webhook{}
+	if err := s.db.QueryRowContext(ctxTimeout, query, values...).Scan(
+		&created.Id,
+		&created.CreatedAt,
+		&created.UpdatedAt,
+		&created.Org,
+		&created.Url,
+		&created.Secret,
+	); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *Store) GetWebhook(id string) (*webhook.Webhook, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	retrieved := &webhook.Webhook{}
+	if err := s.db.QueryRowContext(ctxTimeout, "SELECT * FROM webhooks WHERE $1 = id", id).Scan(
+		&retrieved.Id,
+		&retrieved.CreatedAt,
+		&retrieved.UpdatedAt,
+		&retrieved.Org,
+		&retrieved.Url,
+		&retrieved.Secret,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, internal_errors.NewNotFoundError("webhook is not found")
+		}
+		return nil, err
+	}
+
+	return retrieved, nil
+}
+
+// GetWebhooks returns webhooks of an org, or every webhook when org is empty.
+func (s *Store) GetWebhooks(org string, withSecret bool) ([]*webhook.Webhook, error) {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.rt)
+	defer cancel()
+
+	query := "SELECT * FROM webhooks ORDER BY created_at"
+	args := []any{}
+	if len(org) != 0 {
+		query = "SELECT * FROM webhooks WHERE org = $1 ORDER BY created_at"
+		args = append(args, org)
+	}
+
+	rows, err := s.db.QueryContext(ctxTimeout, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := []*webhook.Webhook{}
+	for rows.Next() {
+		w := &webhook.Webhook{}
+		if err := rows.Scan(
+			&w.Id,
+			&w.CreatedAt,
+			&w.UpdatedAt,
+			&w.Org,
+			&w.Url,
+			&w.Secret,
+		); err != nil {
+			return nil, err
+		}
+
+		if !withSecret {
+			w.Secret = ""
+		}
+
+		hooks = append(hooks, w)
+	}
+
+	return hooks, nil
+}
+
+func (s *Store) DeleteWebhook(id string) error {
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), s.wt)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctxTimeout, "DELETE FROM webhooks WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return internal_errors.NewNotFoundError("webhook is not found for id: " + id)
+	}
+
+	return nil
+}
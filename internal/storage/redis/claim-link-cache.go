@@ -0,0 +1,50 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type ClaimLinksCache struct {
+	client *redis.Client
+	wt     time.Duration
+	rt     time.Duration
+}
+
+func NewClaimLinksCache(c *redis.Client, wt time.Duration, rt time.Duration) *ClaimLinksCache {
+	return &ClaimLinksCache{
+		client: c,
+		wt:     wt,
+		rt:     rt,
+	}
+}
+
+func (c *ClaimLinksCache) Set(token string, keyId string, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.wt)
+	defer cancel()
+	err := c.client.Set(ctx, token, keyId, ttl).Err()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAndDelete atomically reads and removes a claim so that it can only be redeemed once.
+func (c *ClaimLinksCache) GetAndDelete(token string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.wt)
+	defer cancel()
+
+	keyId, err := c.client.GetDel(ctx, token).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return keyId, nil
+}
@@ -0,0 +1,67 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type IdempotencyCache struct {
+	client *redis.Client
+	wt     time.Duration
+	rt     time.Duration
+}
+
+func NewIdempotencyCache(c *redis.Client, wt time.Duration, rt time.Duration) *IdempotencyCache {
+	return &IdempotencyCache{
+		client: c,
+		wt:     wt,
+		rt:     rt,
+	}
+}
+
+func (c *IdempotencyCache) SetNx(key string, value any, ttl time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.wt)
+	defer cancel()
+
+	return c.client.SetNX(ctx, key, value, ttl).Result()
+}
+
+func (c *IdempotencyCache) Set(key string, value any, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.wt)
+	defer cancel()
+	err := c.client.Set(ctx, key, value, ttl).Err()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *IdempotencyCache) Get(key string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.rt)
+	defer cancel()
+
+	bs, err := c.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return bs, nil
+}
+
+func (c *IdempotencyCache) Delete(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.wt)
+	defer cancel()
+	err := c.client.Del(ctx, key).Err()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
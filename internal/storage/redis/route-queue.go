@@ -0,0 +1,145 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RouteQueue admits requests to routes with a concurrency limit across
+// gateways. Admitted requests hold a lease on a slot of the route until they
+// are released, so that slots of crashed gateways are freed once their lease
+// has expired. Requests that cannot be admitted wait in a queue ordered by
+// priority and arrival, and are dropped from it once they stop polling.
+type RouteQueue struct {
+	client *redis.Client
+	wt     time.Duration
+	rt     time.Duration
+}
+
+func NewRouteQueue(c *redis.Client, wt time.Duration, rt time.Duration) *RouteQueue {
+	return &RouteQueue{
+		client: c,
+		wt:     wt,
+		rt:     rt,
+	}
+}
+
+// admitScript returns route.QueueAdmitted once the ticket takes a slot,
+// route.QueueWaiting while it waits and route.QueueFull if the queue is full.
+//
+// KEYS: in flight leases, waiting tickets, last polls of waiting tickets
+// ARGV: ticket, limit, now, lease expiry, queue score, max queued, stale
+// before, whether the ticket skips the queue
+var admitScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[3])
+
+local stale = redis.call('ZRANGEBYSCORE', KEYS[3], '-inf', ARGV[7])
+for _, ticket in ipairs(stale) do
+	redis.call('ZREM', KEYS[2], ticket)
+	redis.call('ZREM', KEYS[3], ticket)
+end
+
+local ttl = tonumber(ARGV[4]) - tonumber(ARGV[3])
+for _, k in ipairs(KEYS) do
+	redis.call('PEXPIRE', k, ttl)
+end
+
+local limit = tonumber(ARGV[2])
+local inflight = redis.call('ZCARD', KEYS[1])
+local position = redis.call('ZRANK', KEYS[2], ARGV[1])
+
+local admitted = false
+if ARGV[8] == '1' then
+	admitted = true
+elseif position then
+	admitted = position < limit - inflight
+else
+	admitted = inflight < limit and redis.call('ZCARD', KEYS[2]) == 0
+end
+
+if admitted then
+	redis.call('ZREM', KEYS[2], ARGV[1])
+	redis.call('ZREM', KEYS[3], ARGV[1])
+	redis.call('ZADD', KEYS[1], ARGV[4], ARGV[1])
+	return 1
+end
+
+if not position then
+	if redis.call('ZCARD', KEYS[2]) >= tonumber(ARGV[6]) then
+		return -1
+	end
+
+	redis.call('ZADD', KEYS[2], ARGV[5], ARGV[1])
+end
+
+redis.call('ZADD', KEYS[3], ARGV[3], ARGV[1])
+return 0
+`)
+
+func routeQueueKeys(routeId string) []string {
+	return []string{routeId + ":inflight", routeId + ":waiting", routeId + ":polled"}
+}
+
+// Admit tries to give ticket a slot of the route and queues it otherwise.
+// Waiting tickets are admitted by rank, highest first, then by arrival, and
+// must call Admit again within stale to keep their place. Tickets that skip
+// the queue are admitted even if the route is at its limit.
+func (c *RouteQueue) Admit(routeId, ticket string, rank, limit, maxQueued int, lease, stale time.Duration, skip bool) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.wt)
+	defer cancel()
+
+	now := time.Now()
+
+	// lower ranks are placed behind every ticket of the ranks above
+	score := float64(10-rank)*1e13 + float64(now.UnixMilli())
+
+	skipped := "0"
+	if skip {
+		skipped = "1"
+	}
+
+	return admitScript.Run(ctx, c.client, routeQueueKeys(routeId),
+		ticket,
+		limit,
+		now.UnixMilli(),
+		now.Add(lease).UnixMilli(),
+		strconv.FormatFloat(score, 'f', 0, 64),
+		maxQueued,
+		now.Add(-stale).UnixMilli(),
+		skipped,
+	).Int()
+}
+
+// Leave removes a ticket that gave up waiting from the queue.
+func (c *RouteQueue) Leave(routeId, ticket string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.wt)
+	defer cancel()
+
+	keys := routeQueueKeys(routeId)
+	_, err := c.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.ZRem(ctx, keys[1], ticket)
+		p.ZRem(ctx, keys[2], ticket)
+		return nil
+	})
+
+	return err
+}
+
+// Release frees the slot held by ticket.
+func (c *RouteQueue) Release(routeId, ticket string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.wt)
+	defer cancel()
+
+	return c.client.ZRem(ctx, routeQueueKeys(routeId)[0], ticket).Err()
+}
+
+// Depth returns the number of tickets waiting for a slot of the route.
+func (c *RouteQueue) Depth(routeId string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.rt)
+	defer cancel()
+
+	return c.client.ZCard(ctx, routeQueueKeys(routeId)[1]).Result()
+}
@@ -0,0 +1,111 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RouteRateLimiter counts the requests of routes in fixed windows shared by
+// every gateway, along with the requests of each key within the window.
+type RouteRateLimiter struct {
+	client *redis.Client
+	wt     time.Duration
+}
+
+func NewRouteRateLimiter(c *redis.Client, wt time.Duration) *RouteRateLimiter {
+	return &RouteRateLimiter{
+		client: c,
+		wt:     wt,
+	}
+}
+
+// takeScript returns route.RateLimitAdmitted once the request is counted,
+// route.RateLimitExceeded if the window is used up and
+// route.RateLimitShareExceeded if the key has used its fair share of it.
+//
+// Keys that sent requests in the window or in the previous one contend for
+// it, and each is guaranteed an even share. A key over its share is still
+// admitted while what is left of the window covers the unused shares of the
+// other contenders, so a key that is alone can use the whole window.
+//
+// KEYS: requests of the window, requests of each key in the window, requests
+// of each key in the previous window
+// ARGV: key id, limit, whether the limit is shared fairly, window in ms
+var takeScript = redis.NewScript(`
+local limit = tonumber(ARGV[2])
+local total = tonumber(redis.call('GET', KEYS[1]) or '0')
+if total >= limit then
+	return -1
+end
+
+if ARGV[3] == '1' then
+	local used = {}
+	local current = redis.call('HGETALL', KEYS[2])
+	for i = 1, #current, 2 do
+		used[current[i]] = tonumber(current[i + 1])
+	end
+
+	for _, k in ipairs(redis.call('HKEYS', KEYS[3])) do
+		used[k] = used[k] or 0
+	end
+
+	used[ARGV[1]] = used[ARGV[1]] or 0
+
+	local contenders = 0
+	for _ in pairs(used) do
+		contenders = contenders + 1
+	end
+
+	local share = math.ceil(limit / contenders)
+	if used[ARGV[1]] >= share then
+		local reserved = 0
+		for k, n in pairs(used) do
+			if k ~= ARGV[1] and n < share then
+				reserved = reserved + share - n
+			end
+		end
+
+		if total + reserved >= limit then
+			return -2
+		end
+	end
+end
+
+redis.call('INCR', KEYS[1])
+redis.call('HINCRBY', KEYS[2], ARGV[1], 1)
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+redis.call('PEXPIRE', KEYS[2], 2 * tonumber(ARGV[4]))
+return 1
+`)
+
+// Take counts a request of keyId against the limit of the route for the
+// current window, and returns the outcome along with the time left in the
+// window.
+func (c *RouteRateLimiter) Take(routeId, keyId string, limit int, window time.Duration, fairShare bool) (int, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.wt)
+	defer cancel()
+
+	now := time.Now()
+	start := now.Truncate(window)
+	left := start.Add(window).Sub(now)
+
+	prefix := routeId + ":ratelimit:" + strconv.FormatInt(start.Unix(), 10)
+	previous := routeId + ":ratelimit:" + strconv.FormatInt(start.Add(-window).Unix(), 10)
+
+	shared := "0"
+	if fairShare {
+		shared = "1"
+	}
+
+	outcome, err := takeScript.Run(ctx, c.client, []string{prefix + ":total", prefix + ":keys", previous + ":keys"},
+		keyId,
+		limit,
+		shared,
+		window.Milliseconds(),
+	).Int()
+
+	return outcome, left, err
+}
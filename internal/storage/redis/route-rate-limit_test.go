@@ -0,0 +1,85 @@
+package redis
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestClient(t *testing.T) *redis.Client {
+	addr := os.Getenv("REDIS_ADDR")
+	if len(addr) == 0 {
+		addr = "localhost:6379"
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Skipf("redis is not reachable at %s: %v", addr, err)
+	}
+
+	t.Cleanup(func() {
+		client.Close()
+	})
+
+	return client
+}
+
+func takeAll(t *testing.T, rl *RouteRateLimiter, routeId, keyId string, limit int) int {
+	admitted := 0
+	for i := 0; i < limit; i++ {
+		outcome, _, err := rl.Take(routeId, keyId, limit, time.Hour, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if outcome == 1 {
+			admitted++
+		}
+	}
+
+	return admitted
+}
+
+func TestTakeLetsLoneKeyUseWholeLimit(t *testing.T) {
+	rl := NewRouteRateLimiter(newTestClient(t), time.Second)
+
+	limit := 10
+	if admitted := takeAll(t, rl, util.NewUuid(), "key-a", limit); admitted != limit {
+		t.Fatalf("expected a lone key to use the whole limit, got %d of %d requests admitted", admitted, limit)
+	}
+}
+
+func TestTakeKeepsShareForLaterKeys(t *testing.T) {
+	client := newTestClient(t)
+	rl := NewRouteRateLimiter(client, time.Second)
+
+	routeId := util.NewUuid()
+	limit := 10
+
+	// key b sent requests in the previous window and is expected back
+	previous := routeId + ":ratelimit:" + strconv.FormatInt(time.Now().Truncate(time.Hour).Add(-time.Hour).Unix(), 10) + ":keys"
+	if err := client.HSet(context.Background(), previous, "key-b", 3).Err(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		client.Del(context.Background(), previous)
+	})
+
+	if admitted := takeAll(t, rl, routeId, "key-a", limit); admitted != limit/2 {
+		t.Fatalf("expected key a to be held to its share while key b contends, got %d of %d requests admitted", admitted, limit)
+	}
+
+	outcome, _, err := rl.Take(routeId, "key-b", limit, time.Hour, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if outcome != 1 {
+		t.Fatalf("expected key b to be admitted, got outcome %d", outcome)
+	}
+}
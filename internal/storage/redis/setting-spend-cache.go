@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SettingSpendCache keeps the spend of provider settings of the current month
+// in micro dollars. Every month has its own counter, which expires once the
+// month is over.
+type SettingSpendCache struct {
+	client *redis.Client
+	wt     time.Duration
+	rt     time.Duration
+}
+
+func NewSettingSpendCache(c *redis.Client, wt time.Duration, rt time.Duration) *SettingSpendCache {
+	return &SettingSpendCache{
+		client: c,
+		wt:     wt,
+		rt:     rt,
+	}
+}
+
+func monthlySpendKey(settingId string, now time.Time) string {
+	return settingId + ":" + now.Format("2006-01")
+}
+
+// Increment adds micros to the spend of the month and returns the new total,
+// which lets callers tell which thresholds the increment crossed even when
+// several gateways record spend at once.
+func (c *SettingSpendCache) Increment(settingId string, micros int64) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.wt)
+	defer cancel()
+
+	now := time.Now().UTC()
+	key := monthlySpendKey(settingId, now)
+
+	var total *redis.IntCmd
+	_, err := c.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		total = p.IncrBy(ctx, key, micros)
+		p.ExpireAt(ctx, key, time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return total.Val(), nil
+}
+
+func (c *SettingSpendCache) GetSpend(settingId string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.rt)
+	defer cancel()
+
+	spend, err := c.client.Get(ctx, monthlySpendKey(settingId, time.Now().UTC())).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return spend, nil
+}
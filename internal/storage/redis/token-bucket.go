@@ -0,0 +1,111 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TokenBucket paces the requests of provider settings against their tokens
+// per minute quota. Buckets are shared by every gateway and may go into debt,
+// so that requests are admitted in the order they reserved their tokens.
+type TokenBucket struct {
+	client *redis.Client
+	wt     time.Duration
+}
+
+func NewTokenBucket(c *redis.Client, wt time.Duration) *TokenBucket {
+	return &TokenBucket{
+		client: c,
+		wt:     wt,
+	}
+}
+
+// reserveScript returns whether the tokens were reserved and the
+// milliseconds to wait before they are available.
+//
+// KEYS: bucket of the setting
+// ARGV: tokens, refill per ms, capacity, now in ms, max wait in ms
+var reserveScript = redis.NewScript(`
+local rate = tonumber(ARGV[2])
+local capacity = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+
+local tokens = tonumber(redis.call('HGET', KEYS[1], 'tokens') or capacity)
+local last = tonumber(redis.call('HGET', KEYS[1], 'ts') or now)
+tokens = math.min(capacity, tokens + math.max(0, now - last) * rate)
+
+local left = tokens - math.min(tonumber(ARGV[1]), capacity)
+local wait = 0
+if left < 0 then
+	wait = math.ceil(-left / rate)
+end
+
+local reserved = 0
+if wait <= tonumber(ARGV[5]) then
+	reserved = 1
+	tokens = left
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], 60000 + wait)
+return {reserved, wait}
+`)
+
+// Reserve takes tokens from the bucket of a setting, and returns how long to
+// wait before sending the request. Requests larger than the bucket wait for
+// it to be full. Nothing is reserved if the wait would exceed maxWait.
+func (c *TokenBucket) Reserve(settingId string, tokens, tokensPerMinute int, maxWait time.Duration) (time.Duration, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.wt)
+	defer cancel()
+
+	res, err := reserveScript.Run(ctx, c.client, []string{"tpm:" + settingId},
+		tokens,
+		float64(tokensPerMinute)/60000,
+		tokensPerMinute,
+		time.Now().UnixMilli(),
+		maxWait.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return 0, false, err
+	}
+
+	return time.Duration(res[1]) * time.Millisecond, res[0] == 1, nil
+}
+
+// refundScript gives tokens back to a bucket, up to its capacity. Buckets that
+// expired in the meantime are full and are left alone.
+//
+// KEYS: bucket of the setting
+// ARGV: tokens, refill per ms, capacity, now in ms
+var refundScript = redis.NewScript(`
+local tokens = tonumber(redis.call('HGET', KEYS[1], 'tokens'))
+if tokens == nil then
+	return 0
+end
+
+local rate = tonumber(ARGV[2])
+local capacity = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+
+local last = tonumber(redis.call('HGET', KEYS[1], 'ts') or now)
+tokens = math.min(capacity, tokens + math.max(0, now - last) * rate + tonumber(ARGV[1]))
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+return 1
+`)
+
+// Refund gives back tokens reserved for a request that was not sent, so that
+// requests queued behind it do not wait for them.
+func (c *TokenBucket) Refund(settingId string, tokens, tokensPerMinute int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.wt)
+	defer cancel()
+
+	return refundScript.Run(ctx, c.client, []string{"tpm:" + settingId},
+		min(tokens, tokensPerMinute),
+		float64(tokensPerMinute)/60000,
+		tokensPerMinute,
+		time.Now().UnixMilli(),
+	).Err()
+}
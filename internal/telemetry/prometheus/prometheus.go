@@ -17,6 +17,7 @@ type Client struct {
 	Config           Config
 	CounterMetrics   map[string]*prometheus.CounterVec
 	HistogramMetrics map[string]*prometheus.HistogramVec
+	GaugeMetrics     map[string]*prometheus.GaugeVec
 }
 
 func Init(cfg Config) (*Client, error) {
@@ -24,6 +25,7 @@ func Init(cfg Config) (*Client, error) {
 		Config:           cfg,
 		CounterMetrics:   make(map[string]*prometheus.CounterVec),
 		HistogramMetrics: make(map[string]*prometheus.HistogramVec),
+		GaugeMetrics:     make(map[string]*prometheus.GaugeVec),
 	}
 
 	c.initMetrics()
@@ -62,3 +64,16 @@ func (c *Client) Timing(name string, value time.Duration, tags []string, rate fl
 
 	histogramMetric.WithLabelValues(tags...).Observe(float64(value))
 }
+
+func (c *Client) Gauge(name string, value float64, tags []string, rate float64) {
+	if c == nil {
+		return
+	}
+
+	gaugeMetric, exists := c.GaugeMetrics[name]
+	if !exists {
+		return
+	}
+
+	gaugeMetric.WithLabelValues(tags...).Set(value)
+}
@@ -19,6 +19,7 @@ const (
 type Provider interface {
 	Incr(name string, tags []string, rate float64)
 	Timing(name string, value time.Duration, tags []string, rate float64)
+	Gauge(name string, value float64, tags []string, rate float64)
 }
 
 type Client struct {
@@ -80,3 +81,9 @@ func Timing(name string, value time.Duration, tags []string, rate float64) {
 		Singleton.Provider.Timing(name, value, tags, rate)
 	}
 }
+
+func Gauge(name string, value float64, tags []string, rate float64) {
+	if Singleton != nil {
+		Singleton.Provider.Gauge(name, value, tags, rate)
+	}
+}
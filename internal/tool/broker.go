@@ -0,0 +1,247 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"github.com/bricks-cloud/bricksllm/internal/util"
+	"go.uber.org/zap"
+)
+
+const (
+	maxResultBytes   = 64 * 1024
+	toolCallIdHeader = "X-BRICKS-TOOL-CALL-ID"
+	toolNameHeader   = "X-BRICKS-TOOL-NAME"
+)
+
+type Storage interface {
+	GetTools(withAuth bool) ([]*Tool, error)
+	CreateToolExecution(e *Execution) error
+}
+
+// Result is what the model receives for a call, together with its audit record.
+type Result struct {
+	Call      *Call
+	Content   string
+	Execution *Execution
+}
+
+// Broker keeps an in memory copy of registered tools and executes the tool
+// calls of a model response concurrently. Failed calls are reported back to the
+// model as error results instead of failing the request, so that the model can
+// recover on its own.
+type Broker struct {
+	s              Storage
+	lock           sync.RWMutex
+	nameToTool     map[string]*Tool
+	client         http.Client
+	defaultTimeout time.Duration
+	maxRounds      int
+	interval       time.Duration
+	done           chan bool
+	log            *zap.Logger
+}
+
+func NewBroker(s Storage, log *zap.Logger, interval, defaultTimeout time.Duration, maxRounds int) (*Broker, error) {
+	b := &Broker{
+		s:              s,
+		defaultTimeout: defaultTimeout,
+		maxRounds:      maxRounds,
+		interval:       interval,
+		done:           make(chan bool),
+		log:            log,
+	}
+
+	if err := b.load(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (b *Broker) load() error {
+	tools, err := b.s.GetTools(true)
+	if err != nil {
+		return err
+	}
+
+	nameToTool := map[string]*Tool{}
+	for _, t := range tools {
+		nameToTool[t.Name] = t
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.nameToTool = nameToTool
+
+	return nil
+}
+
+func (b *Broker) Listen() {
+	ticker := time.NewTicker(b.interval)
+	b.log.Info("tool broker started listening for tool updates")
+
+	go func() {
+		for {
+			select {
+			case <-b.done:
+				ticker.Stop()
+				b.log.Info("tool broker stopped")
+				return
+			case <-ticker.C:
+				if err := b.load(); err != nil {
+					telemetry.Incr("bricksllm.tool.broker.listen.get_tools_error", nil, 1)
+					b.log.Sugar().Debugf("tool broker failed to update tools: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (b *Broker) Stop() {
+	b.done <- true
+}
+
+// GetTool returns the registered tool with the given name, or nil.
+func (b *Broker) GetTool(name string) *Tool {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return b.nameToTool[name]
+}
+
+// MaxRounds is the number of times the broker answers tool calls before the
+// model response is returned to the client as is.
+func (b *Broker) MaxRounds() int {
+	return b.maxRounds
+}
+
+// Execute runs every call concurrently and returns the results in the order
+// of the calls. Audit records are written in the background.
+func (b *Broker) Execute(ctx context.Context, keyId string, round int, calls []*Call) []*Result {
+	results := make([]*Result, len(calls))
+
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		wg.Add(1)
+		go func(i int, call *Call) {
+			defer wg.Done()
+			results[i] = b.execute(ctx, keyId, round, call)
+		}(i, call)
+	}
+
+	wg.Wait()
+
+	go func() {
+		for _, r := range results {
+			if err := b.s.CreateToolExecution(r.Execution); err != nil {
+				telemetry.Incr("bricksllm.tool.broker.execute.create_tool_execution_error", nil, 1)
+				b.log.Sugar().Debugf("tool broker failed to record tool execution: %v", err)
+			}
+		}
+	}()
+
+	return results
+}
+
+func (b *Broker) execute(ctx context.Context, keyId string, round int, call *Call) *Result {
+	start := time.Now()
+	e := &Execution{
+		Id:         util.NewUuid(),
+		CreatedAt:  start.Unix(),
+		ToolName:   call.Name,
+		ToolCallId: call.Id,
+		KeyId:      keyId,
+		Round:      round,
+	}
+
+	r := &Result{
+		Call:      call,
+		Execution: e,
+	}
+
+	content, err := b.call(ctx, call, e)
+	e.LatencyInMs = int(time.Since(start).Milliseconds())
+
+	tags := []string{"tool:" + call.Name}
+	telemetry.Timing("bricksllm.tool.broker.execute.latency", time.Since(start), tags, 1)
+
+	if err != nil {
+		telemetry.Incr("bricksllm.tool.broker.execute.error", tags, 1)
+		e.Error = err.Error()
+
+		data, _ := json.Marshal(map[string]string{"error": e.Error})
+		r.Content = string(data)
+		return r
+	}
+
+	telemetry.Incr("bricksllm.tool.broker.execute.success", tags, 1)
+	r.Content = content
+
+	return r
+}
+
+func (b *Broker) call(ctx context.Context, call *Call, e *Execution) (string, error) {
+	t := b.GetTool(call.Name)
+	if t == nil {
+		return "", fmt.Errorf("tool %s is not registered", call.Name)
+	}
+
+	e.ToolId = t.Id
+
+	timeout := b.defaultTimeout
+	if t.TimeoutInMs > 0 {
+		timeout = time.Duration(t.TimeoutInMs) * time.Millisecond
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	args := call.Arguments
+	if len(args) == 0 {
+		args = "{}"
+	}
+
+	req, err := http.NewRequestWithContext(ctxTimeout, http.MethodPost, t.Url, bytes.NewBufferString(args))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(toolCallIdHeader, call.Id)
+	req.Header.Set(toolNameHeader, call.Name)
+	if len(t.AuthHeader) != 0 {
+		req.Header.Set(t.AuthHeader, t.AuthValue)
+	}
+
+	res, err := b.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	e.Status = res.StatusCode
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxResultBytes+1))
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) > maxResultBytes {
+		return "", fmt.Errorf("tool response exceeds %d bytes", maxResultBytes)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return "", fmt.Errorf("tool responded with status %d", res.StatusCode)
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,86 @@
+package tool
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+)
+
+const maxTimeoutInMs = 60000
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// Tool is an endpoint that the gateway is allowed to call on behalf of a model.
+// Arguments produced by the model are posted to Url as is, and the response
+// body is handed back to the model as the tool result. The auth value is only
+// accepted on creation and never returned.
+type Tool struct {
+	Id          string          `json:"id"`
+	CreatedAt   int64           `json:"createdAt"`
+	UpdatedAt   int64           `json:"updatedAt"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+	Url         string          `json:"url"`
+	AuthHeader  string          `json:"authHeader"`
+	AuthValue   string          `json:"authValue,omitempty"`
+	TimeoutInMs int             `json:"timeoutInMs"`
+}
+
+func (t *Tool) Validate() error {
+	invalid := []string{}
+
+	if !namePattern.MatchString(t.Name) {
+		invalid = append(invalid, "name")
+	}
+
+	u, err := url.Parse(t.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		invalid = append(invalid, "url")
+	}
+
+	if len(t.Parameters) != 0 {
+		schema := map[string]interface{}{}
+		if err := json.Unmarshal(t.Parameters, &schema); err != nil {
+			invalid = append(invalid, "parameters")
+		}
+	}
+
+	if len(t.AuthValue) != 0 && len(t.AuthHeader) == 0 {
+		invalid = append(invalid, "authHeader")
+	}
+
+	if t.TimeoutInMs < 0 || t.TimeoutInMs > maxTimeoutInMs {
+		invalid = append(invalid, "timeoutInMs")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewInvalidFieldsError(invalid)
+	}
+
+	return nil
+}
+
+// Call is a single tool call emitted by a model.
+type Call struct {
+	Id        string
+	Name      string
+	Arguments string
+}
+
+// Execution is the audit record of a call made by the broker. Arguments and
+// results are not kept so that prompt content never lands in the audit trail.
+type Execution struct {
+	Id          string `json:"id"`
+	CreatedAt   int64  `json:"createdAt"`
+	ToolId      string `json:"toolId"`
+	ToolName    string `json:"toolName"`
+	ToolCallId  string `json:"toolCallId"`
+	KeyId       string `json:"keyId"`
+	Round       int    `json:"round"`
+	Status      int    `json:"status"`
+	LatencyInMs int    `json:"latencyInMs"`
+	Error       string `json:"error,omitempty"`
+}
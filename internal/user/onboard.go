@@ -0,0 +1,44 @@
+package user
+
+import (
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/key"
+)
+
+// OnboardRequest provisions a user and a key for an org in one call. The org is
+// attached to both the user and the key as a tag.
+type OnboardRequest struct {
+	Org      string          `json:"org"`
+	User     *User           `json:"user"`
+	PolicyId string          `json:"policyId"`
+	Template *key.RequestKey `json:"template"`
+}
+
+func (r *OnboardRequest) Validate() error {
+	invalid := []string{}
+
+	if len(r.Org) == 0 {
+		invalid = append(invalid, "org")
+	}
+
+	if r.User == nil {
+		invalid = append(invalid, "user")
+	}
+
+	if r.Template == nil {
+		invalid = append(invalid, "template")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewInvalidFieldsError(invalid)
+	}
+
+	return nil
+}
+
+type OnboardResponse struct {
+	Org    string           `json:"org"`
+	User   *User            `json:"user"`
+	Key    *key.ResponseKey `json:"key"`
+	Secret string           `json:"secret"`
+}
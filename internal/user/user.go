@@ -2,7 +2,6 @@ package user
 
 import (
 	"fmt"
-	"strings"
 	"time"
 
 	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
@@ -97,7 +96,7 @@ func (u *User) Validate() error {
 	}
 
 	if len(invalid) > 0 {
-		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+		return internal_errors.NewInvalidFieldsError(invalid)
 	}
 
 	if len(u.RateLimitUnit) != 0 && u.RateLimitOverTime == 0 {
@@ -192,7 +191,7 @@ func (uu *UpdateUser) Validate() error {
 	}
 
 	if len(invalid) > 0 {
-		return internal_errors.NewValidationError(fmt.Sprintf("fields [%s] are invalid", strings.Join(invalid, ", ")))
+		return internal_errors.NewInvalidFieldsError(invalid)
 	}
 
 	if uu.RateLimitUnit != nil {
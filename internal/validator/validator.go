@@ -136,3 +136,56 @@ func (v *Validator) validateCostLimit(keyId string, costLimit float64) error {
 
 	return nil
 }
+
+// Usage returns the usage of every limit set on k. Limits that are not set
+// are left out.
+func (v *Validator) Usage(k *key.ResponseKey) ([]*key.LimitUsage, error) {
+	usages := []*key.LimitUsage{}
+
+	if k.RateLimitOverTime != 0 {
+		c, err := v.rlc.GetCounter(k.KeyId, k.RateLimitUnit)
+		if err != nil {
+			return nil, errors.New("failed to get rate limit counter")
+		}
+
+		usages = append(usages, &key.LimitUsage{
+			Limit: key.LimitRateLimitOverTime,
+			Used:  float64(c),
+			Max:   float64(k.RateLimitOverTime),
+			Unit:  k.RateLimitUnit,
+		})
+	}
+
+	if k.CostLimitInUsdOverTime != 0 {
+		cachedCost, err := v.clc.GetCounter(k.KeyId, k.CostLimitInUsdUnit)
+		if err != nil {
+			return nil, errors.New("failed to get cached token cost")
+		}
+
+		usages = append(usages, &key.LimitUsage{
+			Limit: key.LimitCostLimitInUsdOverTime,
+			Used:  convertMicroDollarsToDollar(cachedCost),
+			Max:   k.CostLimitInUsdOverTime,
+			Unit:  k.CostLimitInUsdUnit,
+		})
+	}
+
+	if k.CostLimitInUsd != 0 {
+		existingTotalCost, err := v.cls.GetCounter(k.KeyId)
+		if err != nil {
+			return nil, errors.New("failed to get total token cost")
+		}
+
+		usages = append(usages, &key.LimitUsage{
+			Limit: key.LimitCostLimitInUsd,
+			Used:  convertMicroDollarsToDollar(existingTotalCost),
+			Max:   k.CostLimitInUsd,
+		})
+	}
+
+	return usages, nil
+}
+
+func convertMicroDollarsToDollar(micros int64) float64 {
+	return float64(micros) / 1000000
+}
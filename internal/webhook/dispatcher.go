@@ -0,0 +1,211 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bricks-cloud/bricksllm/internal/event"
+	"github.com/bricks-cloud/bricksllm/internal/provider"
+	"github.com/bricks-cloud/bricksllm/internal/telemetry"
+	"go.uber.org/zap"
+)
+
+const (
+	queueSize        = 1000
+	numOfDeliverers  = 4
+	deliveryTimeout  = 5 * time.Second
+	signatureHeader  = "X-BRICKS-SIGNATURE"
+	timestampHeader  = "X-BRICKS-TIMESTAMP"
+	webhookIdHeader  = "X-BRICKS-WEBHOOK-ID"
+	signatureVersion = "sha256="
+)
+
+type Storage interface {
+	GetWebhooks(org string, withSecret bool) ([]*Webhook, error)
+}
+
+type delivery struct {
+	webhook *Webhook
+	body    []byte
+}
+
+// Dispatcher keeps an in memory copy of registered webhooks and delivers usage
+// events to them from a bounded queue. Deliveries are dropped when the queue is
+// full so that slow receivers never hold up event recording.
+type Dispatcher struct {
+	s          Storage
+	lock       sync.RWMutex
+	orgToHooks map[string][]*Webhook
+	queue      chan *delivery
+	client     http.Client
+	interval   time.Duration
+	done       chan bool
+	log        *zap.Logger
+}
+
+func NewDispatcher(s Storage, log *zap.Logger, interval time.Duration) (*Dispatcher, error) {
+	d := &Dispatcher{
+		s:        s,
+		queue:    make(chan *delivery, queueSize),
+		client:   http.Client{Timeout: deliveryTimeout},
+		interval: interval,
+		done:     make(chan bool),
+		log:      log,
+	}
+
+	if err := d.load(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (d *Dispatcher) load() error {
+	hooks, err := d.s.GetWebhooks("", true)
+	if err != nil {
+		return err
+	}
+
+	orgToHooks := map[string][]*Webhook{}
+	for _, h := range hooks {
+		orgToHooks[h.Org] = append(orgToHooks[h.Org], h)
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.orgToHooks = orgToHooks
+
+	return nil
+}
+
+func (d *Dispatcher) Listen() {
+	for i := 0; i < numOfDeliverers; i++ {
+		go func() {
+			for dl := range d.queue {
+				d.deliver(dl)
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(d.interval)
+	d.log.Info("webhook dispatcher started listening for webhook updates")
+
+	go func() {
+		for {
+			select {
+			case <-d.done:
+				ticker.Stop()
+				d.log.Info("webhook dispatcher stopped")
+				return
+			case <-ticker.C:
+				if err := d.load(); err != nil {
+					telemetry.Incr("bricksllm.webhook.dispatcher.listen.get_webhooks_error", nil, 1)
+					d.log.Sugar().Debugf("webhook dispatcher failed to update webhooks: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Notify queues the event for every webhook whose org is among the event tags.
+func (d *Dispatcher) Notify(e *event.Event) {
+	if e == nil {
+		return
+	}
+
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	for _, org := range e.Tags {
+		hooks := d.orgToHooks[org]
+		if len(hooks) == 0 {
+			continue
+		}
+
+		body, err := json.Marshal(NewUsageEvent(org, e))
+		if err != nil {
+			telemetry.Incr("bricksllm.webhook.dispatcher.notify.json_marshal_error", nil, 1)
+			continue
+		}
+
+		for _, h := range hooks {
+			select {
+			case d.queue <- &delivery{webhook: h, body: body}:
+			default:
+				telemetry.Incr("bricksllm.webhook.dispatcher.notify.dropped", nil, 1)
+			}
+		}
+	}
+}
+
+// AlertSpend queues a spend alert of a provider setting for its alert url.
+// Alert urls are not registered webhooks, so alerts are delivered unsigned.
+func (d *Dispatcher) AlertSpend(url string, a *provider.SpendAlert) {
+	d.alert(url, "alert_spend", a)
+}
+
+// AlertRevokedKey queues an alert about a provider setting that was disabled
+// because upstream rejected its api key.
+func (d *Dispatcher) AlertRevokedKey(url string, a *provider.RevokedKeyAlert) {
+	d.alert(url, "alert_revoked_key", a)
+}
+
+func (d *Dispatcher) alert(url, metric string, a any) {
+	body, err := json.Marshal(a)
+	if err != nil {
+		telemetry.Incr("bricksllm.webhook.dispatcher."+metric+".json_marshal_error", nil, 1)
+		return
+	}
+
+	select {
+	case d.queue <- &delivery{webhook: &Webhook{Url: url}, body: body}:
+	default:
+		telemetry.Incr("bricksllm.webhook.dispatcher."+metric+".dropped", nil, 1)
+	}
+}
+
+func (d *Dispatcher) deliver(dl *delivery) {
+	req, err := http.NewRequest(http.MethodPost, dl.webhook.Url, bytes.NewReader(dl.body))
+	if err != nil {
+		telemetry.Incr("bricksllm.webhook.dispatcher.deliver.new_request_error", nil, 1)
+		return
+	}
+
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(timestampHeader, strconv.FormatInt(ts, 10))
+	if len(dl.webhook.Id) != 0 {
+		req.Header.Set(webhookIdHeader, dl.webhook.Id)
+		req.Header.Set(signatureHeader, signatureVersion+Sign(dl.webhook.Secret, ts, dl.body))
+	}
+
+	start := time.Now()
+	res, err := d.client.Do(req)
+	telemetry.Timing("bricksllm.webhook.dispatcher.deliver.latency", time.Since(start), nil, 1)
+	if err != nil {
+		telemetry.Incr("bricksllm.webhook.dispatcher.deliver.http_client_error", nil, 1)
+		d.log.Debug("error when delivering webhook", zap.String("webhookId", dl.webhook.Id), zap.Error(err))
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusMultipleChoices {
+		telemetry.Incr("bricksllm.webhook.dispatcher.deliver.non_2xx_status", []string{
+			"status:" + strconv.Itoa(res.StatusCode),
+		}, 1)
+		return
+	}
+
+	telemetry.Incr("bricksllm.webhook.dispatcher.deliver.success", nil, 1)
+}
+
+func (d *Dispatcher) Stop() {
+	d.log.Info("shutting down webhook dispatcher...")
+
+	d.done <- true
+}
@@ -0,0 +1,100 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+
+	internal_errors "github.com/bricks-cloud/bricksllm/internal/errors"
+	"github.com/bricks-cloud/bricksllm/internal/event"
+)
+
+// Webhook delivers usage events of keys tagged with an org to an endpoint owned
+// by that org. The secret is only returned when the webhook is created.
+type Webhook struct {
+	Id        string `json:"id"`
+	CreatedAt int64  `json:"createdAt"`
+	UpdatedAt int64  `json:"updatedAt"`
+	Org       string `json:"org"`
+	Url       string `json:"url"`
+	Secret    string `json:"secret,omitempty"`
+}
+
+func (w *Webhook) Validate() error {
+	invalid := []string{}
+
+	if len(w.Org) == 0 {
+		invalid = append(invalid, "org")
+	}
+
+	u, err := url.Parse(w.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		invalid = append(invalid, "url")
+	}
+
+	if len(invalid) > 0 {
+		return internal_errors.NewInvalidFieldsError(invalid)
+	}
+
+	return nil
+}
+
+// UsageEvent is the payload sent to webhooks. Request and response bodies,
+// metadata and tags are left out so that nothing beyond usage leaves the gateway.
+type UsageEvent struct {
+	Id                   string  `json:"id"`
+	CreatedAt            int64   `json:"createdAt"`
+	Org                  string  `json:"org"`
+	KeyId                string  `json:"keyId"`
+	UserId               string  `json:"userId"`
+	CustomId             string  `json:"customId"`
+	Provider             string  `json:"provider"`
+	Model                string  `json:"model"`
+	Path                 string  `json:"path"`
+	Method               string  `json:"method"`
+	Status               int     `json:"status"`
+	PromptTokenCount     int     `json:"promptTokenCount"`
+	CompletionTokenCount int     `json:"completionTokenCount"`
+	CostInUsd            float64 `json:"costInUsd"`
+	LatencyInMs          int     `json:"latencyInMs"`
+	Action               string  `json:"action"`
+	PolicyId             string  `json:"policyId"`
+	RouteId              string  `json:"routeId"`
+	CorrelationId        string  `json:"correlationId"`
+}
+
+func NewUsageEvent(org string, e *event.Event) *UsageEvent {
+	return &UsageEvent{
+		Id:                   e.Id,
+		CreatedAt:            e.CreatedAt,
+		Org:                  org,
+		KeyId:                e.KeyId,
+		UserId:               e.UserId,
+		CustomId:             e.CustomId,
+		Provider:             e.Provider,
+		Model:                e.Model,
+		Path:                 e.Path,
+		Method:               e.Method,
+		Status:               e.Status,
+		PromptTokenCount:     e.PromptTokenCount,
+		CompletionTokenCount: e.CompletionTokenCount,
+		CostInUsd:            e.CostInUsd,
+		LatencyInMs:          e.LatencyInMs,
+		Action:               e.Action,
+		PolicyId:             e.PolicyId,
+		RouteId:              e.RouteId,
+		CorrelationId:        e.CorrelationId,
+	}
+}
+
+// Sign computes the hex encoded HMAC-SHA256 of "<timestamp>.<body>" so that
+// receivers can reject replayed deliveries.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d.", timestamp)))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}